@@ -195,9 +195,28 @@ The AI assistant can help you:
 - Create new CRDs for your applications
 - Understand existing CRD specifications
 - Troubleshoot configuration issues
-- Generate complete application manifests`,
+- Generate complete application manifests
+
+YAML in a response can be saved to a file or applied to the current cluster: the objects are validated with a
+server side dry run and their changes against the objects in the cluster are shown before confirming.
+
+With --cluster-context, facts of the current cluster (grsf versions, namespaces, GrappleApplicationSets, cluster
+domain and failing pods) are collected once when the session starts and attached to the system message, so that
+answers are based on its actual state. 'grapple ai context' shows what is sent.
+
+Example:
+  grapple ai
+  grapple ai --cluster-context --provider anthropic`,
 	Run: func(cmd *cobra.Command, args []string) {
 		provider, _ := cmd.Flags().GetString("provider")
+		providerOrder, err := aiProviderOrder(cmd)
+		if err != nil {
+			utils.ErrorMessage(err.Error())
+			return
+		}
+		if provider == "" && len(providerOrder) > 0 {
+			provider = providerOrder[0]
+		}
 		config, err := setupAIProvider(provider)
 		if err != nil {
 			utils.ErrorMessage(fmt.Sprintf("Error setting up AI provider: %v", err))
@@ -209,14 +228,22 @@ The AI assistant can help you:
 			config.Model = model
 		}
 
+		noStream, _ := cmd.Flags().GetBool("no-stream")
 		mcpClient := NewRemoteMCPClient(MCPServerURL)
 
-		aiSession, err := createAISession(config, mcpClient)
+		aiSession, err := createSessionWithFallbacks(config, providerOrder, mcpClient)
 		if err != nil {
 			utils.ErrorMessage(fmt.Sprintf("Error creating AI session: %v", err))
 			return
 		}
 
+		if clusterContext, _ := cmd.Flags().GetBool("cluster-context"); clusterContext {
+			if err := attachClusterContext(aiSession); err != nil {
+				utils.ErrorMessage(fmt.Sprintf("Error collecting the cluster context: %v", err))
+				return
+			}
+		}
+
 		utils.SuccessMessage(fmt.Sprintf("AI assistant ready! Using %s (%s)", config.Provider, aiSession.GetModel()))
 		utils.InfoMessage("Type 'exit' or 'quit' to end the session")
 		fmt.Println("=" + strings.Repeat("=", 50))
@@ -246,22 +273,16 @@ The AI assistant can help you:
 				continue
 			}
 
-			utils.InfoMessage(fmt.Sprintf("%s:", strings.Title(config.Provider)))
+			utils.InfoMessage(fmt.Sprintf("%s:", strings.Title(activeProvider(aiSession, config))))
 			fmt.Println(strings.Repeat("-", 50))
 
-			response, err := aiSession.Chat(prompt)
+			response, err := chatAndRender(aiSession, prompt, renderer, !noStream)
 			if err != nil {
 				utils.ErrorMessage(fmt.Sprintf("Error from AI: %v", err))
 				fmt.Println()
 				continue
 			}
-
-			rendered, err := renderer.Render(response)
-			if err != nil {
-				utils.ErrorMessage(fmt.Sprintf("Error rendering response: %v", err))
-				continue
-			}
-			fmt.Println(rendered)
+			fmt.Println()
 
 			// --- YAML detection and save prompt ---
 			yamlBlocks := extractYAMLBlocks(response)
@@ -271,18 +292,24 @@ The AI assistant can help you:
 					utils.InfoMessage("YAML detected in the response.")
 					suggested := suggestYAMLFilename(yaml)
 					filename := uniqueFilename(suggested)
-					save, err := utils.PromptInput(fmt.Sprintf("Do you want to save the YAML to '%s'? (y/n)", filename), "y", "^[yYnN]$")
+					saveAction := fmt.Sprintf("Save it to '%s'", filename)
+					action, err := utils.PromptSelect("What do you want to do with the YAML?", []string{saveAction, yamlActionApply, yamlActionSkip})
 					if err != nil {
 						utils.ErrorMessage(fmt.Sprintf("Error reading input: %v", err))
 						continue
 					}
-					if strings.ToLower(save) == "y" {
+					switch action {
+					case saveAction:
 						err := os.WriteFile(filename, []byte(yaml), 0644)
 						if err != nil {
 							utils.ErrorMessage(fmt.Sprintf("Failed to save YAML: %v", err))
 						} else {
 							utils.SuccessMessage(fmt.Sprintf("YAML saved to %s", filename))
 						}
+					case yamlActionApply:
+						if err := applyYAML(yaml); err != nil {
+							utils.ErrorMessage(fmt.Sprintf("Failed to apply YAML: %v", err))
+						}
 					}
 				}
 			}
@@ -293,5 +320,11 @@ The AI assistant can help you:
 func init() {
 	AiCmd.Flags().StringP("provider", "p", "", "Force specific AI provider (anthropic, openai, gemini)")
 	AiCmd.Flags().StringP("model", "m", "", "AI model to use (overrides defaults and env vars)")
+	AiCmd.Flags().StringSlice("providers", []string{}, "AI providers in priority order, later ones are used when a request to an earlier one fails (e.g: --providers=anthropic,openai)")
+	AiCmd.Flags().Bool("no-stream", false, "Print responses once complete instead of streaming them as they are generated")
+	AiCmd.Flags().Bool("cluster-context", false, "Attach facts of the current cluster (grsf versions, GRAS, namespaces, failing pods) to the system message, see 'grapple ai context'")
 	AiCmd.AddCommand(GrapiAiCmd)
+	AiCmd.AddCommand(ToolsCmd)
+	AiCmd.AddCommand(ExplainCmd)
+	AiCmd.AddCommand(ContextCmd)
 }
@@ -0,0 +1,224 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	yamlActionApply = "Apply it to the cluster"
+	yamlActionSkip  = "Skip"
+	// diffContextLines are the unchanged lines shown around the changes of an object
+	diffContextLines = 3
+)
+
+// applyYAML applies a manifest of a response to the cluster: its objects are checked against the schema of the
+// cluster, validated by a dry run and their changes shown, and only applied once confirmed. Fields owned by other
+// field managers (e.g. helm or an operator) are only taken over once that is confirmed as well.
+func applyYAML(manifest string) error {
+	objects, err := utils.DecodeManifest([]byte(manifest))
+	if err != nil {
+		return fmt.Errorf("%w: %w", utils.ErrValidation, err)
+	}
+	if len(objects) == 0 {
+		return fmt.Errorf("%w: the YAML has no objects", utils.ErrValidation)
+	}
+	for _, obj := range objects {
+		if err := checkManifestObject(obj); err != nil {
+			return err
+		}
+	}
+
+	restConfig, _, err := utils.GetKubernetesConfig()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the cluster, connect first using 'grapple <provider> connect': %w", err)
+	}
+	applier, err := utils.NewApplier(restConfig)
+	if err != nil {
+		return err
+	}
+
+	needsNamespace := false
+	for _, obj := range objects {
+		if err := applier.Validate(obj); err != nil {
+			return err
+		}
+		needs, err := applier.NeedsNamespace(obj)
+		if err != nil {
+			return err
+		}
+		needsNamespace = needsNamespace || needs
+	}
+	namespace := ""
+	if needsNamespace {
+		namespace, err = utils.PromptInput("Namespace of objects without one", utils.KubeContextNamespace(), "^[a-z0-9]([-a-z0-9]*[a-z0-9])?$")
+		if err != nil {
+			return err
+		}
+	}
+
+	changed := 0
+	for _, obj := range objects {
+		applied, existing, err := applier.DryRun(context.TODO(), obj.DeepCopy(), namespace)
+		if k8serrors.IsConflict(err) && !applier.Force {
+			utils.ErrorMessage(fmt.Sprintf("%s has fields managed by someone else: %v", objectName(obj), err))
+			force, promptErr := utils.PromptConfirm("Take over the conflicting fields? The other managers may revert them")
+			if promptErr != nil {
+				return promptErr
+			}
+			if !force {
+				return utils.ErrUserAborted
+			}
+			applier.Force = true
+			applied, existing, err = applier.DryRun(context.TODO(), obj.DeepCopy(), namespace)
+		}
+		if err != nil {
+			return err
+		}
+		name := objectName(applied)
+		if existing == nil {
+			utils.InfoMessage(fmt.Sprintf("%s will be created", name))
+			changed++
+			continue
+		}
+		diff, err := objectDiff(existing, applied)
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			utils.InfoMessage(fmt.Sprintf("%s is unchanged", name))
+			continue
+		}
+		utils.InfoMessage(fmt.Sprintf("%s will be changed:", name))
+		fmt.Print(diff)
+		changed++
+	}
+	if changed == 0 {
+		utils.InfoMessage("Nothing to apply")
+		return nil
+	}
+
+	confirmed, err := utils.PromptConfirm(fmt.Sprintf("Apply %d object(s) to the cluster?", changed))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return utils.ErrUserAborted
+	}
+	for _, obj := range objects {
+		if _, err := applier.Apply(context.TODO(), obj, namespace); err != nil {
+			return err
+		}
+		utils.SuccessMessage(fmt.Sprintf("Applied %s", objectName(obj)))
+	}
+	return nil
+}
+
+// checkManifestObject checks what the cluster needs to resolve and name an object, its schema is checked by
+// the dry run
+func checkManifestObject(obj *unstructured.Unstructured) error {
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+		return fmt.Errorf("%w: an object of the YAML has no apiVersion or kind", utils.ErrValidation)
+	}
+	if obj.GetName() == "" {
+		return fmt.Errorf("%w: the %s of the YAML has no metadata.name", utils.ErrValidation, obj.GetKind())
+	}
+	if errs := validation.IsDNS1123Subdomain(obj.GetName()); len(errs) > 0 {
+		return fmt.Errorf("%w: invalid name of %s %s: %s", utils.ErrValidation, obj.GetKind(), obj.GetName(), strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// objectName names an object like kubectl, e.g. Deployment my-app/grapi
+func objectName(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", obj.GetKind(), obj.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// objectDiff returns the changed lines of the YAML of two versions of an object with some context, "" when
+// only fields maintained by the cluster differ
+func objectDiff(before, after *unstructured.Unstructured) (string, error) {
+	oldYAML, err := comparableYAML(before)
+	if err != nil {
+		return "", err
+	}
+	newYAML, err := comparableYAML(after)
+	if err != nil {
+		return "", err
+	}
+	if oldYAML == newYAML {
+		return "", nil
+	}
+
+	dmp := diffmatchpatch.New()
+	oldChars, newChars, lines := dmp.DiffLinesToChars(oldYAML, newYAML)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(oldChars, newChars, false), lines)
+
+	type diffLine struct {
+		op   diffmatchpatch.Operation
+		text string
+	}
+	var all []diffLine
+	for _, d := range diffs {
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line != "" {
+				all = append(all, diffLine{op: d.Type, text: strings.TrimSuffix(line, "\n")})
+			}
+		}
+	}
+
+	// Only lines close to a change are shown
+	show := make([]bool, len(all))
+	for i, line := range all {
+		if line.op == diffmatchpatch.DiffEqual {
+			continue
+		}
+		for j := i - diffContextLines; j <= i+diffContextLines; j++ {
+			if j >= 0 && j < len(all) {
+				show[j] = true
+			}
+		}
+	}
+	var out strings.Builder
+	for i, line := range all {
+		if !show[i] {
+			if i > 0 && show[i-1] {
+				out.WriteString("  ...\n")
+			}
+			continue
+		}
+		switch line.op {
+		case diffmatchpatch.DiffInsert:
+			out.WriteString("+ " + line.text + "\n")
+		case diffmatchpatch.DiffDelete:
+			out.WriteString("- " + line.text + "\n")
+		default:
+			out.WriteString("  " + line.text + "\n")
+		}
+	}
+	return out.String(), nil
+}
+
+// comparableYAML returns the YAML of an object without the fields the cluster maintains
+func comparableYAML(obj *unstructured.Unstructured) (string, error) {
+	obj = obj.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "status")
+	for _, field := range []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s: %w", obj.GetName(), err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,82 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func configMap(data map[string]interface{}, metadata map[string]interface{}) *unstructured.Unstructured {
+	meta := map[string]interface{}{"name": "app-config", "namespace": "default"}
+	for k, v := range metadata {
+		meta[k] = v
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   meta,
+		"data":       data,
+	}}
+}
+
+func TestObjectDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   *unstructured.Unstructured
+		after    *unstructured.Unstructured
+		want     []string
+		wantNone []string
+	}{
+		{
+			name:   "unchanged",
+			before: configMap(map[string]interface{}{"a": "1"}, nil),
+			after:  configMap(map[string]interface{}{"a": "1"}, nil),
+		},
+		{
+			name:   "fields maintained by the cluster are ignored",
+			before: configMap(map[string]interface{}{"a": "1"}, map[string]interface{}{"resourceVersion": "42", "uid": "abc"}),
+			after:  configMap(map[string]interface{}{"a": "1"}, nil),
+		},
+		{
+			name:   "changed value",
+			before: configMap(map[string]interface{}{"a": "1"}, nil),
+			after:  configMap(map[string]interface{}{"a": "2"}, nil),
+			want:   []string{"-   a: \"1\"", "+   a: \"2\""},
+		},
+		{
+			name:   "added value",
+			before: configMap(map[string]interface{}{"a": "1"}, nil),
+			after:  configMap(map[string]interface{}{"a": "1", "b": "2"}, nil),
+			want:   []string{"+   b: \"2\""},
+		},
+		{
+			name:     "distant lines are elided",
+			before:   configMap(map[string]interface{}{"a": "1", "b": "1", "c": "1", "d": "1", "e": "1", "f": "1", "g": "1", "h": "1"}, nil),
+			after:    configMap(map[string]interface{}{"a": "1", "b": "1", "c": "1", "d": "1", "e": "1", "f": "1", "g": "1", "h": "2"}, nil),
+			want:     []string{"+   h: \"2\"", "  ..."},
+			wantNone: []string{"apiVersion: v1", "    a: \"1\""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := objectDiff(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("objectDiff() error = %v", err)
+			}
+			if len(tt.want) == 0 && got != "" {
+				t.Errorf("objectDiff() = %q, want no diff", got)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(got, line) {
+					t.Errorf("objectDiff() = %q, want it to contain %q", got, line)
+				}
+			}
+			for _, line := range tt.wantNone {
+				if strings.Contains(got, line) {
+					t.Errorf("objectDiff() = %q, want it not to contain %q", got, line)
+				}
+			}
+		})
+	}
+}
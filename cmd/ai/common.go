@@ -33,6 +33,25 @@ type AISession interface {
 	GetModel() string
 }
 
+// systemMessage returns the system message of the sessions with the texts of the prompts of the tool provider
+// and the facts of the cluster, if any
+func systemMessage(prompts []map[string]interface{}, clusterContext string) string {
+	systemMsg := "You are a helpful assistant for Grapple solutions. You have access to tools and prompts that can help you interact with resources and configurations. Use these tools and prompts when appropriate to provide accurate and helpful responses."
+	var promptTexts []string
+	for _, p := range prompts {
+		if text, ok := p["text"].(string); ok && text != "" {
+			promptTexts = append(promptTexts, text)
+		}
+	}
+	if len(promptTexts) > 0 {
+		systemMsg += "\n\nAvailable Prompts:\n" + strings.Join(promptTexts, "\n")
+	}
+	if clusterContext != "" {
+		systemMsg += "\n\nFacts of the user's cluster, collected when the session started. Base answers about the state of the cluster on them and say when they don't cover a question:\n" + clusterContext
+	}
+	return systemMsg
+}
+
 func handleMCPError(err error) {
 	fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	// Check if the error indicates a 401 Unauthorized status
@@ -269,6 +288,12 @@ func setupAIProvider(forcedProvider string) (*AIConfig, error) {
 		}
 	}
 
+	// An API key from the environment or the config file (e.g. ANTHROPIC_API_KEY) is used without prompting
+	if apiKey := utils.ConfigValue(provider + "-api-key"); apiKey != "" {
+		utils.InfoMessage(fmt.Sprintf("Using %s API key from environment/config", provider))
+		return &AIConfig{Provider: provider, APIKey: apiKey}, nil
+	}
+
 	fmt.Println()
 	apiKey, err := utils.PromptPassword(apiKeyPrompt + ":")
 	if err != nil {
@@ -341,16 +366,20 @@ func getEnvModel(envVar, defaultValue string) string {
 // --- Provider Sessions ---
 
 type ClaudeResponse struct {
-	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	} `json:"content"`
-	StopReason string `json:"stop_reason"`
-	ToolUse    []struct {
-		ID        string                 `json:"id"`
-		Name      string                 `json:"name"`
-		Arguments map[string]interface{} `json:"arguments"`
-	} `json:"tool_use,omitempty"`
+	Content    []ClaudeContent `json:"content"`
+	StopReason string          `json:"stop_reason"`
+	ToolUse    []ClaudeToolUse `json:"tool_use,omitempty"`
+}
+
+type ClaudeContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type ClaudeToolUse struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
 }
 
 type ClaudeSession struct {
@@ -358,6 +387,10 @@ type ClaudeSession struct {
 	Model        string
 	ToolProvider ToolProvider
 	Messages     []map[string]interface{}
+	// OnText receives the text of streamed responses, responses aren't streamed when it is nil
+	OnText func(text string)
+	// ClusterContext are the facts of the cluster attached to the system message, see collectClusterFacts
+	ClusterContext string
 }
 
 func (c *ClaudeSession) GetModel() string {
@@ -388,18 +421,7 @@ func (c *ClaudeSession) Chat(prompt string) (string, error) {
 		})
 	}
 
-	systemMsg := "You are a helpful assistant for Grapple solutions. You have access to tools and prompts that can help you interact with resources and configurations. Use these tools and prompts when appropriate to provide accurate and helpful responses."
-	if len(prompts) > 0 {
-		var promptTexts []string
-		for _, p := range prompts {
-			if text, ok := p["text"].(string); ok && text != "" {
-				promptTexts = append(promptTexts, text)
-			}
-		}
-		if len(promptTexts) > 0 {
-			systemMsg += "\n\nAvailable Prompts:\n" + strings.Join(promptTexts, "\n")
-		}
-	}
+	systemMsg := systemMessage(prompts, c.ClusterContext)
 
 	reqData := map[string]interface{}{
 		"model":      c.Model,
@@ -412,7 +434,12 @@ func (c *ClaudeSession) Chat(prompt string) (string, error) {
 		reqData["tools"] = tools
 	}
 
-	response, err := c.callClaudeAPI(reqData)
+	var response *ClaudeResponse
+	if c.OnText != nil {
+		response, err = c.streamClaudeAPI(reqData)
+	} else {
+		response, err = c.callClaudeAPI(reqData)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -510,16 +537,20 @@ func (c *ClaudeSession) callClaudeAPI(reqData map[string]interface{}) (*ClaudeRe
 }
 
 type OpenAIResponse struct {
-	Choices []struct {
-		Message struct {
-			Content      string `json:"content"`
-			FunctionCall *struct {
-				Name      string `json:"name"`
-				Arguments string `json:"arguments"`
-			} `json:"function_call,omitempty"`
-		} `json:"message"`
-		FinishReason string `json:"finish_reason"`
-	} `json:"choices"`
+	Choices []OpenAIChoice `json:"choices"`
+}
+
+type OpenAIChoice struct {
+	Message struct {
+		Content      string              `json:"content"`
+		FunctionCall *OpenAIFunctionCall `json:"function_call,omitempty"`
+	} `json:"message"`
+	FinishReason string `json:"finish_reason"`
+}
+
+type OpenAIFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 type OpenAISession struct {
@@ -527,6 +558,10 @@ type OpenAISession struct {
 	Model        string
 	ToolProvider ToolProvider
 	Messages     []map[string]interface{}
+	// OnText receives the text of streamed responses, responses aren't streamed when it is nil
+	OnText func(text string)
+	// ClusterContext are the facts of the cluster attached to the system message, see collectClusterFacts
+	ClusterContext string
 }
 
 func (o *OpenAISession) GetModel() string {
@@ -562,18 +597,7 @@ func (o *OpenAISession) Chat(prompt string) (string, error) {
 		})
 	}
 
-	systemMsg := "You are a helpful assistant for Grapple solutions. You have access to tools and prompts that can help you interact with resources and configurations. Use these tools and prompts when appropriate to provide accurate and helpful responses."
-	if len(prompts) > 0 {
-		var promptTexts []string
-		for _, p := range prompts {
-			if text, ok := p["text"].(string); ok && text != "" {
-				promptTexts = append(promptTexts, text)
-			}
-		}
-		if len(promptTexts) > 0 {
-			systemMsg += "\n\nAvailable Prompts:\n" + strings.Join(promptTexts, "\n")
-		}
-	}
+	systemMsg := systemMessage(prompts, o.ClusterContext)
 	if len(o.Messages) > 0 && o.Messages[0]["role"] == "system" {
 		o.Messages[0]["content"] = systemMsg
 	}
@@ -597,7 +621,12 @@ func (o *OpenAISession) Chat(prompt string) (string, error) {
 		reqData["function_call"] = "auto"
 	}
 
-	response, err := o.callOpenAIAPI(reqData)
+	var response *OpenAIResponse
+	if o.OnText != nil {
+		response, err = o.streamOpenAIAPI(reqData)
+	} else {
+		response, err = o.callOpenAIAPI(reqData)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -685,18 +714,22 @@ func (o *OpenAISession) callOpenAIAPI(reqData map[string]interface{}) (*OpenAIRe
 }
 
 type GeminiResponse struct {
-	Candidates []struct {
-		Content struct {
-			Parts []struct {
-				Text         string `json:"text,omitempty"`
-				FunctionCall *struct {
-					Name string                 `json:"name"`
-					Args map[string]interface{} `json:"args"`
-				} `json:"functionCall,omitempty"`
-			} `json:"parts"`
-		} `json:"content"`
-		FinishReason string `json:"finishReason"`
-	} `json:"candidates"`
+	Candidates []GeminiCandidate `json:"candidates"`
+}
+
+type GeminiCandidate struct {
+	Content struct {
+		Parts []GeminiPart `json:"parts"`
+	} `json:"content"`
+	FinishReason string `json:"finishReason"`
+}
+
+type GeminiPart struct {
+	Text         string `json:"text,omitempty"`
+	FunctionCall *struct {
+		Name string                 `json:"name"`
+		Args map[string]interface{} `json:"args"`
+	} `json:"functionCall,omitempty"`
 }
 
 type GeminiSession struct {
@@ -704,6 +737,10 @@ type GeminiSession struct {
 	Model        string
 	ToolProvider ToolProvider
 	History      []map[string]interface{}
+	// OnText receives the text of streamed responses, responses aren't streamed when it is nil
+	OnText func(text string)
+	// ClusterContext are the facts of the cluster attached to the system message, see collectClusterFacts
+	ClusterContext string
 }
 
 func (g *GeminiSession) GetModel() string {
@@ -751,18 +788,7 @@ func (g *GeminiSession) Chat(prompt string) (string, error) {
 		})
 	}
 
-	systemMsg := "You are a helpful assistant for Grapple solutions. You have access to tools and prompts that can help you interact with resources and configurations. Use these tools and prompts when appropriate to provide accurate and helpful responses."
-	if len(prompts) > 0 {
-		var promptTexts []string
-		for _, p := range prompts {
-			if text, ok := p["text"].(string); ok && text != "" {
-				promptTexts = append(promptTexts, text)
-			}
-		}
-		if len(promptTexts) > 0 {
-			systemMsg += "\n\nAvailable Prompts:\n" + strings.Join(promptTexts, "\n")
-		}
-	}
+	systemMsg := systemMessage(prompts, g.ClusterContext)
 
 	reqData := map[string]interface{}{
 		"contents": g.History,
@@ -781,7 +807,12 @@ func (g *GeminiSession) Chat(prompt string) (string, error) {
 		reqData["tools"] = geminiTools
 	}
 
-	response, err := g.callGeminiAPI(reqData)
+	var response *GeminiResponse
+	if g.OnText != nil {
+		response, err = g.streamGeminiAPI(reqData)
+	} else {
+		response, err = g.callGeminiAPI(reqData)
+	}
 	if err != nil {
 		return "", err
 	}
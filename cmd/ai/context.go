@@ -0,0 +1,297 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// maxFailingPods limits the failing pods of the cluster facts, the most recently started ones are kept
+	maxFailingPods = 20
+	// maxFactMessageLength limits the messages of conditions and containers of the cluster facts
+	maxFactMessageLength = 300
+)
+
+var grasGVR = schema.GroupVersionResource{Group: "grsf.grpl.io", Version: "v1alpha1", Resource: "grappleapplicationsets"}
+
+// clusterFacts are the facts of the cluster --cluster-context attaches to the system message
+type clusterFacts struct {
+	Context       string            `json:"context,omitempty" yaml:"context,omitempty"`
+	Provider      string            `json:"provider,omitempty" yaml:"provider,omitempty"`
+	ClusterDomain string            `json:"clusterDomain,omitempty" yaml:"clusterDomain,omitempty"`
+	SSLEnabled    bool              `json:"sslEnabled" yaml:"sslEnabled"`
+	Releases      map[string]string `json:"releases" yaml:"releases"`
+	Namespaces    []string          `json:"namespaces" yaml:"namespaces"`
+	Gras          []grasFact        `json:"gras" yaml:"gras"`
+	FailingPods   []podFact         `json:"failingPods" yaml:"failingPods"`
+}
+
+// grasFact is a GrappleApplicationSet of the cluster facts
+type grasFact struct {
+	Namespace  string   `json:"namespace" yaml:"namespace"`
+	Name       string   `json:"name" yaml:"name"`
+	Grapis     []string `json:"grapis,omitempty" yaml:"grapis,omitempty"`
+	Gruims     []string `json:"gruims,omitempty" yaml:"gruims,omitempty"`
+	Conditions []string `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+}
+
+// podFact is a pod of the cluster facts that is failing or can't start
+type podFact struct {
+	Namespace string    `json:"namespace" yaml:"namespace"`
+	Name      string    `json:"name" yaml:"name"`
+	Phase     string    `json:"phase" yaml:"phase"`
+	Problems  []string  `json:"problems" yaml:"problems"`
+	Restarts  int32     `json:"restarts" yaml:"restarts"`
+	Started   time.Time `json:"started" yaml:"started"`
+}
+
+// clusterContextSession is implemented by sessions that attach the facts of the cluster to their system message
+type clusterContextSession interface {
+	AISession
+	SetClusterContext(facts string)
+}
+
+func (c *ClaudeSession) SetClusterContext(facts string) { c.ClusterContext = facts }
+func (o *OpenAISession) SetClusterContext(facts string) { o.ClusterContext = facts }
+func (g *GeminiSession) SetClusterContext(facts string) { g.ClusterContext = facts }
+
+func (f *FallbackSession) SetClusterContext(facts string) {
+	for _, s := range f.sessions {
+		if session, ok := s.session.(clusterContextSession); ok {
+			session.SetClusterContext(facts)
+		}
+	}
+}
+
+// ContextCmd represents the ai context command
+var ContextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Show the facts of the cluster --cluster-context sends to the AI provider",
+	Long: `Show the facts of the current cluster 'grapple ai --cluster-context' attaches to the system message, so that
+answers to questions like "why is my GRAS not ready?" are based on the actual state of the cluster:
+
+- the versions of the grsf releases, the provider, cluster domain and whether SSL is enabled
+- the namespaces
+- the GrappleApplicationSets with their grapis, gruims and conditions
+- up to 20 pods that are failing or can't start, with the reasons of their containers
+
+No spec, logs or Secrets are collected, and credentials in messages are masked.
+
+Example:
+  grapple ai context
+  grapple ai context -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restConfig, kubeClient, err := utils.GetKubernetesConfig()
+		if err != nil {
+			return fmt.Errorf("failed to connect to the cluster, connect first using 'grapple <provider> connect': %w", err)
+		}
+		facts, err := collectClusterFacts(restConfig, kubeClient)
+		if err != nil {
+			return err
+		}
+		return utils.PrintResult(facts, func() {
+			text, err := clusterFactsText(facts)
+			if err != nil {
+				utils.ErrorMessage(err.Error())
+				return
+			}
+			fmt.Print(text)
+		})
+	},
+}
+
+// attachClusterContext collects the facts of the current cluster and attaches them to the system message of
+// session
+func attachClusterContext(session AISession) error {
+	contextSession, ok := session.(clusterContextSession)
+	if !ok {
+		return fmt.Errorf("the session doesn't support cluster context")
+	}
+	restConfig, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the cluster, connect first using 'grapple <provider> connect': %w", err)
+	}
+	facts, err := collectClusterFacts(restConfig, kubeClient)
+	if err != nil {
+		return err
+	}
+	text, err := clusterFactsText(facts)
+	if err != nil {
+		return err
+	}
+	contextSession.SetClusterContext(text)
+	utils.InfoMessage(fmt.Sprintf("Attached the facts of cluster %s: %d namespace(s), %d GRAS, %d failing pod(s), see 'grapple ai context'",
+		facts.Context, len(facts.Namespaces), len(facts.Gras), len(facts.FailingPods)))
+	return nil
+}
+
+// collectClusterFacts inspects the cluster read-only. Facts that can't be read, e.g. for missing permissions,
+// are left out.
+func collectClusterFacts(restConfig *rest.Config, kubeClient *kubernetes.Clientset) (*clusterFacts, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	facts := &clusterFacts{Releases: map[string]string{}}
+	if kubeContext, err := utils.CurrentKubeContext(); err == nil {
+		facts.Context = kubeContext
+	}
+
+	for _, release := range utils.GrplReleases {
+		version, err := utils.GetGrplReleaseVersion(restConfig, release, "grpl-system")
+		if err != nil {
+			utils.DebugMessage(fmt.Sprintf("Failed to get the version of %s: %v", release, err))
+			continue
+		}
+		if version == "" {
+			version = "not installed"
+		}
+		facts.Releases[release] = version
+	}
+
+	if secret, err := kubeClient.CoreV1().Secrets("grpl-system").Get(ctx, "grsf-config", v1.GetOptions{}); err == nil {
+		facts.Provider = string(secret.Data[utils.SecKeyProviderClusterType])
+		facts.ClusterDomain = string(secret.Data[utils.SecKeyClusterdomain])
+		facts.SSLEnabled = string(secret.Data[utils.SecKeySsl]) == "true"
+	} else if !errors.IsNotFound(err) {
+		utils.DebugMessage(fmt.Sprintf("Failed to get grsf-config: %v", err))
+	}
+
+	namespaces, err := kubeClient.CoreV1().Namespaces().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	for _, ns := range namespaces.Items {
+		facts.Namespaces = append(facts.Namespaces, ns.Name)
+	}
+	sort.Strings(facts.Namespaces)
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	if list, err := dynamicClient.Resource(grasGVR).List(ctx, v1.ListOptions{}); err == nil {
+		for _, item := range list.Items {
+			facts.Gras = append(facts.Gras, grasFactOf(item))
+		}
+	} else if !errors.IsNotFound(err) {
+		utils.DebugMessage(fmt.Sprintf("Failed to list GrappleApplicationSets: %v", err))
+	}
+
+	pods, err := kubeClient.CoreV1().Pods("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if fact, failing := podFactOf(pod); failing {
+			facts.FailingPods = append(facts.FailingPods, fact)
+		}
+	}
+	sort.Slice(facts.FailingPods, func(i, j int) bool {
+		return facts.FailingPods[i].Started.After(facts.FailingPods[j].Started)
+	})
+	if len(facts.FailingPods) > maxFailingPods {
+		facts.FailingPods = facts.FailingPods[:maxFailingPods]
+	}
+	return facts, nil
+}
+
+// grasFactOf returns the names of the grapis and gruims and the conditions of a GRAS, its spec isn't sent
+func grasFactOf(gras unstructured.Unstructured) grasFact {
+	fact := grasFact{Namespace: gras.GetNamespace(), Name: gras.GetName()}
+	for field, names := range map[string]*[]string{"grapis": &fact.Grapis, "gruims": &fact.Gruims} {
+		items, _, _ := unstructured.NestedSlice(gras.Object, "spec", field)
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok {
+					*names = append(*names, name)
+				}
+			}
+		}
+	}
+	conditions, _, _ := unstructured.NestedSlice(gras.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		text := fmt.Sprintf("%v=%v", condition["type"], condition["status"])
+		if reason, ok := condition["reason"].(string); ok && reason != "" {
+			text += " " + reason
+		}
+		if message, ok := condition["message"].(string); ok && message != "" {
+			text += ": " + factMessage(message)
+		}
+		fact.Conditions = append(fact.Conditions, text)
+	}
+	return fact
+}
+
+// podFactOf returns the problems of a pod, and whether it is failing: failed, stuck pending or with containers
+// that are waiting for a reason other than starting or that restarted after failing
+func podFactOf(pod corev1.Pod) (podFact, bool) {
+	fact := podFact{Namespace: pod.Namespace, Name: pod.Name, Phase: string(pod.Status.Phase), Started: pod.CreationTimestamp.Time}
+	if pod.Status.StartTime != nil {
+		fact.Started = pod.Status.StartTime.Time
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return fact, false
+	case corev1.PodFailed:
+		fact.Problems = append(fact.Problems, fmt.Sprintf("failed: %s %s", pod.Status.Reason, factMessage(pod.Status.Message)))
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			fact.Problems = append(fact.Problems, fmt.Sprintf("not scheduled: %s %s", condition.Reason, factMessage(condition.Message)))
+		}
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		fact.Restarts += status.RestartCount
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" && waiting.Reason != "ContainerCreating" && waiting.Reason != "PodInitializing" {
+			fact.Problems = append(fact.Problems, fmt.Sprintf("container %s waiting: %s %s", status.Name, waiting.Reason, factMessage(waiting.Message)))
+		}
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.ExitCode != 0 && !status.Ready {
+			fact.Problems = append(fact.Problems, fmt.Sprintf("container %s last exited with %d: %s %s", status.Name, terminated.ExitCode, terminated.Reason, factMessage(terminated.Message)))
+		}
+	}
+	for i := range fact.Problems {
+		fact.Problems[i] = strings.TrimSpace(fact.Problems[i])
+	}
+	return fact, len(fact.Problems) > 0
+}
+
+// factMessage shortens a message of the cluster and masks credentials in it
+func factMessage(message string) string {
+	message = utils.SanitizeLog(strings.Join(strings.Fields(message), " "))
+	if len(message) > maxFactMessageLength {
+		message = message[:maxFactMessageLength] + "..."
+	}
+	return message
+}
+
+// clusterFactsText returns the facts as the YAML the system message gets
+func clusterFactsText(facts *clusterFacts) (string, error) {
+	data, err := yaml.Marshal(facts)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the cluster facts: %w", err)
+	}
+	return utils.SanitizeLog(string(data)), nil
+}
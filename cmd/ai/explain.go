@@ -0,0 +1,242 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/yaml"
+)
+
+var explainNamespace string
+
+// explainResult is the output of 'grapple ai explain' with -o json or yaml
+type explainResult struct {
+	Resource    string `json:"resource" yaml:"resource"`
+	Provider    string `json:"provider" yaml:"provider"`
+	Explanation string `json:"explanation" yaml:"explanation"`
+}
+
+// noTools is the tool provider of one-shot questions that only need the data of the prompt
+type noTools struct{}
+
+func (noTools) GetAvailableTools() ([]map[string]interface{}, error)   { return nil, nil }
+func (noTools) GetAvailablePrompts() ([]map[string]interface{}, error) { return nil, nil }
+func (noTools) CallTool(name string, _ map[string]interface{}) (string, error) {
+	return "", fmt.Errorf("tool %s is not available", name)
+}
+
+// ExplainCmd represents the ai explain command
+var ExplainCmd = &cobra.Command{
+	Use:   "explain <kind>/<name> | <kind> <name>",
+	Short: "Let the AI assistant explain a live Kubernetes or Grapple resource",
+	Long: `Explain fetches a resource from the cluster, together with its recent events, and asks the configured AI
+provider what it is for, what its current conditions mean and what likely causes any error state.
+
+Managed fields and the last applied configuration are removed, the values of Secrets and credentials in the
+spec (passwords, tokens, keys) are masked before anything is sent to the provider. Kinds are given like in
+kubectl: full names, plurals or short names (e.g. deploy, gras).
+
+Example:
+  grapple ai explain gras/my-app --namespace my-app
+  grapple ai explain pod my-app-grapi-5d8f7 -n my-app`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runExplain,
+}
+
+func init() {
+	ExplainCmd.Flags().StringVarP(&explainNamespace, "namespace", "n", "", "Namespace of the resource (default: namespace of the current context)")
+	ExplainCmd.Flags().StringP("provider", "p", "", "Force specific AI provider (anthropic, openai, gemini)")
+	ExplainCmd.Flags().StringP("model", "m", "", "AI model to use (overrides defaults and env vars)")
+	ExplainCmd.Flags().StringSlice("providers", []string{}, "AI providers in priority order, later ones are used when a request to an earlier one fails (e.g: --providers=anthropic,openai)")
+	ExplainCmd.Flags().Bool("no-stream", false, "Print the explanation once complete instead of streaming it as it is generated")
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	kind, name := args[0], ""
+	if len(args) == 2 {
+		name = args[1]
+	} else if k, n, ok := strings.Cut(args[0], "/"); ok {
+		kind, name = k, n
+	}
+	if kind == "" || name == "" {
+		return fmt.Errorf("%w: name the resource as <kind>/<name> or <kind> <name>", utils.ErrValidation)
+	}
+
+	restConfig, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if explainNamespace == "" {
+		explainNamespace = utils.KubeContextNamespace()
+	}
+
+	obj, err := fetchResource(restConfig, kind, name, explainNamespace)
+	if err != nil {
+		return err
+	}
+	manifest, err := sanitizedManifest(obj)
+	if err != nil {
+		return err
+	}
+	events := resourceEvents(kubeClient, obj)
+	resource := fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())
+
+	provider, _ := cmd.Flags().GetString("provider")
+	providerOrder, err := aiProviderOrder(cmd)
+	if err != nil {
+		return err
+	}
+	if provider == "" && len(providerOrder) > 0 {
+		provider = providerOrder[0]
+	}
+	config, err := setupAIProvider(provider)
+	if err != nil {
+		return fmt.Errorf("error setting up AI provider: %w", err)
+	}
+	if model, _ := cmd.Flags().GetString("model"); model != "" {
+		config.Model = model
+	}
+	session, err := createSessionWithFallbacks(config, providerOrder, noTools{})
+	if err != nil {
+		return fmt.Errorf("error creating AI session: %w", err)
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Asking %s (%s) to explain %s...", config.Provider, session.GetModel(), resource))
+	// Text output is rendered while it is streamed, JSON and YAML are printed once complete
+	var explanation string
+	if utils.IsStructuredOutput() {
+		explanation, err = session.Chat(explainPrompt(obj, manifest, events))
+	} else {
+		noStream, _ := cmd.Flags().GetBool("no-stream")
+		explanation, err = chatAndRender(session, explainPrompt(obj, manifest, events), newMarkdownRenderer(), !noStream)
+	}
+	if err != nil {
+		return fmt.Errorf("error from AI: %w", err)
+	}
+
+	result := explainResult{Resource: resource, Provider: activeProvider(session, config), Explanation: explanation}
+	return utils.PrintResult(result, nil)
+}
+
+// fetchResource resolves a kind like kubectl does (plural, singular or short name) and gets the resource
+func fetchResource(restConfig *rest.Config, kind, name, namespace string) (*unstructured.Unstructured, error) {
+	discoveryClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	cached := memory.NewMemCacheClient(discoveryClient.Discovery())
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(cached), cached, nil)
+
+	gvr, err := mapper.ResourceFor(schema.ParseGroupResource(strings.ToLower(kind)).WithVersion(""))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown resource kind %q: %w", utils.ErrValidation, kind, err)
+	}
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve kind of %s: %w", gvr.Resource, err)
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", gvk.Kind, err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	var resource dynamic.ResourceInterface = dynamicClient.Resource(gvr)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resource = dynamicClient.Resource(gvr).Namespace(namespace)
+	}
+	obj, err := resource.Get(context.TODO(), name, v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, name, err)
+	}
+	return obj, nil
+}
+
+// sanitizedManifest returns the resource as YAML without managed fields, the last applied configuration and
+// secret values
+func sanitizedManifest(obj *unstructured.Unstructured) (string, error) {
+	obj = obj.DeepCopy()
+	obj.SetManagedFields(nil)
+	annotations := obj.GetAnnotations()
+	delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+	obj.SetAnnotations(annotations)
+
+	if obj.GetKind() == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			values, found, _ := unstructured.NestedMap(obj.Object, field)
+			if !found {
+				continue
+			}
+			for key := range values {
+				values[key] = "<redacted>"
+			}
+			_ = unstructured.SetNestedMap(obj.Object, values, field)
+		}
+	}
+
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s: %w", obj.GetName(), err)
+	}
+	return utils.SanitizeLog(string(data)), nil
+}
+
+// resourceEvents returns the recent events of the resource, oldest first
+func resourceEvents(kubeClient *kubernetes.Clientset, obj *unstructured.Unstructured) []string {
+	selector := fmt.Sprintf("involvedObject.name=%s,involvedObject.kind=%s", obj.GetName(), obj.GetKind())
+	list, err := kubeClient.CoreV1().Events(obj.GetNamespace()).List(context.TODO(), v1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].LastTimestamp.Before(&list.Items[j].LastTimestamp)
+	})
+	var events []string
+	for _, e := range list.Items {
+		events = append(events, fmt.Sprintf("%s %s %s: %s (x%d)", e.LastTimestamp.Format(time.RFC3339), e.Type, e.Reason, utils.SanitizeLog(e.Message), e.Count))
+	}
+	if len(events) > 20 {
+		events = events[len(events)-20:]
+	}
+	return events
+}
+
+func explainPrompt(obj *unstructured.Unstructured, manifest string, events []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Explain the following %s %q", obj.GetKind(), obj.GetName())
+	if obj.GetNamespace() != "" {
+		fmt.Fprintf(&b, " of namespace %q", obj.GetNamespace())
+	}
+	b.WriteString(` of a Kubernetes cluster running Grapple. Answer in Markdown with these sections:
+1. Purpose: what the resource is for and how it relates to Grapple (GrappleApplicationSets, grapi, gruim) if it does.
+2. Current state: what its status and conditions say.
+3. Problems: if it is in an error or degraded state, the likely causes, most likely first, and how to check and fix them. Say so if everything looks healthy.
+Credentials were masked with ******** or <redacted>, don't report them as problems.
+
+Resource:
+` + "```yaml\n" + manifest + "```\n")
+	if len(events) > 0 {
+		b.WriteString("\nRecent events:\n```\n" + strings.Join(events, "\n") + "\n```\n")
+	} else {
+		b.WriteString("\nThere are no recent events of the resource.\n")
+	}
+	return b.String()
+}
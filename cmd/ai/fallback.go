@@ -0,0 +1,268 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// transcriptMessage is a provider independent conversation turn, it is used to hand the
+// conversation over to another provider when failing over
+type transcriptMessage struct {
+	Role string // "user" or "assistant"
+	Text string
+}
+
+// transcriptSession is implemented by sessions whose history can be converted between providers
+type transcriptSession interface {
+	AISession
+	Transcript() []transcriptMessage
+	LoadTranscript(messages []transcriptMessage)
+}
+
+// providerSession is a session of one of the providers in the priority order
+type providerSession struct {
+	provider string
+	session  transcriptSession
+}
+
+// FallbackSession sends every request to the active provider and fails over to the next provider in
+// the priority order when it errors (e.g. rate limits or outages), keeping the conversation history
+type FallbackSession struct {
+	sessions []providerSession
+	active   int
+	// onStreamReset is called when a provider failed after streaming part of its response, see SetStreamHandler
+	onStreamReset func()
+	// streamed is set once the current request streamed text
+	streamed bool
+}
+
+func (f *FallbackSession) GetModel() string {
+	return f.sessions[f.active].session.GetModel()
+}
+
+// Provider returns the provider that answered the last request
+func (f *FallbackSession) Provider() string {
+	return f.sessions[f.active].provider
+}
+
+func (f *FallbackSession) Chat(prompt string) (string, error) {
+	// The conversation before this prompt, a failed request may have left partial turns in the session
+	transcript := f.sessions[f.active].session.Transcript()
+
+	var errs []string
+	for i := 0; i < len(f.sessions); i++ {
+		idx := (f.active + i) % len(f.sessions)
+		current := f.sessions[idx]
+		if idx != f.active {
+			current.session.LoadTranscript(transcript)
+		}
+
+		f.streamed = false
+		response, err := current.session.Chat(prompt)
+		if err == nil {
+			f.active = idx
+			return response, nil
+		}
+		if f.streamed && f.onStreamReset != nil {
+			f.onStreamReset()
+		}
+
+		errs = append(errs, fmt.Sprintf("%s: %v", current.provider, err))
+		if i+1 < len(f.sessions) {
+			next := f.sessions[(idx+1)%len(f.sessions)]
+			utils.InfoMessage(fmt.Sprintf("%s failed (%v), failing over to %s (%s)", current.provider, err, next.provider, next.session.GetModel()))
+		}
+	}
+
+	// Restore the history so the next prompt starts from a clean state
+	f.sessions[f.active].session.LoadTranscript(transcript)
+	return "", fmt.Errorf("all AI providers failed: %s", strings.Join(errs, "; "))
+}
+
+// aiProviderOrder returns the providers in priority order from --providers or the ai-providers config setting
+func aiProviderOrder(cmd *cobra.Command) ([]string, error) {
+	providers, _ := cmd.Flags().GetStringSlice("providers")
+	if len(providers) == 0 {
+		if configured := utils.ConfigValue("ai-providers"); configured != "" {
+			providers = strings.Split(configured, ",")
+		}
+	}
+
+	var order []string
+	for _, p := range providers {
+		p = strings.ToLower(strings.TrimSpace(p))
+		switch p {
+		case "":
+			continue
+		case "anthropic", "openai", "gemini":
+		default:
+			return nil, fmt.Errorf("invalid provider %q in provider order, use anthropic, openai or gemini", p)
+		}
+		if !utils.Contains(order, p) {
+			order = append(order, p)
+		}
+	}
+	return order, nil
+}
+
+// createSessionWithFallbacks creates the session of the configured provider, and wraps it with sessions of the
+// other providers of the priority order for which an API key is available
+func createSessionWithFallbacks(config *AIConfig, order []string, provider ToolProvider) (AISession, error) {
+	primary, err := createAISession(config, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []providerSession{{provider: config.Provider, session: primary.(transcriptSession)}}
+	for _, name := range order {
+		if name == config.Provider {
+			continue
+		}
+
+		apiKey := utils.ConfigValue(name + "-api-key")
+		if saved, err := loadAIConfig(); apiKey == "" && err == nil && saved.Provider == name {
+			apiKey = saved.APIKey
+		}
+		if apiKey == "" {
+			utils.InfoMessage(fmt.Sprintf("No API key for fallback provider %s, set it with 'grapple config set %s-api-key <key>'", name, name))
+			continue
+		}
+
+		session, err := createAISession(&AIConfig{Provider: name, APIKey: apiKey}, provider)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, providerSession{provider: name, session: session.(transcriptSession)})
+	}
+
+	if len(sessions) == 1 {
+		return primary, nil
+	}
+
+	var names []string
+	for _, s := range sessions {
+		names = append(names, s.provider)
+	}
+	utils.InfoMessage(fmt.Sprintf("Provider priority: %s", strings.Join(names, " > ")))
+	return &FallbackSession{sessions: sessions}, nil
+}
+
+// activeProvider returns the provider that answered the last request of the session
+func activeProvider(session AISession, config *AIConfig) string {
+	if fallback, ok := session.(*FallbackSession); ok {
+		return fallback.Provider()
+	}
+	return config.Provider
+}
+
+// --- Transcript conversion ---
+
+func (c *ClaudeSession) Transcript() []transcriptMessage {
+	var transcript []transcriptMessage
+	for _, msg := range c.Messages {
+		role, _ := msg["role"].(string)
+		switch content := msg["content"].(type) {
+		case string:
+			transcript = appendTranscript(transcript, role, content)
+		case []interface{}:
+			// assistant turns with tool calls, only their text is kept
+			for _, part := range content {
+				if p, ok := part.(map[string]interface{}); ok && p["type"] == "text" {
+					text, _ := p["text"].(string)
+					transcript = appendTranscript(transcript, role, text)
+				}
+			}
+		}
+	}
+	return transcript
+}
+
+func (c *ClaudeSession) LoadTranscript(messages []transcriptMessage) {
+	c.Messages = []map[string]interface{}{}
+	for _, msg := range messages {
+		c.Messages = append(c.Messages, map[string]interface{}{
+			"role":    msg.Role,
+			"content": msg.Text,
+		})
+	}
+}
+
+func (o *OpenAISession) Transcript() []transcriptMessage {
+	var transcript []transcriptMessage
+	for _, msg := range o.Messages {
+		role, _ := msg["role"].(string)
+		if role != "user" && role != "assistant" {
+			continue
+		}
+		text, _ := msg["content"].(string)
+		transcript = appendTranscript(transcript, role, text)
+	}
+	return transcript
+}
+
+func (o *OpenAISession) LoadTranscript(messages []transcriptMessage) {
+	// The system message is filled in by Chat
+	o.Messages = []map[string]interface{}{
+		{
+			"role":    "system",
+			"content": "",
+		},
+	}
+	for _, msg := range messages {
+		o.Messages = append(o.Messages, map[string]interface{}{
+			"role":    msg.Role,
+			"content": msg.Text,
+		})
+	}
+}
+
+func (g *GeminiSession) Transcript() []transcriptMessage {
+	var transcript []transcriptMessage
+	for _, msg := range g.History {
+		role, _ := msg["role"].(string)
+		if role == "model" {
+			role = "assistant"
+		}
+		if role != "user" && role != "assistant" {
+			continue
+		}
+		parts, _ := msg["parts"].([]map[string]interface{})
+		for _, part := range parts {
+			text, _ := part["text"].(string)
+			transcript = appendTranscript(transcript, role, text)
+		}
+	}
+	return transcript
+}
+
+func (g *GeminiSession) LoadTranscript(messages []transcriptMessage) {
+	g.History = []map[string]interface{}{}
+	for _, msg := range messages {
+		role := msg.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		g.History = append(g.History, map[string]interface{}{
+			"role": role,
+			"parts": []map[string]interface{}{
+				{"text": msg.Text},
+			},
+		})
+	}
+}
+
+// appendTranscript adds a turn, merging consecutive turns of the same role since providers
+// like Anthropic and Gemini require the roles to alternate
+func appendTranscript(transcript []transcriptMessage, role, text string) []transcriptMessage {
+	if text == "" || (role != "user" && role != "assistant") {
+		return transcript
+	}
+	if n := len(transcript); n > 0 && transcript[n-1].Role == role {
+		transcript[n-1].Text += "\n\n" + text
+		return transcript
+	}
+	return append(transcript, transcriptMessage{Role: role, Text: text})
+}
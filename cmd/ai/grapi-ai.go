@@ -293,6 +293,14 @@ var GrapiAiCmd = &cobra.Command{
 		}
 
 		provider, _ := cmd.Flags().GetString("provider")
+		providerOrder, err := aiProviderOrder(cmd)
+		if err != nil {
+			utils.ErrorMessage(err.Error())
+			return
+		}
+		if provider == "" && len(providerOrder) > 0 {
+			provider = providerOrder[0]
+		}
 		aiConfig, err := setupAIProvider(provider)
 		if err != nil {
 			utils.ErrorMessage(fmt.Sprintf("Error setting up AI provider: %v", err))
@@ -304,10 +312,11 @@ var GrapiAiCmd = &cobra.Command{
 			aiConfig.Model = model
 		}
 
+		noStream, _ := cmd.Flags().GetBool("no-stream")
 		token, _ := cmd.Flags().GetString("token")
 		grapiClient := NewGrapiClient(serverURL, token)
 
-		aiSession, err := createAISession(aiConfig, grapiClient)
+		aiSession, err := createSessionWithFallbacks(aiConfig, providerOrder, grapiClient)
 		if err != nil {
 			utils.ErrorMessage(fmt.Sprintf("Error creating AI session: %v", err))
 			return
@@ -343,22 +352,15 @@ var GrapiAiCmd = &cobra.Command{
 				continue
 			}
 
-			utils.InfoMessage(fmt.Sprintf("%s:", strings.Title(aiConfig.Provider)))
+			utils.InfoMessage(fmt.Sprintf("%s:", strings.Title(activeProvider(aiSession, aiConfig))))
 			fmt.Println(strings.Repeat("-", 50))
 
-			response, err := aiSession.Chat(prompt)
-			if err != nil {
+			if _, err := chatAndRender(aiSession, prompt, renderer, !noStream); err != nil {
 				utils.ErrorMessage(fmt.Sprintf("Error from AI: %v", err))
 				fmt.Println()
 				continue
 			}
-
-			rendered, err := renderer.Render(response)
-			if err != nil {
-				utils.ErrorMessage(fmt.Sprintf("Error rendering response: %v", err))
-				continue
-			}
-			fmt.Println(rendered)
+			fmt.Println()
 		}
 	},
 }
@@ -367,5 +369,7 @@ func init() {
 	GrapiAiCmd.Flags().String("url", "", "Grapi server URL (e.g. http://localhost:3333)")
 	GrapiAiCmd.Flags().StringP("model", "m", "", "AI model to use (overrides defaults and env vars)")
 	GrapiAiCmd.Flags().StringP("provider", "p", "", "AI provider to use (anthropic, openai, gemini)")
+	GrapiAiCmd.Flags().StringSlice("providers", []string{}, "AI providers in priority order, later ones are used when a request to an earlier one fails (e.g: --providers=anthropic,openai)")
 	GrapiAiCmd.Flags().StringP("token", "t", "", "Auth token for MCP endpoint if required")
+	GrapiAiCmd.Flags().Bool("no-stream", false, "Print responses once complete instead of streaming them as they are generated")
 }
@@ -0,0 +1,395 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/grapple-solution/grapple_cli/utils"
+)
+
+// streamClient has no overall timeout like the clients of the blocking calls, long generations take
+// minutes; only the wait for the response headers is limited
+var streamClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: 60 * time.Second,
+	},
+}
+
+// streamingSession is implemented by sessions that can stream the text of their responses as it is generated
+type streamingSession interface {
+	AISession
+	// SetStreamHandler makes Chat stream the response text to onText, nil turns streaming off
+	SetStreamHandler(onText func(text string))
+}
+
+func (c *ClaudeSession) SetStreamHandler(onText func(text string)) { c.OnText = onText }
+func (o *OpenAISession) SetStreamHandler(onText func(text string)) { o.OnText = onText }
+func (g *GeminiSession) SetStreamHandler(onText func(text string)) { g.OnText = onText }
+
+func (f *FallbackSession) SetStreamHandler(onText func(text string)) {
+	handler := onText
+	if onText != nil {
+		handler = func(text string) {
+			f.streamed = true
+			onText(text)
+		}
+	}
+	for _, s := range f.sessions {
+		if streamer, ok := s.session.(streamingSession); ok {
+			streamer.SetStreamHandler(handler)
+		}
+	}
+}
+
+// SetStreamResetHandler makes Chat call onReset when it fails over after part of a response was streamed, the
+// next provider streams its response from the start
+func (f *FallbackSession) SetStreamResetHandler(onReset func()) {
+	f.onStreamReset = onReset
+}
+
+// newMarkdownRenderer returns the renderer of the responses, nil if the terminal isn't supported
+func newMarkdownRenderer() *glamour.TermRenderer {
+	renderer, err := glamour.NewTermRenderer(glamour.WithAutoStyle(), glamour.WithWordWrap(80))
+	if err != nil {
+		return nil
+	}
+	return renderer
+}
+
+// printMarkdown renders text with renderer, or prints it as is without one
+func printMarkdown(renderer *glamour.TermRenderer, text string) {
+	if renderer != nil {
+		if rendered, err := renderer.Render(text); err == nil {
+			fmt.Println(strings.TrimRight(rendered, "\n"))
+			return
+		}
+	}
+	fmt.Println(text)
+}
+
+// chatAndRender sends prompt and prints the response, streamed block by block while it is generated when stream
+// is set and the session supports it (--no-stream turns it off). It returns the full response.
+func chatAndRender(session AISession, prompt string, renderer *glamour.TermRenderer, stream bool) (string, error) {
+	streamer, ok := session.(streamingSession)
+	if !stream || !ok {
+		response, err := session.Chat(prompt)
+		if err != nil {
+			return "", err
+		}
+		printMarkdown(renderer, response)
+		return response, nil
+	}
+
+	out := &markdownStream{renderer: renderer}
+	streamer.SetStreamHandler(out.Write)
+	defer streamer.SetStreamHandler(nil)
+	if fallback, ok := session.(*FallbackSession); ok {
+		fallback.SetStreamResetHandler(out.Reset)
+		defer fallback.SetStreamResetHandler(nil)
+	}
+	response, err := session.Chat(prompt)
+	out.Flush()
+	return response, err
+}
+
+// markdownStream renders streamed markdown: glamour needs complete blocks, so text is buffered until a
+// paragraph ends outside of a code block and rendered up to there
+type markdownStream struct {
+	renderer *glamour.TermRenderer
+	pending  string
+}
+
+func (m *markdownStream) Write(text string) {
+	m.pending += text
+	if end := completeBlocksEnd(m.pending); end > 0 {
+		printMarkdown(m.renderer, m.pending[:end])
+		m.pending = m.pending[end:]
+	}
+}
+
+// Reset drops the unrendered rest of a response that was cut off, a failed over request streams the response
+// of the next provider after it
+func (m *markdownStream) Reset() {
+	m.pending = ""
+	fmt.Println()
+	utils.InfoMessage("The response above is incomplete, it is discarded")
+}
+
+// Flush renders the rest of the response
+func (m *markdownStream) Flush() {
+	if strings.TrimSpace(m.pending) != "" {
+		printMarkdown(m.renderer, m.pending)
+	}
+	m.pending = ""
+}
+
+// completeBlocksEnd returns the end of the last paragraph of text that isn't inside a code block, 0 if there is none
+func completeBlocksEnd(text string) int {
+	end := 0
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], "\n\n")
+		if i < 0 {
+			return end
+		}
+		offset += i + 2
+		if strings.Count(text[:offset], "```")%2 == 0 {
+			end = offset
+		}
+	}
+}
+
+// readSSE calls handle with the event type and data of each server-sent event of body
+func readSSE(body io.Reader, handle func(event, data string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if err := handle(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		return handle(event, strings.Join(data, "\n"))
+	}
+	return nil
+}
+
+// postStream sends a streaming request, the caller closes the body of the response
+func postStream(url string, reqData map[string]interface{}, headers map[string]string, api string) (*http.Response, error) {
+	jsonData, err := json.Marshal(reqData)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, fmt.Errorf("%s API error: rate limit exceeded (status 429). Please try again later", api)
+		}
+		return nil, fmt.Errorf("%s API error: status %d, body: %s", api, resp.StatusCode, string(bodyBytes))
+	}
+	return resp, nil
+}
+
+// streamClaudeAPI is callClaudeAPI with a streamed response, the text is passed to OnText as it arrives
+func (c *ClaudeSession) streamClaudeAPI(reqData map[string]interface{}) (*ClaudeResponse, error) {
+	reqData["stream"] = true
+	resp, err := postStream("https://api.anthropic.com/v1/messages", reqData, map[string]string{
+		"x-api-key":         c.APIKey,
+		"anthropic-version": "2023-06-01",
+	}, "Claude")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	var response ClaudeResponse
+	// The input of a tool call is streamed as partial JSON
+	toolInputs := map[int]*strings.Builder{}
+	toolIndexes := map[int]int{}
+	err = readSSE(resp.Body, func(event, data string) error {
+		var payload struct {
+			Type         string `json:"type"`
+			Index        int    `json:"index"`
+			ContentBlock struct {
+				Type string `json:"type"`
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"content_block"`
+			Delta struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				PartialJSON string `json:"partial_json"`
+				StopReason  string `json:"stop_reason"`
+			} `json:"delta"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &payload); err != nil {
+			return fmt.Errorf("invalid Claude stream event: %w", err)
+		}
+		switch payload.Type {
+		case "content_block_start":
+			if payload.ContentBlock.Type == "tool_use" {
+				toolIndexes[payload.Index] = len(response.ToolUse)
+				toolInputs[payload.Index] = &strings.Builder{}
+				response.ToolUse = append(response.ToolUse, ClaudeToolUse{ID: payload.ContentBlock.ID, Name: payload.ContentBlock.Name})
+			}
+		case "content_block_delta":
+			switch payload.Delta.Type {
+			case "text_delta":
+				text.WriteString(payload.Delta.Text)
+				c.OnText(payload.Delta.Text)
+			case "input_json_delta":
+				if input, ok := toolInputs[payload.Index]; ok {
+					input.WriteString(payload.Delta.PartialJSON)
+				}
+			}
+		case "message_delta":
+			if payload.Delta.StopReason != "" {
+				response.StopReason = payload.Delta.StopReason
+			}
+		case "error":
+			return fmt.Errorf("Claude API error: %s", payload.Error.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for index, input := range toolInputs {
+		arguments := map[string]interface{}{}
+		if input.Len() > 0 {
+			if err := json.Unmarshal([]byte(input.String()), &arguments); err != nil {
+				return nil, fmt.Errorf("failed to parse tool arguments: %v", err)
+			}
+		}
+		response.ToolUse[toolIndexes[index]].Arguments = arguments
+	}
+	response.Content = append(response.Content, ClaudeContent{Type: "text", Text: text.String()})
+	return &response, nil
+}
+
+// streamOpenAIAPI is callOpenAIAPI with a streamed response, the text is passed to OnText as it arrives
+func (o *OpenAISession) streamOpenAIAPI(reqData map[string]interface{}) (*OpenAIResponse, error) {
+	reqData["stream"] = true
+	resp, err := postStream("https://api.openai.com/v1/chat/completions", reqData, map[string]string{
+		"Authorization": "Bearer " + o.APIKey,
+	}, "OpenAI")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content, functionName, functionArguments strings.Builder
+	var finishReason string
+	err = readSSE(resp.Body, func(event, data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content      string              `json:"content"`
+					FunctionCall *OpenAIFunctionCall `json:"function_call"`
+				} `json:"delta"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("invalid OpenAI stream chunk: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				o.OnText(choice.Delta.Content)
+			}
+			if call := choice.Delta.FunctionCall; call != nil {
+				functionName.WriteString(call.Name)
+				functionArguments.WriteString(call.Arguments)
+			}
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	choice := OpenAIChoice{FinishReason: finishReason}
+	choice.Message.Content = content.String()
+	if functionName.Len() > 0 {
+		choice.Message.FunctionCall = &OpenAIFunctionCall{Name: functionName.String(), Arguments: functionArguments.String()}
+	}
+	return &OpenAIResponse{Choices: []OpenAIChoice{choice}}, nil
+}
+
+// streamGeminiAPI is callGeminiAPI with a streamed response, the text is passed to OnText as it arrives
+func (g *GeminiSession) streamGeminiAPI(reqData map[string]interface{}) (*GeminiResponse, error) {
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", g.Model, g.APIKey)
+	resp, err := postStream(url, reqData, nil, "Gemini")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The chunks are responses of their own, their text parts are joined and function calls kept
+	var merged GeminiResponse
+	var text strings.Builder
+	err = readSSE(resp.Body, func(event, data string) error {
+		var chunk GeminiResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("invalid Gemini stream chunk: %w", err)
+		}
+		if len(chunk.Candidates) == 0 {
+			return nil
+		}
+		if len(merged.Candidates) == 0 {
+			merged.Candidates = chunk.Candidates[:1]
+			merged.Candidates[0].Content.Parts = nil
+		}
+		candidate := chunk.Candidates[0]
+		for _, part := range candidate.Content.Parts {
+			if part.FunctionCall != nil {
+				merged.Candidates[0].Content.Parts = append(merged.Candidates[0].Content.Parts, part)
+			} else if part.Text != "" {
+				text.WriteString(part.Text)
+				g.OnText(part.Text)
+			}
+		}
+		if candidate.FinishReason != "" {
+			merged.Candidates[0].FinishReason = candidate.FinishReason
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if text.Len() > 0 && len(merged.Candidates) > 0 {
+		merged.Candidates[0].Content.Parts = append(merged.Candidates[0].Content.Parts, GeminiPart{Text: text.String()})
+	}
+	return &merged, nil
+}
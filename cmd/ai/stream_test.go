@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompleteBlocksEnd(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "empty", text: "", want: 0},
+		{name: "incomplete paragraph", text: "Hello", want: 0},
+		{name: "one complete paragraph", text: "Hello\n\nWor", want: len("Hello\n\n")},
+		{name: "last complete paragraph", text: "One\n\nTwo\n\nThr", want: len("One\n\nTwo\n\n")},
+		{name: "open code block", text: "Text\n\n```yaml\na: 1\n\nb: 2", want: len("Text\n\n")},
+		{name: "closed code block", text: "```yaml\na: 1\n\nb: 2\n```\n\nNext", want: len("```yaml\na: 1\n\nb: 2\n```\n\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := completeBlocksEnd(tt.text); got != tt.want {
+				t.Errorf("completeBlocksEnd(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSSE(t *testing.T) {
+	type sseEvent struct {
+		event string
+		data  string
+	}
+	tests := []struct {
+		name string
+		body string
+		want []sseEvent
+	}{
+		{name: "data only", body: "data: {\"a\":1}\n\n", want: []sseEvent{{data: "{\"a\":1}"}}},
+		{name: "typed events", body: "event: message_start\ndata: one\n\nevent: content_block_delta\ndata: two\n\n",
+			want: []sseEvent{{event: "message_start", data: "one"}, {event: "content_block_delta", data: "two"}}},
+		{name: "multi-line data", body: "data: one\ndata: two\n\n", want: []sseEvent{{data: "one\ntwo"}}},
+		{name: "comments and empty events are skipped", body: ": ping\n\nevent: ping\n\ndata: x\n\n", want: []sseEvent{{data: "x"}}},
+		{name: "last event without blank line", body: "data: [DONE]", want: []sseEvent{{data: "[DONE]"}}},
+		{name: "data without space", body: "data:x\n\n", want: []sseEvent{{data: "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []sseEvent
+			err := readSSE(strings.NewReader(tt.body), func(event, data string) error {
+				got = append(got, sseEvent{event: event, data: data})
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("readSSE() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readSSE() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSSEHandlerError(t *testing.T) {
+	errStop := errors.New("stop")
+	calls := 0
+	err := readSSE(strings.NewReader("data: one\n\ndata: two\n\n"), func(event, data string) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("readSSE() error = %v, want %v", err, errStop)
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,232 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	toolsGrapi      bool
+	toolsGrapiURL   string
+	toolsToken      string
+	toolsShowSchema bool
+	toolArgs        string
+	toolArgsFile    string
+	toolArgsValues  map[string]string
+)
+
+// toolCatalog is the output of 'grapple ai tools list'
+type toolCatalog struct {
+	Server  string                   `json:"server" yaml:"server"`
+	Tools   []map[string]interface{} `json:"tools" yaml:"tools"`
+	Prompts []map[string]interface{} `json:"prompts" yaml:"prompts"`
+}
+
+// ToolsCmd represents the ai tools command
+var ToolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "Inspect and call the tools of the MCP server directly",
+	Long: `Inspect the tools and prompts the AI assistant gets from the MCP server, and call tools directly
+with JSON arguments, bypassing the LLM. This is useful to debug tool behaviour.
+
+By default the Grapple MCP server is used, --grapi targets the MCP endpoint of a Grapi server instead.`,
+}
+
+// ToolsListCmd represents the ai tools list command
+var ToolsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the available tools and prompts with their input schemas",
+	Long: `List the available tools and prompts of the MCP server. Use --schema to print the full input schema
+of every tool, or -o json to get the complete catalog.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider, server := toolProvider()
+
+		catalog := toolCatalog{Server: server}
+		tools, err := provider.GetAvailableTools()
+		if err != nil {
+			return fmt.Errorf("failed to list tools: %w", err)
+		}
+		catalog.Tools = tools
+		prompts, err := provider.GetAvailablePrompts()
+		if err != nil {
+			handleMCPError(err)
+		}
+		catalog.Prompts = prompts
+
+		sort.Slice(catalog.Tools, func(i, j int) bool {
+			return fmt.Sprint(catalog.Tools[i]["name"]) < fmt.Sprint(catalog.Tools[j]["name"])
+		})
+
+		return utils.PrintResult(catalog, func() { printCatalog(catalog) })
+	},
+}
+
+// ToolsCallCmd represents the ai tools call command
+var ToolsCallCmd = &cobra.Command{
+	Use:   "call <tool>",
+	Short: "Call a tool with JSON arguments",
+	Long: `Call a tool of the MCP server directly and print its result.
+
+Arguments are given as a JSON object with --args, read from a file with --args-file ("-" reads stdin),
+or as key=value pairs with --arg, which override keys of the JSON object.
+
+Example:
+  grapple ai tools call list_resources --args '{"namespace": "grpl-dbfile"}'
+  grapple ai tools call get_resource --arg kind=GrappleApplicationSet --arg name=my-gras`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		arguments, err := toolCallArguments()
+		if err != nil {
+			return err
+		}
+
+		provider, _ := toolProvider()
+		result, err := provider.CallTool(args[0], arguments)
+		if err != nil {
+			return fmt.Errorf("tool %s failed: %w", args[0], err)
+		}
+
+		return utils.PrintResult(map[string]interface{}{"tool": args[0], "arguments": arguments, "result": result}, func() {
+			fmt.Println(result)
+		})
+	},
+}
+
+func init() {
+	ToolsCmd.PersistentFlags().BoolVar(&toolsGrapi, "grapi", false, "Use the MCP endpoint of a Grapi server instead of the Grapple MCP server")
+	ToolsCmd.PersistentFlags().StringVar(&toolsGrapiURL, "url", "", "Grapi server URL, implies --grapi (default: the URL saved by 'grapple ai grapi')")
+	ToolsCmd.PersistentFlags().StringVarP(&toolsToken, "token", "t", "", "Auth token for the MCP endpoint if required")
+
+	ToolsListCmd.Flags().BoolVar(&toolsShowSchema, "schema", false, "Print the input schema of every tool")
+
+	ToolsCallCmd.Flags().StringVar(&toolArgs, "args", "", "Tool arguments as JSON object")
+	ToolsCallCmd.Flags().StringVar(&toolArgsFile, "args-file", "", "File with the tool arguments as JSON object, - for stdin")
+	ToolsCallCmd.Flags().StringToStringVar(&toolArgsValues, "arg", map[string]string{}, "Tool argument as key=value, values are parsed as JSON when possible")
+
+	ToolsCmd.AddCommand(ToolsListCmd)
+	ToolsCmd.AddCommand(ToolsCallCmd)
+}
+
+// toolProvider returns the MCP client selected by the flags and a description of the server
+func toolProvider() (ToolProvider, string) {
+	if !toolsGrapi && toolsGrapiURL == "" {
+		return NewRemoteMCPClient(MCPServerURL), MCPServerURL
+	}
+
+	serverURL := toolsGrapiURL
+	if serverURL == "" {
+		if config, err := loadGrapiConfig(); err == nil && config.ServerURL != "" {
+			serverURL = config.ServerURL
+		} else {
+			serverURL = "http://localhost:3333"
+		}
+	}
+	return NewGrapiClient(serverURL, toolsToken), serverURL
+}
+
+// toolCallArguments merges --args/--args-file with the --arg pairs
+func toolCallArguments() (map[string]interface{}, error) {
+	arguments := map[string]interface{}{}
+
+	raw := []byte(toolArgs)
+	if toolArgsFile != "" {
+		if toolArgs != "" {
+			return nil, fmt.Errorf("--args and --args-file cannot be used together")
+		}
+		var err error
+		if toolArgsFile == "-" {
+			raw, err = io.ReadAll(os.Stdin)
+		} else {
+			raw, err = os.ReadFile(toolArgsFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tool arguments: %w", err)
+		}
+	}
+	if len(strings.TrimSpace(string(raw))) > 0 {
+		if err := json.Unmarshal(raw, &arguments); err != nil {
+			return nil, fmt.Errorf("tool arguments must be a JSON object: %w", err)
+		}
+	}
+
+	for key, value := range toolArgsValues {
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+			arguments[key] = parsed
+		} else {
+			arguments[key] = value
+		}
+	}
+	return arguments, nil
+}
+
+func printCatalog(catalog toolCatalog) {
+	utils.InfoMessage(fmt.Sprintf("MCP server: %s", catalog.Server))
+	fmt.Printf("\nTools (%d):\n", len(catalog.Tools))
+	for _, tool := range catalog.Tools {
+		fmt.Printf("  %s\n", tool["name"])
+		if description, ok := tool["description"].(string); ok && description != "" {
+			fmt.Printf("      %s\n", strings.ReplaceAll(strings.TrimSpace(description), "\n", "\n      "))
+		}
+
+		schema, _ := tool["inputSchema"].(map[string]interface{})
+		if toolsShowSchema && schema != nil {
+			data, _ := json.MarshalIndent(schema, "      ", "  ")
+			fmt.Printf("      schema: %s\n", data)
+		} else if properties, ok := schema["properties"].(map[string]interface{}); ok && len(properties) > 0 {
+			fmt.Printf("      arguments: %s\n", strings.Join(argumentSummary(schema, properties), ", "))
+		}
+	}
+
+	fmt.Printf("\nPrompts (%d):\n", len(catalog.Prompts))
+	for _, prompt := range catalog.Prompts {
+		name := prompt["name"]
+		if name == nil {
+			name = "(unnamed)"
+		}
+		fmt.Printf("  %v\n", name)
+		if description, ok := prompt["description"].(string); ok && description != "" {
+			fmt.Printf("      %s\n", description)
+		}
+	}
+}
+
+// argumentSummary returns "name (type)" for every property, required ones marked with *
+func argumentSummary(schema, properties map[string]interface{}) []string {
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, r := range list {
+			required[fmt.Sprint(r)] = true
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var summary []string
+	for _, name := range names {
+		argType := "any"
+		if property, ok := properties[name].(map[string]interface{}); ok {
+			if t, ok := property["type"].(string); ok {
+				argType = t
+			}
+		}
+		marker := ""
+		if required[name] {
+			marker = "*"
+		}
+		summary = append(summary, fmt.Sprintf("%s%s (%s)", name, marker, argType))
+	}
+	return summary
+}
@@ -20,6 +20,7 @@ func init() {
 
 	ApplicationCmd.AddCommand(InitCmd)
 	ApplicationCmd.AddCommand(UpdateCmd)
+	ApplicationCmd.AddCommand(PushCmd)
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -6,4 +6,12 @@ var (
 	githubToken     string
 	grappleType     string
 	grappleTemplate string
+	pushChanges     bool
+	pushBranch      string
+	pushBaseBranch  string
+	createPR        bool
+	templateRefName string
+
+	// appliedTemplateVersion is the template version of the current update
+	appliedTemplateVersion *templateVersion
 )
@@ -0,0 +1,193 @@
+package application
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/quick"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// number of unchanged lines shown around every hunk
+const hunkContextLines = 3
+
+// lineOp is a single line of a line based diff between the local file and the template
+type lineOp struct {
+	op   diffmatchpatch.Operation
+	text string
+}
+
+// diffHunk is a consecutive run of changed lines, start/end index into the ops slice
+type diffHunk struct {
+	start int
+	end   int
+}
+
+// fileUpdateSummary keeps track of what happened to each file during an update
+type fileUpdateSummary struct {
+	file    string
+	applied int
+	skipped int
+}
+
+var updateSummary []fileUpdateSummary
+
+// computeLineOps diffs local and template content line by line
+func computeLineOps(local, template string) []lineOp {
+	dmp := diffmatchpatch.New()
+	localChars, templateChars, lineArray := dmp.DiffLinesToChars(local, template)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(localChars, templateChars, false), lineArray)
+
+	var ops []lineOp
+	for _, d := range diffs {
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			ops = append(ops, lineOp{op: d.Type, text: line})
+		}
+	}
+	return ops
+}
+
+// groupHunks groups consecutive changed lines into hunks
+func groupHunks(ops []lineOp) []diffHunk {
+	var hunks []diffHunk
+	for i := 0; i < len(ops); i++ {
+		if ops[i].op == diffmatchpatch.DiffEqual {
+			continue
+		}
+		start := i
+		for i < len(ops) && ops[i].op != diffmatchpatch.DiffEqual {
+			i++
+		}
+		hunks = append(hunks, diffHunk{start: start, end: i})
+	}
+	return hunks
+}
+
+// renderHunk returns the hunk in unified diff format, including surrounding context
+func renderHunk(filePath string, ops []lineOp, h diffHunk) string {
+	from := h.start - hunkContextLines
+	if from < 0 {
+		from = 0
+	}
+	to := h.end + hunkContextLines
+	if to > len(ops) {
+		to = len(ops)
+	}
+
+	// work out the line numbers of the first displayed line on both sides
+	oldLine, newLine := 1, 1
+	for _, o := range ops[:from] {
+		if o.op != diffmatchpatch.DiffInsert {
+			oldLine++
+		}
+		if o.op != diffmatchpatch.DiffDelete {
+			newLine++
+		}
+	}
+
+	var body strings.Builder
+	oldCount, newCount := 0, 0
+	for _, o := range ops[from:to] {
+		line := strings.TrimSuffix(o.text, "\n")
+		switch o.op {
+		case diffmatchpatch.DiffEqual:
+			body.WriteString(" " + line + "\n")
+			oldCount++
+			newCount++
+		case diffmatchpatch.DiffDelete:
+			body.WriteString("-" + line + "\n")
+			oldCount++
+		case diffmatchpatch.DiffInsert:
+			body.WriteString("+" + line + "\n")
+			newCount++
+		}
+	}
+
+	return fmt.Sprintf("--- a/%s\n+++ b/%s\n@@ -%d,%d +%d,%d @@\n%s", filePath, filePath, oldLine, oldCount, newLine, newCount, body.String())
+}
+
+// printHighlighted writes source to stdout with terminal syntax highlighting, falling back to plain text
+func printHighlighted(source, lexer string) {
+	if err := quick.Highlight(os.Stdout, source, lexer, "terminal256", "monokai"); err != nil {
+		fmt.Print(source)
+	}
+	fmt.Println()
+}
+
+// mergeHunks rebuilds the file content, taking the template side only for the applied hunks
+func mergeHunks(ops []lineOp, hunks []diffHunk, applied []bool) string {
+	var out strings.Builder
+	hunkIdx := 0
+	for i, o := range ops {
+		for hunkIdx < len(hunks) && i >= hunks[hunkIdx].end {
+			hunkIdx++
+		}
+		inHunk := hunkIdx < len(hunks) && i >= hunks[hunkIdx].start
+		switch {
+		case o.op == diffmatchpatch.DiffEqual:
+			out.WriteString(o.text)
+		case inHunk && applied[hunkIdx] && o.op == diffmatchpatch.DiffInsert:
+			out.WriteString(o.text)
+		case inHunk && !applied[hunkIdx] && o.op == diffmatchpatch.DiffDelete:
+			out.WriteString(o.text)
+		}
+	}
+	return out.String()
+}
+
+// reviewHunks walks the user through every hunk and returns which ones should be applied
+func reviewHunks(filePath string, ops []lineOp, hunks []diffHunk) ([]bool, error) {
+	applied := make([]bool, len(hunks))
+	decideRest := ""
+	for i, h := range hunks {
+		if decideRest != "" {
+			applied[i] = decideRest == "apply"
+			continue
+		}
+		if autoConfirm {
+			applied[i] = true
+			continue
+		}
+
+		utils.InfoMessage(fmt.Sprintf("Hunk %d/%d for %s:", i+1, len(hunks), filePath))
+		printHighlighted(renderHunk(filePath, ops, h), "diff")
+
+		choice, err := utils.PromptSelect("Apply this hunk?", []string{
+			"Apply", "Skip", "Apply all remaining hunks in this file", "Skip all remaining hunks in this file",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hunk selection: %w", err)
+		}
+
+		switch choice {
+		case "Apply":
+			applied[i] = true
+		case "Apply all remaining hunks in this file":
+			applied[i] = true
+			decideRest = "apply"
+		case "Skip all remaining hunks in this file":
+			decideRest = "skip"
+		}
+	}
+	return applied, nil
+}
+
+// printUpdateSummary shows how many hunks were applied and skipped per file
+func printUpdateSummary() {
+	if len(updateSummary) == 0 {
+		return
+	}
+	utils.InfoMessage("Update summary:")
+	totalApplied, totalSkipped := 0, 0
+	for _, s := range updateSummary {
+		utils.InfoMessage(fmt.Sprintf("  %s: %d applied, %d skipped", s.file, s.applied, s.skipped))
+		totalApplied += s.applied
+		totalSkipped += s.skipped
+	}
+	utils.SuccessMessage(fmt.Sprintf("%d hunk(s) applied, %d hunk(s) skipped", totalApplied, totalSkipped))
+}
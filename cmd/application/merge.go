@@ -0,0 +1,260 @@
+package application
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/yaml.v2"
+)
+
+// templateBaseFile records, per synced file, the template commit the file was last updated from. It is the
+// merge base of the next update, projects created from a GitHub template share no history with the template.
+const templateBaseFile = ".grapple-template-base.yaml"
+
+// templateBase is the content of templateBaseFile
+type templateBase struct {
+	Template string            `yaml:"template"`
+	Files    map[string]string `yaml:"files"`
+}
+
+// lineChange replaces the base lines [start, end) with lines
+type lineChange struct {
+	start int
+	end   int
+	lines []string
+}
+
+// mergeConflict is a region changed differently in the local file and in the template
+type mergeConflict struct {
+	line   int // first line of the region in the merged file
+	local  []string
+	remote []string
+}
+
+func loadTemplateBase() (*templateBase, error) {
+	base := &templateBase{Files: map[string]string{}}
+	data, err := os.ReadFile(templateBaseFile)
+	if os.IsNotExist(err) {
+		return base, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", templateBaseFile, err)
+	}
+	if err := yaml.Unmarshal(data, base); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", templateBaseFile, err)
+	}
+	if base.Files == nil {
+		base.Files = map[string]string{}
+	}
+	return base, nil
+}
+
+// save writes and stages the template base, so it is committed together with the applied changes
+func (b *templateBase) save(repo *git.Repository) error {
+	b.Template = grappleTemplate
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(templateBaseFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", templateBaseFile, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if _, err := wt.Add(templateBaseFile); err != nil {
+		return fmt.Errorf("failed to stage %s: %w", templateBaseFile, err)
+	}
+	return nil
+}
+
+// initialTemplateBase guesses the template commit the project was created from, for projects updated for the
+// first time: the newest template commit not younger than the first commit of the project
+func initialTemplateBase(repo *git.Repository, templateHead plumbing.Hash) *object.Commit {
+	head, err := repo.Head()
+	if err != nil {
+		return nil
+	}
+	commits, err := repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return nil
+	}
+	var first *object.Commit
+	_ = commits.ForEach(func(c *object.Commit) error {
+		first = c
+		return nil
+	})
+	if first == nil {
+		return nil
+	}
+
+	templateCommits, err := repo.Log(&git.LogOptions{From: templateHead})
+	if err != nil {
+		return nil
+	}
+	var base *object.Commit
+	_ = templateCommits.ForEach(func(c *object.Commit) error {
+		if !c.Committer.When.After(first.Committer.When) {
+			base = c
+			return storer.ErrStop
+		}
+		return nil
+	})
+	return base
+}
+
+// baseContent returns the content of the file in the template commit it was last synced from
+func baseContent(repo *git.Repository, base *templateBase, fallback *object.Commit, path string) (string, bool) {
+	commit := fallback
+	if hash, ok := base.Files[path]; ok {
+		c, err := repo.CommitObject(plumbing.NewHash(hash))
+		if err != nil {
+			utils.InfoMessage(fmt.Sprintf("Template commit %s of %s not found, falling back to a two-way diff", hash, path))
+			return "", false
+		}
+		commit = c
+	}
+	if commit == nil {
+		return "", false
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		// the file was added to the template after the base
+		return "", true
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return "", false
+	}
+	return strings.ReplaceAll(content, "\r\n", "\n"), true
+}
+
+func splitLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineChanges lists the regions of base that other changed
+func lineChanges(base, other string) []lineChange {
+	var changes []lineChange
+	ops := computeLineOps(base, other)
+	baseIdx := 0
+	for i := 0; i < len(ops); {
+		if ops[i].op == diffmatchpatch.DiffEqual {
+			baseIdx++
+			i++
+			continue
+		}
+		change := lineChange{start: baseIdx}
+		for ; i < len(ops) && ops[i].op != diffmatchpatch.DiffEqual; i++ {
+			if ops[i].op == diffmatchpatch.DiffDelete {
+				baseIdx++
+			} else {
+				change.lines = append(change.lines, ops[i].text)
+			}
+		}
+		change.end = baseIdx
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// applyChanges returns the base lines [start, end) with the changes applied
+func applyChanges(baseLines []string, start, end int, changes []lineChange) []string {
+	var out []string
+	pos := start
+	for _, c := range changes {
+		out = append(out, baseLines[pos:c.start]...)
+		out = append(out, c.lines...)
+		pos = c.end
+	}
+	return append(out, baseLines[pos:end]...)
+}
+
+// threeWayMerge merges the changes of local and remote since base. Regions changed on one side only take that
+// side, regions changed on both sides keep the local lines and are returned as conflicts unless both sides agree.
+func threeWayMerge(base, local, remote string) (string, []mergeConflict) {
+	baseLines := splitLines(base)
+	localChanges := lineChanges(base, local)
+	remoteChanges := lineChanges(base, remote)
+
+	var (
+		out       []string
+		conflicts []mergeConflict
+		pos       int
+		i, j      int
+	)
+	for i < len(localChanges) || j < len(remoteChanges) {
+		// start a region with the first change of either side, and grow it while changes overlap it. Insertions
+		// at the same line overlap, changes that only touch each other don't.
+		var start, end int
+		if j >= len(remoteChanges) || (i < len(localChanges) && localChanges[i].start <= remoteChanges[j].start) {
+			start, end = localChanges[i].start, localChanges[i].end
+		} else {
+			start, end = remoteChanges[j].start, remoteChanges[j].end
+		}
+		overlaps := func(c lineChange) bool {
+			return c.start < end || (c.start == end && start == end)
+		}
+		var localRegion, remoteRegion []lineChange
+		for grown := true; grown; {
+			grown = false
+			for ; i < len(localChanges) && overlaps(localChanges[i]); i++ {
+				localRegion = append(localRegion, localChanges[i])
+				end = max(end, localChanges[i].end)
+				grown = true
+			}
+			for ; j < len(remoteChanges) && overlaps(remoteChanges[j]); j++ {
+				remoteRegion = append(remoteRegion, remoteChanges[j])
+				end = max(end, remoteChanges[j].end)
+				grown = true
+			}
+		}
+
+		out = append(out, baseLines[pos:start]...)
+		localLines := applyChanges(baseLines, start, end, localRegion)
+		remoteLines := applyChanges(baseLines, start, end, remoteRegion)
+		switch {
+		case len(remoteRegion) == 0:
+			out = append(out, localLines...)
+		case len(localRegion) == 0:
+			out = append(out, remoteLines...)
+		case strings.Join(localLines, "") == strings.Join(remoteLines, ""):
+			out = append(out, localLines...)
+		default:
+			conflicts = append(conflicts, mergeConflict{line: len(out) + 1, local: localLines, remote: remoteLines})
+			out = append(out, localLines...)
+		}
+		pos = end
+	}
+	out = append(out, baseLines[pos:]...)
+	return strings.Join(out, ""), conflicts
+}
+
+// writeRejects writes the conflicting template changes to <file>.rej in unified diff format, the local file keeps
+// its lines so they can be merged by hand
+func writeRejects(filePath string, conflicts []mergeConflict) error {
+	var rej strings.Builder
+	fmt.Fprintf(&rej, "--- a/%s (local)\n+++ b/%s (template)\n", filePath, filePath)
+	for _, c := range conflicts {
+		fmt.Fprintf(&rej, "@@ -%d,%d +%d,%d @@\n", c.line, len(c.local), c.line, len(c.remote))
+		for _, line := range c.local {
+			rej.WriteString("-" + strings.TrimSuffix(line, "\n") + "\n")
+		}
+		for _, line := range c.remote {
+			rej.WriteString("+" + strings.TrimSuffix(line, "\n") + "\n")
+		}
+	}
+	return os.WriteFile(filePath+".rej", []byte(rej.String()), 0644)
+}
@@ -0,0 +1,55 @@
+package application
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	tests := []struct {
+		name      string
+		local     string
+		remote    string
+		want      string
+		conflicts int
+	}{
+		{name: "unchanged", local: base, remote: base, want: base},
+		{name: "local change only", local: "a\nB\nc\nd\ne\n", remote: base, want: "a\nB\nc\nd\ne\n"},
+		{name: "remote change only", local: base, remote: "a\nb\nc\nD\ne\n", want: "a\nb\nc\nD\ne\n"},
+		{name: "changes in different regions", local: "A\nb\nc\nd\ne\n", remote: "a\nb\nc\nd\nE\n", want: "A\nb\nc\nd\nE\n"},
+		{name: "same change on both sides", local: "a\nb\nC\nd\ne\n", remote: "a\nb\nC\nd\ne\n", want: "a\nb\nC\nd\ne\n"},
+		{name: "remote insertion", local: base, remote: "a\nb\nc\nx\nd\ne\n", want: "a\nb\nc\nx\nd\ne\n"},
+		{name: "remote deletion", local: "a\nB\nc\nd\ne\n", remote: "a\nb\nc\nd\n", want: "a\nB\nc\nd\n"},
+		{name: "conflicting change keeps local", local: "a\nb\nlocal\nd\ne\n", remote: "a\nb\nremote\nd\ne\n", want: "a\nb\nlocal\nd\ne\n", conflicts: 1},
+		{name: "insertions at the same line conflict", local: "a\nlocal\nb\nc\nd\ne\n", remote: "a\nremote\nb\nc\nd\ne\n", want: "a\nlocal\nb\nc\nd\ne\n", conflicts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := threeWayMerge(base, tt.local, tt.remote)
+			if got != tt.want {
+				t.Errorf("threeWayMerge() = %q, want %q", got, tt.want)
+			}
+			if len(conflicts) != tt.conflicts {
+				t.Errorf("threeWayMerge() returned %d conflicts, want %d", len(conflicts), tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestThreeWayMergeConflictRegion(t *testing.T) {
+	_, conflicts := threeWayMerge("a\nb\nc\n", "a\nlocal\nc\n", "a\nremote\nc\n")
+	if len(conflicts) != 1 {
+		t.Fatalf("threeWayMerge() returned %d conflicts, want 1", len(conflicts))
+	}
+	conflict := conflicts[0]
+	if conflict.line != 2 {
+		t.Errorf("conflict line = %d, want 2", conflict.line)
+	}
+	if got := strings.Join(conflict.local, ""); got != "local\n" {
+		t.Errorf("conflict local = %q, want %q", got, "local\n")
+	}
+	if got := strings.Join(conflict.remote, ""); got != "remote\n" {
+		t.Errorf("conflict remote = %q, want %q", got, "remote\n")
+	}
+}
@@ -0,0 +1,286 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/google/go-github/v54/github"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// PushCmd represents the push command
+var PushCmd = &cobra.Command{
+	Use:     "push",
+	Aliases: []string{"p"},
+	Short:   "Commit and push applied template updates",
+	Long: `Commit the template changes staged by 'grapple application update' on a new branch
+and push it to origin, optionally opening a pull request on GitHub.`,
+	RunE: pushApplication,
+}
+
+func init() {
+	PushCmd.Flags().StringVarP(&githubToken, "github-token", "", "", "GitHub token for authentication")
+	PushCmd.Flags().StringVarP(&pushBranch, "branch", "", "", "Branch to commit the changes to (default: grapple-template-update-<timestamp>)")
+	PushCmd.Flags().StringVarP(&pushBaseBranch, "base-branch", "", "", "Base branch of the pull request (default: current branch)")
+	PushCmd.Flags().BoolVarP(&createPR, "create-pr", "", false, "Open a pull request for the pushed branch")
+	PushCmd.Flags().BoolVarP(&autoConfirm, "auto-confirm", "", false, "Automatically confirm all prompts")
+}
+
+func pushApplication(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_app_push.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to push application changes, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	if err = validateGrappleTemplate(); err != nil {
+		return err
+	}
+
+	if err = getGitHubToken(); err != nil {
+		return fmt.Errorf("failed to get GitHub token: %w", err)
+	}
+
+	err = pushAppliedChanges()
+	return err
+}
+
+// pushAppliedChanges commits the staged template changes on a new branch, pushes it to
+// origin and opens a pull request if requested
+func pushAppliedChanges() error {
+	repo, err := git.PlainOpen(".")
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree status: %w", err)
+	}
+
+	var changedFiles []string
+	for file, s := range status {
+		if s.Staging != git.Unmodified && s.Staging != git.Untracked {
+			changedFiles = append(changedFiles, file)
+		}
+	}
+	sort.Strings(changedFiles)
+
+	if len(changedFiles) == 0 {
+		utils.InfoMessage("No staged changes to push, run 'grapple application update' first")
+		return nil
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if pushBaseBranch == "" {
+		if !head.Name().IsBranch() {
+			return fmt.Errorf("HEAD is detached, please pass --base-branch")
+		}
+		pushBaseBranch = head.Name().Short()
+	}
+	if pushBranch == "" {
+		pushBranch = fmt.Sprintf("grapple-template-update-%s", time.Now().Format("20060102-150405"))
+	}
+
+	message := generateCommitMessage(repo, changedFiles)
+
+	if !autoConfirm {
+		utils.InfoMessage(fmt.Sprintf("Going to commit %d file(s) on branch '%s' with message:", len(changedFiles), pushBranch))
+		fmt.Println(message)
+		confirm, err := utils.PromptConfirm("Commit and push these changes?")
+		if err != nil {
+			return fmt.Errorf("failed to get confirmation: %w", err)
+		}
+		if !confirm {
+			utils.InfoMessage("Changes not pushed")
+			return nil
+		}
+	}
+
+	// Keep the staged changes while switching to the new branch
+	branchRef := plumbing.NewBranchReferenceName(pushBranch)
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: branchRef, Create: true, Keep: true}); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", pushBranch, err)
+	}
+
+	commitHash, err := wt.Commit(message, &git.CommitOptions{Author: commitSignature(repo)})
+	if err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	utils.InfoMessage(fmt.Sprintf("Committed changes as %s", commitHash.String()[:7]))
+
+	utils.InfoMessage(fmt.Sprintf("Pushing branch '%s' to origin...", pushBranch))
+	err = repo.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:%s", branchRef, branchRef))},
+		Auth: &http.BasicAuth{
+			Username: "git",
+			Password: githubToken,
+		},
+		Progress: os.Stdout,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("failed to push branch %s: %w", pushBranch, err)
+	}
+	utils.SuccessMessage(fmt.Sprintf("Pushed branch '%s'", pushBranch))
+
+	if !createPR && !autoConfirm {
+		createPR, err = utils.PromptConfirm("Would you like to open a pull request?")
+		if err != nil {
+			return fmt.Errorf("failed to get confirmation: %w", err)
+		}
+	}
+	if createPR {
+		return openPullRequest(repo, message)
+	}
+	return nil
+}
+
+// resolveTemplateVersion describes the template commit the application was updated to, preferring a tag of the
+// template pointing at it
+func resolveTemplateVersion(repo *git.Repository) string {
+	applied := appliedTemplateVersion
+	if applied == nil {
+		applied = loadTemplateVersion()
+	}
+	var templateHash plumbing.Hash
+	if applied != nil {
+		templateHash = plumbing.NewHash(applied.Commit)
+	} else {
+		hash, _, err := resolveTemplateRef(repo, "")
+		if err != nil {
+			return "unknown"
+		}
+		templateHash = hash
+	}
+
+	version := templateHash.String()[:7]
+	if refs, err := repo.References(); err == nil {
+		_ = refs.ForEach(func(t *plumbing.Reference) error {
+			if !strings.HasPrefix(t.Name().String(), templateTagsPrefix) {
+				return nil
+			}
+			hash := t.Hash()
+			if tagObj, err := repo.TagObject(hash); err == nil {
+				hash = tagObj.Target
+			}
+			if hash == templateHash {
+				version = strings.TrimPrefix(t.Name().String(), templateTagsPrefix)
+			}
+			return nil
+		})
+	}
+	return version
+}
+
+// generateCommitMessage builds the commit message from the template version and the changed files
+func generateCommitMessage(repo *git.Repository, changedFiles []string) string {
+	template := grappleTemplate
+	if template == "" {
+		template = "grapple-template"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Update from %s (%s)\n\n", template, resolveTemplateVersion(repo)))
+	sb.WriteString("Files changed:\n")
+	for _, f := range changedFiles {
+		sb.WriteString(fmt.Sprintf("- %s\n", f))
+	}
+	return sb.String()
+}
+
+// commitSignature uses the git user from the repo/global config, falling back to a generic author
+func commitSignature(repo *git.Repository) *object.Signature {
+	sig := &object.Signature{Name: "Grapple CLI", Email: "cli@grapple-solutions.com", When: time.Now()}
+
+	if cfg, err := repo.ConfigScoped(config.GlobalScope); err == nil {
+		if cfg.User.Name != "" {
+			sig.Name = cfg.User.Name
+		}
+		if cfg.User.Email != "" {
+			sig.Email = cfg.User.Email
+		}
+	}
+	return sig
+}
+
+// openPullRequest opens a PR from the pushed branch against the base branch of origin
+func openPullRequest(repo *git.Repository, message string) error {
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return fmt.Errorf("failed to get origin remote: %w", err)
+	}
+
+	owner, name, err := parseGitHubRepo(remote.Config().URLs[0])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: githubToken},
+	)
+	tc := oauth2.NewClient(ctx, ts)
+	githubClient := github.NewClient(tc)
+
+	title, body, _ := strings.Cut(message, "\n\n")
+	pr, _, err := githubClient.PullRequests.Create(ctx, owner, name, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(pushBranch),
+		Base:  github.String(pushBaseBranch),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create pull request: %w", err)
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("Pull request created: %s", pr.GetHTMLURL()))
+	return nil
+}
+
+// parseGitHubRepo extracts owner and repo name from a GitHub remote URL
+func parseGitHubRepo(url string) (string, string, error) {
+	url = strings.TrimSuffix(url, ".git")
+	var repoPath string
+	if strings.HasPrefix(url, "https://github.com/") {
+		repoPath = strings.TrimPrefix(url, "https://github.com/")
+	} else if strings.HasPrefix(url, "git@github.com:") {
+		repoPath = strings.TrimPrefix(url, "git@github.com:")
+	}
+
+	parts := strings.Split(repoPath, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("origin %s is not a GitHub repository", url)
+	}
+	return parts[0], parts[1], nil
+}
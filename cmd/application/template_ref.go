@@ -0,0 +1,93 @@
+package application
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// templateVersionFile records the template ref and commit the application was last updated to
+const templateVersionFile = ".grpl-template-version"
+
+// templateTagsPrefix keeps the tags of the template apart from the tags of the application
+const templateTagsPrefix = "refs/remotes/template/tags/"
+
+// templateVersion is the content of templateVersionFile
+type templateVersion struct {
+	Ref    string `yaml:"ref"`
+	Commit string `yaml:"commit"`
+}
+
+// loadTemplateVersion returns the recorded template version, nil if the application was never updated
+func loadTemplateVersion() *templateVersion {
+	data, err := os.ReadFile(templateVersionFile)
+	if err != nil {
+		return nil
+	}
+	version := &templateVersion{}
+	if err := yaml.Unmarshal(data, version); err != nil || version.Commit == "" {
+		utils.InfoMessage(fmt.Sprintf("Ignoring %s, it has no template commit", templateVersionFile))
+		return nil
+	}
+	return version
+}
+
+// save writes and stages the template version, so it is committed together with the applied changes
+func (v *templateVersion) save(repo *git.Repository) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(templateVersionFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", templateVersionFile, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if _, err := wt.Add(templateVersionFile); err != nil {
+		return fmt.Errorf("failed to stage %s: %w", templateVersionFile, err)
+	}
+	return nil
+}
+
+// resolveTemplateRef returns the template commit of a branch, tag or commit sha of the template, and the ref it
+// was resolved from. Without a ref, main and then master are used.
+func resolveTemplateRef(repo *git.Repository, ref string) (plumbing.Hash, string, error) {
+	if ref == "" {
+		for _, branch := range []string{"main", "master"} {
+			if reference, err := repo.Reference(plumbing.NewRemoteReferenceName("template", branch), true); err == nil {
+				return reference.Hash(), branch, nil
+			}
+		}
+		return plumbing.ZeroHash, "", fmt.Errorf("failed to get template reference (tried main and master)")
+	}
+
+	candidates := []plumbing.ReferenceName{
+		plumbing.NewRemoteReferenceName("template", ref),
+		plumbing.ReferenceName(templateTagsPrefix + ref),
+	}
+	for _, name := range candidates {
+		reference, err := repo.Reference(name, true)
+		if err != nil {
+			continue
+		}
+		hash := reference.Hash()
+		// annotated tags point to a tag object, not to the commit
+		if tag, err := repo.TagObject(hash); err == nil {
+			hash = tag.Target
+		}
+		return hash, ref, nil
+	}
+
+	if hash, err := repo.ResolveRevision(plumbing.Revision(ref)); err == nil {
+		if _, err := repo.CommitObject(*hash); err == nil {
+			return *hash, ref, nil
+		}
+	}
+	return plumbing.ZeroHash, "", fmt.Errorf("%w: template ref %q is neither a branch, a tag nor a commit of %s", utils.ErrValidation, ref, grappleTemplate)
+}
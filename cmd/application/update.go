@@ -12,10 +12,10 @@ import (
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/grapple-solution/grapple_cli/utils"
-	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/spf13/cobra"
 )
 
@@ -25,7 +25,19 @@ var UpdateCmd = &cobra.Command{
 	Aliases: []string{"u"},
 	Short:   "Update a Grapple application from template",
 	Long: `Update a Grapple application by syncing differences from the template repository.
-This command checks for and applies updates to configuration files and documentation.`,
+This command checks for and applies updates to configuration files and documentation.
+
+The changes are merged three-way: the template commit each file was last synced from (recorded in
+.grapple-template-base.yaml, or the template commit the project was created from) is the merge base, so
+only what changed in the template since then is offered, hunk by hunk, and local modifications are kept.
+Template changes conflicting with local modifications are written to <file>.rej to be merged by hand.
+
+Use --template-ref to update to a specific release of the template instead of its main branch. The template
+ref and commit the application was updated to are recorded in .grpl-template-version.
+
+Example:
+  grapple application update
+  grapple application update --template-ref v1.4.0 --push --create-pr`,
 	RunE: updateApplication,
 }
 
@@ -33,6 +45,10 @@ func init() {
 	UpdateCmd.Flags().StringVarP(&grappleTemplate, "grapple-template", "", "", "Template repository to use")
 	UpdateCmd.Flags().StringVarP(&githubToken, "github-token", "", "", "GitHub token for authentication")
 	UpdateCmd.Flags().BoolVarP(&autoConfirm, "auto-confirm", "", false, "Automatically confirm all prompts")
+	UpdateCmd.Flags().BoolVarP(&pushChanges, "push", "", false, "Commit the applied changes on a new branch and push it to origin")
+	UpdateCmd.Flags().StringVarP(&pushBranch, "branch", "", "", "Branch used by --push (default: grapple-template-update-<timestamp>)")
+	UpdateCmd.Flags().BoolVarP(&createPR, "create-pr", "", false, "Open a pull request after pushing (requires --push)")
+	UpdateCmd.Flags().StringVarP(&templateRefName, "template-ref", "", "", "Branch, tag or commit of the template to update to (default: main or master)")
 }
 
 func updateApplication(cmd *cobra.Command, args []string) error {
@@ -82,7 +98,29 @@ func updateApplication(cmd *cobra.Command, args []string) error {
 	}
 
 	// Sync differences
-	return syncDifferences()
+	if err := syncDifferences(); err != nil {
+		return err
+	}
+
+	appliedHunks := 0
+	for _, s := range updateSummary {
+		appliedHunks += s.applied
+	}
+	if appliedHunks == 0 {
+		return nil
+	}
+
+	// Pushing is opt-in, either via --push or by confirming here
+	if !pushChanges && !autoConfirm {
+		pushChanges, err = utils.PromptConfirm("Would you like to commit and push the applied changes to a new branch?")
+		if err != nil {
+			return fmt.Errorf("failed to get confirmation: %w", err)
+		}
+	}
+	if pushChanges {
+		return pushAppliedChanges()
+	}
+	return nil
 }
 
 func validateGrappleTemplate() error {
@@ -306,6 +344,12 @@ func syncDifferences() error {
 	utils.InfoMessage("Fetching updates from template...")
 	err = repo.Fetch(&git.FetchOptions{
 		RemoteName: "template",
+		// the tags of the template are kept apart from the tags of the application
+		RefSpecs: []config.RefSpec{
+			"+refs/heads/*:refs/remotes/template/*",
+			config.RefSpec("+refs/tags/*:" + templateTagsPrefix + "*"),
+		},
+		Tags: git.NoTags,
 		Auth: &http.BasicAuth{
 			Username: "git",
 			Password: githubToken,
@@ -315,18 +359,16 @@ func syncDifferences() error {
 		return fmt.Errorf("failed to fetch template: %w", err)
 	}
 
-	// Get template branch reference (try main first, then master)
-	templateRef, err := repo.Reference("refs/remotes/template/main", true)
+	// Get the template commit of --template-ref, or of main/master
+	templateHash, refName, err := resolveTemplateRef(repo, templateRefName)
 	if err != nil {
-		// Try template/master if main not found
-		templateRef, err = repo.Reference("refs/remotes/template/master", true)
-		if err != nil {
-			return fmt.Errorf("failed to get template reference (tried main and master): %w", err)
-		}
+		return err
 	}
+	utils.InfoMessage(fmt.Sprintf("Syncing with template %s at %s (%s)", grappleTemplate, refName, templateHash.String()[:7]))
+	appliedTemplateVersion = &templateVersion{Ref: refName, Commit: templateHash.String()}
 
 	// Get template commit and tree
-	templateCommit, err := repo.CommitObject(templateRef.Hash())
+	templateCommit, err := repo.CommitObject(templateHash)
 	if err != nil {
 		return fmt.Errorf("failed to get template commit: %w", err)
 	}
@@ -355,9 +397,35 @@ func syncDifferences() error {
 		return fmt.Errorf("failed to enumerate template files: %w", err)
 	}
 
+	// The merge base of every file is the template commit it was last synced from
+	base, err := loadTemplateBase()
+	if err != nil {
+		return err
+	}
+	var fallbackBase *object.Commit
+	if recorded := loadTemplateVersion(); recorded != nil {
+		if fallbackBase, err = repo.CommitObject(plumbing.NewHash(recorded.Commit)); err != nil {
+			utils.InfoMessage(fmt.Sprintf("Template commit %s of %s not found", recorded.Commit, templateVersionFile))
+		}
+	}
+	if fallbackBase == nil {
+		fallbackBase = initialTemplateBase(repo, templateHash)
+	}
+	mergeBases := map[string]*string{}
+
 	// Compare with local files
 	var diffFiles []string
 	for path, templateContent := range templateFiles {
+		// Normalize line endings to avoid false positives
+		normalizedTemplate := strings.ReplaceAll(templateContent, "\r\n", "\n")
+		if content, ok := baseContent(repo, base, fallbackBase, path); ok {
+			if content == normalizedTemplate {
+				// the template didn't change since the last sync, local differences are local modifications
+				continue
+			}
+			mergeBases[path] = &content
+		}
+
 		// Read local file
 		localContent, err := os.ReadFile(path)
 		if err != nil {
@@ -366,8 +434,6 @@ func syncDifferences() error {
 			continue
 		}
 
-		// Normalize line endings to avoid false positives
-		normalizedTemplate := strings.ReplaceAll(templateContent, "\r\n", "\n")
 		normalizedLocal := strings.ReplaceAll(string(localContent), "\r\n", "\n")
 
 		// Compare content (ignoring whitespace for better results)
@@ -389,12 +455,14 @@ func syncDifferences() error {
 		utils.InfoMessage("Auto-confirm enabled. Applying all differences...")
 		for _, file := range diffFiles {
 			utils.InfoMessage(fmt.Sprintf("Applying differences for %s...", file))
-			if err := applyFileChanges(file, templateFiles[file]); err != nil {
+			if err := applyFileChanges(file, templateFiles[file], mergeBases[file]); err != nil {
 				return fmt.Errorf("failed to apply changes to %s: %w", file, err)
 			}
+			base.Files[file] = templateHash.String()
 		}
 		utils.SuccessMessage("All differences applied")
-		return nil
+		printUpdateSummary()
+		return saveSyncState(repo, base)
 	}
 
 	// Let user choose files to update
@@ -411,23 +479,37 @@ func syncDifferences() error {
 	case "Apply All":
 		for _, file := range diffFiles {
 			utils.InfoMessage(fmt.Sprintf("Applying differences for %s...", file))
-			if err := applyFileChanges(file, templateFiles[file]); err != nil {
+			if err := applyFileChanges(file, templateFiles[file], mergeBases[file]); err != nil {
 				return fmt.Errorf("failed to apply changes to %s: %w", file, err)
 			}
+			base.Files[file] = templateHash.String()
 		}
 		utils.SuccessMessage("All differences applied")
 	default:
 		utils.InfoMessage(fmt.Sprintf("Applying differences for %s...", selected))
-		if err := applyFileChanges(selected, templateFiles[selected]); err != nil {
+		if err := applyFileChanges(selected, templateFiles[selected], mergeBases[selected]); err != nil {
 			return fmt.Errorf("failed to apply changes to %s: %w", selected, err)
 		}
+		base.Files[selected] = templateHash.String()
 		utils.SuccessMessage(fmt.Sprintf("%s updated", selected))
 	}
 
-	return nil
+	printUpdateSummary()
+	return saveSyncState(repo, base)
 }
 
-func applyFileChanges(filePath string, templateContent string) error {
+// saveSyncState records the merge bases of the synced files and the template version they were synced to
+func saveSyncState(repo *git.Repository, base *templateBase) error {
+	if err := base.save(repo); err != nil {
+		return err
+	}
+	return appliedTemplateVersion.save(repo)
+}
+
+// applyFileChanges updates the file with the changes of the template, reviewed hunk by hunk. With the merge
+// base known, only the template changes since the base are offered and local modifications are kept, changes
+// conflicting with local modifications are written to <file>.rej instead of overwriting them.
+func applyFileChanges(filePath string, templateContent string, mergeBase *string) error {
 	// Read local file content if it exists
 	localContent, err := os.ReadFile(filePath)
 	var localContentStr string
@@ -445,30 +527,67 @@ func applyFileChanges(filePath string, templateContent string) error {
 		return nil
 	}
 
-	// Show diff
 	utils.InfoMessage(fmt.Sprintf("Changes for %s:", filePath))
-	dmp := diffmatchpatch.New()
-	diffs := dmp.DiffMain(normalizedLocal, normalizedTemplate, false)
+	newContent := templateContent
+	summary := fileUpdateSummary{file: filePath}
 
-	// Only show diff if local file exists
 	if len(localContentStr) > 0 {
-		fmt.Println(dmp.DiffPrettyText(diffs))
-	} else {
-		// For new files, just show content
-		fmt.Println(templateContent)
-	}
+		target := normalizedTemplate
+		if mergeBase != nil {
+			merged, conflicts := threeWayMerge(*mergeBase, normalizedLocal, normalizedTemplate)
+			if len(conflicts) > 0 {
+				if err := writeRejects(filePath, conflicts); err != nil {
+					return fmt.Errorf("failed to write rejects: %w", err)
+				}
+				utils.ErrorMessage(fmt.Sprintf("%d template change(s) conflict with local modifications of %s, see %s.rej", len(conflicts), filePath, filePath))
+			}
+			if merged == normalizedLocal {
+				utils.InfoMessage(fmt.Sprintf("No template changes to apply to %s", filePath))
+				return nil
+			}
+			target = merged
+		}
 
-	// Ask for confirmation unless auto-confirm is enabled
-	if !autoConfirm {
-		confirm, err := utils.PromptConfirm("Would you like to apply these changes?")
+		// Review the diff hunk by hunk, only the accepted hunks are taken from the template
+		ops := computeLineOps(normalizedLocal, target)
+		hunks := groupHunks(ops)
+		applied, err := reviewHunks(filePath, ops, hunks)
 		if err != nil {
-			return fmt.Errorf("failed to get confirmation: %w", err)
+			return err
 		}
-
-		if !confirm {
+		for _, a := range applied {
+			if a {
+				summary.applied++
+			} else {
+				summary.skipped++
+			}
+		}
+		updateSummary = append(updateSummary, summary)
+		if summary.applied == 0 {
 			utils.InfoMessage("Changes not applied")
 			return nil
 		}
+		newContent = mergeHunks(ops, hunks, applied)
+	} else {
+		// For new files, just show content
+		printHighlighted(templateContent, filepath.Base(filePath))
+
+		// Ask for confirmation unless auto-confirm is enabled
+		if !autoConfirm {
+			confirm, err := utils.PromptConfirm("Would you like to apply these changes?")
+			if err != nil {
+				return fmt.Errorf("failed to get confirmation: %w", err)
+			}
+
+			if !confirm {
+				summary.skipped++
+				updateSummary = append(updateSummary, summary)
+				utils.InfoMessage("Changes not applied")
+				return nil
+			}
+		}
+		summary.applied++
+		updateSummary = append(updateSummary, summary)
 	}
 
 	// Ensure directory exists
@@ -479,8 +598,8 @@ func applyFileChanges(filePath string, templateContent string) error {
 		}
 	}
 
-	// Write template content to file
-	if err := os.WriteFile(filePath, []byte(templateContent), 0644); err != nil {
+	// Write the merged content to file
+	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
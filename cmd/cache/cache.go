@@ -0,0 +1,22 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package cache
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// CacheCmd represents the cache command
+var CacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local Helm chart cache used for offline installs",
+	Long: `Manage the local Helm chart cache in ~/.cache/grpl/charts (or $XDG_CACHE_HOME/grpl/charts).
+
+Installs use cached charts instead of pulling them from the chart registry, and with --offline they resolve
+charts only from the cache, which allows installing in air-gapped environments.`,
+}
+
+func init() {
+	CacheCmd.AddCommand(PullCmd)
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var grappleVersion string
+
+// PullCmd represents the cache pull command
+var PullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Download the charts of a Grapple version into the chart cache",
+	Long: `Download the grsf-init, grsf, grsf-config and grsf-integration charts of --grapple-version, the
+KubeBlocks chart and CRDs into the chart cache, and write the list of container images they use to
+images-<version>.txt in the cache, so the images can be mirrored as well.
+
+Example:
+  grapple cache pull --grapple-version=0.3.5
+  grapple k3d install --grapple-version=0.3.5 --offline`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if grappleVersion == "" || grappleVersion == "latest" {
+			grappleVersion = utils.DefaultGrappleVersion
+		}
+
+		cache, err := utils.PullCharts(grappleVersion)
+		if err != nil {
+			return err
+		}
+
+		return utils.PrintResult(cache, func() {
+			for _, chart := range cache.Charts {
+				utils.InfoMessage(fmt.Sprintf("  %s", chart))
+			}
+			utils.InfoMessage(fmt.Sprintf("Images used by the charts (%d), listed in %s/images-%s.txt:", len(cache.Images), cache.Dir, grappleVersion))
+			for _, image := range cache.Images {
+				utils.InfoMessage(fmt.Sprintf("  %s", image))
+			}
+			utils.SuccessMessage(fmt.Sprintf("Charts of grapple %s cached in %s", grappleVersion, cache.Dir))
+		})
+	},
+}
+
+func init() {
+	PullCmd.Flags().StringVar(&grappleVersion, "grapple-version", "latest", "Version of Grapple to cache (default: latest)")
+}
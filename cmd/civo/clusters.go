@@ -0,0 +1,128 @@
+package civo
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/civo/civogo"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
+)
+
+const (
+	// civoClustersPerPage is the page size of the cluster list, the API answers 20 clusters per page by default
+	civoClustersPerPage = 100
+	// civoClustersCacheTTL is how long a cluster list is reused, by the same run and by the selection prompts
+	// of the next runs
+	civoClustersCacheTTL = time.Minute
+	// civoRegionsCacheTTL is how long the region list is reused, regions hardly ever change
+	civoRegionsCacheTTL = 24 * time.Hour
+)
+
+// civoClusterSummary is the part of a cluster cached on disk for the selection prompts, the kubeconfig of a
+// cluster is never written to the cache
+type civoClusterSummary struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+}
+
+// fetchedClusters is the cluster list of a region fetched during this run
+type fetchedClusters struct {
+	fetchedAt time.Time
+	clusters  []civogo.KubernetesCluster
+}
+
+var clusterLists = map[string]fetchedClusters{}
+
+// listCivoClusters returns all clusters of the region of the client, page by page, a list fetched less than
+// civoClustersCacheTTL ago is reused
+func listCivoClusters(client *civogo.Client) ([]civogo.KubernetesCluster, error) {
+	if fetched, ok := clusterLists[client.Region]; ok && time.Since(fetched.fetchedAt) < civoClustersCacheTTL {
+		return fetched.clusters, nil
+	}
+
+	var clusters []civogo.KubernetesCluster
+	for page := 1; ; page++ {
+		resp, err := client.SendGetRequest(fmt.Sprintf("/v2/kubernetes/clusters?page=%d&per_page=%d", page, civoClustersPerPage))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list clusters: %w", err)
+		}
+		var paginated civogo.PaginatedKubernetesClusters
+		if err := json.Unmarshal(resp, &paginated); err != nil {
+			return nil, fmt.Errorf("failed to parse clusters: %w", err)
+		}
+		clusters = append(clusters, paginated.Items...)
+		if page >= paginated.Pages || len(paginated.Items) == 0 {
+			break
+		}
+	}
+
+	clusterLists[client.Region] = fetchedClusters{fetchedAt: time.Now(), clusters: clusters}
+	summaries := make([]civoClusterSummary, 0, len(clusters))
+	for _, cluster := range clusters {
+		summaries = append(summaries, civoClusterSummary{ID: cluster.ID, Name: cluster.Name, Ready: cluster.Ready})
+	}
+	utils.WriteAPICache(civoClustersCacheKey(client), summaries)
+	return clusters, nil
+}
+
+// findCivoCluster returns the cluster of the name in the region of the client, nil if there is none
+func findCivoCluster(client *civogo.Client, name string) (*civogo.KubernetesCluster, error) {
+	clusters, err := listCivoClusters(client)
+	if err != nil {
+		return nil, err
+	}
+	for _, cluster := range clusters {
+		if cluster.Name == name {
+			return &cluster, nil
+		}
+	}
+	return nil, nil
+}
+
+// selectCivoCluster asks to select a cluster of the region of the client, the list can be searched by typing.
+// The names come from the cache of an earlier run when it is younger than civoClustersCacheTTL.
+func selectCivoCluster(client *civogo.Client, label string) (string, error) {
+	var summaries []civoClusterSummary
+	if !utils.ReadAPICache(civoClustersCacheKey(client), civoClustersCacheTTL, &summaries) {
+		if _, err := listCivoClusters(client); err != nil {
+			return "", err
+		}
+		for _, cluster := range clusterLists[client.Region].clusters {
+			summaries = append(summaries, civoClusterSummary{ID: cluster.ID, Name: cluster.Name, Ready: cluster.Ready})
+		}
+	}
+
+	names := make([]string, 0, len(summaries))
+	for _, summary := range summaries {
+		names = append(names, summary.Name)
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("%w: no clusters found in region %s", utils.ErrValidation, client.Region)
+	}
+	sort.Strings(names)
+	return prompt.Select(prompt.Question{Label: label, Flag: "--cluster-name", Search: true}, names)
+}
+
+// forgetCivoClusters drops the cached clusters of the region after a cluster was created or deleted
+func forgetCivoClusters(client *civogo.Client) {
+	delete(clusterLists, client.Region)
+	utils.InvalidateAPICache(civoClustersCacheKey(client))
+}
+
+func civoClustersCacheKey(client *civogo.Client) string {
+	return utils.APICacheKey("civo-clusters", client.APIKey, client.Region)
+}
+
+// selectCivoRegion asks to select a region of the account, the list can be searched by typing
+func selectCivoRegion(apiKey string) (string, error) {
+	return prompt.Select(prompt.Question{
+		Label:   "Select region",
+		Flag:    "--civo-region",
+		History: "civo-region",
+		Search:  true,
+	}, getCivoRegion(apiKey))
+}
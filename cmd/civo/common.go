@@ -21,8 +21,13 @@ var (
 	nodes          int
 	size           string
 
+	kubernetesVersion  string
+	network            string
+	installAfterCreate bool
+
 	// Common flags
 	autoConfirm       bool
+	rollbackOnFailure bool
 	key               string
 	civoRegion        string
 	civoEmailAddress  string
@@ -41,9 +46,14 @@ var (
 	completeDomain        string
 	grappleLicense        string
 	hostedZoneID          string
+	verifyDomain          bool
+	domainCheckTimeout    = 15 * time.Minute
 	ingressController     string
 	additionalValuesFiles []string
+	providerValues        string
 	imagePullSecret       string
+	labels                map[string]string
+	annotations           map[string]string
 )
 
 var (
@@ -52,20 +62,29 @@ var (
 
 // Wait for the cluster to be ready
 func waitForClusterReady(client *civogo.Client, cluster *civogo.KubernetesCluster) error {
-	endTime := time.Now().Add(10 * time.Minute)
+	timeout := 10 * time.Minute
+	progress := utils.StartWaitProgress(fmt.Sprintf("cluster '%s' to be ready", cluster.Name), timeout)
+	defer progress.Stop()
+
+	poller := utils.NewPoller()
+	endTime := time.Now().Add(timeout)
 
 	for time.Now().Before(endTime) {
 		status, err := client.GetKubernetesCluster(cluster.ID)
 		if err != nil {
 			utils.ErrorMessage(fmt.Sprintf("Error fetching cluster status: %v", err))
-			time.Sleep(10 * time.Second)
+			if err := poller.Wait(); err != nil {
+				return err
+			}
 			continue
 		}
 		if status.Ready {
-			utils.SuccessMessage("Cluster is ready.")
+			progress.Done("Cluster is ready.")
 			return nil
 		}
-		time.Sleep(10 * time.Second)
+		if err := poller.Wait(); err != nil {
+			return err
+		}
 	}
 
 	utils.ErrorMessage(fmt.Sprintf("Cluster '%s' was not ready within the timeout", cluster.Name))
@@ -150,7 +169,13 @@ func getCivoKeyFromConfig() (string, error) {
 	return key, nil
 }
 
+// getCivoRegion returns the region codes of the account, cached for civoRegionsCacheTTL
 func getCivoRegion(key string) []string {
+	cacheKey := utils.APICacheKey("civo-regions", key)
+	var cached []string
+	if utils.ReadAPICache(cacheKey, civoRegionsCacheTTL, &cached) && len(cached) > 0 {
+		return cached
+	}
 
 	// Create HTTP client
 	client := &http.Client{}
@@ -189,6 +214,9 @@ func getCivoRegion(key string) []string {
 	for _, region := range regions {
 		regionCodes = append(regionCodes, region.Code)
 	}
+	if len(regionCodes) > 0 {
+		utils.WriteAPICache(cacheKey, regionCodes)
+	}
 
 	return regionCodes
 }
@@ -2,10 +2,7 @@ package civo
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/civo/civogo"
 	"github.com/grapple-solution/grapple_cli/utils"
@@ -23,7 +20,8 @@ var ConnectCmd = &cobra.Command{
 	Short:   "Connect to an existing Civo Kubernetes cluster",
 	Long: `Connect to an existing Kubernetes cluster on Civo cloud platform and configure kubectl.
 This will update your kubeconfig file to allow kubectl access to the cluster.`,
-	RunE: connectToCluster,
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE:        runConnect,
 }
 
 func init() {
@@ -53,11 +51,10 @@ func connectToCluster(cmd *cobra.Command, args []string) error {
 	civoAPIKey := getCivoAPIKey()
 
 	if civoRegion == "" {
-		regions := getCivoRegion(civoAPIKey)
-		result, err := utils.PromptSelect("Select region", regions)
+		result, err := selectCivoRegion(civoAPIKey)
 		if err != nil {
 			utils.ErrorMessage("Region selection is required")
-			return errors.New("region selection is required")
+			return err
 		}
 		civoRegion = result
 	}
@@ -69,36 +66,20 @@ func connectToCluster(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// List all clusters and find the target cluster
-	clusters, err := client.ListKubernetesClusters()
-	if err != nil {
-		utils.ErrorMessage(fmt.Sprintf("Failed to list clusters: %v", err))
-		return err
-	}
-
 	if clusterName == "" {
-		var clusterNames []string
-		for _, cluster := range clusters.Items {
-			clusterNames = append(clusterNames, cluster.Name)
-		}
-		if len(clusterNames) == 0 {
-			utils.ErrorMessage("No clusters found in region " + civoRegion)
-			return errors.New("no clusters found in region " + civoRegion)
-		}
-		result, err := utils.PromptSelect("Select cluster to connect to", clusterNames)
+		result, err := selectCivoCluster(client, "Select cluster to connect to")
 		if err != nil {
 			utils.ErrorMessage("Cluster selection is required")
-			return errors.New("cluster selection is required")
+			return err
 		}
 		clusterName = result
 	}
 
-	var targetCluster *civogo.KubernetesCluster
-	for _, c := range clusters.Items {
-		if c.Name == clusterName {
-			targetCluster = &c
-			break
-		}
+	// Find the target cluster
+	targetCluster, err := findCivoCluster(client, clusterName)
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to list clusters: %v", err))
+		return err
 	}
 
 	if targetCluster == nil {
@@ -129,65 +110,26 @@ func connectToCluster(cmd *cobra.Command, args []string) error {
 
 // Configure kubectl for the created cluster
 func configureKubeConfig(kubeConfig string) (*rest.Config, error) {
-	// Get home directory in a cross-platform way
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
-	}
-
-	// Create .kube directory if it doesn't exist
-	kubeDir := filepath.Join(home, ".kube")
-	if err := os.MkdirAll(kubeDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create .kube directory: %w", err)
-	}
-
-	// Read existing kubeconfig
-	configPath := filepath.Join(kubeDir, "config")
-	existingConfig, err := clientcmd.LoadFromFile(configPath)
-	if err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("failed to load existing kubeconfig: %w", err)
-	}
-
 	// Parse the new kubeconfig
 	newConfig, err := clientcmd.Load([]byte(kubeConfig))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse new kubeconfig: %w", err)
 	}
 
-	// Merge configurations
-	if existingConfig == nil {
-		existingConfig = newConfig
-	} else {
-		// Merge clusters
-		for name, cluster := range newConfig.Clusters {
-			existingConfig.Clusters[name] = cluster
-		}
-
-		// Merge contexts
-		for name, context := range newConfig.Contexts {
-			existingConfig.Contexts[name] = context
-		}
-
-		// Merge authInfos (users)
-		for name, authInfo := range newConfig.AuthInfos {
-			existingConfig.AuthInfos[name] = authInfo
-		}
-
-		// Set the new context as current context
+	// Merge it into the kubeconfig and switch to its context
+	contextName := newConfig.CurrentContext
+	if contextName == "" {
 		for name := range newConfig.Contexts {
-			existingConfig.CurrentContext = name
+			contextName = name
 			break
 		}
 	}
-
-	// Write merged config
-	err = clientcmd.WriteToFile(*existingConfig, configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write merged kubeconfig: %w", err)
+	if err := utils.MergeKubeconfig(newConfig, contextName); err != nil {
+		return nil, err
 	}
 
 	// Load kubeconfig and initialize kubectl client
-	config, err := clientcmd.BuildConfigFromFlags("", configPath)
+	config, err := utils.KubeContextRESTConfig(contextName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 	}
@@ -206,3 +148,14 @@ func configureKubeConfig(kubeConfig string) (*rest.Config, error) {
 	utils.SuccessMessage("Kubeconfig configured successfully.")
 	return config, nil
 }
+
+// runConnect connects to the cluster and prints the resulting connection with --output json|yaml
+func runConnect(cmd *cobra.Command, args []string) error {
+	if err := connectToCluster(cmd, args); err != nil {
+		return err
+	}
+	if utils.IsStructuredOutput() {
+		return utils.PrintClusterConnection(utils.ProviderClusterTypeCivo, clusterName, civoRegion)
+	}
+	return nil
+}
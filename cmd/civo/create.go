@@ -1,22 +1,24 @@
 package civo
 
 import (
-	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/civo/civogo"
 	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
 	"github.com/spf13/cobra"
 )
 
 // CreateCmd represents the create command
 var CreateCmd = &cobra.Command{
-	Use:     "create",
-	Aliases: []string{"c"},
-	Short:   "Create a Kubernetes cluster in Civo",
-	Long:    "Create a new Kubernetes cluster on the Civo cloud platform with specified configuration.",
-	RunE:    createCluster,
+	Use:         "create",
+	Aliases:     []string{"c"},
+	Short:       "Create a Kubernetes cluster in Civo",
+	Long:        "Create a new Kubernetes cluster on the Civo cloud platform with specified configuration.",
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE:        createCluster,
 }
 
 // Initialize flags
@@ -30,6 +32,9 @@ func init() {
 	CreateCmd.Flags().IntVarP(&nodes, "nodes", "n", 3, "Number of nodes (default: 3)")
 	CreateCmd.Flags().StringVar(&size, "size", "g4s.kube.medium", "Node size (default: g4s.kube.medium)")
 	CreateCmd.Flags().BoolVar(&waitForReady, "wait", false, "Wait for cluster to be ready (default: false)")
+	CreateCmd.Flags().StringVar(&kubernetesVersion, "kubernetes-version", "", "Kubernetes version of the cluster (default: Civo's default version)")
+	CreateCmd.Flags().StringVar(&network, "network", "", "Name or ID of the Civo network to create the cluster in (default: the region's default network)")
+	CreateCmd.Flags().BoolVar(&installAfterCreate, "install", false, "Install Grapple on the cluster once it is ready, waits for it unless --wait=false is given")
 }
 
 // Function to handle the "create" command logic
@@ -53,10 +58,14 @@ func createCluster(cmd *cobra.Command, args []string) error {
 
 	// Validate input
 	if clusterName == "" {
-		result, err := utils.PromptInput("Enter cluster name", utils.DefaultValue, utils.NonEmptyValueRegex)
+		result, err := prompt.Input(prompt.Question{
+			Label:    "Enter cluster name",
+			Flag:     "--cluster-name",
+			Validate: prompt.MatchRegex(utils.NonEmptyValueRegex),
+		})
 		if err != nil {
 			utils.ErrorMessage("Cluster name is required")
-			return errors.New("cluster name is required")
+			return err
 		}
 		clusterName = result
 	}
@@ -64,11 +73,10 @@ func createCluster(cmd *cobra.Command, args []string) error {
 	civoAPIKey := getCivoAPIKey()
 
 	if civoRegion == "" {
-		regions := getCivoRegion(civoAPIKey)
-		result, err := utils.PromptSelect("Select region", regions)
+		result, err := selectCivoRegion(civoAPIKey)
 		if err != nil {
 			utils.ErrorMessage("Region selection is required")
-			return errors.New("region selection is required")
+			return err
 		}
 		civoRegion = result
 	}
@@ -101,6 +109,14 @@ func createCluster(cmd *cobra.Command, args []string) error {
 	}
 	utils.SuccessMessage(fmt.Sprintf("Cluster '%s' creation initiated, it will be ready in a few minutes", cluster.Name))
 
+	// Installing needs a ready cluster unless --wait=false is given, and install takes care of connecting to it
+	if installAfterCreate {
+		if !cmd.Flags().Changed("wait") {
+			waitForReady = true
+		}
+		connectToCivoCluster = false
+	}
+
 	if waitForReady {
 		// Wait for cluster readiness
 		utils.InfoMessage(fmt.Sprintf("Waiting for cluster '%s' to be ready...", cluster.Name))
@@ -109,8 +125,18 @@ func createCluster(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		// sleep for 20 seconds to ensure cluster is fully registered
-		time.Sleep(20 * time.Second)
+		// The API server answers a little after the cluster is reported ready
+		err := utils.WaitForClusterAPI(func() ([]byte, error) {
+			status, err := client.GetKubernetesCluster(cluster.ID)
+			if err != nil {
+				return nil, err
+			}
+			return []byte(status.KubeConfig), nil
+		}, 5*time.Minute)
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("The API server of cluster '%s' does not answer: %v", cluster.Name, err))
+			return err
+		}
 
 		// Instead of duplicating connection logic, use the connect command
 		if connectToCivoCluster {
@@ -125,38 +151,84 @@ func createCluster(cmd *cobra.Command, args []string) error {
 
 	}
 
+	if installAfterCreate {
+		// create doesn't register the install flags, so fall back to the install defaults
+		if ingressController == "" {
+			ingressController = "traefik"
+		}
+		if sslIssuer == "" {
+			sslIssuer = "letsencrypt-grapple-demo"
+		}
+		err = runInstallStepByStep(cmd, args)
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to install Grapple: %v", err))
+			return err
+		}
+	}
+
 	return nil
 }
 
 // Check if a cluster already exists
 func checkClusterExists(client *civogo.Client, name string) (bool, error) {
-	clusters, err := client.ListKubernetesClusters()
+	cluster, err := findCivoCluster(client, name)
 	if err != nil {
 		return false, fmt.Errorf("error fetching clusters: %w", err)
 	}
-	for _, cluster := range clusters.Items {
-		if cluster.Name == name {
-			return true, nil
-		}
-	}
-	return false, nil
+	return cluster != nil, nil
 }
 
 // Create a new Civo cluster
 func createCivoCluster(client *civogo.Client) (*civogo.KubernetesCluster, error) {
 
+	if kubernetesVersion != "" {
+		if err := validateKubernetesVersion(client, kubernetesVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	var networkID string
+	if network != "" {
+		net, err := client.FindNetwork(network)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find network '%s': %w", network, err)
+		}
+		networkID = net.ID
+		utils.InfoMessage(fmt.Sprintf("Using network %s (%s)", net.Label, net.ID))
+	}
+
 	applications = fmt.Sprintf("-traefik2-nodeport,%s", applications)
 	config := &civogo.KubernetesClusterConfig{
-		Name:            clusterName,
-		NumTargetNodes:  nodes,
-		TargetNodesSize: size,
-		Applications:    applications,
-		Region:          civoRegion,
-		FirewallRule:    "80,443,6443",
+		Name:              clusterName,
+		NumTargetNodes:    nodes,
+		TargetNodesSize:   size,
+		KubernetesVersion: kubernetesVersion,
+		NetworkID:         networkID,
+		Applications:      applications,
+		Region:            civoRegion,
+		FirewallRule:      "80,443,6443",
 	}
 	cluster, err := client.NewKubernetesClusters(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cluster: %w", err)
 	}
+	forgetCivoClusters(client)
 	return cluster, nil
 }
+
+// Check that the requested Kubernetes version is offered by Civo
+func validateKubernetesVersion(client *civogo.Client, version string) error {
+	versions, err := client.ListAvailableKubernetesVersions()
+	if err != nil {
+		return fmt.Errorf("failed to list kubernetes versions: %w", err)
+	}
+
+	var available []string
+	for _, v := range versions {
+		if v.Version == version {
+			return nil
+		}
+		available = append(available, v.Version)
+	}
+	return fmt.Errorf("kubernetes version '%s' is not available, choose one of: %s", version, strings.Join(available, ", "))
+}
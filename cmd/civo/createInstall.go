@@ -2,7 +2,9 @@ package civo
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/grapple-solution/grapple_cli/installer"
 	"github.com/grapple-solution/grapple_cli/utils"
 	"github.com/spf13/cobra"
 )
@@ -14,7 +16,8 @@ var CreateInstallCmd = &cobra.Command{
 	Short:   "Create a Kubernetes cluster in Civo and Install Grapple on a Civo Kubernetes cluster (step by step)",
 	Long: `Create a Kubernetes cluster in Civo and Install Grapple on a Civo Kubernetes cluster (step by step).
 This command combines the functionality of 'create' and 'install' commands.`,
-	RunE: runCreateInstall,
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE:        runCreateInstall,
 }
 
 func init() {
@@ -26,19 +29,35 @@ func init() {
 	CreateInstallCmd.Flags().StringVar(&applications, "applications", "civo-cluster-autoscaler,metrics-server", "Applications to install")
 	CreateInstallCmd.Flags().IntVarP(&nodes, "nodes", "n", 3, "Number of nodes")
 	CreateInstallCmd.Flags().StringVar(&size, "size", "g4s.kube.medium", "Node size")
+	CreateInstallCmd.Flags().StringVar(&kubernetesVersion, "kubernetes-version", "", "Kubernetes version of the cluster (default: Civo's default version)")
+	CreateInstallCmd.Flags().StringVar(&network, "network", "", "Name or ID of the Civo network to create the cluster in (default: the region's default network)")
 
 	// Install command flags
 	CreateInstallCmd.Flags().StringVar(&grappleVersion, "grapple-version", "latest", "Version of Grapple to install")
 	CreateInstallCmd.Flags().StringVar(&grappleDNS, "grapple-dns", "", "Domain for Grapple")
 	CreateInstallCmd.Flags().StringVar(&organization, "organization", "", "Organization name")
 	CreateInstallCmd.Flags().BoolVar(&installKubeblocks, "install-kubeblocks", false, "Install Kubeblocks in background")
+	CreateInstallCmd.Flags().BoolVar(&utils.OfflineMode, "offline", false, "Resolve charts only from the chart cache, see 'grapple cache pull'")
+	CreateInstallCmd.Flags().BoolVar(&utils.SkipPreflight, "skip-preflight", false, "Skip the preflight checks of the cluster, see 'grapple preflight'")
+	CreateInstallCmd.Flags().BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "Remove the releases and namespaces created by this installation if it fails")
+	CreateInstallCmd.Flags().BoolVar(&utils.ClusterLogEnabled, "log-to-cluster", false, "Mirror the sanitized install log to the grpl-install-log ConfigMap for support")
 	CreateInstallCmd.Flags().BoolVar(&waitForReady, "wait", false, "Wait for Grapple to be fully ready at the end")
 	CreateInstallCmd.Flags().BoolVar(&sslEnable, "ssl", false, "Enable SSL usage")
 	CreateInstallCmd.Flags().StringVar(&sslIssuer, "ssl-issuer", "letsencrypt-grapple-demo", "SSL Issuer")
 	CreateInstallCmd.Flags().StringVar(&hostedZoneID, "hosted-zone-id", "", "AWS Route53 Hosted Zone ID (Inside Grapple's account) for DNS management")
+	CreateInstallCmd.Flags().BoolVar(&verifyDomain, "verify-domain", false, "Wait for the ownership and wildcard records of a custom --grapple-dns domain, the ownership code is kept for reruns")
+	CreateInstallCmd.Flags().Bool("skip-domain-check", false, "")
+	_ = CreateInstallCmd.Flags().MarkDeprecated("skip-domain-check", "the domain check is off unless --verify-domain is set")
+	CreateInstallCmd.Flags().DurationVar(&domainCheckTimeout, "domain-check-timeout", 15*time.Minute, "How long to wait for the DNS records of a custom --grapple-dns domain")
 	CreateInstallCmd.Flags().StringVar(&ingressController, "ingress-controller", "traefik", "First checks if an Ingress Controller is already installed, if not, then it can be 'nginx' or 'traefik'")
 	CreateInstallCmd.Flags().StringSliceVar(&additionalValuesFiles, "values", []string{}, "Specify values files to use (can specify multiple times using following format: --values=values1.yaml,values2.yaml)")
+	installer.AddProviderValuesFlag(CreateInstallCmd, &providerValues)
+	CreateInstallCmd.Flags().StringVar(&utils.ValuesSecret, "values-secret", "", "Secret (<namespace>/<name>, default namespace grpl-system) with sensitive values, as values.yaml key or one key per config value")
+	CreateInstallCmd.Flags().StringVar(&utils.ValuesSopsFile, "values-sops", "", "SOPS-encrypted values file, decrypted in memory with sops")
 	CreateInstallCmd.Flags().StringVar(&imagePullSecret, "image-pull-secret", "", "Image pull secret for private repositories")
+	CreateInstallCmd.Flags().StringVar(&utils.PriorityClass, "priority-class", "", "PriorityClass of the grsf components and KubeBlocks, created if it doesn't exist (e.g: --priority-class=grpl-platform)")
+	CreateInstallCmd.Flags().StringToStringVar(&labels, "labels", map[string]string{}, "Labels to add to all generated resources (e.g: --labels=team=platform,cost-center=1234)")
+	CreateInstallCmd.Flags().StringToStringVar(&annotations, "annotations", map[string]string{}, "Annotations to add to all generated resources (e.g: --annotations=owner=platform)")
 
 }
 
@@ -2,15 +2,16 @@ package civo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/civo/civogo"
+	"github.com/grapple-solution/grapple_cli/installer"
 	"github.com/grapple-solution/grapple_cli/utils" // your logging/prompting
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 	"helm.sh/helm/v3/pkg/action"
@@ -36,7 +37,12 @@ var InstallCmd = &cobra.Command{
 	Aliases: []string{"i"},
 	Short:   "Install Grapple on a Civo Kubernetes cluster (step by step)",
 	Long: `Installs Grapple components (grsf-init, grsf, grsf-config, grsf-integration) 
-sequentially, waiting for required resources in between, mirroring the step-by-step logic of your Bash script.`,
+sequentially, waiting for required resources in between, mirroring the step-by-step logic of your Bash script.
+
+With your own domain in --grapple-dns (and no --hosted-zone-id), create a wildcard record *.<domain> pointing to
+the cluster. With --verify-domain the install prints the DNS records to create and waits for a TXT record proving
+the ownership of the domain and for *.<domain> to route to the cluster. The ownership code is kept in
+~/.config/grpl/domain-challenges, so a rerun waits for the same record.`,
 	RunE: runInstallStepByStep,
 }
 
@@ -52,18 +58,33 @@ func init() {
 	InstallCmd.Flags().StringVar(&grappleDNS, "grapple-dns", "", "Domain for Grapple (default: {cluster-name}.grapple-solutions.com)")
 	InstallCmd.Flags().StringVar(&organization, "organization", "", "Organization name (default: grapple-solutions)")
 	InstallCmd.Flags().BoolVar(&installKubeblocks, "install-kubeblocks", false, "Install Kubeblocks in background")
+	InstallCmd.Flags().BoolVar(&utils.OfflineMode, "offline", false, "Resolve charts only from the chart cache, see 'grapple cache pull'")
+	InstallCmd.Flags().BoolVar(&utils.SkipPreflight, "skip-preflight", false, "Skip the preflight checks of the cluster, see 'grapple preflight'")
+	InstallCmd.Flags().BoolVar(&utils.ClusterLogEnabled, "log-to-cluster", false, "Mirror the sanitized install log to the grpl-install-log ConfigMap for support")
+	InstallCmd.Flags().BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "Remove the releases and namespaces created by this installation if it fails")
+	InstallCmd.Flags().BoolVar(&utils.ResumeInstall, "resume", false, "Continue an interrupted installation, skipping the phases it completed")
 	InstallCmd.Flags().BoolVar(&waitForReady, "wait", false, "Wait for Grapple to be fully ready at the end")
 	InstallCmd.Flags().BoolVar(&sslEnable, "ssl", false, "Enable SSL usage")
 	InstallCmd.Flags().StringVar(&sslIssuer, "ssl-issuer", "letsencrypt-grapple-demo", "SSL Issuer")
 	InstallCmd.Flags().StringVar(&hostedZoneID, "hosted-zone-id", "", "AWS Route53 Hosted Zone ID (Inside Grapple's account) for DNS management")
+	InstallCmd.Flags().BoolVar(&verifyDomain, "verify-domain", false, "Wait for the ownership and wildcard records of a custom --grapple-dns domain, the ownership code is kept for reruns")
+	InstallCmd.Flags().Bool("skip-domain-check", false, "")
+	_ = InstallCmd.Flags().MarkDeprecated("skip-domain-check", "the domain check is off unless --verify-domain is set")
+	InstallCmd.Flags().DurationVar(&domainCheckTimeout, "domain-check-timeout", 15*time.Minute, "How long to wait for the DNS records of a custom --grapple-dns domain")
 	InstallCmd.Flags().StringVar(&ingressController, "ingress-controller", "traefik", "First checks if an Ingress Controller is already installed, if not, then it can be 'nginx' or 'traefik'")
 	InstallCmd.Flags().StringSliceVar(&additionalValuesFiles, "values", []string{}, "Specify values files to use (can specify multiple times using following format: --values=values1.yaml,values2.yaml)")
+	installer.AddProviderValuesFlag(InstallCmd, &providerValues)
+	InstallCmd.Flags().StringVar(&utils.ValuesSecret, "values-secret", "", "Secret (<namespace>/<name>, default namespace grpl-system) with sensitive values, as values.yaml key or one key per config value")
+	InstallCmd.Flags().StringVar(&utils.ValuesSopsFile, "values-sops", "", "SOPS-encrypted values file, decrypted in memory with sops")
 	InstallCmd.Flags().StringVar(&imagePullSecret, "image-pull-secret", "", "Image pull secret for private repositories")
+	InstallCmd.Flags().StringVar(&utils.PriorityClass, "priority-class", "", "PriorityClass of the grsf components and KubeBlocks, created if it doesn't exist (e.g: --priority-class=grpl-platform)")
+	InstallCmd.Flags().StringToStringVar(&labels, "labels", map[string]string{}, "Labels to add to all generated resources (e.g: --labels=team=platform,cost-center=1234)")
+	InstallCmd.Flags().StringToStringVar(&annotations, "annotations", map[string]string{}, "Annotations to add to all generated resources (e.g: --annotations=owner=platform)")
 
 }
 
 // runInstallStepByStep is the main function
-func runInstallStepByStep(cmd *cobra.Command, args []string) error {
+func runInstallStepByStep(cmd *cobra.Command, args []string) (installErr error) {
 
 	logFileName := "grpl_civo_install.log"
 	logFilePath := utils.GetLogFilePath(logFileName)
@@ -73,6 +94,7 @@ func runInstallStepByStep(cmd *cobra.Command, args []string) error {
 
 	defer func() {
 		logFile.Sync()
+		utils.MirrorLogToCluster(logFilePath, "civo install")
 		logFile.Close()
 		if err != nil {
 			utils.ErrorMessage(fmt.Sprintf("Failed to install grpl, please run cat %s for more details", logFilePath))
@@ -82,6 +104,8 @@ func runInstallStepByStep(cmd *cobra.Command, args []string) error {
 	// Start logging to both CLI and file
 	logOnCliAndFileStart()
 
+	utils.SetCommonMetadata(labels, annotations)
+
 	connectToCivoCluster := func() error {
 		// Instead of duplicating connection logic, use the connect command
 		err := connectToCluster(cmd, args)
@@ -98,216 +122,92 @@ func runInstallStepByStep(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// If user wants to install Kubeblocks in background:
-	var kubeblocksWg sync.WaitGroup
-	kubeblocksInstallStatus := true
-	var kubeblocksInstallError error
-
-	// Check if flag was not set and not explicitly false
-	if !cmd.Flags().Changed("install-kubeblocks") && !installKubeblocks {
-		// Ask user if they want to install KubeBlocks
-		confirmMsg := "Do you want to install KubeBlocks? (y/N): "
-		confirmed, err := utils.PromptInput(confirmMsg, "n", "^[yYnN]$")
-		if err != nil {
-			return err
-		}
-		if strings.ToLower(confirmed) == "y" {
-			installKubeblocks = true
-		}
+	// Record what this run creates, so a failed installation can be rolled back
+	if err = utils.BeginInstallTransaction(kubeClient, restConfig, utils.ProviderClusterTypeCivo, clusterName, grappleVersion); err != nil {
+		return err
 	}
+	defer func() {
+		utils.FinishInstallTransaction(installErr, rollbackOnFailure)
+	}()
 
-	if installKubeblocks {
-		kubeblocksWg.Add(1)
-		go func() {
-			defer kubeblocksWg.Done()
-			if err := utils.InstallKubeBlocksOnCluster(restConfig); err != nil {
-				utils.ErrorMessage("kubeblocks installation error: " + err.Error())
-				kubeblocksInstallStatus = false
-				kubeblocksInstallError = err
-			} else {
-				utils.InfoMessage("kubeblocks installed.")
-			}
-		}()
+	if err = installer.PromptKubeblocks(cmd, &installKubeblocks); err != nil {
+		return err
 	}
 
-	// Start preloading images in parallel
-	var preloadImagesWg sync.WaitGroup
-	preloadImagesWg.Add(1)
-	var preloadImagesError error
-	go func() {
-		defer preloadImagesWg.Done()
-		if err := utils.PreloadGrappleImages(restConfig, "0.2.8"); err != nil {
-			utils.ErrorMessage("image preload error: " + err.Error())
-			preloadImagesError = err
-		} else {
-			utils.InfoMessage("grapple images preloaded.")
-		}
-	}()
+	err = installer.Run(civoProvider{}, &installer.Installation{
+		ClusterType:       utils.ProviderClusterTypeCivo,
+		ClusterName:       clusterName,
+		GrappleVersion:    grappleVersion,
+		Domain:            completeDomain,
+		Organization:      organization,
+		Email:             civoEmailAddress,
+		License:           grappleLicense,
+		ImagePullSecret:   imagePullSecret,
+		SSL:               sslEnable,
+		SSLIssuer:         sslIssuer,
+		InstallKubeblocks: installKubeblocks,
+		WaitForReady:      waitForReady,
+		AutoConfirm:       autoConfirm,
+		ValuesFiles:       additionalValuesFiles,
+		ProviderValues:    providerValues,
+		Summary: []string{
+			fmt.Sprintf("civo-cluster-id: %s", civoClusterID),
+			fmt.Sprintf("civo-region: %s", civoRegion),
+			fmt.Sprintf("civo-email-address: %s", civoEmailAddress),
+			fmt.Sprintf("cluster-ip: %s", clusterIP),
+		},
+		KubeClient:           kubeClient,
+		RestConfig:           restConfig,
+		LogOnFileStart:       logOnFileStart,
+		LogOnCliAndFileStart: logOnCliAndFileStart,
+	})
+	return err
+}
 
-	if err := prepareValuesFile(); err != nil {
-		return fmt.Errorf("failed to prepare values file: %w", err)
-	}
+// civoProvider is the Civo part of the installation
+type civoProvider struct{}
 
-	if err := setupIngressController(restConfig, logOnFileStart, logOnCliAndFileStart); err != nil {
+func (civoProvider) PrepareCluster(inst *installer.Installation) error {
+	if err := setupIngressController(inst.RestConfig, inst.LogOnFileStart, inst.LogOnCliAndFileStart); err != nil {
 		return fmt.Errorf("failed to setup ingress controller: %w", err)
 	}
+	return nil
+}
 
+func (civoProvider) ClusterIP(inst *installer.Installation) (string, error) {
 	// wait for loadbalancer to be ready
 	utils.InfoMessage("waiting for loadbalancer to be ready...")
-
-	clusterIP, err := utils.GetClusterExternalIP(restConfig, ingressController)
+	ip, err := utils.GetClusterExternalIP(inst.RestConfig, ingressController)
 	if err != nil {
-		return fmt.Errorf("failed to get k3d cluster IP: %w", err)
+		return "", fmt.Errorf("failed to get civo cluster IP: %w", err)
 	}
 	utils.SuccessMessage("Loadbalancer setup completed.")
+	return ip, nil
+}
 
-	valuesFileName := "values-override.yaml"
-	valuesFilePath := filepath.Join(os.TempDir(), valuesFileName)
-	valuesFiles := []string{valuesFilePath}
-	if len(additionalValuesFiles) > 0 {
-		valuesFiles = append(valuesFiles, additionalValuesFiles...)
-	}
-
-	// Step 3) Deploy "grsf-init"
-	utils.InfoMessage("Deploying 'grsf-init' chart...")
-	logOnFileStart()
-	err = utils.HelmDeployGrplReleasesWithRetry(kubeClient, "grsf-init", "grpl-system", grappleVersion, valuesFiles)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("failed to deploy grsf-init: %w", err)
-	}
-
-	utils.InfoMessage("Waiting for grsf-init to be ready...")
-	logOnFileStart()
-	err = utils.WaitForGrsfInit(kubeClient)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("grsf-init not ready: %w", err)
-	}
-	utils.SuccessMessage("grsf-init is installed and ready.")
-
-	// // Step 4) Deploy "grsf"
-	utils.InfoMessage("Deploying 'grsf' chart...")
-	logOnFileStart()
-	err = utils.HelmDeployGrplReleasesWithRetry(kubeClient, "grsf", "grpl-system", grappleVersion, valuesFiles)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("failed to deploy grsf: %w", err)
-	}
-
-	utils.InfoMessage("Waiting for grsf to be ready (checking crossplane providers, etc.)...")
-	logOnFileStart()
-	err = utils.WaitForGrsf(kubeClient, "grpl-system")
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("grsf not ready: %w", err)
-	}
-	utils.SuccessMessage("grsf is installed and ready.")
-
-	// // Step 5) Deploy "grsf-config"
-	utils.InfoMessage("Deploying 'grsf-config' chart...")
-	logOnFileStart()
-	err = utils.HelmDeployGrplReleasesWithRetry(kubeClient, "grsf-config", "grpl-system", grappleVersion, valuesFiles)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("failed to deploy grsf-config: %w", err)
-	}
-
-	utils.InfoMessage("Waiting for grsf-config to be applied (CRDs, XRDs, etc.)...")
-	logOnFileStart()
-	err = utils.WaitForGrsfConfig(kubeClient, restConfig)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("grsf-config not ready: %w", err)
-	}
-	utils.SuccessMessage("grsf-config is installed.")
-
-	// Step 6) Deploy "grsf-integration"
-	utils.InfoMessage("Deploying 'grsf-integration' chart...")
-	logOnFileStart()
-	if err := utils.HelmDeployGrplReleasesWithRetry(kubeClient, "grsf-integration", "grpl-system", grappleVersion, valuesFiles); err != nil {
-		return fmt.Errorf("failed to deploy grsf-integration: %w", err)
-	}
-
-	utils.InfoMessage("Waiting for grsf-integration to be ready...")
-	logOnFileStart()
-	err = utils.WaitForGrsfIntegration(restConfig)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("grsf-integration not ready: %w", err)
-	}
-	utils.SuccessMessage("grsf-integration is installed.")
-
-	// Step 7) SSL enabling (placeholder)
-	if sslEnable {
-		utils.InfoMessage("Enabling SSL (applying clusterissuer, etc.)")
-		logOnFileStart()
-		err = utils.CreateClusterIssuer(restConfig, sslEnable, ingressController)
-		logOnCliAndFileStart()
-		if err != nil {
-			return fmt.Errorf("failed to create clusterissuer: %w", err)
-		}
-		utils.InfoMessage("Successfully created clusterissuer.")
-	}
-
-	// // Step 8) If user wants to wait for the entire Grapple system
-	if waitForReady {
-		utils.InfoMessage("Waiting for Grapple to be ready...")
-		logOnFileStart()
-		err = utils.WaitForGrappleReady(restConfig)
-		logOnCliAndFileStart()
-		if err != nil {
-			return fmt.Errorf("failed to wait for grapple to be ready: %w", err)
-		}
-		utils.SuccessMessage("Grapple is ready!")
-	}
-
-	// 2) If domain is NOT resolvable, create your DNS route53 upsert job (placeholder)
-	if !utils.IsResolvable(utils.ExtractDomain(grappleDNS)) || hostedZoneID != "" {
-		utils.InfoMessage("Domain not resolvable. Creating DNS upsert job...")
-		code := utils.GenerateRandomString()
-		if err := utils.SetupCodeVerificationServer(restConfig, code, completeDomain, "civo"); err != nil {
-			utils.ErrorMessage("Failed to setup code verification server: " + err.Error())
-			return err
-		}
-		if hostedZoneID == "" {
-			hostedZoneID = "Z03015782ZG7K1CRJLN42"
-		}
-		apiURL := "https://4t2skptq3g.execute-api.eu-central-1.amazonaws.com/dev/grpl-route53-dns-manager-v2"
-		if err := utils.UpsertDNSRecord(restConfig, apiURL, completeDomain, code, clusterIP, hostedZoneID, "A"); err != nil {
-			utils.ErrorMessage("Failed to upsert DNS record: " + err.Error())
-			return err
-		}
-	}
-
-	if installKubeblocks {
-		utils.InfoMessage("Waiting for kubeblocks to be ready, it might take a while...")
-		logOnFileStart()
-		kubeblocksWg.Wait()
-		logOnCliAndFileStart()
-		if kubeblocksInstallStatus {
-			utils.SuccessMessage("Kubeblocks installation completed!")
-		} else {
-			utils.ErrorMessage("Kubeblocks installation failed! with error: " + kubeblocksInstallError.Error())
-		}
-	}
-
-	utils.InfoMessage("Waiting for grapple images to be preloaded...")
-	preloadImagesWg.Wait()
-	if preloadImagesError != nil {
-		utils.ErrorMessage("image preload error: " + preloadImagesError.Error())
-	} else {
-		utils.SuccessMessage("Grapple images preloaded.")
-	}
+func (civoProvider) ExtraValues(inst *installer.Installation) (map[string]interface{}, []string, error) {
+	return map[string]interface{}{
+		"config": map[string]interface{}{
+			utils.SecKeyCivoClusterID: civoClusterID,
+			utils.SecKeyCivoRegion:    civoRegion,
+			utils.SecKeyCivoMasterIP:  clusterIP,
+		},
+	}, nil, nil
+}
 
-	if err := utils.RemoveCodeVerificationServer(restConfig); err != nil {
-		utils.ErrorMessage("Failed to remove code verification server: " + err.Error())
-		// Continue execution as this is not a critical error
+func (civoProvider) PostInstall(inst *installer.Installation) error {
+	if err := installer.EnableSSL(inst, ingressController); err != nil {
+		return err
 	}
-
-	utils.SuccessMessage("Grapple installation completed!")
-	return nil
+	return installer.SetupDomainRecords(inst, installer.DomainRecords{
+		Cloud:              "civo",
+		DNS:                grappleDNS,
+		HostedZoneID:       hostedZoneID,
+		VerifyCustomDomain: verifyDomain,
+		VerifyTimeout:      domainCheckTimeout,
+	})
 }
+
 func setupIngressController(restConfig *rest.Config, logOnFileStart, logOnCliAndFileStart func()) error {
 	// Create a k8s client
 	clientset, err := apiv1.NewForConfig(restConfig)
@@ -369,72 +269,6 @@ func setupIngressController(restConfig *rest.Config, logOnFileStart, logOnCliAnd
 	return nil
 }
 
-func prepareValuesFile() error {
-	// Create values map
-	values := map[string]interface{}{
-		"clusterdomain": completeDomain,
-		"config": map[string]interface{}{
-			// Common fields
-			utils.SecKeyEmail:               civoEmailAddress,
-			utils.SecKeyOrganization:        organization,
-			utils.SecKeyClusterdomain:       completeDomain,
-			utils.SecKeyGrapiversion:        "0.0.1",
-			utils.SecKeyGruimversion:        "0.0.1",
-			utils.SecKeyDev:                 "false",
-			utils.SecKeySsl:                 fmt.Sprintf("%v", sslEnable),
-			utils.SecKeySslissuer:           sslIssuer,
-			utils.SecKeyClusterName:         clusterName,
-			utils.SecKeyGrapleDNS:           completeDomain,
-			utils.SecKeyGrapleVersion:       grappleVersion,
-			utils.SecKeyGrapleCliVersion:    utils.GetGrappleCliVersion(),
-			utils.SecKeyGrapleLicense:       grappleLicense,
-			utils.SecKeyProviderClusterType: utils.ProviderClusterTypeCivo,
-
-			// Civo specific fields
-			utils.SecKeyCivoClusterID:   civoClusterID,
-			utils.SecKeyCivoRegion:      civoRegion,
-			utils.SecKeyCivoMasterIP:    clusterIP,
-			utils.SecKeyImagePullSecret: imagePullSecret,
-		},
-	}
-
-	// Marshal to YAML
-	yamlData, err := yaml.Marshal(values)
-	if err != nil {
-		return fmt.Errorf("failed to marshal values to YAML: %w", err)
-	}
-
-	// Write to temp file
-	valuesFileName := "values-override.yaml"
-	valuesFilePath := filepath.Join(os.TempDir(), valuesFileName)
-	if err := os.WriteFile(valuesFilePath, yamlData, 0644); err != nil {
-		return fmt.Errorf("failed to write values file: %w", err)
-	}
-
-	// Print values if needed
-	if !autoConfirm {
-		utils.InfoMessage("Going to deploy grpl on CIVO with following configurations")
-
-		utils.InfoMessage(fmt.Sprintf("civo-cluster-id: %s", civoClusterID))
-		utils.InfoMessage(fmt.Sprintf("cluster-name: %s", clusterName))
-		utils.InfoMessage(fmt.Sprintf("civo-region: %s", civoRegion))
-		utils.InfoMessage(fmt.Sprintf("civo-email-address: %s", civoEmailAddress))
-		utils.InfoMessage(fmt.Sprintf("cluster-ip: %s", clusterIP))
-		utils.InfoMessage(fmt.Sprintf("grapple-version: %s", grappleVersion))
-		utils.InfoMessage(fmt.Sprintf("grapple-dns: %s", completeDomain))
-		utils.InfoMessage(fmt.Sprintf("grapple-license: %s", grappleLicense))
-		utils.InfoMessage(fmt.Sprintf("organization: %s", organization))
-		utils.InfoMessage(fmt.Sprintf("email: %s", civoEmailAddress))
-		utils.InfoMessage(fmt.Sprintf("image-pull-secret: %s", imagePullSecret))
-
-		if confirmed, err := utils.PromptConfirm("Proceed with deployment using the values above?"); err != nil || !confirmed {
-			return fmt.Errorf("failed to install grpl: user cancelled")
-		}
-	}
-
-	return nil
-}
-
 // -----------------------------------------------------------------------------
 // initClientsAndConfig: does the following:
 // 1) Create a civo client from flags
@@ -460,11 +294,10 @@ func initClientsAndConfig(connectToCivoCluster func() error) (apiv1.Interface, *
 
 		// Get CIVO region if not provided
 		if civoRegion == "" {
-			regions := getCivoRegion(civoAPIKey)
-			result, err := utils.PromptSelect("Select region", regions)
+			result, err := selectCivoRegion(civoAPIKey)
 			if err != nil {
 				utils.ErrorMessage("Region selection is required")
-				return nil, nil, fmt.Errorf("region selection is required")
+				return nil, nil, err
 			}
 			civoRegion = result
 		}
@@ -476,17 +309,7 @@ func initClientsAndConfig(connectToCivoCluster func() error) (apiv1.Interface, *
 
 		// Get cluster info
 		if clusterName == "" {
-			clusters, err := client.ListKubernetesClusters()
-			if err != nil {
-				return nil, nil, fmt.Errorf("failed to list clusters: %w", err)
-			}
-
-			clusterNames := make([]string, len(clusters.Items))
-			for i, c := range clusters.Items {
-				clusterNames[i] = c.Name
-			}
-
-			result, err := utils.PromptSelect("Select CIVO cluster", clusterNames)
+			result, err := selectCivoCluster(client, "Select CIVO cluster")
 			if err != nil {
 				return nil, nil, fmt.Errorf("failed to select cluster: %w", err)
 			}
@@ -525,7 +348,12 @@ func initClientsAndConfig(connectToCivoCluster func() error) (apiv1.Interface, *
 
 		// Get CIVO email address if not provided
 		if civoEmailAddress == "" {
-			result, err := utils.PromptInput("Enter CIVO email address", utils.DefaultValue, utils.EmailRegex)
+			result, err := prompt.Input(prompt.Question{
+				Label:    "Enter CIVO email address",
+				Flag:     "--civo-email-address",
+				History:  "email-address",
+				Validate: prompt.MatchRegex(utils.EmailRegex),
+			})
 			if err != nil {
 				return nil, nil, fmt.Errorf("failed to get email address: %w", err)
 			}
@@ -569,103 +397,53 @@ func initClientsAndConfig(connectToCivoCluster func() error) (apiv1.Interface, *
 		utils.InfoMessage("Retrieving cluster IP from kubectl cluster-info")
 		utils.InfoMessage("Waiting for cluster IP to be ready (30 seconds max)")
 
-		timeout := 30
-		elapsed := 0
 		var clusterIP string
-		for clusterIP == "" && elapsed < timeout {
+		err = utils.Poll(30*time.Second, func() (bool, error) {
 			nodes, err := k8sClient.CoreV1().Nodes().List(context.Background(), v1.ListOptions{})
 			if err != nil {
-				return nil, nil, fmt.Errorf("failed to list nodes: %w", err)
+				return false, fmt.Errorf("failed to list nodes: %w", err)
 			}
-
 			for _, node := range nodes.Items {
 				for _, addr := range node.Status.Addresses {
 					if addr.Type == "ExternalIP" {
 						clusterIP = addr.Address
-						break
+						return true, nil
 					}
 				}
-				if clusterIP != "" {
-					break
-				}
-			}
-
-			if clusterIP == "" {
-				time.Sleep(10 * time.Second)
-				elapsed += 10
 			}
+			return false, nil
+		})
+		if err != nil && !errors.Is(err, utils.ErrTimeout) {
+			return nil, nil, err
 		}
 
 		if clusterIP == "" {
 			utils.InfoMessage("")
 			utils.InfoMessage("Unable to retrieve cluster IP within 30 seconds")
 		}
-
-		time.Sleep(2 * time.Second)
-	}
-
-	if grappleVersion == "" || grappleVersion == "latest" {
-		grappleVersion = "0.3.5"
-	}
-
-	// Define grappleDomain variable
-	var grappleDomain string
-
-	// Check if a full domain name was passed in grappleDNS
-	if grappleDNS != "" {
-		if !utils.IsResolvable(utils.ExtractDomain(grappleDNS)) {
-			utils.InfoMessage(fmt.Sprintf("DNS name %s is not a FQDN", grappleDNS))
-			grappleDomain = ".grapple-demo.com"
-		} else if hostedZoneID == "" {
-			utils.InfoMessage("Make sure you have a wildcard entry for your domain e.g *.<your-domain> in your hosted zone and it points to the current cluster. If it doesn't then the dns won't work")
-		}
 	}
 
-	// Set default grappleDNS if empty
-	if grappleDNS == "" {
-		grappleDNS = clusterName
-		grappleDomain = ".grapple-demo.com"
-	}
-
-	// Set default organization if empty
+	grappleVersion = installer.GrappleVersion(grappleVersion)
+	grappleDNS, completeDomain = installer.ResolveDomain(grappleDNS, clusterName, hostedZoneID, verifyDomain)
 	if organization == "" {
-		organization = "grapple solutions AG"
-	}
-
-	// Create complete domain
-	if utils.IsResolvable(utils.ExtractDomain(grappleDNS)) {
-		completeDomain = grappleDNS
-	} else {
-		completeDomain = grappleDNS + grappleDomain
-	}
-
-	// Get license from grsf-config secret if it exists, otherwise use "free"
-	secret, err := k8sClient.CoreV1().Secrets("grpl-system").Get(context.Background(), "grsf-config", v1.GetOptions{})
-	if err != nil {
-		grappleLicense = "free"
-	} else {
-		if licBytes, ok := secret.Data["LIC"]; !ok || len(licBytes) == 0 {
-			grappleLicense = "free"
-		} else {
-			grappleLicense = string(licBytes)
-		}
+		organization = installer.DefaultOrganization
 	}
+	// Reinstalls keep the license of the grsf-config secret
+	grappleLicense = installer.ExistingLicense(k8sClient)
 
 	return k8sClient, restConfig, nil
 }
 
 // findClusterByName attempts to get a cluster by listing and matching name
 func findClusterByName(client *civogo.Client, name string) (*civogo.KubernetesCluster, error) {
-	list, err := client.ListKubernetesClusters()
+	cluster, err := findCivoCluster(client, name)
 	if err != nil {
 		return nil, err
 	}
-	for _, c := range list.Items {
-		if c.Name == name {
-			return &c, nil
-		}
+	if cluster == nil {
+		return nil, fmt.Errorf("no cluster found with name '%s'", name)
 	}
-	return nil, fmt.Errorf("no cluster found with name '%s'", name)
+	return cluster, nil
 }
 
 // setupTraefik installs Traefik as a load balancer in the Kubernetes cluster
@@ -756,7 +534,7 @@ func setupTraefik(restConfig *rest.Config) error {
 		chartPath, err := installClient.ChartPathOptions.LocateChart("traefik/traefik", settings)
 		if err != nil {
 			utils.ErrorMessage("Failed to locate Traefik chart: " + err.Error())
-			return err
+			return fmt.Errorf("%w: %w", utils.ErrChartNotFound, err)
 		}
 
 		// Load chart
@@ -786,7 +564,7 @@ func setupTraefik(restConfig *rest.Config) error {
 		}
 
 		// Install chart
-		_, err = installClient.Run(chart, values)
+		_, err = installClient.RunWithContext(utils.CommandContext(), chart, values)
 		if err != nil {
 			utils.ErrorMessage("Failed to install Traefik: " + err.Error())
 			return err
@@ -886,7 +664,7 @@ func setupNginx(restConfig *rest.Config) error {
 		chartPath, err := installClient.ChartPathOptions.LocateChart("ingress-nginx/ingress-nginx", settings)
 		if err != nil {
 			utils.ErrorMessage("Failed to locate NGINX Ingress chart: " + err.Error())
-			return err
+			return fmt.Errorf("%w: %w", utils.ErrChartNotFound, err)
 		}
 
 		// Load chart
@@ -911,7 +689,7 @@ func setupNginx(restConfig *rest.Config) error {
 		}
 
 		// Install chart
-		_, err = installClient.Run(chart, values)
+		_, err = installClient.RunWithContext(utils.CommandContext(), chart, values)
 		if err != nil {
 			utils.ErrorMessage("Failed to install NGINX Ingress Controller: " + err.Error())
 			return err
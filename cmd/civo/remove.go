@@ -2,7 +2,6 @@ package civo
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,10 +9,17 @@ import (
 	"github.com/civo/civogo"
 	"github.com/grapple-solution/grapple_cli/utils"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
+var (
+	uninstallGrappleFirst bool
+	uninstallOnly         bool
+	releaseTimeout        time.Duration
+)
+
 // RemoveCmd represents the remove command
 var RemoveCmd = &cobra.Command{
 	Use:     "remove",
@@ -22,6 +28,12 @@ var RemoveCmd = &cobra.Command{
 	Long: `Remove command will clean up and delete all resources associated with 
 the Kubernetes cluster from the supplier account
 
+Before the cluster is destroyed Grapple is uninstalled from it, the load balancers and volumes of the
+Grapple namespaces (grpl-system, kb-system, ingress-nginx and the namespaces of GrappleApplicationSets)
+are released and the command waits until the cluster has none left, so that no billable resources are
+left behind. Use --uninstall-only to remove Grapple but keep the cluster, its load balancers and volumes
+are then left alone.
+
 This ensures a complete cleanup of all cluster-related resources.`,
 	RunE: runRemove,
 }
@@ -31,6 +43,9 @@ func init() {
 	RemoveCmd.Flags().StringVar(&civoRegion, "civo-region", "", "Civo region")
 	RemoveCmd.Flags().StringVar(&clusterName, "cluster-name", "", "Civo cluster name")
 	RemoveCmd.Flags().BoolVarP(&skipConfirmation, "yes", "y", false, "Skip confirmation prompt before removing cluster")
+	RemoveCmd.Flags().BoolVar(&uninstallGrappleFirst, "uninstall-grapple", true, "Uninstall Grapple and release volumes and load balancers before destroying the cluster")
+	RemoveCmd.Flags().BoolVar(&uninstallOnly, "uninstall-only", false, "Only uninstall Grapple and keep the cluster with its load balancers and volumes")
+	RemoveCmd.Flags().DurationVar(&releaseTimeout, "release-timeout", 5*time.Minute, "How long to wait for volumes and load balancers to be released")
 }
 
 func isProtectedCluster(clusterName string) bool {
@@ -76,7 +91,7 @@ func runRemove(cmd *cobra.Command, args []string) error {
 
 	logFileName := "grpl_civo_remove.log"
 	logFilePath := utils.GetLogFilePath(logFileName)
-	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+	logFile, logOnFileStart, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
 
 	var err error
 
@@ -113,7 +128,7 @@ func runRemove(cmd *cobra.Command, args []string) error {
 
 	civoAPIKey := getCivoAPIKey()
 
-	if autoConfirm {
+	if autoConfirm && clientset != nil {
 		if getClusterDetailsFromConfig(clientset) {
 			// Check if the currently connected cluster is protected
 			if isProtectedCluster(clusterName) {
@@ -127,11 +142,10 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	}
 
 	if civoRegion == "" {
-		regions := getCivoRegion(civoAPIKey)
-		result, err := utils.PromptSelect("Select region", regions)
+		result, err := selectCivoRegion(civoAPIKey)
 		if err != nil {
 			utils.ErrorMessage("Region selection is required")
-			return errors.New("region selection is required")
+			return err
 		}
 		civoRegion = result
 	}
@@ -144,37 +158,23 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Get list of clusters first to verify existence
-	clusters, err := client.ListKubernetesClusters()
-	if err != nil {
-		utils.ErrorMessage(fmt.Sprintf("Failed to list clusters: %v", err))
-		return err
-	}
-
 	if clusterName == "" {
-		var clusterNames []string
-		for _, cluster := range clusters.Items {
-			clusterNames = append(clusterNames, cluster.Name)
-		}
-		if len(clusterNames) == 0 {
-			utils.ErrorMessage("No clusters found in region " + civoRegion)
-			return errors.New("no clusters found in region " + civoRegion)
-		}
-		result, err := utils.PromptSelect("Select cluster to remove", clusterNames)
+		result, err := selectCivoCluster(client, "Select cluster to remove")
 		if err != nil {
 			utils.ErrorMessage("Cluster selection is required")
-			return errors.New("cluster selection is required")
+			return err
 		}
 		clusterName = result
 	}
 
-	var targetCluster *civogo.KubernetesCluster
-	for _, cluster := range clusters.Items {
-		if cluster.Name == clusterName {
-			utils.InfoMessage(fmt.Sprintf("Cluster %s found in region %s", clusterName, civoRegion))
-			targetCluster = &cluster
-			break
-		}
+	// Verify the cluster exists
+	targetCluster, err := findCivoCluster(client, clusterName)
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to list clusters: %v", err))
+		return err
+	}
+	if targetCluster != nil {
+		utils.InfoMessage(fmt.Sprintf("Cluster %s found in region %s", clusterName, civoRegion))
 	}
 
 	if targetCluster == nil {
@@ -192,6 +192,9 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	// Ask for confirmation unless --yes flag is set
 	if !skipConfirmation {
 		confirmMsg := fmt.Sprintf("Are you sure you want to delete cluster '%s' in region '%s'? This action cannot be undone (y/N): ", clusterName, civoRegion)
+		if uninstallOnly {
+			confirmMsg = fmt.Sprintf("Are you sure you want to uninstall Grapple from cluster '%s' in region '%s'? This will remove all Grapple components and data (y/N): ", clusterName, civoRegion)
+		}
 		confirmed, err := utils.PromptInput(confirmMsg, "n", "^[yYnN]$")
 		if err != nil {
 			return err
@@ -202,6 +205,22 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if uninstallGrappleFirst || uninstallOnly {
+		err = cleanupGrapple(cmd, args, client, targetCluster, logOnFileStart, logOnCliAndFileStart)
+		if err != nil {
+			if uninstallOnly {
+				return err
+			}
+			utils.ErrorMessage(fmt.Sprintf("Grapple cleanup did not complete, continuing with cluster deletion: %v", err))
+			err = nil
+		}
+	}
+
+	if uninstallOnly {
+		utils.SuccessMessage(fmt.Sprintf("Grapple removed from cluster %s, the cluster was kept", clusterName))
+		return nil
+	}
+
 	utils.InfoMessage(fmt.Sprintf("Deleting cluster %s...", clusterName))
 	// Delete the cluster using Civo API
 	_, err = client.DeleteKubernetesCluster(targetCluster.ID)
@@ -209,19 +228,21 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		utils.ErrorMessage(fmt.Sprintf("Failed to delete cluster: %v", err))
 		return err
 	}
+	forgetCivoClusters(client)
 	// Wait and verify deletion
 	maxRetries := 10
 	for i := 0; i < maxRetries; i++ {
 		time.Sleep(10 * time.Second)
 
-		// Check if cluster still exists
-		clusters, err := client.ListKubernetesClusters()
+		// Check if cluster still exists, on a fresh list
+		forgetCivoClusters(client)
+		clusters, err := listCivoClusters(client)
 		if err != nil {
 			continue
 		}
 
 		clusterExists := false
-		for _, cluster := range clusters.Items {
+		for _, cluster := range clusters {
 			if cluster.ID == targetCluster.ID {
 				clusterExists = true
 				break
@@ -240,3 +261,154 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	utils.SuccessMessage(fmt.Sprintf("Delete request sent for cluster %s. The cluster should be removed shortly.", clusterName))
 	return nil
 }
+
+// grappleNamespaces are the namespaces of the workloads Grapple installs on Civo clusters, the namespaces of
+// the GrappleApplicationSets are added to them
+var grappleNamespaces = []string{"grpl-system", "kb-system", "ingress-nginx"}
+
+// cleanupGrapple uninstalls Grapple from the target cluster. When the cluster is removed as well, the volumes
+// and load balancers created for Grapple are released first, so that they are not left behind in the Civo
+// account once the cluster is gone.
+func cleanupGrapple(cmd *cobra.Command, args []string, client *civogo.Client, targetCluster *civogo.KubernetesCluster, logOnFileStart, logOnCliAndFileStart func()) error {
+
+	// Always switch to the cluster being removed, the current context may point to another cluster
+	utils.InfoMessage(fmt.Sprintf("Connecting to cluster %s to uninstall Grapple...", clusterName))
+	if err := connectToCluster(cmd, args); err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+
+	restConfig, clientset, err := utils.GetKubernetesConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get kubernetes config: %w", err)
+	}
+	// The GRAS namespaces are listed before the uninstall removes their CRD
+	namespaces := append([]string{}, grappleNamespaces...)
+	if gras, err := utils.ListGrasNames(restConfig, ""); err == nil {
+		for ns := range gras {
+			if !utils.Contains(namespaces, ns) {
+				namespaces = append(namespaces, ns)
+			}
+		}
+	}
+
+	connectToCivoCluster := func() error {
+		return connectToCluster(cmd, args)
+	}
+	if err := utils.UninstallGrapple(connectToCivoCluster, logOnFileStart, logOnCliAndFileStart); err != nil {
+		return fmt.Errorf("failed to uninstall grapple: %w", err)
+	}
+
+	if uninstallOnly {
+		return nil
+	}
+	released := releaseClusterResources(clientset, namespaces)
+
+	return waitForCloudResourcesReleased(client, targetCluster.ID, released)
+}
+
+// releaseClusterResources deletes the LoadBalancer services and persistent volume claims of the Grapple
+// namespaces, which makes the Civo cloud controller and CSI driver release the backing load balancers and
+// volumes. Resources of other namespaces are left to the deletion of the cluster.
+func releaseClusterResources(clientset *kubernetes.Clientset, namespaces []string) releasedResources {
+	var released releasedResources
+	for _, ns := range namespaces {
+		services, err := clientset.CoreV1().Services(ns).List(context.TODO(), v1.ListOptions{})
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to list services of %s: %v", ns, err))
+		} else {
+			for _, svc := range services.Items {
+				if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+					continue
+				}
+				utils.InfoMessage(fmt.Sprintf("Deleting load balancer service %s/%s...", svc.Namespace, svc.Name))
+				if err := clientset.CoreV1().Services(svc.Namespace).Delete(context.TODO(), svc.Name, v1.DeleteOptions{}); err != nil {
+					utils.ErrorMessage(fmt.Sprintf("Failed to delete service %s/%s: %v", svc.Namespace, svc.Name, err))
+					continue
+				}
+				released.services = append(released.services, svc.Namespace+"/"+svc.Name)
+			}
+		}
+
+		pvcs, err := clientset.CoreV1().PersistentVolumeClaims(ns).List(context.TODO(), v1.ListOptions{})
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to list persistent volume claims of %s: %v", ns, err))
+			continue
+		}
+		for _, pvc := range pvcs.Items {
+			utils.InfoMessage(fmt.Sprintf("Deleting persistent volume claim %s/%s...", pvc.Namespace, pvc.Name))
+			if err := clientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Delete(context.TODO(), pvc.Name, v1.DeleteOptions{}); err != nil {
+				utils.ErrorMessage(fmt.Sprintf("Failed to delete persistent volume claim %s/%s: %v", pvc.Namespace, pvc.Name, err))
+				continue
+			}
+			if pvc.Spec.VolumeName != "" {
+				released.volumes = append(released.volumes, pvc.Spec.VolumeName)
+			}
+		}
+	}
+	return released
+}
+
+// releasedResources are the services (namespace/name) and persistent volumes whose cloud resources
+// releaseClusterResources released
+type releasedResources struct {
+	services []string
+	volumes  []string
+}
+
+// hasLoadBalancer reports whether a Civo load balancer belongs to one of the released services, its service
+// name is the name of the service with or without its namespace
+func (r releasedResources) hasLoadBalancer(lb civogo.LoadBalancer) bool {
+	for _, svc := range r.services {
+		if lb.ServiceName == svc || strings.HasSuffix(svc, "/"+lb.ServiceName) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForCloudResourcesReleased polls the Civo API until none of the released volumes and load balancers of the
+// cluster is left
+func waitForCloudResourcesReleased(client *civogo.Client, clusterID string, released releasedResources) error {
+	if len(released.services) == 0 && len(released.volumes) == 0 {
+		return nil
+	}
+	utils.InfoMessage("Waiting for volumes and load balancers to be released...")
+
+	progress := utils.StartWaitProgress("volumes and load balancers to be released", releaseTimeout)
+	defer progress.Stop()
+
+	var remaining []string
+	deadline := time.Now().Add(releaseTimeout)
+	for time.Now().Before(deadline) {
+		remaining = nil
+
+		volumes, err := client.ListVolumesForCluster(clusterID)
+		if err != nil {
+			return fmt.Errorf("failed to list volumes: %w", err)
+		}
+		for _, volume := range volumes {
+			if !utils.Contains(released.volumes, volume.Name) {
+				continue
+			}
+			remaining = append(remaining, fmt.Sprintf("volume %s (%s)", volume.Name, volume.ID))
+		}
+
+		loadBalancers, err := client.ListLoadBalancers()
+		if err != nil {
+			return fmt.Errorf("failed to list load balancers: %w", err)
+		}
+		for _, lb := range loadBalancers {
+			if lb.ClusterID == clusterID && released.hasLoadBalancer(lb) {
+				remaining = append(remaining, fmt.Sprintf("load balancer %s (%s)", lb.Name, lb.ID))
+			}
+		}
+
+		if len(remaining) == 0 {
+			progress.Done("All volumes and load balancers have been released")
+			return nil
+		}
+		time.Sleep(10 * time.Second)
+	}
+
+	return fmt.Errorf("%w waiting for resources to be released, remove them manually from the Civo dashboard: %s", utils.ErrTimeout, strings.Join(remaining, ", "))
+}
@@ -0,0 +1,27 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package cluster
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// ClusterCmd represents the cluster command
+var ClusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Manage the kubeconfig contexts of your clusters",
+	Long: `Manage the contexts of ~/.kube/config: list them, switch the active one and connect new clusters of
+civo, k3d, DigitalOcean, GKE or any other provider (e.g. EKS) by merging their kubeconfig.
+
+Every change of the kubeconfig is backed up to ~/.kube/config.grpl-backup-<time> first, the last 5
+backups are kept. To run a single command on another context without switching, use the global
+--kube-context flag; --kubeconfig uses another kubeconfig file, and a KUBECONFIG list of files is merged
+like kubectl does.`,
+}
+
+func init() {
+	ClusterCmd.AddCommand(ListCmd)
+	ClusterCmd.AddCommand(UseCmd)
+	ClusterCmd.AddCommand(ConnectCmd)
+}
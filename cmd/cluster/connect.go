@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/cmd/civo"
+	"github.com/grapple-solution/grapple_cli/cmd/doks"
+	"github.com/grapple-solution/grapple_cli/cmd/gke"
+	"github.com/grapple-solution/grapple_cli/cmd/k3d"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	kubeconfigFile string
+	contextName    string
+)
+
+// ConnectCmd represents the cluster connect command
+var ConnectCmd = &cobra.Command{
+	Use:   "connect",
+	Short: "Merge the kubeconfig of a cluster into ~/.kube/config and switch to it",
+	Long: `Connect a cluster by merging its kubeconfig into ~/.kube/config, switching to its context and
+checking the access to it. The kubeconfig is backed up before it is changed.
+
+Clusters of civo, k3d, DigitalOcean and GKE are connected with their provider subcommand, which takes
+the flags of 'grapple <provider> connect'. The kubeconfig of any other cluster, e.g. one written by
+'aws eks update-kubeconfig --kubeconfig eks.yaml' for EKS, is merged with --file.
+
+Example:
+  grapple cluster connect civo --cluster-name shop --civo-region FRA1
+  grapple cluster connect k3d --cluster-name grpl-dev
+  grapple cluster connect --file eks.yaml --context shop-eks`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if kubeconfigFile == "" {
+			return cmd.Help()
+		}
+
+		newConfig, err := clientcmd.LoadFromFile(kubeconfigFile)
+		if err != nil {
+			return fmt.Errorf("%w: failed to load kubeconfig %s: %w", utils.ErrValidation, kubeconfigFile, err)
+		}
+		name := contextName
+		if name == "" {
+			name = newConfig.CurrentContext
+		}
+		if _, ok := newConfig.Contexts[name]; !ok {
+			return fmt.Errorf("%w: context %q not found in %s, set it with --context", utils.ErrValidation, name, kubeconfigFile)
+		}
+
+		if err := utils.MergeKubeconfig(newConfig, name); err != nil {
+			return err
+		}
+		if err := validateContext(name); err != nil {
+			return err
+		}
+		utils.SuccessMessage(fmt.Sprintf("Connected to context %s", name))
+		return nil
+	},
+}
+
+func init() {
+	ConnectCmd.Flags().StringVar(&kubeconfigFile, "file", "", "Kubeconfig file of the cluster to merge, e.g. of EKS")
+	ConnectCmd.Flags().StringVar(&contextName, "context", "", "Context of --file to switch to (default: the current context of the file)")
+
+	ConnectCmd.AddCommand(providerConnectCmd("civo", civo.ConnectCmd))
+	ConnectCmd.AddCommand(providerConnectCmd("k3d", k3d.ConnectCmd))
+	ConnectCmd.AddCommand(providerConnectCmd("doks", doks.ConnectCmd))
+	ConnectCmd.AddCommand(providerConnectCmd("gke", gke.ConnectCmd))
+}
+
+// providerConnectCmd runs 'grapple <provider> connect' as 'grapple cluster connect <provider>', the flags
+// are parsed by the connect command of the provider
+func providerConnectCmd(provider string, connect *cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:                provider,
+		Short:              connect.Short,
+		Annotations:        connect.Annotations,
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.ParseFlags(args); err != nil {
+				return err
+			}
+			if help, _ := connect.Flags().GetBool("help"); help {
+				return connect.Help()
+			}
+			return connect.RunE(connect, connect.Flags().Args())
+		},
+	}
+}
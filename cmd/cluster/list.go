@@ -0,0 +1,46 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// ListCmd represents the cluster list command
+var ListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the contexts of the kubeconfig",
+	Long: `List the contexts of ~/.kube/config with their cluster, API server and user, the current context is
+marked with *.
+
+Example:
+  grapple cluster list
+  grapple cluster list -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contexts, err := utils.ListKubeContexts()
+		if err != nil {
+			return err
+		}
+		if contexts == nil {
+			contexts = []utils.KubeContextInfo{}
+		}
+
+		return utils.PrintResult(contexts, func() {
+			if len(contexts) == 0 {
+				utils.InfoMessage("No contexts found, connect a cluster using 'grapple cluster connect'")
+				return
+			}
+			fmt.Printf("%-2s %-32s %-32s %-40s %s\n", "", "NAME", "CLUSTER", "SERVER", "NAMESPACE")
+			for _, kubeContext := range contexts {
+				current := ""
+				if kubeContext.Current {
+					current = "*"
+				}
+				fmt.Printf("%-2s %-32s %-32s %-40s %s\n", current, kubeContext.Name, kubeContext.Cluster, kubeContext.Server, kubeContext.Namespace)
+			}
+		})
+	},
+}
@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var skipValidation bool
+
+// UseCmd represents the cluster use command
+var UseCmd = &cobra.Command{
+	Use:   "use [context]",
+	Short: "Switch the current context of the kubeconfig",
+	Long: `Switch the current context of ~/.kube/config, which is used by grapple, kubectl and helm.
+
+The cluster of the context is checked to answer first and the switch is refused if it doesn't, unless
+--skip-validation is set. Without a context, it is selected among the contexts of the kubeconfig.
+
+Example:
+  grapple cluster use k3d-grpl-dev
+  grapple cluster use`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contexts, err := utils.ListKubeContexts()
+		if err != nil {
+			return err
+		}
+		var names []string
+		for _, kubeContext := range contexts {
+			names = append(names, kubeContext.Name)
+		}
+
+		var name string
+		if len(args) == 1 {
+			name = args[0]
+			if !utils.Contains(names, name) {
+				return fmt.Errorf("%w: context %s not found, see 'grapple cluster list'", utils.ErrValidation, name)
+			}
+		} else {
+			if len(names) == 0 {
+				return fmt.Errorf("%w: no contexts found, connect a cluster using 'grapple cluster connect'", utils.ErrValidation)
+			}
+			name, err = utils.PromptSelect("Select context", names)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !skipValidation {
+			if err := validateContext(name); err != nil {
+				return err
+			}
+		}
+		if err := utils.UseKubeContext(name); err != nil {
+			return err
+		}
+		utils.SuccessMessage(fmt.Sprintf("Switched to context %s", name))
+		return nil
+	},
+}
+
+func init() {
+	UseCmd.Flags().BoolVar(&skipValidation, "skip-validation", false, "Switch without checking that the cluster of the context answers")
+}
+
+// validateContext checks the access to the cluster of a context and reports its Kubernetes version
+func validateContext(name string) error {
+	utils.InfoMessage(fmt.Sprintf("Checking the access to the cluster of context %s...", name))
+	version, err := utils.ValidateKubeContext(name)
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("The cluster of context %s is not accessible: %v", name, err))
+		return err
+	}
+	utils.SuccessMessage(fmt.Sprintf("The cluster of context %s answers, Kubernetes %s", name, version))
+	return nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package completion
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// CompletionCmd represents the completion command
+var CompletionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate the shell completion script",
+	Long: `Completion prints the completion script of the given shell. Besides commands and flags, it completes
+namespaces, cluster names and GrappleApplicationSet names by looking them up in the current cluster.
+
+Bash (needs the bash-completion package):
+  source <(grapple completion bash)
+  grapple completion bash > /etc/bash_completion.d/grapple
+
+Zsh:
+  grapple completion zsh > "${fpath[1]}/_grapple"
+
+Fish:
+  grapple completion fish > ~/.config/fish/completions/grapple.fish
+
+PowerShell:
+  grapple completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := cmd.Root()
+		switch args[0] {
+		case "bash":
+			return root.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return root.GenZshCompletion(os.Stdout)
+		case "fish":
+			return root.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			return root.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		return fmt.Errorf("unsupported shell %q", args[0])
+	},
+}
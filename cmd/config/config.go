@@ -0,0 +1,25 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package config
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// ConfigCmd represents the config command
+var ConfigCmd = &cobra.Command{
+	Use:     "config",
+	Aliases: []string{"cfg"},
+	Short:   "Manage the Grapple CLI configuration",
+	Long: `Manage the persistent configuration of the Grapple CLI, stored in ~/.config/grpl/config.yaml.
+
+Values are resolved with the precedence: command-line flag > environment variable > config file.
+Run 'grapple config view' to list the supported keys and where their current values come from.`,
+}
+
+func init() {
+	ConfigCmd.AddCommand(SetCmd)
+	ConfigCmd.AddCommand(GetCmd)
+	ConfigCmd.AddCommand(ViewCmd)
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// GetCmd represents the config get command
+var GetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a config key",
+	Long:  `Print the effective value of a config key, i.e. the environment variable if it is set, otherwise the value from the config file.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := utils.LookupConfigSetting(args[0]); err != nil {
+			return err
+		}
+		value := utils.ConfigValue(args[0])
+		return utils.PrintResult(map[string]string{args[0]: value}, func() {
+			fmt.Println(value)
+		})
+	},
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// SetCmd represents the config set command
+var SetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Store a value in the config file",
+	Long: `Store a value in the config file, an empty value removes the key.
+
+Example:
+  grapple config set grapple-version 0.2.8
+  grapple config set telemetry.enabled true
+  grapple config set civo-api-token ""`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], args[1]
+		if value != "" {
+			switch key {
+			case "package-manager":
+				if err := utils.ValidatePackageManager(value); err != nil {
+					return err
+				}
+			case "cluster-provider":
+				if err := utils.ValidateClusterProvider(value); err != nil {
+					return err
+				}
+			case "telemetry.enabled":
+				if _, err := strconv.ParseBool(value); err != nil {
+					return fmt.Errorf("%w: telemetry.enabled must be true or false", utils.ErrValidation)
+				}
+			}
+		}
+		if err := utils.SetConfigValue(key, value); err != nil {
+			return err
+		}
+
+		path, _ := utils.ConfigFilePath()
+		if value == "" {
+			utils.SuccessMessage(fmt.Sprintf("Removed %s from %s", key, path))
+		} else {
+			utils.SuccessMessage(fmt.Sprintf("Saved %s to %s", key, path))
+		}
+		return nil
+	},
+}
+
+func init() {
+	var keys strings.Builder
+	for _, setting := range utils.ConfigSettings {
+		keys.WriteString(fmt.Sprintf("\n  %-28s %s (env: %s)", setting.Key, setting.Description, setting.Env))
+	}
+	SetCmd.Long += "\n\nSupported keys:" + keys.String()
+}
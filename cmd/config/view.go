@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var showSecrets bool
+
+// configEntry is a single row of 'grapple config view'
+type configEntry struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`
+	Source string `json:"source" yaml:"source"`
+	Env    string `json:"env" yaml:"env"`
+}
+
+// ViewCmd represents the config view command
+var ViewCmd = &cobra.Command{
+	Use:     "view",
+	Aliases: []string{"list", "ls"},
+	Short:   "Show all config keys with their effective values",
+	Long:    `Show all supported config keys, their effective values and whether they come from the environment or the config file. Secrets are masked unless --show-secrets is set.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var entries []configEntry
+		for _, setting := range utils.ConfigSettings {
+			entry := configEntry{Key: setting.Key, Env: setting.Env, Source: utils.ConfigSource(setting)}
+			if entry.Source != "" {
+				entry.Value = utils.ConfigValue(setting.Key)
+			}
+			if setting.Secret && !showSecrets && entry.Value != "" {
+				entry.Value = utils.MaskSecret(entry.Value)
+			}
+			entries = append(entries, entry)
+		}
+
+		path, _ := utils.ConfigFilePath()
+		return utils.PrintResult(entries, func() {
+			fmt.Printf("Config file: %s\n\n", path)
+			fmt.Printf("%-28s %-8s %s\n", "KEY", "SOURCE", "VALUE")
+			for _, entry := range entries {
+				source := entry.Source
+				if source == "" {
+					source = "-"
+				}
+				fmt.Printf("%-28s %-8s %s\n", entry.Key, source, entry.Value)
+			}
+		})
+	},
+}
+
+func init() {
+	ViewCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print API keys and tokens in clear text")
+}
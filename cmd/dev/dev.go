@@ -21,7 +21,9 @@ var DevCmd = &cobra.Command{
 	Short:              "Development commands for Grapple",
 	DisableFlagParsing: true,
 	Long: `Development commands for Grapple including:
-- grapple dev: Start development environment
+- grapple dev: Start development environment, inside a grapple template project it selects the kube-context
+  and namespace, sets the cluster domain and grapi/gruim image tags in devspace.yaml and runs devspace dev
+- grapple dev --namespace my-app --kube-context my-cluster: Start it in the given namespace and kube-context
 - grapple dev ns: Set namespace
 - grapple dev enter [grapi|gruim]: Enter container
 - grapple dev logs: View logs (passes through to devspace logs)
@@ -84,8 +86,8 @@ func runDev(cmd *cobra.Command, args []string) error {
 	}
 
 	// Handle different command scenarios
-	if len(args) == 0 {
-		return runDevspace()
+	if opts, ok := parseDevOptions(args); ok {
+		return startDev(opts)
 	}
 
 	if args[0] == "ns" {
@@ -108,7 +110,12 @@ func printDevHelp() {
 	fmt.Println("Development commands for Grapple including:")
 	fmt.Println()
 	fmt.Println("  grapple dev")
-	fmt.Println("    Start development environment")
+	fmt.Println("    Start development environment: selects the kube-context and namespace, sets the")
+	fmt.Println("    cluster domain and grapi/gruim image tags in devspace.yaml and runs devspace dev")
+	fmt.Println()
+	fmt.Println("  grapple dev [--namespace ns] [--kube-context ctx] [--skip-vars]")
+	fmt.Println("    Start development environment in the given namespace and kube-context,")
+	fmt.Println("    --skip-vars keeps the variables of devspace.yaml as they are")
 	fmt.Println()
 	fmt.Println("  grapple dev ns [namespace]")
 	fmt.Println("    Set or view namespace")
@@ -146,6 +153,7 @@ func runDevspace() error {
 	} else {
 		devCmd = exec.Command("devspace", "dev")
 	}
+	devCmd.Stdin = os.Stdin
 	devCmd.Stdout = os.Stdout
 	devCmd.Stderr = os.Stderr
 	if err := devCmd.Run(); err != nil {
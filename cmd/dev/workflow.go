@@ -0,0 +1,243 @@
+package dev
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/rest"
+)
+
+// devspaceConfigFile is part of every project created from a grapple template
+const devspaceConfigFile = "devspace.yaml"
+
+// devOptions are the flags of grapple dev itself, every other argument is passed through to devspace
+type devOptions struct {
+	namespace   string
+	kubeContext string
+	skipVars    bool
+}
+
+// parseDevOptions returns the options when args only contain flags of the dev workflow
+func parseDevOptions(args []string) (devOptions, bool) {
+	var opts devOptions
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		switch name {
+		case "--namespace", "-n", "--kube-context":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, false
+				}
+				i++
+				value = args[i]
+			}
+			if name == "--kube-context" {
+				opts.kubeContext = value
+			} else {
+				opts.namespace = value
+			}
+		case "--skip-vars":
+			opts.skipVars = true
+		default:
+			return opts, false
+		}
+	}
+	return opts, true
+}
+
+// startDev runs the inner loop of a grapple template project: it selects the kube-context and namespace,
+// updates the variables of devspace.yaml from the cluster and runs devspace dev
+func startDev(opts devOptions) error {
+	if _, err := os.Stat(devspaceConfigFile); err != nil {
+		return fmt.Errorf("no %s found in the current directory, run grapple dev inside a project created with 'grapple application init'", devspaceConfigFile)
+	}
+
+	kubeContext, err := selectKubeContext(opts.kubeContext)
+	if err != nil {
+		return err
+	}
+	if err := runDevspaceQuiet("use", "context", kubeContext); err != nil {
+		return fmt.Errorf("failed to use kube-context %s: %w", kubeContext, err)
+	}
+	utils.InfoMessage(fmt.Sprintf("Using kube-context %s", kubeContext))
+
+	namespace := opts.namespace
+	if namespace == "" {
+		current, _ := getCurrentNamespace()
+		if current != "" && current != "default" {
+			namespace = current
+		} else {
+			suggestion := strings.ToLower(filepath.Base(mustGetwd()))
+			if len(suggestion) > 10 {
+				suggestion = suggestion[:10]
+			}
+			namespace, err = utils.PromptInput("Namespace to develop in", suggestion, utils.NonEmptyValueRegex)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %w", err)
+			}
+		}
+	}
+	if err := handleNamespace([]string{namespace}); err != nil {
+		return err
+	}
+
+	if !opts.skipVars {
+		restConfig, err := utils.KubeContextRESTConfig(kubeContext)
+		if err != nil {
+			utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+			return err
+		}
+		if err := updateDevspaceVars(restConfig); err != nil {
+			return err
+		}
+	}
+
+	return runDevspace()
+}
+
+// selectKubeContext returns the requested context, the current one, or asks when there is no current context
+func selectKubeContext(requested string) (string, error) {
+	config, err := utils.KubeconfigLoadingRules().Load()
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	if requested != "" {
+		if _, ok := config.Contexts[requested]; !ok {
+			return "", fmt.Errorf("kube-context %s not found in kubeconfig", requested)
+		}
+		return requested, nil
+	}
+	if config.CurrentContext != "" {
+		return config.CurrentContext, nil
+	}
+
+	var contexts []string
+	for name := range config.Contexts {
+		contexts = append(contexts, name)
+	}
+	if len(contexts) == 0 {
+		return "", fmt.Errorf("no kube-context found, connect to a cluster first using 'grapple <provider> connect'")
+	}
+	sort.Strings(contexts)
+	return utils.PromptSelect("Select the kube-context to develop in", contexts)
+}
+
+// devspaceVarValues maps the devspace.yaml variables kept in sync with the cluster to their values
+func devspaceVarValues(restConfig *rest.Config) map[string]string {
+	values := map[string]string{}
+
+	domain, err := utils.ExtractDomainFromGrplConfig(restConfig)
+	if err != nil || domain == "" {
+		utils.InfoMessage("Cluster domain not found in grsf-config, is grapple installed? Keeping the domain of devspace.yaml")
+	} else {
+		for _, name := range []string{"DOMAIN", "CLUSTER_DOMAIN", "GRAPPLE_DOMAIN"} {
+			values[name] = domain
+		}
+	}
+
+	version, err := utils.GetGrplReleaseVersion(restConfig, "grsf", "grpl-system")
+	if err != nil || version == "" {
+		version = utils.DefaultGrappleVersion
+	}
+	for _, name := range []string{"GRAPI_IMAGE_TAG", "GRAPI_TAG", "GRUIM_IMAGE_TAG", "GRUIM_TAG"} {
+		values[name] = version
+	}
+	return values
+}
+
+// updateDevspaceVars sets the cluster domain and the grapi/gruim image tags in the vars section of devspace.yaml,
+// only the variables the project declares are touched
+func updateDevspaceVars(restConfig *rest.Config) error {
+	data, err := os.ReadFile(devspaceConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", devspaceConfigFile, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", devspaceConfigFile, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	vars := mappingValue(doc.Content[0], "vars")
+	if vars == nil || vars.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	values := devspaceVarValues(restConfig)
+	changed := false
+	for i := 0; i+1 < len(vars.Content); i += 2 {
+		name := vars.Content[i].Value
+		value, ok := values[strings.ToUpper(name)]
+		if !ok {
+			continue
+		}
+		node := vars.Content[i+1]
+		// A variable is either a plain value or a definition with value or default
+		if node.Kind == yaml.MappingNode {
+			if definition := mappingValue(node, "value"); definition != nil {
+				node = definition
+			} else {
+				node = mappingValue(node, "default")
+			}
+		}
+		if node == nil || node.Kind != yaml.ScalarNode || node.Value == value {
+			continue
+		}
+		utils.InfoMessage(fmt.Sprintf("Setting %s to %s in %s", name, value, devspaceConfigFile))
+		node.Value = value
+		node.Tag = "!!str"
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", devspaceConfigFile, err)
+	}
+	encoder.Close()
+	if err := os.WriteFile(devspaceConfigFile, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", devspaceConfigFile, err)
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a yaml mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func runDevspaceQuiet(args ...string) error {
+	cmd := exec.Command("devspace", args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func mustGetwd() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return wd
+}
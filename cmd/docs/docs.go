@@ -0,0 +1,88 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package docs
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var outputDir string
+
+// DocsCmd represents the docs command
+var DocsCmd = &cobra.Command{
+	Use:    "docs",
+	Short:  "Generate the man pages or the markdown reference of the CLI",
+	Long:   `Generate the documentation of all commands, e.g. to ship man pages with the Homebrew and apt packages.`,
+	Hidden: true,
+}
+
+// ManCmd represents the docs man command
+var ManCmd = &cobra.Command{
+	Use:   "man",
+	Short: "Generate a man page per command",
+	Long: `Generate a man page per command in --dir (section 1).
+
+Example:
+  grapple docs man --dir ./man
+  man ./man/grapple-resource-deploy.1`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := prepareDocs(cmd, "./man")
+		if err != nil {
+			return err
+		}
+		header := &doc.GenManHeader{Title: "GRAPPLE", Section: "1", Source: "Grapple CLI " + utils.GetGrappleCliVersion(), Manual: "Grapple Manual"}
+		if err := doc.GenManTree(root, header, outputDir); err != nil {
+			return fmt.Errorf("failed to generate man pages: %w", err)
+		}
+		utils.SuccessMessage(fmt.Sprintf("Man pages written to %s", outputDir))
+		return nil
+	},
+}
+
+// MarkdownCmd represents the docs markdown command
+var MarkdownCmd = &cobra.Command{
+	Use:   "markdown",
+	Short: "Generate a markdown page per command",
+	Long: `Generate a markdown page per command in --dir, linked to each other.
+
+Example:
+  grapple docs markdown --dir ./docs/cli`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := prepareDocs(cmd, "./docs/cli")
+		if err != nil {
+			return err
+		}
+		if err := doc.GenMarkdownTree(root, outputDir); err != nil {
+			return fmt.Errorf("failed to generate markdown: %w", err)
+		}
+		utils.SuccessMessage(fmt.Sprintf("Markdown reference written to %s", outputDir))
+		return nil
+	},
+}
+
+func init() {
+	ManCmd.Flags().StringVar(&outputDir, "dir", "", "Directory the man pages are written to (default: ./man)")
+	MarkdownCmd.Flags().StringVar(&outputDir, "dir", "", "Directory the markdown pages are written to (default: ./docs/cli)")
+	DocsCmd.AddCommand(ManCmd)
+	DocsCmd.AddCommand(MarkdownCmd)
+}
+
+// prepareDocs creates the output directory and returns the root command, without the generation date so
+// packaged docs are reproducible
+func prepareDocs(cmd *cobra.Command, defaultDir string) (*cobra.Command, error) {
+	if outputDir == "" {
+		outputDir = defaultDir
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", outputDir, err)
+	}
+	root := cmd.Root()
+	root.DisableAutoGenTag = true
+	return root, nil
+}
@@ -0,0 +1,235 @@
+package doks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// Command-line flags
+var (
+	// Cluster creation flags
+	clusterName       string
+	nodePoolName      string
+	nodes             int
+	size              string
+	autoScale         bool
+	minNodes          int
+	maxNodes          int
+	kubernetesVersion string
+
+	// Common flags
+	autoConfirm       bool
+	doRegion          string
+	doEmailAddress    string
+	installKubeblocks bool
+	skipConfirmation  bool
+
+	// Installation specific flags
+	grappleVersion        string
+	doksClusterID         string
+	clusterIP             string
+	grappleDNS            string
+	organization          string
+	waitForReady          bool
+	sslEnable             bool
+	sslIssuer             string
+	completeDomain        string
+	grappleLicense        string
+	hostedZoneID          string
+	ingressController     string
+	additionalValuesFiles []string
+	providerValues        string
+	imagePullSecret       string
+	labels                map[string]string
+	annotations           map[string]string
+)
+
+var (
+	connectToDoksCluster = true
+)
+
+// getDoToken returns the DigitalOcean API token from the environment, the doctl config or a prompt
+func getDoToken() string {
+	token := os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
+	if token == "" {
+		token = getDoTokenFromDoctlConfig()
+
+		if token == "" {
+			// Prompt user for API token if not found
+			utils.InfoMessage("No DigitalOcean API token found. Please enter your API token:")
+			result, err := utils.PromptPassword("DigitalOcean API Token")
+			if err != nil {
+				utils.ErrorMessage("Failed to get API token input")
+				return ""
+			}
+			token = result
+		}
+	}
+
+	// Set the token as environment variable
+	if err := os.Setenv("DIGITALOCEAN_ACCESS_TOKEN", token); err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to set DIGITALOCEAN_ACCESS_TOKEN environment variable: %v", err))
+	}
+
+	return token
+}
+
+// getDoTokenFromDoctlConfig reads the access token doctl stores after 'doctl auth init'
+func getDoTokenFromDoctlConfig() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	configData, err := os.ReadFile(filepath.Join(configDir, "doctl", "config.yaml"))
+	if err != nil {
+		return ""
+	}
+
+	var config struct {
+		AccessToken string `yaml:"access-token"`
+	}
+	if err := yaml.Unmarshal(configData, &config); err != nil {
+		utils.ErrorMessage("Failed to parse doctl config file")
+		return ""
+	}
+
+	if config.AccessToken != "" {
+		utils.InfoMessage("Using API token from local doctl config")
+	}
+	return config.AccessToken
+}
+
+// getDoRegions returns the regions DOKS clusters can be created in
+func getDoRegions(client *godo.Client) []string {
+	options, _, err := client.Kubernetes.GetOptions(context.Background())
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to get regions: %v", err))
+		return []string{"nyc1", "sfo3", "ams3", "fra1", "lon1", "sgp1"} // Return default regions on error
+	}
+
+	var regions []string
+	for _, r := range options.Regions {
+		regions = append(regions, r.Slug)
+	}
+	return regions
+}
+
+// selectRegion prompts for the region if it was not passed as a flag
+func selectRegion(client *godo.Client) error {
+	if doRegion != "" {
+		return nil
+	}
+	result, err := utils.PromptSelect("Select region", getDoRegions(client))
+	if err != nil {
+		utils.ErrorMessage("Region selection is required")
+		return fmt.Errorf("region selection is required")
+	}
+	doRegion = result
+	return nil
+}
+
+// listClusters returns all DOKS clusters of the account, following pagination
+func listClusters(client *godo.Client) ([]*godo.KubernetesCluster, error) {
+	var clusters []*godo.KubernetesCluster
+	opt := &godo.ListOptions{PerPage: 200}
+	for {
+		page, resp, err := client.Kubernetes.List(context.Background(), opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list clusters: %w", err)
+		}
+		clusters = append(clusters, page...)
+		if resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		current, err := resp.Links.CurrentPage()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current page: %w", err)
+		}
+		opt.Page = current + 1
+	}
+	return clusters, nil
+}
+
+// findClusterByName attempts to get a cluster by listing and matching name
+func findClusterByName(client *godo.Client, name string) (*godo.KubernetesCluster, error) {
+	clusters, err := listClusters(client)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range clusters {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no cluster found with name '%s'", name)
+}
+
+// selectCluster prompts for the cluster if no name was given, optionally limited to a region
+func selectCluster(client *godo.Client, prompt string) error {
+	if clusterName != "" {
+		return nil
+	}
+
+	clusters, err := listClusters(client)
+	if err != nil {
+		return err
+	}
+
+	var clusterNames []string
+	for _, c := range clusters {
+		if doRegion == "" || c.RegionSlug == doRegion {
+			clusterNames = append(clusterNames, c.Name)
+		}
+	}
+	if len(clusterNames) == 0 {
+		utils.ErrorMessage("No DOKS clusters found")
+		return fmt.Errorf("no DOKS clusters found")
+	}
+
+	result, err := utils.PromptSelect(prompt, clusterNames)
+	if err != nil {
+		utils.ErrorMessage("Cluster selection is required")
+		return fmt.Errorf("cluster selection is required")
+	}
+	clusterName = result
+	return nil
+}
+
+// Wait for the cluster to be ready
+func waitForClusterReady(client *godo.Client, cluster *godo.KubernetesCluster) error {
+	timeout := 15 * time.Minute
+	progress := utils.StartWaitProgress(fmt.Sprintf("cluster '%s' to be ready", cluster.Name), timeout)
+	defer progress.Stop()
+
+	poller := utils.NewPoller()
+	endTime := time.Now().Add(timeout)
+
+	for time.Now().Before(endTime) {
+		status, _, err := client.Kubernetes.Get(context.Background(), cluster.ID)
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Error fetching cluster status: %v", err))
+			if err := poller.Wait(); err != nil {
+				return err
+			}
+			continue
+		}
+		if status.Status != nil && status.Status.State == godo.KubernetesClusterStatusRunning {
+			progress.Done("Cluster is ready.")
+			return nil
+		}
+		if err := poller.Wait(); err != nil {
+			return err
+		}
+	}
+
+	utils.ErrorMessage(fmt.Sprintf("Cluster '%s' was not ready within the timeout", cluster.Name))
+	return fmt.Errorf("cluster '%s' was not ready within the timeout", cluster.Name)
+}
@@ -0,0 +1,131 @@
+package doks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ConnectCmd represents the connect command
+var ConnectCmd = &cobra.Command{
+	Use:     "connect",
+	Aliases: []string{"conn"},
+	Short:   "Connect to an existing DOKS cluster",
+	Long: `Connect to an existing DigitalOcean Kubernetes cluster and configure kubectl.
+This will update your kubeconfig file to allow kubectl access to the cluster.`,
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE:        runConnect,
+}
+
+func init() {
+	ConnectCmd.Flags().StringVar(&clusterName, "cluster-name", "", "Name of the cluster to connect to")
+	ConnectCmd.Flags().StringVar(&doRegion, "do-region", "", "DigitalOcean region where the cluster is located")
+}
+
+// Function to handle the "connect" command logic
+func connectToCluster(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_doks_connect.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to connect to cluster, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	client := godo.NewFromToken(getDoToken())
+
+	if err = selectCluster(client, "Select cluster to connect to"); err != nil {
+		return err
+	}
+
+	targetCluster, err := findClusterByName(client, clusterName)
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Cluster '%s' not found", clusterName))
+		return err
+	}
+
+	// Configure kubectl for the cluster
+	utils.InfoMessage("Configuring kubectl for the cluster...")
+	for i := 0; i < 3; i++ {
+		_, err = configureKubeConfig(client, targetCluster.ID)
+		if err == nil {
+			break
+		}
+		if i < 2 {
+			utils.InfoMessage(fmt.Sprintf("Retry %d/3: Failed to configure kubectl, retrying...", i+1))
+		}
+	}
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to configure kubectl for cluster '%s' after 3 retries: %v", targetCluster.Name, err))
+		return err
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("Successfully connected to cluster '%s'", clusterName))
+
+	return nil
+}
+
+// configureKubeConfig downloads the cluster's kubeconfig and merges it into ~/.kube/config
+func configureKubeConfig(client *godo.Client, clusterID string) (*rest.Config, error) {
+	kubeConfig, _, err := client.Kubernetes.GetKubeConfig(context.Background(), clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download kubeconfig: %w", err)
+	}
+
+	// Parse the new kubeconfig
+	newConfig, err := clientcmd.Load(kubeConfig.KubeconfigYAML)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new kubeconfig: %w", err)
+	}
+
+	// Merge it into the kubeconfig and switch to its context
+	if err := utils.MergeKubeconfig(newConfig, newConfig.CurrentContext); err != nil {
+		return nil, err
+	}
+
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeConfig.KubeconfigYAML)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	// Test client
+	_, err = clientset.CoreV1().Namespaces().List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to test Kubernetes client: %w", err)
+	}
+
+	utils.SuccessMessage("Kubeconfig configured successfully.")
+	return config, nil
+}
+
+// runConnect connects to the cluster and prints the resulting connection with --output json|yaml
+func runConnect(cmd *cobra.Command, args []string) error {
+	if err := connectToCluster(cmd, args); err != nil {
+		return err
+	}
+	if utils.IsStructuredOutput() {
+		return utils.PrintClusterConnection(utils.ProviderClusterTypeDoks, clusterName, doRegion)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package doks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
+	"github.com/spf13/cobra"
+)
+
+// CreateCmd represents the create command
+var CreateCmd = &cobra.Command{
+	Use:         "create",
+	Aliases:     []string{"c"},
+	Short:       "Create a Kubernetes cluster in DigitalOcean",
+	Long:        "Create a new DigitalOcean Kubernetes (DOKS) cluster with the specified node pool configuration.",
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE:        createCluster,
+}
+
+// Initialize flags
+func init() {
+	CreateCmd.Flags().StringVar(&clusterName, "cluster-name", "", "Name of the cluster")
+	CreateCmd.Flags().StringVar(&doRegion, "do-region", "", "DigitalOcean region")
+	CreateCmd.Flags().StringVar(&kubernetesVersion, "kubernetes-version", "latest", "Kubernetes version slug")
+	CreateCmd.Flags().StringVar(&nodePoolName, "node-pool-name", "grpl-pool", "Name of the default node pool")
+	CreateCmd.Flags().IntVarP(&nodes, "nodes", "n", 3, "Number of nodes in the default node pool")
+	CreateCmd.Flags().StringVar(&size, "size", "s-4vcpu-8gb", "Droplet size of the nodes")
+	CreateCmd.Flags().BoolVar(&autoScale, "auto-scale", false, "Enable auto scaling for the default node pool")
+	CreateCmd.Flags().IntVar(&minNodes, "min-nodes", 1, "Minimum number of nodes when auto scaling is enabled")
+	CreateCmd.Flags().IntVar(&maxNodes, "max-nodes", 5, "Maximum number of nodes when auto scaling is enabled")
+	CreateCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts (default: false)")
+	CreateCmd.Flags().BoolVar(&waitForReady, "wait", false, "Wait for cluster to be ready (default: false)")
+}
+
+// Function to handle the "create" command logic
+func createCluster(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_doks_create.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to create cluster, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	// Validate input
+	if clusterName == "" {
+		result, err := prompt.Input(prompt.Question{
+			Label:    "Enter cluster name",
+			Flag:     "--cluster-name",
+			Validate: prompt.MatchRegex(utils.NonEmptyValueRegex),
+		})
+		if err != nil {
+			utils.ErrorMessage("Cluster name is required")
+			return err
+		}
+		clusterName = result
+	}
+
+	utils.InfoMessage("Initializing DigitalOcean client...")
+	client := godo.NewFromToken(getDoToken())
+
+	if err = selectRegion(client); err != nil {
+		return err
+	}
+
+	// Check if cluster already exists
+	utils.InfoMessage(fmt.Sprintf("Checking if cluster '%s' already exists...", clusterName))
+	if _, findErr := findClusterByName(client, clusterName); findErr == nil {
+		utils.ErrorMessage(fmt.Sprintf("Cluster with name '%s' already exists", clusterName))
+		err = fmt.Errorf("cluster with name '%s' already exists", clusterName)
+		return err
+	}
+
+	// Create the cluster
+	utils.InfoMessage("Creating the cluster...")
+	cluster, err := createDoksCluster(client)
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to create cluster: %v", err))
+		return err
+	}
+	doksClusterID = cluster.ID
+	utils.SuccessMessage(fmt.Sprintf("Cluster '%s' creation initiated, it will be ready in a few minutes", cluster.Name))
+
+	if waitForReady {
+		// Wait for cluster readiness
+		utils.InfoMessage(fmt.Sprintf("Waiting for cluster '%s' to be ready...", cluster.Name))
+		if err = waitForClusterReady(client, cluster); err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Cluster '%s' is not ready: %v", cluster.Name, err))
+			return err
+		}
+
+		// The API server answers a little after the cluster is reported ready
+		err = utils.WaitForClusterAPI(func() ([]byte, error) {
+			config, _, err := client.Kubernetes.GetKubeConfig(context.Background(), cluster.ID)
+			if err != nil {
+				return nil, err
+			}
+			return config.KubeconfigYAML, nil
+		}, 5*time.Minute)
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("The API server of cluster '%s' does not answer: %v", cluster.Name, err))
+			return err
+		}
+
+		// Instead of duplicating connection logic, use the connect command
+		if connectToDoksCluster {
+			err = connectToCluster(cmd, args)
+			if err != nil {
+				utils.ErrorMessage(fmt.Sprintf("Failed to connect to cluster: %v", err))
+				return err
+			}
+		}
+
+		utils.SuccessMessage(fmt.Sprintf("Cluster '%s' is ready and kubectl is configured.", clusterName))
+	}
+
+	return nil
+}
+
+// Create a new DOKS cluster
+func createDoksCluster(client *godo.Client) (*godo.KubernetesCluster, error) {
+	nodePool := &godo.KubernetesNodePoolCreateRequest{
+		Name:  nodePoolName,
+		Size:  size,
+		Count: nodes,
+	}
+	if autoScale {
+		nodePool.AutoScale = true
+		nodePool.MinNodes = minNodes
+		nodePool.MaxNodes = maxNodes
+	}
+
+	request := &godo.KubernetesClusterCreateRequest{
+		Name:        clusterName,
+		RegionSlug:  doRegion,
+		VersionSlug: kubernetesVersion,
+		NodePools:   []*godo.KubernetesNodePoolCreateRequest{nodePool},
+	}
+
+	cluster, _, err := client.Kubernetes.Create(context.Background(), request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster: %w", err)
+	}
+	return cluster, nil
+}
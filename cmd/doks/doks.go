@@ -0,0 +1,21 @@
+package doks
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// DoksCmd represents the doks command
+var DoksCmd = &cobra.Command{
+	Use:     "doks",
+	Aliases: []string{"do", "digitalocean"},
+	Short:   "DigitalOcean Kubernetes operations",
+	Long:    "Commands related to operations on DigitalOcean Kubernetes (DOKS) clusters.",
+}
+
+func init() {
+	// Initialize subcommands for doks
+	DoksCmd.AddCommand(CreateCmd)
+	DoksCmd.AddCommand(InstallCmd)
+	DoksCmd.AddCommand(ConnectCmd)
+	DoksCmd.AddCommand(RemoveCmd)
+}
@@ -0,0 +1,411 @@
+package doks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/grapple-solution/grapple_cli/installer"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// InstallCmd represents the install command
+var InstallCmd = &cobra.Command{
+	Use:     "install",
+	Aliases: []string{"i"},
+	Short:   "Install Grapple on a DOKS cluster (step by step)",
+	Long: `Installs Grapple components (grsf-init, grsf, grsf-config, grsf-integration)
+sequentially on a DigitalOcean Kubernetes cluster, waiting for required resources in between.`,
+	RunE: runInstallStepByStep,
+}
+
+// init sets up flags for install
+func init() {
+	InstallCmd.Flags().StringVar(&grappleVersion, "grapple-version", "latest", "Version of Grapple to install")
+	InstallCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts")
+	InstallCmd.Flags().StringVar(&doRegion, "do-region", "", "DigitalOcean region")
+	InstallCmd.Flags().StringVar(&clusterName, "cluster-name", "", "DOKS cluster name")
+	InstallCmd.Flags().StringVar(&doksClusterID, "doks-cluster-id", "", "DOKS cluster ID")
+	InstallCmd.Flags().StringVar(&doEmailAddress, "email-address", "", "Email address")
+	InstallCmd.Flags().StringVar(&clusterIP, "cluster-ip", "", "Cluster IP")
+	InstallCmd.Flags().StringVar(&grappleDNS, "grapple-dns", "", "Domain for Grapple (default: {cluster-name}.grapple-demo.com)")
+	InstallCmd.Flags().StringVar(&organization, "organization", "", "Organization name (default: grapple-solutions)")
+	InstallCmd.Flags().BoolVar(&installKubeblocks, "install-kubeblocks", false, "Install Kubeblocks in background")
+	InstallCmd.Flags().BoolVar(&utils.OfflineMode, "offline", false, "Resolve charts only from the chart cache, see 'grapple cache pull'")
+	InstallCmd.Flags().BoolVar(&utils.SkipPreflight, "skip-preflight", false, "Skip the preflight checks of the cluster, see 'grapple preflight'")
+	InstallCmd.Flags().BoolVar(&utils.ClusterLogEnabled, "log-to-cluster", false, "Mirror the sanitized install log to the grpl-install-log ConfigMap for support")
+	InstallCmd.Flags().BoolVar(&waitForReady, "wait", false, "Wait for Grapple to be fully ready at the end")
+	InstallCmd.Flags().BoolVar(&sslEnable, "ssl", false, "Enable SSL usage")
+	InstallCmd.Flags().StringVar(&sslIssuer, "ssl-issuer", "letsencrypt-grapple-demo", "SSL Issuer")
+	InstallCmd.Flags().StringVar(&hostedZoneID, "hosted-zone-id", "", "AWS Route53 Hosted Zone ID (Inside Grapple's account) for DNS management")
+	InstallCmd.Flags().StringVar(&ingressController, "ingress-controller", "nginx", "First checks if an Ingress Controller is already installed, if not, then it can be 'nginx' or 'traefik'")
+	InstallCmd.Flags().StringSliceVar(&additionalValuesFiles, "values", []string{}, "Specify values files to use (can specify multiple times using following format: --values=values1.yaml,values2.yaml)")
+	installer.AddProviderValuesFlag(InstallCmd, &providerValues)
+	InstallCmd.Flags().StringVar(&imagePullSecret, "image-pull-secret", "", "Image pull secret for private repositories")
+	InstallCmd.Flags().StringVar(&utils.PriorityClass, "priority-class", "", "PriorityClass of the grsf components and KubeBlocks, created if it doesn't exist (e.g: --priority-class=grpl-platform)")
+	InstallCmd.Flags().StringToStringVar(&labels, "labels", map[string]string{}, "Labels to add to all generated resources (e.g: --labels=team=platform,cost-center=1234)")
+	InstallCmd.Flags().StringToStringVar(&annotations, "annotations", map[string]string{}, "Annotations to add to all generated resources (e.g: --annotations=owner=platform)")
+}
+
+// runInstallStepByStep is the main function
+func runInstallStepByStep(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_doks_install.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, logOnFileStart, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		utils.MirrorLogToCluster(logFilePath, "doks install")
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to install grpl, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	// Start logging to both CLI and file
+	logOnCliAndFileStart()
+
+	utils.SetCommonMetadata(labels, annotations)
+
+	// 1) Resolve the DOKS cluster, fetch its kubeconfig and build a Kube client
+	kubeClient, restConfig, err := initClientsAndConfig()
+	if err != nil {
+		return err
+	}
+
+	if err = installer.PromptKubeblocks(cmd, &installKubeblocks); err != nil {
+		return err
+	}
+
+	err = installer.Run(doksProvider{}, &installer.Installation{
+		ClusterType:       utils.ProviderClusterTypeDoks,
+		ClusterName:       clusterName,
+		GrappleVersion:    grappleVersion,
+		Domain:            completeDomain,
+		Organization:      organization,
+		Email:             doEmailAddress,
+		License:           grappleLicense,
+		ImagePullSecret:   imagePullSecret,
+		SSL:               sslEnable,
+		SSLIssuer:         sslIssuer,
+		InstallKubeblocks: installKubeblocks,
+		WaitForReady:      waitForReady,
+		AutoConfirm:       autoConfirm,
+		ValuesFiles:       additionalValuesFiles,
+		ProviderValues:    providerValues,
+		Summary: []string{
+			fmt.Sprintf("doks-cluster-id: %s", doksClusterID),
+			fmt.Sprintf("do-region: %s", doRegion),
+			fmt.Sprintf("ingress-controller: %s", ingressController),
+		},
+		KubeClient:           kubeClient,
+		RestConfig:           restConfig,
+		LogOnFileStart:       logOnFileStart,
+		LogOnCliAndFileStart: logOnCliAndFileStart,
+	})
+	return err
+}
+
+// doksProvider is the DOKS part of the installation
+type doksProvider struct{}
+
+func (doksProvider) PrepareCluster(inst *installer.Installation) error {
+	if err := setupIngressController(inst.RestConfig, inst.LogOnFileStart, inst.LogOnCliAndFileStart); err != nil {
+		return fmt.Errorf("failed to setup ingress controller: %w", err)
+	}
+	return nil
+}
+
+func (doksProvider) ClusterIP(inst *installer.Installation) (string, error) {
+	// wait for the DigitalOcean load balancer to be ready
+	utils.InfoMessage("waiting for loadbalancer to be ready...")
+	ip, err := utils.GetClusterExternalIP(inst.RestConfig, ingressController)
+	if err != nil {
+		return "", fmt.Errorf("failed to get doks cluster IP: %w", err)
+	}
+	utils.SuccessMessage("Loadbalancer setup completed.")
+	return ip, nil
+}
+
+func (doksProvider) ExtraValues(inst *installer.Installation) (map[string]interface{}, []string, error) {
+	return map[string]interface{}{
+		"config": map[string]interface{}{
+			utils.SecKeyDoksClusterID: doksClusterID,
+			utils.SecKeyDoksRegion:    doRegion,
+		},
+	}, nil, nil
+}
+
+func (doksProvider) PostInstall(inst *installer.Installation) error {
+	if err := installer.EnableSSL(inst, ingressController); err != nil {
+		return err
+	}
+	return installer.SetupDomainRecords(inst, installer.DomainRecords{
+		Cloud:        "doks",
+		DNS:          grappleDNS,
+		HostedZoneID: hostedZoneID,
+	})
+}
+
+// -----------------------------------------------------------------------------
+// initClientsAndConfig: does the following:
+// 1) Create a godo client and resolve the cluster
+// 2) Wait for the cluster to be ready and merge its kubeconfig
+// 3) Build a K8s client-go client
+// -----------------------------------------------------------------------------
+func initClientsAndConfig() (apiv1.Interface, *rest.Config, error) {
+	client := godo.NewFromToken(getDoToken())
+
+	var cluster *godo.KubernetesCluster
+	var err error
+	if doksClusterID != "" {
+		cluster, _, err = client.Kubernetes.Get(context.Background(), doksClusterID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get doks cluster: %w", err)
+		}
+		clusterName = cluster.Name
+	} else {
+		if err := selectCluster(client, "Select DOKS cluster"); err != nil {
+			return nil, nil, err
+		}
+		cluster, err = findClusterByName(client, clusterName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get doks cluster: %w", err)
+		}
+		doksClusterID = cluster.ID
+		utils.InfoMessage(fmt.Sprintf("Using cluster ID: %s", doksClusterID))
+	}
+	doRegion = cluster.RegionSlug
+
+	if cluster.Status == nil || cluster.Status.State != godo.KubernetesClusterStatusRunning {
+		utils.InfoMessage("Waiting for cluster to be ready...")
+		if err := waitForClusterReady(client, cluster); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	utils.InfoMessage("Configuring kubectl for the cluster...")
+	restConfig, err := configureKubeConfig(client, cluster.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to doks cluster: %w", err)
+	}
+
+	k8sClient, err := apiv1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	// Get email address if not provided
+	if doEmailAddress == "" {
+		result, err := prompt.Input(prompt.Question{
+			Label:    "Enter email address",
+			Flag:     "--email-address",
+			History:  "email-address",
+			Validate: prompt.MatchRegex(utils.EmailRegex),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get email address: %w", err)
+		}
+		doEmailAddress = result
+
+		// Set organization from email domain if not already set
+		if organization == "" {
+			parts := strings.Split(doEmailAddress, "@")
+			if len(parts) == 2 {
+				organization = parts[1]
+			}
+		}
+	}
+
+	grappleVersion = installer.GrappleVersion(grappleVersion)
+	grappleDNS, completeDomain = installer.ResolveDomain(grappleDNS, clusterName, hostedZoneID, false)
+	if organization == "" {
+		organization = installer.DefaultOrganization
+	}
+	// Reinstalls keep the license of the grsf-config secret
+	grappleLicense = installer.ExistingLicense(k8sClient)
+
+	return k8sClient, restConfig, nil
+}
+
+func setupIngressController(restConfig *rest.Config, logOnFileStart, logOnCliAndFileStart func()) error {
+	// Create a k8s client
+	clientset, err := apiv1.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	// List all IngressClasses
+	ingClassList, err := clientset.NetworkingV1().IngressClasses().List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list IngressClasses: %w", err)
+	}
+
+	// Check if any IngressClass is set as default
+	for _, ingClass := range ingClassList.Items {
+		if val, ok := ingClass.Annotations["ingressclass.kubernetes.io/is-default-class"]; ok && (val == "true" || val == "True") {
+			ingressController = ingClass.Name
+			utils.InfoMessage(fmt.Sprintf("Found default IngressClass: %s", ingClass.Name))
+			utils.InfoMessage("A default IngressClass is already set. Proceeding with installation.")
+			return nil
+		}
+	}
+
+	if len(ingClassList.Items) > 0 {
+		utils.ErrorMessage("No IngressClass is set as default. Please set one of the following IngressClasses as default before proceeding:")
+		for _, ingClass := range ingClassList.Items {
+			utils.InfoMessage(fmt.Sprintf("  - Name: %s\n", ingClass.Name))
+		}
+		return fmt.Errorf("no IngressClass is set as default; please set one as default and rerun the installer")
+	}
+
+	if ingressController != "nginx" && ingressController != "traefik" {
+		utils.InfoMessage(fmt.Sprintf("invalid ingress controller: %s", ingressController))
+		utils.InfoMessage("using default ingress controller: nginx")
+		ingressController = "nginx"
+	}
+
+	// Both charts expose the controller through a LoadBalancer service, which DOKS backs with a DigitalOcean load balancer
+	logOnFileStart()
+	var ingressErr error
+	if ingressController == "traefik" {
+		ingressErr = installIngressChart(restConfig, "traefik", "https://traefik.github.io/charts", "traefik", map[string]interface{}{
+			"ingressClass": map[string]interface{}{
+				"enabled":        true,
+				"isDefaultClass": true,
+			},
+		})
+	} else {
+		ingressErr = installIngressChart(restConfig, "ingress-nginx", "https://kubernetes.github.io/ingress-nginx", "ingress-nginx", map[string]interface{}{
+			"controller": map[string]interface{}{
+				"ingressClassResource": map[string]interface{}{
+					"default": true,
+				},
+				"config": map[string]interface{}{
+					"proxy-body-size": "50m",
+				},
+			},
+		})
+	}
+	logOnCliAndFileStart()
+	if ingressErr != nil {
+		return fmt.Errorf("failed to setup ingress controller: %w", ingressErr)
+	}
+	return nil
+}
+
+// installIngressChart installs an ingress controller chart from its helm repository,
+// release name and namespace are the same as the repository name
+func installIngressChart(restConfig *rest.Config, repoName, repoURL, chartName string, values map[string]interface{}) error {
+	utils.StartSpinner(fmt.Sprintf("Setting up %s...", repoName))
+	defer utils.StopSpinner()
+
+	// Initialize Helm client
+	helmCfg, err := utils.GetHelmConfig(restConfig, repoName)
+	if err != nil {
+		utils.ErrorMessage("Failed to initialize Helm configuration: " + err.Error())
+		return err
+	}
+
+	// Check if the controller is already installed
+	listClient := action.NewList(helmCfg)
+	listClient.AllNamespaces = true
+	releases, err := listClient.Run()
+	if err != nil {
+		utils.ErrorMessage("Failed to list releases: " + err.Error())
+		return err
+	}
+	for _, release := range releases {
+		if release.Name == repoName {
+			utils.InfoMessage(fmt.Sprintf("%s already installed", repoName))
+			return nil
+		}
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Installing %s...", repoName))
+
+	// Create Helm environment settings
+	settings := cli.New()
+	settings.SetNamespace(repoName)
+
+	repoEntry := repo.Entry{
+		Name: repoName,
+		URL:  repoURL,
+	}
+
+	chartRepo, err := repo.NewChartRepository(&repoEntry, getter.All(settings))
+	if err != nil {
+		utils.ErrorMessage("Failed to create chart repository object: " + err.Error())
+		return err
+	}
+
+	// Add repo to repositories.yaml
+	repoFile := settings.RepositoryConfig
+	b, err := os.ReadFile(repoFile)
+	if err != nil && !os.IsNotExist(err) {
+		utils.ErrorMessage("Failed to read repository file: " + err.Error())
+		return err
+	}
+
+	var f repo.File
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		utils.ErrorMessage("Failed to unmarshal repository file: " + err.Error())
+		return err
+	}
+
+	// Add new repo or update existing
+	f.Add(&repoEntry)
+
+	if err := f.WriteFile(repoFile, 0644); err != nil {
+		utils.ErrorMessage("Failed to write repository file: " + err.Error())
+		return err
+	}
+
+	if _, err := chartRepo.DownloadIndexFile(); err != nil {
+		utils.ErrorMessage("Failed to download repository index: " + err.Error())
+		return err
+	}
+
+	// Create install client
+	installClient := action.NewInstall(helmCfg)
+	installClient.Namespace = repoName
+	installClient.CreateNamespace = true
+	installClient.ReleaseName = repoName
+
+	// Locate and load the chart
+	chartPath, err := installClient.ChartPathOptions.LocateChart(fmt.Sprintf("%s/%s", repoName, chartName), settings)
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to locate %s chart: %v", chartName, err))
+		return fmt.Errorf("%w: %w", utils.ErrChartNotFound, err)
+	}
+
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to load %s chart: %v", chartName, err))
+		return err
+	}
+
+	if _, err := installClient.RunWithContext(utils.CommandContext(), chart, values); err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to install %s: %v", repoName, err))
+		return err
+	}
+
+	utils.InfoMessage(fmt.Sprintf("%s installed successfully", repoName))
+	return nil
+}
@@ -0,0 +1,150 @@
+package doks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var deleteAssociatedResources bool
+
+// RemoveCmd represents the remove command
+var RemoveCmd = &cobra.Command{
+	Use:     "remove",
+	Aliases: []string{"r"},
+	Short:   "Remove all traces of the cluster from the DigitalOcean account",
+	Long: `Remove command will delete the DOKS cluster and, unless disabled, the load balancers
+and volumes that were created for it.
+
+This ensures a complete cleanup of all cluster-related resources.`,
+	RunE: runRemove,
+}
+
+func init() {
+	RemoveCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", true, "If true, deletes the currently connected DOKS cluster. If false, prompts for the cluster name and deletes the specified cluster. Default value of auto-confirm is true")
+	RemoveCmd.Flags().StringVar(&doRegion, "do-region", "", "DigitalOcean region")
+	RemoveCmd.Flags().StringVar(&clusterName, "cluster-name", "", "DOKS cluster name")
+	RemoveCmd.Flags().BoolVar(&deleteAssociatedResources, "delete-associated-resources", true, "Also delete load balancers, volumes and volume snapshots created for the cluster")
+	RemoveCmd.Flags().BoolVarP(&skipConfirmation, "yes", "y", false, "Skip confirmation prompt before removing cluster")
+}
+
+func getClusterDetailsFromConfig(clientset *kubernetes.Clientset) bool {
+
+	// Try to get grsf-config secret
+	secret, err := clientset.CoreV1().Secrets("grpl-system").Get(context.TODO(), "grsf-config", v1.GetOptions{})
+	if err != nil {
+		return false
+	}
+	// Check provider type
+	if string(secret.Data[utils.SecKeyProviderClusterType]) == utils.ProviderClusterTypeDoks {
+		// Extract cluster name and region if not provided via flags
+		if clusterName == "" {
+			clusterName = string(secret.Data[utils.SecKeyClusterName])
+		}
+		if doRegion == "" {
+			doRegion = string(secret.Data[utils.SecKeyDoksRegion])
+		}
+		utils.InfoMessage(fmt.Sprintf("Using values from grsf-config: cluster=%s, region=%s", clusterName, doRegion))
+		return true
+	}
+	return false
+}
+
+func runRemove(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_doks_remove.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to remove cluster, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	client := godo.NewFromToken(getDoToken())
+
+	if autoConfirm {
+		_, clientset, configErr := utils.GetKubernetesConfig()
+		if configErr != nil || !getClusterDetailsFromConfig(clientset) {
+			utils.InfoMessage("Unable to find cluster details in grsf-config, moving to prompt for cluster name")
+		}
+	}
+
+	if err = selectCluster(client, "Select cluster to remove"); err != nil {
+		return err
+	}
+
+	targetCluster, err := findClusterByName(client, clusterName)
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Cluster %s not found", clusterName))
+		return err
+	}
+	utils.InfoMessage(fmt.Sprintf("Cluster %s found in region %s", clusterName, targetCluster.RegionSlug))
+
+	if deleteAssociatedResources {
+		resources, _, listErr := client.Kubernetes.ListAssociatedResourcesForDeletion(context.Background(), targetCluster.ID)
+		if listErr == nil {
+			for _, lb := range resources.LoadBalancers {
+				utils.InfoMessage(fmt.Sprintf("Load balancer %s will be deleted", lb.Name))
+			}
+			for _, vol := range resources.Volumes {
+				utils.InfoMessage(fmt.Sprintf("Volume %s will be deleted", vol.Name))
+			}
+			for _, snap := range resources.VolumeSnapshots {
+				utils.InfoMessage(fmt.Sprintf("Volume snapshot %s will be deleted", snap.Name))
+			}
+		}
+	}
+
+	// Ask for confirmation unless --yes flag is set
+	if !skipConfirmation {
+		confirmMsg := fmt.Sprintf("Are you sure you want to delete cluster '%s' in region '%s'? This action cannot be undone (y/N): ", clusterName, targetCluster.RegionSlug)
+		confirmed, err := utils.PromptInput(confirmMsg, "n", "^[yYnN]$")
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(confirmed) != "y" {
+			utils.InfoMessage("Cluster deletion cancelled")
+			return nil
+		}
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Deleting cluster %s...", clusterName))
+	if deleteAssociatedResources {
+		_, err = client.Kubernetes.DeleteDangerous(context.Background(), targetCluster.ID)
+	} else {
+		_, err = client.Kubernetes.Delete(context.Background(), targetCluster.ID)
+	}
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to delete cluster: %v", err))
+		return err
+	}
+
+	// Wait and verify deletion
+	maxRetries := 10
+	for i := 0; i < maxRetries; i++ {
+		time.Sleep(10 * time.Second)
+
+		if _, findErr := findClusterByName(client, clusterName); findErr != nil && strings.Contains(findErr.Error(), "no cluster found") {
+			utils.SuccessMessage(fmt.Sprintf("Successfully deleted cluster %s", clusterName))
+			return nil
+		}
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("Delete request sent for cluster %s. The cluster should be removed shortly.", clusterName))
+	return nil
+}
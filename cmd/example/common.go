@@ -1,6 +1,22 @@
 package example
 
+import "k8s.io/apimachinery/pkg/runtime/schema"
+
+const (
+	// exampleLabel is set to the template on the objects and the namespace created by 'example deploy'
+	exampleLabel = "grpl.io/example"
+	// exampleNameLabel is set to the name of the example, its GRAS, on the objects created by 'example deploy'
+	exampleNameLabel = "grpl.io/example-name"
+	// exampleDBTypeLabel is the database type of an example GRAS, internal or external
+	exampleDBTypeLabel = "grpl.io/example-db-type"
+)
+
 var (
 	DeploymentNamespace string
 	GrasName            string
 )
+
+var (
+	grasGVR    = schema.GroupVersionResource{Group: "grsf.grpl.io", Version: "v1alpha1", Resource: "grappleapplicationsets"}
+	clusterGVR = schema.GroupVersionResource{Group: "apps.kubeblocks.io", Version: "v1alpha1", Resource: "clusters"}
+)
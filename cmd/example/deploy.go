@@ -1,28 +1,19 @@
 package example
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
-	"strings"
-	"time"
 
-	"github.com/go-git/go-git/v5" // Go-git package
 	"github.com/grapple-solution/grapple_cli/utils"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 var (
@@ -44,7 +35,16 @@ Available templates:
 - db-mysql-model-based
 - db-mysql-discovery-based
 
-For database resources, you can choose between internal or external databases.`,
+For database resources, you can choose between internal or external databases.
+
+The examples come from the grpl-gras-examples repository, cloned once into ~/.cache/grpl/examples and updated
+at every deploy. --examples-ref pins a tag or commit, --examples-path deploys from a local copy; when the update
+fails (or with --offline) the cached clone is used.
+
+Example:
+  grapple example deploy --gras-template db-file
+  grapple example deploy --gras-template db-file --examples-ref v1.2.0
+  grapple example deploy --gras-template db-file --examples-path ~/src/grpl-gras-examples`,
 	RunE: runDeploy,
 }
 
@@ -53,6 +53,9 @@ func init() {
 	DeployCmd.Flags().StringVar(&dbType, "db-type", "", "Database type (internal/external)")
 	DeployCmd.Flags().StringVar(&kubeContext, "kube-context", "", "Kubernetes context")
 	DeployCmd.Flags().BoolVar(&wait, "wait", false, "Wait for deployment to be ready")
+	DeployCmd.Flags().StringVar(&examplesRef, "examples-ref", "", "Branch, tag or commit of the examples repository to deploy (default: main)")
+	DeployCmd.Flags().StringVar(&examplesPath, "examples-path", "", "Deploy from a local copy of the examples repository instead of the cached clone")
+	DeployCmd.Flags().BoolVar(&utils.OfflineMode, "offline", false, "Use the cached examples and charts without updating them, see 'grapple cache pull'")
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
@@ -76,23 +79,8 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	}()
 
 	logOnCliAndFileStart()
-	// Get home directory in a cross-platform way
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %w", err)
-	}
-
-	// Create .kube directory if it doesn't exist
-	kubeDir := filepath.Join(home, ".kube")
-	if err := os.MkdirAll(kubeDir, 0755); err != nil {
-		return fmt.Errorf("failed to create .kube directory: %w", err)
-	}
-
-	// Get kubeconfig path
-	configPath := filepath.Join(kubeDir, "config")
-
 	// Load kubeconfig and initialize kubectl client
-	restConfig, err := clientcmd.BuildConfigFromFlags("", configPath)
+	restConfig, err := utils.KubeContextRESTConfig(utils.KubeContext)
 	if err != nil {
 		return fmt.Errorf("failed to load kubeconfig: %w", err)
 	}
@@ -111,9 +99,8 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	}
 	utils.SuccessMessage("Grapple is ready!")
 
-	// Clone examples repo
-	repoPath := filepath.Join(os.TempDir(), "grpl-gras-examples")
-	if err := cloneExamplesRepo(repoPath); err != nil {
+	repoPath, err := resolveExamplesRepo()
+	if err != nil {
 		return err
 	}
 
@@ -158,26 +145,6 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	}
 }
 
-func cloneExamplesRepo(path string) error {
-	// Remove existing repo directory if it exists
-	if err := os.RemoveAll(path); err != nil {
-		return fmt.Errorf("failed to clean existing repo: %w", err)
-	}
-
-	utils.InfoMessage("Cloning examples repository...")
-
-	// Clone the repository using go-git
-	_, err := git.PlainClone(path, false, &git.CloneOptions{
-		URL:      "https://github.com/grapple-solution/grpl-gras-examples.git",
-		Progress: os.Stdout,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to clone repository: %w", err)
-	}
-
-	return nil
-}
-
 func deployDBFile(client *kubernetes.Clientset, restConfig *rest.Config, repoPath string) error {
 	manifestPath := filepath.Join(repoPath, "db-file/resource.yaml")
 	return applyManifest(client, restConfig, manifestPath)
@@ -237,29 +204,24 @@ func applyManifest(client *kubernetes.Clientset, restConfig *rest.Config, manife
 		return fmt.Errorf("failed to read manifest file: %w", err)
 	}
 
-	// Create dynamic client for applying manifests
-	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	objects, err := utils.DecodeManifest(yamlFile)
 	if err != nil {
-		return fmt.Errorf("failed to create dynamic client: %w", err)
+		return err
 	}
-
-	// Split the YAML into individual documents
-	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(yamlFile), 4096)
-	for {
-		var obj unstructured.Unstructured
-		if err := decoder.Decode(&obj); err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("failed to decode manifest: %w", err)
-		}
-
-		// Skip empty documents
-		if len(obj.Object) == 0 {
-			utils.InfoMessage("Skipping empty document")
-			continue
+	applier, err := utils.NewApplier(restConfig)
+	if err != nil {
+		return err
+	}
+	applier.Force = true
+	// The objects of the example are labeled with the name of its GRAS, which names the example
+	exampleName := ""
+	for _, obj := range objects {
+		if obj.GetKind() == "GrappleApplicationSet" {
+			exampleName = obj.GetName()
 		}
+	}
 
+	for _, obj := range objects {
 		// Get namespace from manifest and create if needed
 		namespace := obj.GetNamespace()
 		if namespace != "" {
@@ -267,9 +229,11 @@ func applyManifest(client *kubernetes.Clientset, restConfig *rest.Config, manife
 			if err != nil {
 				if errors.IsNotFound(err) {
 					utils.InfoMessage(fmt.Sprintf("Creating namespace '%s'", namespace))
+					// The label lets 'example remove' delete the namespace with the example
 					ns := &corev1.Namespace{
 						ObjectMeta: metav1.ObjectMeta{
-							Name: namespace,
+							Name:   namespace,
+							Labels: map[string]string{exampleLabel: grasTemplate},
 						},
 					}
 					_, err = client.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
@@ -283,58 +247,20 @@ func applyManifest(client *kubernetes.Clientset, restConfig *rest.Config, manife
 		}
 		DeploymentNamespace = namespace
 		GrasName = obj.GetName()
+		labelExampleObject(obj, exampleName)
 
-		// Get GVR for the resource
-		gvr := schema.GroupVersionResource{
-			Group:    obj.GetObjectKind().GroupVersionKind().Group,
-			Version:  obj.GetObjectKind().GroupVersionKind().Version,
-			Resource: strings.ToLower(obj.GetKind()) + "s",
-		}
-
-		// Apply the resource
 		utils.InfoMessage(fmt.Sprintf("Applying %s '%s' in namespace '%s'",
 			obj.GetKind(),
 			obj.GetName(),
 			namespace))
-
-		var dr dynamic.ResourceInterface
-		if namespace != "" {
-			dr = dynamicClient.Resource(gvr).Namespace(namespace)
-		} else {
-			dr = dynamicClient.Resource(gvr)
-		}
-
-		// Try to get existing resource first
-		existing, err := dr.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
-		if err != nil && !errors.IsNotFound(err) {
-			return fmt.Errorf("failed to check existing resource: %w", err)
+		applied, err := applier.Apply(context.TODO(), obj, namespace)
+		if err != nil {
+			return err
 		}
-
-		if errors.IsNotFound(err) {
-			// Resource doesn't exist, create it
-			_, err = dr.Create(context.TODO(), &obj, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to deploy GrappleApplicationSet resource: %w", err)
-			}
-			utils.SuccessMessage(fmt.Sprintf("Created %s '%s' in namespace '%s'", obj.GetKind(), obj.GetName(), namespace))
+		if applied.Created {
+			utils.SuccessMessage(fmt.Sprintf("Created %s '%s' in namespace '%s'", applied.Kind, applied.Name, applied.Namespace))
 		} else {
-			// Resource exists, update it
-			// Set the resourceVersion to ensure we're updating the latest version
-			obj.SetResourceVersion(existing.GetResourceVersion())
-			_, err = dr.Update(context.TODO(), &obj, metav1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to update resource: %w", err)
-			}
-			utils.SuccessMessage(fmt.Sprintf("Updated %s '%s' in namespace '%s'", obj.GetKind(), obj.GetName(), namespace))
-		}
-
-		// Wait a bit for the resource to be processed
-		time.Sleep(2 * time.Second)
-
-		// Verify the resource exists
-		_, err = dr.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to verify resource creation: %w", err)
+			utils.SuccessMessage(fmt.Sprintf("Updated %s '%s' in namespace '%s'", applied.Kind, applied.Name, applied.Namespace))
 		}
 	}
 
@@ -372,6 +298,23 @@ func applyManifest(client *kubernetes.Clientset, restConfig *rest.Config, manife
 	return nil
 }
 
+// labelExampleObject marks an object of an example with its template and name, and the GRAS with the database
+// type, for 'example list' and 'example remove'
+func labelExampleObject(obj *unstructured.Unstructured, name string) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[exampleLabel] = grasTemplate
+	if name != "" {
+		labels[exampleNameLabel] = name
+	}
+	if obj.GetKind() == "GrappleApplicationSet" && dbType != "" {
+		labels[exampleDBTypeLabel] = dbType
+	}
+	obj.SetLabels(labels)
+}
+
 func displayDeploymentDetails(namespace, resourceName, clusterDomain string, sslEnabled bool) {
 
 	if !wait {
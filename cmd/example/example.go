@@ -22,4 +22,6 @@ var ExampleCmd = &cobra.Command{
 
 func init() {
 	ExampleCmd.AddCommand(DeployCmd)
+	ExampleCmd.AddCommand(ListCmd)
+	ExampleCmd.AddCommand(RemoveCmd)
 }
@@ -0,0 +1,146 @@
+package example
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+var listNamespace string
+
+// deployedExample is an example GRAS found on the cluster
+type deployedExample struct {
+	Name      string   `json:"name" yaml:"name"`
+	Template  string   `json:"template" yaml:"template"`
+	DBType    string   `json:"dbType,omitempty" yaml:"dbType,omitempty"`
+	Namespace string   `json:"namespace" yaml:"namespace"`
+	URLs      []string `json:"urls,omitempty" yaml:"urls,omitempty"`
+}
+
+// ListCmd represents the example list command
+var ListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the deployed examples",
+	Long: `List shows the example GrappleApplicationSets deployed with 'grapple example deploy': their name,
+template, database type, namespace and the URLs of grapi and gruim.
+
+Examples are recognized by the grpl.io/example label 'example deploy' sets, examples deployed by older
+versions of the CLI aren't listed.
+
+Example:
+  grapple example list
+  grapple example list --namespace grpl-e-d-i -o json`,
+	Args: cobra.NoArgs,
+	RunE: runList,
+}
+
+func init() {
+	ListCmd.Flags().StringVar(&listNamespace, "namespace", "", "Only list the examples of this namespace (default: all namespaces)")
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	restConfig, err := utils.KubeContextRESTConfig(utils.KubeContext)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	items, err := listExampleGras(restConfig, listNamespace)
+	if err != nil {
+		return err
+	}
+
+	// URLs are only known once Grapple is installed, without grsf-config they are left out
+	clusterDomain, _ := utils.ExtractDomainFromGrplConfig(restConfig)
+	sslEnabled, _ := utils.IsSSLEnabled(restConfig)
+	scheme := "http"
+	if sslEnabled {
+		scheme = "https"
+	}
+
+	examples := make([]deployedExample, 0, len(items))
+	for _, item := range items {
+		labels := item.GetLabels()
+		example := deployedExample{
+			Name:      item.GetName(),
+			Template:  labels[exampleLabel],
+			DBType:    labels[exampleDBTypeLabel],
+			Namespace: item.GetNamespace(),
+		}
+		if clusterDomain != "" {
+			example.URLs = append(example.URLs, fmt.Sprintf("%s://%s-grapi.%s", scheme, item.GetName(), clusterDomain))
+			for _, gruim := range exampleGruimNames(item) {
+				example.URLs = append(example.URLs, fmt.Sprintf("%s://%s-gruim.%s", scheme, gruim, clusterDomain))
+			}
+		}
+		examples = append(examples, example)
+	}
+
+	return utils.PrintResult(examples, func() { printExamples(examples) })
+}
+
+// listExampleGras returns the GRAS resources deployed by 'example deploy', namespace "" lists all namespaces
+func listExampleGras(restConfig *rest.Config, namespace string) ([]unstructured.Unstructured, error) {
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	list, err := dynamicClient.Resource(grasGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: exampleLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GrappleApplicationSets: %w", err)
+	}
+	items := list.Items
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].GetNamespace() != items[j].GetNamespace() {
+			return items[i].GetNamespace() < items[j].GetNamespace()
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+	return items, nil
+}
+
+// exampleGruimNames returns the GRUIM names of a GRAS, the GRAS name when the spec names none
+func exampleGruimNames(gras unstructured.Unstructured) []string {
+	gruims, _, _ := unstructured.NestedSlice(gras.Object, "spec", "gruims")
+	var names []string
+	for _, gruim := range gruims {
+		if entry, ok := gruim.(map[string]interface{}); ok {
+			if name, ok := entry["name"].(string); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = []string{gras.GetName()}
+	}
+	return names
+}
+
+func printExamples(examples []deployedExample) {
+	if len(examples) == 0 {
+		utils.InfoMessage("No examples deployed, deploy one with 'grapple example deploy'")
+		return
+	}
+	fmt.Printf("%-28s %-26s %-9s %-20s %s\n", "NAME", "TEMPLATE", "DB", "NAMESPACE", "URLS")
+	for _, example := range examples {
+		dbType := example.DBType
+		if dbType == "" {
+			dbType = "-"
+		}
+		urls := "-"
+		if len(example.URLs) > 0 {
+			urls = example.URLs[0]
+		}
+		fmt.Printf("%-28s %-26s %-9s %-20s %s\n", example.Name, example.Template, dbType, example.Namespace, urls)
+		for _, url := range example.URLs[min(1, len(example.URLs)):] {
+			fmt.Printf("%-28s %-26s %-9s %-20s %s\n", "", "", "", "", url)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package example
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	removeNamespace  string
+	skipConfirmation bool
+)
+
+// RemoveCmd represents the example remove command
+var RemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a deployed example",
+	Long: `Remove deletes an example deployed with 'grapple example deploy': the GrappleApplicationSet, the
+KubeBlocks cluster of an internal database, the <name>-conn-credential secret of an external database and
+the namespace, when 'example deploy' created it and no other GrappleApplicationSet is left in it.
+
+Example:
+  grapple example remove grpl-e-d-i
+  grapple example remove grpl-e-d-i --namespace grpl-e-d-i --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRemove,
+}
+
+func init() {
+	RemoveCmd.Flags().StringVar(&removeNamespace, "namespace", "", "Namespace of the example (default: found by name)")
+	RemoveCmd.Flags().BoolVarP(&skipConfirmation, "yes", "y", false, "Skip confirmation prompt before removing the example")
+}
+
+func runRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	restConfig, err := utils.KubeContextRESTConfig(utils.KubeContext)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	items, err := listExampleGras(restConfig, removeNamespace)
+	if err != nil {
+		return err
+	}
+	var namespaces []string
+	dbType := ""
+	for _, item := range items {
+		if item.GetName() == name {
+			namespaces = append(namespaces, item.GetNamespace())
+			dbType = item.GetLabels()[exampleDBTypeLabel]
+		}
+	}
+	switch len(namespaces) {
+	case 0:
+		return fmt.Errorf("%w: no example %s found, see 'grapple example list'", utils.ErrValidation, name)
+	case 1:
+	default:
+		return fmt.Errorf("%w: example %s is deployed in several namespaces (%s), select one with --namespace",
+			utils.ErrValidation, name, strings.Join(namespaces, ", "))
+	}
+	namespace := namespaces[0]
+
+	// The namespace goes with the example if the example created it and nothing else uses it
+	removeNS := false
+	if ns, err := client.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{}); err == nil && ns.Labels[exampleLabel] != "" {
+		others, err := dynamicClient.Resource(grasGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		removeNS = err == nil && len(others.Items) == 1
+	}
+
+	if !skipConfirmation {
+		message := fmt.Sprintf("Remove example %s from namespace %s?", name, namespace)
+		if removeNS {
+			message = fmt.Sprintf("Remove example %s and its namespace %s?", name, namespace)
+		}
+		confirmed, err := utils.PromptConfirm(message)
+		if err != nil || !confirmed {
+			return fmt.Errorf("example remove: %w", utils.ErrUserAborted)
+		}
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Removing GrappleApplicationSet %s...", name))
+	if err := dynamicClient.Resource(grasGVR).Namespace(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete GrappleApplicationSet %s: %w", name, err)
+	}
+
+	// The internal database of the example is a KubeBlocks cluster deployed from the same manifest, other
+	// examples of the namespace have their own
+	selector := fmt.Sprintf("%s,%s=%s", exampleLabel, exampleNameLabel, name)
+	clusters, err := dynamicClient.Resource(clusterGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err == nil {
+		for _, cluster := range clusters.Items {
+			utils.InfoMessage(fmt.Sprintf("Removing database cluster %s...", cluster.GetName()))
+			if err := dynamicClient.Resource(clusterGVR).Namespace(namespace).Delete(context.TODO(), cluster.GetName(), metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete database cluster %s: %w", cluster.GetName(), err)
+			}
+		}
+	} else {
+		utils.DebugMessage(fmt.Sprintf("Failed to list the KubeBlocks clusters of namespace %s: %v", namespace, err))
+	}
+
+	if dbType == utils.DB_EXTERNAL {
+		secret := fmt.Sprintf("%s-conn-credential", name)
+		utils.InfoMessage(fmt.Sprintf("Removing external db secret %s...", secret))
+		if err := client.CoreV1().Secrets(namespace).Delete(context.TODO(), secret, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete secret %s: %w", secret, err)
+		}
+	}
+
+	if removeNS {
+		utils.InfoMessage(fmt.Sprintf("Removing namespace %s...", namespace))
+		if err := client.CoreV1().Namespaces().Delete(context.TODO(), namespace, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete namespace %s: %w", namespace, err)
+		}
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("Removed example %s", name))
+	return nil
+}
@@ -0,0 +1,90 @@
+package example
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/grapple-solution/grapple_cli/utils"
+)
+
+const (
+	examplesRepoURL = "https://github.com/grapple-solution/grpl-gras-examples.git"
+	// defaultExamplesRef is the branch deployed without --examples-ref
+	defaultExamplesRef = "main"
+)
+
+var (
+	// examplesRef is the branch, tag or commit of the examples repository to deploy
+	examplesRef string
+	// examplesPath is a local copy of the examples repository, used instead of the cached clone
+	examplesPath string
+)
+
+// resolveExamplesRepo returns the directory of the examples: --examples-path, or the clone of the examples
+// repository cached in ~/.cache/grpl/examples, updated and checked out at --examples-ref. When the update fails
+// (e.g. offline) the cached clone is used as is, with --offline it isn't updated at all.
+func resolveExamplesRepo() (string, error) {
+	if examplesPath != "" {
+		if info, err := os.Stat(examplesPath); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("%w: --examples-path %s is not a directory", utils.ErrValidation, examplesPath)
+		}
+		utils.InfoMessage(fmt.Sprintf("Using the examples of %s", examplesPath))
+		return examplesPath, nil
+	}
+
+	cacheDir, err := utils.ExamplesCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cacheDir, "grpl-gras-examples")
+
+	repo, err := git.PlainOpen(dir)
+	switch {
+	case err != nil && utils.OfflineMode:
+		return "", fmt.Errorf("%w: the examples repository is not cached, deploy an example once while online or use --examples-path",
+			utils.ErrValidation)
+	case err != nil:
+		if err := os.RemoveAll(dir); err != nil {
+			return "", fmt.Errorf("failed to clean the examples cache: %w", err)
+		}
+		utils.InfoMessage("Cloning examples repository...")
+		repo, err = git.PlainClone(dir, false, &git.CloneOptions{URL: examplesRepoURL})
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("failed to clone %s: %w (use --examples-path to deploy from a local copy)", examplesRepoURL, err)
+		}
+	case !utils.OfflineMode:
+		utils.InfoMessage("Updating examples repository...")
+		err := repo.Fetch(&git.FetchOptions{Tags: git.AllTags, Force: true})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			utils.InfoMessage(fmt.Sprintf("Failed to update the examples repository, using the cached clone: %v", err))
+		}
+	}
+
+	ref := examplesRef
+	if ref == "" {
+		ref = defaultExamplesRef
+	}
+	// Branches are taken from the remote, the local branch of the clone is not updated by a fetch
+	hash, err := repo.ResolveRevision(plumbing.Revision("refs/remotes/origin/" + ref))
+	if err != nil {
+		hash, err = repo.ResolveRevision(plumbing.Revision(ref))
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: examples ref %s not found: %w", utils.ErrValidation, ref, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Hash: *hash, Force: true}); err != nil {
+		return "", fmt.Errorf("failed to checkout %s: %w", ref, err)
+	}
+	utils.InfoMessage(fmt.Sprintf("Using examples %s (%s)", ref, hash.String()[:7]))
+	return dir, nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package execenv
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	namespace string
+	shell     string
+)
+
+// envVars are the variables exported by exec-env, in this order
+var envVars = []string{"NAMESPACE", "GRAPI_URL", "GRUIM_URL", "DB_HOST", "DB_SECRET_NAME"}
+
+// ExecEnvCmd represents the exec-env command
+var ExecEnvCmd = &cobra.Command{
+	Use:   "exec-env [gras-name]",
+	Short: "Print shell exports with the connection variables of a GrappleApplicationSet",
+	Long: `Print shell exports with the connection variables of a GrappleApplicationSet (GRAS), so scripts and
+local dev servers can pick them up with eval instead of copying values from kubectl:
+
+  NAMESPACE       namespace of the GRAS
+  GRAPI_URL       URL of the grapi ingress, or of the grapi service inside the cluster
+  GRUIM_URL       URL of the gruim ingress, or of the gruim service inside the cluster
+  DB_HOST         host of the datasource, from the credential secret of grapi
+  DB_SECRET_NAME  name of the secret with the host, port, username and password of the datasource
+
+Variables that don't apply to the GRAS (e.g. DB_HOST of a GRAS without database) are exported empty. Without
+a name, the GRAS is selected from the namespace. Use -o json or -o yaml for the values without exports.
+
+Example:
+  eval $(grapple exec-env -n my-namespace my-app)
+  grapple exec-env my-app --shell fish | source`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExecEnv,
+}
+
+func init() {
+	ExecEnvCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace of the GRAS (default: namespace of the current context)")
+	ExecEnvCmd.Flags().StringVar(&shell, "shell", "sh", "Syntax of the exports: sh (bash, zsh), fish or powershell")
+	_ = ExecEnvCmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions([]string{"sh", "fish", "powershell"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func runExecEnv(cmd *cobra.Command, args []string) error {
+	if shell != "sh" && shell != "fish" && shell != "powershell" {
+		return fmt.Errorf("%w: --shell must be sh, fish or powershell, got %q", utils.ErrValidation, shell)
+	}
+
+	restConfig, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if namespace == "" {
+		namespace = utils.KubeContextNamespace()
+	}
+
+	grasName := ""
+	if len(args) == 1 {
+		grasName = args[0]
+	} else {
+		names, err := utils.ListGrasNames(restConfig, namespace)
+		if err != nil {
+			return err
+		}
+		switch len(names[namespace]) {
+		case 0:
+			return fmt.Errorf("%w: no GrappleApplicationSet found in namespace %s", utils.ErrValidation, namespace)
+		case 1:
+			grasName = names[namespace][0]
+		default:
+			if grasName, err = utils.PromptSelect("Select GRAS", names[namespace]); err != nil {
+				return err
+			}
+		}
+	}
+
+	gras, err := utils.GetGras(restConfig, namespace, grasName)
+	if err != nil {
+		return err
+	}
+	env, err := collectEnv(kubeClient, gras)
+	if err != nil {
+		return err
+	}
+
+	return utils.PrintResult(env, func() {
+		for _, key := range envVars {
+			fmt.Println(exportLine(key, env[key]))
+		}
+	})
+}
+
+// collectEnv looks up the URLs and the datasource of the GRAS
+func collectEnv(kubeClient *kubernetes.Clientset, gras *unstructured.Unstructured) (map[string]string, error) {
+	name := gras.GetName()
+	env := map[string]string{"NAMESPACE": namespace}
+
+	ingresses, err := kubeClient.NetworkingV1().Ingresses(namespace).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses of namespace %s: %w", namespace, err)
+	}
+	services, err := kubeClient.CoreV1().Services(namespace).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services of namespace %s: %w", namespace, err)
+	}
+	env["GRAPI_URL"] = serviceURL(name+"-grapi", ingresses.Items, services.Items)
+	env["GRUIM_URL"] = serviceURL(name+"-gruim", ingresses.Items, services.Items)
+
+	secretName := dbSecretName(gras)
+	if secretName != "" {
+		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), secretName, v1.GetOptions{})
+		if err == nil {
+			env["DB_SECRET_NAME"] = secretName
+			env["DB_HOST"] = string(secret.Data["host"])
+		}
+	}
+	return env, nil
+}
+
+// serviceURL returns the URL of the first ingress rule routing to the service, or the in-cluster URL of the
+// service when it has no ingress
+func serviceURL(serviceName string, ingresses []networkingv1.Ingress, services []corev1.Service) string {
+	for _, ingress := range ingresses {
+		tlsHosts := map[string]bool{}
+		for _, tls := range ingress.Spec.TLS {
+			for _, host := range tls.Hosts {
+				tlsHosts[host] = true
+			}
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil || rule.Host == "" {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service == nil || path.Backend.Service.Name != serviceName {
+					continue
+				}
+				scheme := "http"
+				if tlsHosts[rule.Host] {
+					scheme = "https"
+				}
+				return scheme + "://" + rule.Host + strings.TrimSuffix(path.Path, "/")
+			}
+		}
+	}
+	for _, service := range services {
+		if service.Name == serviceName && len(service.Spec.Ports) > 0 {
+			return fmt.Sprintf("http://%s.%s.svc:%d", service.Name, service.Namespace, service.Spec.Ports[0].Port)
+		}
+	}
+	return ""
+}
+
+// dbSecretName returns the credential secret grapi reads its datasource from, deploy names it
+// <gras-name>-conn-credential and lists it in the extraSecrets of the grapi
+func dbSecretName(gras *unstructured.Unstructured) string {
+	grapis, _, _ := unstructured.NestedSlice(gras.Object, "spec", "grapis")
+	var secrets []string
+	for _, grapi := range grapis {
+		grapiMap, ok := grapi.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		extraSecrets, _, _ := unstructured.NestedStringSlice(grapiMap, "spec", "extraSecrets")
+		secrets = append(secrets, extraSecrets...)
+	}
+	sort.Strings(secrets)
+	for _, secret := range secrets {
+		if strings.HasSuffix(secret, "-conn-credential") {
+			return secret
+		}
+	}
+	if len(secrets) > 0 {
+		return secrets[0]
+	}
+	return gras.GetName() + "-conn-credential"
+}
+
+// exportLine formats an export in the syntax of --shell, values are quoted so they are never interpreted
+func exportLine(key, value string) string {
+	switch shell {
+	case "fish":
+		return fmt.Sprintf("set -gx %s '%s';", key, strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value))
+	case "powershell":
+		return fmt.Sprintf("$env:%s = '%s'", key, strings.ReplaceAll(value, "'", "''"))
+	default:
+		return fmt.Sprintf("export %s='%s';", key, strings.ReplaceAll(value, "'", `'\''`))
+	}
+}
@@ -0,0 +1,259 @@
+package gke
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+)
+
+// Command-line flags
+var (
+	// Common flags
+	autoConfirm       bool
+	clusterName       string
+	gcpProject        string
+	gkeLocation       string
+	gkeEmailAddress   string
+	installKubeblocks bool
+
+	// Installation specific flags
+	grappleVersion        string
+	clusterIP             string
+	grappleDNS            string
+	organization          string
+	waitForReady          bool
+	sslEnable             bool
+	sslIssuer             string
+	completeDomain        string
+	grappleLicense        string
+	hostedZoneID          string
+	ingressController     string
+	additionalValuesFiles []string
+	providerValues        string
+	imagePullSecret       string
+	labels                map[string]string
+	annotations           map[string]string
+)
+
+const (
+	gkeAPIURL              = "https://container.googleapis.com/v1"
+	resourceManagerAPIURL  = "https://cloudresourcemanager.googleapis.com/v1"
+	gkeClusterStatusActive = "RUNNING"
+)
+
+// gkeCluster holds the fields of the GKE cluster resource the CLI needs
+type gkeCluster struct {
+	Name       string `json:"name"`
+	Location   string `json:"location"`
+	Status     string `json:"status"`
+	Endpoint   string `json:"endpoint"`
+	MasterAuth struct {
+		ClusterCaCertificate string `json:"clusterCaCertificate"`
+	} `json:"masterAuth"`
+}
+
+// getGcpAccessToken returns an OAuth access token for the Google APIs, either from
+// GOOGLE_OAUTH_ACCESS_TOKEN or from the gcloud CLI
+func getGcpAccessToken() (string, error) {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token != "" {
+		return token, nil
+	}
+
+	if _, err := exec.LookPath("gcloud"); err != nil {
+		return "", fmt.Errorf("no GOOGLE_OAUTH_ACCESS_TOKEN set and gcloud CLI not found, please install gcloud and run 'gcloud auth login'")
+	}
+
+	out, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token from gcloud, please run 'gcloud auth login': %w", err)
+	}
+	token = strings.TrimSpace(string(out))
+
+	// Set the token as environment variable so it is only fetched once
+	if err := os.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", token); err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to set GOOGLE_OAUTH_ACCESS_TOKEN environment variable: %v", err))
+	}
+
+	return token, nil
+}
+
+// googleAPIGet performs an authenticated GET against a Google API and decodes the JSON response into out
+func googleAPIGet(token, url string, out interface{}) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("request to %s failed with status %d: %s", url, resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// listGcpProjects returns the IDs of all active projects the token has access to
+func listGcpProjects(token string) ([]string, error) {
+	var projectIDs []string
+	pageToken := ""
+	for {
+		var resp struct {
+			Projects []struct {
+				ProjectID      string `json:"projectId"`
+				LifecycleState string `json:"lifecycleState"`
+			} `json:"projects"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		url := resourceManagerAPIURL + "/projects?filter=lifecycleState:ACTIVE"
+		if pageToken != "" {
+			url += "&pageToken=" + pageToken
+		}
+		if err := googleAPIGet(token, url, &resp); err != nil {
+			return nil, fmt.Errorf("failed to list projects: %w", err)
+		}
+		for _, p := range resp.Projects {
+			projectIDs = append(projectIDs, p.ProjectID)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	return projectIDs, nil
+}
+
+// listGkeClusters returns all clusters of the project across all locations
+func listGkeClusters(token, project string) ([]gkeCluster, error) {
+	var resp struct {
+		Clusters []gkeCluster `json:"clusters"`
+	}
+	url := fmt.Sprintf("%s/projects/%s/locations/-/clusters", gkeAPIURL, project)
+	if err := googleAPIGet(token, url, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	return resp.Clusters, nil
+}
+
+// getGkeCluster fetches a single cluster
+func getGkeCluster(token, project, location, name string) (*gkeCluster, error) {
+	cluster := &gkeCluster{}
+	url := fmt.Sprintf("%s/projects/%s/locations/%s/clusters/%s", gkeAPIURL, project, location, name)
+	if err := googleAPIGet(token, url, cluster); err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	return cluster, nil
+}
+
+// selectProject prompts for the GCP project if it was not passed as a flag
+func selectProject(token string) error {
+	if gcpProject != "" {
+		return nil
+	}
+	if p := os.Getenv("GOOGLE_CLOUD_PROJECT"); p != "" {
+		gcpProject = p
+		utils.InfoMessage(fmt.Sprintf("Using GCP project from GOOGLE_CLOUD_PROJECT: %s", gcpProject))
+		return nil
+	}
+
+	projects, err := listGcpProjects(token)
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		return fmt.Errorf("no active GCP projects found")
+	}
+	result, err := utils.PromptSelect("Select GCP project", projects)
+	if err != nil {
+		return fmt.Errorf("project selection is required")
+	}
+	gcpProject = result
+	return nil
+}
+
+// findCluster resolves the cluster by name (and location if set), prompting for it if no name was given
+func findCluster(token string) (*gkeCluster, error) {
+	clusters, err := listGkeClusters(token, gcpProject)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("no clusters found in project %s", gcpProject)
+	}
+
+	if clusterName == "" {
+		clusterNames := make([]string, len(clusters))
+		for i, c := range clusters {
+			clusterNames[i] = fmt.Sprintf("%s (%s)", c.Name, c.Location)
+		}
+		result, err := utils.PromptSelect("Select GKE cluster", clusterNames)
+		if err != nil {
+			return nil, fmt.Errorf("cluster selection is required")
+		}
+		for i, n := range clusterNames {
+			if n == result {
+				clusterName = clusters[i].Name
+				gkeLocation = clusters[i].Location
+				break
+			}
+		}
+	}
+
+	for _, c := range clusters {
+		if c.Name == clusterName && (gkeLocation == "" || c.Location == gkeLocation) {
+			gkeLocation = c.Location
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("no cluster found with name '%s' in project %s", clusterName, gcpProject)
+}
+
+// Wait for the cluster to be ready
+func waitForClusterReady(token string, cluster *gkeCluster) (*gkeCluster, error) {
+	timeout := 15 * time.Minute
+	progress := utils.StartWaitProgress(fmt.Sprintf("cluster '%s' to be ready", cluster.Name), timeout)
+	defer progress.Stop()
+
+	poller := utils.NewPoller()
+	endTime := time.Now().Add(timeout)
+
+	for time.Now().Before(endTime) {
+		status, err := getGkeCluster(token, gcpProject, cluster.Location, cluster.Name)
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Error fetching cluster status: %v", err))
+			if err := poller.Wait(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if status.Status == gkeClusterStatusActive {
+			progress.Done("Cluster is ready.")
+			return status, nil
+		}
+		utils.InfoMessage(fmt.Sprintf("Cluster status: %s", status.Status))
+		if err := poller.Wait(); err != nil {
+			return nil, err
+		}
+	}
+
+	utils.ErrorMessage(fmt.Sprintf("Cluster '%s' was not ready within the timeout", cluster.Name))
+	return nil, fmt.Errorf("cluster '%s' was not ready within the timeout", cluster.Name)
+}
@@ -0,0 +1,156 @@
+package gke
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ConnectCmd represents the connect command
+var ConnectCmd = &cobra.Command{
+	Use:     "connect",
+	Aliases: []string{"conn"},
+	Short:   "Connect to an existing GKE cluster",
+	Long: `Connect to an existing Google Kubernetes Engine cluster and configure kubectl.
+This will update your kubeconfig file to allow kubectl access to the cluster (requires gke-gcloud-auth-plugin).`,
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE:        runConnect,
+}
+
+func init() {
+	ConnectCmd.Flags().StringVar(&clusterName, "cluster-name", "", "Name of the cluster to connect to")
+	ConnectCmd.Flags().StringVar(&gcpProject, "gcp-project", "", "GCP project the cluster belongs to")
+	ConnectCmd.Flags().StringVar(&gkeLocation, "gke-location", "", "Region or zone of the cluster")
+}
+
+// Function to handle the "connect" command logic
+func connectToCluster(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_gke_connect.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to connect to cluster, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	token, err := getGcpAccessToken()
+	if err != nil {
+		utils.ErrorMessage(err.Error())
+		return err
+	}
+
+	if err = selectProject(token); err != nil {
+		utils.ErrorMessage(err.Error())
+		return err
+	}
+
+	cluster, err := findCluster(token)
+	if err != nil {
+		utils.ErrorMessage(err.Error())
+		return err
+	}
+
+	utils.InfoMessage("Configuring kubectl for the cluster...")
+	if _, err = configureKubeConfig(cluster, token); err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to configure kubectl for cluster '%s': %v", cluster.Name, err))
+		return err
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("Successfully connected to cluster '%s'", cluster.Name))
+	return nil
+}
+
+// gkeKubeConfig builds the same kubeconfig entry 'gcloud container clusters get-credentials' would write
+func gkeKubeConfig(cluster *gkeCluster) (*clientcmdapi.Config, string, error) {
+	caData, err := base64.StdEncoding.DecodeString(cluster.MasterAuth.ClusterCaCertificate)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode cluster CA certificate: %w", err)
+	}
+
+	name := fmt.Sprintf("gke_%s_%s_%s", gcpProject, cluster.Location, cluster.Name)
+	config := clientcmdapi.NewConfig()
+	config.Clusters[name] = &clientcmdapi.Cluster{
+		Server:                   "https://" + cluster.Endpoint,
+		CertificateAuthorityData: caData,
+	}
+	config.AuthInfos[name] = &clientcmdapi.AuthInfo{
+		Exec: &clientcmdapi.ExecConfig{
+			APIVersion:         "client.authentication.k8s.io/v1beta1",
+			Command:            "gke-gcloud-auth-plugin",
+			InstallHint:        "Install gke-gcloud-auth-plugin for use with kubectl by following https://cloud.google.com/kubernetes-engine/docs/how-to/cluster-access-for-kubectl#install_plugin",
+			ProvideClusterInfo: true,
+			InteractiveMode:    clientcmdapi.IfAvailableExecInteractiveMode,
+		},
+	}
+	config.Contexts[name] = &clientcmdapi.Context{
+		Cluster:  name,
+		AuthInfo: name,
+	}
+	config.CurrentContext = name
+	return config, name, nil
+}
+
+// configureKubeConfig merges the cluster into ~/.kube/config and returns a rest config
+// that authenticates with the given access token
+func configureKubeConfig(cluster *gkeCluster, token string) (*rest.Config, error) {
+	newConfig, contextName, err := gkeKubeConfig(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge it into the kubeconfig and switch to its context
+	if err := utils.MergeKubeconfig(newConfig, contextName); err != nil {
+		return nil, err
+	}
+
+	// The CLI itself talks to the cluster with the access token, so the auth plugin
+	// is only needed for kubectl
+	config := &rest.Config{
+		Host:        "https://" + cluster.Endpoint,
+		BearerToken: token,
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData: newConfig.Clusters[contextName].CertificateAuthorityData,
+		},
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	// Test client
+	_, err = clientset.CoreV1().Namespaces().List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to test Kubernetes client: %w", err)
+	}
+
+	utils.SuccessMessage("Kubeconfig configured successfully.")
+	return config, nil
+}
+
+// runConnect connects to the cluster and prints the resulting connection with --output json|yaml
+func runConnect(cmd *cobra.Command, args []string) error {
+	if err := connectToCluster(cmd, args); err != nil {
+		return err
+	}
+	if utils.IsStructuredOutput() {
+		return utils.PrintClusterConnection(utils.ProviderClusterTypeGke, clusterName, gkeLocation)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package gke
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// GkeCmd represents the gke command
+var GkeCmd = &cobra.Command{
+	Use:     "gke",
+	Aliases: []string{"g"},
+	Short:   "Google Kubernetes Engine operations",
+	Long:    "Commands related to operations on Google Kubernetes Engine (GKE) clusters.",
+}
+
+func init() {
+	// Initialize subcommands for gke
+	GkeCmd.AddCommand(InstallCmd)
+	GkeCmd.AddCommand(ConnectCmd)
+}
@@ -0,0 +1,478 @@
+package gke
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/installer"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// InstallCmd represents the install command
+var InstallCmd = &cobra.Command{
+	Use:     "install",
+	Aliases: []string{"i"},
+	Short:   "Install Grapple on a GKE cluster (step by step)",
+	Long: `Installs Grapple components (grsf-init, grsf, grsf-config, grsf-integration)
+sequentially on a Google Kubernetes Engine cluster, waiting for required resources in between.`,
+	RunE: runInstallStepByStep,
+}
+
+// init sets up flags for install
+func init() {
+	InstallCmd.Flags().StringVar(&grappleVersion, "grapple-version", "latest", "Version of Grapple to install")
+	InstallCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts")
+	InstallCmd.Flags().StringVar(&gcpProject, "gcp-project", "", "GCP project the cluster belongs to")
+	InstallCmd.Flags().StringVar(&gkeLocation, "gke-location", "", "Region or zone of the cluster")
+	InstallCmd.Flags().StringVar(&clusterName, "cluster-name", "", "GKE cluster name")
+	InstallCmd.Flags().StringVar(&gkeEmailAddress, "email-address", "", "Email address")
+	InstallCmd.Flags().StringVar(&clusterIP, "cluster-ip", "", "Cluster IP")
+	InstallCmd.Flags().StringVar(&grappleDNS, "grapple-dns", "", "Domain for Grapple (default: {cluster-name}.grapple-demo.com)")
+	InstallCmd.Flags().StringVar(&organization, "organization", "", "Organization name (default: grapple-solutions)")
+	InstallCmd.Flags().BoolVar(&installKubeblocks, "install-kubeblocks", false, "Install Kubeblocks in background")
+	InstallCmd.Flags().BoolVar(&utils.OfflineMode, "offline", false, "Resolve charts only from the chart cache, see 'grapple cache pull'")
+	InstallCmd.Flags().BoolVar(&utils.SkipPreflight, "skip-preflight", false, "Skip the preflight checks of the cluster, see 'grapple preflight'")
+	InstallCmd.Flags().BoolVar(&utils.ClusterLogEnabled, "log-to-cluster", false, "Mirror the sanitized install log to the grpl-install-log ConfigMap for support")
+	InstallCmd.Flags().BoolVar(&waitForReady, "wait", false, "Wait for Grapple to be fully ready at the end")
+	InstallCmd.Flags().BoolVar(&sslEnable, "ssl", false, "Enable SSL usage")
+	InstallCmd.Flags().StringVar(&sslIssuer, "ssl-issuer", "letsencrypt-grapple-demo", "SSL Issuer")
+	InstallCmd.Flags().StringVar(&hostedZoneID, "hosted-zone-id", "", "AWS Route53 Hosted Zone ID (Inside Grapple's account) for DNS management")
+	InstallCmd.Flags().StringVar(&ingressController, "ingress-controller", "nginx", "First checks if an Ingress Controller is already installed, if not, then it can be 'nginx' or 'gce'")
+	InstallCmd.Flags().StringSliceVar(&additionalValuesFiles, "values", []string{}, "Specify values files to use (can specify multiple times using following format: --values=values1.yaml,values2.yaml)")
+	installer.AddProviderValuesFlag(InstallCmd, &providerValues)
+	InstallCmd.Flags().StringVar(&imagePullSecret, "image-pull-secret", "", "Image pull secret for private repositories")
+	InstallCmd.Flags().StringVar(&utils.PriorityClass, "priority-class", "", "PriorityClass of the grsf components and KubeBlocks, created if it doesn't exist (e.g: --priority-class=grpl-platform)")
+	InstallCmd.Flags().StringToStringVar(&labels, "labels", map[string]string{}, "Labels to add to all generated resources (e.g: --labels=team=platform,cost-center=1234)")
+	InstallCmd.Flags().StringToStringVar(&annotations, "annotations", map[string]string{}, "Annotations to add to all generated resources (e.g: --annotations=owner=platform)")
+}
+
+// runInstallStepByStep is the main function
+func runInstallStepByStep(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_gke_install.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, logOnFileStart, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		utils.MirrorLogToCluster(logFilePath, "gke install")
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to install grpl, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	// Start logging to both CLI and file
+	logOnCliAndFileStart()
+
+	utils.SetCommonMetadata(labels, annotations)
+
+	// 1) Resolve the GKE cluster, fetch its kubeconfig and build a Kube client
+	kubeClient, restConfig, err := initClientsAndConfig()
+	if err != nil {
+		return err
+	}
+
+	if err = installer.PromptKubeblocks(cmd, &installKubeblocks); err != nil {
+		return err
+	}
+
+	err = installer.Run(gkeProvider{}, &installer.Installation{
+		ClusterType:       utils.ProviderClusterTypeGke,
+		ClusterName:       clusterName,
+		GrappleVersion:    grappleVersion,
+		Domain:            completeDomain,
+		Organization:      organization,
+		Email:             gkeEmailAddress,
+		License:           grappleLicense,
+		ImagePullSecret:   imagePullSecret,
+		SSL:               sslEnable,
+		SSLIssuer:         sslIssuer,
+		InstallKubeblocks: installKubeblocks,
+		WaitForReady:      waitForReady,
+		AutoConfirm:       autoConfirm,
+		ValuesFiles:       additionalValuesFiles,
+		ProviderValues:    providerValues,
+		Summary: []string{
+			fmt.Sprintf("gcp-project: %s", gcpProject),
+			fmt.Sprintf("gke-location: %s", gkeLocation),
+			fmt.Sprintf("ingress-controller: %s", ingressController),
+		},
+		KubeClient:           kubeClient,
+		RestConfig:           restConfig,
+		LogOnFileStart:       logOnFileStart,
+		LogOnCliAndFileStart: logOnCliAndFileStart,
+	})
+	return err
+}
+
+// gkeProvider is the GKE part of the installation
+type gkeProvider struct{}
+
+func (gkeProvider) PrepareCluster(inst *installer.Installation) error {
+	if err := setupIngressController(inst.RestConfig, inst.LogOnFileStart, inst.LogOnCliAndFileStart); err != nil {
+		return fmt.Errorf("failed to setup ingress controller: %w", err)
+	}
+	return nil
+}
+
+func (gkeProvider) ClusterIP(inst *installer.Installation) (string, error) {
+	// The GCE ingress controller creates one load balancer per Ingress, so there is no
+	// controller service to take the IP from, it is looked up once an Ingress exists
+	if ingressController == "gce" {
+		return clusterIP, nil
+	}
+	utils.InfoMessage("waiting for loadbalancer to be ready...")
+	ip, err := utils.GetClusterExternalIP(inst.RestConfig, ingressController)
+	if err != nil {
+		return "", fmt.Errorf("failed to get gke cluster IP: %w", err)
+	}
+	utils.SuccessMessage("Loadbalancer setup completed.")
+	return ip, nil
+}
+
+func (gkeProvider) ExtraValues(inst *installer.Installation) (map[string]interface{}, []string, error) {
+	return map[string]interface{}{
+		"providerClusterType": "gke",
+		"config": map[string]interface{}{
+			utils.SecKeyGkeProject:  gcpProject,
+			utils.SecKeyGkeLocation: gkeLocation,
+		},
+	}, nil, nil
+}
+
+func (gkeProvider) PostInstall(inst *installer.Installation) error {
+	if err := installer.EnableSSL(inst, ingressController); err != nil {
+		return err
+	}
+	return installer.SetupDomainRecords(inst, installer.DomainRecords{
+		Cloud:        "gke",
+		DNS:          grappleDNS,
+		HostedZoneID: hostedZoneID,
+		WaitForIP: func() (string, error) {
+			utils.InfoMessage("Waiting for the GCE load balancer of the verification server, it might take a few minutes...")
+			return waitForIngressExternalIP(inst.RestConfig, "verification-server")
+		},
+	})
+}
+
+// -----------------------------------------------------------------------------
+// initClientsAndConfig: does the following:
+// 1) Get an access token and resolve project/cluster via the GKE API
+// 2) Wait for the cluster to be ready and write its kubeconfig
+// 3) Build a K8s client-go client
+// -----------------------------------------------------------------------------
+func initClientsAndConfig() (apiv1.Interface, *rest.Config, error) {
+	token, err := getGcpAccessToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := selectProject(token); err != nil {
+		return nil, nil, err
+	}
+
+	cluster, err := findCluster(token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get gke cluster: %w", err)
+	}
+
+	if cluster.Status != gkeClusterStatusActive {
+		utils.InfoMessage("Waiting for cluster to be ready...")
+		cluster, err = waitForClusterReady(token, cluster)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	utils.InfoMessage("Configuring kubectl for the cluster...")
+	restConfig, err := configureKubeConfig(cluster, token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to gke cluster: %w", err)
+	}
+
+	k8sClient, err := apiv1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	// Get email address if not provided
+	if gkeEmailAddress == "" {
+		result, err := prompt.Input(prompt.Question{
+			Label:    "Enter email address",
+			Flag:     "--email-address",
+			History:  "email-address",
+			Validate: prompt.MatchRegex(utils.EmailRegex),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get email address: %w", err)
+		}
+		gkeEmailAddress = result
+
+		// Set organization from email domain if not already set
+		if organization == "" {
+			parts := strings.Split(gkeEmailAddress, "@")
+			if len(parts) == 2 {
+				organization = parts[1]
+			}
+		}
+	}
+
+	grappleVersion = installer.GrappleVersion(grappleVersion)
+	grappleDNS, completeDomain = installer.ResolveDomain(grappleDNS, clusterName, hostedZoneID, false)
+	if organization == "" {
+		organization = installer.DefaultOrganization
+	}
+	// Reinstalls keep the license of the grsf-config secret
+	grappleLicense = installer.ExistingLicense(k8sClient)
+
+	return k8sClient, restConfig, nil
+}
+
+func setupIngressController(restConfig *rest.Config, logOnFileStart, logOnCliAndFileStart func()) error {
+	// Create a k8s client
+	clientset, err := apiv1.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	// List all IngressClasses
+	ingClassList, err := clientset.NetworkingV1().IngressClasses().List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list IngressClasses: %w", err)
+	}
+
+	// Check if any IngressClass is set as default
+	for _, ingClass := range ingClassList.Items {
+		if val, ok := ingClass.Annotations["ingressclass.kubernetes.io/is-default-class"]; ok && (val == "true" || val == "True") {
+			ingressController = ingClass.Name
+			utils.InfoMessage(fmt.Sprintf("Found default IngressClass: %s", ingClass.Name))
+			utils.InfoMessage("A default IngressClass is already set. Proceeding with installation.")
+			return nil
+		}
+	}
+
+	if len(ingClassList.Items) > 0 {
+		utils.ErrorMessage("No IngressClass is set as default. Please set one of the following IngressClasses as default before proceeding:")
+		for _, ingClass := range ingClassList.Items {
+			utils.InfoMessage(fmt.Sprintf("  - Name: %s\n", ingClass.Name))
+		}
+		return fmt.Errorf("no IngressClass is set as default; please set one as default and rerun the installer")
+	}
+
+	logOnFileStart()
+	// If no IngressClass exists, set up the requested ingress controller
+	var ingressErr error
+	if ingressController == "gce" {
+		ingressErr = setupGCEIngressClass(clientset)
+	} else if ingressController == "nginx" {
+		ingressErr = setupNginx(restConfig)
+	} else {
+		logOnCliAndFileStart()
+		utils.InfoMessage(fmt.Sprintf("invalid ingress controller: %s", ingressController))
+		utils.InfoMessage("using default ingress controller: nginx")
+		ingressController = "nginx"
+		logOnFileStart()
+		ingressErr = setupNginx(restConfig)
+	}
+	logOnCliAndFileStart()
+	if ingressErr != nil {
+		return fmt.Errorf("failed to setup ingress controller: %w", ingressErr)
+	}
+	return nil
+}
+
+// setupGCEIngressClass registers the GKE built-in ingress controller as the default IngressClass
+func setupGCEIngressClass(clientset apiv1.Interface) error {
+	ingClass := &networkingv1.IngressClass{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "gce",
+			Annotations: map[string]string{
+				"ingressclass.kubernetes.io/is-default-class": "true",
+			},
+		},
+		Spec: networkingv1.IngressClassSpec{
+			Controller: "k8s.io/ingress-gce",
+		},
+	}
+	utils.ApplyCommonMetadata(&ingClass.ObjectMeta)
+
+	_, err := clientset.NetworkingV1().IngressClasses().Create(context.TODO(), ingClass, v1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create gce IngressClass: %w", err)
+	}
+	utils.InfoMessage("GCE IngressClass configured successfully")
+	return nil
+}
+
+// waitForIngressExternalIP waits until an Ingress in the namespace got an address from the GCE load balancer
+func waitForIngressExternalIP(restConfig *rest.Config, namespace string) (string, error) {
+	clientset, err := apiv1.NewForConfig(restConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	maxWait := 10 * time.Minute
+	progress := utils.StartWaitProgress(fmt.Sprintf("an ingress address in namespace '%s'", namespace), maxWait)
+	defer progress.Stop()
+
+	poller := utils.NewPoller()
+	deadline := time.Now().Add(maxWait)
+	for time.Now().Before(deadline) {
+		ingresses, err := clientset.NetworkingV1().Ingresses(namespace).List(context.TODO(), v1.ListOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to list ingresses: %w", err)
+		}
+		for _, ing := range ingresses.Items {
+			for _, lb := range ing.Status.LoadBalancer.Ingress {
+				if lb.IP != "" {
+					progress.Done(fmt.Sprintf("External IP for Ingress '%s/%s': %s", ing.Namespace, ing.Name, lb.IP))
+					return lb.IP, nil
+				}
+			}
+		}
+		fmt.Print(".")
+		if err := poller.Wait(); err != nil {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("timeout: no external IP assigned to an ingress in namespace '%s' within %v", namespace, maxWait)
+}
+
+func setupNginx(restConfig *rest.Config) error {
+	utils.StartSpinner("Setting up NGINX Ingress Controller...")
+	defer utils.StopSpinner()
+
+	// Initialize Helm client
+	helmCfg, err := utils.GetHelmConfig(restConfig, "ingress-nginx")
+	if err != nil {
+		utils.ErrorMessage("Failed to initialize Helm configuration: " + err.Error())
+		return err
+	}
+
+	// Check if NGINX is already installed
+	listClient := action.NewList(helmCfg)
+	listClient.AllNamespaces = true
+	releases, err := listClient.Run()
+	if err != nil {
+		utils.ErrorMessage("Failed to list releases: " + err.Error())
+		return err
+	}
+
+	for _, release := range releases {
+		if release.Name == "ingress-nginx" {
+			utils.InfoMessage("NGINX Ingress Controller already installed")
+			return nil
+		}
+	}
+
+	utils.InfoMessage("Installing NGINX Ingress Controller...")
+
+	// Create Helm environment settings
+	settings := cli.New()
+	settings.SetNamespace("ingress-nginx")
+
+	// Add the NGINX Ingress Controller Helm repository
+	repoEntry := repo.Entry{
+		Name: "ingress-nginx",
+		URL:  "https://kubernetes.github.io/ingress-nginx",
+	}
+
+	chartRepo, err := repo.NewChartRepository(&repoEntry, getter.All(settings))
+	if err != nil {
+		utils.ErrorMessage("Failed to create chart repository object: " + err.Error())
+		return err
+	}
+
+	// Add repo to repositories.yaml
+	repoFile := settings.RepositoryConfig
+	b, err := os.ReadFile(repoFile)
+	if err != nil && !os.IsNotExist(err) {
+		utils.ErrorMessage("Failed to read repository file: " + err.Error())
+		return err
+	}
+
+	var f repo.File
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		utils.ErrorMessage("Failed to unmarshal repository file: " + err.Error())
+		return err
+	}
+
+	// Add new repo or update existing
+	f.Add(&repoEntry)
+
+	if err := f.WriteFile(repoFile, 0644); err != nil {
+		utils.ErrorMessage("Failed to write repository file: " + err.Error())
+		return err
+	}
+
+	_, err = chartRepo.DownloadIndexFile()
+	if err != nil {
+		utils.ErrorMessage("Failed to download repository index: " + err.Error())
+		return err
+	}
+
+	// Create install client
+	installClient := action.NewInstall(helmCfg)
+	installClient.Namespace = "ingress-nginx"
+	installClient.CreateNamespace = true
+	installClient.ReleaseName = "ingress-nginx"
+	installClient.Version = ""
+
+	// Locate and load the chart
+	chartPath, err := installClient.ChartPathOptions.LocateChart("ingress-nginx/ingress-nginx", settings)
+	if err != nil {
+		utils.ErrorMessage("Failed to locate NGINX Ingress chart: " + err.Error())
+		return fmt.Errorf("%w: %w", utils.ErrChartNotFound, err)
+	}
+
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		utils.ErrorMessage("Failed to load NGINX Ingress chart: " + err.Error())
+		return err
+	}
+
+	// On GKE the controller is exposed through a regular LoadBalancer service
+	values := map[string]interface{}{
+		"controller": map[string]interface{}{
+			"ingressClassResource": map[string]interface{}{
+				"default": true,
+			},
+			"service": map[string]interface{}{
+				"type": "LoadBalancer",
+			},
+			"config": map[string]interface{}{
+				"proxy-body-size": "50m",
+			},
+		},
+	}
+
+	// Install chart
+	_, err = installClient.RunWithContext(utils.CommandContext(), chart, values)
+	if err != nil {
+		utils.ErrorMessage("Failed to install NGINX Ingress Controller: " + err.Error())
+		return err
+	}
+
+	utils.InfoMessage("NGINX Ingress Controller installed successfully")
+	return nil
+}
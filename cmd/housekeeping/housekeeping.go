@@ -0,0 +1,62 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package housekeeping
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var dryRun bool
+
+// HousekeepingCmd represents the housekeeping command
+var HousekeepingCmd = &cobra.Command{
+	Use:     "housekeeping",
+	Aliases: []string{"hk", "prune"},
+	Short:   "Prune the helper pods, jobs and daemonsets left behind by installs",
+	Long: `Housekeeping removes the short lived helper workloads the installs create in the cluster of the
+current kubectl context, like the DNS upsert pod and the image preload daemonsets.
+
+Succeeded helpers are removed right away, failed ones are kept for debugging until they are older
+than --retention. Installs run the housekeeping automatically when they complete successfully.
+
+Example:
+  grapple housekeeping --retention=2h --dry-run`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, kubeClient, err := utils.GetKubernetesConfig()
+		if err != nil {
+			utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+			return err
+		}
+
+		pruned, err := utils.PruneHelperWorkloads(kubeClient, utils.HelperRetention, dryRun)
+		if err != nil {
+			return err
+		}
+
+		return utils.PrintResult(pruned, func() {
+			if len(pruned) == 0 {
+				utils.InfoMessage("Nothing to prune")
+				return
+			}
+			action := "Pruned"
+			if dryRun {
+				action = "Would prune"
+			}
+			for _, obj := range pruned {
+				utils.InfoMessage(fmt.Sprintf("%s %s %s/%s (%s)", action, obj.Kind, obj.Namespace, obj.Name, obj.Reason))
+			}
+			if !dryRun {
+				utils.SuccessMessage(fmt.Sprintf("Pruned %d helper workloads", len(pruned)))
+			}
+		})
+	},
+}
+
+func init() {
+	HousekeepingCmd.Flags().DurationVar(&utils.HelperRetention, "retention", utils.HelperRetention, "How long failed helper workloads are kept for debugging")
+	HousekeepingCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show what would be pruned")
+}
@@ -0,0 +1,20 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package install
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// InstallCmd represents the install command
+var InstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Manage Grapple installations independent of the cluster provider",
+	Long: `Commands that act on the Grapple installation of the cluster of the current kubectl context.
+Grapple itself is installed with the provider commands, e.g. 'grapple k3d install' or 'grapple civo install'.`,
+}
+
+func init() {
+	InstallCmd.AddCommand(RollbackCmd)
+}
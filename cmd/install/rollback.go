@@ -0,0 +1,86 @@
+package install
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var autoConfirm bool
+
+// RollbackCmd represents the install rollback command
+var RollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Remove the releases and namespaces created by the last installation",
+	Long: `Rollback removes, in reverse order, the Helm releases and namespaces that the last Grapple installation
+on the current cluster created, restoring the state before the installation started.
+
+Failed installations are left as they are, to be continued with --resume or removed with this command.
+With --rollback-on-failure they roll back automatically.`,
+	RunE: runRollback,
+}
+
+func init() {
+	RollbackCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts (default: false)")
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_install_rollback.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to roll back installation, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	restConfig, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster: " + err.Error())
+		return err
+	}
+
+	tx, err := utils.LoadInstallTransaction(kubeClient)
+	if err != nil {
+		return err
+	}
+	if tx == nil || len(tx.Steps) == 0 {
+		utils.InfoMessage("No installation to roll back on this cluster")
+		return nil
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Installation of grapple %s on %s cluster %s started at %s, status: %s",
+		tx.Version, tx.Provider, tx.Cluster, tx.StartedAt.Local().Format("2006-01-02 15:04:05"), tx.Status))
+	utils.InfoMessage("The following objects will be removed:")
+	for i := len(tx.Steps) - 1; i >= 0; i-- {
+		step := tx.Steps[i]
+		if step.Namespace != "" {
+			utils.InfoMessage(fmt.Sprintf("  %s %s/%s", step.Kind, step.Namespace, step.Name))
+		} else {
+			utils.InfoMessage(fmt.Sprintf("  %s %s", step.Kind, step.Name))
+		}
+	}
+
+	if tx.Status == utils.InstallStatusCompleted {
+		utils.InfoMessage("This installation completed successfully, rolling it back removes a working Grapple installation")
+	}
+	if !autoConfirm {
+		confirmed, promptErr := utils.PromptConfirm("Proceed with the rollback?")
+		if promptErr != nil || !confirmed {
+			utils.InfoMessage("Rollback cancelled")
+			return nil
+		}
+	}
+
+	err = utils.RollbackInstallTransaction(tx, kubeClient, restConfig)
+	return err
+}
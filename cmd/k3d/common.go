@@ -1,13 +1,17 @@
 package k3d
 
 import (
+	"errors"
 	"os"
+
+	"github.com/grapple-solution/grapple_cli/utils"
 )
 
 // Variables for command flags
 var (
-	grappleVersion string
-	autoConfirm    bool
+	grappleVersion    string
+	autoConfirm       bool
+	rollbackOnFailure bool
 	// clusterName       string
 	clusterIP         string
 	grappleDNS        string
@@ -23,12 +27,21 @@ var (
 	waitForReady          bool
 	skipConfirmation      bool
 	additionalValuesFiles []string
+	providerValues        string
 	server                int
 	agent                 int
 	httpLoadBalancer      string
 	httpsLoadBalancer     string
 	apiPort               string
+	k3sVersion            string
+	registryCreate        string
+	volumes               []string
+	ports                 []string
+	installAfterCreate    bool
+	keepDNS               bool
 	imagePullSecret       string
+	labels                map[string]string
+	annotations           map[string]string
 )
 
 // fileExists checks if a file exists and is not a directory
@@ -39,3 +52,39 @@ func fileExists(filename string) bool {
 	}
 	return !info.IsDir()
 }
+
+// SetAutoConfirm skips the confirmation prompts of the k3d helpers when they are used by other commands
+func SetAutoConfirm(confirm bool) {
+	autoConfirm = confirm
+}
+
+// listK3dClusters returns the names of the k3d clusters
+func listK3dClusters() ([]string, error) {
+	clusters, err := k3dClusters()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, cluster := range clusters {
+		names = append(names, cluster.Name)
+	}
+	return names, nil
+}
+
+// selectK3dCluster returns --cluster-name, or the cluster the user selects
+func selectK3dCluster(prompt string) (string, error) {
+	if clusterName != "" {
+		return clusterName, nil
+	}
+	names, err := listK3dClusters()
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", errors.New("no k3d clusters found")
+	}
+	if len(names) == 1 {
+		return names[0], nil
+	}
+	return utils.PromptSelect(prompt, names)
+}
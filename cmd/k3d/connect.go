@@ -4,12 +4,13 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package k3d
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +20,8 @@ var ConnectCmd = &cobra.Command{
 	Short: "Connect to an existing k3d Kubernetes cluster",
 	Long: `Connect to an existing Kubernetes cluster created with k3d and configure kubectl.
 This will update your kubeconfig file to allow kubectl access to the cluster.`,
-	RunE: connectToCluster,
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE:        runConnect,
 }
 
 func init() {
@@ -54,25 +56,39 @@ func connectToCluster(cmd *cobra.Command, args []string) error {
 
 	// Validate input
 	if clusterName == "" {
-		result, err := utils.PromptInput("Enter cluster name", utils.DefaultValue, utils.NonEmptyValueRegex)
+		result, err := prompt.Input(prompt.Question{
+			Label:    "Enter cluster name",
+			Flag:     "--cluster-name",
+			History:  "k3d-cluster-name",
+			Validate: prompt.MatchRegex(utils.NonEmptyValueRegex),
+		})
 		if err != nil {
 			utils.ErrorMessage("Cluster name is required")
-			return errors.New("cluster name is required")
+			return err
 		}
 		clusterName = result
 	}
 
 	// Check if the cluster exists
 	utils.InfoMessage(fmt.Sprintf("Checking if cluster '%s' exists...", clusterName))
-	checkCmd := exec.Command("k3d", "cluster", "list", clusterName, "-o", "json")
-	output, err := checkCmd.CombinedOutput()
-	if err != nil || len(output) <= 2 { // empty JSON
+	cluster, err := getK3dCluster(clusterName)
+	if err != nil {
+		utils.ErrorMessage(err.Error())
+		return err
+	}
+	if cluster == nil {
 		utils.ErrorMessage(fmt.Sprintf("Cluster with name '%s' does not exist", clusterName))
 		return fmt.Errorf("cluster with name '%s' does not exist", clusterName)
 	}
 
 	// Configure kubectl for the cluster
 	utils.InfoMessage("Configuring kubectl for the cluster...")
+	if backups, err := utils.BackupKubeconfig(); err != nil {
+		utils.ErrorMessage(err.Error())
+		return err
+	} else if len(backups) > 0 {
+		utils.InfoMessage(fmt.Sprintf("Backed up the kubeconfig to %s", strings.Join(backups, ", ")))
+	}
 	configureCmd := exec.Command("k3d", "kubeconfig", "merge", clusterName, "--kubeconfig-merge-default", "--kubeconfig-switch-context")
 	configureCmd.Stdout = os.Stdout
 	configureCmd.Stderr = os.Stderr
@@ -81,7 +97,20 @@ func connectToCluster(cmd *cobra.Command, args []string) error {
 		utils.ErrorMessage(fmt.Sprintf("Failed to configure kubectl for cluster '%s': %v", clusterName, err))
 		return fmt.Errorf("failed to configure kubectl for cluster '%s': %v", clusterName, err)
 	}
+	// k3d names the context of a cluster k3d-<name>
+	utils.PinKubeContext("k3d-" + clusterName)
 
 	utils.SuccessMessage(fmt.Sprintf("Successfully connected to cluster '%s'", clusterName))
 	return nil
 }
+
+// runConnect connects to the cluster and prints the resulting connection with --output json|yaml
+func runConnect(cmd *cobra.Command, args []string) error {
+	if err := connectToCluster(cmd, args); err != nil {
+		return err
+	}
+	if utils.IsStructuredOutput() {
+		return utils.PrintClusterConnection(utils.ProviderClusterTypeK3d, clusterName, "")
+	}
+	return nil
+}
@@ -4,12 +4,12 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package k3d
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 
 	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
 	"github.com/spf13/cobra"
 )
 
@@ -18,8 +18,16 @@ var CreateCmd = &cobra.Command{
 	Use:     "create",
 	Aliases: []string{"c"},
 	Short:   "Create a Kubernetes cluster using k3d",
-	Long:    "Create a new Kubernetes cluster locally using k3d with specified configuration.",
-	RunE:    createCluster,
+	Long: `Create a new Kubernetes cluster locally using k3d with specified configuration: the number of servers and
+agents, the port mappings of the load balancer (80 and 443 for the Grapple ingress), the k3s version, a local
+image registry and volume mounts. With --install, Grapple is installed on the cluster afterwards, like
+'grapple k3d install' with its defaults.
+
+Example:
+  grapple k3d create --cluster-name dev --agents 2 --k3s-version v1.30.4-k3s1
+  grapple k3d create --cluster-name dev --registry-create grpl-registry:0.0.0.0:5000 --volume $HOME/data:/data@all --install`,
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE:        runCreate,
 }
 
 func init() {
@@ -30,6 +38,36 @@ func init() {
 	CreateCmd.Flags().StringVar(&httpLoadBalancer, "http-loadbalancer", "80:80@loadbalancer", "Port mapping for HTTP load balancer")
 	CreateCmd.Flags().StringVar(&httpsLoadBalancer, "https-loadbalancer", "443:443@loadbalancer", "Port mapping for HTTPS load balancer")
 	CreateCmd.Flags().StringVar(&apiPort, "api-port", "6550", "API port for the k3d cluster")
+	CreateCmd.Flags().StringVar(&k3sVersion, "k3s-version", "", "Version of k3s, the tag of the rancher/k3s image (e.g: v1.30.4-k3s1, default: k3d's default)")
+	CreateCmd.Flags().StringVar(&registryCreate, "registry-create", "", "Create a local image registry with the cluster, as NAME[:HOST][:HOSTPORT] (e.g: grpl-registry:0.0.0.0:5000)")
+	CreateCmd.Flags().StringArrayVar(&volumes, "volume", []string{}, "Mount a volume into the nodes, as SOURCE:DEST[@NODEFILTER] (can be repeated)")
+	CreateCmd.Flags().StringArrayVar(&ports, "port", []string{}, "Additional port mapping, as HOSTPORT:CONTAINERPORT@NODEFILTER (can be repeated)")
+	CreateCmd.Flags().BoolVar(&installAfterCreate, "install", false, "Install Grapple on the cluster once it is created")
+}
+
+// runCreate creates the cluster and, with --install, installs Grapple on it
+func runCreate(cmd *cobra.Command, args []string) error {
+	if installAfterCreate {
+		// The cluster has to be ready before Grapple is deployed
+		waitForReady = true
+	}
+	if err := createCluster(cmd, args); err != nil {
+		return err
+	}
+	if !installAfterCreate {
+		return nil
+	}
+
+	// The install flags aren't part of create, they keep the defaults of 'grapple k3d install'
+	if sslIssuer == "" {
+		sslIssuer = "letsencrypt-grapple-demo"
+	}
+	if err := runInstallStepByStep(cmd, args); err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to install Grapple: %v", err))
+		return err
+	}
+	utils.SuccessMessage("Successfully created cluster and installed Grapple!")
+	return nil
 }
 
 // Function to handle the "create" command logic
@@ -56,19 +94,26 @@ func createCluster(cmd *cobra.Command, args []string) error {
 
 	// Validate input
 	if clusterName == "" {
-		result, err := utils.PromptInput("Enter cluster name", utils.DefaultValue, utils.NonEmptyValueRegex)
+		result, err := prompt.Input(prompt.Question{
+			Label:    "Enter cluster name",
+			Flag:     "--cluster-name",
+			Validate: prompt.MatchRegex(utils.NonEmptyValueRegex),
+		})
 		if err != nil {
 			utils.ErrorMessage("Cluster name is required")
-			return errors.New("cluster name is required")
+			return err
 		}
 		clusterName = result
 	}
 
 	// Check if the cluster already exists
 	utils.InfoMessage(fmt.Sprintf("Checking if cluster '%s' already exists...", clusterName))
-	checkCmd := exec.Command("k3d", "cluster", "list", clusterName, "-o", "json")
-	output, err := checkCmd.CombinedOutput()
-	if err == nil && len(output) > 2 { // not empty JSON
+	existing, err := getK3dCluster(clusterName)
+	if err != nil {
+		utils.ErrorMessage(err.Error())
+		return err
+	}
+	if existing != nil {
 		utils.ErrorMessage(fmt.Sprintf("Cluster with name '%s' already exists", clusterName))
 		return fmt.Errorf("cluster with name '%s' already exists", clusterName)
 	}
@@ -83,6 +128,18 @@ func createCluster(cmd *cobra.Command, args []string) error {
 		"-p", httpLoadBalancer,
 		"-p", httpsLoadBalancer,
 	}
+	for _, port := range ports {
+		createCmdArgs = append(createCmdArgs, "-p", port)
+	}
+	for _, volume := range volumes {
+		createCmdArgs = append(createCmdArgs, "--volume", volume)
+	}
+	if k3sVersion != "" {
+		createCmdArgs = append(createCmdArgs, "--image", "rancher/k3s:"+k3sVersion)
+	}
+	if registryCreate != "" {
+		createCmdArgs = append(createCmdArgs, "--registry-create", registryCreate)
+	}
 	if waitForReady {
 		createCmdArgs = append(createCmdArgs, "--wait")
 	}
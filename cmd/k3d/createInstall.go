@@ -14,7 +14,8 @@ var CreateInstallCmd = &cobra.Command{
 	Short:   "Create a Kubernetes cluster using k3d and Install Grapple on it (step by step)",
 	Long: `Create a Kubernetes cluster using k3d and Install Grapple on it (step by step).
 This command combines the functionality of 'create' and 'install' commands.`,
-	RunE: runCreateInstall,
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE:        runCreateInstall,
 }
 
 func init() {
@@ -25,6 +26,10 @@ func init() {
 	CreateInstallCmd.Flags().StringVar(&httpLoadBalancer, "http-loadbalancer", "80:80@loadbalancer", "Port mapping for HTTP load balancer")
 	CreateInstallCmd.Flags().StringVar(&httpsLoadBalancer, "https-loadbalancer", "443:443@loadbalancer", "Port mapping for HTTPS load balancer")
 	CreateInstallCmd.Flags().StringVar(&apiPort, "api-port", "6550", "API port for the k3d cluster")
+	CreateInstallCmd.Flags().StringVar(&k3sVersion, "k3s-version", "", "Version of k3s, the tag of the rancher/k3s image (e.g: v1.30.4-k3s1, default: k3d's default)")
+	CreateInstallCmd.Flags().StringVar(&registryCreate, "registry-create", "", "Create a local image registry with the cluster, as NAME[:HOST][:HOSTPORT] (e.g: grpl-registry:0.0.0.0:5000)")
+	CreateInstallCmd.Flags().StringArrayVar(&volumes, "volume", []string{}, "Mount a volume into the nodes, as SOURCE:DEST[@NODEFILTER] (can be repeated)")
+	CreateInstallCmd.Flags().StringArrayVar(&ports, "port", []string{}, "Additional port mapping, as HOSTPORT:CONTAINERPORT@NODEFILTER (can be repeated)")
 	CreateInstallCmd.Flags().BoolVar(&waitForReady, "wait", false, "Wait for cluster to be ready (default: false)")
 	CreateInstallCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts (default: false)")
 
@@ -33,10 +38,19 @@ func init() {
 	CreateInstallCmd.Flags().StringVar(&clusterIP, "cluster-ip", "", "Cluster IP")
 	CreateInstallCmd.Flags().StringVar(&organization, "organization", "", "Organization name (default: grapple-solutions)")
 	CreateInstallCmd.Flags().BoolVar(&installKubeblocks, "install-kubeblocks", false, "Install Kubeblocks in background (default: false)")
+	CreateInstallCmd.Flags().BoolVar(&utils.OfflineMode, "offline", false, "Resolve charts only from the chart cache, see 'grapple cache pull'")
+	CreateInstallCmd.Flags().BoolVar(&utils.SkipPreflight, "skip-preflight", false, "Skip the preflight checks of the cluster, see 'grapple preflight'")
+	CreateInstallCmd.Flags().BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "Remove the releases and namespaces created by this installation if it fails")
+	CreateInstallCmd.Flags().BoolVar(&utils.ClusterLogEnabled, "log-to-cluster", false, "Mirror the sanitized install log to the grpl-install-log ConfigMap for support")
 	CreateInstallCmd.Flags().BoolVar(&sslEnable, "ssl-enable", false, "Enable SSL usage (default: false)")
 	CreateInstallCmd.Flags().StringVar(&sslIssuer, "ssl-issuer", "letsencrypt-grapple-demo", "SSL Issuer (default: letsencrypt-grapple-demo)")
 	CreateInstallCmd.Flags().StringVar(&grappleLicense, "grapple-license", "", "Grapple license key")
 	CreateInstallCmd.Flags().StringVar(&imagePullSecret, "image-pull-secret", "", "Image pull secret for private repositories")
+	CreateInstallCmd.Flags().StringVar(&utils.PriorityClass, "priority-class", "", "PriorityClass of the grsf components and KubeBlocks, created if it doesn't exist (e.g: --priority-class=grpl-platform)")
+	CreateInstallCmd.Flags().StringVar(&utils.ValuesSecret, "values-secret", "", "Secret (<namespace>/<name>, default namespace grpl-system) with sensitive values, as values.yaml key or one key per config value")
+	CreateInstallCmd.Flags().StringVar(&utils.ValuesSopsFile, "values-sops", "", "SOPS-encrypted values file, decrypted in memory with sops")
+	CreateInstallCmd.Flags().StringToStringVar(&labels, "labels", map[string]string{}, "Labels to add to all generated resources (e.g: --labels=team=platform,cost-center=1234)")
+	CreateInstallCmd.Flags().StringToStringVar(&annotations, "annotations", map[string]string{}, "Annotations to add to all generated resources (e.g: --annotations=owner=platform)")
 }
 
 func runCreateInstall(cmd *cobra.Command, args []string) error {
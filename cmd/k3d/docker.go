@@ -0,0 +1,287 @@
+package k3d
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Labels k3d sets on the containers of a cluster
+const (
+	k3dClusterLabel = "k3d.cluster"
+	k3dRoleLabel    = "k3d.role"
+	k3dNetworkLabel = "k3d.cluster.network"
+)
+
+// K3dNode is a container of a k3d cluster, e.g. a server, an agent or the load balancer
+type K3dNode struct {
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Image   string `json:"image"`
+	State   string `json:"state"`
+	Running bool   `json:"running"`
+}
+
+// K3dCluster is a k3d cluster with its nodes
+type K3dCluster struct {
+	Name    string    `json:"name"`
+	Network string    `json:"network,omitempty"`
+	Nodes   []K3dNode `json:"nodes"`
+}
+
+// Running reports whether all servers of the cluster are running
+func (c K3dCluster) Running() bool {
+	servers := 0
+	for _, node := range c.Nodes {
+		if node.Role == "server" {
+			if !node.Running {
+				return false
+			}
+			servers++
+		}
+	}
+	return servers > 0
+}
+
+// dockerContainer is an entry of the container list of the Docker Engine API
+type dockerContainer struct {
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// k3dClusters lists the k3d clusters from the containers of the Docker Engine, without the k3d CLI. Where the
+// Docker API isn't reachable over a socket (the named pipe of Docker Desktop on Windows), 'k3d cluster list'
+// is used instead. Creating, starting and deleting clusters still needs the k3d CLI, it sets up the network,
+// the registries and the load balancer of a cluster.
+func k3dClusters() ([]K3dCluster, error) {
+	client, err := dockerAPIClient()
+	if errors.Is(err, errNoDockerSocket) {
+		return k3dClustersFromCLI()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	filters, _ := json.Marshal(map[string][]string{"label": {k3dClusterLabel}})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker/containers/json?all=1&filters="+url.QueryEscape(string(filters)), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the k3d containers, is Docker running? %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list the k3d containers: Docker answered %s", resp.Status)
+	}
+	var containers []dockerContainer
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("failed to parse the container list of Docker: %w", err)
+	}
+
+	byName := map[string]*K3dCluster{}
+	for _, container := range containers {
+		name := container.Labels[k3dClusterLabel]
+		if name == "" {
+			continue
+		}
+		cluster, ok := byName[name]
+		if !ok {
+			cluster = &K3dCluster{Name: name}
+			byName[name] = cluster
+		}
+		if network := container.Labels[k3dNetworkLabel]; network != "" {
+			cluster.Network = network
+		}
+		nodeName := ""
+		if len(container.Names) > 0 {
+			nodeName = strings.TrimPrefix(container.Names[0], "/")
+		}
+		cluster.Nodes = append(cluster.Nodes, K3dNode{
+			Name:    nodeName,
+			Role:    container.Labels[k3dRoleLabel],
+			Image:   container.Image,
+			State:   container.State,
+			Running: container.State == "running",
+		})
+	}
+
+	clusters := make([]K3dCluster, 0, len(byName))
+	for _, cluster := range byName {
+		sort.Slice(cluster.Nodes, func(i, j int) bool { return cluster.Nodes[i].Name < cluster.Nodes[j].Name })
+		clusters = append(clusters, *cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters, nil
+}
+
+// getK3dCluster returns the k3d cluster of the name, nil if there is none
+func getK3dCluster(name string) (*K3dCluster, error) {
+	clusters, err := k3dClusters()
+	if err != nil {
+		return nil, err
+	}
+	for _, cluster := range clusters {
+		if cluster.Name == name {
+			return &cluster, nil
+		}
+	}
+	return nil, nil
+}
+
+// stopK3dCluster stops the containers of the cluster through the Docker Engine API, the agents and the load
+// balancer before the servers, as 'k3d cluster stop' does. Without a Docker socket 'k3d cluster stop' is used.
+func stopK3dCluster(cluster *K3dCluster) error {
+	client, err := dockerAPIClient()
+	if errors.Is(err, errNoDockerSocket) {
+		stopCmd := exec.Command("k3d", "cluster", "stop", cluster.Name)
+		stopCmd.Stderr = os.Stderr
+		return stopCmd.Run()
+	}
+	if err != nil {
+		return err
+	}
+
+	nodes := append([]K3dNode(nil), cluster.Nodes...)
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Role != "server" && nodes[j].Role == "server" })
+	for _, node := range nodes {
+		if !node.Running {
+			continue
+		}
+		if err := stopContainer(client, node.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopContainer stops a container through the Docker Engine API, a container that is already stopped is fine
+func stopContainer(client *http.Client, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://docker/containers/"+url.PathEscape(name)+"/stop", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to stop container %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified {
+		return fmt.Errorf("failed to stop container %s: Docker answered %s", name, resp.Status)
+	}
+	return nil
+}
+
+var errNoDockerSocket = errors.New("the Docker API is not served on a socket")
+
+// dockerAPIClient returns an HTTP client of the Docker Engine API: the socket of DOCKER_HOST, or the first
+// socket found of Docker, Docker Desktop, rootless Docker or Colima
+func dockerAPIClient() (*http.Client, error) {
+	var dial func(ctx context.Context) (net.Conn, error)
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		switch {
+		case strings.HasPrefix(host, "unix://"):
+			socket := strings.TrimPrefix(host, "unix://")
+			dial = func(ctx context.Context) (net.Conn, error) { return dialer.DialContext(ctx, "unix", socket) }
+		case strings.HasPrefix(host, "tcp://"):
+			if os.Getenv("DOCKER_TLS_VERIFY") != "" {
+				return nil, errNoDockerSocket
+			}
+			address := strings.TrimPrefix(host, "tcp://")
+			dial = func(ctx context.Context) (net.Conn, error) { return dialer.DialContext(ctx, "tcp", address) }
+		default:
+			return nil, errNoDockerSocket
+		}
+	} else {
+		if runtime.GOOS == "windows" {
+			return nil, errNoDockerSocket
+		}
+		home, _ := os.UserHomeDir()
+		candidates := []string{
+			"/var/run/docker.sock",
+			filepath.Join(home, ".docker", "run", "docker.sock"),
+			filepath.Join(home, ".colima", "default", "docker.sock"),
+		}
+		if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+			candidates = append(candidates, filepath.Join(runtimeDir, "docker.sock"))
+		}
+		for _, socket := range candidates {
+			if _, err := os.Stat(socket); err == nil {
+				socket := socket
+				dial = func(ctx context.Context) (net.Conn, error) { return dialer.DialContext(ctx, "unix", socket) }
+				break
+			}
+		}
+		if dial == nil {
+			return nil, fmt.Errorf("Docker is not running, no Docker socket found (%s), set DOCKER_HOST if it is elsewhere", strings.Join(candidates, ", "))
+		}
+	}
+
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) { return dial(ctx) },
+	}}, nil
+}
+
+// k3dCLICluster is a cluster of 'k3d cluster list -o json'
+type k3dCLICluster struct {
+	Name  string `json:"name"`
+	Nodes []struct {
+		Name  string `json:"name"`
+		Role  string `json:"role"`
+		Image string `json:"image"`
+		State struct {
+			Running bool   `json:"Running"`
+			Status  string `json:"Status"`
+		} `json:"State"`
+	} `json:"nodes"`
+}
+
+// k3dClustersFromCLI lists the k3d clusters with the k3d CLI
+func k3dClustersFromCLI() ([]K3dCluster, error) {
+	if _, err := exec.LookPath("k3d"); err != nil {
+		return nil, fmt.Errorf("k3d is not installed, run 'grapple k3d create' to install it: %w", err)
+	}
+	output, err := exec.Command("k3d", "cluster", "list", "-o", "json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	var listed []k3dCLICluster
+	if err := json.Unmarshal(output, &listed); err != nil {
+		return nil, fmt.Errorf("failed to parse clusters: %w", err)
+	}
+	clusters := make([]K3dCluster, 0, len(listed))
+	for _, l := range listed {
+		cluster := K3dCluster{Name: l.Name}
+		for _, node := range l.Nodes {
+			cluster.Nodes = append(cluster.Nodes, K3dNode{
+				Name:    node.Name,
+				Role:    node.Role,
+				Image:   node.Image,
+				State:   node.State.Status,
+				Running: node.State.Running,
+			})
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
@@ -2,18 +2,14 @@ package k3d
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"sync"
-	"time"
 
+	"github.com/grapple-solution/grapple_cli/installer"
 	"github.com/grapple-solution/grapple_cli/utils" // your logging/prompting
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -22,7 +18,6 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	// Helm libraries
 	// Kubernetes libraries
 )
@@ -46,17 +41,28 @@ func init() {
 	InstallCmd.Flags().StringVar(&email, "email", "", "Email address")
 	InstallCmd.Flags().StringVar(&organization, "organization", "", "Organization name (default: grapple-solutions)")
 	InstallCmd.Flags().BoolVar(&installKubeblocks, "install-kubeblocks", false, "Install Kubeblocks in background (default: false)")
+	InstallCmd.Flags().BoolVar(&utils.OfflineMode, "offline", false, "Resolve charts only from the chart cache, see 'grapple cache pull'")
+	InstallCmd.Flags().BoolVar(&utils.SkipPreflight, "skip-preflight", false, "Skip the preflight checks of the cluster, see 'grapple preflight'")
+	InstallCmd.Flags().BoolVar(&utils.ClusterLogEnabled, "log-to-cluster", false, "Mirror the sanitized install log to the grpl-install-log ConfigMap for support")
+	InstallCmd.Flags().BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "Remove the releases and namespaces created by this installation if it fails")
+	InstallCmd.Flags().BoolVar(&utils.ResumeInstall, "resume", false, "Continue an interrupted installation, skipping the phases it completed")
 	InstallCmd.Flags().BoolVar(&waitForReady, "wait", false, "Wait for Grapple to be fully ready at the end (default: false)")
 	InstallCmd.Flags().BoolVar(&sslEnable, "ssl-enable", false, "Enable SSL usage (default: false)")
 	InstallCmd.Flags().StringVar(&sslIssuer, "ssl-issuer", "letsencrypt-grapple-demo", "SSL Issuer (default: letsencrypt-grapple-demo)")
 	InstallCmd.Flags().StringVar(&grappleLicense, "grapple-license", "", "Grapple license key")
 	InstallCmd.Flags().StringSliceVar(&additionalValuesFiles, "values", []string{}, "Specify values files to use (can specify multiple times using following format: --values=values1.yaml,values2.yaml)")
+	installer.AddProviderValuesFlag(InstallCmd, &providerValues)
+	InstallCmd.Flags().StringVar(&utils.ValuesSecret, "values-secret", "", "Secret (<namespace>/<name>, default namespace grpl-system) with sensitive values, as values.yaml key or one key per config value")
+	InstallCmd.Flags().StringVar(&utils.ValuesSopsFile, "values-sops", "", "SOPS-encrypted values file, decrypted in memory with sops")
 	InstallCmd.Flags().StringVar(&imagePullSecret, "image-pull-secret", "", "Image pull secret for private repositories")
+	InstallCmd.Flags().StringVar(&utils.PriorityClass, "priority-class", "", "PriorityClass of the grsf components and KubeBlocks, created if it doesn't exist (e.g: --priority-class=grpl-platform)")
+	InstallCmd.Flags().StringToStringVar(&labels, "labels", map[string]string{}, "Labels to add to all generated resources (e.g: --labels=team=platform,cost-center=1234)")
+	InstallCmd.Flags().StringToStringVar(&annotations, "annotations", map[string]string{}, "Annotations to add to all generated resources (e.g: --annotations=owner=platform)")
 
 }
 
 // runInstallStepByStep is the main function
-func runInstallStepByStep(cmd *cobra.Command, args []string) error {
+func runInstallStepByStep(cmd *cobra.Command, args []string) (installErr error) {
 
 	logFileName := "grpl_k3d_install.log"
 	logFilePath := utils.GetLogFilePath(logFileName)
@@ -66,6 +72,7 @@ func runInstallStepByStep(cmd *cobra.Command, args []string) error {
 
 	defer func() {
 		logFile.Sync()
+		utils.MirrorLogToCluster(logFilePath, "k3d install")
 		logFile.Close()
 		if err != nil {
 			utils.ErrorMessage(fmt.Sprintf("Failed to install grpl, please run cat %s for more details", logFilePath))
@@ -75,37 +82,25 @@ func runInstallStepByStep(cmd *cobra.Command, args []string) error {
 	// Start logging to both CLI and file
 	logOnCliAndFileStart()
 
+	utils.SetCommonMetadata(labels, annotations)
+
 	// Set default values if not provided
 	if organization == "" {
 		organization = "grapple-solutions"
 	}
 
 	if clusterName == "" {
-		// Get list of k3d clusters
-		output, err := exec.Command("k3d", "cluster", "list", "-o", "json").Output()
+		clusterNames, err := listK3dClusters()
 		if err != nil {
-			utils.ErrorMessage(fmt.Sprintf("Failed to list clusters: %v", err))
-			return err
-		}
-
-		// Parse the JSON output to get cluster names
-		var clusters []K3dCluster
-		if err := json.Unmarshal(output, &clusters); err != nil {
-			utils.ErrorMessage(fmt.Sprintf("Failed to parse clusters: %v", err))
+			utils.ErrorMessage(err.Error())
 			return err
 		}
-
-		if len(clusters) == 0 {
-			utils.ErrorMessage("No k3d clusters found, run 'grapple k3d create' to create a cluster")
-			return fmt.Errorf("no k3d clusters found, run 'grapple k3d create' to create a cluster")
-		}
-
-		var clusterNames []string
-		for _, cluster := range clusters {
-			clusterNames = append(clusterNames, cluster.Name)
+		if len(clusterNames) == 0 {
+			utils.ErrorMessage("No k3d clusters found, run 'grapple k3d create --install' to create a cluster with Grapple")
+			return fmt.Errorf("no k3d clusters found, run 'grapple k3d create --install' to create a cluster with Grapple")
 		}
 
-		result, err := utils.PromptSelect("Select cluster to remove", clusterNames)
+		result, err := utils.PromptSelect("Select cluster to install Grapple on", clusterNames)
 		if err != nil {
 			utils.ErrorMessage("Cluster selection is required")
 			return fmt.Errorf("cluster selection is required")
@@ -114,9 +109,7 @@ func runInstallStepByStep(cmd *cobra.Command, args []string) error {
 	}
 	grappleDNS = "grpl-k3d.dev"
 
-	if grappleVersion == "" || grappleVersion == "latest" {
-		grappleVersion = "0.3.5"
-	}
+	grappleVersion = installer.GrappleVersion(grappleVersion)
 
 	completeDomain = grappleDNS
 
@@ -126,169 +119,83 @@ func runInstallStepByStep(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	err = waitForK3dClusterToBeReady(restConfig)
-	if err != nil {
-		utils.ErrorMessage(fmt.Sprintf("Failed to wait for cluster to be ready: %v", err))
-		return fmt.Errorf("failed to wait for cluster to be ready: %v", err)
+	// Record what this run creates, so a failed installation can be rolled back
+	if err = utils.BeginInstallTransaction(kubeClient, restConfig, utils.ProviderClusterTypeK3d, clusterName, grappleVersion); err != nil {
+		return err
 	}
+	defer func() {
+		utils.FinishInstallTransaction(installErr, rollbackOnFailure)
+	}()
 
-	// Check if flag was not set and not explicitly false
-	if !cmd.Flags().Changed("install-kubeblocks") && !installKubeblocks {
-		// Ask user if they want to install KubeBlocks
-		confirmMsg := "Do you want to install KubeBlocks? (y/N): "
-		confirmed, err := utils.PromptInput(confirmMsg, "n", "^[yYnN]$")
-		if err != nil {
-			return err
-		}
-		if strings.ToLower(confirmed) == "y" {
-			installKubeblocks = true
-		}
+	if err = installer.PromptKubeblocks(cmd, &installKubeblocks); err != nil {
+		return err
 	}
 
-	if installKubeblocks {
-		if err := utils.InstallKubeBlocksOnCluster(restConfig); err != nil {
-			utils.ErrorMessage("kubeblocks installation error: " + err.Error())
-		} else {
-			utils.InfoMessage("kubeblocks installed.")
-		}
+	err = installer.Run(k3dProvider{cmd: cmd}, &installer.Installation{
+		ClusterType:       utils.ProviderClusterTypeK3d,
+		ClusterName:       clusterName,
+		GrappleVersion:    grappleVersion,
+		Domain:            completeDomain,
+		Organization:      organization,
+		Email:             "test@gmail.com",
+		License:           grappleLicense,
+		ImagePullSecret:   imagePullSecret,
+		SSL:               sslEnable,
+		SSLIssuer:         sslIssuer,
+		InstallKubeblocks: installKubeblocks,
+		WaitForReady:      waitForReady,
+		AutoConfirm:       autoConfirm,
+		ValuesFiles:       additionalValuesFiles,
+		ProviderValues:    providerValues,
+		Summary: []string{
+			fmt.Sprintf("cluster-ip: %s", clusterIP),
+		},
+		KubeClient:           kubeClient,
+		RestConfig:           restConfig,
+		LogOnFileStart:       logOnFileStart,
+		LogOnCliAndFileStart: logOnCliAndFileStart,
+	})
+	return err
+}
+
+// k3dProvider is the K3d part of the installation
+type k3dProvider struct {
+	cmd *cobra.Command
+}
+
+func (p k3dProvider) PrepareCluster(inst *installer.Installation) error {
+	if err := waitForK3dClusterToBeReady(inst.RestConfig); err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to wait for cluster to be ready: %v", err))
+		return fmt.Errorf("failed to wait for cluster to be ready: %v", err)
 	}
 
 	// Setup local DNS configuration
 	utils.InfoMessage("Setting up local DNS configuration...")
 
 	// Call the patch DNS command to configure DNS
-	if err := runPatchDNS(cmd, []string{}); err != nil {
+	if err := runPatchDNS(p.cmd, []string{}); err != nil {
 		utils.ErrorMessage(fmt.Sprintf("Failed to patch DNS: %v", err))
 		return fmt.Errorf("failed to patch DNS: %w", err)
 	}
 
 	utils.SuccessMessage("Local DNS configuration completed successfully")
+	return nil
+}
 
-	// Start preloading images in parallel
-	var preloadImagesWg sync.WaitGroup
-	preloadImagesWg.Add(1)
-	var preloadImagesError error
-	go func() {
-		defer preloadImagesWg.Done()
-		if err := utils.PreloadGrappleImages(restConfig, "0.2.8"); err != nil {
-			utils.ErrorMessage("image preload error: " + err.Error())
-			preloadImagesError = err
-		} else {
-			utils.InfoMessage("grapple images preloaded.")
-		}
-	}()
-
-	if err := prepareValuesFile(); err != nil {
-		return fmt.Errorf("failed to prepare values file: %w", err)
-	}
-
-	deploymentPath, err := utils.GetResourcePath("template-files")
-	if err != nil {
-		return fmt.Errorf("failed to get deployment path: %w", err)
-	}
-	// deploymentPath := "template-files"
-	valuesFileForK3d := filepath.Join(deploymentPath, "values-k3d.yaml")
-
-	valuesFile := []string{"/tmp/values-override.yaml", valuesFileForK3d}
-	if len(additionalValuesFiles) > 0 {
-		valuesFile = append(valuesFile, additionalValuesFiles...)
-	}
-
-	// Step 3) Deploy "grsf-init"
-	utils.InfoMessage("Deploying 'grsf-init' chart...")
-	logOnFileStart()
-	err = utils.HelmDeployGrplReleasesWithRetry(kubeClient, "grsf-init", "grpl-system", grappleVersion, valuesFile)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("failed to deploy grsf-init: %w", err)
-	}
-
-	utils.InfoMessage("Waiting for grsf-init to be ready...")
-	logOnFileStart()
-	err = utils.WaitForGrsfInit(kubeClient)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("grsf-init not ready: %w", err)
-	}
-	utils.SuccessMessage("grsf-init is installed and ready.")
-	// Step 4) Deploy "grsf"
-	utils.InfoMessage("Deploying 'grsf' chart...")
-	logOnFileStart()
-	err = utils.HelmDeployGrplReleasesWithRetry(kubeClient, "grsf", "grpl-system", grappleVersion, valuesFile)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("failed to deploy grsf: %w", err)
-	}
-
-	utils.InfoMessage("Waiting for grsf to be ready (checking crossplane providers, etc.)...")
-	logOnFileStart()
-	err = utils.WaitForGrsf(kubeClient, "grpl-system")
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("grsf not ready: %w", err)
-	}
-	utils.SuccessMessage("grsf is installed and ready.")
-
-	// Step 5) Deploy "grsf-config"
-	utils.InfoMessage("Deploying 'grsf-config' chart...")
-	logOnFileStart()
-	err = utils.HelmDeployGrplReleasesWithRetry(kubeClient, "grsf-config", "grpl-system", grappleVersion, valuesFile)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("failed to deploy grsf-config: %w", err)
-	}
-
-	utils.InfoMessage("Waiting for grsf-config to be applied (CRDs, XRDs, etc.)...")
-	logOnFileStart()
-	err = utils.WaitForGrsfConfig(kubeClient, restConfig)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("grsf-config not ready: %w", err)
-	}
-	utils.SuccessMessage("grsf-config is installed.")
-
-	// Step 6) Deploy "grsf-integration"
-	utils.InfoMessage("Deploying 'grsf-integration' chart...")
-	logOnFileStart()
-	if err := utils.HelmDeployGrplReleasesWithRetry(kubeClient, "grsf-integration", "grpl-system", grappleVersion, valuesFile); err != nil {
-		return fmt.Errorf("failed to deploy grsf-integration: %w", err)
-	}
-
-	utils.InfoMessage("Waiting for grsf-integration to be ready...")
-	logOnFileStart()
-	err = utils.WaitForGrsfIntegration(restConfig)
-	logOnCliAndFileStart()
-	if err != nil {
-		return fmt.Errorf("grsf-integration not ready: %w", err)
-	}
-	utils.SuccessMessage("grsf-integration is installed.")
-
-	// Step 8) If user wants to wait for the entire Grapple system
-	if waitForReady {
-		utils.InfoMessage("Waiting for Grapple to be ready...")
-		logOnFileStart()
-		err = utils.WaitForGrappleReady(restConfig)
-		logOnCliAndFileStart()
-		if err != nil {
-			return fmt.Errorf("failed to wait for grapple to be ready: %w", err)
-		}
-		utils.SuccessMessage("Grapple is ready!")
-	}
+// ClusterIP returns the IP of the traefik load balancer, looked up by the DNS patch
+func (k3dProvider) ClusterIP(inst *installer.Installation) (string, error) {
+	return clusterIP, nil
+}
 
-	utils.InfoMessage("Waiting for grapple images to be preloaded...")
-	preloadImagesWg.Wait()
-	if preloadImagesError != nil {
-		utils.ErrorMessage("image preload error: " + preloadImagesError.Error())
-	} else {
-		utils.SuccessMessage("Grapple images preloaded.")
-	}
+// ExtraValues has nothing to add, the k3d values are the values-k3d.yaml profile
+func (k3dProvider) ExtraValues(inst *installer.Installation) (map[string]interface{}, []string, error) {
+	return nil, nil, nil
+}
 
-	err = setupClusterIssuer(context.TODO(), restConfig)
-	if err != nil {
+func (k3dProvider) PostInstall(inst *installer.Installation) error {
+	if err := SetupClusterIssuer(context.TODO(), inst.RestConfig); err != nil {
 		return fmt.Errorf("failed to setup cluster issuer: %w", err)
 	}
-
-	utils.SuccessMessage("Grapple installation completed!")
 	return nil
 }
 
@@ -301,23 +208,31 @@ func waitForK3dClusterToBeReady(restConfig *rest.Config) error {
 		return fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
+	progress := utils.StartWaitProgress("coredns deployment", 0)
+	defer progress.Stop()
+
+	poller := utils.NewPoller()
 	for {
 		deployment, err := clientset.AppsV1().Deployments("kube-system").Get(context.TODO(), "coredns", v1.GetOptions{})
 		if err != nil {
 			fmt.Print(".")
-			time.Sleep(5 * time.Second)
+			if err := poller.Wait(); err != nil {
+				return err
+			}
 			continue
 		}
 
 		if deployment.Status.ReadyReplicas == *deployment.Spec.Replicas &&
 			deployment.Status.UpdatedReplicas == *deployment.Spec.Replicas &&
 			deployment.Status.AvailableReplicas == *deployment.Spec.Replicas {
-			utils.SuccessMessage("coredns deployment is ready")
+			progress.Done("coredns deployment is ready")
 			return nil
 		}
 
 		fmt.Print(".")
-		time.Sleep(5 * time.Second)
+		if err := poller.Wait(); err != nil {
+			return err
+		}
 	}
 }
 
@@ -327,14 +242,8 @@ func initClientsAndConfig() (kubernetes.Interface, *rest.Config, error) {
 	// var restConfig *rest.Config
 	var err error
 
-	// Try to use the current context from kubeconfig
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		kubeconfig = clientcmd.RecommendedHomeFile
-	}
-
-	// Build the config from the kubeconfig file
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	// Build the config from the kubeconfig, on the context of --kube-context or the current one
+	config, err := utils.KubeContextRESTConfig(utils.KubeContext)
 	if err != nil {
 		// Try in-cluster config as fallback
 		config, err = rest.InClusterConfig()
@@ -355,84 +264,23 @@ func initClientsAndConfig() (kubernetes.Interface, *rest.Config, error) {
 		return nil, nil, fmt.Errorf("failed to connect to kubernetes: %w", err)
 	}
 
-	// Get license from grsf-config secret if it exists, otherwise use "free"
-	secret, err := k8sClient.CoreV1().Secrets("grpl-system").Get(context.Background(), "grsf-config", v1.GetOptions{})
-	if err != nil {
-		grappleLicense = "free"
-	} else {
-		if licBytes, ok := secret.Data["LIC"]; !ok || len(licBytes) == 0 {
-			grappleLicense = "free"
-		} else {
-			grappleLicense = string(licBytes)
-		}
-	}
+	// Reinstalls keep the license of the grsf-config secret
+	grappleLicense = installer.ExistingLicense(k8sClient)
 
 	utils.InfoMessage("Successfully connected to Kubernetes cluster")
 	return k8sClient, config, nil
 }
 
-func prepareValuesFile() error {
-	// Create values map
-	values := map[string]interface{}{
-		"clusterdomain": completeDomain,
-		"config": map[string]interface{}{
-			// Common fields
-			utils.SecKeyEmail:               "test@gmail.com",
-			utils.SecKeyOrganization:        organization,
-			utils.SecKeyClusterdomain:       completeDomain,
-			utils.SecKeyGrapiversion:        "0.0.1",
-			utils.SecKeyGruimversion:        "0.0.1",
-			utils.SecKeyDev:                 "false",
-			utils.SecKeySsl:                 fmt.Sprintf("%v", sslEnable),
-			utils.SecKeySslissuer:           sslIssuer,
-			utils.SecKeyClusterName:         clusterName,
-			utils.SecKeyGrapleDNS:           completeDomain,
-			utils.SecKeyGrapleVersion:       grappleVersion,
-			utils.SecKeyGrapleLicense:       grappleLicense,
-			utils.SecKeyProviderClusterType: utils.ProviderClusterTypeK3d,
-			utils.SecKeyImagePullSecret:     imagePullSecret,
-		},
-	}
-
-	// Marshal to YAML
-	yamlData, err := yaml.Marshal(values)
-	if err != nil {
-		return fmt.Errorf("failed to marshal values to YAML: %w", err)
-	}
-
-	// Write to temp file
-	if err := os.WriteFile("/tmp/values-override.yaml", yamlData, 0644); err != nil {
-		return fmt.Errorf("failed to write values file: %w", err)
-	}
-
-	// Print values if needed
-	if !autoConfirm {
-		utils.InfoMessage("Going to deploy grpl on K3d with following configurations")
-
-		utils.InfoMessage(fmt.Sprintf("cluster-name: %s", clusterName))
-		utils.InfoMessage(fmt.Sprintf("cluster-ip: %s", clusterIP))
-		utils.InfoMessage(fmt.Sprintf("grapple-version: %s", grappleVersion))
-		utils.InfoMessage(fmt.Sprintf("grapple-dns: %s", completeDomain))
-		utils.InfoMessage(fmt.Sprintf("grapple-license: %s", grappleLicense))
-		utils.InfoMessage(fmt.Sprintf("organization: %s", organization))
-		utils.InfoMessage(fmt.Sprintf("image-pull-secret: %s", imagePullSecret))
-
-		if confirmed, err := utils.PromptConfirm("Proceed with deployment using the values above?"); err != nil || !confirmed {
-			return fmt.Errorf("failed to install grpl: user cancelled")
-		}
-	}
-
-	return nil
-}
-
-// setupClusterIssuer creates and loads CA certificates into a Kubernetes secret
+// SetupClusterIssuer creates and loads CA certificates into a Kubernetes secret
 // and creates a ClusterIssuer for SSL certificates
-func setupClusterIssuer(ctx context.Context, restConfig *rest.Config) error {
+func SetupClusterIssuer(ctx context.Context, restConfig *rest.Config) error {
 	// Define file paths and directories
-	crt := "rootCA.pem"
-	key := "rootCA-key.pem"
-	macDir := filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "mkcert")
-	linuxDir := filepath.Join(os.Getenv("HOME"), ".local", "share", "mkcert")
+	caPath, err := utils.LocalCADir()
+	if err != nil {
+		return err
+	}
+	crt := filepath.Join(caPath, utils.LocalCACertFile)
+	key := filepath.Join(caPath, utils.LocalCAKeyFile)
 	namespace := "grpl-system"
 	secretName := "mkcert-ca-secret"
 	grplNamespace := "grpl-system"
@@ -455,6 +303,7 @@ func setupClusterIssuer(ctx context.Context, restConfig *rest.Config) error {
 					Name: namespace,
 				},
 			}
+			utils.ApplyCommonMetadata(&ns.ObjectMeta)
 			_, err = clientset.CoreV1().Namespaces().Create(ctx, ns, v1.CreateOptions{})
 			if err != nil {
 				return fmt.Errorf("failed to create namespace %s: %v", namespace, err)
@@ -474,34 +323,22 @@ func setupClusterIssuer(ctx context.Context, restConfig *rest.Config) error {
 		return fmt.Errorf("error checking for secret: %v", err)
 	} else {
 		// Secret doesn't exist, create it
-		// Find CA files
-		var caPath string
-		if fileExists(filepath.Join(macDir, crt)) && fileExists(filepath.Join(macDir, key)) {
-			utils.InfoMessage(fmt.Sprintf("Files found in %s", macDir))
-			caPath = macDir
-		} else if fileExists(filepath.Join(linuxDir, crt)) && fileExists(filepath.Join(linuxDir, key)) {
-			utils.InfoMessage(fmt.Sprintf("Files found in %s", linuxDir))
-			caPath = linuxDir
-		} else {
-			if err := askAndCreateMkcert(); err != nil {
-				return fmt.Errorf("failed to create mkcert CA secret: %w", err)
-			}
-			if fileExists(filepath.Join(macDir, crt)) && fileExists(filepath.Join(macDir, key)) {
-				utils.InfoMessage(fmt.Sprintf("Files found in %s", macDir))
-				caPath = macDir
-			} else if fileExists(filepath.Join(linuxDir, crt)) && fileExists(filepath.Join(linuxDir, key)) {
-				utils.InfoMessage(fmt.Sprintf("Files found in %s", linuxDir))
-				caPath = linuxDir
+		// Create the CA unless mkcert or an earlier install created it
+		if !fileExists(crt) || !fileExists(key) {
+			if err := askAndCreateLocalCA(caPath); err != nil {
+				return fmt.Errorf("failed to create local CA: %w", err)
 			}
+		} else {
+			utils.InfoMessage(fmt.Sprintf("Files found in %s", caPath))
 		}
 
 		// Read certificate and key files
-		certData, err := os.ReadFile(filepath.Join(caPath, crt))
+		certData, err := os.ReadFile(crt)
 		if err != nil {
 			return fmt.Errorf("error reading certificate file: %v", err)
 		}
 
-		keyData, err := os.ReadFile(filepath.Join(caPath, key))
+		keyData, err := os.ReadFile(key)
 		if err != nil {
 			return fmt.Errorf("error reading key file: %v", err)
 		}
@@ -519,6 +356,7 @@ func setupClusterIssuer(ctx context.Context, restConfig *rest.Config) error {
 				"tls.key": keyData,
 			},
 		}
+		utils.ApplyCommonMetadata(&secret.ObjectMeta)
 
 		_, err = clientset.CoreV1().Secrets(namespace).Create(ctx, secret, v1.CreateOptions{})
 		if err != nil {
@@ -562,6 +400,8 @@ func setupClusterIssuer(ctx context.Context, restConfig *rest.Config) error {
 			},
 		}
 
+		utils.ApplyCommonMetadataToUnstructured(clusterIssuer)
+
 		_, err = dynamicClient.Resource(clusterIssuerGVR).Create(ctx, clusterIssuer, v1.CreateOptions{})
 		if err != nil {
 			utils.ErrorMessage(fmt.Sprintf("Failed to create ClusterIssuer mkcert-ca-issuer: %v", err))
@@ -612,32 +452,34 @@ func setupClusterIssuer(ctx context.Context, restConfig *rest.Config) error {
 	return nil
 }
 
-func askAndCreateMkcert() error {
-	utils.InfoMessage("Mkcert secrets not found. Need to install mkcert (if not present) and create new secrets for ClusterIssuer setup.")
+// askAndCreateLocalCA generates the local CA of the mkcert-ca-issuer in caPath and adds it to the trust stores
+// of the system, so browsers accept the certificates of the cluster
+func askAndCreateLocalCA(caPath string) error {
+	utils.InfoMessage(fmt.Sprintf("Local CA not found in %s. Need to create a new CA for the ClusterIssuer setup and add it to the trust store of the system.", caPath))
 
 	if !autoConfirm {
-		confirmMsg := "Do you want to proceed with mkcert installation and setup? (y/N): "
+		confirmMsg := "Do you want to proceed with the local CA setup? (y/N): "
 		confirmed, err := utils.PromptInput(confirmMsg, "n", "^[yYnN]$")
 		if err != nil {
 			return err
 		}
 		if strings.ToLower(confirmed) != "y" {
-			return fmt.Errorf("failed to setup cluster issuer: user cancelled")
+			return fmt.Errorf("failed to setup cluster issuer: %w", utils.ErrUserAborted)
 		}
 	}
 
-	// Install mkcert if not already installed
-	if err := utils.InstallMkcert(); err != nil {
-		return fmt.Errorf("failed to install mkcert: %w", err)
+	utils.InfoMessage("Generating local root CA and key...")
+	if err := utils.CreateLocalCA(caPath); err != nil {
+		return err
 	}
+	utils.SuccessMessage(fmt.Sprintf("Generated local root CA and key in %s", caPath))
 
-	// Generate root CA and key using mkcert
-	utils.InfoMessage("Generating mkcert root CA and key...")
-	cmd := exec.Command("mkcert", "-install")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to generate mkcert root CA: %w", err)
+	// The cluster works without it, only browsers and curl warn about the certificates
+	utils.InfoMessage("Adding the local CA to the trust store of the system, this may ask for your password...")
+	if err := utils.InstallLocalCATrust(filepath.Join(caPath, utils.LocalCACertFile)); err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to trust the local CA, the certificates of the cluster will show warnings: %v", err))
+		return nil
 	}
-	utils.SuccessMessage("Generated mkcert root CA and key successfully")
-
+	utils.SuccessMessage("Local CA added to the trust store")
 	return nil
 }
@@ -27,7 +27,10 @@ func init() {
 	K3dCmd.AddCommand(PatchCmd)
 	K3dCmd.AddCommand(CreateInstallCmd)
 	K3dCmd.AddCommand(RemoveCmd)
+	K3dCmd.AddCommand(StopCmd)
+	K3dCmd.AddCommand(StartCmd)
 	K3dCmd.AddCommand(UninstallCmd)
+	K3dCmd.AddCommand(RegistrySecretCmd)
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -0,0 +1,93 @@
+package k3d
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// StopCmd represents the stop command
+var StopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop a k3d cluster, keeping Grapple and its data",
+	Long: `Stop the nodes of a k3d cluster to free the resources of your machine. The cluster, the Grapple installation
+and the data of the GrappleApplicationSets are kept, 'grapple k3d start' continues where you left off.
+
+Example:
+  grapple k3d stop --cluster-name dev`,
+	RunE: runStop,
+}
+
+// StartCmd represents the start command
+var StartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start a stopped k3d cluster",
+	Long: `Start a k3d cluster stopped by 'grapple k3d stop', switch kubectl to it and wait for it to be ready.
+The cluster is started with 'k3d cluster start', which restores its load balancer and host entries.
+
+Example:
+  grapple k3d start --cluster-name dev`,
+	Annotations: map[string]string{utils.AnnotationSwitchesKubeContext: "true"},
+	RunE:        runStart,
+}
+
+func init() {
+	StopCmd.Flags().StringVar(&clusterName, "cluster-name", "", "k3d cluster name")
+	StartCmd.Flags().StringVar(&clusterName, "cluster-name", "", "k3d cluster name")
+}
+
+func runStop(cmd *cobra.Command, args []string) error {
+	name, err := selectK3dCluster("Select cluster to stop")
+	if err != nil {
+		return err
+	}
+	cluster, err := getK3dCluster(name)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf("%w: cluster %s not found", utils.ErrValidation, name)
+	}
+	if !cluster.Running() {
+		utils.InfoMessage(fmt.Sprintf("Cluster %s is already stopped", name))
+		return nil
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Stopping cluster %s...", name))
+	if err := stopK3dCluster(cluster); err != nil {
+		return fmt.Errorf("failed to stop cluster %s: %w", name, err)
+	}
+	utils.SuccessMessage(fmt.Sprintf("Cluster %s stopped, run 'grapple k3d start --cluster-name %s' to continue", name, name))
+	return nil
+}
+
+func runStart(cmd *cobra.Command, args []string) error {
+	name, err := selectK3dCluster("Select cluster to start")
+	if err != nil {
+		return err
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Starting cluster %s...", name))
+	startCmd := exec.Command("k3d", "cluster", "start", name, "--wait")
+	startCmd.Stderr = os.Stderr
+	if err := startCmd.Run(); err != nil {
+		return fmt.Errorf("failed to start cluster %s: %w", name, err)
+	}
+
+	clusterName = name
+	if err := connectToCluster(cmd, args); err != nil {
+		return err
+	}
+	restConfig, _, err := utils.GetKubernetesConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get kubernetes config: %w", err)
+	}
+	if err := waitForK3dClusterToBeReady(restConfig); err != nil {
+		return fmt.Errorf("failed to wait for cluster to be ready: %w", err)
+	}
+	utils.SuccessMessage(fmt.Sprintf("Cluster %s started", name))
+	return nil
+}
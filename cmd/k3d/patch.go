@@ -133,14 +133,12 @@ func patchCoreDNS(restConfig *rest.Config) error {
 		grappleDNS = "grpl-k3d.dev"
 	}
 	// Get the path to the coredns-custom.yaml file
-	resourcePath, err := utils.GetResourcePath("files")
+	configMapPath, err := utils.ResolveResourceFile("files", "coredns-custom.yaml")
 	if err != nil {
 		return fmt.Errorf("failed to get resource path: %w", err)
 	}
-	// resourcePath := "files"
 
 	// Read the ConfigMap yaml file
-	configMapPath := path.Join(resourcePath, "coredns-custom.yaml")
 	yamlFile, err := os.ReadFile(configMapPath)
 	if err != nil {
 		return fmt.Errorf("failed to read coredns-custom.yaml: %w", err)
@@ -463,3 +461,75 @@ func configureDNSForMacOS() error {
 
 	return nil
 }
+
+// removeDNSPatch removes the grpl-k3d.dev entries added by patch from the dnsmasq configuration and the macOS
+// resolver. The nameserver and the network services keep pointing to dnsmasq, which forwards other domains.
+func removeDNSPatch() error {
+	dnsmasqPath := "/etc/dnsmasq.conf"
+	resolverPath := ""
+	restartCmd := []string{"sudo", "systemctl", "restart", "dnsmasq"}
+	switch runtime.GOOS {
+	case "linux":
+	case "darwin":
+		homebrewPrefix, err := exec.Command("brew", "--prefix").Output()
+		if err != nil {
+			return fmt.Errorf("failed to get homebrew prefix: %w", err)
+		}
+		dnsmasqPath = fmt.Sprintf("%s/etc/dnsmasq.conf", strings.TrimSpace(string(homebrewPrefix)))
+		resolverPath = "/etc/resolver/grpl-k3d.dev"
+		restartCmd = []string{"brew", "services", "restart", "dnsmasq"}
+	default:
+		return nil
+	}
+
+	data, err := os.ReadFile(dnsmasqPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", dnsmasqPath, err)
+	}
+	addressLine := "address=/grpl-k3d.dev/127.0.0.1"
+	var kept []string
+	patched := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == addressLine {
+			patched = true
+		} else if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	if _, err := os.Stat(resolverPath); resolverPath != "" && err == nil {
+		patched = true
+	}
+	if !patched {
+		return nil
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Removing the grpl-k3d.dev DNS entries from %s", dnsmasqPath))
+	if !skipConfirmation {
+		confirmed, err := utils.PromptInput("Remove the DNS entries added by 'grapple k3d patch'? (y/N): ", "n", "^[yYnN]$")
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(confirmed) != "y" {
+			utils.InfoMessage("Keeping the DNS entries")
+			return nil
+		}
+	}
+
+	tmpPath := path.Join(os.TempDir(), "dnsmasq.conf")
+	if err := os.WriteFile(tmpPath, []byte(strings.Join(kept, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+	if err := exec.Command("sudo", "cp", tmpPath, dnsmasqPath).Run(); err != nil {
+		return fmt.Errorf("failed to update %s: %w", dnsmasqPath, err)
+	}
+	if resolverPath != "" {
+		if err := exec.Command("sudo", "rm", "-f", resolverPath).Run(); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", resolverPath, err)
+		}
+	}
+	if err := exec.Command(restartCmd[0], restartCmd[1:]...).Run(); err != nil {
+		utils.InfoMessage("Failed to restart dnsmasq, the DNS entries are removed once it restarts")
+	}
+	utils.SuccessMessage("Removed the grpl-k3d.dev DNS entries")
+	return nil
+}
@@ -0,0 +1,284 @@
+package k3d
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	registryServer     string
+	registryUsername   string
+	registryPassword   string
+	registryEmail      string
+	registrySecretName string
+	registryNamespaces []string
+	restartFailedPulls bool
+)
+
+// RegistrySecretCmd represents the registry-secret command
+var RegistrySecretCmd = &cobra.Command{
+	Use:     "registry-secret",
+	Aliases: []string{"rs"},
+	Short:   "Create an image pull secret to avoid Docker Hub rate limits",
+	Long: `Create an image pull secret from your registry credentials (Docker Hub by default) in the
+Grapple namespaces and attach it to their service accounts, so that platform and GRAS workloads
+pull images authenticated instead of hitting the anonymous rate limit.
+
+By default the secret is created in every namespace except the kube-* ones, pods stuck in
+ErrImagePull/ImagePullBackOff are restarted so they pick up the secret, and grsf-config is updated
+so that GRAS resources deployed later use it as well.
+
+Credentials are read from the flags, the DOCKER_USERNAME/DOCKER_PASSWORD environment variables or a prompt.
+
+Example:
+  grapple k3d registry-secret --docker-username=me --docker-password=$TOKEN`,
+	RunE: runRegistrySecret,
+}
+
+func init() {
+	RegistrySecretCmd.Flags().StringVar(&registryServer, "docker-server", "https://index.docker.io/v1/", "Registry server the credentials are for")
+	RegistrySecretCmd.Flags().StringVar(&registryUsername, "docker-username", "", "Registry username (default: $DOCKER_USERNAME)")
+	RegistrySecretCmd.Flags().StringVar(&registryPassword, "docker-password", "", "Registry password or access token (default: $DOCKER_PASSWORD)")
+	RegistrySecretCmd.Flags().StringVar(&registryEmail, "docker-email", "", "Registry email")
+	RegistrySecretCmd.Flags().StringVar(&registrySecretName, "secret-name", "grpl-registry-credentials", "Name of the image pull secret")
+	RegistrySecretCmd.Flags().StringSliceVar(&registryNamespaces, "namespaces", []string{}, "Namespaces to create the secret in (default: all namespaces except kube-*)")
+	RegistrySecretCmd.Flags().BoolVar(&restartFailedPulls, "restart-failed-pods", true, "Restart pods that failed to pull their images so they retry with the secret")
+}
+
+func runRegistrySecret(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_k3d_registry_secret.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to create registry secret, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	if err = getRegistryCredentials(); err != nil {
+		return err
+	}
+
+	_, clientset, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to get Kubernetes config: " + err.Error())
+		return err
+	}
+
+	namespaces := registryNamespaces
+	if len(namespaces) == 0 {
+		namespaces, err = workloadNamespaces(clientset)
+		if err != nil {
+			return err
+		}
+	}
+
+	dockerConfig, err := dockerConfigJSON()
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		utils.InfoMessage(fmt.Sprintf("Creating image pull secret %s in namespace %s...", registrySecretName, ns))
+		if err = applyRegistrySecret(clientset, ns, dockerConfig); err != nil {
+			return err
+		}
+		if err = attachPullSecretToServiceAccounts(clientset, ns); err != nil {
+			return err
+		}
+		if restartFailedPulls {
+			restartPodsWithPullErrors(clientset, ns)
+		}
+	}
+
+	if err = setImagePullSecretInGrsfConfig(clientset); err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to update grsf-config, GRAS resources deployed later may not use the secret: %v", err))
+		err = nil
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("Image pull secret %s is configured in %d namespaces", registrySecretName, len(namespaces)))
+	return nil
+}
+
+func getRegistryCredentials() error {
+	if registryUsername == "" {
+		registryUsername = os.Getenv("DOCKER_USERNAME")
+	}
+	if registryPassword == "" {
+		registryPassword = os.Getenv("DOCKER_PASSWORD")
+	}
+
+	if registryUsername == "" {
+		result, err := utils.PromptInput("Enter registry username", utils.DefaultValue, utils.NonEmptyValueRegex)
+		if err != nil {
+			return fmt.Errorf("registry username is required: %w", err)
+		}
+		registryUsername = result
+	}
+	if registryPassword == "" {
+		result, err := utils.PromptPassword("Enter registry password or access token")
+		if err != nil {
+			return fmt.Errorf("registry password is required: %w", err)
+		}
+		registryPassword = result
+	}
+	return nil
+}
+
+// dockerConfigJSON builds the .dockerconfigjson content for the credentials
+func dockerConfigJSON() ([]byte, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(registryUsername + ":" + registryPassword))
+	entry := map[string]string{
+		"username": registryUsername,
+		"password": registryPassword,
+		"auth":     auth,
+	}
+	if registryEmail != "" {
+		entry["email"] = registryEmail
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{registryServer: entry},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build docker config: %w", err)
+	}
+	return data, nil
+}
+
+// workloadNamespaces returns every namespace that can run platform or GRAS workloads
+func workloadNamespaces(clientset *kubernetes.Clientset) ([]string, error) {
+	list, err := clientset.CoreV1().Namespaces().List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	var namespaces []string
+	for _, ns := range list.Items {
+		if strings.HasPrefix(ns.Name, "kube-") || ns.Status.Phase == corev1.NamespaceTerminating {
+			continue
+		}
+		namespaces = append(namespaces, ns.Name)
+	}
+	return namespaces, nil
+}
+
+func applyRegistrySecret(clientset *kubernetes.Clientset, namespace string, dockerConfig []byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      registrySecretName,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: dockerConfig,
+		},
+	}
+	utils.ApplyCommonMetadata(&secret.ObjectMeta)
+
+	_, err := clientset.CoreV1().Secrets(namespace).Create(context.TODO(), secret, v1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().Secrets(namespace).Update(context.TODO(), secret, v1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create secret in namespace %s: %w", namespace, err)
+	}
+	return nil
+}
+
+// attachPullSecretToServiceAccounts adds the secret to the imagePullSecrets of every service account in the namespace
+func attachPullSecretToServiceAccounts(clientset *kubernetes.Clientset, namespace string) error {
+	accounts, err := clientset.CoreV1().ServiceAccounts(namespace).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list service accounts in namespace %s: %w", namespace, err)
+	}
+
+	for _, sa := range accounts.Items {
+		attached := false
+		for _, ref := range sa.ImagePullSecrets {
+			if ref.Name == registrySecretName {
+				attached = true
+				break
+			}
+		}
+		if attached {
+			continue
+		}
+
+		sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: registrySecretName})
+		if _, err := clientset.CoreV1().ServiceAccounts(namespace).Update(context.TODO(), &sa, v1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update service account %s/%s: %w", namespace, sa.Name, err)
+		}
+	}
+	return nil
+}
+
+// restartPodsWithPullErrors deletes pods whose images failed to pull, their controllers recreate them with the secret
+func restartPodsWithPullErrors(clientset *kubernetes.Clientset, namespace string) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to list pods in namespace %s: %v", namespace, err))
+		return
+	}
+
+	for _, pod := range pods.Items {
+		if !hasImagePullError(pod) || len(pod.OwnerReferences) == 0 {
+			continue
+		}
+		utils.InfoMessage(fmt.Sprintf("Restarting pod %s/%s which failed to pull its image...", namespace, pod.Name))
+		if err := clientset.CoreV1().Pods(namespace).Delete(context.TODO(), pod.Name, v1.DeleteOptions{}); err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to restart pod %s/%s: %v", namespace, pod.Name, err))
+		}
+	}
+}
+
+func hasImagePullError(pod corev1.Pod) bool {
+	statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		switch status.State.Waiting.Reason {
+		case "ErrImagePull", "ImagePullBackOff":
+			return true
+		}
+	}
+	return false
+}
+
+// setImagePullSecretInGrsfConfig records the secret in grsf-config, which is where GRAS deployments look it up
+func setImagePullSecretInGrsfConfig(clientset *kubernetes.Clientset) error {
+	secret, err := clientset.CoreV1().Secrets("grpl-system").Get(context.TODO(), "grsf-config", v1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if string(secret.Data[utils.SecKeyImagePullSecret]) == registrySecretName {
+		return nil
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[utils.SecKeyImagePullSecret] = []byte(registrySecretName)
+	_, err = clientset.CoreV1().Secrets("grpl-system").Update(context.TODO(), secret, v1.UpdateOptions{})
+	return err
+}
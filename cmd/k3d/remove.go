@@ -2,7 +2,6 @@ package k3d
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os/exec"
@@ -14,11 +13,6 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-// K3dCluster represents the relevant cluster info from k3d
-type K3dCluster struct {
-	Name string `json:"name"`
-}
-
 // RemoveCmd represents the remove command
 var RemoveCmd = &cobra.Command{
 	Use:     "remove",
@@ -27,7 +21,9 @@ var RemoveCmd = &cobra.Command{
 	Long: `Remove command will clean up and delete all resources associated with 
 the Kubernetes cluster from k3d
 
-This ensures a complete cleanup of all cluster-related resources.`,
+This ensures a complete cleanup of all cluster-related resources. When the last k3d cluster is removed, the
+grpl-k3d.dev entries added by 'grapple k3d patch' are removed from dnsmasq (and /etc/resolver on macOS), unless
+--keep-dns is set.`,
 	RunE: runRemove,
 }
 
@@ -35,6 +31,7 @@ func init() {
 	RemoveCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", true, "If true, deletes the currently connected k3d cluster. If false, prompts for cluster name. Default value of auto-confirm is true")
 	RemoveCmd.Flags().StringVar(&clusterName, "cluster-name", "", "k3d cluster name")
 	RemoveCmd.Flags().BoolVarP(&skipConfirmation, "yes", "y", false, "Skip confirmation prompt before removing cluster")
+	RemoveCmd.Flags().BoolVar(&keepDNS, "keep-dns", false, "Keep the grpl-k3d.dev DNS entries of 'grapple k3d patch' when the last cluster is removed")
 }
 
 func getClusterDetailsFromConfig(clientset *kubernetes.Clientset) bool {
@@ -84,30 +81,16 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	}
 
 	if clusterName == "" {
-		// Get list of k3d clusters
-		output, err := exec.Command("k3d", "cluster", "list", "-o", "json").Output()
+		clusterNames, err := listK3dClusters()
 		if err != nil {
-			utils.ErrorMessage(fmt.Sprintf("Failed to list clusters: %v", err))
+			utils.ErrorMessage(err.Error())
 			return err
 		}
-
-		// Parse the JSON output to get cluster names
-		var clusters []K3dCluster
-		if err := json.Unmarshal(output, &clusters); err != nil {
-			utils.ErrorMessage(fmt.Sprintf("Failed to parse clusters: %v", err))
-			return err
-		}
-
-		if len(clusters) == 0 {
+		if len(clusterNames) == 0 {
 			utils.ErrorMessage("No k3d clusters found")
 			return errors.New("no k3d clusters found")
 		}
 
-		var clusterNames []string
-		for _, cluster := range clusters {
-			clusterNames = append(clusterNames, cluster.Name)
-		}
-
 		result, err := utils.PromptSelect("Select cluster to remove", clusterNames)
 		if err != nil {
 			utils.ErrorMessage("Cluster selection is required")
@@ -117,8 +100,12 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	}
 
 	// Verify cluster exists
-	err = exec.Command("k3d", "cluster", "list", clusterName).Run()
+	cluster, err := getK3dCluster(clusterName)
 	if err != nil {
+		utils.ErrorMessage(err.Error())
+		return err
+	}
+	if cluster == nil {
 		utils.ErrorMessage(fmt.Sprintf("Cluster %s not found", clusterName))
 		return fmt.Errorf("cluster %s not found", clusterName)
 	}
@@ -147,5 +134,12 @@ func runRemove(cmd *cobra.Command, args []string) error {
 
 	logOnCliAndFileStart()
 	utils.SuccessMessage(fmt.Sprintf("Successfully deleted cluster %s", clusterName))
+
+	// All k3d clusters share grpl-k3d.dev, its DNS entries are only removed with the last one
+	if remaining, err := listK3dClusters(); !keepDNS && err == nil && len(remaining) == 0 {
+		if err := removeDNSPatch(); err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to remove the DNS entries: %v", err))
+		}
+	}
 	return nil
 }
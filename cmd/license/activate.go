@@ -0,0 +1,95 @@
+package license
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// ActivateCmd represents the license activate command
+var ActivateCmd = &cobra.Command{
+	Use:   "activate [license-key]",
+	Short: "Activate a license key on the current cluster",
+	Long: `Activate a license key on the Grapple installation of the current cluster.
+
+The key is validated against the licensing API, grsf-config is rendered again with the license (use
+--skip-render to only store the key) and the key, tier and expiry are stored in the grsf-config secret.
+Without a key as argument, the key is prompted for.
+
+Example:
+  grapple license activate XXXX-XXXX-XXXX-XXXX
+  grapple license activate --auto-confirm XXXX-XXXX-XXXX-XXXX`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runActivate,
+}
+
+func init() {
+	ActivateCmd.Flags().BoolVar(&skipRender, "skip-render", false, "Only store the license, don't render grsf-config again")
+	ActivateCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts (default: false)")
+}
+
+func runActivate(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_license_activate.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, logOnFileStart, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to activate the license, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	var key string
+	if len(args) == 1 {
+		key = args[0]
+	} else {
+		key, err = utils.PromptPassword("License key")
+		if err != nil {
+			return err
+		}
+	}
+	if key == "" {
+		err = fmt.Errorf("%w: license key is required", utils.ErrValidation)
+		return err
+	}
+
+	restConfig, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+
+	current, err := utils.GetClusterLicense(kubeClient)
+	if err != nil {
+		return err
+	}
+	license, err := utils.ValidateLicense(key)
+	if err != nil {
+		return err
+	}
+	utils.SuccessMessage(fmt.Sprintf("License %s is valid, tier: %s", utils.MaskSecret(key), valueOrUnknown(license.Tier)))
+
+	if !autoConfirm {
+		confirmed, promptErr := utils.PromptConfirm(fmt.Sprintf("Change the license of the cluster from %s to %s?", current.Tier, valueOrUnknown(license.Tier)))
+		if promptErr != nil || !confirmed {
+			err = fmt.Errorf("license activation: %w", utils.ErrUserAborted)
+			return err
+		}
+	}
+
+	if err = applyLicense(kubeClient, restConfig, license, logOnFileStart, logOnCliAndFileStart); err != nil {
+		return err
+	}
+
+	return utils.PrintResult(map[string]interface{}{"tier": license.Tier, "expires": license.Expires}, func() {
+		utils.SuccessMessage(fmt.Sprintf("License activated, tier: %s", valueOrUnknown(license.Tier)))
+	})
+}
@@ -0,0 +1,105 @@
+package license
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// DeactivateCmd represents the license deactivate command
+var DeactivateCmd = &cobra.Command{
+	Use:   "deactivate",
+	Short: "Deactivate the license of the current cluster and fall back to the free tier",
+	Long: `Deactivate the license of the Grapple installation of the current cluster.
+
+The key is released at the licensing API, so it can be activated on another cluster, grsf-config is
+rendered again with the free license (use --skip-render to only update the secret) and the grsf-config
+secret is reset to the free license.
+
+Example:
+  grapple license deactivate --auto-confirm`,
+	RunE: runDeactivate,
+}
+
+func init() {
+	DeactivateCmd.Flags().BoolVar(&skipRender, "skip-render", false, "Only update the license in grsf-config, don't render it again")
+	DeactivateCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts (default: false)")
+}
+
+func runDeactivate(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_license_deactivate.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, logOnFileStart, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to deactivate the license, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	restConfig, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+
+	current, err := utils.GetClusterLicense(kubeClient)
+	if err != nil {
+		return err
+	}
+	if current.Key == utils.FreeLicense {
+		utils.InfoMessage("The cluster has no license key, it already uses the free license")
+		return nil
+	}
+
+	if !autoConfirm {
+		confirmed, promptErr := utils.PromptConfirm(fmt.Sprintf("Deactivate license %s (tier: %s) and fall back to the free license?", utils.MaskSecret(current.Key), valueOrUnknown(current.Tier)))
+		if promptErr != nil || !confirmed {
+			err = fmt.Errorf("license deactivation: %w", utils.ErrUserAborted)
+			return err
+		}
+	}
+
+	free := &utils.License{Key: utils.FreeLicense, Tier: utils.FreeLicense}
+	if err = applyLicense(kubeClient, restConfig, free, logOnFileStart, logOnCliAndFileStart); err != nil {
+		return err
+	}
+
+	// the cluster is on the free license already, a key that can't be released only blocks its reuse
+	if releaseErr := utils.ReleaseLicense(current.Key); releaseErr != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to release license %s at the licensing API: %v", utils.MaskSecret(current.Key), releaseErr))
+	}
+
+	return utils.PrintResult(map[string]interface{}{"tier": utils.FreeLicense}, func() {
+		utils.SuccessMessage("License deactivated, the cluster uses the free license")
+	})
+}
+
+// applyLicense renders grsf-config with the license and stores it in the grsf-config secret afterwards, the
+// upgrade of the release rewrites the secret
+func applyLicense(kubeClient *kubernetes.Clientset, restConfig *rest.Config, license *utils.License, logOnFileStart, logOnCliAndFileStart func()) error {
+	if !skipRender {
+		utils.InfoMessage("Rendering grsf-config with the license...")
+		logOnFileStart()
+		err := utils.RenderLicenseConfig(kubeClient, restConfig, license.Key)
+		logOnCliAndFileStart()
+		if err != nil {
+			return err
+		}
+	}
+	if err := utils.SetClusterLicense(kubeClient, license); err != nil {
+		return err
+	}
+	utils.SuccessMessage(fmt.Sprintf("Updated grsf-config with the %s license", valueOrUnknown(license.Tier)))
+	return nil
+}
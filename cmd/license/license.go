@@ -0,0 +1,32 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package license
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	autoConfirm  bool
+	skipRender   bool
+	offlineCheck bool
+)
+
+// LicenseCmd represents the license command
+var LicenseCmd = &cobra.Command{
+	Use:     "license",
+	Aliases: []string{"lic"},
+	Short:   "Show, activate or deactivate the Grapple license of the current cluster",
+	Long: `Manage the Grapple license of the cluster of the current kubectl context.
+
+The license key is stored in the grsf-config secret, installations without a key use the free license.
+Activating and deactivating a license validate the key against the licensing API and render grsf-config
+again, so the configuration derived from the license (e.g. the GRUIM feature flags) follows the new tier.`,
+}
+
+func init() {
+	LicenseCmd.AddCommand(StatusCmd)
+	LicenseCmd.AddCommand(ActivateCmd)
+	LicenseCmd.AddCommand(DeactivateCmd)
+}
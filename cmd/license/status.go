@@ -0,0 +1,82 @@
+package license
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// StatusCmd represents the license status command
+var StatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the license tier and expiry of the current cluster",
+	Long: `Show the license of the Grapple installation of the current cluster: the masked key, the tier, the expiry
+and the licensed features.
+
+The key is checked against the licensing API, with --offline (or when the API can't be reached) the tier and
+expiry recorded at activation are shown.
+
+Example:
+  grapple license status
+  grapple license status -o json`,
+	RunE: runStatus,
+}
+
+func init() {
+	StatusCmd.Flags().BoolVar(&offlineCheck, "offline", false, "Don't check the key against the licensing API")
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	_, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+
+	license, err := utils.GetClusterLicense(kubeClient)
+	if err != nil {
+		return err
+	}
+	if !offlineCheck && license.Key != utils.FreeLicense {
+		verified, err := utils.ValidateLicense(license.Key)
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("License check failed, showing the license recorded at activation: %v", err))
+		} else {
+			license = verified
+		}
+	}
+
+	result := *license
+	result.Key = utils.MaskSecret(license.Key)
+	if license.Key == utils.FreeLicense {
+		result.Key = utils.FreeLicense
+	}
+	return utils.PrintResult(result, func() { printLicense(&result) })
+}
+
+func printLicense(license *utils.License) {
+	utils.InfoMessage(fmt.Sprintf("License: %s", license.Key))
+	utils.InfoMessage(fmt.Sprintf("Tier:    %s", valueOrUnknown(license.Tier)))
+	switch {
+	case license.Expires == nil:
+		utils.InfoMessage("Expires: never")
+	case license.Expired():
+		utils.ErrorMessage(fmt.Sprintf("Expired: %s, activate a new license with 'grapple license activate'", license.Expires.Format("2006-01-02")))
+	default:
+		utils.InfoMessage(fmt.Sprintf("Expires: %s", license.Expires.Format("2006-01-02")))
+	}
+	if len(license.Features) > 0 {
+		utils.InfoMessage(fmt.Sprintf("Features: %v", license.Features))
+	}
+	if license.Key != utils.FreeLicense && !license.Verified {
+		utils.InfoMessage("The license was not verified against the licensing API")
+	}
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package preflight
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	forDeploy bool
+	domain    string
+)
+
+// PreflightCmd represents the preflight command
+var PreflightCmd = &cobra.Command{
+	Use:   "preflight",
+	Short: "Check that the current cluster is ready for a Grapple install or a GRAS deploy",
+	Long: `Preflight runs the checks an install (or, with --deploy, a resource deploy) runs before it changes the cluster:
+  - the Kubernetes version is supported
+  - the nodes have enough allocatable CPU and memory
+  - a default StorageClass exists
+  - an ingress controller (IngressClass) exists
+  - the chart registry and GitHub are reachable (skipped with --offline)
+  - the cluster domain resolves as wildcard (--domain, or the domain of an installed Grapple)
+  - no Grapple, cert-manager or crossplane is installed already (install), Grapple is installed (deploy)
+
+Each check passes, warns or fails. The command exits with an error if any check fails, warnings don't block.
+Installs and deploys run the same checks unless --skip-preflight is set.
+
+Example:
+  grapple preflight
+  grapple preflight --deploy -o json
+  grapple preflight --domain apps.example.com`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restConfig, kubeClient, err := utils.GetKubernetesConfig()
+		if err != nil {
+			utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+			return err
+		}
+
+		report, err := utils.RunPreflight(kubeClient, restConfig, utils.PreflightOptions{Install: !forDeploy, Domain: domain})
+		if err != nil {
+			return fmt.Errorf("failed to run the preflight checks: %w", err)
+		}
+
+		if err := utils.PrintResult(report, func() { printReport(report) }); err != nil {
+			return err
+		}
+		return report.Err()
+	},
+}
+
+func init() {
+	PreflightCmd.Flags().BoolVar(&forDeploy, "deploy", false, "Check the cluster for a GRAS deploy instead of a Grapple install")
+	PreflightCmd.Flags().StringVar(&domain, "domain", "", "Domain to check for wildcard DNS (default: the domain of the installed Grapple)")
+	PreflightCmd.Flags().BoolVar(&utils.OfflineMode, "offline", false, "Skip the connectivity checks")
+}
+
+func printReport(report *utils.PreflightReport) {
+	fmt.Printf("Context: %s\n\n", report.Context)
+	utils.PrintPreflightReport(report)
+
+	fmt.Println()
+	switch {
+	case !report.Passed:
+		utils.ErrorMessage(fmt.Sprintf("%d of %d checks failed, %d warnings", report.Failures, len(report.Checks), report.Warnings))
+	case report.Warnings > 0:
+		utils.InfoMessage(fmt.Sprintf("All checks passed with %d warnings", report.Warnings))
+	default:
+		utils.SuccessMessage(fmt.Sprintf("All %d checks passed", len(report.Checks)))
+	}
+}
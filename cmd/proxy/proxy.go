@@ -0,0 +1,184 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// k3dDomain is the cluster domain of grapple on k3d, it needs the DNS changes of 'grapple k3d patch'
+const k3dDomain = "grpl-k3d.dev"
+
+var (
+	proxyPort    int
+	proxyDomain  string
+	proxyTarget  string
+	proxyAddress string
+)
+
+// ProxyCmd represents the proxy command
+var ProxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Run a local reverse proxy to preview the applications of a local cluster without DNS changes",
+	Long: `Proxy makes the applications of a local cluster reachable without the DNS changes of 'grapple k3d patch'
+(dnsmasq and resolver configuration).
+
+It listens on a local port and routes by host name: <name>.localhost:<port> is sent to the ingress of the
+cluster as <name>.<domain>. Browsers resolve *.localhost to the local machine on their own, so no DNS entry is
+needed. The preview URLs of the ingresses of the cluster are printed on start.
+
+The ingress is reached through --target, by default the k3d load balancer published on port 80 of the host.
+
+Example:
+  grapple proxy
+  grapple proxy --port 9000 --domain grpl-k3d.dev --target 127.0.0.1:80`,
+	RunE: runProxy,
+}
+
+func init() {
+	ProxyCmd.Flags().IntVar(&proxyPort, "port", 8080, "Local port to listen on")
+	ProxyCmd.Flags().StringVar(&proxyAddress, "address", "localhost", "Local address to listen on")
+	ProxyCmd.Flags().StringVar(&proxyDomain, "domain", "", "Cluster domain the host names are mapped to (default: the cluster domain of grsf-config, or grpl-k3d.dev)")
+	ProxyCmd.Flags().StringVar(&proxyTarget, "target", "127.0.0.1:80", "Address of the cluster ingress")
+}
+
+func runProxy(cmd *cobra.Command, args []string) error {
+	if _, _, err := net.SplitHostPort(proxyTarget); err != nil {
+		return fmt.Errorf("%w: invalid --target %q, use host:port: %w", utils.ErrValidation, proxyTarget, err)
+	}
+
+	var hosts []string
+	restConfig, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.InfoMessage("Not connected to a cluster, preview URLs can't be listed: " + err.Error())
+	} else {
+		if proxyDomain == "" {
+			if domain, err := utils.ExtractDomainFromGrplConfig(restConfig); err == nil {
+				proxyDomain = domain
+			}
+		}
+		if ingresses, err := kubeClient.NetworkingV1().Ingresses("").List(context.TODO(), v1.ListOptions{}); err == nil {
+			for _, ingress := range ingresses.Items {
+				for _, rule := range ingress.Spec.Rules {
+					hosts = append(hosts, rule.Host)
+				}
+			}
+		}
+	}
+	if proxyDomain == "" {
+		proxyDomain = k3dDomain
+	}
+
+	listenAddress := net.JoinHostPort(proxyAddress, fmt.Sprint(proxyPort))
+	server := &http.Server{
+		Addr:              listenAddress,
+		Handler:           newHostProxy(proxyDomain, proxyTarget, proxyPort),
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", listenAddress, err)
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("Proxying *.localhost:%d to *.%s at %s", proxyPort, proxyDomain, proxyTarget))
+	printPreviewURLs(hosts)
+	utils.InfoMessage("Press Ctrl+C to stop the proxy")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("proxy stopped: %w", err)
+	}
+	return nil
+}
+
+// newHostProxy returns a reverse proxy sending <name>.localhost to <name>.<domain> at target, redirects of the
+// applications are mapped back so the browser stays on the proxy
+func newHostProxy(domain, target string, port int) http.Handler {
+	localSuffix := fmt.Sprintf(".localhost:%d", port)
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(&url.URL{Scheme: "http", Host: target})
+			r.SetXForwarded()
+			r.Out.Host = clusterHost(r.In.Host, domain)
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			location, err := resp.Location()
+			if err != nil {
+				return nil
+			}
+			if strings.HasSuffix(location.Hostname(), "."+domain) {
+				location.Scheme = "http"
+				location.Host = strings.TrimSuffix(location.Hostname(), "."+domain) + localSuffix
+				resp.Header.Set("Location", location.String())
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, fmt.Sprintf("grapple proxy: %s is not reachable at %s: %v", clusterHost(r.Host, domain), target, err), http.StatusBadGateway)
+		},
+	}
+}
+
+// clusterHost maps the host of a request, e.g. my-app.localhost:8080, to the host name of the cluster ingress,
+// e.g. my-app.grpl-k3d.dev
+func clusterHost(host, domain string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	if name, ok := strings.CutSuffix(host, ".localhost"); ok {
+		return name + "." + domain
+	}
+	if host == "localhost" {
+		return domain
+	}
+	return host
+}
+
+// printPreviewURLs prints the local URL of every ingress host of the cluster domain
+func printPreviewURLs(hosts []string) {
+	seen := map[string]bool{}
+	var urls []string
+	for _, host := range hosts {
+		name, ok := strings.CutSuffix(host, "."+proxyDomain)
+		if !ok || strings.HasPrefix(name, "*") || seen[name] {
+			continue
+		}
+		seen[name] = true
+		urls = append(urls, fmt.Sprintf("  http://%s.localhost:%d  ->  %s", name, proxyPort, host))
+	}
+	if len(urls) == 0 {
+		utils.InfoMessage(fmt.Sprintf("No ingress of *.%s found yet, open http://<name>.localhost:%d for <name>.%s", proxyDomain, proxyPort, proxyDomain))
+		return
+	}
+	sort.Strings(urls)
+	utils.InfoMessage("Preview URLs:")
+	for _, u := range urls {
+		utils.InfoMessage(u)
+	}
+}
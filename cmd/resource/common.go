@@ -2,25 +2,39 @@ package resource
 
 // Global flag variables (which you may bind in init())
 var (
-	GRASName         string
-	GRASTemplate     string
-	DBType           string
-	ModelsInput      string
-	RelationsInput   string
-	DatasourcesInput string
-	DiscoveriesInput string
-	DatabaseSchema   string
-	AutoDiscovery    bool
-	SourceData       string
-	EnableGRUIM      bool
-	DBFilePath       string
-	KubeContext      string
-	KubeNS           string
+	GRASName          string
+	GRASTemplate      string
+	DBType            string
+	ModelsInput       string
+	RelationsInput    string
+	DatasourcesInput  string
+	DiscoveriesInput  string
+	DatabaseSchema    string
+	AutoDiscovery     bool
+	SourceData        string
+	EnableGRUIM       bool
+	GruimsInput       string
+	DBFilePath        string
+	KubeNS            string
+	Labels            map[string]string
+	Annotations       map[string]string
+	GitURL            string
+	GitRef            string
+	GitPath           string
+	githubToken       string
+	cleanupOnFailure  bool
+	verifyDB          bool
+	dbSecretStore     string
+	dbSecretStoreKind string
+	dbSecretKey       string
+	SeedSampleData    int
+	forceDeploy       bool
+	setFiles          []string
+	// helmKubeContext is the kube-context of the helm actions, promote sets it to deploy to another cluster
+	helmKubeContext string
 
 	// Constants (adjust as needed)
-	awsRegistry                = "p7h7z5g3"
-	templateFileDest           = "/tmp/template.yaml" // working template file location
-	kubeblocksTemplateFileDest = "/tmp/kube_db.yaml"
+	templateFileDest = "/tmp/template.yaml" // working template file location
 
 	// Additional Global variables
 	URL      string
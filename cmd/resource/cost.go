@@ -0,0 +1,417 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/civo/civogo"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+const gib = 1 << 30
+
+var (
+	costCPUPrice     float64
+	costMemoryPrice  float64
+	costStoragePrice float64
+	costCivoSize     string
+	costCivoRegion   string
+)
+
+// civoNodePrice is the monthly list price (USD) of a Civo Kubernetes node size with its capacity, the capacity
+// is refreshed from the Civo API when a token is configured
+type civoNodePrice struct {
+	CPU       float64
+	MemoryGiB float64
+	Monthly   float64
+}
+
+// civoNodePrices are the list prices of the standard Civo Kubernetes node sizes
+var civoNodePrices = map[string]civoNodePrice{
+	"g4s.kube.xsmall": {CPU: 1, MemoryGiB: 1, Monthly: 5},
+	"g4s.kube.small":  {CPU: 1, MemoryGiB: 2, Monthly: 10},
+	"g4s.kube.medium": {CPU: 2, MemoryGiB: 4, Monthly: 20},
+	"g4s.kube.large":  {CPU: 4, MemoryGiB: 8, Monthly: 40},
+	"g4s.kube.xlarge": {CPU: 6, MemoryGiB: 16, Monthly: 80},
+}
+
+// civoVolumePrice is the monthly list price (USD) of a GiB of Civo block storage
+const civoVolumePrice = 0.10
+
+// costPrices are the monthly prices of a vCPU, a GiB of memory and a GiB of storage
+type costPrices struct {
+	CPU     float64 `json:"cpu" yaml:"cpu"`
+	Memory  float64 `json:"memory" yaml:"memory"`
+	Storage float64 `json:"storage" yaml:"storage"`
+	Source  string  `json:"source" yaml:"source"`
+}
+
+// costItem are the requests of a part of a GRAS, for all its replicas
+type costItem struct {
+	Component   string   `json:"component" yaml:"component"`
+	Kind        string   `json:"kind" yaml:"kind"`
+	Replicas    int64    `json:"replicas" yaml:"replicas"`
+	CPU         string   `json:"cpu" yaml:"cpu"`
+	Memory      string   `json:"memory" yaml:"memory"`
+	Storage     string   `json:"storage" yaml:"storage"`
+	MonthlyCost *float64 `json:"monthlyCost,omitempty" yaml:"monthlyCost,omitempty"`
+	// NoRequests is set when containers don't request resources, they are counted as 0
+	NoRequests bool `json:"noRequests,omitempty" yaml:"noRequests,omitempty"`
+
+	cpu, memory, storage resource.Quantity
+}
+
+// costReport is the result of resource cost
+type costReport struct {
+	GRAS        string      `json:"gras" yaml:"gras"`
+	Namespace   string      `json:"namespace" yaml:"namespace"`
+	Items       []costItem  `json:"items" yaml:"items"`
+	CPU         string      `json:"cpu" yaml:"cpu"`
+	Memory      string      `json:"memory" yaml:"memory"`
+	Storage     string      `json:"storage" yaml:"storage"`
+	Prices      *costPrices `json:"prices,omitempty" yaml:"prices,omitempty"`
+	MonthlyCost *float64    `json:"monthlyCost,omitempty" yaml:"monthlyCost,omitempty"`
+}
+
+// CostCmd represents the resource cost command
+var CostCmd = &cobra.Command{
+	Use:   "cost [gras-name]",
+	Short: "Estimate the resources and the monthly cost of a GRAS",
+	Long: `Cost sums the CPU, memory and storage requested by a GrappleApplicationSet: the grapi and gruim
+deployments with all their replicas, the internal DB (the KubeBlocks cluster of the GRAS) and the volumes
+named after the GRAS. Containers without requests are counted as 0 and flagged, so the report also shows
+what isn't sized yet.
+
+With prices per month of a vCPU (--cpu-price), a GiB of memory (--memory-price) and a GiB of storage
+(--storage-price), or derived from a Civo node size (--civo-size), the monthly cost is estimated as well.
+The price of a Civo node is split evenly between its CPUs and its memory; the capacity of the size is taken
+from the Civo API when a Civo API token is configured, the prices are the Civo list prices. Prices given
+explicitly override the derived ones.
+
+Example:
+  grapple resource cost my-app --namespace my-app
+  grapple resource cost my-app --civo-size g4s.kube.medium
+  grapple resource cost my-app --cpu-price 15 --memory-price 4 --storage-price 0.1 -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCost,
+}
+
+func init() {
+	CostCmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace of the GRAS resource")
+	CostCmd.Flags().Float64Var(&costCPUPrice, "cpu-price", 0, "Monthly price of a vCPU")
+	CostCmd.Flags().Float64Var(&costMemoryPrice, "memory-price", 0, "Monthly price of a GiB of memory")
+	CostCmd.Flags().Float64Var(&costStoragePrice, "storage-price", 0, "Monthly price of a GiB of storage")
+	CostCmd.Flags().StringVar(&costCivoSize, "civo-size", "", "Derive the prices from this Civo node size, e.g. g4s.kube.medium")
+	CostCmd.Flags().StringVar(&costCivoRegion, "civo-region", "LON1", "Civo region the node sizes are read from")
+}
+
+func runCost(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		GRASName = args[0]
+	}
+
+	prices, err := costPricesFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if err := resolveGrasName(); err != nil {
+		return err
+	}
+
+	items, err := grasCostItems()
+	if err != nil {
+		return err
+	}
+
+	report := costReport{GRAS: GRASName, Namespace: KubeNS, Prices: prices}
+	var cpu, memory, storage resource.Quantity
+	total := 0.0
+	for i := range items {
+		cpu.Add(items[i].cpu)
+		memory.Add(items[i].memory)
+		storage.Add(items[i].storage)
+		if prices != nil {
+			cost := prices.monthly(items[i].cpu, items[i].memory, items[i].storage)
+			items[i].MonthlyCost = &cost
+			total += cost
+		}
+	}
+	report.Items = items
+	report.CPU, report.Memory, report.Storage = formatQuantity(cpu), formatQuantity(memory), formatQuantity(storage)
+	if prices != nil {
+		report.MonthlyCost = &total
+	}
+
+	return utils.PrintResult(report, func() { printCostReport(report) })
+}
+
+// costPricesFromFlags returns the prices of the flags, nil when none are given
+func costPricesFromFlags(cmd *cobra.Command) (*costPrices, error) {
+	var prices *costPrices
+	if costCivoSize != "" {
+		node, err := civoNodeSize(costCivoSize)
+		if err != nil {
+			return nil, err
+		}
+		prices = &costPrices{
+			CPU:     node.Monthly / 2 / node.CPU,
+			Memory:  node.Monthly / 2 / node.MemoryGiB,
+			Storage: civoVolumePrice,
+			Source:  "civo " + costCivoSize,
+		}
+	}
+
+	explicit := false
+	for _, name := range []string{"cpu-price", "memory-price", "storage-price"} {
+		if cmd.Flags().Changed(name) {
+			explicit = true
+		}
+	}
+	if !explicit {
+		return prices, nil
+	}
+	if costCPUPrice < 0 || costMemoryPrice < 0 || costStoragePrice < 0 {
+		return nil, fmt.Errorf("%w: prices can't be negative", utils.ErrValidation)
+	}
+	if prices == nil {
+		prices = &costPrices{Source: "flags"}
+	} else {
+		prices.Source += " and flags"
+	}
+	if cmd.Flags().Changed("cpu-price") {
+		prices.CPU = costCPUPrice
+	}
+	if cmd.Flags().Changed("memory-price") {
+		prices.Memory = costMemoryPrice
+	}
+	if cmd.Flags().Changed("storage-price") {
+		prices.Storage = costStoragePrice
+	}
+	return prices, nil
+}
+
+// civoNodeSize returns the list price of a Civo node size, with its capacity from the Civo API when a token is
+// configured
+func civoNodeSize(name string) (civoNodePrice, error) {
+	node, ok := civoNodePrices[name]
+	if !ok {
+		known := make([]string, 0, len(civoNodePrices))
+		for size := range civoNodePrices {
+			known = append(known, size)
+		}
+		sort.Strings(known)
+		return node, fmt.Errorf("%w: no list price known for Civo size %s (known: %s), use --cpu-price and --memory-price",
+			utils.ErrValidation, name, strings.Join(known, ", "))
+	}
+
+	token := utils.ConfigValue("civo-api-token")
+	if token == "" {
+		return node, nil
+	}
+	client, err := civogo.NewClient(token, costCivoRegion)
+	if err != nil {
+		utils.DebugMessage(fmt.Sprintf("Failed to create civo client, using the built-in capacity of %s: %v", name, err))
+		return node, nil
+	}
+	sizes, err := client.ListInstanceSizes()
+	if err != nil {
+		utils.DebugMessage(fmt.Sprintf("Failed to list the Civo sizes, using the built-in capacity of %s: %v", name, err))
+		return node, nil
+	}
+	for _, size := range sizes {
+		if size.Name == name && size.CPUCores > 0 && size.RAMMegabytes > 0 {
+			node.CPU = float64(size.CPUCores)
+			node.MemoryGiB = float64(size.RAMMegabytes) / 1024
+		}
+	}
+	return node, nil
+}
+
+// monthly returns the monthly cost of the given requests
+func (p *costPrices) monthly(cpu, memory, storage resource.Quantity) float64 {
+	return float64(cpu.MilliValue())/1000*p.CPU +
+		float64(memory.Value())/gib*p.Memory +
+		float64(storage.Value())/gib*p.Storage
+}
+
+// grasCostItems collects the requests of the deployments, the internal DB and the volumes of the GRAS
+func grasCostItems() ([]costItem, error) {
+	var items []costItem
+
+	deployments := append([]string{GRASName + "-grapi"}, gruimDeployments()...)
+	for _, name := range deployments {
+		deployment, err := clientset.AppsV1().Deployments(KubeNS).Get(context.TODO(), name, v1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployment %s: %w", name, err)
+		}
+		replicas := int64(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = int64(*deployment.Spec.Replicas)
+		}
+		item := costItem{Component: name, Kind: "Deployment", Replicas: replicas}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			requests := container.Resources.Requests
+			if requests.Cpu().IsZero() || requests.Memory().IsZero() {
+				item.NoRequests = true
+			}
+			item.cpu.Add(*requests.Cpu())
+			item.memory.Add(*requests.Memory())
+		}
+		item.cpu = multiplyQuantity(item.cpu, replicas)
+		item.memory = multiplyQuantity(item.memory, replicas)
+		items = append(items, item)
+	}
+
+	dbItems, err := internalDBCostItems()
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, dbItems...)
+
+	claims, err := clientset.CoreV1().PersistentVolumeClaims(KubeNS).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes of namespace %s: %w", KubeNS, err)
+	}
+	for _, claim := range claims.Items {
+		if !belongsToGras(claim.Name) {
+			continue
+		}
+		item := costItem{Component: claim.Name, Kind: "PersistentVolumeClaim", Replicas: 1}
+		item.storage = claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		items = append(items, item)
+	}
+
+	for i := range items {
+		items[i].CPU = formatQuantity(items[i].cpu)
+		items[i].Memory = formatQuantity(items[i].memory)
+		items[i].Storage = formatQuantity(items[i].storage)
+	}
+	return items, nil
+}
+
+// internalDBCostItems returns the components of the KubeBlocks cluster of the GRAS, the internal DB, with the
+// storage of their volume claim templates
+func internalDBCostItems() ([]costItem, error) {
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	cluster, err := dynamicClient.Resource(kubeBlocksClusterGVR).Namespace(KubeNS).Get(context.TODO(), GRASName, v1.GetOptions{})
+	if err != nil {
+		// Without an internal DB (or without KubeBlocks) there is nothing to add
+		utils.DebugMessage(fmt.Sprintf("No internal DB found for GRAS %s: %v", GRASName, err))
+		return nil, nil
+	}
+
+	components, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "componentSpecs")
+	var items []costItem
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(component, "name")
+		replicas, found, _ := unstructured.NestedInt64(component, "replicas")
+		if !found {
+			replicas = 1
+		}
+		item := costItem{Component: fmt.Sprintf("%s-%s", GRASName, name), Kind: "Cluster (internal DB)", Replicas: replicas}
+
+		cpu, _, _ := unstructured.NestedString(component, "resources", "requests", "cpu")
+		memory, _, _ := unstructured.NestedString(component, "resources", "requests", "memory")
+		item.NoRequests = cpu == "" || memory == ""
+		item.cpu = multiplyQuantity(parseQuantity(cpu), replicas)
+		item.memory = multiplyQuantity(parseQuantity(memory), replicas)
+
+		claims, _, _ := unstructured.NestedSlice(component, "volumeClaimTemplates")
+		for _, t := range claims {
+			claim, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			storage, _, _ := unstructured.NestedString(claim, "spec", "resources", "requests", "storage")
+			item.storage.Add(multiplyQuantity(parseQuantity(storage), replicas))
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseQuantity parses a quantity of a manifest, invalid or empty quantities are 0
+func parseQuantity(value string) resource.Quantity {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}
+	}
+	return quantity
+}
+
+func multiplyQuantity(quantity resource.Quantity, factor int64) resource.Quantity {
+	return *resource.NewMilliQuantity(quantity.MilliValue()*factor, quantity.Format)
+}
+
+func formatQuantity(quantity resource.Quantity) string {
+	if quantity.IsZero() {
+		return "-"
+	}
+	return quantity.String()
+}
+
+func printCostReport(report costReport) {
+	if len(report.Items) == 0 {
+		utils.InfoMessage(fmt.Sprintf("No deployments, internal DB or volumes found for GRAS %s in namespace %s", report.GRAS, report.Namespace))
+		return
+	}
+
+	fmt.Printf("%-36s %-22s %-9s %-8s %-8s %-8s", "COMPONENT", "KIND", "REPLICAS", "CPU", "MEMORY", "STORAGE")
+	if report.Prices != nil {
+		fmt.Printf(" %s", "MONTHLY")
+	}
+	fmt.Println()
+	unsized := false
+	for _, item := range report.Items {
+		component := item.Component
+		if item.NoRequests {
+			component += " *"
+			unsized = true
+		}
+		fmt.Printf("%-36s %-22s %-9d %-8s %-8s %-8s", component, item.Kind, item.Replicas, item.CPU, item.Memory, item.Storage)
+		if item.MonthlyCost != nil {
+			fmt.Printf(" %.2f", *item.MonthlyCost)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("%-36s %-22s %-9s %-8s %-8s %-8s", "TOTAL", "", "", report.CPU, report.Memory, report.Storage)
+	if report.MonthlyCost != nil {
+		fmt.Printf(" %.2f", *report.MonthlyCost)
+	}
+	fmt.Println()
+
+	if unsized {
+		fmt.Println("\n* containers without CPU or memory requests, counted as 0; set requests to size the GRAS reliably")
+	}
+	if report.Prices != nil {
+		fmt.Printf("\nPrices per month (%s): %.2f per vCPU, %.2f per GiB of memory, %.2f per GiB of storage\n",
+			report.Prices.Source, report.Prices.CPU, report.Prices.Memory, report.Prices.Storage)
+	} else {
+		fmt.Println("\nPass --civo-size or --cpu-price, --memory-price and --storage-price to estimate the monthly cost")
+	}
+}
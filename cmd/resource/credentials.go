@@ -0,0 +1,167 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// dbCredentialSecretName is the secret grapi reads the datasource credentials from, via extraSecrets
+func dbCredentialSecretName() string {
+	return fmt.Sprintf("%s-conn-credential", GRASName)
+}
+
+// createExternalDBSecret writes the datasource credentials to the credential secret, they are only referenced
+// from the GRAS as $(key), so they never end up in the working template file
+func createExternalDBSecret(host, port, user, password, url string) error {
+	utils.InfoMessage("Creating external db secret using collected datasource info...")
+
+	data := map[string][]byte{
+		"host":     []byte(host),
+		"port":     []byte(port),
+		"username": []byte(user),
+		"password": []byte(password),
+	}
+	// The url may embed credentials as well
+	if url != "" {
+		data["url"] = []byte(url)
+	}
+	if err := createCredentialSecret(dbCredentialSecretName(), data); err != nil {
+		utils.ErrorMessage("Failed to create external db secret: " + err.Error())
+		return err
+	}
+	utils.SuccessMessage("Created external db secret")
+	return nil
+}
+
+// createCredentialSecret creates or updates a credential secret of the GRAS namespace
+func createCredentialSecret(name string, data map[string][]byte) error {
+	newSecret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: KubeNS,
+		},
+		Data: data,
+	}
+	utils.ApplyCommonMetadata(&newSecret.ObjectMeta)
+
+	_, err := clientset.CoreV1().Secrets(KubeNS).Create(context.TODO(), newSecret, v1.CreateOptions{})
+	if err == nil {
+		recordCreatedSecret(KubeNS, newSecret.Name)
+		return nil
+	}
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().Secrets(KubeNS).Update(context.TODO(), newSecret, v1.UpdateOptions{})
+	}
+	return err
+}
+
+// externalSecretGVR returns the ExternalSecret resource of the installed External Secrets Operator,
+// newer releases serve external-secrets.io/v1, older ones only v1beta1
+func externalSecretGVR() (schema.GroupVersionResource, error) {
+	for _, version := range []string{"v1", "v1beta1"} {
+		resources, err := clientset.Discovery().ServerResourcesForGroupVersion("external-secrets.io/" + version)
+		if err != nil {
+			continue
+		}
+		for _, r := range resources.APIResources {
+			if r.Kind == "ExternalSecret" {
+				return schema.GroupVersionResource{Group: "external-secrets.io", Version: version, Resource: r.Name}, nil
+			}
+		}
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("the External Secrets Operator is not installed, ExternalSecret is not served by the cluster")
+}
+
+// createDBExternalSecret lets the External Secrets Operator create the credential secret from dbSecretStore,
+// the remote secret dbSecretKey has to provide the host, port, username and password properties
+func createDBExternalSecret() error {
+	gvr, err := externalSecretGVR()
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	name := dbCredentialSecretName()
+	if dbSecretKey == "" {
+		dbSecretKey = GRASName + "-db"
+	}
+	externalSecret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": gvr.GroupVersion().String(),
+		"kind":       "ExternalSecret",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": KubeNS,
+		},
+		"spec": map[string]interface{}{
+			"refreshInterval": "1h",
+			"secretStoreRef": map[string]interface{}{
+				"name": dbSecretStore,
+				"kind": dbSecretStoreKind,
+			},
+			"target": map[string]interface{}{
+				"name":           name,
+				"creationPolicy": "Owner",
+			},
+			"dataFrom": []interface{}{
+				map[string]interface{}{
+					"extract": map[string]interface{}{"key": dbSecretKey},
+				},
+			},
+		},
+	}}
+	utils.ApplyCommonMetadataToUnstructured(externalSecret)
+
+	utils.InfoMessage(fmt.Sprintf("Creating ExternalSecret %s from %s %s...", name, dbSecretStoreKind, dbSecretStore))
+	resource := dynamicClient.Resource(gvr).Namespace(KubeNS)
+	_, err = resource.Create(context.TODO(), externalSecret, v1.CreateOptions{})
+	if err == nil {
+		recordCreatedResource(gvr, "ExternalSecret", KubeNS, name)
+	} else if k8serrors.IsAlreadyExists(err) {
+		existing, getErr := resource.Get(context.TODO(), name, v1.GetOptions{})
+		if getErr != nil {
+			return fmt.Errorf("failed to get ExternalSecret %s: %w", name, getErr)
+		}
+		externalSecret.SetResourceVersion(existing.GetResourceVersion())
+		_, err = resource.Update(context.TODO(), externalSecret, v1.UpdateOptions{})
+	}
+	if err != nil {
+		utils.ErrorMessage("Failed to create ExternalSecret: " + err.Error())
+		return err
+	}
+
+	// grapi can't start without the credentials, wait until the operator has synced them
+	err = utils.Poll(2*time.Minute, func() (bool, error) {
+		obj, err := resource.Get(context.TODO(), name, v1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if ok && condition["type"] == "Ready" && condition["status"] == "True" {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if utils.IsCanceled(err) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("ExternalSecret %s was not synced from %s %s within 2 minutes, check 'kubectl describe externalsecret %s -n %s'", name, dbSecretStoreKind, dbSecretStore, name, KubeNS)
+	}
+	utils.SuccessMessage(fmt.Sprintf("Credentials synced to secret %s", name))
+	return nil
+}
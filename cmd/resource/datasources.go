@@ -0,0 +1,242 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// datasourceInputs are the --datasource entries, datasourcesFile is the YAML list of --datasources-file
+	datasourceInputs []string
+	datasourcesFile  string
+	// extraDatasources are the datasources of the GRAS next to the one of --db-type
+	extraDatasources []extraDatasource
+)
+
+var datasourceNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// extraDatasource is an additional MySQL datasource of a GRAS, with its own credential secret, restcrud and
+// optionally discovery. Models lists the models stored in it, the others stay in the main datasource.
+type extraDatasource struct {
+	Name     string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Database string
+	URL      string
+	Models   []string
+	Discover bool
+}
+
+// secretName is the credential secret of the datasource, referenced from the GRAS via extraSecrets
+func (ds extraDatasource) secretName() string {
+	return fmt.Sprintf("%s-%s-conn-credential", GRASName, ds.Name)
+}
+
+// keyPrefix prefixes the keys of the credential secret: grapi gets all extraSecrets as environment variables,
+// unprefixed keys of several datasources would collide
+func (ds extraDatasource) keyPrefix() string {
+	return strings.ReplaceAll(ds.Name, "-", "_") + "_"
+}
+
+// parseExtraDatasources reads the datasources of --datasource and --datasources-file
+func parseExtraDatasources() ([]extraDatasource, error) {
+	var entries []NamedSpec
+	for _, input := range datasourceInputs {
+		specs, err := parseNamedSpecs(input, "datasource")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", utils.ErrValidation, err)
+		}
+		entries = append(entries, specs...)
+	}
+	if datasourcesFile != "" {
+		data, err := os.ReadFile(datasourcesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read datasources file: %w", err)
+		}
+		var list []map[string]interface{}
+		if err := yaml.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("%w: failed to parse datasources file %s: %w", utils.ErrValidation, datasourcesFile, err)
+		}
+		for _, item := range list {
+			name, _ := item["name"].(string)
+			entries = append(entries, NamedSpec{Name: name, Spec: item})
+		}
+	}
+
+	var datasources []extraDatasource
+	seen := map[string]bool{}
+	for i, entry := range entries {
+		ds := extraDatasource{
+			Name:     entry.Name,
+			Host:     specValue(entry.Spec, "host"),
+			Port:     specValue(entry.Spec, "port"),
+			User:     specValue(entry.Spec, "user"),
+			Password: specValue(entry.Spec, "password"),
+			Database: specValue(entry.Spec, "database"),
+			URL:      specValue(entry.Spec, "url"),
+			Models:   specList(entry.Spec, "models"),
+			Discover: specValue(entry.Spec, "discover") == "true",
+		}
+		if ds.Port == "" {
+			ds.Port = "3306"
+		}
+		switch {
+		case !datasourceNameRegex.MatchString(ds.Name):
+			return nil, fmt.Errorf("%w: datasource %d: name %q must consist of lower case letters, digits and '-'", utils.ErrValidation, i+1, ds.Name)
+		case seen[ds.Name]:
+			return nil, fmt.Errorf("%w: datasource %s is defined twice", utils.ErrValidation, ds.Name)
+		case ds.Host == "" || ds.Database == "":
+			return nil, fmt.Errorf("%w: datasource %s needs a host and a database", utils.ErrValidation, ds.Name)
+		}
+		seen[ds.Name] = true
+		datasources = append(datasources, ds)
+	}
+	return datasources, nil
+}
+
+// specValue returns a property of a datasource entry as a string, JSON numbers and booleans included
+func specValue(spec map[string]interface{}, key string) string {
+	switch value := spec[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// specList returns a property given as a list or as a comma separated string
+func specList(spec map[string]interface{}, key string) []string {
+	var items []string
+	switch value := spec[key].(type) {
+	case string:
+		items = splitDefaultsList(value)
+	case []interface{}:
+		for _, item := range value {
+			items = append(items, fmt.Sprint(item))
+		}
+	}
+	return items
+}
+
+// prepareExtraDatasources verifies the connections of the additional datasources and creates their credential secrets
+func prepareExtraDatasources() error {
+	for _, ds := range extraDatasources {
+		if verifyDB {
+			utils.InfoMessage(fmt.Sprintf("Verifying the connection of datasource %s...", ds.Name))
+			conn := utils.MySQLConnection{
+				Host:            ds.Host,
+				Port:            ds.Port,
+				User:            ds.User,
+				Password:        ds.Password,
+				Database:        ds.Database,
+				RequireDatabase: ds.Discover,
+			}
+			if err := utils.VerifyMySQLConnection(clientset, KubeNS, conn); err != nil {
+				utils.ErrorMessage(err.Error() + " (use --verify-db=false to skip this check)")
+				return err
+			}
+		}
+
+		prefix := ds.keyPrefix()
+		data := map[string][]byte{
+			prefix + "host":     []byte(ds.Host),
+			prefix + "port":     []byte(ds.Port),
+			prefix + "username": []byte(ds.User),
+			prefix + "password": []byte(ds.Password),
+		}
+		if ds.URL != "" {
+			data[prefix+"url"] = []byte(ds.URL)
+		}
+		if err := createCredentialSecret(ds.secretName(), data); err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to create the secret of datasource %s: %v", ds.Name, err))
+			return err
+		}
+		utils.SuccessMessage(fmt.Sprintf("Created secret %s of datasource %s", ds.secretName(), ds.Name))
+	}
+	return nil
+}
+
+// updateTemplateForExtraDatasources adds the additional datasources, their secrets and discoveries to the
+// template and stores the models they list in them
+func updateTemplateForExtraDatasources(tmpl *GrasTemplate) error {
+	for _, ds := range extraDatasources {
+		if ds.Name == DatabaseSchema {
+			return fmt.Errorf("%w: datasource %s has the name of the main datasource", utils.ErrValidation, ds.Name)
+		}
+		prefix := ds.keyPrefix()
+		url := ""
+		if ds.URL != "" {
+			url = fmt.Sprintf("$(%surl)", prefix)
+		}
+		tmpl.Grapi.ExtraSecrets = append(tmpl.Grapi.ExtraSecrets, ds.secretName())
+		tmpl.Grapi.Datasources = append(tmpl.Grapi.Datasources, NamedSpec{
+			Name: ds.Name,
+			Spec: map[string]interface{}{
+				"mysql": map[string]interface{}{
+					"name":     ds.Name,
+					"url":      url,
+					"host":     fmt.Sprintf("$(%shost)", prefix),
+					"port":     fmt.Sprintf("$(%sport)", prefix),
+					"user":     fmt.Sprintf("$(%susername)", prefix),
+					"password": fmt.Sprintf("$(%spassword)", prefix),
+					"database": ds.Database,
+				},
+			},
+		})
+
+		if ds.Discover {
+			tmpl.Grapi.Discoveries = append(tmpl.Grapi.Discoveries, NamedSpec{
+				Name: ds.Name,
+				Spec: map[string]interface{}{
+					"all":              len(ds.Models) == 0,
+					"models":           strings.Join(ds.Models, ","),
+					"disableCamelCase": false,
+					"schema":           ds.Database,
+					"dataSource":       ds.Name,
+				},
+			})
+			continue
+		}
+
+		for _, model := range ds.Models {
+			found := false
+			for i, spec := range tmpl.Grapi.Models {
+				if spec.Name != model {
+					continue
+				}
+				if spec.Spec == nil {
+					tmpl.Grapi.Models[i].Spec = map[string]interface{}{}
+				}
+				tmpl.Grapi.Models[i].Spec["datasource"] = ds.Name
+				found = true
+			}
+			if !found {
+				return fmt.Errorf("%w: datasource %s lists model %s, which is not defined (see --models)", utils.ErrValidation, ds.Name, model)
+			}
+		}
+	}
+	return nil
+}
+
+// extraDatasourceRestcruds returns a restcrud per additional datasource
+func extraDatasourceRestcruds() []NamedSpec {
+	var restcruds []NamedSpec
+	for _, ds := range extraDatasources {
+		restcruds = append(restcruds, NamedSpec{
+			Name: ds.Name,
+			Spec: map[string]interface{}{
+				"datasource": ds.Name,
+			},
+		})
+	}
+	return restcruds
+}
@@ -0,0 +1,168 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// deployDefaultsConfigMap is published by cluster admins in grpl-system for the whole cluster, and
+// optionally in a namespace to override the cluster wide keys for that namespace
+const deployDefaultsConfigMap = "grpl-defaults"
+
+// Keys of the grpl-defaults ConfigMap, lists are comma separated
+const (
+	defaultsKeyAllowedDBTypes    = "allowed-db-types"
+	defaultsKeyRequiredLabels    = "required-labels"
+	defaultsKeyIngressClass      = "ingress-class"
+	defaultsKeyAllowedRegistries = "allowed-registries"
+)
+
+// deployDefaults are the guardrails of the platform team for resource deploy
+type deployDefaults struct {
+	AllowedDBTypes    []string
+	RequiredLabels    []string
+	IngressClass      string
+	AllowedRegistries []string
+}
+
+// loadDeployDefaults reads the grpl-defaults ConfigMaps of grpl-system and of the namespace, the keys set in
+// the namespace take precedence. Without any ConfigMap nothing is enforced.
+func loadDeployDefaults(namespace string) (*deployDefaults, error) {
+	values := map[string]string{}
+	for _, ns := range []string{"grpl-system", namespace} {
+		if ns == "" {
+			continue
+		}
+		cm, err := clientset.CoreV1().ConfigMaps(ns).Get(context.TODO(), deployDefaultsConfigMap, v1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the %s ConfigMap of namespace %s: %w", deployDefaultsConfigMap, ns, err)
+		}
+		utils.InfoMessage(fmt.Sprintf("Applying the deploy defaults of %s/%s", ns, deployDefaultsConfigMap))
+		for key, value := range cm.Data {
+			values[key] = value
+		}
+	}
+
+	return &deployDefaults{
+		AllowedDBTypes:    splitDefaultsList(values[defaultsKeyAllowedDBTypes]),
+		RequiredLabels:    splitDefaultsList(values[defaultsKeyRequiredLabels]),
+		IngressClass:      strings.TrimSpace(values[defaultsKeyIngressClass]),
+		AllowedRegistries: splitDefaultsList(values[defaultsKeyAllowedRegistries]),
+	}, nil
+}
+
+func splitDefaultsList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// applyToInputs prefills and checks the deploy inputs: the DB type and the labels
+func (d *deployDefaults) applyToInputs() error {
+	if len(d.AllowedDBTypes) > 0 && (GRASTemplate == utils.DB_MYSQL_MODEL_BASED || GRASTemplate == utils.DB_MYSQL_DISCOVERY_BASED) {
+		if DBType == "" && len(d.AllowedDBTypes) == 1 {
+			DBType = d.AllowedDBTypes[0]
+			utils.InfoMessage(fmt.Sprintf("Using db type %s, the only one allowed in namespace %s", DBType, KubeNS))
+		}
+		if DBType != "" && !utils.Contains(d.AllowedDBTypes, DBType) {
+			return fmt.Errorf("db type %s is not allowed in namespace %s, allowed: %s", DBType, KubeNS, strings.Join(d.AllowedDBTypes, ", "))
+		}
+	}
+
+	var missing []string
+	for _, label := range d.RequiredLabels {
+		if _, ok := utils.CommonLabels[label]; !ok {
+			missing = append(missing, label)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("namespace %s requires the labels %s, set them with --labels=%s=...", KubeNS, strings.Join(missing, ", "), missing[0])
+	}
+	return nil
+}
+
+// applyToTemplate sets the default ingress class and checks that all images of the GRAS come from an allowed
+// registry: grapi, the gruims and the init containers
+func (d *deployDefaults) applyToTemplate(tmpl *GrasTemplate) error {
+	if d.IngressClass != "" {
+		if tmpl.Grapi.Extra == nil {
+			tmpl.Grapi.Extra = map[string]interface{}{}
+		}
+		if _, ok := tmpl.Grapi.Extra["ingressClassName"]; !ok {
+			tmpl.Grapi.Extra["ingressClassName"] = d.IngressClass
+		}
+		if tmpl.Gruim != nil {
+			if _, ok := tmpl.Gruim["ingressClassName"]; !ok {
+				tmpl.Gruim["ingressClassName"] = d.IngressClass
+			}
+		}
+		for _, gruim := range tmpl.Gruims {
+			if _, ok := gruim.Spec["ingressClassName"]; !ok {
+				gruim.Spec["ingressClassName"] = d.IngressClass
+			}
+		}
+	}
+
+	if len(d.AllowedRegistries) == 0 {
+		return nil
+	}
+	var rejected []string
+	for _, image := range templateImages(tmpl) {
+		if !d.registryAllowed(image) && !utils.Contains(rejected, image) {
+			rejected = append(rejected, image)
+		}
+	}
+	if len(rejected) > 0 {
+		sort.Strings(rejected)
+		return fmt.Errorf("the images %s are not from a registry allowed in namespace %s (%s), use --image-registry to pull them from a mirror",
+			strings.Join(rejected, ", "), KubeNS, strings.Join(d.AllowedRegistries, ", "))
+	}
+	return nil
+}
+
+// templateImages returns the images the GRAS of the template runs. grapi and gruim are pulled by the GRAS chart,
+// through the --image-registry mirror it gets as global.imageRegistry.
+func templateImages(tmpl *GrasTemplate) []string {
+	images := []string{utils.MirrorImage("grpl/grapi")}
+	if tmpl.Gruim != nil || len(tmpl.Gruims) > 0 {
+		images = append(images, utils.MirrorImage("grpl/gruim"))
+	}
+	for _, c := range tmpl.Grapi.InitContainers {
+		if image, _ := c.Spec["image"].(string); image != "" {
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+// registryAllowed reports whether the fully qualified image reference starts with one of the allowed registries,
+// e.g. "mysql" is docker.io/library/mysql and is allowed by "docker.io" or "docker.io/library"
+func (d *deployDefaults) registryAllowed(image string) bool {
+	ref := image
+	first := strings.SplitN(image, "/", 2)[0]
+	if !strings.Contains(image, "/") {
+		ref = "docker.io/library/" + image
+	} else if !strings.ContainsAny(first, ".:") && first != "localhost" {
+		ref = "docker.io/" + image
+	}
+	for _, registry := range d.AllowedRegistries {
+		registry = strings.TrimSuffix(registry, "/")
+		if ref == registry || strings.HasPrefix(ref, registry+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -8,13 +8,11 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/grapple-solution/grapple_cli/utils"
-	"github.com/manifoldco/promptui"
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -23,7 +21,7 @@ import (
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
-	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
 
 	// Kubernetes client libraries
 
@@ -31,7 +29,6 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // DeployCmd represents the deploy command.
@@ -47,27 +44,86 @@ This command will:
   3. Build a Kubernetes+Helm client and deploy your manifest to the cluster
   4. Wait for the deployment to become ready
 
+The GRAS spec can also be taken from a Git repository with --git, pointing --git-path either to
+a GrappleApplicationSet manifest (as written by 'grapple resource render') or to an answers file
+that sets the deploy flags, e.g. "gras-template: db-file". Flags on the command line take precedence.
+
+With an external database the credentials are checked from a short lived pod in the target namespace
+before the secret and the release are created, --verify-db=false skips the check. The credentials are
+only written to the <gras-name>-conn-credential secret and referenced from the GRAS, never to the
+template file. With --db-secret-store they don't pass through the CLI at all: an ExternalSecret lets
+the External Secrets Operator sync them from the store (the remote secret --db-secret-key has to provide
+the host, port, username and password properties).
+
+Cluster admins can set guardrails in a grpl-defaults ConfigMap in grpl-system, or in the target namespace
+to override them there: allowed-db-types, required-labels, ingress-class and allowed-registries (lists are
+comma separated). The deploy prefills and enforces them.
+
+Several UIs can be deployed over the same grapi, e.g. an admin and a customer UI: --gruims adds GRUIMs named
+<gras-name>-<name>, each with its own deployment and ingress host. Their spec starts from the main GRUIM and is
+overridden by the JSON given for each of them.
+
+Large or sensitive values (TLS certificates, SSH keys, JSON credentials) can be taken from files with
+--set-file <path>=<file>. The files are stored in the <gras-name>-files secret, keyed by the last segment of
+the path, and the value is set to a $(key) reference to it instead of the file contents.
+
+Custom template types can be added as template plugins, see 'grapple resource templates --help'. They are
+offered next to the built-in types, their prompts are answered with --template-value <name>=<value>.
+
+Deploying is idempotent: when the release of the GRAS is deployed with the same chart version and the
+same rendered values, nothing is changed. Use --force to redeploy it anyway.
+
 Example:
-  grapple resource deploy --name my-app --namespace default`,
+  grapple resource deploy --name my-app --namespace default
+  grapple resource deploy --git https://github.com/my-org/specs.git --git-ref v1.2.0 --git-path apps/my-app
+  grapple resource deploy --gras-name my-app --gras-template db-mysql-model-based --db-type external --database-schema shop --db-secret-store vault --db-secret-key shop-db
+  grapple resource deploy --gras-name shop --gras-template db-mysql-model-based --db-type internal --db-engine postgres --db-replicas 2 --db-storage-size 50Gi
+  grapple resource deploy --gras-name shop --gras-template db-file --db-type internal --enable-gruim --gruims "admin:{}"
+  grapple resource deploy --gras-name shop --gras-template db-postgres --template-value schema=shop
+  grapple resource deploy --gras-name my-app --set-file grapi.env.GOOGLE_CREDS=creds.json`,
 	RunE: runDeploy,
 }
 
 func init() {
 	// Setup cobra flags (bind these to the global variables)
 	DeployCmd.Flags().StringVar(&GRASName, "gras-name", "", "Name of the GRAS resource")
-	DeployCmd.Flags().StringVar(&GRASTemplate, "gras-template", "", "Template type to use")
+	DeployCmd.Flags().StringVar(&GRASTemplate, "gras-template", "", "Template type to use, a built-in type, a template plugin or an oci:// reference of one")
+	DeployCmd.Flags().StringToStringVar(&templateValues, "template-value", map[string]string{}, "Answer of a prompt of a template plugin, e.g. --template-value schema=shop")
 	DeployCmd.Flags().StringVar(&DBType, "db-type", "", "Database type (internal or external)")
+	DeployCmd.Flags().StringVar(&dbEngine, "db-engine", "", "Engine of the internal DB: mysql, mariadb, postgres or redis (default mysql)")
+	DeployCmd.Flags().StringVar(&dbVersion, "db-version", "", "Version of the internal DB engine, e.g. 8.0.30 (default: the version tested with the engine)")
+	DeployCmd.Flags().IntVar(&dbReplicas, "db-replicas", 1, "Replicas of the internal DB")
+	DeployCmd.Flags().StringVar(&dbStorageClass, "db-storage-class", "", "Storage class of the internal DB volumes (default: the default storage class)")
+	DeployCmd.Flags().StringVar(&dbStorageSize, "db-storage-size", "20Gi", "Size of the internal DB volumes")
+	DeployCmd.Flags().BoolVar(&showConnection, "show-connection", false, "Print the host, port and user of the internal DB once it is ready")
 	DeployCmd.Flags().StringVar(&ModelsInput, "models", "", "Models input (if not interactive)")
 	DeployCmd.Flags().StringVar(&RelationsInput, "relations", "", "Relations input (if not interactive)")
 	DeployCmd.Flags().StringVar(&DatasourcesInput, "datasources", "", "Datasources input (if not interactive)")
+	DeployCmd.Flags().StringArrayVar(&datasourceInputs, "datasource", nil, "Additional MySQL datasource with its own secret and restcrud, name:{'host':'...','port':'3306','user':'...','password':'...','database':'...','models':'Order,Item','discover':false} (repeatable)")
+	DeployCmd.Flags().StringVar(&datasourcesFile, "datasources-file", "", "YAML list of additional datasources, each with the name and properties of --datasource")
 	DeployCmd.Flags().StringVar(&DiscoveriesInput, "discoveries", "", "Discoveries input (if not interactive)")
 	DeployCmd.Flags().StringVar(&DatabaseSchema, "database-schema", "", "Database schema")
 	DeployCmd.Flags().BoolVar(&AutoDiscovery, "auto-discovery", false, "Auto discovery flag")
 	DeployCmd.Flags().StringVar(&SourceData, "source-data", "", "Data source URL")
 	DeployCmd.Flags().BoolVar(&EnableGRUIM, "enable-gruim", false, "Enables GRUIM")
+	DeployCmd.Flags().StringVar(&GruimsInput, "gruims", "", "Additional GRUIMs over the same grapi, e.g. \"admin:{}|shop:{'config':'...'}\"")
 	DeployCmd.Flags().StringVar(&DBFilePath, "db-file-path", "", "Path to DB file")
-	DeployCmd.Flags().StringVar(&KubeContext, "kube-context", "", "Kubernetes context to use")
 	DeployCmd.Flags().StringVar(&KubeNS, "namespace", "", "Kubernetes namespace to use")
+	DeployCmd.Flags().StringToStringVar(&Labels, "labels", map[string]string{}, "Labels to add to all generated resources (e.g: --labels=team=platform,cost-center=1234)")
+	DeployCmd.Flags().StringToStringVar(&Annotations, "annotations", map[string]string{}, "Annotations to add to all generated resources (e.g: --annotations=owner=platform)")
+	DeployCmd.Flags().StringVar(&GitURL, "git", "", "Git repository containing the GRAS spec or answers file")
+	DeployCmd.Flags().StringVar(&GitRef, "git-ref", "", "Branch, tag or commit of the Git repository (default: default branch)")
+	DeployCmd.Flags().StringVar(&GitPath, "git-path", ".", "Path of the spec file, or of a directory containing gras.yaml or answers.yaml, inside the Git repository")
+	DeployCmd.Flags().StringVar(&githubToken, "github-token", "", "GitHub token for private repositories (default: $GITHUB_TOKEN)")
+	DeployCmd.Flags().BoolVar(&cleanupOnFailure, "cleanup-on-failure", false, "Remove the objects created by this run (namespace, secrets, internal DB, helm release) if the deployment fails, without asking")
+	DeployCmd.Flags().BoolVar(&verifyDB, "verify-db", true, "Check that the external datasource accepts the credentials before deploying")
+	DeployCmd.Flags().StringVar(&dbSecretStore, "db-secret-store", "", "Take the external DB credentials from this External Secrets Operator store instead of --datasources")
+	DeployCmd.Flags().StringVar(&dbSecretStoreKind, "db-secret-store-kind", "ClusterSecretStore", "Kind of --db-secret-store (SecretStore or ClusterSecretStore)")
+	DeployCmd.Flags().StringVar(&dbSecretKey, "db-secret-key", "", "Key of the remote secret with the host, port, username and password properties (default: <gras-name>-db)")
+	DeployCmd.Flags().BoolVar(&utils.SkipPreflight, "skip-preflight", false, "Skip the preflight checks of the cluster, see 'grapple preflight --deploy'")
+	DeployCmd.Flags().BoolVar(&forceDeploy, "force", false, "Redeploy the GRAS even if the deployed release has the same chart version and values")
+	DeployCmd.Flags().IntVar(&SeedSampleData, "seed-sample-data", 0, "After the deploy, create this many sample records per model through the grapi REST endpoints")
+	DeployCmd.Flags().StringArrayVar(&setFiles, "set-file", nil, "Set a value from a file, stored in the <gras-name>-files secret and referenced as $(key), e.g. grapi.env.GOOGLE_CREDS=creds.json (repeatable)")
 }
 
 var (
@@ -76,7 +132,7 @@ var (
 )
 
 // runDeploy is the main function for the deploy command.
-func runDeploy(cmd *cobra.Command, args []string) error {
+func runDeploy(cmd *cobra.Command, args []string) (deployErr error) {
 
 	var err error
 	utils.InfoMessage("Getting Kubernetes config...")
@@ -104,6 +160,44 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 
 	logOnCliAndFileStart()
 
+	// Rendering doesn't touch the cluster, it needs no preflight
+	if !isRender {
+		if err := utils.Preflight(clientset, restConfig, utils.PreflightOptions{}); err != nil {
+			return err
+		}
+	}
+
+	createdObjects = nil
+	defer func() {
+		if deployErr != nil {
+			logOnCliAndFileStart()
+			cleanupAfterFailure(deployErr)
+		}
+	}()
+
+	utils.SetCommonMetadata(Labels, Annotations)
+
+	if importFile != "" {
+		tmpl, err := loadManifestFile(cmd, importFile)
+		if err != nil {
+			return err
+		}
+		return deployManifestTemplate(tmpl, logOnFileStart, logOnCliAndFileStart)
+	}
+	if GitURL != "" {
+		gitTmpl, err := loadSpecFromGit(cmd)
+		if err != nil {
+			return err
+		}
+		if gitTmpl != nil {
+			return deployManifestTemplate(gitTmpl, logOnFileStart, logOnCliAndFileStart)
+		}
+	}
+
+	if err := validateSpecInputs(); err != nil {
+		return err
+	}
+
 	// Validate and get GRAS name
 	if GRASName != "" {
 		if err := utils.ValidateResourceName(GRASName); err != nil {
@@ -115,38 +209,85 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 
 	utils.InfoMessage(fmt.Sprintf("gras name: %s", GRASName))
 
-	// Check if GRAS_TEMPLATE is provided and validate it
-	if GRASTemplate != "" {
-		if err := utils.ValidateGrasTemplates(GRASTemplate); err != nil {
-			return err
-		}
-	} else {
-		// Prompt user to select template if not provided
-		templates := utils.GrasTemplates
-		prompt := promptui.Select{
-			Label: "Please select template you want to create",
-			Items: templates,
-		}
-		_, result, err := prompt.Run()
+	// Prompt user to select template if not provided, the template plugins are offered next to the built-in ones
+	if GRASTemplate == "" {
+		result, err := prompt.Select(prompt.Question{
+			Label:   "Please select template you want to create",
+			Flag:    "--gras-template",
+			History: "gras-template",
+		}, templateNames())
 		if err != nil {
 			return err
 		}
 		GRASTemplate = result
 	}
+	templatePlugin, err = findTemplatePlugin(GRASTemplate)
+	if err != nil {
+		return err
+	}
+	if templatePlugin != nil {
+		GRASTemplate = templatePlugin.Name
+		if DBType == "" {
+			DBType = templatePlugin.DBType
+		}
+	} else if err := utils.ValidateGrasTemplates(GRASTemplate); err != nil {
+		return fmt.Errorf("%w (see 'grapple resource templates list')", err)
+	}
 
 	utils.InfoMessage(fmt.Sprintf("gras template: %s", GRASTemplate))
 
+	extraDatasources, err = parseExtraDatasources()
+	if err != nil {
+		return err
+	}
+	if len(extraDatasources) > 0 && GRASTemplate != utils.DB_MYSQL_MODEL_BASED && GRASTemplate != utils.DB_MYSQL_DISCOVERY_BASED {
+		return fmt.Errorf("%w: --datasource and --datasources-file need the %s or %s template", utils.ErrValidation, utils.DB_MYSQL_MODEL_BASED, utils.DB_MYSQL_DISCOVERY_BASED)
+	}
+
 	err = prepareNamespaceForGrasInstallation()
 	if err != nil {
 		return err
 	}
 
-	// 3. Copy a base template file to our working file.
-	if err := prepareTemplateFile(); err != nil {
+	defaults, err := loadDeployDefaults(KubeNS)
+	if err != nil {
+		return err
+	}
+	if err := defaults.applyToInputs(); err != nil {
+		return err
+	}
+	if err := validateInternalDBInputs(cmd); err != nil {
 		return err
 	}
 
-	if (GRASTemplate == utils.DB_MYSQL_MODEL_BASED || GRASTemplate == utils.DB_MYSQL_DISCOVERY_BASED) && DBType == utils.DB_EXTERNAL {
+	// 3. Load the base template, every step below mutates it in memory and it is written once at the end.
+	grasTmpl, err := prepareTemplateFile()
+	if err != nil {
+		return err
+	}
+	if templatePlugin != nil {
+		answers, err := askTemplatePluginValues(templatePlugin)
+		if err != nil {
+			return err
+		}
+		if err := applyTemplatePluginValues(templatePlugin, answers, grasTmpl); err != nil {
+			return err
+		}
+	}
+
+	if (GRASTemplate == utils.DB_MYSQL_MODEL_BASED || GRASTemplate == utils.DB_MYSQL_DISCOVERY_BASED) && DBType == utils.DB_EXTERNAL && dbSecretStore != "" {
+		// The credentials never pass through the CLI, the operator syncs them into the credential secret
+		if DatabaseSchema == "" {
+			DatabaseSchema, err = utils.PromptInput("Enter database schema name", utils.DefaultValue, utils.EmptyValueRegex)
+			if err != nil {
+				return err
+			}
+		}
+		if err := createDBExternalSecret(); err != nil {
+			return err
+		}
+
+	} else if (GRASTemplate == utils.DB_MYSQL_MODEL_BASED || GRASTemplate == utils.DB_MYSQL_DISCOVERY_BASED) && DBType == utils.DB_EXTERNAL {
 		var database, host, port, user, password, url string
 
 		utils.InfoMessage("Updating resource for with datasource info")
@@ -167,36 +308,27 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		DatabaseSchema = database
 		URL = url
 
-		// Create secret for external DB credentials
-		utils.InfoMessage("Creating external db secret using collected datasource info...")
-
-		// Create new secret
-		newSecret := &corev1.Secret{
-			ObjectMeta: v1.ObjectMeta{
-				Name:      fmt.Sprintf("%s-conn-credential", GRASName),
-				Namespace: KubeNS,
-			},
-			Data: map[string][]byte{
-				"host":     []byte(host),
-				"port":     []byte(port),
-				"username": []byte(user),
-				"password": []byte(password),
-			},
-		}
-
-		_, err = clientset.CoreV1().Secrets(KubeNS).Create(context.TODO(), newSecret, v1.CreateOptions{})
-		if k8serrors.IsAlreadyExists(err) {
-			_, err = clientset.CoreV1().Secrets(KubeNS).Update(context.TODO(), newSecret, v1.UpdateOptions{})
-			if err != nil {
-				utils.ErrorMessage("Failed to update external db secret: " + err.Error())
+		if verifyDB {
+			utils.InfoMessage("Verifying the datasource connection...")
+			conn := utils.MySQLConnection{
+				Host:     host,
+				Port:     port,
+				User:     user,
+				Password: password,
+				Database: database,
+				// Without source data nothing creates the schema, discovery needs it to exist already
+				RequireDatabase: GRASTemplate == utils.DB_MYSQL_DISCOVERY_BASED && SourceData == "",
+			}
+			if err := utils.VerifyMySQLConnection(clientset, KubeNS, conn); err != nil {
+				utils.ErrorMessage(err.Error() + " (use --verify-db=false to skip this check)")
 				return err
 			}
+			utils.SuccessMessage("Datasource connection verified")
 		}
-		if err != nil {
-			utils.ErrorMessage("Failed to create external db secret: " + err.Error())
+
+		if err := createExternalDBSecret(host, port, user, password, url); err != nil {
 			return err
 		}
-		utils.SuccessMessage("Created external db secret")
 
 	} else if GRASTemplate == utils.DB_FILE {
 		utils.InfoMessage("Taking DB file path...")
@@ -204,10 +336,13 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		utils.InfoMessage("Updating resource for with datasource info")
-		if err := updateTemplateForDataSourceIncaseOfDbFile(); err != nil {
+		if err := updateTemplateForDataSourceIncaseOfDbFile(grasTmpl); err != nil {
 			return err
 		}
 	}
+	if err := prepareExtraDatasources(); err != nil {
+		return err
+	}
 
 	// 4. Process inputs – if models/datasources/discoveries/relations were passed via CLI, transform them.
 	// Otherwise, invoke interactive functions.
@@ -215,12 +350,12 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		utils.InfoMessage("Updating resource with models info")
 		if ModelsInput != "" {
 			utils.InfoMessage("Transforming models input to YAML...")
-			if err := transformModelInputToYAML(ModelsInput, templateFileDest); err != nil {
+			if err := transformModelInputToYAML(ModelsInput, grasTmpl); err != nil {
 				return err
 			}
 		} else {
 			utils.InfoMessage("Taking models input from CLI...")
-			if err := takeModelInputFromCLI(templateFileDest); err != nil {
+			if err := takeModelInputFromCLI(grasTmpl); err != nil {
 				return err
 			}
 		}
@@ -230,12 +365,12 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		utils.InfoMessage("Updating resource with discoveries info")
 		if DiscoveriesInput != "" {
 			utils.InfoMessage("Transforming discoveries input to YAML...")
-			if err := transformDiscoveriesInputToYAML(DiscoveriesInput, templateFileDest); err != nil {
+			if err := transformDiscoveriesInputToYAML(DiscoveriesInput, grasTmpl); err != nil {
 				return err
 			}
 		} else {
 			utils.InfoMessage("Taking discoveries input from CLI...")
-			if err := takeDiscoveryInputFromCLI(templateFileDest, cmd.Flags().Changed("auto-discovery")); err != nil {
+			if err := takeDiscoveryInputFromCLI(grasTmpl, cmd.Flags().Changed("auto-discovery")); err != nil {
 				return err
 			}
 		}
@@ -248,49 +383,62 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		utils.InfoMessage("Internal DB created")
-		if err := updateTemplateForInternalDB(); err != nil {
+		if err := updateTemplateForInternalDB(grasTmpl); err != nil {
 			return err
 		}
 	} else if DBType == utils.DB_EXTERNAL {
 		utils.InfoMessage("Updating resource for external DB info")
-		if err := updateTemplateForExternalDB(); err != nil {
+		if err := updateTemplateForExternalDB(grasTmpl); err != nil {
 			return err
 		}
 	}
+	if err := updateTemplateForExtraDatasources(grasTmpl); err != nil {
+		return err
+	}
 
 	if RelationsInput != "" {
 		utils.InfoMessage("Updating resource with relations info")
 		utils.InfoMessage("Transforming relations input to YAML...")
-		if err := transformRelationInputToYAML(RelationsInput, templateFileDest); err != nil {
+		if err := transformRelationInputToYAML(RelationsInput, grasTmpl); err != nil {
 			return err
 		}
 	} else if !cmd.Flags().Changed("relations") {
 		utils.InfoMessage("Taking relations input from CLI...")
-		if err := takeRelationInputFromCLI(templateFileDest); err != nil {
+		if err := takeRelationInputFromCLI(grasTmpl); err != nil {
 			return err
 		}
 	}
 
 	// 5. Ask for GRUIM enablement (interactive or by flag)
 	utils.InfoMessage("Asking for GRUIM enablement...")
-	if err := askGRUIMEnablement(templateFileDest, cmd.Flags().Changed("enable-gruim")); err != nil {
+	if err := askGRUIMEnablement(grasTmpl, cmd.Flags().Changed("enable-gruim")); err != nil {
+		return err
+	}
+	if err := takeAdditionalGruims(grasTmpl); err != nil {
 		return err
 	}
 
 	// Handle database schema and init containers
 	utils.InfoMessage("Updating resource for init containers")
-	if err := updateTemplateForInitContainers(cmd.Flags().Changed("source-data")); err != nil {
+	if err := updateTemplateForInitContainers(grasTmpl, cmd.Flags().Changed("source-data")); err != nil {
 		return err
 	}
 
 	utils.InfoMessage("Updating resource for restcruds")
-	if err := updateTemplateForRestcruds(); err != nil {
+	if err := updateTemplateForRestcruds(grasTmpl); err != nil {
 		return err
 	}
 
-	// 7. Substitute environment variables in the template (using os.ExpandEnv).
+	if err := defaults.applyToTemplate(grasTmpl); err != nil {
+		return err
+	}
+	if err := applySetFiles(grasTmpl); err != nil {
+		return err
+	}
+
+	// 7. Substitute environment variables in the template (using os.ExpandEnv) and write it out.
 	utils.InfoMessage("Substituting environment variables in the template...")
-	if err := substituteEnvVarsInTemplate(templateFileDest); err != nil {
+	if err := substituteEnvVarsInTemplate(grasTmpl, templateFileDest); err != nil {
 		return err
 	}
 
@@ -298,11 +446,26 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		// 8. Finally, deploy the template using the Helm Go SDK.
 		utils.InfoMessage("Deploying the template using the Helm")
 		logOnFileStart()
-		if err := deployTemplate(templateFileDest, GRASName, KubeNS); err != nil {
-			logOnCliAndFileStart()
+		upToDate, err := deployTemplate(templateFileDest, GRASName, KubeNS)
+		logOnCliAndFileStart()
+		if err != nil {
 			return err
 		}
-		logOnCliAndFileStart()
+		if upToDate {
+			utils.SuccessMessage(fmt.Sprintf("GRAS %s is up to date in namespace %s, nothing to deploy (use --force to redeploy it)", GRASName, KubeNS))
+			return nil
+		}
+		if err := deployAdditionalGruims(grasTmpl); err != nil {
+			return err
+		}
+
+		if SeedSampleData > 0 {
+			utils.InfoMessage(fmt.Sprintf("Seeding %d sample records per model...", SeedSampleData))
+			if err := seedSampleData(grasTmpl, SeedSampleData); err != nil {
+				// The resource is deployed, sample data is a convenience
+				utils.ErrorMessage("Failed to seed sample data: " + err.Error())
+			}
+		}
 
 		// 9. Optionally, clean up the temporary file.
 		// _ = os.Remove(templateFileDest)
@@ -312,75 +475,59 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func prepareTemplateFile() error {
-	templateDir, err := utils.GetResourcePath("template-files")
-	if err != nil {
-		return err
+func prepareTemplateFile() (*GrasTemplate, error) {
+	if templatePlugin != nil {
+		return loadGrasTemplate(templatePlugin.basePath())
 	}
-
-	var src string
+	name := "db.yaml"
 	if GRASTemplate == utils.DB_FILE {
-		src = filepath.Join(templateDir, "db-file.yaml")
-	} else {
-		src = filepath.Join(templateDir, "db.yaml")
+		name = "db-file.yaml"
 	}
-
-	data, err := os.ReadFile(src)
+	src, err := utils.ResolveResourceFile("template-files", name)
 	if err != nil {
-		return fmt.Errorf("failed to read template file %s: %v", src, err)
+		return nil, err
 	}
-	return os.WriteFile(templateFileDest, data, 0644)
+
+	return loadGrasTemplate(src)
 }
 
 //
-// Functions to transform the YAML template – these functions load the YAML into a map,
-// update the relevant sections (such as grapi.models, grapi.datasources, etc.), and then write it back.
+// Functions to transform the template – these functions update the relevant sections
+// (such as grapi.models, grapi.datasources, etc.) of the in-memory GrasTemplate.
 //
 
-func transformModelInputToYAML(models string, tmplFile string) error {
-	parts := strings.Split(models, "|")
-	data, err := os.ReadFile(tmplFile)
-	if err != nil {
-		return err
-	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
-	}
-	grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-	if !ok {
-		grapi = make(map[interface{}]interface{})
-		tmpl["grapi"] = grapi
+// parseNamedSpecs parses the "name:{json}|name:{json}" format used by the --models, --relations
+// and --discoveries flags, flag names the flag in the error of an entry that doesn't parse
+func parseNamedSpecs(input string, flag string) ([]NamedSpec, error) {
+	var specs []NamedSpec
+	if strings.TrimSpace(input) == "" {
+		return specs, nil
 	}
-	var modelsList []interface{}
-	if ml, ok := grapi["models"].([]interface{}); ok {
-		modelsList = ml
-	}
-	for _, part := range parts {
+	for i, part := range strings.Split(input, "|") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
 		part = strings.ReplaceAll(part, "'", "\"") // replace single quotes with double quotes
 		subParts := strings.SplitN(part, ":", 2)
 		if len(subParts) != 2 {
-			continue
+			return nil, fmt.Errorf("invalid --%s entry %d %q: expected name:{json}", flag, i+1, part)
 		}
-		modelName := subParts[0]
-		propertiesJSON := subParts[1]
 		var props map[string]interface{}
-		if err := json.Unmarshal([]byte(propertiesJSON), &props); err != nil {
-			log.Printf("failed to unmarshal model properties: %v", err)
-			continue
+		if err := json.Unmarshal([]byte(subParts[1]), &props); err != nil {
+			return nil, fmt.Errorf("invalid --%s entry %d (%s): %v%s", flag, i+1, subParts[0], err, jsonErrorPosition(err))
 		}
-		modelEntry := map[string]interface{}{
-			"name": modelName,
-			"spec": props,
-		}
-		modelsList = append(modelsList, modelEntry)
+		specs = append(specs, NamedSpec{Name: strings.TrimSpace(subParts[0]), Spec: props})
 	}
-	grapi["models"] = modelsList
-	newData, err := yaml.Marshal(tmpl)
+	return specs, nil
+}
+
+func transformModelInputToYAML(models string, tmpl *GrasTemplate) error {
+	specs, err := parseNamedSpecs(models, "models")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(tmplFile, newData, 0644)
+	tmpl.Grapi.Models = append(tmpl.Grapi.Models, specs...)
+	return nil
 }
 
 func extractDatasourceInfo(ds string) (string, string, string, string, string, string, error) {
@@ -424,239 +571,28 @@ func extractDatasourceInfo(ds string) (string, string, string, string, string, s
 	return database, host, port, user, password, url, nil
 }
 
-func transformDiscoveriesInputToYAML(discoveries string, tmplFile string) error {
-	parts := strings.Split(discoveries, "|")
-	data, err := os.ReadFile(tmplFile)
+func transformDiscoveriesInputToYAML(discoveries string, tmpl *GrasTemplate) error {
+	specs, err := parseNamedSpecs(discoveries, "discoveries")
 	if err != nil {
 		return err
 	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
-	}
-	grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-	if !ok {
-		grapi = make(map[interface{}]interface{})
-		tmpl["grapi"] = grapi
-	}
-	var discList []interface{}
-	if dl, ok := grapi["discoveries"].([]interface{}); ok {
-		discList = dl
-	}
-	for _, part := range parts {
-		part = strings.ReplaceAll(part, "'", "\"")
-		subParts := strings.SplitN(part, ":", 2)
-		if len(subParts) != 2 {
-			continue
-		}
-		discoveryName := subParts[0]
-		propsJSON := subParts[1]
-		var props map[string]interface{}
-		if err := json.Unmarshal([]byte(propsJSON), &props); err != nil {
-			log.Printf("failed to unmarshal discovery properties: %v", err)
-			continue
-		}
-		discEntry := map[string]interface{}{
-			"name": discoveryName,
-			"spec": props,
-		}
-		discList = append(discList, discEntry)
-	}
-	grapi["discoveries"] = discList
-	newData, err := yaml.Marshal(tmpl)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(tmplFile, newData, 0644)
+	tmpl.Grapi.Discoveries = append(tmpl.Grapi.Discoveries, specs...)
+	return nil
 }
 
-func transformRelationInputToYAML(relations string, tmplFile string) error {
-	parts := strings.Split(relations, "|")
-	data, err := os.ReadFile(tmplFile)
+func transformRelationInputToYAML(relations string, tmpl *GrasTemplate) error {
+	specs, err := parseNamedSpecs(relations, "relations")
 	if err != nil {
 		return err
 	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
-	}
-	grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-	if !ok {
-		grapi = make(map[interface{}]interface{})
-		tmpl["grapi"] = grapi
-	}
-	var relList []interface{}
-	if rl, ok := grapi["relations"].([]interface{}); ok {
-		relList = rl
-	}
-	for _, part := range parts {
-		part = strings.ReplaceAll(part, "'", "\"")
-		subParts := strings.SplitN(part, ":", 2)
-		if len(subParts) != 2 {
-			continue
-		}
-		relationName := subParts[0]
-		propsJSON := subParts[1]
-		var props map[string]interface{}
-		if err := json.Unmarshal([]byte(propsJSON), &props); err != nil {
-			log.Printf("failed to unmarshal relation properties: %v", err)
-			continue
-		}
-		relEntry := map[string]interface{}{
-			"name": relationName,
-			"spec": props,
-		}
-		relList = append(relList, relEntry)
-	}
-	grapi["relations"] = relList
-	newData, err := yaml.Marshal(tmpl)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(tmplFile, newData, 0644)
+	tmpl.Grapi.Relations = append(tmpl.Grapi.Relations, specs...)
+	return nil
 }
 
 //
-// Interactive input functions using promptui.
+// Interactive input functions using the prompt package.
 //
 
-func takeModelInputFromCLI(tmplFile string) error {
-	for {
-		// Prompt for model name
-		modelName, err := utils.PromptInput("Enter model name (or leave empty to finish)", utils.DefaultValue, utils.EmptyValueRegex)
-		if err != nil {
-			return err
-		}
-		modelName = strings.TrimSpace(modelName)
-		if modelName == "" {
-			return nil // Exit cleanly if empty model name
-		}
-
-		// Prompt for base class
-		baseClass, err := utils.PromptSelect("Select model base class", []string{"Entity", "Model"})
-		if err != nil {
-			return err
-		}
-
-		// Prompt for properties
-		properties := make(map[string]interface{})
-		idSelected := false
-		for {
-			promptMsg := ""
-			if len(properties) == 0 {
-				promptMsg = "Enter property name (at least one property is required)"
-			} else {
-				promptMsg = "Enter property name (or leave empty to finish)"
-			}
-
-			propName, err := utils.PromptInput(promptMsg, utils.DefaultValue, utils.EmptyValueRegex)
-			if err != nil {
-				return err
-			}
-			propName = strings.TrimSpace(propName)
-			if propName == "" && len(properties) == 0 {
-				utils.InfoMessage("At least one property is required")
-				continue
-			}
-			if propName == "" {
-				break // Exit property loop if empty property name and we have properties
-			}
-
-			propType, err := utils.PromptSelect("Select property type", []string{
-				"string", "integer", "boolean", "float", "array", "object", "date", "buffer", "geopoint", "any",
-			})
-			if err != nil {
-				return err
-			}
-
-			propSpec := map[string]interface{}{
-				"type": propType,
-			}
-
-			// Handle ID field logic
-			if !idSelected {
-				isID, err := utils.PromptConfirm(fmt.Sprintf("Is %s the ID property?", propName))
-				if err != nil {
-					return err
-				}
-
-				if isID {
-					isGenerated, err := utils.PromptConfirm(fmt.Sprintf("Is %s generated automatically?", propName))
-					if err != nil {
-						return err
-					}
-
-					propSpec["id"] = true
-					propSpec["required"] = true
-					if isGenerated {
-						propSpec["generated"] = true
-					}
-					idSelected = true
-					properties[propName] = propSpec
-					continue
-				}
-			}
-
-			// Handle required/default value logic for non-ID fields
-			required, err := utils.PromptConfirm("Is this property required?")
-			if err != nil {
-				return err
-			}
-
-			if required {
-				propSpec["required"] = true
-			} else {
-				defaultValue, err := utils.PromptInput("Default value [leave blank for none]", utils.DefaultValue, utils.EmptyValueRegex)
-				if err != nil {
-					return err
-				}
-				if defaultValue != "" {
-					propSpec["defaultFn"] = defaultValue
-				}
-			}
-
-			properties[propName] = propSpec
-		}
-
-		// Update YAML template
-		data, err := os.ReadFile(tmplFile)
-		if err != nil {
-			return err
-		}
-		var tmpl map[string]interface{}
-		if err := yaml.Unmarshal(data, &tmpl); err != nil {
-			return err
-		}
-		grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-		if !ok {
-			grapi = make(map[interface{}]interface{})
-			tmpl["grapi"] = grapi
-		}
-		var modelsList []interface{}
-		if ml, ok := grapi["models"].([]interface{}); ok {
-			modelsList = ml
-		}
-
-		modelEntry := map[string]interface{}{
-			"name": modelName,
-			"spec": map[string]interface{}{
-				"base":       baseClass,
-				"properties": properties,
-			},
-		}
-		modelsList = append(modelsList, modelEntry)
-		grapi["models"] = modelsList
-
-		newData, err := yaml.Marshal(tmpl)
-		if err != nil {
-			return err
-		}
-		if err := os.WriteFile(tmplFile, newData, 0644); err != nil {
-			return err
-		}
-	}
-}
-
 func takeDatasourceInputFromCLI() (string, string, string, string, string, string, error) {
 	dsName, err := utils.PromptInput("Enter datasource name", utils.DefaultValue, utils.EmptyValueRegex)
 	if err != nil {
@@ -691,7 +627,7 @@ func takeDatasourceInputFromCLI() (string, string, string, string, string, strin
 	return dsName, host, port, user, password, url, nil
 }
 
-func takeDiscoveryInputFromCLI(tmplFile string, checkAutoDiscovery bool) error {
+func takeDiscoveryInputFromCLI(tmpl *GrasTemplate, checkAutoDiscovery bool) error {
 	var auto bool
 	if AutoDiscovery || checkAutoDiscovery {
 		auto = AutoDiscovery
@@ -702,24 +638,11 @@ func takeDiscoveryInputFromCLI(tmplFile string, checkAutoDiscovery bool) error {
 		}
 		auto = (choice == "Yes")
 	}
-	data, err := os.ReadFile(tmplFile)
-	if err != nil {
-		return err
-	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
-	}
-	grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-	if !ok {
-		grapi = make(map[interface{}]interface{})
-		tmpl["grapi"] = grapi
-	}
-	var discEntry map[string]interface{}
+	var discEntry NamedSpec
 	if auto {
-		discEntry = map[string]interface{}{
-			"name": DatabaseSchema,
-			"spec": map[string]interface{}{
+		discEntry = NamedSpec{
+			Name: DatabaseSchema,
+			Spec: map[string]interface{}{
 				"all":              true,
 				"disableCamelCase": false,
 				"schema":           DatabaseSchema,
@@ -743,10 +666,7 @@ func takeDiscoveryInputFromCLI(tmplFile string, checkAutoDiscovery bool) error {
 		if allModels == "Yes" {
 			all = true
 		} else {
-			prompt := promptui.Prompt{
-				Label: "Enter models (optional) e.g: table1,table2",
-			}
-			models, err = prompt.Run()
+			models, err = prompt.Input(prompt.Question{Label: "Enter models (optional) e.g: table1,table2"})
 			if err != nil {
 				return err
 			}
@@ -761,10 +681,7 @@ func takeDiscoveryInputFromCLI(tmplFile string, checkAutoDiscovery bool) error {
 		isOptionalId := optionalId == "Yes"
 		utils.InfoMessage(fmt.Sprintf("optionalId: %v", isOptionalId))
 
-		prompt := promptui.Prompt{
-			Label: "Enter outDir (optional)",
-		}
-		outDir, err := prompt.Run()
+		outDir, err := prompt.Input(prompt.Question{Label: "Enter outDir (optional)"})
 		if err != nil {
 			return err
 		}
@@ -784,9 +701,9 @@ func takeDiscoveryInputFromCLI(tmplFile string, checkAutoDiscovery bool) error {
 		hasViews := views == "Yes"
 		utils.InfoMessage(fmt.Sprintf("views: %v", hasViews))
 
-		discEntry = map[string]interface{}{
-			"name": discoveryName,
-			"spec": map[string]interface{}{
+		discEntry = NamedSpec{
+			Name: discoveryName,
+			Spec: map[string]interface{}{
 				"all":              all,
 				"views":            hasViews,
 				"relations":        hasRelations,
@@ -799,87 +716,53 @@ func takeDiscoveryInputFromCLI(tmplFile string, checkAutoDiscovery bool) error {
 			},
 		}
 	}
-	grapi["discoveries"] = []interface{}{discEntry}
-	newData, err := yaml.Marshal(tmpl)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(tmplFile, newData, 0644)
+	tmpl.Grapi.Discoveries = []NamedSpec{discEntry}
+	return nil
 }
 
-func takeRelationInputFromCLI(tmplFile string) error {
-	prompt := promptui.Prompt{
-		Label: "Enter relation name",
-	}
-	relName, err := prompt.Run()
+func takeRelationInputFromCLI(tmpl *GrasTemplate) error {
+	relName, err := prompt.Input(prompt.Question{Label: "Enter relation name"})
 	if err != nil {
 		return err
 	}
-	selectPrompt := promptui.Select{
-		Label: "Select relation type",
-		Items: []string{"belongsTo", "hasMany", "hasOne", "referencesMany"},
-	}
-	_, relType, err := selectPrompt.Run()
+	relType, err := prompt.Select(prompt.Question{Label: "Select relation type"}, relationTypes)
 	if err != nil {
 		return err
 	}
-	prompt = promptui.Prompt{
-		Label: "Enter source model",
-	}
-	sourceModel, err := prompt.Run()
+	sourceModel, err := prompt.Input(prompt.Question{Label: "Enter source model"})
 	if err != nil {
 		return err
 	}
-	prompt = promptui.Prompt{
-		Label: "Enter target model",
-	}
-	targetModel, err := prompt.Run()
+	targetModel, err := prompt.Input(prompt.Question{Label: "Enter target model"})
 	if err != nil {
 		return err
 	}
-	prompt = promptui.Prompt{
-		Label: "Enter foreign key name",
-	}
-	foreignKey, err := prompt.Run()
+	foreignKey, err := prompt.Input(prompt.Question{Label: "Enter foreign key name"})
 	if err != nil {
 		return err
 	}
-	data, err := os.ReadFile(tmplFile)
-	if err != nil {
-		return err
-	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
-	}
-	grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-	if !ok {
-		grapi = make(map[interface{}]interface{})
-		tmpl["grapi"] = grapi
-	}
-	relEntry := map[string]interface{}{
-		"name": relName,
-		"spec": map[string]interface{}{
-			"relationType":     relType,
-			"relationName":     relName,
-			"sourceModel":      sourceModel,
-			"destinationModel": targetModel,
-			"foreignKeyName":   foreignKey,
+	tmpl.Grapi.Relations = []NamedSpec{
+		{
+			Name: relName,
+			Spec: map[string]interface{}{
+				"relationType":     relType,
+				"relationName":     relName,
+				"sourceModel":      sourceModel,
+				"destinationModel": targetModel,
+				"foreignKeyName":   foreignKey,
+			},
 		},
 	}
-	grapi["relations"] = []interface{}{relEntry}
-	newData, err := yaml.Marshal(tmpl)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(tmplFile, newData, 0644)
+	return nil
 }
 
 // askGRUIMEnablement prompts whether to enable GRUIM and, if not, removes the "gruims" section from the template.
-func askGRUIMEnablement(tmplFile string, isFlagSet bool) error {
+func askGRUIMEnablement(tmpl *GrasTemplate, isFlagSet bool) error {
 	var enable bool
 	if EnableGRUIM || isFlagSet {
 		enable = EnableGRUIM
+	} else if GruimsInput != "" {
+		enable = true
 	} else {
 		choice, err := utils.PromptSelect("Do you want to enable GRUIM?", []string{"Yes", "No"})
 		if err != nil {
@@ -888,25 +771,16 @@ func askGRUIMEnablement(tmplFile string, isFlagSet bool) error {
 		enable = (choice == "Yes")
 	}
 
-	data, err := os.ReadFile(tmplFile)
-	if err != nil {
-		return err
-	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
+	if !enable && GruimsInput != "" {
+		return fmt.Errorf("%w: --gruims needs GRUIM to be enabled", utils.ErrValidation)
 	}
 	if !enable {
 		utils.InfoMessage("Disabling GRUIM...")
-		delete(tmpl, "gruim")
+		tmpl.Gruim = nil
 	} else {
 		utils.InfoMessage("Enabling GRUIM...")
 	}
-	newData, err := yaml.Marshal(tmpl)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(tmplFile, newData, 0644)
+	return nil
 }
 
 // takeDBFilePath prompts the user for a file path for the DB file.
@@ -915,12 +789,12 @@ func takeDBFilePath() error {
 	if DBFilePath != "" {
 		path = DBFilePath
 	} else {
-		prompt := promptui.Prompt{
+		var err error
+		path, err = prompt.Input(prompt.Question{
 			Label:   "Enter DB file path",
 			Default: "/tmp/data.json",
-		}
-		var err error
-		path, err = prompt.Run()
+			Flag:    "--db-file-path",
+		})
 		if err != nil {
 			return err
 		}
@@ -933,38 +807,27 @@ func takeDBFilePath() error {
 	return nil
 }
 
-func updateTemplateForInitContainers(sourceDataExplicitlySet bool) error {
-
-	// Handle init containers based on source data
-	data, err := os.ReadFile(templateFileDest)
-	if err != nil {
-		return err
-	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
-	}
-	grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-	if !ok {
-		grapi = make(map[interface{}]interface{})
-		tmpl["grapi"] = grapi
-	}
+func updateTemplateForInitContainers(tmpl *GrasTemplate, sourceDataExplicitlySet bool) error {
 
 	if GRASTemplate == utils.DB_MYSQL_MODEL_BASED || GRASTemplate == utils.DB_MYSQL_DISCOVERY_BASED {
 		// Prompt for source data if not provided
 		if SourceData == "" && !sourceDataExplicitlySet {
-			prompt := promptui.Prompt{
-				Label:     "Enter source data",
-				Default:   "",
-				AllowEdit: true,
-			}
-			sourceData, err := prompt.Run()
+			sourceData, err := prompt.Input(prompt.Question{
+				Label:   "Enter source data",
+				Flag:    "--source-data",
+				History: "source-data",
+			})
 			if err != nil {
 				return err
 			}
 			SourceData = sourceData
 		}
 
+		if containers, ok := internalDBInitContainers(); ok {
+			tmpl.Grapi.InitContainers = containers
+			return nil
+		}
+
 		var initScript string
 		if SourceData == "" {
 			// Basic init container that just creates database
@@ -974,12 +837,12 @@ func updateTemplateForInitContainers(sourceDataExplicitlySet bool) error {
 			initScript = fmt.Sprintf("sleep 5; while ! mysql -h $(host) -P $(port) -u $(username) -p$(password) -e \"show databases;\" 2>/dev/null; do echo -n .; sleep 2; done; if mysql -h $(host) -P $(port) -u $(username) -p$(password) -e \"USE %s; SET @tablename := (select table_name from information_schema.tables where table_type = 'BASE TABLE' and table_schema = '%s' limit 1); set @qry1:= concat('select * from ',@tablename,' limit 1'); prepare stmt from @qry1 ; execute stmt ;\" ; then echo \"database already exists...\"; else curl -o /tmp/%s.sql %s; mysql -h $(host) -P $(port) -u $(username) -p$(password) < /tmp/%s.sql; fi;", DatabaseSchema, DatabaseSchema, DatabaseSchema, SourceData, DatabaseSchema)
 		}
 
-		grapi["initContainers"] = []interface{}{
-			map[string]interface{}{
-				"name": "init-db",
-				"spec": map[string]interface{}{
+		tmpl.Grapi.InitContainers = []NamedSpec{
+			{
+				Name: "init-db",
+				Spec: map[string]interface{}{
 					"name":    "init-db",
-					"image":   "mysql",
+					"image":   utils.MirrorImage("mysql"),
 					"command": []string{"bash", "-c", initScript},
 				},
 			},
@@ -989,12 +852,12 @@ func updateTemplateForInitContainers(sourceDataExplicitlySet bool) error {
 
 		initScript := fmt.Sprintf("if ! test -f %s; then wget -O %s %s; chmod 777 %s; fi", DBFilePath, DBFilePath, SourceData, DBFilePath)
 
-		grapi["initContainers"] = []interface{}{
-			map[string]interface{}{
-				"name": "test",
-				"spec": map[string]interface{}{
+		tmpl.Grapi.InitContainers = []NamedSpec{
+			{
+				Name: "test",
+				Spec: map[string]interface{}{
 					"name":    "init-db",
-					"image":   "busybox:1.28",
+					"image":   utils.MirrorImage("busybox:1.28"),
 					"command": []string{"sh", "-c", initScript},
 				},
 			},
@@ -1002,74 +865,72 @@ func updateTemplateForInitContainers(sourceDataExplicitlySet bool) error {
 
 	}
 
-	tmpl["grapi"] = grapi
-	newData, err := yaml.Marshal(tmpl)
+	return hardenInitContainers(tmpl)
+}
+
+// hardenInitContainers adds a securityContext and resource limits to the db seeding init containers.
+// Running as non-root is only enforced when the namespace requires the "restricted" Pod Security
+// Standard, since the file based template writes into the data volume.
+func hardenInitContainers(tmpl *GrasTemplate) error {
+	if len(tmpl.Grapi.InitContainers) == 0 {
+		return nil
+	}
+
+	level, err := utils.GetNamespacePodSecurityLevel(clientset, KubeNS)
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(templateFileDest, newData, 0644); err != nil {
-		return err
+
+	hardened := utils.HardenedContainerSpecValues()
+	securityContext := hardened["securityContext"].(map[string]interface{})
+	if level == utils.PodSecurityLevelRestricted {
+		utils.InfoMessage(fmt.Sprintf("Namespace %s enforces the restricted Pod Security Standard, init containers will run as non-root", KubeNS))
+	} else {
+		delete(securityContext, "runAsNonRoot")
+		delete(securityContext, "runAsUser")
+		delete(securityContext, "runAsGroup")
 	}
 
+	for _, c := range tmpl.Grapi.InitContainers {
+		c.Spec["securityContext"] = securityContext
+		c.Spec["resources"] = hardened["resources"]
+	}
 	return nil
 }
 
-// substituteEnvVarsInTemplate performs environment variable substitution on the template file.
-func substituteEnvVarsInTemplate(tmplFile string) error {
-	data, err := os.ReadFile(tmplFile)
+// substituteEnvVarsInTemplate performs environment variable substitution on the template and writes it to tmplFile.
+func substituteEnvVarsInTemplate(tmpl *GrasTemplate, tmplFile string) error {
+	data, err := tmpl.Marshal()
 	if err != nil {
 		return err
 	}
 	expanded := os.ExpandEnv(string(data))
-	return os.WriteFile(tmplFile, []byte(expanded), 0644)
+	return os.WriteFile(tmplFile, []byte(expanded), 0600)
 }
 
-// deployTemplate uses the Helm Go SDK to install (or upgrade) the release.
-func deployTemplate(tmplFile, releaseName, namespace string) error {
+// deployTemplate uses the Helm Go SDK to install (or upgrade) the release. An existing release with the same
+// chart version and values is left alone unless --force is set, upToDate reports that case.
+func deployTemplate(tmplFile, releaseName, namespace string) (upToDate bool, err error) {
 	// Set up Helm settings.
 
 	utils.StartSpinner("Deploying the gras resource using the Helm\n")
 	defer utils.StopSpinner()
 
-	settings := cli.New()
-	settings.SetNamespace(namespace)
+	settings := newHelmSettings(namespace)
 
 	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
-		return fmt.Errorf("failed to initialize helm action configuration: %v", err)
+	if err := utils.InitHelmActionConfig(actionConfig, settings.RESTClientGetter(), namespace); err != nil {
+		return false, fmt.Errorf("failed to initialize helm action configuration: %v", err)
 	}
 
 	// Create registry client
-	registryClient, err := registry.NewClient(
-		registry.ClientOptDebug(settings.Debug),
-		registry.ClientOptWriter(os.Stdout),
-		registry.ClientOptCredentialsFile(settings.RegistryConfig),
-	)
+	registryClient, err := utils.NewChartRegistryClient()
 	if err != nil {
-		return fmt.Errorf("failed to create registry client: %v", err)
+		return false, err
 	}
 
 	// OCI chart reference
-	chartRef := fmt.Sprintf("oci://public.ecr.aws/%s/gras-deploy", awsRegistry)
-
-	// Check if release already exists
-	list := action.NewList(actionConfig)
-	releases, err := list.Run()
-	if err != nil {
-		return fmt.Errorf("failed to list releases: %v", err)
-	}
-
-	for _, rel := range releases {
-		if rel.Name == releaseName {
-			// Delete existing release
-			uninstall := action.NewUninstall(actionConfig)
-			if _, err := uninstall.Run(releaseName); err != nil {
-				return fmt.Errorf("failed to uninstall existing release: %v", err)
-			}
-			log.Printf("Existing release %q uninstalled", releaseName)
-			break
-		}
-	}
+	chartRef := utils.GrplChartRef("gras-deploy")
 
 	install := action.NewInstall(actionConfig)
 	install.ReleaseName = releaseName
@@ -1078,168 +939,149 @@ func deployTemplate(tmplFile, releaseName, namespace string) error {
 
 	chartPath, err := install.ChartPathOptions.LocateChart(chartRef, settings)
 	if err != nil {
-		return fmt.Errorf("failed to locate chart: %v", err)
+		return false, fmt.Errorf("%w: failed to locate chart: %w", utils.ErrChartNotFound, err)
 	}
 
 	chart, err := loader.Load(chartPath)
 	if err != nil {
-		return fmt.Errorf("failed to load chart: %v", err)
+		return false, fmt.Errorf("failed to load chart: %v", err)
 	}
 
 	// Merge values from the template file.
 	vals := map[string]interface{}{}
 	if fileVals, err := os.ReadFile(tmplFile); err == nil {
-		// First unmarshal into map[interface{}]interface{}
-		var rawVals map[interface{}]interface{}
-		if err := yaml.Unmarshal(fileVals, &rawVals); err != nil {
+		if parsed, err := valuesFromYAML(fileVals); err != nil {
 			log.Printf("warning: could not parse values from %s: %v", tmplFile, err)
 		} else {
-			// Convert to map[string]interface{} recursively
-			vals = convertToStringKeysMap(rawVals)
+			vals = parsed
 		}
 	} else {
 		log.Printf("warning: could not read values from %s: %v", tmplFile, err)
 	}
+	utils.AddCommonMetadataToValues(vals)
+	utils.AddRegistryOverrideToValues(vals)
 
-	rel, err := install.Run(chart, vals)
+	// Check if release already exists
+	list := action.NewList(actionConfig)
+	releases, err := list.Run()
 	if err != nil {
-		return fmt.Errorf("failed to install helm release: %v", err)
+		return false, fmt.Errorf("failed to list releases: %v", err)
 	}
 
+	for _, existing := range releases {
+		if existing.Name != releaseName {
+			continue
+		}
+		if !forceDeploy {
+			unchanged, err := releaseUnchanged(existing, chart.Metadata.Version, vals)
+			if err != nil {
+				return false, err
+			}
+			if unchanged {
+				log.Printf("Release %q is deployed with the same chart version and values, skipping it", releaseName)
+				return true, nil
+			}
+		}
+		// Delete existing release
+		uninstall := action.NewUninstall(actionConfig)
+		if _, err := uninstall.Run(releaseName); err != nil {
+			return false, fmt.Errorf("failed to uninstall existing release: %v", err)
+		}
+		log.Printf("Existing release %q uninstalled", releaseName)
+		break
+	}
+
+	rel, err := install.RunWithContext(utils.CommandContext(), chart, vals)
+	if err != nil {
+		// a failed install leaves a release behind in the "failed" state
+		recordCreatedRelease(namespace, releaseName)
+		return false, fmt.Errorf("failed to install helm release: %v", err)
+	}
+	recordCreatedRelease(namespace, releaseName)
+
 	log.Printf("Helm release %q installed in namespace %q (chart version: %s)", rel.Name, rel.Namespace, rel.Chart.Metadata.Version)
-	return nil
+	return false, nil
 }
 
-// convertToStringKeysMap recursively converts a map[interface{}]interface{} to map[string]interface{}
-func convertToStringKeysMap(m map[interface{}]interface{}) map[string]interface{} {
-	res := make(map[string]interface{})
-	for k, v := range m {
-		switch v := v.(type) {
-		case map[interface{}]interface{}:
-			res[fmt.Sprint(k)] = convertToStringKeysMap(v)
-		case []interface{}:
-			res[fmt.Sprint(k)] = convertToStringKeysList(v)
-		default:
-			res[fmt.Sprint(k)] = v
-		}
+// newHelmSettings returns the helm settings of namespace, on the kube-context of helmKubeContext if it is set
+// (otherwise on the one of --kube-context, which helm reads from HELM_KUBECONTEXT)
+func newHelmSettings(namespace string) *cli.EnvSettings {
+	settings := cli.New()
+	if helmKubeContext != "" {
+		settings.KubeContext = helmKubeContext
 	}
-	return res
+	settings.SetNamespace(namespace)
+	return settings
 }
 
-// convertToStringKeysList recursively converts []interface{} that might contain map[interface{}]interface{}
-func convertToStringKeysList(l []interface{}) []interface{} {
-	res := make([]interface{}, len(l))
-	for i, v := range l {
-		switch v := v.(type) {
-		case map[interface{}]interface{}:
-			res[i] = convertToStringKeysMap(v)
-		case []interface{}:
-			res[i] = convertToStringKeysList(v)
-		default:
-			res[i] = v
-		}
+// releaseUnchanged reports whether the deployed release already runs chartVersion with the same values,
+// a release that is not in the deployed state (failed, pending) is never considered up to date
+func releaseUnchanged(existing *release.Release, chartVersion string, vals map[string]interface{}) (bool, error) {
+	if existing.Info == nil || existing.Info.Status != release.StatusDeployed {
+		return false, nil
+	}
+	if existing.Chart == nil || existing.Chart.Metadata == nil || existing.Chart.Metadata.Version != chartVersion {
+		return false, nil
 	}
-	return res
+	deployedHash, err := valuesHash(existing.Config)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash the values of release %s: %v", existing.Name, err)
+	}
+	renderedHash, err := valuesHash(vals)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash the rendered values: %v", err)
+	}
+	return deployedHash == renderedHash, nil
 }
 
-func updateTemplateForInternalDB() error {
+func updateTemplateForInternalDB(tmpl *GrasTemplate) error {
 
-	if DatabaseSchema == "" {
-		prompt := promptui.Prompt{
+	if DatabaseSchema == "" && dbEngine == dbEngineRedis {
+		// Redis has no schemas, the datasource is named after the GRAS
+		DatabaseSchema = GRASName
+	} else if DatabaseSchema == "" {
+		schema, err := prompt.Input(prompt.Question{
 			Label: "Enter database schema name",
-		}
-		schema, err := prompt.Run()
+			Flag:  "--database-schema",
+		})
 		if err != nil {
 			return err
 		}
 		DatabaseSchema = schema
 	}
 
-	// Handle init containers based on source data
-	data, err := os.ReadFile(templateFileDest)
-	if err != nil {
-		return err
-	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
-	}
-	grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-	if !ok {
-		grapi = make(map[interface{}]interface{})
-		tmpl["grapi"] = grapi
-	}
-
-	grapi["extraSecrets"] = []string{fmt.Sprintf("%s-conn-credential", GRASName)}
-
-	datasources, ok := grapi["datasources"].([]interface{})
-	if !ok {
-		datasources = make([]interface{}, 0)
-	}
+	tmpl.Grapi.ExtraSecrets = []string{fmt.Sprintf("%s-conn-credential", GRASName)}
 
-	datasource := map[string]interface{}{
-		"name": DatabaseSchema,
-		"spec": map[string]interface{}{
-			"mysql": map[string]interface{}{
-				"name":     DatabaseSchema,
-				"host":     "$(host)",
-				"port":     "$(port)",
-				"user":     "$(username)",
-				"password": "$(password)",
-				"database": DatabaseSchema,
-			},
-		},
+	datasource := NamedSpec{
+		Name: DatabaseSchema,
+		Spec: internalDatasourceSpec(),
 	}
 
-	if len(datasources) == 0 {
-		datasources = append(datasources, datasource)
+	if len(tmpl.Grapi.Datasources) == 0 {
+		tmpl.Grapi.Datasources = append(tmpl.Grapi.Datasources, datasource)
 	} else {
-		datasources[0] = datasource
-	}
-
-	grapi["datasources"] = datasources
-
-	tmpl["grapi"] = grapi
-	newData, err := yaml.Marshal(tmpl)
-	if err != nil {
-		return err
-	}
-	if err := os.WriteFile(templateFileDest, newData, 0644); err != nil {
-		return err
+		tmpl.Grapi.Datasources[0] = datasource
 	}
 
 	return nil
 }
 
-func updateTemplateForExternalDB() error {
-	// Handle init containers based on source data
-	data, err := os.ReadFile(templateFileDest)
-	if err != nil {
-		return err
-	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
-	}
-	grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-	if !ok {
-		grapi = make(map[interface{}]interface{})
-		tmpl["grapi"] = grapi
-	}
+func updateTemplateForExternalDB(tmpl *GrasTemplate) error {
 
-	grapi["extraSecrets"] = []string{fmt.Sprintf("%s-conn-credential", GRASName)}
+	tmpl.Grapi.ExtraSecrets = []string{dbCredentialSecretName()}
 
-	datasources, ok := grapi["datasources"].([]interface{})
-	if !ok {
-		datasources = make([]interface{}, 0)
+	// The url is stored in the credential secret, like the password it may contain credentials
+	url := ""
+	if URL != "" {
+		url = "$(url)"
 	}
 
-	datasource := map[string]interface{}{
-		"name": DatabaseSchema,
-		"spec": map[string]interface{}{
+	datasource := NamedSpec{
+		Name: DatabaseSchema,
+		Spec: map[string]interface{}{
 			"mysql": map[string]interface{}{
 				"name":     DatabaseSchema,
-				"url":      URL,
+				"url":      url,
 				"host":     "$(host)",
 				"port":     "$(port)",
 				"user":     "$(username)",
@@ -1249,95 +1091,48 @@ func updateTemplateForExternalDB() error {
 		},
 	}
 
-	if len(datasources) == 0 {
-		datasources = append(datasources, datasource)
+	if len(tmpl.Grapi.Datasources) == 0 {
+		tmpl.Grapi.Datasources = append(tmpl.Grapi.Datasources, datasource)
 	} else {
-		datasources[0] = datasource
-	}
-
-	grapi["datasources"] = datasources
-
-	tmpl["grapi"] = grapi
-	newData, err := yaml.Marshal(tmpl)
-	if err != nil {
-		return err
-	}
-	if err := os.WriteFile(templateFileDest, newData, 0644); err != nil {
-		return err
+		tmpl.Grapi.Datasources[0] = datasource
 	}
 
 	return nil
 }
 
-func updateTemplateForRestcruds() error {
-	data, err := os.ReadFile(templateFileDest)
-	if err != nil {
-		return err
-	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
-	}
-	grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-	if !ok {
-		grapi = make(map[interface{}]interface{})
-		tmpl["grapi"] = grapi
-	}
+func updateTemplateForRestcruds(tmpl *GrasTemplate) error {
 
 	if GRASTemplate == utils.DB_FILE {
-		restcruds := []interface{}{
-			map[string]interface{}{
-				"name": "restcrud",
-				"spec": map[string]interface{}{
+		tmpl.Grapi.Restcruds = []NamedSpec{
+			{
+				Name: "restcrud",
+				Spec: map[string]interface{}{
 					"datasource": "db",
 				},
 			},
 		}
-		grapi["restcruds"] = restcruds
 	} else if GRASTemplate == utils.DB_MYSQL_MODEL_BASED || GRASTemplate == utils.DB_MYSQL_DISCOVERY_BASED {
-		restcruds := []interface{}{
-			map[string]interface{}{
-				"name": DatabaseSchema,
-				"spec": map[string]interface{}{
+		tmpl.Grapi.Restcruds = []NamedSpec{
+			{
+				Name: DatabaseSchema,
+				Spec: map[string]interface{}{
 					"datasource": DatabaseSchema,
 				},
 			},
 		}
-		grapi["restcruds"] = restcruds
-	}
-
-	tmpl["grapi"] = grapi
-	newData, err := yaml.Marshal(tmpl)
-	if err != nil {
-		return err
-	}
-	if err := os.WriteFile(templateFileDest, newData, 0644); err != nil {
-		return err
+		tmpl.Grapi.Restcruds = append(tmpl.Grapi.Restcruds, extraDatasourceRestcruds()...)
 	}
 
 	return nil
 
 }
 
-func updateTemplateForDataSourceIncaseOfDbFile() error {
-	data, err := os.ReadFile(templateFileDest)
-	if err != nil {
-		return err
-	}
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return err
-	}
-	grapi, ok := tmpl["grapi"].(map[interface{}]interface{})
-	if !ok {
-		grapi = make(map[interface{}]interface{})
-		tmpl["grapi"] = grapi
-	}
+func updateTemplateForDataSourceIncaseOfDbFile(tmpl *GrasTemplate) error {
 
-	datasources := []interface{}{
-		map[string]interface{}{
-			"name": "db",
-			"spec": map[string]interface{}{
+	tmpl.Grapi.Datasources = []NamedSpec{
+		{
+			Name: "db",
+			Spec: map[string]interface{}{
 				"memory": map[string]interface{}{
 					"connector":    "memory",
 					"name":         "db",
@@ -1347,16 +1142,6 @@ func updateTemplateForDataSourceIncaseOfDbFile() error {
 			},
 		},
 	}
-	grapi["datasources"] = datasources
-
-	tmpl["grapi"] = grapi
-	newData, err := yaml.Marshal(tmpl)
-	if err != nil {
-		return err
-	}
-	if err := os.WriteFile(templateFileDest, newData, 0644); err != nil {
-		return err
-	}
 
 	return nil
 
@@ -1364,50 +1149,24 @@ func updateTemplateForDataSourceIncaseOfDbFile() error {
 
 func createInternalDB() error {
 
-	filesDir, err := utils.GetResourcePath("files")
+	src, err := utils.ResolveResourceFile("files", "db.yaml")
 	if err != nil {
 		return err
 	}
 
-	src := filepath.Join(filesDir, "db.yaml")
 	srcData, err := os.ReadFile(src)
 	if err != nil {
 		return fmt.Errorf("failed to read source file: %v", err)
 	}
-	if err := os.WriteFile(kubeblocksTemplateFileDest, srcData, 0644); err != nil {
-		return fmt.Errorf("failed to write kubeblocks template file: %v", err)
-	}
-
-	// Read the template file
-	data, err := os.ReadFile(kubeblocksTemplateFileDest)
+	obj, err := valuesFromYAML(srcData)
 	if err != nil {
-		return fmt.Errorf("failed to read kubeblocks template file: %v", err)
-	}
-
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
 		return fmt.Errorf("failed to parse kubeblocks template YAML: %v", err)
 	}
 
+	unstructuredObj := &unstructured.Unstructured{Object: obj}
 	// Update the name in metadata
-	metadata := tmpl["metadata"].(map[interface{}]interface{})
-	metadata["name"] = GRASName
-
-	// Marshal back to YAML
-	newData, err := yaml.Marshal(tmpl)
-	if err != nil {
-		return fmt.Errorf("failed to marshal updated kubeblocks template: %v", err)
-	}
-
-	// Write back to file
-	if err := os.WriteFile(kubeblocksTemplateFileDest, newData, 0644); err != nil {
-		return fmt.Errorf("failed to write updated kubeblocks template: %v", err)
-	}
-	// Read and decode the YAML file
-	yamlFile, err := os.ReadFile(kubeblocksTemplateFileDest)
-	if err != nil {
-		return fmt.Errorf("failed to read manifest file: %v", err)
-	}
+	unstructuredObj.SetName(GRASName)
+	utils.ApplyCommonMetadataToUnstructured(unstructuredObj)
 
 	utils.InfoMessage("Checking and installing kubeblocks on cluster")
 	if err := utils.InstallKubeBlocksOnCluster(restConfig); err != nil {
@@ -1422,63 +1181,40 @@ func createInternalDB() error {
 		return fmt.Errorf("failed to create dynamic client: %v", err)
 	}
 
-	// Define the GVR for KubeBlocks Cluster
-	clusterGVR := schema.GroupVersionResource{
-		Group:    "apps.kubeblocks.io",
-		Version:  "v1alpha1",
-		Resource: "clusters",
+	version, err := resolveDBVersion(dynamicClient)
+	if err != nil {
+		return err
 	}
-
-	// First unmarshal into a map[interface{}]interface{}
-	var tempObj map[interface{}]interface{}
-	if err := yaml.Unmarshal(yamlFile, &tempObj); err != nil {
-		return fmt.Errorf("failed to parse YAML: %v", err)
+	if err := applyDBEngine(unstructuredObj, version); err != nil {
+		return fmt.Errorf("failed to apply the db engine to the kubeblocks template: %v", err)
 	}
+	utils.InfoMessage(fmt.Sprintf("Internal DB: %s %s, %d replica(s), %s storage", dbEngine, strings.TrimPrefix(version, selectedDBEngine().VersionPrefix), dbReplicas, dbStorageSize))
 
-	// Convert to map[string]interface{} recursively
-	obj := convertToStringKeysMap(tempObj)
-
-	unstructuredObj := &unstructured.Unstructured{Object: obj}
-
-	// Try to create the cluster first
-	_, err = dynamicClient.Resource(clusterGVR).Namespace(KubeNS).Create(
-		context.Background(),
-		unstructuredObj,
-		v1.CreateOptions{},
-	)
+	applier, err := utils.NewApplier(restConfig)
 	if err != nil {
-		// If resource already exists, get it first to obtain resourceVersion
-		if k8serrors.IsAlreadyExists(err) {
-			utils.InfoMessage(fmt.Sprintf("Cluster %s already exists, updating it", GRASName))
-
-			// Get existing resource
-			existing, err := dynamicClient.Resource(clusterGVR).Namespace(KubeNS).Get(
-				context.Background(),
-				GRASName,
-				v1.GetOptions{},
-			)
-			if err != nil {
-				return fmt.Errorf("failed to get existing cluster: %v", err)
-			}
-
-			// Set resourceVersion from existing to new object
-			unstructuredObj.SetResourceVersion(existing.GetResourceVersion())
-
-			// Update the resource
-			_, err = dynamicClient.Resource(clusterGVR).Namespace(KubeNS).Update(
-				context.Background(),
-				unstructuredObj,
-				v1.UpdateOptions{},
-			)
-			if err != nil {
-				return fmt.Errorf("failed to update cluster: %v", err)
-			}
-			utils.InfoMessage(fmt.Sprintf("Cluster %s updated successfully", GRASName))
-		} else {
-			return fmt.Errorf("failed to create cluster: %v", err)
-		}
+		return err
+	}
+	applier.Force = true
+	applied, err := applier.Apply(context.Background(), unstructuredObj, KubeNS)
+	if err != nil {
+		return fmt.Errorf("failed to create cluster: %v", err)
+	}
+	if applied.Created {
+		recordCreatedResource(applied.GVR, applied.Kind, KubeNS, GRASName)
+	} else {
+		utils.InfoMessage(fmt.Sprintf("Cluster %s updated successfully", GRASName))
 	}
 
+	// grapi and its init container need the database, continuing earlier leaves them crash looping
+	utils.InfoMessage(fmt.Sprintf("Waiting for the internal DB %s to be running...", GRASName))
+	connection, err := utils.WaitForKubeBlocksCluster(restConfig, KubeNS, GRASName)
+	if err != nil {
+		return fmt.Errorf("internal DB %s: %w", GRASName, err)
+	}
+	if showConnection {
+		utils.InfoMessage(fmt.Sprintf("Internal DB %s: host %s, port %s, user %s (password in secret %s)",
+			GRASName, connection.Host, connection.Port, connection.Username, connection.Secret))
+	}
 	return nil
 }
 
@@ -1532,10 +1268,12 @@ func prepareNamespaceForGrasInstallation() error {
 					Name: KubeNS,
 				},
 			}
+			utils.ApplyCommonMetadata(&ns.ObjectMeta)
 			_, err = clientset.CoreV1().Namespaces().Create(context.Background(), ns, v1.CreateOptions{})
 			if err != nil {
 				return fmt.Errorf("failed to create namespace: %v", err)
 			}
+			recordCreatedNamespace(KubeNS)
 			utils.InfoMessage(fmt.Sprintf("Created namespace: %s", KubeNS))
 		} else {
 			return fmt.Errorf("error checking namespace: %v", err)
@@ -0,0 +1,184 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	eventsWarningsOnly bool
+	eventsSince        time.Duration
+)
+
+// grasEvent is a Kubernetes event of an object of a GRAS
+type grasEvent struct {
+	Time    time.Time `json:"time" yaml:"time"`
+	Type    string    `json:"type" yaml:"type"`
+	Reason  string    `json:"reason" yaml:"reason"`
+	Object  string    `json:"object" yaml:"object"`
+	Count   int32     `json:"count" yaml:"count"`
+	Message string    `json:"message" yaml:"message"`
+}
+
+// EventsCmd represents the resource events command
+var EventsCmd = &cobra.Command{
+	Use:   "events [gras-name]",
+	Short: "Show the Kubernetes events of a GRAS and of the objects it created",
+	Long: `Events lists the Kubernetes events of a GrappleApplicationSet: the events of the GRAS resource itself
+and of its deployments, replica sets, pods, services and ingresses, oldest first. Warnings are highlighted,
+they usually tell why something doesn't start (image pull errors, failing probes, unschedulable pods).
+
+Without a GRAS name, the GRAS is selected among the GRAS resources of --namespace (or of the cluster).
+
+Example:
+  grapple resource events my-app --namespace my-app
+  grapple resource events my-app --warnings --since 30m`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEvents,
+}
+
+func init() {
+	EventsCmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace of the GRAS resource")
+	EventsCmd.Flags().BoolVar(&eventsWarningsOnly, "warnings", false, "Only show warnings")
+	EventsCmd.Flags().DurationVar(&eventsSince, "since", 0, "Only show events newer than this duration, e.g. 30m (default: all)")
+}
+
+func runEvents(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		GRASName = args[0]
+	}
+
+	var err error
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if err := resolveGrasName(); err != nil {
+		return err
+	}
+
+	related, err := grasObjects()
+	if err != nil {
+		return err
+	}
+
+	list, err := clientset.CoreV1().Events(KubeNS).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list events of namespace %s: %w", KubeNS, err)
+	}
+
+	var events []grasEvent
+	for _, e := range list.Items {
+		object := fmt.Sprintf("%s/%s", e.InvolvedObject.Kind, e.InvolvedObject.Name)
+		if !related[object] && !belongsToGras(e.InvolvedObject.Name) {
+			continue
+		}
+		if eventsWarningsOnly && e.Type != corev1.EventTypeWarning {
+			continue
+		}
+		at := eventTime(e)
+		if eventsSince > 0 && time.Since(at) > eventsSince {
+			continue
+		}
+		events = append(events, grasEvent{Time: at, Type: e.Type, Reason: e.Reason, Object: object, Count: e.Count, Message: strings.TrimSpace(e.Message)})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+
+	return utils.PrintResult(events, func() { printEvents(events) })
+}
+
+// grasObjects returns kind/name of the GRAS and of the objects it created, the pods and replica sets are found
+// through the selectors of the deployments
+func grasObjects() (map[string]bool, error) {
+	related := map[string]bool{"GrappleApplicationSet/" + GRASName: true}
+
+	deployments := append([]string{GRASName + "-grapi"}, gruimDeployments()...)
+	for _, name := range deployments {
+		related["Deployment/"+name] = true
+		related["Service/"+name] = true
+
+		deployment, err := clientset.AppsV1().Deployments(KubeNS).Get(context.TODO(), name, v1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		selector, err := v1.LabelSelectorAsSelector(deployment.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		options := v1.ListOptions{LabelSelector: selector.String()}
+		if pods, err := clientset.CoreV1().Pods(KubeNS).List(context.TODO(), options); err == nil {
+			for _, pod := range pods.Items {
+				related["Pod/"+pod.Name] = true
+			}
+		}
+		if replicaSets, err := clientset.AppsV1().ReplicaSets(KubeNS).List(context.TODO(), options); err == nil {
+			for _, rs := range replicaSets.Items {
+				related["ReplicaSet/"+rs.Name] = true
+			}
+		}
+	}
+
+	ingresses, err := clientset.NetworkingV1().Ingresses(KubeNS).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses of namespace %s: %w", KubeNS, err)
+	}
+	for _, ingress := range ingresses.Items {
+		if belongsToGras(ingress.Name) || utils.Contains(mapValues(ingress.Labels), GRASName) {
+			related["Ingress/"+ingress.Name] = true
+		}
+	}
+	return related, nil
+}
+
+// belongsToGras reports whether an object is named after the GRAS, like everything created for it
+func belongsToGras(name string) bool {
+	return name == GRASName || strings.HasPrefix(name, GRASName+"-")
+}
+
+func mapValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+// eventTime returns when the event last happened, events of the events.k8s.io API only set EventTime
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	case !e.FirstTimestamp.IsZero():
+		return e.FirstTimestamp.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+func printEvents(events []grasEvent) {
+	if len(events) == 0 {
+		utils.InfoMessage(fmt.Sprintf("No events found for GRAS %s in namespace %s", GRASName, KubeNS))
+		return
+	}
+	fmt.Printf("%-8s %-8s %-24s %-40s %s\n", "AGE", "TYPE", "REASON", "OBJECT", "MESSAGE")
+	for _, e := range events {
+		line := fmt.Sprintf("%-8s %-8s %-24s %-40s %s", utils.FormatDuration(time.Since(e.Time).Truncate(time.Second)), e.Type, e.Reason, e.Object, e.Message)
+		if e.Count > 1 {
+			line += fmt.Sprintf(" (x%d)", e.Count)
+		}
+		if e.Type == corev1.EventTypeWarning {
+			line = utils.ColorRed + line + utils.ColorReset
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,339 @@
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// namedSpecAnswers are the answers that may be given as a map of name to spec instead of the name:{json} string
+var namedSpecAnswers = []string{"models", "relations", "discoveries"}
+
+// specFileNames are looked up, in order, when --git-path points to a directory
+var specFileNames = []string{"gras.yaml", "gras.yml", "answers.yaml", "answers.yml", "resource.yaml", "resource.yml"}
+
+// grasManifest is a GrappleApplicationSet as written by 'grapple resource render'
+type grasManifest struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name        string            `yaml:"name"`
+		Namespace   string            `yaml:"namespace"`
+		Labels      map[string]string `yaml:"labels"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Grapis []struct {
+			Name string       `yaml:"name"`
+			Spec GrapiSection `yaml:"spec"`
+		} `yaml:"grapis"`
+		Gruims []struct {
+			Name string                 `yaml:"name"`
+			Spec map[string]interface{} `yaml:"spec"`
+		} `yaml:"gruims"`
+	} `yaml:"spec"`
+}
+
+// loadSpecFromGit clones the --git repository and reads the spec it points to. A GrappleApplicationSet
+// manifest is returned as a template ready to be deployed, an answers file is applied to the deploy
+// flags instead (flags passed on the command line take precedence) and nil is returned.
+func loadSpecFromGit(cmd *cobra.Command) (*GrasTemplate, error) {
+	repoDir, err := os.MkdirTemp("", "grpl-gras-spec-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(repoDir)
+
+	if err := cloneSpecRepo(repoDir); err != nil {
+		return nil, err
+	}
+
+	specFile, err := resolveSpecFile(filepath.Join(repoDir, GitPath))
+	if err != nil {
+		return nil, err
+	}
+	utils.InfoMessage(fmt.Sprintf("Using spec %s", strings.TrimPrefix(specFile, repoDir+string(os.PathSeparator))))
+
+	data, err := os.ReadFile(specFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file: %w", err)
+	}
+
+	var manifest grasManifest
+	if err := yaml.Unmarshal(data, &manifest); err == nil && manifest.Kind == "GrappleApplicationSet" {
+		return templateFromManifest(cmd, &manifest)
+	}
+
+	return nil, applyAnswersFile(cmd, data)
+}
+
+// cloneSpecRepo clones --git into dir and checks out --git-ref, using the GitHub token for private repositories
+func cloneSpecRepo(dir string) error {
+	if githubToken == "" {
+		githubToken = os.Getenv("GITHUB_TOKEN")
+	}
+
+	clone := func() (*git.Repository, error) {
+		opts := &git.CloneOptions{URL: GitURL}
+		if githubToken != "" {
+			opts.Auth = &http.BasicAuth{
+				Username: "git", // This can be anything except empty string
+				Password: githubToken,
+			}
+		}
+		return git.PlainClone(dir, false, opts)
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Cloning %s...", GitURL))
+	repo, err := clone()
+	if err != nil && githubToken == "" && (errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrRepositoryNotFound)) {
+		result, promptErr := utils.PromptPassword("Repository requires authentication, enter GitHub token")
+		if promptErr != nil {
+			return fmt.Errorf("invalid GitHub token: %w", promptErr)
+		}
+		githubToken = result
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to clean clone directory: %w", err)
+		}
+		repo, err = clone()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to clone %s: %w", GitURL, err)
+	}
+
+	if GitRef == "" {
+		return nil
+	}
+
+	// The ref may be a branch (only known as a remote branch after the clone), a tag or a commit
+	hash, err := repo.ResolveRevision(plumbing.Revision(GitRef))
+	if err != nil {
+		hash, err = repo.ResolveRevision(plumbing.Revision("refs/remotes/origin/" + GitRef))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resolve git ref %s: %w", GitRef, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Hash: *hash}); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", GitRef, err)
+	}
+	utils.InfoMessage(fmt.Sprintf("Checked out %s (%s)", GitRef, hash.String()[:7]))
+	return nil
+}
+
+// resolveSpecFile returns path itself if it is a file, otherwise the first known spec file inside it
+func resolveSpecFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("spec path %s not found in repository", GitPath)
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+	for _, name := range specFileNames {
+		candidate := filepath.Join(path, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no spec file found in %s, expected one of: %s", GitPath, strings.Join(specFileNames, ", "))
+}
+
+// templateFromManifest turns a GrappleApplicationSet manifest back into the template deployed by helm,
+// it is the reverse of what 'grapple resource render' writes
+func templateFromManifest(cmd *cobra.Command, manifest *grasManifest) (*GrasTemplate, error) {
+	if len(manifest.Spec.Grapis) == 0 {
+		return nil, fmt.Errorf("GrappleApplicationSet %s has no grapis", manifest.Metadata.Name)
+	}
+
+	if !cmd.Flags().Changed("gras-name") {
+		GRASName = manifest.Metadata.Name
+	}
+	if !cmd.Flags().Changed("namespace") && manifest.Metadata.Namespace != "" {
+		KubeNS = manifest.Metadata.Namespace
+	}
+	for k, v := range manifest.Metadata.Labels {
+		if _, ok := utils.CommonLabels[k]; !ok {
+			utils.CommonLabels[k] = v
+		}
+	}
+	for k, v := range manifest.Metadata.Annotations {
+		if _, ok := utils.CommonAnnotations[k]; !ok {
+			utils.CommonAnnotations[k] = v
+		}
+	}
+
+	tmpl := &GrasTemplate{
+		Gras:  map[string]interface{}{},
+		Grapi: manifest.Spec.Grapis[0].Spec,
+	}
+	if len(manifest.Spec.Gruims) > 0 {
+		tmpl.Gruim = manifest.Spec.Gruims[0].Spec
+		for _, gruim := range manifest.Spec.Gruims[1:] {
+			tmpl.Gruims = append(tmpl.Gruims, NamedSpec{Name: gruim.Name, Spec: gruim.Spec})
+		}
+	}
+	return tmpl, nil
+}
+
+// applyAnswersFile sets deploy flags from a YAML file of flag name to value, e.g. "gras-template: db-file"
+// Models, relations and discoveries may be given as a map of name to spec.
+func applyAnswersFile(cmd *cobra.Command, data []byte) error {
+	answers := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &answers); err != nil {
+		return fmt.Errorf("spec is neither a GrappleApplicationSet nor an answers file: %w", err)
+	}
+
+	keys := make([]string, 0, len(answers))
+	for key := range answers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, "git") {
+			return fmt.Errorf("answers file cannot set %s", key)
+		}
+		flag := cmd.Flags().Lookup(key)
+		if flag == nil {
+			return fmt.Errorf("unknown answer %q, answers must be named like the deploy flags", key)
+		}
+		if flag.Changed {
+			continue
+		}
+
+		var value string
+		switch v := answers[key].(type) {
+		case map[interface{}]interface{}:
+			if containsString(namedSpecAnswers, key) {
+				var err error
+				if value, err = namedSpecsAnswer(v); err != nil {
+					return fmt.Errorf("invalid answer for %s: %w", key, err)
+				}
+				break
+			}
+			var pairs []string
+			for k, val := range v {
+				pairs = append(pairs, fmt.Sprintf("%v=%v", k, val))
+			}
+			sort.Strings(pairs)
+			value = strings.Join(pairs, ",")
+		default:
+			value = fmt.Sprint(v)
+		}
+		if err := cmd.Flags().Set(key, value); err != nil {
+			return fmt.Errorf("invalid answer for %s: %w", key, err)
+		}
+	}
+
+	utils.SetCommonMetadata(Labels, Annotations)
+	return nil
+}
+
+// namedSpecsAnswer turns the named specs of an answers file, e.g. the models written by 'grapple resource templates
+// import-schema', into the name:{json}|... format of their flag
+func namedSpecsAnswer(specs map[interface{}]interface{}) (string, error) {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, fmt.Sprint(name))
+	}
+	sort.Strings(names)
+
+	entries := make([]string, 0, len(names))
+	for _, name := range names {
+		spec, err := json.Marshal(jsonCompatible(specs[name]))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+		entries = append(entries, fmt.Sprintf("%s:%s", name, spec))
+	}
+	return strings.Join(entries, "|"), nil
+}
+
+// jsonCompatible converts the map[interface{}]interface{} maps read by yaml.v2 into maps JSON can encode
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonCompatible(item)
+		}
+	}
+	return value
+}
+
+// deployManifestTemplate deploys a template read from a GrappleApplicationSet manifest as is
+func deployManifestTemplate(tmpl *GrasTemplate, logOnFileStart, logOnCliAndFileStart func()) error {
+	if err := utils.ValidateResourceName(GRASName); err != nil {
+		return err
+	}
+	utils.InfoMessage(fmt.Sprintf("gras name: %s", GRASName))
+
+	if err := prepareNamespaceForGrasInstallation(); err != nil {
+		return err
+	}
+
+	defaults, err := loadDeployDefaults(KubeNS)
+	if err != nil {
+		return err
+	}
+	if err := defaults.applyToInputs(); err != nil {
+		return err
+	}
+	if err := defaults.applyToTemplate(tmpl); err != nil {
+		return err
+	}
+	if err := applySetFiles(tmpl); err != nil {
+		return err
+	}
+
+	utils.InfoMessage("Substituting environment variables in the template...")
+	if err := substituteEnvVarsInTemplate(tmpl, templateFileDest); err != nil {
+		return err
+	}
+
+	utils.InfoMessage("Deploying the template using the Helm")
+	logOnFileStart()
+	upToDate, err := deployTemplate(templateFileDest, GRASName, KubeNS)
+	logOnCliAndFileStart()
+	if err != nil {
+		return err
+	}
+	if upToDate {
+		utils.SuccessMessage(fmt.Sprintf("GRAS %s is up to date in namespace %s, nothing to deploy (use --force to redeploy it)", GRASName, KubeNS))
+		return nil
+	}
+	if err := deployAdditionalGruims(tmpl); err != nil {
+		return err
+	}
+
+	if SeedSampleData > 0 {
+		utils.InfoMessage(fmt.Sprintf("Seeding %d sample records per model...", SeedSampleData))
+		if err := seedSampleData(tmpl, SeedSampleData); err != nil {
+			utils.ErrorMessage("Failed to seed sample data: " + err.Error())
+		}
+	}
+
+	utils.SuccessMessage("Resource deployed successfully!")
+	return nil
+}
@@ -0,0 +1,433 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	graphFile   string
+	graphFormat string
+	graphOut    string
+)
+
+// graphNode is an element of a GRAS, group selects its shape and color
+type graphNode struct {
+	id    string
+	label string
+	group string
+}
+
+type graphEdge struct {
+	from  string
+	to    string
+	label string
+}
+
+// grasGraph is the dependency graph of a GRAS, nodes keep the order they were added in
+type grasGraph struct {
+	name  string
+	nodes []graphNode
+	seen  map[string]bool
+	edges []graphEdge
+}
+
+// k8sObject is a Kubernetes object backing a GRAS, backends are the services an ingress routes to
+type k8sObject struct {
+	kind     string
+	name     string
+	backends []string
+}
+
+var graphGroupStyles = map[string]struct{ shape, color string }{
+	"gras":       {"box", "#004a99"},
+	"grapi":      {"box", "#2e7d32"},
+	"gruim":      {"box", "#fa7252"},
+	"datasource": {"cylinder", "#6d4c41"},
+	"discovery":  {"parallelogram", "#8e24aa"},
+	"model":      {"ellipse", "#1e88e5"},
+	"restcrud":   {"hexagon", "#00897b"},
+	"module":     {"component", "#f9a825"},
+	"k8s":        {"note", "#757575"},
+}
+
+// GraphCmd represents the resource graph command
+var GraphCmd = &cobra.Command{
+	Use:   "graph [gras-name]",
+	Short: "Show the dependency graph of a GRAS as DOT or Mermaid",
+	Long: `Graph draws what a GrappleApplicationSet consists of: its grapis with their datasources, discoveries,
+models, relations and restcruds, its GRUIMs with their modules, and the Kubernetes deployments, services and
+ingresses backing them.
+
+The GRAS is read from the cluster, or from a manifest written by 'grapple resource render' with --file (the
+Kubernetes objects are only shown for deployed GRAS resources). The graph is printed in Mermaid (default) or
+DOT format, --out writes it to a file instead, an .svg file is rendered with Graphviz (dot).
+
+Example:
+  grapple resource graph my-app --namespace my-app
+  grapple resource graph --file gras.yaml --format dot
+  grapple resource graph my-app --out my-app.svg`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGraph,
+}
+
+func init() {
+	GraphCmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace of the GRAS resource")
+	GraphCmd.Flags().StringVar(&graphFile, "file", "", "GRAS manifest to draw instead of a deployed GRAS")
+	GraphCmd.Flags().StringVar(&graphFormat, "format", "", "Graph format: mermaid or dot (default: mermaid, dot for .dot and .svg files)")
+	GraphCmd.Flags().StringVar(&graphOut, "out", "", "File to write the graph to, .svg files are rendered with Graphviz")
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		GRASName = args[0]
+	}
+
+	format := graphFormat
+	ext := strings.ToLower(filepath.Ext(graphOut))
+	if format == "" {
+		format = "mermaid"
+		if ext == ".dot" || ext == ".gv" || ext == ".svg" {
+			format = "dot"
+		}
+	}
+	if format != "mermaid" && format != "dot" {
+		return fmt.Errorf("%w: unsupported --format %q, use mermaid or dot", utils.ErrValidation, format)
+	}
+	if ext == ".svg" && format != "dot" {
+		return fmt.Errorf("%w: SVG files are rendered from the dot format", utils.ErrValidation)
+	}
+
+	manifest, objects, err := loadGraphSource()
+	if err != nil {
+		return err
+	}
+	graph := buildGrasGraph(manifest, objects)
+
+	var rendered string
+	if format == "dot" {
+		rendered = graph.dot()
+	} else {
+		rendered = graph.mermaid()
+	}
+
+	switch {
+	case graphOut == "":
+		fmt.Print(rendered)
+		return nil
+	case ext == ".svg":
+		if _, err := exec.LookPath("dot"); err != nil {
+			return fmt.Errorf("Graphviz is required to render SVG files, install it or use --out %s.dot: %w", strings.TrimSuffix(graphOut, ext), err)
+		}
+		dot := exec.Command("dot", "-Tsvg", "-o", graphOut)
+		dot.Stdin = strings.NewReader(rendered)
+		if output, err := dot.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to render %s: %v: %s", graphOut, err, output)
+		}
+	default:
+		if err := os.WriteFile(graphOut, []byte(rendered), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", graphOut, err)
+		}
+	}
+	utils.SuccessMessage(fmt.Sprintf("Graph of %s written to %s", graph.name, graphOut))
+	return nil
+}
+
+// loadGraphSource returns the GRAS of --file, or the deployed GRAS with the Kubernetes objects backing it
+func loadGraphSource() (*grasManifest, []k8sObject, error) {
+	manifest := &grasManifest{}
+	if graphFile != "" {
+		data, err := os.ReadFile(graphFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", graphFile, err)
+		}
+		if err := yaml.Unmarshal(data, manifest); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", graphFile, err)
+		}
+		if manifest.Kind != "GrappleApplicationSet" {
+			return nil, nil, fmt.Errorf("%w: %s is not a GrappleApplicationSet manifest", utils.ErrValidation, graphFile)
+		}
+		return manifest, nil, nil
+	}
+
+	var err error
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return nil, nil, err
+	}
+	if err := resolveGrasName(); err != nil {
+		return nil, nil, err
+	}
+	gras, err := utils.GetGras(restConfig, KubeNS, GRASName)
+	if err != nil {
+		return nil, nil, err
+	}
+	// JSON is YAML, so the object is read like a rendered manifest
+	data, err := json.Marshal(gras.Object)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := yaml.Unmarshal(data, manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to read GrappleApplicationSet %s: %w", GRASName, err)
+	}
+
+	objects, err := grasBackingObjects()
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifest, objects, nil
+}
+
+// grasBackingObjects returns the deployments, services and ingresses of the GRAS
+func grasBackingObjects() ([]k8sObject, error) {
+	var objects []k8sObject
+	deployments, err := clientset.AppsV1().Deployments(KubeNS).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments of namespace %s: %w", KubeNS, err)
+	}
+	for _, d := range deployments.Items {
+		if belongsToGras(d.Name) {
+			objects = append(objects, k8sObject{kind: "Deployment", name: d.Name})
+		}
+	}
+	services, err := clientset.CoreV1().Services(KubeNS).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services of namespace %s: %w", KubeNS, err)
+	}
+	for _, s := range services.Items {
+		if belongsToGras(s.Name) {
+			objects = append(objects, k8sObject{kind: "Service", name: s.Name})
+		}
+	}
+	ingresses, err := clientset.NetworkingV1().Ingresses(KubeNS).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses of namespace %s: %w", KubeNS, err)
+	}
+	for _, ingress := range ingresses.Items {
+		if !belongsToGras(ingress.Name) && !utils.Contains(mapValues(ingress.Labels), GRASName) {
+			continue
+		}
+		object := k8sObject{kind: "Ingress", name: ingress.Name}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service != nil && !utils.Contains(object.backends, path.Backend.Service.Name) {
+					object.backends = append(object.backends, path.Backend.Service.Name)
+				}
+			}
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// buildGrasGraph turns the spec of a GRAS and its Kubernetes objects into a graph
+func buildGrasGraph(manifest *grasManifest, objects []k8sObject) *grasGraph {
+	name := manifest.Metadata.Name
+	if name == "" {
+		name = GRASName
+	}
+	g := &grasGraph{name: name, seen: map[string]bool{}}
+	gras := g.node("gras", "GrappleApplicationSet "+name, "gras")
+
+	var grapis []string
+	for _, grapi := range manifest.Spec.Grapis {
+		api := g.node("grapi/"+grapi.Name, "grapi "+grapi.Name, "grapi")
+		grapis = append(grapis, api)
+		g.edge(gras, api, "")
+
+		for _, ds := range grapi.Spec.Datasources {
+			g.edge(api, g.datasource(ds.Name, ds.Spec), "")
+		}
+		for _, discovery := range grapi.Spec.Discoveries {
+			id := g.node("discovery/"+discovery.Name, "discovery "+discovery.Name, "discovery")
+			g.edge(api, id, "")
+			if ds := specString(discovery.Spec, "dataSource", "datasource"); ds != "" {
+				g.edge(id, g.datasource(ds, nil), "discovers")
+			}
+		}
+		for _, model := range grapi.Spec.Models {
+			id := g.model(model.Name)
+			g.edge(api, id, "")
+			if ds := specString(model.Spec, "datasource", "dataSource"); ds != "" {
+				g.edge(id, g.datasource(ds, nil), "stored in")
+			}
+		}
+		for _, relation := range grapi.Spec.Relations {
+			source := specString(relation.Spec, "sourceModel")
+			destination := specString(relation.Spec, "destinationModel")
+			if source == "" || destination == "" {
+				continue
+			}
+			label := relation.Name
+			if relationType := specString(relation.Spec, "relationType"); relationType != "" {
+				label = fmt.Sprintf("%s (%s)", label, relationType)
+			}
+			g.edge(g.model(source), g.model(destination), label)
+		}
+		for _, restcrud := range grapi.Spec.Restcruds {
+			id := g.node("restcrud/"+restcrud.Name, "restcrud "+restcrud.Name, "restcrud")
+			g.edge(api, id, "")
+			if ds := specString(restcrud.Spec, "datasource", "dataSource"); ds != "" {
+				g.edge(id, g.datasource(ds, nil), "crud")
+			}
+		}
+	}
+
+	for _, gruim := range manifest.Spec.Gruims {
+		ui := g.node("gruim/"+gruim.Name, "gruim "+gruim.Name, "gruim")
+		g.edge(gras, ui, "")
+		for _, api := range grapis {
+			g.edge(ui, api, "uses")
+		}
+		for _, module := range gruimModules(gruim.Spec) {
+			g.edge(ui, g.node("module/"+gruim.Name+"/"+module, "module "+module, "module"), "")
+		}
+	}
+
+	for _, object := range objects {
+		id := g.node("k8s/"+object.kind+"/"+object.name, object.kind+" "+object.name, "k8s")
+		owner := gras
+		if base, ok := strings.CutSuffix(object.name, "-grapi"); ok && g.seen["grapi/"+base] {
+			owner = "grapi/" + base
+		} else if base, ok := strings.CutSuffix(object.name, "-gruim"); ok && g.seen["gruim/"+base] {
+			owner = "gruim/" + base
+		}
+		g.edge(id, owner, "runs")
+		for _, backend := range object.backends {
+			if g.seen["k8s/Service/"+backend] {
+				g.edge(id, "k8s/Service/"+backend, "routes to")
+			}
+		}
+	}
+	return g
+}
+
+// node adds a node unless it exists and returns its id
+func (g *grasGraph) node(id, label, group string) string {
+	if !g.seen[id] {
+		g.seen[id] = true
+		g.nodes = append(g.nodes, graphNode{id: id, label: label, group: group})
+	}
+	return id
+}
+
+func (g *grasGraph) edge(from, to, label string) {
+	g.edges = append(g.edges, graphEdge{from: from, to: to, label: label})
+}
+
+func (g *grasGraph) model(name string) string {
+	return g.node("model/"+name, "model "+name, "model")
+}
+
+// datasource adds a datasource node, labelled with its connector (e.g. mysql) when the spec is known
+func (g *grasGraph) datasource(name string, spec map[string]interface{}) string {
+	label := "datasource " + name
+	connectors := make([]string, 0, len(spec))
+	for key := range spec {
+		connectors = append(connectors, key)
+	}
+	sort.Strings(connectors)
+	if len(connectors) > 0 {
+		label = fmt.Sprintf("%s (%s)", label, strings.Join(connectors, ", "))
+	}
+	id := "datasource/" + name
+	if !g.seen[id] {
+		return g.node(id, label, "datasource")
+	}
+	// a datasource referenced before its definition gets the label of the definition
+	for i := range g.nodes {
+		if g.nodes[i].id == id && len(connectors) > 0 {
+			g.nodes[i].label = label
+		}
+	}
+	return id
+}
+
+// specString returns the first of keys set to a string in spec
+func specString(spec map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := spec[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// gruimModules returns the names of the modules of a GRUIM spec, given as names or as entries with a name
+func gruimModules(spec map[string]interface{}) []string {
+	list, _ := spec["modules"].([]interface{})
+	var modules []string
+	for _, entry := range list {
+		switch module := entry.(type) {
+		case string:
+			modules = append(modules, module)
+		case map[interface{}]interface{}:
+			if name, ok := module["name"].(string); ok {
+				modules = append(modules, name)
+			}
+		}
+	}
+	return modules
+}
+
+// dot renders the graph in Graphviz format
+func (g *grasGraph) dot() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "digraph %q {\n  rankdir=LR;\n  node [fontname=\"Helvetica\" style=filled fontcolor=white];\n  edge [fontname=\"Helvetica\" fontsize=10];\n", g.name)
+	for _, n := range g.nodes {
+		style := graphGroupStyles[n.group]
+		fmt.Fprintf(&b, "  %q [label=%q shape=%s fillcolor=%q];\n", n.id, n.label, style.shape, style.color)
+	}
+	for _, e := range g.edges {
+		if e.label == "" {
+			fmt.Fprintf(&b, "  %q -> %q;\n", e.from, e.to)
+		} else {
+			fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", e.from, e.to, e.label)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// mermaid renders the graph as a Mermaid flowchart, which GitHub and most markdown viewers display
+func (g *grasGraph) mermaid() string {
+	ids := map[string]string{}
+	var b bytes.Buffer
+	b.WriteString("flowchart LR\n")
+	for i, n := range g.nodes {
+		ids[n.id] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]:::%s\n", ids[n.id], strings.ReplaceAll(n.label, `"`, "#quot;"), n.group)
+	}
+	for _, e := range g.edges {
+		if e.label == "" {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[e.from], ids[e.to])
+		} else {
+			fmt.Fprintf(&b, "  %s -->|\"%s\"| %s\n", ids[e.from], strings.ReplaceAll(e.label, `"`, "#quot;"), ids[e.to])
+		}
+	}
+	groups := make([]string, 0, len(graphGroupStyles))
+	for group := range graphGroupStyles {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		fmt.Fprintf(&b, "  classDef %s fill:%s,color:#fff\n", group, graphGroupStyles[group].color)
+	}
+	return b.String()
+}
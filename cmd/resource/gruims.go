@@ -0,0 +1,98 @@
+package resource
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// takeAdditionalGruims adds the GRUIMs of --gruims to the template. Every one is named <gras-name>-<name>, so
+// its deployment and ingress host differ from the main GRUIM, and starts from the settings of the main GRUIM
+// overridden by its own spec.
+func takeAdditionalGruims(tmpl *GrasTemplate) error {
+	specs, err := parseNamedSpecs(GruimsInput, "gruims")
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{GRASName: true}
+	for _, s := range specs {
+		if err := utils.ValidateResourceName(s.Name); err != nil {
+			return fmt.Errorf("invalid --gruims name %q: %w", s.Name, err)
+		}
+		name := fmt.Sprintf("%s-%s", GRASName, s.Name)
+		if seen[name] {
+			return fmt.Errorf("%w: GRUIM %s is defined twice", utils.ErrValidation, s.Name)
+		}
+		seen[name] = true
+
+		spec := map[string]interface{}{}
+		for k, v := range tmpl.Gruim {
+			spec[k] = v
+		}
+		for k, v := range s.Spec {
+			spec[k] = v
+		}
+		tmpl.Gruims = append(tmpl.Gruims, NamedSpec{Name: name, Spec: spec})
+		utils.InfoMessage(fmt.Sprintf("Adding GRUIM %s", name))
+	}
+	return nil
+}
+
+// gruimEntries returns the gruims of the GRAS spec, the main GRUIM first, with JSON compatible values
+func gruimEntries(tmpl *GrasTemplate) ([]map[string]interface{}, error) {
+	var entries []interface{}
+	if tmpl.Gruim != nil {
+		entries = append(entries, map[string]interface{}{"name": GRASName, "spec": tmpl.Gruim})
+	}
+	for _, gruim := range tmpl.Gruims {
+		entries = append(entries, map[string]interface{}{"name": gruim.Name, "spec": gruim.Spec})
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	// the template is read with yaml.v2, whose nested maps are not keyed by string
+	data, err := yaml.Marshal(map[string]interface{}{"gruims": entries})
+	if err != nil {
+		return nil, err
+	}
+	vals, err := valuesFromYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	var gruims []map[string]interface{}
+	for _, entry := range vals["gruims"].([]interface{}) {
+		gruims = append(gruims, entry.(map[string]interface{}))
+	}
+	return gruims, nil
+}
+
+// gruimDeployments returns the deployments of the GRUIMs of the GRAS, <gras-name>-gruim when the GRAS can't be read
+func gruimDeployments() []string {
+	names, err := utils.GrasGruimNames(restConfig, KubeNS, GRASName)
+	if err != nil || len(names) == 0 {
+		names = []string{GRASName}
+	}
+	deployments := make([]string, 0, len(names))
+	for _, name := range names {
+		deployments = append(deployments, name+"-gruim")
+	}
+	return deployments
+}
+
+// deployAdditionalGruims adds the additional GRUIMs to the GRAS created by the helm release
+func deployAdditionalGruims(tmpl *GrasTemplate) error {
+	if len(tmpl.Gruims) == 0 {
+		return nil
+	}
+	gruims, err := gruimEntries(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to prepare gruims: %w", err)
+	}
+	if err := utils.EnsureGrasGruims(restConfig, KubeNS, GRASName, gruims); err != nil {
+		return fmt.Errorf("failed to add the additional gruims: %w", err)
+	}
+	utils.InfoMessage(fmt.Sprintf("%d additional GRUIM(s) added to GRAS %s", len(tmpl.Gruims), GRASName))
+	return nil
+}
@@ -0,0 +1,279 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// importSchemaTables are the tables turned into models, all tables when empty
+	importSchemaTables    []string
+	importSchemaFile      string
+	importSchemaNamespace string
+	importSchemaForce     bool
+)
+
+// TemplateImportSchemaCmd represents the resource templates import-schema command
+var TemplateImportSchemaCmd = &cobra.Command{
+	Use:   "import-schema",
+	Short: "Turn the tables of an existing MySQL database into the models of an answers file",
+	Long: `Import-schema reads the tables of an existing external MySQL database once and writes them as explicit models
+of the db-mysql-model-based template into an answers file, for teams that move an existing database to Grapple
+but want to curate their models instead of discovering them at every start of grapi.
+
+Each selected table becomes a model named like the table, each column a property: its type is mapped to the
+closest model type (tinyint(1) to boolean, decimal to float, datetime to date, json to object, ...), primary
+keys are marked as id, auto increment columns as generated and NOT NULL columns without default as required.
+Foreign keys between selected tables become belongsTo relations.
+
+The schema is read from information_schema by a short lived pod in --namespace, like the connection check of
+'grapple resource deploy', so databases only reachable from inside the cluster work too. The connection is
+given with --datasources, like for deploy, or interactively.
+
+The answers file sets gras-template, db-type, models and relations, other answers of an existing file are
+kept. Credentials are not written to it, pass them with --datasources when deploying:
+
+  grapple resource deploy --git <repo> --git-path answers.yaml --datasources "shop:{...}"
+
+Example:
+  grapple resource templates import-schema --datasources "shop:{'host':'db.corp.com','port':'3306','user':'app','password':'secret','database':'shop'}"
+  grapple resource templates import-schema --tables customers,orders --file apps/shop/answers.yaml --namespace shop`,
+	Args: cobra.NoArgs,
+	RunE: runImportSchema,
+}
+
+func init() {
+	TemplateImportSchemaCmd.Flags().StringVar(&DatasourcesInput, "datasources", "", "Connection of the database, in the format of 'resource deploy --datasources' (default: prompted)")
+	TemplateImportSchemaCmd.Flags().StringSliceVar(&importSchemaTables, "tables", nil, "Tables to import, comma separated (default: prompted, all tables)")
+	TemplateImportSchemaCmd.Flags().StringVar(&importSchemaFile, "file", "answers.yaml", "Answers file the models are written to")
+	TemplateImportSchemaCmd.Flags().StringVar(&importSchemaNamespace, "namespace", "default", "Namespace of the pod reading the schema")
+	TemplateImportSchemaCmd.Flags().BoolVar(&importSchemaForce, "force", false, "Replace the models and relations of an existing answers file")
+}
+
+func runImportSchema(cmd *cobra.Command, args []string) error {
+	answers, err := readAnswersFile(importSchemaFile)
+	if err != nil {
+		return err
+	}
+	if !importSchemaForce {
+		for _, item := range answers {
+			if key := fmt.Sprint(item.Key); key == "models" || key == "relations" {
+				return fmt.Errorf("%w: %s already has %s, which may have been curated; use --force to replace them",
+					utils.ErrValidation, importSchemaFile, key)
+			}
+		}
+	}
+
+	var database, host, port, user, password string
+	if DatasourcesInput != "" {
+		database, host, port, user, password, _, err = extractDatasourceInfo(DatasourcesInput)
+	} else {
+		database, host, port, user, password, _, err = takeDatasourceInputFromCLI()
+	}
+	if err != nil {
+		return err
+	}
+	if database == "" {
+		return fmt.Errorf("%w: the datasource has no database", utils.ErrValidation)
+	}
+
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Reading the schema of %s...", database))
+	conn := utils.MySQLConnection{Host: host, Port: port, User: user, Password: password, Database: database}
+	tables, err := utils.DescribeMySQLSchema(clientset, importSchemaNamespace, conn)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return fmt.Errorf("%w: database %s has no tables", utils.ErrValidation, database)
+	}
+
+	selected, err := selectSchemaTables(tables)
+	if err != nil {
+		return err
+	}
+
+	models, relations := schemaModels(selected)
+	answers = setAnswer(answers, "gras-template", utils.DB_MYSQL_MODEL_BASED)
+	answers = setAnswer(answers, "db-type", utils.DB_EXTERNAL)
+	answers = setAnswer(answers, "models", models)
+	if len(relations) > 0 {
+		answers = setAnswer(answers, "relations", relations)
+	}
+
+	data, err := yaml.Marshal(answers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal answers file: %w", err)
+	}
+	header := fmt.Sprintf("# Models imported from the schema of %s by 'grapple resource templates import-schema' at %s\n",
+		database, time.Now().UTC().Format(time.RFC3339))
+	if err := os.WriteFile(importSchemaFile, append([]byte(header), data...), 0644); err != nil {
+		return fmt.Errorf("failed to write answers file: %w", err)
+	}
+	utils.SuccessMessage(fmt.Sprintf("%d models and %d relations written to %s", len(models), len(relations), importSchemaFile))
+	return nil
+}
+
+// readAnswersFile reads an existing answers file keeping the order of its answers, a missing file is empty
+func readAnswersFile(path string) (yaml.MapSlice, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answers file: %w", err)
+	}
+	var answers yaml.MapSlice
+	if err := yaml.Unmarshal(data, &answers); err != nil {
+		return nil, fmt.Errorf("%w: %s is not an answers file: %w", utils.ErrValidation, path, err)
+	}
+	for _, item := range answers {
+		if fmt.Sprint(item.Key) == "kind" {
+			return nil, fmt.Errorf("%w: %s is a manifest, not an answers file", utils.ErrValidation, path)
+		}
+	}
+	return answers, nil
+}
+
+// setAnswer replaces the answer key, or appends it
+func setAnswer(answers yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i := range answers {
+		if fmt.Sprint(answers[i].Key) == key {
+			answers[i].Value = value
+			return answers
+		}
+	}
+	return append(answers, yaml.MapItem{Key: key, Value: value})
+}
+
+// selectSchemaTables returns the tables of --tables, or the ones entered at the prompt
+func selectSchemaTables(tables []utils.MySQLTable) ([]utils.MySQLTable, error) {
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, table.Name)
+	}
+	wanted := importSchemaTables
+	if len(wanted) == 0 {
+		input, err := utils.PromptInput("Tables to import, comma separated", strings.Join(names, ","), utils.EmptyValueRegex)
+		if err != nil {
+			return nil, err
+		}
+		wanted = strings.Split(input, ",")
+	}
+
+	var selected []utils.MySQLTable
+	for _, name := range wanted {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, table := range tables {
+			if table.Name == name {
+				selected = append(selected, table)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: table %s not found, the database has %s", utils.ErrValidation, name, strings.Join(names, ", "))
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: no tables selected", utils.ErrValidation)
+	}
+	return selected, nil
+}
+
+// schemaModels returns the model of each table and the belongsTo relations of the foreign keys between them, in
+// the format of the models and relations answers. Properties keep the order of the columns.
+func schemaModels(tables []utils.MySQLTable) (yaml.MapSlice, yaml.MapSlice) {
+	imported := map[string]bool{}
+	for _, table := range tables {
+		imported[table.Name] = true
+	}
+
+	var models, relations yaml.MapSlice
+	for _, table := range tables {
+		base := "Model"
+		var properties yaml.MapSlice
+		for _, column := range table.Columns {
+			property := yaml.MapSlice{{Key: "type", Value: mysqlPropertyType(column.Type)}}
+			if column.PrimaryKey {
+				base = "Entity"
+				property = append(property, yaml.MapItem{Key: "id", Value: true})
+			}
+			if column.AutoIncrement {
+				property = append(property, yaml.MapItem{Key: "generated", Value: true})
+			} else if !column.Nullable && !column.HasDefault {
+				property = append(property, yaml.MapItem{Key: "required", Value: true})
+			}
+			properties = append(properties, yaml.MapItem{Key: column.Name, Value: property})
+
+			if column.References != "" && imported[column.References] {
+				relationName := strings.TrimSuffix(strings.TrimSuffix(column.Name, "_id"), "Id")
+				if relationName == "" || relationName == column.Name {
+					relationName = column.References
+				}
+				relations = append(relations, yaml.MapItem{
+					Key: fmt.Sprintf("%s-%s", table.Name, relationName),
+					Value: yaml.MapSlice{
+						{Key: "relationType", Value: "belongsTo"},
+						{Key: "relationName", Value: relationName},
+						{Key: "sourceModel", Value: table.Name},
+						{Key: "destinationModel", Value: column.References},
+						{Key: "foreignKeyName", Value: column.Name},
+					},
+				})
+			}
+		}
+		if base == "Model" {
+			utils.InfoMessage(fmt.Sprintf("Table %s has no primary key, its model is not an Entity", table.Name))
+		}
+		models = append(models, yaml.MapItem{
+			Key:   table.Name,
+			Value: yaml.MapSlice{{Key: "base", Value: base}, {Key: "properties", Value: properties}},
+		})
+	}
+	return models, relations
+}
+
+// mysqlPropertyType maps a MySQL column type, e.g. int unsigned or varchar(255), to the closest model property type
+func mysqlPropertyType(columnType string) string {
+	columnType = strings.ToLower(columnType)
+	if strings.HasPrefix(columnType, "tinyint(1)") || columnType == "bit(1)" || columnType == "boolean" {
+		return "boolean"
+	}
+	dataType := columnType
+	if i := strings.IndexAny(dataType, "( "); i >= 0 {
+		dataType = dataType[:i]
+	}
+	switch dataType {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year":
+		return "integer"
+	case "decimal", "numeric", "float", "double", "real":
+		return "float"
+	case "date", "datetime", "timestamp":
+		return "date"
+	case "json":
+		return "object"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit":
+		return "buffer"
+	case "point":
+		return "geopoint"
+	case "geometry", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		return "any"
+	}
+	return "string"
+}
@@ -0,0 +1,242 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	dbEngineMySQL    = "mysql"
+	dbEngineMariaDB  = "mariadb"
+	dbEnginePostgres = "postgres"
+	dbEngineRedis    = "redis"
+)
+
+var (
+	// dbEngine, dbVersion, dbReplicas, dbStorageClass and dbStorageSize shape the KubeBlocks cluster of --db-type internal
+	dbEngine       string
+	dbVersion      string
+	dbReplicas     int
+	dbStorageClass string
+	dbStorageSize  string
+	// showConnection prints the connection of the internal DB once it is ready
+	showConnection bool
+)
+
+// dbEngineNames are the engines of --db-engine, in the order they are offered
+var dbEngineNames = []string{dbEngineMySQL, dbEngineMariaDB, dbEnginePostgres, dbEngineRedis}
+
+// kubeBlocksEngine is the KubeBlocks addon an internal DB engine is created from
+type kubeBlocksEngine struct {
+	// ClusterDefinition is the cluster definition of the addon, also the name of the addon
+	ClusterDefinition string
+	Component         string
+	// VersionPrefix prefixes a plain --db-version (8.0.30) to the name of the cluster version (ac-mysql-8.0.30)
+	VersionPrefix  string
+	DefaultVersion string
+	// Connector is the grapi datasource connector of the engine
+	Connector string
+}
+
+var kubeBlocksEngines = map[string]kubeBlocksEngine{
+	dbEngineMySQL:    {ClusterDefinition: "apecloud-mysql", Component: "mysql", VersionPrefix: "ac-mysql-", DefaultVersion: "ac-mysql-8.0.30", Connector: "mysql"},
+	dbEngineMariaDB:  {ClusterDefinition: "mariadb", Component: "mariadb-compdef", VersionPrefix: "mariadb-", DefaultVersion: "mariadb-10.6.15", Connector: "mysql"},
+	dbEnginePostgres: {ClusterDefinition: "postgresql", Component: "postgresql", VersionPrefix: "postgresql-", DefaultVersion: "postgresql-14.8.0", Connector: "postgresql"},
+	dbEngineRedis:    {ClusterDefinition: "redis", Component: "redis", VersionPrefix: "redis-", DefaultVersion: "redis-7.0.6", Connector: "kv-redis"},
+}
+
+var (
+	clusterDefinitionGVR = schema.GroupVersionResource{Group: "apps.kubeblocks.io", Version: "v1alpha1", Resource: "clusterdefinitions"}
+	clusterVersionGVR    = schema.GroupVersionResource{Group: "apps.kubeblocks.io", Version: "v1alpha1", Resource: "clusterversions"}
+	kubeBlocksClusterGVR = schema.GroupVersionResource{Group: "apps.kubeblocks.io", Version: "v1alpha1", Resource: "clusters"}
+)
+
+// selectedDBEngine returns the engine of --db-engine, mysql by default
+func selectedDBEngine() kubeBlocksEngine {
+	if dbEngine == "" {
+		return kubeBlocksEngines[dbEngineMySQL]
+	}
+	return kubeBlocksEngines[dbEngine]
+}
+
+// validateInternalDBInputs checks the --db-* flags of the internal DB, they only apply with --db-type internal
+func validateInternalDBInputs(cmd *cobra.Command) error {
+	if DBType != utils.DB_INTERNAL {
+		for _, name := range []string{"db-engine", "db-version", "db-replicas", "db-storage-class", "db-storage-size"} {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("%w: --%s only applies to --db-type %s", utils.ErrValidation, name, utils.DB_INTERNAL)
+			}
+		}
+		return nil
+	}
+
+	if dbEngine == "" {
+		dbEngine = dbEngineMySQL
+	}
+	if _, ok := kubeBlocksEngines[dbEngine]; !ok {
+		return fmt.Errorf("%w: invalid --db-engine %q, use one of %s", utils.ErrValidation, dbEngine, strings.Join(dbEngineNames, ", "))
+	}
+	if dbReplicas < 1 {
+		return fmt.Errorf("%w: --db-replicas must be at least 1", utils.ErrValidation)
+	}
+	if _, err := resource.ParseQuantity(dbStorageSize); err != nil {
+		return fmt.Errorf("%w: invalid --db-storage-size %q, e.g. 20Gi", utils.ErrValidation, dbStorageSize)
+	}
+	if dbEngine == dbEngineRedis {
+		// Redis holds key-value models only, there are no tables to discover or SQL dumps to load
+		if GRASTemplate == utils.DB_MYSQL_DISCOVERY_BASED {
+			return fmt.Errorf("%w: --db-engine redis can't be used with the %s template", utils.ErrValidation, utils.DB_MYSQL_DISCOVERY_BASED)
+		}
+		if SourceData != "" {
+			return fmt.Errorf("%w: --source-data can't be loaded into --db-engine redis", utils.ErrValidation)
+		}
+	}
+	return nil
+}
+
+// resolveDBVersion returns the cluster version of the internal DB and checks that the KubeBlocks addon of the
+// engine is enabled and offers the version. Without access to the cluster definitions it is taken as is.
+func resolveDBVersion(dynamicClient dynamic.Interface) (string, error) {
+	engine := selectedDBEngine()
+	version := engine.DefaultVersion
+	if dbVersion != "" {
+		version = dbVersion
+		if !strings.HasPrefix(version, engine.VersionPrefix) {
+			version = engine.VersionPrefix + version
+		}
+	}
+
+	ctx := context.Background()
+	if _, err := dynamicClient.Resource(clusterDefinitionGVR).Get(ctx, engine.ClusterDefinition, v1.GetOptions{}); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "", fmt.Errorf("the KubeBlocks addon %s of --db-engine %s is not enabled, enable it with 'kbcli addon enable %s'",
+				engine.ClusterDefinition, dbEngine, engine.ClusterDefinition)
+		}
+		utils.DebugMessage(fmt.Sprintf("Failed to get cluster definition %s, using version %s unchecked: %v", engine.ClusterDefinition, version, err))
+		return version, nil
+	}
+
+	versions, err := dynamicClient.Resource(clusterVersionGVR).List(ctx, v1.ListOptions{
+		LabelSelector: "clusterdefinition.kubeblocks.io/name=" + engine.ClusterDefinition,
+	})
+	if err != nil || len(versions.Items) == 0 {
+		return version, nil
+	}
+	var available []string
+	for _, item := range versions.Items {
+		if item.GetName() == version {
+			return version, nil
+		}
+		available = append(available, strings.TrimPrefix(item.GetName(), engine.VersionPrefix))
+	}
+	sort.Strings(available)
+	return "", fmt.Errorf("%w: %s version %s is not available, use one of %s", utils.ErrValidation, dbEngine,
+		strings.TrimPrefix(version, engine.VersionPrefix), strings.Join(available, ", "))
+}
+
+// applyDBEngine sets the cluster definition, version, replicas and storage of the internal DB in the KubeBlocks
+// cluster of files/db.yaml
+func applyDBEngine(cluster *unstructured.Unstructured, version string) error {
+	engine := selectedDBEngine()
+	if err := unstructured.SetNestedField(cluster.Object, engine.ClusterDefinition, "spec", "clusterDefinitionRef"); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(cluster.Object, version, "spec", "clusterVersionRef"); err != nil {
+		return err
+	}
+
+	components, _, err := unstructured.NestedSlice(cluster.Object, "spec", "componentSpecs")
+	if err != nil || len(components) == 0 {
+		return fmt.Errorf("the KubeBlocks template has no componentSpecs")
+	}
+	component, ok := components[0].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("the KubeBlocks template has an invalid componentSpec")
+	}
+	component["componentDefRef"] = engine.Component
+	component["name"] = dbEngine
+	component["replicas"] = int64(dbReplicas)
+
+	claims, _, _ := unstructured.NestedSlice(component, "volumeClaimTemplates")
+	for i := range claims {
+		claim, ok := claims[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if err := unstructured.SetNestedField(claim, dbStorageSize, "spec", "resources", "requests", "storage"); err != nil {
+			return err
+		}
+		if dbStorageClass != "" {
+			if err := unstructured.SetNestedField(claim, dbStorageClass, "spec", "storageClassName"); err != nil {
+				return err
+			}
+		}
+		claims[i] = claim
+	}
+	if len(claims) > 0 {
+		component["volumeClaimTemplates"] = claims
+	}
+	components[0] = component
+	return unstructured.SetNestedSlice(cluster.Object, components, "spec", "componentSpecs")
+}
+
+// internalDatasourceSpec is the datasource of the internal DB, the credentials come from the conn-credential
+// secret KubeBlocks creates for the cluster
+func internalDatasourceSpec() map[string]interface{} {
+	engine := selectedDBEngine()
+	spec := map[string]interface{}{
+		"name":     DatabaseSchema,
+		"host":     "$(host)",
+		"port":     "$(port)",
+		"user":     "$(username)",
+		"password": "$(password)",
+	}
+	if dbEngine != dbEngineRedis {
+		spec["database"] = DatabaseSchema
+	}
+	return map[string]interface{}{engine.Connector: spec}
+}
+
+// internalDBInitContainers returns the init containers of an internal DB that isn't MySQL compatible, they create
+// the database and load the source data into it. ok is false for MySQL and MariaDB, which use the mysql init container.
+func internalDBInitContainers() (containers []NamedSpec, ok bool) {
+	if DBType != utils.DB_INTERNAL {
+		return nil, false
+	}
+	switch dbEngine {
+	case dbEngineRedis:
+		return nil, true
+	case dbEnginePostgres:
+		// The alpine image has wget, the source data is downloaded with it
+		psql := "psql -h $(host) -p $(port) -U $(username)"
+		script := fmt.Sprintf("export PGPASSWORD=$(password); sleep 5; while ! pg_isready -h $(host) -p $(port) -U $(username) 2>/dev/null; do echo -n .; sleep 2; done; "+
+			"%s -d postgres -tc \"SELECT 1 FROM pg_database WHERE datname = '%s'\" | grep -q 1 || %s -d postgres -c \"CREATE DATABASE %s\";",
+			psql, DatabaseSchema, psql, DatabaseSchema)
+		if SourceData != "" {
+			script += fmt.Sprintf(" if %s -d %s -tc \"SELECT 1 FROM information_schema.tables WHERE table_schema = 'public' LIMIT 1\" | grep -q 1; then echo \"database already exists...\"; else wget -O /tmp/%s.sql %s; %s -d %s -f /tmp/%s.sql; fi;",
+				psql, DatabaseSchema, DatabaseSchema, SourceData, psql, DatabaseSchema, DatabaseSchema)
+		}
+		return []NamedSpec{
+			{
+				Name: "init-db",
+				Spec: map[string]interface{}{
+					"name":    "init-db",
+					"image":   utils.MirrorImage("postgres:16-alpine"),
+					"command": []string{"sh", "-c", script},
+				},
+			},
+		}, true
+	}
+	return nil, false
+}
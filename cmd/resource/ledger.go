@@ -0,0 +1,119 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// createdObject is an object created by the current invocation, it knows how to delete itself again
+type createdObject struct {
+	kind      string
+	namespace string
+	name      string
+	remove    func() error
+}
+
+// createdObjects is the ledger of everything the current invocation created, in creation order
+var createdObjects []createdObject
+
+func recordCreated(kind, namespace, name string, remove func() error) {
+	createdObjects = append(createdObjects, createdObject{kind: kind, namespace: namespace, name: name, remove: remove})
+}
+
+func recordCreatedNamespace(name string) {
+	recordCreated("Namespace", "", name, func() error {
+		return clientset.CoreV1().Namespaces().Delete(context.TODO(), name, v1.DeleteOptions{})
+	})
+}
+
+func recordCreatedSecret(namespace, name string) {
+	recordCreated("Secret", namespace, name, func() error {
+		return clientset.CoreV1().Secrets(namespace).Delete(context.TODO(), name, v1.DeleteOptions{})
+	})
+}
+
+func recordCreatedResource(gvr schema.GroupVersionResource, kind, namespace, name string) {
+	recordCreated(kind, namespace, name, func() error {
+		dynamicClient, err := dynamic.NewForConfig(restConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create dynamic client: %w", err)
+		}
+		return dynamicClient.Resource(gvr).Namespace(namespace).Delete(context.TODO(), name, v1.DeleteOptions{})
+	})
+}
+
+func recordCreatedRelease(namespace, name string) {
+	recordCreated("HelmRelease", namespace, name, func() error {
+		settings := newHelmSettings(namespace)
+		actionConfig := new(action.Configuration)
+		if err := utils.InitHelmActionConfig(actionConfig, settings.RESTClientGetter(), namespace); err != nil {
+			return fmt.Errorf("failed to initialize helm action configuration: %w", err)
+		}
+		_, err := action.NewUninstall(actionConfig).Run(name)
+		if err == driver.ErrReleaseNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+// cleanupAfterFailure rolls back the objects created by a failed deploy, automatically with
+// --cleanup-on-failure or after confirmation otherwise. An interrupted deploy is not prompted, the objects
+// are listed so the deploy can be re-run.
+func cleanupAfterFailure(deployErr error) {
+	if len(createdObjects) == 0 {
+		return
+	}
+
+	if utils.IsCanceled(deployErr) {
+		utils.ErrorMessage("The deployment was interrupted, the following objects were created by this run:")
+	} else {
+		utils.ErrorMessage("The deployment failed, the following objects were created by this run:")
+	}
+	for _, obj := range createdObjects {
+		utils.ErrorMessage(fmt.Sprintf("  %s %s", obj.kind, objectRef(obj)))
+	}
+
+	if utils.IsCanceled(deployErr) && !cleanupOnFailure {
+		utils.InfoMessage("Leaving the objects in place, re-run the deploy to continue or re-run with --cleanup-on-failure to remove them")
+		return
+	}
+	if !cleanupOnFailure {
+		confirmed, err := utils.PromptConfirm("Do you want to remove them again?")
+		if err != nil || !confirmed {
+			utils.InfoMessage("Leaving the objects in place, re-run with --cleanup-on-failure to remove them automatically")
+			return
+		}
+	}
+
+	rollbackCreated()
+}
+
+// rollbackCreated deletes the recorded objects in reverse creation order
+func rollbackCreated() {
+	for i := len(createdObjects) - 1; i >= 0; i-- {
+		obj := createdObjects[i]
+		utils.InfoMessage(fmt.Sprintf("Removing %s %s...", obj.kind, objectRef(obj)))
+		if err := obj.remove(); err != nil && !k8serrors.IsNotFound(err) {
+			utils.ErrorMessage(fmt.Sprintf("Failed to remove %s %s: %v", obj.kind, objectRef(obj), err))
+			continue
+		}
+		utils.SuccessMessage(fmt.Sprintf("Removed %s %s", obj.kind, objectRef(obj)))
+	}
+	createdObjects = nil
+}
+
+func objectRef(obj createdObject) string {
+	if obj.namespace == "" {
+		return obj.name
+	}
+	return obj.namespace + "/" + obj.name
+}
@@ -0,0 +1,200 @@
+package resource
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	logsComponent string
+	logsFollow    bool
+	logsSince     time.Duration
+	logsTail      int64
+)
+
+// logComponents are the components of a GRAS, init-db is the init container of the grapi pods
+var logComponents = []string{"grapi", "gruim", "init-db"}
+
+// logPrefixColors tell the pods apart when their lines are interleaved
+var logPrefixColors = []string{"\033[36m", "\033[35m", "\033[34m", "\033[32m", "\033[33m", "\033[96m", "\033[95m"}
+
+// LogsCmd represents the resource logs command
+var LogsCmd = &cobra.Command{
+	Use:   "logs [gras-name]",
+	Short: "Show the logs of the grapi and gruim pods of a GRAS",
+	Long: `Logs shows the logs of the pods of a GrappleApplicationSet, every line is prefixed with the pod and
+container it comes from. The logs of all pods are interleaved as they arrive, like stern does.
+
+Without a GRAS name, the GRAS is selected among the GRAS resources of --namespace (or of the cluster).
+By default the logs of grapi and of all GRUIMs are shown, --component limits them to grapi, gruim or
+init-db (the init container creating and loading the database).
+
+Example:
+  grapple resource logs my-app --namespace my-app
+  grapple resource logs my-app --component grapi --follow --since 10m`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLogs,
+}
+
+func init() {
+	LogsCmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace of the GRAS resource")
+	LogsCmd.Flags().StringVar(&logsComponent, "component", "", "Only show the logs of this component: grapi, gruim or init-db")
+	LogsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep streaming new log lines")
+	LogsCmd.Flags().DurationVar(&logsSince, "since", 0, "Only show lines newer than this duration, e.g. 10m (default: all)")
+	LogsCmd.Flags().Int64Var(&logsTail, "tail", -1, "Number of recent lines to show per container (default: all)")
+}
+
+// logSource is a single container whose logs are streamed
+type logSource struct {
+	pod       string
+	container string
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	if logsComponent != "" && !utils.Contains(logComponents, logsComponent) {
+		return fmt.Errorf("%w: invalid --component %q, use one of grapi, gruim or init-db", utils.ErrValidation, logsComponent)
+	}
+	if len(args) == 1 {
+		GRASName = args[0]
+	}
+
+	var err error
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if err := resolveGrasName(); err != nil {
+		return err
+	}
+
+	sources, err := collectLogSources()
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no pods found for GRAS %s in namespace %s", GRASName, KubeNS)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		out = bufio.NewWriter(os.Stdout)
+	)
+	for i, source := range sources {
+		wg.Add(1)
+		go func(source logSource, color string) {
+			defer wg.Done()
+			prefix := fmt.Sprintf("%s%s %s%s ", color, source.pod, source.container, utils.ColorReset)
+			if err := streamLogs(ctx, source, func(line string) {
+				mu.Lock()
+				defer mu.Unlock()
+				fmt.Fprintf(out, "%s%s\n", prefix, line)
+				out.Flush()
+			}); err != nil {
+				utils.ErrorMessage(fmt.Sprintf("Failed to stream logs of %s/%s: %v", source.pod, source.container, err))
+			}
+		}(source, logPrefixColors[i%len(logPrefixColors)])
+	}
+	wg.Wait()
+	return nil
+}
+
+// collectLogSources lists the containers of the selected components, the pods are found through the
+// selectors of the <gras>-grapi deployment and of the deployments of all GRUIMs of the GRAS
+func collectLogSources() ([]logSource, error) {
+	components := []string{"grapi", "gruim"}
+	if logsComponent != "" {
+		components = []string{logsComponent}
+	}
+
+	var sources []logSource
+	for _, component := range components {
+		deploymentNames := []string{fmt.Sprintf("%s-grapi", GRASName)}
+		if component == "gruim" {
+			deploymentNames = gruimDeployments()
+		}
+		for _, deploymentName := range deploymentNames {
+			componentSources, err := collectDeploymentLogSources(component, deploymentName)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, componentSources...)
+		}
+	}
+	return sources, nil
+}
+
+// collectDeploymentLogSources lists the containers of the pods of a deployment, the init-db init container
+// for the init-db component
+func collectDeploymentLogSources(component, deploymentName string) ([]logSource, error) {
+	deployment, err := clientset.AppsV1().Deployments(KubeNS).Get(context.TODO(), deploymentName, v1.GetOptions{})
+	if err != nil {
+		if logsComponent == "" && component == "gruim" {
+			// GRUIM is optional
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get deployment %s: %w", deploymentName, err)
+	}
+	selector, err := v1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse selector of %s: %w", deploymentName, err)
+	}
+	pods, err := clientset.CoreV1().Pods(KubeNS).List(context.TODO(), v1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of %s: %w", deploymentName, err)
+	}
+
+	var sources []logSource
+	initContainers := component == "init-db"
+	for _, pod := range pods.Items {
+		containers := pod.Spec.Containers
+		if initContainers {
+			containers = pod.Spec.InitContainers
+		}
+		for _, container := range containers {
+			if initContainers && container.Name != "init-db" {
+				continue
+			}
+			sources = append(sources, logSource{pod: pod.Name, container: container.Name})
+		}
+	}
+	return sources, nil
+}
+
+// streamLogs calls emit for every log line of the container until the stream ends
+func streamLogs(ctx context.Context, source logSource, emit func(string)) error {
+	options := &corev1.PodLogOptions{Container: source.container, Follow: logsFollow}
+	if logsSince > 0 {
+		seconds := int64(logsSince.Seconds())
+		options.SinceSeconds = &seconds
+	}
+	if logsTail >= 0 {
+		options.TailLines = &logsTail
+	}
+
+	stream, err := clientset.CoreV1().Pods(KubeNS).GetLogs(source.pod, options).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		emit(scanner.Text())
+	}
+	return scanner.Err()
+}
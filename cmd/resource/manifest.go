@@ -0,0 +1,182 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	exportFile string
+	// importFile is the GrappleApplicationSet manifest deployed by 'grapple resource template import'
+	importFile string
+)
+
+// runtimeMetadataPrefixes are the labels and annotations set by helm, kubectl or the CLI at runtime, they are
+// left out of exported manifests
+var runtimeMetadataPrefixes = []string{
+	"meta.helm.sh/",
+	"helm.sh/",
+	"kubectl.kubernetes.io/",
+	"app.kubernetes.io/managed-by",
+	"grpl.io/rediscovered-at",
+	"grpl.io/promoted-revision",
+}
+
+// TemplateExportCmd represents the resource template export command
+var TemplateExportCmd = &cobra.Command{
+	Use:   "export [gras-name]",
+	Short: "Export the spec of a deployed GRAS into a clean GrappleApplicationSet manifest",
+	Long: `Export writes the effective spec of a deployed GrappleApplicationSet to a YAML manifest that can be checked
+into Git, e.g. after deploying it interactively. Runtime fields (status, uid, resourceVersion, managed fields,
+the labels and annotations of helm and kubectl) are left out.
+
+The manifest is deployed again with 'grapple resource template import' or 'grapple resource deploy --git'.
+Credentials are not part of it: the GRAS references the secrets of its namespace, they have to exist where the
+manifest is imported.
+
+Without a GRAS name, the GRAS is selected among the GRAS resources of --namespace (or of the cluster).
+
+Example:
+  grapple resource template export shop --namespace shop
+  grapple resource template export shop --namespace shop --file apps/shop/gras.yaml
+  grapple resource template export shop --namespace shop --file - > gras.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTemplateExport,
+}
+
+// TemplateImportCmd represents the resource template import command
+var TemplateImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Deploy a GRAS from a GrappleApplicationSet manifest",
+	Long: `Import deploys a GrappleApplicationSet manifest, as written by 'grapple resource template export' or
+'grapple resource render', the way 'grapple resource deploy' deploys it from Git. The name and namespace of
+the manifest are used unless --gras-name or --namespace are set.
+
+Example:
+  grapple resource template import gras.yaml
+  grapple resource template import gras.yaml --namespace shop-staging`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		importFile = args[0]
+		return runDeploy(cmd, args)
+	},
+}
+
+func init() {
+	TemplateExportCmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace of the GRAS resource")
+	TemplateExportCmd.Flags().StringVar(&exportFile, "file", "", "File the manifest is written to, - for stdout (default: <gras-name>.yaml)")
+
+	TemplateImportCmd.Flags().StringVar(&GRASName, "gras-name", "", "Name of the GRAS resource (default: the name of the manifest)")
+	TemplateImportCmd.Flags().StringVar(&KubeNS, "namespace", "", "Kubernetes namespace to use (default: the namespace of the manifest)")
+	TemplateImportCmd.Flags().StringToStringVar(&Labels, "labels", map[string]string{}, "Labels to add to all generated resources (e.g: --labels=team=platform,cost-center=1234)")
+	TemplateImportCmd.Flags().StringToStringVar(&Annotations, "annotations", map[string]string{}, "Annotations to add to all generated resources (e.g: --annotations=owner=platform)")
+	TemplateImportCmd.Flags().BoolVar(&cleanupOnFailure, "cleanup-on-failure", false, "Remove the objects created by this run if the deployment fails, without asking")
+	TemplateImportCmd.Flags().BoolVar(&utils.SkipPreflight, "skip-preflight", false, "Skip the preflight checks of the cluster, see 'grapple preflight --deploy'")
+	TemplateImportCmd.Flags().BoolVar(&forceDeploy, "force", false, "Redeploy the GRAS even if the deployed release has the same chart version and values")
+	TemplateImportCmd.Flags().StringArrayVar(&setFiles, "set-file", nil, "Set a value from a file, stored in the <gras-name>-files secret and referenced as $(key) (repeatable)")
+}
+
+func runTemplateExport(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		GRASName = args[0]
+	}
+
+	var err error
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if err := resolveGrasName(); err != nil {
+		return err
+	}
+
+	gras, err := utils.GetGras(restConfig, KubeNS, GRASName)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(exportableManifest(gras))
+	if err != nil {
+		return fmt.Errorf("failed to marshal gras manifest: %w", err)
+	}
+	header := fmt.Sprintf("# Exported from %s/%s by 'grapple resource template export' at %s\n", KubeNS, GRASName, time.Now().UTC().Format(time.RFC3339))
+	data = append([]byte(header), data...)
+
+	if exportFile == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if exportFile == "" {
+		exportFile = GRASName + ".yaml"
+	}
+	if err := os.WriteFile(exportFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write gras manifest: %w", err)
+	}
+	utils.SuccessMessage(fmt.Sprintf("GRAS manifest written to %s", exportFile))
+	return nil
+}
+
+// exportableManifest returns the GRAS without its runtime fields: only apiVersion, kind, name, namespace, the
+// labels and annotations set by the user and the spec are kept
+func exportableManifest(gras *unstructured.Unstructured) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"name":      gras.GetName(),
+		"namespace": gras.GetNamespace(),
+	}
+	if labels := userMetadata(gras.GetLabels()); len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+	if annotations := userMetadata(gras.GetAnnotations()); len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+
+	spec, _, _ := unstructured.NestedMap(gras.Object, "spec")
+	return map[string]interface{}{
+		"apiVersion": gras.GetAPIVersion(),
+		"kind":       gras.GetKind(),
+		"metadata":   metadata,
+		"spec":       spec,
+	}
+}
+
+// userMetadata drops the runtime labels or annotations
+func userMetadata(values map[string]string) map[string]string {
+	kept := map[string]string{}
+	for key, value := range values {
+		runtime := false
+		for _, prefix := range runtimeMetadataPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				runtime = true
+				break
+			}
+		}
+		if !runtime {
+			kept[key] = value
+		}
+	}
+	return kept
+}
+
+// loadManifestFile reads a GrappleApplicationSet manifest and returns the template deployed for it
+func loadManifestFile(cmd *cobra.Command, path string) (*GrasTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest grasManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse manifest %s: %w", utils.ErrValidation, path, err)
+	}
+	if manifest.Kind != "GrappleApplicationSet" {
+		return nil, fmt.Errorf("%w: %s is not a GrappleApplicationSet manifest", utils.ErrValidation, path)
+	}
+	utils.InfoMessage(fmt.Sprintf("Using manifest %s", path))
+	return templateFromManifest(cmd, &manifest)
+}
@@ -0,0 +1,416 @@
+package resource
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	modelActionAdd     = "Add model"
+	modelActionList    = "List models"
+	modelActionEdit    = "Edit model"
+	modelActionDelete  = "Delete model"
+	modelActionPreview = "Preview YAML"
+	modelActionDone    = "Done"
+
+	modelEditRename         = "Rename"
+	modelEditBaseClass      = "Change base class"
+	modelEditAddProperty    = "Add property"
+	modelEditEditProperty   = "Edit property"
+	modelEditDeleteProperty = "Delete property"
+	modelEditBack           = "Back"
+)
+
+var propertyTypes = []string{"string", "integer", "boolean", "float", "array", "object", "date", "buffer", "geopoint", "any"}
+
+// takeModelInputFromCLI lets the user add, review, edit and delete the models of the template before the
+// deploy continues. The models are kept in tmpl, nothing is written until the template is saved.
+func takeModelInputFromCLI(tmpl *GrasTemplate) error {
+	if len(tmpl.Grapi.Models) == 0 {
+		if err := addModelFromCLI(tmpl); err != nil {
+			return err
+		}
+	}
+
+	for {
+		actions := []string{modelActionAdd}
+		if len(tmpl.Grapi.Models) > 0 {
+			actions = append(actions, modelActionList, modelActionEdit, modelActionDelete, modelActionPreview)
+		}
+		actions = append(actions, modelActionDone)
+
+		action, err := utils.PromptSelect(fmt.Sprintf("Models (%d), what next?", len(tmpl.Grapi.Models)), actions)
+		if err != nil {
+			return err
+		}
+
+		switch action {
+		case modelActionAdd:
+			err = addModelFromCLI(tmpl)
+		case modelActionList:
+			listModels(tmpl)
+		case modelActionEdit:
+			var i int
+			if i, err = selectModel(tmpl, "Select model to edit"); err == nil {
+				err = editModelFromCLI(tmpl, i)
+			}
+		case modelActionDelete:
+			var i int
+			if i, err = selectModel(tmpl, "Select model to delete"); err == nil {
+				err = deleteModel(tmpl, i)
+			}
+		case modelActionPreview:
+			err = previewModels(tmpl)
+		case modelActionDone:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// addModelFromCLI prompts for a new model, an empty name cancels
+func addModelFromCLI(tmpl *GrasTemplate) error {
+	modelName, err := promptModelName(tmpl, "Enter model name (or leave empty to finish)", -1)
+	if err != nil || modelName == "" {
+		return err
+	}
+
+	// Prompt for base class
+	baseClass, err := utils.PromptSelect("Select model base class", []string{"Entity", "Model"})
+	if err != nil {
+		return err
+	}
+
+	// Prompt for properties
+	properties := make(map[string]interface{})
+	for {
+		promptMsg := "Enter property name (or leave empty to finish)"
+		if len(properties) == 0 {
+			promptMsg = "Enter property name (at least one property is required)"
+		}
+
+		propName, err := promptPropertyName(properties, promptMsg)
+		if err != nil {
+			return err
+		}
+		if propName == "" && len(properties) == 0 {
+			utils.InfoMessage("At least one property is required")
+			continue
+		}
+		if propName == "" {
+			break // Exit property loop if empty property name and we have properties
+		}
+
+		propSpec, err := promptProperty(propName, !hasIDProperty(properties))
+		if err != nil {
+			return err
+		}
+		properties[propName] = propSpec
+	}
+
+	tmpl.Grapi.Models = append(tmpl.Grapi.Models, NamedSpec{
+		Name: modelName,
+		Spec: map[string]interface{}{
+			"base":       baseClass,
+			"properties": properties,
+		},
+	})
+	utils.SuccessMessage(fmt.Sprintf("Model %s added", modelName))
+	return nil
+}
+
+// editModelFromCLI edits the i-th model until the user goes back
+func editModelFromCLI(tmpl *GrasTemplate, i int) error {
+	for {
+		model := &tmpl.Grapi.Models[i]
+		properties := modelProperties(model)
+
+		action, err := utils.PromptSelect(fmt.Sprintf("Edit model %s", model.Name), []string{
+			modelEditRename, modelEditBaseClass, modelEditAddProperty, modelEditEditProperty, modelEditDeleteProperty, modelEditBack,
+		})
+		if err != nil {
+			return err
+		}
+
+		switch action {
+		case modelEditRename:
+			name, err := promptModelName(tmpl, fmt.Sprintf("Enter new name of %s (or leave empty to keep it)", model.Name), i)
+			if err != nil {
+				return err
+			}
+			if name != "" {
+				renameModelInRelations(tmpl, model.Name, name)
+				model.Name = name
+			}
+		case modelEditBaseClass:
+			baseClass, err := utils.PromptSelect("Select model base class", []string{"Entity", "Model"})
+			if err != nil {
+				return err
+			}
+			model.Spec["base"] = baseClass
+		case modelEditAddProperty:
+			propName, err := promptPropertyName(properties, "Enter property name (or leave empty to cancel)")
+			if err != nil {
+				return err
+			}
+			if propName == "" {
+				continue
+			}
+			propSpec, err := promptProperty(propName, !hasIDProperty(properties))
+			if err != nil {
+				return err
+			}
+			properties[propName] = propSpec
+		case modelEditEditProperty:
+			propName, err := selectProperty(properties, "Select property to edit")
+			if err != nil {
+				return err
+			}
+			// The property may stay the ID property, or become it if the model has none
+			wasID := isIDProperty(properties[propName])
+			delete(properties, propName)
+			propSpec, err := promptProperty(propName, wasID || !hasIDProperty(properties))
+			if err != nil {
+				return err
+			}
+			properties[propName] = propSpec
+		case modelEditDeleteProperty:
+			if len(properties) == 1 {
+				utils.InfoMessage("A model needs at least one property, delete the model instead")
+				continue
+			}
+			propName, err := selectProperty(properties, "Select property to delete")
+			if err != nil {
+				return err
+			}
+			delete(properties, propName)
+			utils.InfoMessage(fmt.Sprintf("Property %s deleted", propName))
+		case modelEditBack:
+			return nil
+		}
+	}
+}
+
+// deleteModel removes the i-th model after a confirmation, relations referencing it are removed too
+func deleteModel(tmpl *GrasTemplate, i int) error {
+	name := tmpl.Grapi.Models[i].Name
+	confirmed, err := utils.PromptConfirm(fmt.Sprintf("Delete model %s", name))
+	if err != nil || !confirmed {
+		return err
+	}
+	tmpl.Grapi.Models = append(tmpl.Grapi.Models[:i], tmpl.Grapi.Models[i+1:]...)
+
+	var relations []NamedSpec
+	for _, rel := range tmpl.Grapi.Relations {
+		if rel.Spec["sourceModel"] == name || rel.Spec["destinationModel"] == name {
+			utils.InfoMessage(fmt.Sprintf("Relation %s removed, it references %s", rel.Name, name))
+			continue
+		}
+		relations = append(relations, rel)
+	}
+	tmpl.Grapi.Relations = relations
+	utils.InfoMessage(fmt.Sprintf("Model %s deleted", name))
+	return nil
+}
+
+func listModels(tmpl *GrasTemplate) {
+	for _, model := range tmpl.Grapi.Models {
+		properties := modelProperties(&model)
+		names := make([]string, 0, len(properties))
+		for name, spec := range properties {
+			propSpec, _ := spec.(map[string]interface{})
+			description := fmt.Sprintf("%s (%v", name, propSpec["type"])
+			if isIDProperty(spec) {
+				description += ", id"
+			}
+			names = append(names, description+")")
+		}
+		sort.Strings(names)
+		fmt.Printf("  %s [%v]: %s\n", model.Name, model.Spec["base"], strings.Join(names, ", "))
+	}
+}
+
+func previewModels(tmpl *GrasTemplate) error {
+	data, err := yaml.Marshal(map[string]interface{}{"models": tmpl.Grapi.Models})
+	if err != nil {
+		return fmt.Errorf("failed to marshal models: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// promptModelName asks for a model name that no other model than the i-th has, "" if the user left it empty
+func promptModelName(tmpl *GrasTemplate, message string, i int) (string, error) {
+	for {
+		name, err := utils.PromptInput(message, utils.DefaultValue, utils.EmptyValueRegex)
+		if err != nil {
+			return "", err
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return "", nil
+		}
+		duplicate := false
+		for j, model := range tmpl.Grapi.Models {
+			if j != i && model.Name == name {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			return name, nil
+		}
+		utils.InfoMessage(fmt.Sprintf("Model %s already exists, edit it instead", name))
+	}
+}
+
+func promptPropertyName(properties map[string]interface{}, message string) (string, error) {
+	for {
+		name, err := utils.PromptInput(message, utils.DefaultValue, utils.EmptyValueRegex)
+		if err != nil {
+			return "", err
+		}
+		name = strings.TrimSpace(name)
+		if _, exists := properties[name]; !exists || name == "" {
+			return name, nil
+		}
+		utils.InfoMessage(fmt.Sprintf("Property %s already exists, edit it instead", name))
+	}
+}
+
+// promptProperty asks for the type and the options of a property, offering to make it the ID property if canBeID
+func promptProperty(propName string, canBeID bool) (map[string]interface{}, error) {
+	propType, err := utils.PromptSelect("Select property type", propertyTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	propSpec := map[string]interface{}{
+		"type": propType,
+	}
+
+	// Handle ID field logic
+	if canBeID {
+		isID, err := utils.PromptConfirm(fmt.Sprintf("Is %s the ID property?", propName))
+		if err != nil {
+			return nil, err
+		}
+
+		if isID {
+			isGenerated, err := utils.PromptConfirm(fmt.Sprintf("Is %s generated automatically?", propName))
+			if err != nil {
+				return nil, err
+			}
+
+			propSpec["id"] = true
+			propSpec["required"] = true
+			if isGenerated {
+				propSpec["generated"] = true
+			}
+			return propSpec, nil
+		}
+	}
+
+	// Handle required/default value logic for non-ID fields
+	required, err := utils.PromptConfirm("Is this property required?")
+	if err != nil {
+		return nil, err
+	}
+
+	if required {
+		propSpec["required"] = true
+	} else {
+		defaultValue, err := utils.PromptInput("Default value [leave blank for none]", utils.DefaultValue, utils.EmptyValueRegex)
+		if err != nil {
+			return nil, err
+		}
+		if defaultValue != "" {
+			propSpec["defaultFn"] = defaultValue
+		}
+	}
+
+	return propSpec, nil
+}
+
+func selectModel(tmpl *GrasTemplate, label string) (int, error) {
+	names := make([]string, 0, len(tmpl.Grapi.Models))
+	for _, model := range tmpl.Grapi.Models {
+		names = append(names, model.Name)
+	}
+	name, err := utils.PromptSelect(label, names)
+	if err != nil {
+		return 0, err
+	}
+	for i, model := range tmpl.Grapi.Models {
+		if model.Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("model %s not found", name)
+}
+
+func selectProperty(properties map[string]interface{}, label string) (string, error) {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return utils.PromptSelect(label, names)
+}
+
+// modelProperties returns the properties of a model, creating the map if the model has none
+func modelProperties(model *NamedSpec) map[string]interface{} {
+	if model.Spec == nil {
+		model.Spec = map[string]interface{}{}
+	}
+	if properties, ok := model.Spec["properties"].(map[string]interface{}); ok {
+		return properties
+	}
+
+	// Models read from a template file are decoded with interface{} keys
+	properties := map[string]interface{}{}
+	if raw, ok := model.Spec["properties"].(map[interface{}]interface{}); ok {
+		for name, spec := range raw {
+			if propSpec, ok := spec.(map[interface{}]interface{}); ok {
+				converted := map[string]interface{}{}
+				for k, v := range propSpec {
+					converted[fmt.Sprint(k)] = v
+				}
+				spec = converted
+			}
+			properties[fmt.Sprint(name)] = spec
+		}
+	}
+	model.Spec["properties"] = properties
+	return properties
+}
+
+func isIDProperty(spec interface{}) bool {
+	propSpec, _ := spec.(map[string]interface{})
+	return propSpec["id"] == true
+}
+
+func hasIDProperty(properties map[string]interface{}) bool {
+	for _, spec := range properties {
+		if isIDProperty(spec) {
+			return true
+		}
+	}
+	return false
+}
+
+// renameModelInRelations keeps the relations pointing to a renamed model
+func renameModelInRelations(tmpl *GrasTemplate, oldName, newName string) {
+	for _, rel := range tmpl.Grapi.Relations {
+		for _, key := range []string{"sourceModel", "destinationModel"} {
+			if rel.Spec[key] == oldName {
+				rel.Spec[key] = newName
+			}
+		}
+	}
+}
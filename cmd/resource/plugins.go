@@ -0,0 +1,350 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// templatePluginManifest is the file describing a template plugin inside its directory
+const templatePluginManifest = "template.yaml"
+
+// TemplatePlugin is a GRAS template type added next to the built-in ones, loaded from a directory of the
+// templates directory. Its manifest names the base template and the prompts whose answers are mapped into it.
+type TemplatePlugin struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// Base is the GRAS template the plugin starts from, in the format of template-files/db.yaml, relative to the
+	// plugin directory
+	Base string `yaml:"base" json:"base"`
+	// DBType is the database type used when --db-type is not set: internal, external or "" for none
+	DBType  string                 `yaml:"dbType,omitempty" json:"dbType,omitempty"`
+	Prompts []TemplatePluginPrompt `yaml:"prompts,omitempty" json:"prompts,omitempty"`
+	// Values map a dotted path of the template, e.g. grapi.env.SCHEMA, to a Go template over the answers,
+	// e.g. "{{ .Values.schema }}". The rendered value is parsed as YAML, so numbers and lists keep their type.
+	Values map[string]string `yaml:"values,omitempty" json:"values,omitempty"`
+
+	Dir string `yaml:"-" json:"dir"`
+}
+
+// TemplatePluginPrompt is a question of a plugin, answered by --template-value <name>=<value> or interactively
+type TemplatePluginPrompt struct {
+	Name    string `yaml:"name" json:"name"`
+	Label   string `yaml:"label,omitempty" json:"label,omitempty"`
+	Default string `yaml:"default,omitempty" json:"default,omitempty"`
+	// Pattern is a regular expression the answer must match
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	// Options turn the question into a select
+	Options []string `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+var (
+	// templateValues are the answers of the prompts of a template plugin, --template-value
+	templateValues map[string]string
+	// templatePlugin is the plugin of --gras-template, nil for the built-in templates
+	templatePlugin *TemplatePlugin
+)
+
+// TemplatePluginsDir returns the directory of the template plugins: the templates-dir setting
+// (GRPL_TEMPLATES_DIR), or ~/.config/grpl/templates
+func TemplatePluginsDir() (string, error) {
+	if dir := utils.ConfigValue("templates-dir"); dir != "" {
+		return dir, nil
+	}
+	configPath, err := utils.ConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "templates"), nil
+}
+
+// listTemplatePlugins returns the valid plugins of the templates directory, invalid ones are reported and skipped
+func listTemplatePlugins() ([]*TemplatePlugin, error) {
+	dir, err := TemplatePluginsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory %s: %w", dir, err)
+	}
+
+	var plugins []*TemplatePlugin
+	for _, entry := range entries {
+		// Hidden directories are pulls in progress
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		plugin, err := loadTemplatePlugin(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			utils.InfoMessage(fmt.Sprintf("Skipping template plugin %s: %v", entry.Name(), err))
+			continue
+		}
+		plugins = append(plugins, plugin)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, nil
+}
+
+// loadTemplatePlugin reads and checks the manifest of a plugin directory
+func loadTemplatePlugin(dir string) (*TemplatePlugin, error) {
+	data, err := os.ReadFile(filepath.Join(dir, templatePluginManifest))
+	if err != nil {
+		return nil, err
+	}
+	plugin := &TemplatePlugin{}
+	if err := yaml.UnmarshalStrict(data, plugin); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", templatePluginManifest, err)
+	}
+	plugin.Dir = dir
+	if plugin.Name == "" {
+		plugin.Name = filepath.Base(dir)
+	}
+
+	// The name becomes a directory of the templates directory, the base a file inside the plugin directory
+	if errs := validation.IsDNS1123Label(plugin.Name); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid name %q: %s", plugin.Name, strings.Join(errs, ", "))
+	}
+	switch {
+	case utils.Contains(utils.GrasTemplates, plugin.Name):
+		return nil, fmt.Errorf("%s is a built-in template", plugin.Name)
+	case plugin.Base == "":
+		return nil, fmt.Errorf("no base template set")
+	case !filepath.IsLocal(plugin.Base):
+		return nil, fmt.Errorf("base template %s is outside of the plugin directory", plugin.Base)
+	case plugin.DBType != "" && !utils.Contains(utils.GrasDBType, plugin.DBType):
+		return nil, fmt.Errorf("invalid dbType %q, use one of %s", plugin.DBType, strings.Join(utils.GrasDBType, ", "))
+	}
+	if _, err := os.Stat(plugin.basePath()); err != nil {
+		return nil, fmt.Errorf("base template: %v", err)
+	}
+	for _, q := range plugin.Prompts {
+		if q.Name == "" {
+			return nil, fmt.Errorf("prompt without a name")
+		}
+		if _, err := regexp.Compile(q.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern of prompt %s: %v", q.Name, err)
+		}
+	}
+	for path, value := range plugin.Values {
+		if _, err := template.New(path).Option("missingkey=error").Parse(value); err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %v", path, err)
+		}
+	}
+	return plugin, nil
+}
+
+func (p *TemplatePlugin) basePath() string {
+	return filepath.Join(p.Dir, p.Base)
+}
+
+// findTemplatePlugin returns the plugin of a template name, nil if there is none. An oci:// reference is pulled
+// into the templates directory first.
+func findTemplatePlugin(name string) (*TemplatePlugin, error) {
+	if strings.HasPrefix(name, "oci://") {
+		return pullTemplatePlugin(name, "")
+	}
+	plugins, err := listTemplatePlugins()
+	if err != nil {
+		return nil, err
+	}
+	for _, plugin := range plugins {
+		if plugin.Name == name {
+			return plugin, nil
+		}
+	}
+	return nil, nil
+}
+
+// templateNames returns the built-in templates followed by the plugins
+func templateNames() []string {
+	names := append([]string(nil), utils.GrasTemplates...)
+	plugins, err := listTemplatePlugins()
+	if err != nil {
+		utils.InfoMessage(fmt.Sprintf("Failed to list the template plugins: %v", err))
+	}
+	for _, plugin := range plugins {
+		names = append(names, plugin.Name)
+	}
+	return names
+}
+
+// pullTemplatePlugin pulls a plugin packaged as a helm chart (Chart.yaml next to template.yaml and the base
+// template) from an OCI registry into the templates directory, replacing an installed version
+func pullTemplatePlugin(ref, version string) (*TemplatePlugin, error) {
+	dir, err := TemplatePluginsDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create templates directory %s: %w", dir, err)
+	}
+	regClient, err := utils.NewChartRegistryClient()
+	if err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp(dir, ".pull-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	utils.InfoMessage(fmt.Sprintf("Pulling template plugin %s...", ref))
+	client := action.NewPullWithOpts(action.WithConfig(&action.Configuration{RegistryClient: regClient}))
+	client.Settings = cli.New()
+	client.Version = version
+	client.DestDir = tmpDir
+	client.Untar = true
+	client.UntarDir = tmpDir
+	if _, err := client.Run(ref); err != nil {
+		return nil, fmt.Errorf("%w: failed to pull template plugin %s: %w", utils.ErrChartNotFound, ref, err)
+	}
+
+	// The chart is unpacked into a directory named after the chart
+	var pulled string
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the pulled template plugin: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			pulled = filepath.Join(tmpDir, entry.Name())
+		}
+	}
+	if pulled == "" {
+		return nil, fmt.Errorf("%w: %s is not a template plugin: the chart has no files", utils.ErrValidation, ref)
+	}
+	plugin, err := loadTemplatePlugin(pulled)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s is not a template plugin: %w", utils.ErrValidation, ref, err)
+	}
+
+	target := filepath.Join(dir, plugin.Name)
+	if err := os.RemoveAll(target); err != nil {
+		return nil, fmt.Errorf("failed to remove the installed plugin %s: %w", target, err)
+	}
+	if err := os.Rename(pulled, target); err != nil {
+		return nil, fmt.Errorf("failed to install template plugin into %s: %w", target, err)
+	}
+	plugin.Dir = target
+	utils.SuccessMessage(fmt.Sprintf("Template plugin %s installed in %s", plugin.Name, target))
+	return plugin, nil
+}
+
+// askTemplatePluginValues answers the prompts of the plugin from --template-value or interactively
+func askTemplatePluginValues(plugin *TemplatePlugin) (map[string]string, error) {
+	answers := map[string]string{}
+	for _, q := range plugin.Prompts {
+		pattern := regexp.MustCompile(q.Pattern)
+		if value, ok := templateValues[q.Name]; ok {
+			if err := checkTemplatePluginAnswer(q, pattern, value); err != nil {
+				return nil, err
+			}
+			answers[q.Name] = value
+			continue
+		}
+
+		label := q.Label
+		if label == "" {
+			label = q.Name
+		}
+		question := prompt.Question{Label: label, Default: q.Default, Flag: fmt.Sprintf("--template-value %s=<value>", q.Name)}
+		var value string
+		var err error
+		if len(q.Options) > 0 {
+			value, err = prompt.Select(question, q.Options)
+		} else {
+			question.Validate = func(answer string) error {
+				return checkTemplatePluginAnswer(q, pattern, answer)
+			}
+			value, err = prompt.Input(question)
+		}
+		if err != nil {
+			return nil, err
+		}
+		answers[q.Name] = value
+	}
+
+	for name := range templateValues {
+		if _, ok := answers[name]; !ok {
+			return nil, fmt.Errorf("%w: template %s has no prompt %s", utils.ErrValidation, plugin.Name, name)
+		}
+	}
+	return answers, nil
+}
+
+func checkTemplatePluginAnswer(q TemplatePluginPrompt, pattern *regexp.Regexp, value string) error {
+	if len(q.Options) > 0 && !utils.Contains(q.Options, value) {
+		return fmt.Errorf("%w: %s must be one of %s", utils.ErrValidation, q.Name, strings.Join(q.Options, ", "))
+	}
+	if !pattern.MatchString(value) {
+		return fmt.Errorf("%w: %s must match %s", utils.ErrValidation, q.Name, q.Pattern)
+	}
+	return nil
+}
+
+// applyTemplatePluginValues renders the value mappings of the plugin with the answers and sets them in the template
+func applyTemplatePluginValues(plugin *TemplatePlugin, answers map[string]string, tmpl *GrasTemplate) error {
+	if len(plugin.Values) == 0 {
+		return nil
+	}
+	data, err := tmpl.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal template: %w", err)
+	}
+	obj, err := valuesFromYAML(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	context := map[string]interface{}{"Name": GRASName, "Namespace": KubeNS, "Values": answers}
+	paths := make([]string, 0, len(plugin.Values))
+	for path := range plugin.Values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		var rendered bytes.Buffer
+		t := template.Must(template.New(path).Option("missingkey=error").Parse(plugin.Values[path]))
+		if err := t.Execute(&rendered, context); err != nil {
+			return fmt.Errorf("failed to render %s of template %s: %w", path, plugin.Name, err)
+		}
+		var value interface{}
+		if err := k8syaml.Unmarshal(rendered.Bytes(), &value); err != nil || value == nil {
+			value = rendered.String()
+		}
+		if err := unstructured.SetNestedField(obj, value, strings.Split(path, ".")...); err != nil {
+			return fmt.Errorf("failed to set %s of template %s: %w", path, plugin.Name, err)
+		}
+	}
+
+	data, err = k8syaml.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template: %w", err)
+	}
+	*tmpl = GrasTemplate{}
+	if err := yaml.Unmarshal(data, tmpl); err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	if tmpl.Gras == nil {
+		tmpl.Gras = map[string]interface{}{}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+var (
+	grapiLocalPort   int
+	gruimLocalPort   int
+	forwardAddresses []string
+)
+
+// PortForwardCmd represents the resource port-forward command
+var PortForwardCmd = &cobra.Command{
+	Use:     "port-forward [gras-name]",
+	Aliases: []string{"pf"},
+	Short:   "Forward local ports to the grapi and gruim services of a GRAS",
+	Long: `Port-forward makes grapi and gruim of a GrappleApplicationSet reachable on local ports, without
+an ingress or DNS. The ports are forwarded to a running pod behind each service until Ctrl+C is pressed.
+
+Without a GRAS name, the GRAS is selected among the GRAS resources of --namespace (or of the cluster).
+Set --grapi or --gruim to 0 to skip that component. Additional GRUIMs of the GRAS are forwarded on the
+ports following --gruim.
+
+Example:
+  grapple resource port-forward my-app --grapi 3000 --gruim 8080
+  grapple resource port-forward my-app --namespace my-app --gruim 0`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPortForward,
+}
+
+func init() {
+	PortForwardCmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace of the GRAS resource")
+	PortForwardCmd.Flags().IntVar(&grapiLocalPort, "grapi", 3000, "Local port forwarded to grapi, 0 to skip it")
+	PortForwardCmd.Flags().IntVar(&gruimLocalPort, "gruim", 8080, "Local port forwarded to gruim, 0 to skip it")
+	PortForwardCmd.Flags().StringSliceVar(&forwardAddresses, "address", []string{"localhost"}, "Local addresses to listen on")
+}
+
+func runPortForward(cmd *cobra.Command, args []string) error {
+	if grapiLocalPort == 0 && gruimLocalPort == 0 {
+		return fmt.Errorf("%w: nothing to forward, --grapi and --gruim are both 0", utils.ErrValidation)
+	}
+	if len(args) == 1 {
+		GRASName = args[0]
+	}
+
+	var err error
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if err := resolveGrasName(); err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		close(stopCh)
+	}()
+
+	type component struct {
+		name      string
+		service   string
+		localPort int
+	}
+	components := []component{{"grapi", fmt.Sprintf("%s-grapi", GRASName), grapiLocalPort}}
+	if gruimLocalPort != 0 {
+		// additional GRUIMs are forwarded on the ports following --gruim
+		for i, deployment := range gruimDeployments() {
+			components = append(components, component{deployment, deployment, gruimLocalPort + i})
+		}
+	}
+
+	errCh := make(chan error, len(components))
+	forwards := 0
+	for _, component := range components {
+		if component.localPort == 0 {
+			continue
+		}
+		forwarder, err := newServiceForwarder(component.service, component.localPort, stopCh)
+		if err != nil {
+			if component.name != "grapi" && !cmd.Flags().Changed("gruim") {
+				// GRUIM is optional, only fail if it was asked for explicitly
+				utils.InfoMessage(fmt.Sprintf("Skipping %s: %v", component.name, err))
+				continue
+			}
+			return err
+		}
+		forwards++
+		go func(forwarder *portforward.PortForwarder) {
+			errCh <- forwarder.ForwardPorts()
+		}(forwarder)
+		go func(name string, localPort int, forwarder *portforward.PortForwarder) {
+			select {
+			case <-forwarder.Ready:
+				utils.SuccessMessage(fmt.Sprintf("%s is available at http://localhost:%d", name, localPort))
+			case <-stopCh:
+			}
+		}(component.name, component.localPort, forwarder)
+	}
+	if forwards == 0 {
+		return fmt.Errorf("nothing to forward for GRAS %s", GRASName)
+	}
+	utils.InfoMessage("Press Ctrl+C to stop forwarding")
+
+	for i := 0; i < forwards; i++ {
+		if err := <-errCh; err != nil {
+			select {
+			case <-stopCh:
+			default:
+				close(stopCh)
+			}
+			return fmt.Errorf("port forwarding failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// newServiceForwarder forwards localPort to the first port of the service, on a running pod selected by the service
+func newServiceForwarder(serviceName string, localPort int, stopCh chan struct{}) (*portforward.PortForwarder, error) {
+	service, err := clientset.CoreV1().Services(KubeNS).Get(context.TODO(), serviceName, v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service %s: %w", serviceName, err)
+	}
+	if len(service.Spec.Ports) == 0 || len(service.Spec.Selector) == 0 {
+		return nil, fmt.Errorf("service %s has no ports or no selector", serviceName)
+	}
+
+	pods, err := clientset.CoreV1().Pods(KubeNS).List(context.TODO(), v1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(service.Spec.Selector).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of service %s: %w", serviceName, err)
+	}
+	var pod *corev1.Pod
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning && pods.Items[i].DeletionTimestamp == nil {
+			pod = &pods.Items[i]
+			break
+		}
+	}
+	if pod == nil {
+		return nil, fmt.Errorf("no running pod found for service %s", serviceName)
+	}
+
+	podPort, err := resolveTargetPort(service.Spec.Ports[0], pod)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create port forward transport: %w", err)
+	}
+	url := clientset.CoreV1().RESTClient().Post().Resource("pods").Namespace(KubeNS).Name(pod.Name).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	ports := []string{fmt.Sprintf("%d:%d", localPort, podPort)}
+	return portforward.NewOnAddresses(dialer, forwardAddresses, ports, stopCh, make(chan struct{}), io.Discard, os.Stderr)
+}
+
+// resolveTargetPort returns the container port a service port points to, named target ports are looked up in the pod
+func resolveTargetPort(servicePort corev1.ServicePort, pod *corev1.Pod) (int32, error) {
+	switch servicePort.TargetPort.Type {
+	case intstr.Int:
+		if servicePort.TargetPort.IntVal != 0 {
+			return servicePort.TargetPort.IntVal, nil
+		}
+		return servicePort.Port, nil
+	default:
+		for _, container := range pod.Spec.Containers {
+			for _, port := range container.Ports {
+				if port.Name == servicePort.TargetPort.StrVal {
+					return port.ContainerPort, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("port %s not found in pod %s", servicePort.TargetPort.StrVal, pod.Name)
+	}
+}
@@ -0,0 +1,391 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/action"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+var (
+	promoteFromContext string
+	promoteToContext   string
+	promoteToNamespace string
+	promoteToName      string
+	promoteProfileFile string
+)
+
+// promoteProfile is an environment profile, the overrides applied to a GRAS promoted into the environment
+type promoteProfile struct {
+	// Domain replaces the cluster domain of the source in the values (default: domain of the target cluster)
+	Domain string `json:"domain,omitempty"`
+	// DBSecret is a secret of the target namespace with the host, port, username and password of the
+	// datasource, it replaces the <gras-name>-conn-credential secret of the source
+	DBSecret string `json:"dbSecret,omitempty"`
+	// Resources are set as resources of grapi and all GRUIMs
+	Resources   map[string]interface{} `json:"resources,omitempty"`
+	Labels      map[string]string      `json:"labels,omitempty"`
+	Annotations map[string]string      `json:"annotations,omitempty"`
+	// Values are merged into the GRAS values
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// PromoteCmd represents the resource promote command
+var PromoteCmd = &cobra.Command{
+	Use:   "promote [gras-name]",
+	Short: "Promote a GRAS to another namespace or cluster",
+	Long: `Promote copies a deployed GrappleApplicationSet (GRAS) from a source namespace, or cluster with
+--from-context, to the target namespace (--to-namespace) or cluster (--to-context) in one step: the values of
+its helm release are read, the overrides of the environment profile are applied and the GRAS is deployed in
+the target.
+
+The secrets of the GRAS (the <gras-name>-conn-credential secret of the datasource, the <gras-name>-files
+secret of --set-file) are copied to the target. The environment profile (--profile) is a YAML file:
+
+  domain: shop.example.com     # replaces the cluster domain of the source (default: domain of the target cluster)
+  dbSecret: shop-prod-db       # secret of the target namespace with host, port, username and password
+  resources:                   # resources of grapi and all GRUIMs
+    limits: {cpu: "1", memory: 1Gi}
+  labels: {env: prod}
+  annotations: {owner: shop-team}
+  values:                      # merged into the GRAS values
+    grapi:
+      beimagetag: v0.112
+
+The promotion is recorded in the grpl.io/promoted-from and grpl.io/promoted-revision annotations of the
+promoted objects, promoting the same revision again leaves an unchanged target alone.
+
+Example:
+  grapple resource promote shop --namespace shop-dev --to-namespace shop-staging
+  grapple resource promote shop --namespace shop --from-context staging --to-context prod --profile prod.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPromote,
+}
+
+func init() {
+	PromoteCmd.Flags().StringVar(&GRASName, "gras-name", "", "Name of the GRAS resource to promote")
+	PromoteCmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace of the GRAS resource to promote")
+	PromoteCmd.Flags().StringVar(&promoteFromContext, "from-context", "", "Kube-context of the source cluster (default: current context)")
+	PromoteCmd.Flags().StringVar(&promoteToContext, "to-context", "", "Kube-context of the target cluster (default: current context)")
+	PromoteCmd.Flags().StringVar(&promoteToNamespace, "to-namespace", "", "Namespace to promote the GRAS to (default: the source namespace)")
+	PromoteCmd.Flags().StringVar(&promoteToName, "to-name", "", "Name of the promoted GRAS (default: the source name)")
+	PromoteCmd.Flags().StringVar(&promoteProfileFile, "profile", "", "Environment profile with the overrides of the target")
+	PromoteCmd.Flags().BoolVar(&forceDeploy, "force", false, "Redeploy the GRAS even if the target release has the same chart version and values")
+	PromoteCmd.Flags().BoolVar(&cleanupOnFailure, "cleanup-on-failure", false, "Remove the objects created in the target if the promotion fails, without asking")
+}
+
+func runPromote(cmd *cobra.Command, args []string) (promoteErr error) {
+	if len(args) == 1 {
+		GRASName = args[0]
+	}
+	profile, err := loadPromoteProfile(promoteProfileFile)
+	if err != nil {
+		return err
+	}
+
+	sourceConfig, sourceClient, err := kubeClientsForContext(promoteFromContext)
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the source cluster: " + err.Error())
+		return err
+	}
+	restConfig, clientset = sourceConfig, sourceClient
+	if err := resolveGrasName(); err != nil {
+		return err
+	}
+	sourceName, sourceNS := GRASName, KubeNS
+
+	targetName := promoteToName
+	if targetName == "" {
+		targetName = sourceName
+	}
+	if err := utils.ValidateResourceName(targetName); err != nil {
+		return err
+	}
+	targetNS := promoteToNamespace
+	if targetNS == "" {
+		targetNS = sourceNS
+	}
+	if promoteFromContext == promoteToContext && sourceNS == targetNS && sourceName == targetName {
+		return fmt.Errorf("%w: the target is the source GRAS, set --to-namespace, --to-context or --to-name", utils.ErrValidation)
+	}
+
+	logFilePath := utils.GetLogFilePath("grpl_resource_promote.log")
+	logFile, logOnFileStart, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+	defer func() {
+		if err := logFile.Sync(); err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to sync log file: %v", err))
+		}
+		if err := logFile.Close(); err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to close log file: %v", err))
+		}
+		if promoteErr != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to promote resource, please run cat %s for more details", logFilePath))
+		}
+	}()
+	logOnCliAndFileStart()
+
+	utils.InfoMessage(fmt.Sprintf("Reading the release of GRAS %s in namespace %s...", sourceName, sourceNS))
+	helmKubeContext = promoteFromContext
+	vals, revision, err := releaseValues(sourceName, sourceNS)
+	if err != nil {
+		return err
+	}
+	sourceDomain, _ := utils.ExtractDomainFromGrplConfig(sourceConfig)
+
+	// Everything from here on happens in the target
+	targetConfig, targetClient, err := kubeClientsForContext(promoteToContext)
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the target cluster: " + err.Error())
+		return err
+	}
+	restConfig, clientset = targetConfig, targetClient
+	helmKubeContext = promoteToContext
+	GRASName, KubeNS = targetName, targetNS
+
+	createdObjects = nil
+	defer func() {
+		if promoteErr != nil {
+			logOnCliAndFileStart()
+			cleanupAfterFailure(promoteErr)
+		}
+	}()
+
+	annotations := map[string]string{
+		"grpl.io/promoted-from":     promotionRef(promoteFromContext, sourceNS, sourceName),
+		"grpl.io/promoted-revision": strconv.Itoa(revision),
+	}
+	for k, v := range profile.Annotations {
+		annotations[k] = v
+	}
+	utils.SetCommonMetadata(profile.Labels, annotations)
+
+	if err := prepareNamespaceForGrasInstallation(); err != nil {
+		return err
+	}
+
+	targetDomain := profile.Domain
+	if targetDomain == "" {
+		targetDomain, _ = utils.ExtractDomainFromGrplConfig(targetConfig)
+	}
+	if sourceDomain != "" && targetDomain != "" && sourceDomain != targetDomain {
+		utils.InfoMessage(fmt.Sprintf("Replacing domain %s with %s", sourceDomain, targetDomain))
+		vals = replaceInValues(vals, sourceDomain, targetDomain).(map[string]interface{})
+	}
+	if err := promoteSecrets(vals, sourceClient, sourceName, sourceNS, profile.DBSecret); err != nil {
+		return err
+	}
+	if len(profile.Resources) > 0 {
+		setPromotedResources(vals, profile.Resources)
+	}
+	utils.MergeValues(vals, profile.Values)
+
+	data, err := k8syaml.Marshal(vals)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the promoted values: %w", err)
+	}
+	if err := os.WriteFile(templateFileDest, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", templateFileDest, err)
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Deploying GRAS %s in namespace %s...", targetName, targetNS))
+	logOnFileStart()
+	upToDate, err := deployTemplate(templateFileDest, targetName, targetNS)
+	logOnCliAndFileStart()
+	if err != nil {
+		return err
+	}
+	if upToDate {
+		utils.SuccessMessage(fmt.Sprintf("GRAS %s is up to date in namespace %s, nothing to promote (use --force to redeploy it)", targetName, targetNS))
+		return nil
+	}
+	tmpl, err := loadGrasTemplate(templateFileDest)
+	if err != nil {
+		return err
+	}
+	if err := deployAdditionalGruims(tmpl); err != nil {
+		return err
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("Promoted GRAS %s to %s", promotionRef(promoteFromContext, sourceNS, sourceName), promotionRef(promoteToContext, targetNS, targetName)))
+	return nil
+}
+
+// loadPromoteProfile reads the environment profile, an empty path is an empty profile
+func loadPromoteProfile(path string) (*promoteProfile, error) {
+	profile := &promoteProfile{}
+	if path == "" {
+		return profile, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read profile %s: %w", utils.ErrValidation, path, err)
+	}
+	if err := k8syaml.UnmarshalStrict(data, profile); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse profile %s: %w", utils.ErrValidation, path, err)
+	}
+	return profile, nil
+}
+
+// kubeClientsForContext connects to the cluster of a kube-context, the current context if it is empty
+func kubeClientsForContext(kubeContext string) (*rest.Config, *kubernetes.Clientset, error) {
+	if kubeContext == "" {
+		return utils.GetKubernetesConfig()
+	}
+	config, err := utils.KubeContextRESTConfig(kubeContext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load kube-context %s: %w", kubeContext, err)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
+	}
+	if _, err := client.Discovery().ServerVersion(); err != nil {
+		return nil, nil, fmt.Errorf("%w: kube-context %s: %w", utils.ErrClusterUnreachable, kubeContext, err)
+	}
+	return config, client, nil
+}
+
+// releaseValues returns the values and the revision of the helm release of a GRAS
+func releaseValues(name, namespace string) (map[string]interface{}, int, error) {
+	actionConfig := new(action.Configuration)
+	if err := utils.InitHelmActionConfig(actionConfig, newHelmSettings(namespace).RESTClientGetter(), namespace); err != nil {
+		return nil, 0, fmt.Errorf("failed to initialize helm action configuration: %w", err)
+	}
+	rel, err := action.NewGet(actionConfig).Run(name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get the release of GRAS %s in namespace %s: %w", name, namespace, err)
+	}
+	vals := rel.Config
+	if vals == nil {
+		vals = map[string]interface{}{}
+	}
+	return vals, rel.Version, nil
+}
+
+// promoteSecrets copies the extraSecrets of grapi and gruim to the target namespace. Secrets named after the
+// source GRAS are renamed after the target, the credential secret is replaced by dbSecret if it is set.
+func promoteSecrets(vals map[string]interface{}, sourceClient *kubernetes.Clientset, sourceName, sourceNS, dbSecret string) error {
+	credentialSecret := sourceName + "-conn-credential"
+	for _, section := range []string{"grapi", "gruim"} {
+		spec, ok := vals[section].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		secrets, _ := spec["extraSecrets"].([]interface{})
+		for i, entry := range secrets {
+			name, _ := entry.(string)
+			if name == "" {
+				continue
+			}
+			if name == credentialSecret && dbSecret != "" {
+				if _, err := clientset.CoreV1().Secrets(KubeNS).Get(context.TODO(), dbSecret, v1.GetOptions{}); err != nil {
+					return fmt.Errorf("%w: dbSecret %s of the profile not found in namespace %s: %w", utils.ErrValidation, dbSecret, KubeNS, err)
+				}
+				secrets[i] = dbSecret
+				continue
+			}
+
+			source, err := sourceClient.CoreV1().Secrets(sourceNS).Get(context.TODO(), name, v1.GetOptions{})
+			if k8serrors.IsNotFound(err) {
+				utils.InfoMessage(fmt.Sprintf("Warning: secret %s of the GRAS doesn't exist in namespace %s, it is not copied", name, sourceNS))
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get secret %s: %w", name, err)
+			}
+			targetName := name
+			if strings.HasPrefix(name, sourceName+"-") {
+				targetName = GRASName + strings.TrimPrefix(name, sourceName)
+			}
+			if err := copySecret(source, targetName); err != nil {
+				return err
+			}
+			secrets[i] = targetName
+			if name == credentialSecret {
+				utils.InfoMessage(fmt.Sprintf("Warning: the promoted GRAS uses the datasource of %s, set dbSecret in the profile to give it a database of its own", sourceName))
+			}
+		}
+	}
+	return nil
+}
+
+// copySecret creates the secret as name in the target namespace, or replaces its data
+func copySecret(source *corev1.Secret, name string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: KubeNS,
+		},
+		Type: source.Type,
+		Data: source.Data,
+	}
+	utils.ApplyCommonMetadata(&secret.ObjectMeta)
+
+	_, err := clientset.CoreV1().Secrets(KubeNS).Create(context.TODO(), secret, v1.CreateOptions{})
+	if err == nil {
+		recordCreatedSecret(KubeNS, name)
+	}
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().Secrets(KubeNS).Update(context.TODO(), secret, v1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy secret %s: %w", name, err)
+	}
+	utils.InfoMessage(fmt.Sprintf("Copied secret %s to %s/%s", source.Name, KubeNS, name))
+	return nil
+}
+
+// setPromotedResources sets the resources of grapi, the main GRUIM and the additional GRUIMs
+func setPromotedResources(vals map[string]interface{}, resources map[string]interface{}) {
+	for _, section := range []string{"grapi", "gruim"} {
+		if spec, ok := vals[section].(map[string]interface{}); ok {
+			spec["resources"] = resources
+		}
+	}
+	gruims, _ := vals["gruims"].([]interface{})
+	for _, gruim := range gruims {
+		entry, ok := gruim.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if spec, ok := entry["spec"].(map[string]interface{}); ok {
+			spec["resources"] = resources
+		}
+	}
+}
+
+// replaceInValues replaces old with new in all strings of the values
+func replaceInValues(value interface{}, old, new string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return strings.ReplaceAll(v, old, new)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = replaceInValues(item, old, new)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = replaceInValues(item, old, new)
+		}
+	}
+	return value
+}
+
+// promotionRef identifies a GRAS as [context/]namespace/name
+func promotionRef(kubeContext, namespace, name string) string {
+	if kubeContext == "" {
+		return namespace + "/" + name
+	}
+	return kubeContext + "/" + namespace + "/" + name
+}
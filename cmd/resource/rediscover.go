@@ -0,0 +1,209 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// rediscoverAnnotation records when discovery was last re-run, on the GRAS and on the pod template of grapi
+const rediscoverAnnotation = "grpl.io/rediscovered-at"
+
+// rediscoverResult is the outcome of a rediscovery
+type rediscoverResult struct {
+	GRAS        string   `json:"gras" yaml:"gras"`
+	Namespace   string   `json:"namespace" yaml:"namespace"`
+	Discoveries []string `json:"discoveries" yaml:"discoveries"`
+	Models      []string `json:"models" yaml:"models"`
+	Added       []string `json:"added" yaml:"added"`
+	Removed     []string `json:"removed" yaml:"removed"`
+}
+
+// RediscoverCmd represents the resource rediscover command
+var RediscoverCmd = &cobra.Command{
+	Use:   "rediscover [gras-name]",
+	Short: "Re-run the model discovery of a GRAS after a database schema change",
+	Long: `Rediscover re-runs the discoveries of a GrappleApplicationSet deployed from the db-mysql-discovery-based
+template, so tables added to (or removed from) the database schema show up as models without redeploying the
+GRAS from scratch.
+
+The rediscovery is recorded in the grpl.io/rediscovered-at annotation of the GRAS and of the pod template of
+its grapi, which restarts grapi and makes it discover the models again. Once the new grapi is ready, the models
+served by its API are compared with the ones before and the added and removed models are reported (-o json or
+-o yaml for scripts).
+
+Without a GRAS name, the GRAS is selected among the GRAS resources of --namespace (or of the cluster).
+
+Example:
+  grapple resource rediscover shop --namespace shop
+  grapple resource rediscover shop -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRediscover,
+}
+
+func init() {
+	RediscoverCmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace of the GRAS resource")
+}
+
+func runRediscover(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		GRASName = args[0]
+	}
+
+	var err error
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if err := resolveGrasName(); err != nil {
+		return err
+	}
+
+	gras, err := utils.GetGras(restConfig, KubeNS, GRASName)
+	if err != nil {
+		return err
+	}
+	discoveries := grasDiscoveries(gras)
+	if len(discoveries) == 0 {
+		return fmt.Errorf("%w: GRAS %s has no discoveries, rediscover only applies to GRAS resources deployed from the %s template",
+			utils.ErrValidation, GRASName, utils.DB_MYSQL_DISCOVERY_BASED)
+	}
+
+	grapiURL, err := grapiIngressURL()
+	if err != nil {
+		return err
+	}
+	var before []string
+	if spec, err := fetchGrapiOpenAPI(grapiURL); err == nil {
+		before = openAPIModelNames(spec)
+	} else {
+		utils.InfoMessage(fmt.Sprintf("grapi doesn't serve its API right now (%v), all models will be reported as added", err))
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	utils.InfoMessage(fmt.Sprintf("Re-running the discoveries %s of GRAS %s...", strings.Join(discoveries, ", "), GRASName))
+	if err := utils.AnnotateGras(restConfig, KubeNS, GRASName, map[string]string{rediscoverAnnotation: now}); err != nil {
+		return err
+	}
+	deployment := GRASName + "-grapi"
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`, rediscoverAnnotation, now)
+	if _, err := clientset.AppsV1().Deployments(KubeNS).Patch(context.TODO(), deployment, types.StrategicMergePatchType, []byte(patch), v1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to restart deployment %s: %w", deployment, err)
+	}
+	if err := waitForRollout(deployment); err != nil {
+		return err
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Waiting for grapi at %s to serve its API...", grapiURL))
+	spec, err := waitForGrapiOpenAPI(grapiURL)
+	if err != nil {
+		return err
+	}
+	after := openAPIModelNames(spec)
+
+	result := rediscoverResult{
+		GRAS:        GRASName,
+		Namespace:   KubeNS,
+		Discoveries: discoveries,
+		Models:      after,
+		Added:       subtractNames(after, before),
+		Removed:     subtractNames(before, after),
+	}
+	return utils.PrintResult(result, func() {
+		utils.SuccessMessage(fmt.Sprintf("Discovery of GRAS %s completed, grapi serves %d models", GRASName, len(after)))
+		if len(result.Added) == 0 && len(result.Removed) == 0 {
+			utils.InfoMessage("No models were added or removed")
+		}
+		for _, model := range result.Added {
+			fmt.Printf("  + %s\n", model)
+		}
+		for _, model := range result.Removed {
+			fmt.Printf("  - %s\n", model)
+		}
+	})
+}
+
+// grasDiscoveries returns the names of the discoveries of the grapis of a GRAS
+func grasDiscoveries(gras *unstructured.Unstructured) []string {
+	grapis, _, _ := unstructured.NestedSlice(gras.Object, "spec", "grapis")
+	var names []string
+	for _, grapi := range grapis {
+		grapiMap, ok := grapi.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		discoveries, _, _ := unstructured.NestedSlice(grapiMap, "spec", "discoveries")
+		for _, discovery := range discoveries {
+			if entry, ok := discovery.(map[string]interface{}); ok {
+				names = append(names, fmt.Sprint(entry["name"]))
+			}
+		}
+	}
+	return names
+}
+
+// waitForRollout waits until all replicas of the deployment run its latest pod template
+func waitForRollout(name string) error {
+	progress := utils.StartWaitProgress(fmt.Sprintf("deployment %s/%s to roll out", KubeNS, name), utils.WaitTimeout)
+	defer progress.Stop()
+
+	poller := utils.NewPoller()
+	deadline := time.Now().Add(utils.WaitTimeout)
+	for time.Now().Before(deadline) {
+		deployment, err := clientset.AppsV1().Deployments(KubeNS).Get(context.TODO(), name, v1.GetOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get deployment %s: %w", name, err)
+		}
+		if err == nil {
+			replicas := int32(1)
+			if deployment.Spec.Replicas != nil {
+				replicas = *deployment.Spec.Replicas
+			}
+			status := deployment.Status
+			if status.ObservedGeneration >= deployment.Generation && status.UpdatedReplicas == replicas &&
+				status.AvailableReplicas == replicas && status.Replicas == replicas {
+				progress.Done(fmt.Sprintf("Deployment %s/%s rolled out", KubeNS, name))
+				return nil
+			}
+		}
+		if err := poller.Wait(); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: deployment %s/%s did not roll out in %s, check 'grapple resource logs %s'", utils.ErrTimeout, KubeNS, name, utils.WaitTimeout, GRASName)
+}
+
+// openAPIModelNames returns the names of the models with REST endpoints
+func openAPIModelNames(spec map[string]interface{}) []string {
+	var names []string
+	for _, model := range seedModelsFromOpenAPI(spec) {
+		names = append(names, model.Name)
+	}
+	return names
+}
+
+// subtractNames returns the names of a that are not in b, sorted
+func subtractNames(a, b []string) []string {
+	present := map[string]bool{}
+	for _, name := range b {
+		present[name] = true
+	}
+	var missing []string
+	for _, name := range a {
+		if !present[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
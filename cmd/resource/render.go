@@ -25,19 +25,29 @@ var RenderCmd = &cobra.Command{
 func init() {
 	// Setup cobra flags (bind these to the global variables) - same as deploy
 	RenderCmd.Flags().StringVar(&GRASName, "gras-name", "", "Name of the GRAS resource")
-	RenderCmd.Flags().StringVar(&GRASTemplate, "gras-template", "", "Template type to use")
+	RenderCmd.Flags().StringVar(&GRASTemplate, "gras-template", "", "Template type to use, a built-in type, a template plugin or an oci:// reference of one")
+	RenderCmd.Flags().StringToStringVar(&templateValues, "template-value", map[string]string{}, "Answer of a prompt of a template plugin, e.g. --template-value schema=shop")
 	RenderCmd.Flags().StringVar(&DBType, "db-type", "", "Database type (internal or external)")
+	RenderCmd.Flags().StringVar(&dbEngine, "db-engine", "", "Engine of the internal DB: mysql, mariadb, postgres or redis (default mysql)")
+	RenderCmd.Flags().StringVar(&dbVersion, "db-version", "", "Version of the internal DB engine, e.g. 8.0.30 (default: the version tested with the engine)")
+	RenderCmd.Flags().IntVar(&dbReplicas, "db-replicas", 1, "Replicas of the internal DB")
+	RenderCmd.Flags().StringVar(&dbStorageClass, "db-storage-class", "", "Storage class of the internal DB volumes (default: the default storage class)")
+	RenderCmd.Flags().StringVar(&dbStorageSize, "db-storage-size", "20Gi", "Size of the internal DB volumes")
 	RenderCmd.Flags().StringVar(&ModelsInput, "models", "", "Models input (if not interactive)")
 	RenderCmd.Flags().StringVar(&RelationsInput, "relations", "", "Relations input (if not interactive)")
 	RenderCmd.Flags().StringVar(&DatasourcesInput, "datasources", "", "Datasources input (if not interactive)")
+	RenderCmd.Flags().StringArrayVar(&datasourceInputs, "datasource", nil, "Additional MySQL datasource with its own secret and restcrud, name:{'host':'...','port':'3306','user':'...','password':'...','database':'...','models':'Order,Item','discover':false} (repeatable)")
+	RenderCmd.Flags().StringVar(&datasourcesFile, "datasources-file", "", "YAML list of additional datasources, each with the name and properties of --datasource")
 	RenderCmd.Flags().StringVar(&DiscoveriesInput, "discoveries", "", "Discoveries input (if not interactive)")
 	RenderCmd.Flags().StringVar(&DatabaseSchema, "database-schema", "", "Database schema")
 	RenderCmd.Flags().BoolVar(&AutoDiscovery, "auto-discovery", false, "Auto discovery flag")
 	RenderCmd.Flags().StringVar(&SourceData, "source-data", "", "Data source URL")
 	RenderCmd.Flags().BoolVar(&EnableGRUIM, "enable-gruim", false, "Enables GRUIM")
+	RenderCmd.Flags().StringVar(&GruimsInput, "gruims", "", "Additional GRUIMs over the same grapi, e.g. \"admin:{}|shop:{'config':'...'}\"")
 	RenderCmd.Flags().StringVar(&DBFilePath, "db-file-path", "", "Path to DB file")
-	RenderCmd.Flags().StringVar(&KubeContext, "kube-context", "", "Kubernetes context to use")
 	RenderCmd.Flags().StringVar(&KubeNS, "namespace", "", "Kubernetes namespace to use")
+	RenderCmd.Flags().StringToStringVar(&Labels, "labels", map[string]string{}, "Labels to add to all generated resources (e.g: --labels=team=platform,cost-center=1234)")
+	RenderCmd.Flags().StringToStringVar(&Annotations, "annotations", map[string]string{}, "Annotations to add to all generated resources (e.g: --annotations=owner=platform)")
 }
 
 // runRender is the main function for the render command
@@ -51,15 +61,9 @@ func runRender(cmd *cobra.Command, args []string) error {
 	}
 
 	// Read the generated template.yaml
-	data, err := os.ReadFile("/tmp/template.yaml")
+	tmpl, err := loadGrasTemplate(templateFileDest)
 	if err != nil {
-		utils.ErrorMessage(fmt.Sprintf("failed to read template file: %v", err))
-		return err
-	}
-
-	var tmpl map[string]interface{}
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		utils.ErrorMessage(fmt.Sprintf("failed to parse template yaml: %v", err))
+		utils.ErrorMessage(err.Error())
 		return err
 	}
 
@@ -76,20 +80,27 @@ func runRender(cmd *cobra.Command, args []string) error {
 			"grapis": []interface{}{
 				map[string]interface{}{
 					"name": GRASName,
-					"spec": tmpl["grapi"],
+					"spec": tmpl.Grapi,
 				},
 			},
 		},
 	}
 
+	// Add common labels/annotations passed via flags
+	if len(utils.CommonLabels) > 0 {
+		gras["metadata"].(map[string]interface{})["labels"] = utils.CommonLabels
+	}
+	if len(utils.CommonAnnotations) > 0 {
+		gras["metadata"].(map[string]interface{})["annotations"] = utils.CommonAnnotations
+	}
+
 	// Add gruims if enabled
-	if EnableGRUIM {
-		gras["spec"].(map[string]interface{})["gruims"] = []interface{}{
-			map[string]interface{}{
-				"name": GRASName,
-				"spec": tmpl["gruim"],
-			},
-		}
+	gruims, err := gruimEntries(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to render gruims: %v", err)
+	}
+	if len(gruims) > 0 {
+		gras["spec"].(map[string]interface{})["gruims"] = gruims
 	}
 
 	// Generate output filename with current timestamp
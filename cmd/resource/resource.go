@@ -19,6 +19,16 @@ var ResourceCmd = &cobra.Command{
 You can use this command to:
 - Render a GrappleApplicationSet resource without deploying it
 - Deploy a GrappleApplicationSet resource to your cluster
+- Show the logs of the pods of a deployed GrappleApplicationSet
+- Forward local ports to grapi and gruim of a deployed GrappleApplicationSet
+- Show the Kubernetes events of a deployed GrappleApplicationSet
+- Draw the dependency graph of a GrappleApplicationSet
+- Promote a GrappleApplicationSet to another namespace or cluster
+- Re-run the model discovery of a GrappleApplicationSet after a schema change
+- List and install template types, including custom template plugins
+- Export a deployed GrappleApplicationSet into a manifest for Git and import it again
+- Estimate the resources and the monthly cost of a GrappleApplicationSet
+- Snapshot a GrappleApplicationSet with its database into an archive and restore it
 
 Use the subcommands to perform specific actions on resources.`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -29,6 +39,17 @@ Use the subcommands to perform specific actions on resources.`,
 func init() {
 	ResourceCmd.AddCommand(DeployCmd)
 	ResourceCmd.AddCommand(RenderCmd)
+	ResourceCmd.AddCommand(LogsCmd)
+	ResourceCmd.AddCommand(PortForwardCmd)
+	ResourceCmd.AddCommand(EventsCmd)
+	ResourceCmd.AddCommand(GraphCmd)
+	ResourceCmd.AddCommand(PromoteCmd)
+	ResourceCmd.AddCommand(RediscoverCmd)
+	ResourceCmd.AddCommand(TestAPICmd)
+	ResourceCmd.AddCommand(TemplatesCmd)
+	ResourceCmd.AddCommand(CostCmd)
+	ResourceCmd.AddCommand(SnapshotCmd)
+	ResourceCmd.AddCommand(RestoreCmd)
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -0,0 +1,339 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+)
+
+// seedModel is a model with a REST create endpoint, as described by the grapi OpenAPI spec
+type seedModel struct {
+	Name         string
+	Path         string
+	Properties   map[string]map[string]interface{}
+	IDProperties []string
+}
+
+// seedRelation is a foreign key of a model referencing the records of another model
+type seedRelation struct {
+	ForeignKey string
+	Target     string
+}
+
+var seedHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// seedSampleData creates count sample records per model through the grapi REST endpoints of the
+// deployed GRAS. Foreign keys of the relations of the template reference the seeded records.
+func seedSampleData(tmpl *GrasTemplate, count int) error {
+	grapiURL, err := grapiIngressURL()
+	if err != nil {
+		return err
+	}
+
+	utils.InfoMessage(fmt.Sprintf("Waiting for grapi at %s to serve its API...", grapiURL))
+	spec, err := waitForGrapiOpenAPI(grapiURL)
+	if err != nil {
+		return err
+	}
+
+	models := seedModelsFromOpenAPI(spec)
+	if len(models) == 0 {
+		utils.InfoMessage("No models with REST endpoints found, skipping sample data")
+		return nil
+	}
+	relations := seedRelations(tmpl)
+
+	// created holds the ids of the seeded records by lower case model name
+	created := map[string][]interface{}{}
+	for _, model := range seedOrder(models, relations) {
+		key := strings.ToLower(model.Name)
+		seeded := 0
+		for i := 1; i <= count; i++ {
+			record := sampleRecord(model, i)
+			for _, rel := range relations[key] {
+				if ids := created[rel.Target]; len(ids) > 0 {
+					record[rel.ForeignKey] = ids[rand.Intn(len(ids))]
+				}
+			}
+
+			id, err := postSampleRecord(grapiURL+model.Path, record, model.IDProperties)
+			if err != nil {
+				utils.ErrorMessage(fmt.Sprintf("Failed to seed %s: %v", model.Name, err))
+				break
+			}
+			seeded++
+			if id != nil {
+				created[key] = append(created[key], id)
+			}
+		}
+		utils.InfoMessage(fmt.Sprintf("Seeded %d %s records", seeded, model.Name))
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("Sample data seeded, explore it at %s/explorer", grapiURL))
+	return nil
+}
+
+// grapiIngressURL returns the URL of the grapi of the GRAS, from the cluster domain and SSL setting of grsf-config
+func grapiIngressURL() (string, error) {
+	clusterDomain, err := utils.ExtractDomainFromGrplConfig(restConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract cluster domain: %w", err)
+	}
+	sslEnabled, err := utils.IsSSLEnabled(restConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to check SSL status: %w", err)
+	}
+	scheme := "http"
+	if sslEnabled {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s-grapi.%s", scheme, GRASName, clusterDomain), nil
+}
+
+// waitForGrapiOpenAPI polls the OpenAPI spec of grapi until it is served
+func waitForGrapiOpenAPI(grapiURL string) (map[string]interface{}, error) {
+	progress := utils.StartWaitProgress("grapi to serve its API", utils.WaitTimeout)
+	defer progress.Stop()
+
+	var lastErr error
+	poller := utils.NewPoller()
+	deadline := time.Now().Add(utils.WaitTimeout)
+	for time.Now().Before(deadline) {
+		spec, err := fetchGrapiOpenAPI(grapiURL)
+		if err == nil {
+			return spec, nil
+		}
+		if errors.Is(err, errInvalidOpenAPI) {
+			return nil, err
+		}
+		lastErr = err
+		if err := poller.Wait(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("grapi did not serve its API in time: %v", lastErr)
+}
+
+var errInvalidOpenAPI = errors.New("failed to parse the OpenAPI spec of grapi")
+
+// fetchGrapiOpenAPI returns the OpenAPI spec served by grapi
+func fetchGrapiOpenAPI(grapiURL string) (map[string]interface{}, error) {
+	resp, err := seedHTTPClient.Get(grapiURL + "/openapi.json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	spec := map[string]interface{}{}
+	if err := json.Unmarshal(body, &spec); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidOpenAPI, err)
+	}
+	return spec, nil
+}
+
+// seedModelsFromOpenAPI returns the models with a create endpoint (<Model>Controller.create), their
+// properties come from the New<Model> request schema, properties only in the <Model> schema are ids
+func seedModelsFromOpenAPI(spec map[string]interface{}) []seedModel {
+	paths, _ := spec["paths"].(map[string]interface{})
+	components, _ := spec["components"].(map[string]interface{})
+	schemas, _ := components["schemas"].(map[string]interface{})
+
+	var models []seedModel
+	for path, item := range paths {
+		if strings.Contains(path, "{") {
+			continue
+		}
+		operations, _ := item.(map[string]interface{})
+		post, _ := operations["post"].(map[string]interface{})
+		operationID, _ := post["operationId"].(string)
+		name, ok := strings.CutSuffix(operationID, "Controller.create")
+		if !ok || name == "" {
+			continue
+		}
+
+		newSchema := schemaProperties(schemas, "New"+name)
+		if newSchema == nil {
+			continue
+		}
+		model := seedModel{Name: name, Path: path, Properties: newSchema}
+		for property := range schemaProperties(schemas, name) {
+			if _, ok := newSchema[property]; !ok {
+				model.IDProperties = append(model.IDProperties, property)
+			}
+		}
+		sort.Strings(model.IDProperties)
+		models = append(models, model)
+	}
+
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models
+}
+
+func schemaProperties(schemas map[string]interface{}, name string) map[string]map[string]interface{} {
+	schema, _ := schemas[name].(map[string]interface{})
+	raw, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	properties := map[string]map[string]interface{}{}
+	for property, definition := range raw {
+		d, _ := definition.(map[string]interface{})
+		properties[property] = d
+	}
+	return properties
+}
+
+// seedRelations returns the foreign keys of the template relations by the lower case name of the model holding them
+func seedRelations(tmpl *GrasTemplate) map[string][]seedRelation {
+	relations := map[string][]seedRelation{}
+	if tmpl == nil {
+		return relations
+	}
+	for _, rel := range tmpl.Grapi.Relations {
+		relationType := fmt.Sprint(rel.Spec["relationType"])
+		// Template model names may differ in case from the model classes of grapi
+		source := strings.ToLower(fmt.Sprint(rel.Spec["sourceModel"]))
+		destination := strings.ToLower(fmt.Sprint(rel.Spec["destinationModel"]))
+		foreignKey, _ := rel.Spec["foreignKeyName"].(string)
+		if foreignKey == "" {
+			continue
+		}
+		// belongsTo keeps the foreign key on the source, hasMany/hasOne on the destination
+		if relationType == "belongsTo" {
+			relations[source] = append(relations[source], seedRelation{ForeignKey: foreignKey, Target: destination})
+		} else {
+			relations[destination] = append(relations[destination], seedRelation{ForeignKey: foreignKey, Target: source})
+		}
+	}
+	return relations
+}
+
+// seedOrder orders the models so referenced models are seeded before the models referencing them
+func seedOrder(models []seedModel, relations map[string][]seedRelation) []seedModel {
+	var ordered []seedModel
+	// placed is keyed by lower case model name, like the relations
+	placed := map[string]bool{}
+	for len(ordered) < len(models) {
+		progress := false
+		for _, model := range models {
+			key := strings.ToLower(model.Name)
+			if placed[key] {
+				continue
+			}
+			ready := true
+			for _, rel := range relations[key] {
+				if rel.Target != key && !placed[rel.Target] && hasSeedModel(models, rel.Target) {
+					ready = false
+				}
+			}
+			if ready {
+				ordered = append(ordered, model)
+				placed[key] = true
+				progress = true
+			}
+		}
+		if !progress {
+			// circular relations, the remaining models are seeded in name order
+			for _, model := range models {
+				if key := strings.ToLower(model.Name); !placed[key] {
+					ordered = append(ordered, model)
+					placed[key] = true
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+func hasSeedModel(models []seedModel, name string) bool {
+	for _, model := range models {
+		if strings.EqualFold(model.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sampleRecord generates the i-th record of a model from the types of its properties
+func sampleRecord(model seedModel, i int) map[string]interface{} {
+	record := map[string]interface{}{}
+	for property, definition := range model.Properties {
+		record[property] = sampleValue(property, definition, i)
+	}
+	return record
+}
+
+func sampleValue(property string, definition map[string]interface{}, i int) interface{} {
+	if enum, ok := definition["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[(i-1)%len(enum)]
+	}
+
+	switch definition["type"] {
+	case "integer":
+		return rand.Intn(1000) + 1
+	case "number":
+		return float64(rand.Intn(100000)) / 100
+	case "boolean":
+		return i%2 == 0
+	case "array":
+		return []interface{}{}
+	case "object":
+		return map[string]interface{}{}
+	}
+
+	var value string
+	lower := strings.ToLower(property)
+	switch {
+	case definition["format"] == "date-time":
+		return time.Now().Add(-time.Duration(rand.Intn(365*24)) * time.Hour).UTC().Format(time.RFC3339)
+	case definition["format"] == "date":
+		return time.Now().AddDate(0, 0, -rand.Intn(365)).Format("2006-01-02")
+	case strings.Contains(lower, "email"):
+		value = fmt.Sprintf("user%d@example.com", i)
+	case strings.Contains(lower, "url"):
+		value = fmt.Sprintf("https://example.com/%s/%d", lower, i)
+	default:
+		value = fmt.Sprintf("%s %d", property, i)
+	}
+	if maxLength, ok := definition["maxLength"].(float64); ok && len(value) > int(maxLength) {
+		value = value[:int(maxLength)]
+	}
+	return value
+}
+
+// postSampleRecord creates a record and returns its id
+func postSampleRecord(url string, record map[string]interface{}, idProperties []string) (interface{}, error) {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal record: %w", err)
+	}
+	resp, err := seedHTTPClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("failed to parse the created record: %s", strings.TrimSpace(string(data)))
+	}
+	return createdRecordID(data, idProperties), nil
+}
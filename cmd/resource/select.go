@@ -0,0 +1,71 @@
+package resource
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+)
+
+// resolveGrasName is used by the commands acting on an existing GRAS: when --gras-name is omitted, the
+// GRAS resources of --namespace are listed and one is selected. Without --namespace the namespace is
+// selected first among the namespaces holding GRAS resources (named GRASName, if it is set).
+func resolveGrasName() error {
+	if GRASName != "" {
+		if err := utils.ValidateResourceName(GRASName); err != nil {
+			return err
+		}
+		if KubeNS != "" {
+			return nil
+		}
+	}
+
+	if restConfig == nil {
+		var err error
+		restConfig, clientset, err = utils.GetKubernetesConfig()
+		if err != nil {
+			utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+			return err
+		}
+	}
+
+	grasNames, err := utils.ListGrasNames(restConfig, KubeNS)
+	if err != nil {
+		return err
+	}
+	if len(grasNames) == 0 {
+		if KubeNS != "" {
+			return fmt.Errorf("no GRAS resources found in namespace %s", KubeNS)
+		}
+		return fmt.Errorf("no GRAS resources found in the cluster")
+	}
+
+	if KubeNS == "" {
+		namespaces := make([]string, 0, len(grasNames))
+		for ns, names := range grasNames {
+			if GRASName == "" || utils.Contains(names, GRASName) {
+				namespaces = append(namespaces, ns)
+			}
+		}
+		sort.Strings(namespaces)
+		if len(namespaces) == 0 {
+			return fmt.Errorf("GRAS %s not found in any namespace", GRASName)
+		}
+		if len(namespaces) == 1 {
+			KubeNS = namespaces[0]
+		} else if KubeNS, err = utils.PromptSelect("Select namespace", namespaces); err != nil {
+			return err
+		}
+	}
+
+	if GRASName == "" {
+		names := grasNames[KubeNS]
+		if len(names) == 1 {
+			GRASName = names[0]
+		} else if GRASName, err = utils.PromptSelect("Select GRAS resource", names); err != nil {
+			return err
+		}
+	}
+	utils.InfoMessage(fmt.Sprintf("Using GRAS %s in namespace %s", GRASName, KubeNS))
+	return nil
+}
@@ -0,0 +1,174 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// secretKeyPattern is what Kubernetes accepts as key of a secret
+var secretKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// grapiManagedFields are the grapi fields the CLI builds itself, they can't be set from a file
+var grapiManagedFields = map[string]bool{
+	"models": true, "datasources": true, "relations": true, "discoveries": true,
+	"initContainers": true, "restcruds": true, "extraSecrets": true,
+}
+
+// setFileSecretName is the secret holding the contents of the --set-file files
+func setFileSecretName() string {
+	return fmt.Sprintf("%s-files", GRASName)
+}
+
+// applySetFiles stores the files of --set-file (path=file) in the <gras-name>-files secret and sets the values
+// at their paths to a $(key) reference, like the datasource credentials, so the contents never end up in the
+// template file. The secret is added to the extraSecrets of the grapi or gruim, the key is the last path segment.
+func applySetFiles(tmpl *GrasTemplate) error {
+	if len(setFiles) == 0 {
+		return nil
+	}
+
+	data := map[string][]byte{}
+	sections := map[string]bool{}
+	for _, entry := range setFiles {
+		valuePath, file, ok := strings.Cut(entry, "=")
+		if !ok || valuePath == "" || file == "" {
+			return fmt.Errorf("%w: --set-file must be <path>=<file>, e.g. grapi.env.GOOGLE_CREDS=creds.json, got %q", utils.ErrValidation, entry)
+		}
+		segments := strings.Split(valuePath, ".")
+		if len(segments) < 2 {
+			return fmt.Errorf("%w: --set-file path %q must start with grapi. or gruim.", utils.ErrValidation, valuePath)
+		}
+		key := segments[len(segments)-1]
+		if !secretKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: --set-file path %q must end with a valid secret key (letters, digits, '-', '_' or '.')", utils.ErrValidation, valuePath)
+		}
+		if _, duplicate := data[key]; duplicate {
+			return fmt.Errorf("%w: --set-file key %s is given more than once", utils.ErrValidation, key)
+		}
+
+		reference := fmt.Sprintf("$(%s)", key)
+		switch segments[0] {
+		case "grapi":
+			if grapiManagedFields[segments[1]] {
+				return fmt.Errorf("%w: grapi.%s is set by the deploy and can't be taken from a file", utils.ErrValidation, segments[1])
+			}
+			if tmpl.Grapi.Extra == nil {
+				tmpl.Grapi.Extra = map[string]interface{}{}
+			}
+			if err := setNestedValue(tmpl.Grapi.Extra, segments[1:], reference); err != nil {
+				return fmt.Errorf("%w: --set-file %s: %w", utils.ErrValidation, valuePath, err)
+			}
+		case "gruim":
+			if tmpl.Gruim == nil {
+				return fmt.Errorf("%w: --set-file %s needs GRUIM, use --enable-gruim", utils.ErrValidation, valuePath)
+			}
+			if err := setNestedValue(tmpl.Gruim, segments[1:], reference); err != nil {
+				return fmt.Errorf("%w: --set-file %s: %w", utils.ErrValidation, valuePath, err)
+			}
+		default:
+			return fmt.Errorf("%w: --set-file path %q must start with grapi. or gruim.", utils.ErrValidation, valuePath)
+		}
+		sections[segments[0]] = true
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("%w: failed to read --set-file %s: %w", utils.ErrValidation, file, err)
+		}
+		data[key] = content
+	}
+
+	name := setFileSecretName()
+	if sections["grapi"] && !containsString(tmpl.Grapi.ExtraSecrets, name) {
+		tmpl.Grapi.ExtraSecrets = append(tmpl.Grapi.ExtraSecrets, name)
+	}
+	if sections["gruim"] {
+		extraSecrets, _ := tmpl.Gruim["extraSecrets"].([]interface{})
+		if !containsInterface(extraSecrets, name) {
+			tmpl.Gruim["extraSecrets"] = append(extraSecrets, name)
+		}
+	}
+
+	if isRender {
+		utils.InfoMessage(fmt.Sprintf("The files of --set-file are referenced from secret %s, create it before applying the rendered GRAS", name))
+		return nil
+	}
+	return createSetFileSecret(name, data)
+}
+
+// createSetFileSecret creates the secret of --set-file, or replaces its contents
+func createSetFileSecret(name string, data map[string][]byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: KubeNS,
+		},
+		Data: data,
+	}
+	utils.ApplyCommonMetadata(&secret.ObjectMeta)
+
+	_, err := clientset.CoreV1().Secrets(KubeNS).Create(context.TODO(), secret, v1.CreateOptions{})
+	if err == nil {
+		recordCreatedSecret(KubeNS, name)
+	}
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().Secrets(KubeNS).Update(context.TODO(), secret, v1.UpdateOptions{})
+	}
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to create secret %s: %v", name, err))
+		return err
+	}
+	utils.SuccessMessage(fmt.Sprintf("Stored %d file(s) of --set-file in secret %s", len(data), name))
+	return nil
+}
+
+// setNestedValue sets value at path, creating the maps on the way. Maps read from a template are
+// map[interface{}]interface{}, both kinds are handled.
+func setNestedValue(values map[string]interface{}, path []string, value interface{}) error {
+	if len(path) == 1 {
+		values[path[0]] = value
+		return nil
+	}
+	switch next := values[path[0]].(type) {
+	case nil:
+		child := map[string]interface{}{}
+		values[path[0]] = child
+		return setNestedValue(child, path[1:], value)
+	case map[string]interface{}:
+		return setNestedValue(next, path[1:], value)
+	case map[interface{}]interface{}:
+		child := map[string]interface{}{}
+		for k, v := range next {
+			child[fmt.Sprintf("%v", k)] = v
+		}
+		values[path[0]] = child
+		return setNestedValue(child, path[1:], value)
+	default:
+		return fmt.Errorf("%s is not a map", path[0])
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInterface(values []interface{}, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
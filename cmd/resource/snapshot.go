@@ -0,0 +1,472 @@
+package resource
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	snapshotManifestFile = "snapshot.yaml"
+	snapshotGrasFile     = "gras.yaml"
+	snapshotClusterFile  = "db-cluster.yaml"
+	snapshotDumpFile     = "database.sql"
+	// snapshotVersion is the version of the archive layout, restore refuses newer ones
+	snapshotVersion = 1
+)
+
+var (
+	snapshotFile     string
+	snapshotSkipData bool
+)
+
+// snapshotManifest describes the content of a snapshot archive
+type snapshotManifest struct {
+	Version    int               `yaml:"version"`
+	GRAS       string            `yaml:"gras"`
+	Namespace  string            `yaml:"namespace"`
+	CreatedAt  string            `yaml:"createdAt"`
+	CLIVersion string            `yaml:"cliVersion"`
+	Database   *snapshotDatabase `yaml:"database,omitempty"`
+}
+
+// snapshotDatabase is the database of the GRAS in a snapshot
+type snapshotDatabase struct {
+	// Type is internal (a KubeBlocks cluster, its manifest is in db-cluster.yaml) or external
+	Type   string `yaml:"type"`
+	Engine string `yaml:"engine"`
+	Name   string `yaml:"name"`
+	// Dump is the SQL dump in the archive, empty when the data was skipped or the engine can't be dumped
+	Dump string `yaml:"dump,omitempty"`
+}
+
+// SnapshotCmd represents the resource snapshot command
+var SnapshotCmd = &cobra.Command{
+	Use:   "snapshot [gras-name]",
+	Short: "Back up the spec and the database of a GRAS into one archive",
+	Long: `Snapshot writes an application level backup of a GrappleApplicationSet into a .tar.gz archive: the manifest of
+the GRAS as written by 'grapple resource template export', the KubeBlocks cluster of an internal database and a
+SQL dump of its MySQL or MariaDB database (internal or external), described by a snapshot.yaml manifest.
+
+The dump is taken with mysqldump from a short lived pod in the namespace of the GRAS, using the credentials of
+its <gras-name>-conn-credential secret. Credentials are not part of the archive. PostgreSQL and Redis internal
+databases are not dumped, --skip-data leaves the data out for any database.
+
+'grapple resource restore' deploys the archive again.
+
+Without a GRAS name, the GRAS is selected among the GRAS resources of --namespace (or of the cluster).
+
+Example:
+  grapple resource snapshot shop --namespace shop
+  grapple resource snapshot shop --namespace shop --file backups/shop.tar.gz`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSnapshot,
+}
+
+// RestoreCmd represents the resource restore command
+var RestoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Restore a GRAS and its database from a snapshot",
+	Long: `Restore redeploys a GrappleApplicationSet from an archive written by 'grapple resource snapshot' and loads its
+database dump:
+
+  1. the KubeBlocks cluster of an internal database is created, unless it exists, and waited for
+  2. the dump is loaded into the database, which is created if needed (--skip-data skips it)
+  3. the GRAS is deployed like 'grapple resource template import' does
+
+An external database is reached with the <gras-name>-conn-credential secret, which has to exist in the target
+namespace (host, port, username and password keys). The GRAS is restored into its namespace, or --namespace.
+
+Example:
+  grapple resource restore shop-20250101-120000.tar.gz
+  grapple resource restore shop-20250101-120000.tar.gz --namespace shop-restored`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	SnapshotCmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace of the GRAS resource")
+	SnapshotCmd.Flags().StringVar(&snapshotFile, "file", "", "Archive the snapshot is written to (default: <gras-name>-<time>.tar.gz)")
+	SnapshotCmd.Flags().BoolVar(&snapshotSkipData, "skip-data", false, "Leave the database dump out of the snapshot")
+
+	RestoreCmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace to restore into (default: the namespace of the snapshot)")
+	RestoreCmd.Flags().BoolVar(&snapshotSkipData, "skip-data", false, "Don't load the database dump of the snapshot")
+	RestoreCmd.Flags().BoolVar(&forceDeploy, "force", false, "Redeploy the GRAS even if the deployed release has the same chart version and values")
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		GRASName = args[0]
+	}
+
+	var err error
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if err := resolveGrasName(); err != nil {
+		return err
+	}
+	gras, err := utils.GetGras(restConfig, KubeNS, GRASName)
+	if err != nil {
+		return err
+	}
+
+	workDir, err := os.MkdirTemp("", "grpl-snapshot-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	manifest := snapshotManifest{
+		Version:    snapshotVersion,
+		GRAS:       GRASName,
+		Namespace:  KubeNS,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+		CLIVersion: utils.GetGrappleCliVersion(),
+	}
+	files := []string{snapshotManifestFile}
+
+	if err := writeYAMLFile(filepath.Join(workDir, snapshotGrasFile), exportableManifest(gras)); err != nil {
+		return err
+	}
+	files = append(files, snapshotGrasFile)
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	cluster, err := dynamicClient.Resource(kubeBlocksClusterGVR).Namespace(KubeNS).Get(context.TODO(), GRASName, v1.GetOptions{})
+	switch {
+	case err == nil:
+		engine := kubeBlocksEngineName(cluster)
+		manifest.Database = &snapshotDatabase{Type: utils.DB_INTERNAL, Engine: engine, Name: grasDatabaseName(gras)}
+		if err := writeYAMLFile(filepath.Join(workDir, snapshotClusterFile), exportableManifest(cluster)); err != nil {
+			return err
+		}
+		files = append(files, snapshotClusterFile)
+	case !k8serrors.IsNotFound(err):
+		utils.DebugMessage(fmt.Sprintf("Failed to get the KubeBlocks cluster %s: %v", GRASName, err))
+	}
+	if manifest.Database == nil {
+		if _, err := clientset.CoreV1().Secrets(KubeNS).Get(context.TODO(), dbCredentialSecretName(), v1.GetOptions{}); err == nil {
+			manifest.Database = &snapshotDatabase{Type: utils.DB_EXTERNAL, Engine: dbEngineMySQL, Name: grasDatabaseName(gras)}
+		}
+	}
+
+	if db := manifest.Database; db != nil && !snapshotSkipData {
+		switch {
+		case db.Engine != dbEngineMySQL && db.Engine != dbEngineMariaDB:
+			utils.InfoMessage(fmt.Sprintf("The %s database is not dumped, only MySQL and MariaDB databases are", db.Engine))
+		case db.Name == "":
+			utils.InfoMessage("The GRAS names no database in its datasource, the database is not dumped")
+		default:
+			if err := dumpGrasDatabase(db.Name, filepath.Join(workDir, snapshotDumpFile)); err != nil {
+				return err
+			}
+			db.Dump = snapshotDumpFile
+			files = append(files, snapshotDumpFile)
+		}
+	}
+
+	if err := writeYAMLFile(filepath.Join(workDir, snapshotManifestFile), manifest); err != nil {
+		return err
+	}
+	if snapshotFile == "" {
+		snapshotFile = fmt.Sprintf("%s-%s.tar.gz", GRASName, time.Now().Format("20060102-150405"))
+	}
+	if err := writeSnapshotArchive(snapshotFile, workDir, files); err != nil {
+		return err
+	}
+	utils.SuccessMessage(fmt.Sprintf("Snapshot of GRAS %s written to %s", GRASName, snapshotFile))
+	return nil
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	workDir, err := os.MkdirTemp("", "grpl-restore-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if err := extractSnapshotArchive(args[0], workDir); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, snapshotManifestFile))
+	if err != nil {
+		return fmt.Errorf("%w: %s is not a snapshot, it has no %s", utils.ErrValidation, args[0], snapshotManifestFile)
+	}
+	var manifest snapshotManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("%w: invalid %s: %w", utils.ErrValidation, snapshotManifestFile, err)
+	}
+	if manifest.Version > snapshotVersion {
+		return fmt.Errorf("%w: the snapshot has version %d, this CLI restores up to version %d, update it with 'grapple self-update'",
+			utils.ErrValidation, manifest.Version, snapshotVersion)
+	}
+
+	GRASName = manifest.GRAS
+	if KubeNS == "" {
+		KubeNS = manifest.Namespace
+	}
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if err := prepareNamespaceForGrasInstallation(); err != nil {
+		return err
+	}
+
+	if db := manifest.Database; db != nil {
+		if db.Type == utils.DB_INTERNAL {
+			if err := restoreDBCluster(filepath.Join(workDir, snapshotClusterFile)); err != nil {
+				return err
+			}
+		}
+		if db.Dump != "" && !snapshotSkipData {
+			if err := restoreGrasDatabase(db.Name, filepath.Join(workDir, db.Dump)); err != nil {
+				return err
+			}
+		}
+	}
+
+	importFile = filepath.Join(workDir, snapshotGrasFile)
+	return runDeploy(cmd, nil)
+}
+
+// kubeBlocksEngineName returns the --db-engine of a KubeBlocks cluster, its cluster definition when it is unknown
+func kubeBlocksEngineName(cluster *unstructured.Unstructured) string {
+	definition, _, _ := unstructured.NestedString(cluster.Object, "spec", "clusterDefinitionRef")
+	for name, engine := range kubeBlocksEngines {
+		if engine.ClusterDefinition == definition {
+			return name
+		}
+	}
+	return definition
+}
+
+// grasDatabaseName returns the database of the first datasource of the GRAS, "" if it names none
+func grasDatabaseName(gras *unstructured.Unstructured) string {
+	grapis, _, _ := unstructured.NestedSlice(gras.Object, "spec", "grapis")
+	for _, grapi := range grapis {
+		grapiMap, ok := grapi.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		datasources, _, _ := unstructured.NestedSlice(grapiMap, "spec", "datasources")
+		for _, datasource := range datasources {
+			spec, _, _ := unstructured.NestedMap(datasource.(map[string]interface{}), "spec")
+			// The spec is keyed by the connector, e.g. {mysql: {database: shop, ...}}
+			for _, connector := range spec {
+				if settings, ok := connector.(map[string]interface{}); ok {
+					if database, ok := settings["database"].(string); ok && database != "" {
+						return database
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// grasDBConnection returns the connection of the database in the credential secret of the GRAS
+func grasDBConnection(database string) (utils.MySQLConnection, error) {
+	secret, err := clientset.CoreV1().Secrets(KubeNS).Get(context.TODO(), dbCredentialSecretName(), v1.GetOptions{})
+	if err != nil {
+		return utils.MySQLConnection{}, fmt.Errorf("failed to get the database credentials from secret %s: %w", dbCredentialSecretName(), err)
+	}
+	return utils.MySQLConnection{
+		Host:     string(secret.Data["host"]),
+		Port:     string(secret.Data["port"]),
+		User:     string(secret.Data["username"]),
+		Password: string(secret.Data["password"]),
+		Database: database,
+	}, nil
+}
+
+// dumpGrasDatabase writes a SQL dump of the database of the GRAS to path
+func dumpGrasDatabase(database, path string) error {
+	conn, err := grasDBConnection(database)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	utils.InfoMessage(fmt.Sprintf("Dumping database %s...", database))
+	if err := utils.DumpMySQL(restConfig, clientset, KubeNS, conn, file); err != nil {
+		return fmt.Errorf("failed to dump database %s: %w", database, err)
+	}
+	if info, err := file.Stat(); err == nil {
+		utils.InfoMessage(fmt.Sprintf("Dumped database %s (%s)", database, utils.FormatBytes(info.Size())))
+	}
+	return nil
+}
+
+// restoreGrasDatabase loads the SQL dump at path into the database of the GRAS
+func restoreGrasDatabase(database, path string) error {
+	conn, err := grasDBConnection(database)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return fmt.Errorf("%w: create the secret %s with the host, port, username and password of the database first",
+				utils.ErrValidation, dbCredentialSecretName())
+		}
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the dump: %w", err)
+	}
+	defer file.Close()
+
+	utils.InfoMessage(fmt.Sprintf("Restoring database %s...", database))
+	if err := utils.RestoreMySQL(restConfig, clientset, KubeNS, conn, file); err != nil {
+		return fmt.Errorf("failed to restore database %s: %w", database, err)
+	}
+	utils.SuccessMessage(fmt.Sprintf("Restored database %s", database))
+	return nil
+}
+
+// restoreDBCluster creates the KubeBlocks cluster of the snapshot in the target namespace, unless it exists, and
+// waits for it and its credentials
+func restoreDBCluster(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: the snapshot has an internal database but no %s", utils.ErrValidation, snapshotClusterFile)
+	}
+	obj, err := valuesFromYAML(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", snapshotClusterFile, err)
+	}
+	cluster := &unstructured.Unstructured{Object: obj}
+	cluster.SetNamespace(KubeNS)
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	_, err = dynamicClient.Resource(kubeBlocksClusterGVR).Namespace(KubeNS).Create(context.TODO(), cluster, v1.CreateOptions{})
+	switch {
+	case err == nil:
+		utils.InfoMessage(fmt.Sprintf("Created database cluster %s", cluster.GetName()))
+	case k8serrors.IsAlreadyExists(err):
+		utils.InfoMessage(fmt.Sprintf("Database cluster %s already exists, restoring into it", cluster.GetName()))
+	default:
+		return fmt.Errorf("failed to create database cluster %s: %w", cluster.GetName(), err)
+	}
+
+	if _, err := utils.WaitForKubeBlocksCluster(restConfig, KubeNS, cluster.GetName()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeYAMLFile marshals value into path
+func writeYAMLFile(path string, value interface{}) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filepath.Base(path), err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// writeSnapshotArchive packs the files of dir into a .tar.gz archive at path
+func writeSnapshotArchive(path, dir string, files []string) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, name := range files {
+		if err := addArchiveFile(tw, filepath.Join(dir, name), name); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return out.Close()
+}
+
+// addArchiveFile adds the file at path to the archive as name
+func addArchiveFile(tw *tar.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	header := &tar.Header{Name: name, Mode: 0600, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, file); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	return nil
+}
+
+// extractSnapshotArchive unpacks a snapshot archive into dir, entries with a directory are rejected
+func extractSnapshotArchive(path, dir string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return fmt.Errorf("%w: %s is not a snapshot archive: %w", utils.ErrValidation, path, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || filepath.Base(header.Name) != header.Name {
+			return fmt.Errorf("%w: unexpected entry %s in the snapshot", utils.ErrValidation, header.Name)
+		}
+		out, err := os.OpenFile(filepath.Join(dir, header.Name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package resource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// NamedSpec is the name/spec pair used by every list inside the grapi section
+type NamedSpec struct {
+	Name string                 `yaml:"name"`
+	Spec map[string]interface{} `yaml:"spec"`
+}
+
+// GrapiSection holds the grapi part of a GRAS template. Fields the CLI doesn't
+// manage (ingress, volumes, ...) are kept in Extra so they survive a round trip.
+type GrapiSection struct {
+	Models         []NamedSpec            `yaml:"models,omitempty"`
+	Datasources    []NamedSpec            `yaml:"datasources,omitempty"`
+	Relations      []NamedSpec            `yaml:"relations,omitempty"`
+	Discoveries    []NamedSpec            `yaml:"discoveries,omitempty"`
+	InitContainers []NamedSpec            `yaml:"initContainers,omitempty"`
+	Restcruds      []NamedSpec            `yaml:"restcruds,omitempty"`
+	ExtraSecrets   []string               `yaml:"extraSecrets,omitempty"`
+	Extra          map[string]interface{} `yaml:",inline"`
+}
+
+// GrasTemplate is the in-memory representation of template-files/db*.yaml
+type GrasTemplate struct {
+	Gras  map[string]interface{} `yaml:"gras"`
+	Grapi GrapiSection           `yaml:"grapi"`
+	Gruim map[string]interface{} `yaml:"gruim,omitempty"`
+	// Gruims are the additional GRUIMs, e.g. an admin UI next to the customer UI, over the same grapi
+	Gruims []NamedSpec            `yaml:"gruims,omitempty"`
+	Extra  map[string]interface{} `yaml:",inline"`
+}
+
+// loadGrasTemplate reads a GRAS template from disk
+func loadGrasTemplate(path string) (*GrasTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template file %s: %v", path, err)
+	}
+	tmpl := &GrasTemplate{}
+	if err := yaml.Unmarshal(data, tmpl); err != nil {
+		return nil, fmt.Errorf("failed to parse template file %s: %v", path, err)
+	}
+	if tmpl.Gras == nil {
+		tmpl.Gras = map[string]interface{}{}
+	}
+	return tmpl, nil
+}
+
+// Marshal returns the template as YAML
+func (t *GrasTemplate) Marshal() ([]byte, error) {
+	return yaml.Marshal(t)
+}
+
+// valuesFromYAML converts YAML into helm values, i.e. maps keyed by string all the way down
+func valuesFromYAML(data []byte) (map[string]interface{}, error) {
+	vals := map[string]interface{}{}
+	if err := k8syaml.Unmarshal(data, &vals); err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
+
+// valuesHash fingerprints helm values, maps are marshalled with sorted keys so equal values hash the same
+// whether they were rendered now or read back from a release
+func valuesHash(vals map[string]interface{}) (string, error) {
+	if vals == nil {
+		vals = map[string]interface{}{}
+	}
+	data, err := json.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
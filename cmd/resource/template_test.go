@@ -0,0 +1,383 @@
+package resource
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// withDeployFlags sets the deploy flags the transforms read, they are restored after the test
+func withDeployFlags(t *testing.T, template, schema string) {
+	t.Helper()
+	oldTemplate, oldSchema, oldName, oldType := GRASTemplate, DatabaseSchema, GRASName, DBType
+	oldEngine, oldURL, oldSource, oldFile, oldExtra := dbEngine, URL, SourceData, DBFilePath, extraDatasources
+	t.Cleanup(func() {
+		GRASTemplate, DatabaseSchema, GRASName, DBType = oldTemplate, oldSchema, oldName, oldType
+		dbEngine, URL, SourceData, DBFilePath, extraDatasources = oldEngine, oldURL, oldSource, oldFile, oldExtra
+	})
+	GRASTemplate, DatabaseSchema, GRASName, DBType = template, schema, "shop", ""
+	dbEngine, URL, SourceData, DBFilePath, extraDatasources = "", "", "", "", nil
+}
+
+// withNamespaceServer points clientset at an API server without namespaces, so the namespace enforces no Pod
+// Security Standard
+func withNamespaceServer(t *testing.T) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldClientset, oldNS := clientset, KubeNS
+	t.Cleanup(func() { clientset, KubeNS = oldClientset, oldNS })
+	clientset, KubeNS = client, "shop"
+}
+
+func TestParseNamedSpecs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []NamedSpec
+		wantErr string
+	}{
+		{name: "empty", input: "  "},
+		{name: "single", input: `customer:{"base":"Entity"}`,
+			want: []NamedSpec{{Name: "customer", Spec: map[string]interface{}{"base": "Entity"}}}},
+		{name: "single quotes", input: `customer:{'base':'Entity'}`,
+			want: []NamedSpec{{Name: "customer", Spec: map[string]interface{}{"base": "Entity"}}}},
+		{name: "several with empty entries", input: `a:{"x":1}||b:{"y":{"z":true}}|`,
+			want: []NamedSpec{
+				{Name: "a", Spec: map[string]interface{}{"x": float64(1)}},
+				{Name: "b", Spec: map[string]interface{}{"y": map[string]interface{}{"z": true}}},
+			}},
+		{name: "name is trimmed", input: ` a :{}`, want: []NamedSpec{{Name: "a", Spec: map[string]interface{}{}}}},
+		{name: "colon in the spec", input: `a:{"url":"http://x:80"}`,
+			want: []NamedSpec{{Name: "a", Spec: map[string]interface{}{"url": "http://x:80"}}}},
+		{name: "missing spec", input: `customer`, wantErr: "invalid --models entry 1"},
+		{name: "invalid json", input: `a:{}|b:{"x":}`, wantErr: "invalid --models entry 2 (b)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNamedSpecs(tt.input, "models")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseNamedSpecs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseNamedSpecs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNamedSpecs() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformInputs(t *testing.T) {
+	existing := NamedSpec{Name: "existing", Spec: map[string]interface{}{}}
+	tests := []struct {
+		name      string
+		transform func(string, *GrasTemplate) error
+		section   func(*GrasTemplate) []NamedSpec
+	}{
+		{name: "models", transform: transformModelInputToYAML, section: func(t *GrasTemplate) []NamedSpec { return t.Grapi.Models }},
+		{name: "relations", transform: transformRelationInputToYAML, section: func(t *GrasTemplate) []NamedSpec { return t.Grapi.Relations }},
+		{name: "discoveries", transform: transformDiscoveriesInputToYAML, section: func(t *GrasTemplate) []NamedSpec { return t.Grapi.Discoveries }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := &GrasTemplate{Grapi: GrapiSection{
+				Models:      []NamedSpec{existing},
+				Relations:   []NamedSpec{existing},
+				Discoveries: []NamedSpec{existing},
+			}}
+			if err := tt.transform(`added:{"a":"b"}`, tmpl); err != nil {
+				t.Fatalf("transform error = %v", err)
+			}
+			got := tt.section(tmpl)
+			if len(got) != 2 || got[0].Name != "existing" || got[1].Name != "added" || got[1].Spec["a"] != "b" {
+				t.Errorf("transform appended %#v, want existing followed by added", got)
+			}
+			if err := tt.transform(`broken`, tmpl); err == nil {
+				t.Error("transform of an invalid entry returned no error")
+			}
+		})
+	}
+}
+
+func TestUpdateTemplateDatasources(t *testing.T) {
+	existing := []NamedSpec{{Name: "old", Spec: map[string]interface{}{}}, {Name: "second", Spec: map[string]interface{}{}}}
+	tests := []struct {
+		name         string
+		template     string
+		setup        func()
+		update       func(*GrasTemplate) error
+		wantName     string
+		wantSpec     map[string]interface{}
+		wantSecrets  []string
+		wantReplaced bool
+	}{
+		{
+			name:     "internal mysql",
+			template: utils.DB_MYSQL_MODEL_BASED,
+			update:   updateTemplateForInternalDB,
+			wantName: "shopdb",
+			wantSpec: map[string]interface{}{"mysql": map[string]interface{}{
+				"name": "shopdb", "host": "$(host)", "port": "$(port)", "user": "$(username)", "password": "$(password)", "database": "shopdb",
+			}},
+			wantSecrets:  []string{"shop-conn-credential"},
+			wantReplaced: true,
+		},
+		{
+			name:     "internal redis is named after the GRAS",
+			template: utils.DB_MYSQL_MODEL_BASED,
+			setup:    func() { DatabaseSchema, dbEngine = "", dbEngineRedis },
+			update:   updateTemplateForInternalDB,
+			wantName: "shop",
+			wantSpec: map[string]interface{}{"kv-redis": map[string]interface{}{
+				"name": "shop", "host": "$(host)", "port": "$(port)", "user": "$(username)", "password": "$(password)",
+			}},
+			wantSecrets:  []string{"shop-conn-credential"},
+			wantReplaced: true,
+		},
+		{
+			name:     "external without url",
+			template: utils.DB_MYSQL_MODEL_BASED,
+			update:   updateTemplateForExternalDB,
+			wantName: "shopdb",
+			wantSpec: map[string]interface{}{"mysql": map[string]interface{}{
+				"name": "shopdb", "url": "", "host": "$(host)", "port": "$(port)", "user": "$(username)", "password": "$(password)", "database": "shopdb",
+			}},
+			wantSecrets:  []string{"shop-conn-credential"},
+			wantReplaced: true,
+		},
+		{
+			name:     "external url comes from the secret",
+			template: utils.DB_MYSQL_MODEL_BASED,
+			setup:    func() { URL = "mysql://user:secret@db:3306/shopdb" },
+			update:   updateTemplateForExternalDB,
+			wantName: "shopdb",
+			wantSpec: map[string]interface{}{"mysql": map[string]interface{}{
+				"name": "shopdb", "url": "$(url)", "host": "$(host)", "port": "$(port)", "user": "$(username)", "password": "$(password)", "database": "shopdb",
+			}},
+			wantSecrets:  []string{"shop-conn-credential"},
+			wantReplaced: true,
+		},
+		{
+			name:     "db file",
+			template: utils.DB_FILE,
+			setup:    func() { DBFilePath = "/data/db.json" },
+			update:   updateTemplateForDataSourceIncaseOfDbFile,
+			wantName: "db",
+			wantSpec: map[string]interface{}{"memory": map[string]interface{}{
+				"connector": "memory", "name": "db", "file": "/data/db.json", "localStorage": "db",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDeployFlags(t, tt.template, "shopdb")
+			if tt.setup != nil {
+				tt.setup()
+			}
+			for _, datasources := range [][]NamedSpec{nil, existing} {
+				tmpl := &GrasTemplate{Grapi: GrapiSection{Datasources: append([]NamedSpec(nil), datasources...)}}
+				if err := tt.update(tmpl); err != nil {
+					t.Fatalf("update error = %v", err)
+				}
+				got := tmpl.Grapi.Datasources
+				if len(got) == 0 || got[0].Name != tt.wantName || !reflect.DeepEqual(got[0].Spec, tt.wantSpec) {
+					t.Errorf("datasources = %#v, want %s with %#v first", got, tt.wantName, tt.wantSpec)
+				}
+				// Only the datasource of --db-type is replaced, the others are kept
+				if tt.wantReplaced && len(datasources) > 1 && (len(got) != 2 || got[1].Name != "second") {
+					t.Errorf("datasources = %#v, want the second datasource kept", got)
+				}
+				if !reflect.DeepEqual(tmpl.Grapi.ExtraSecrets, tt.wantSecrets) {
+					t.Errorf("extraSecrets = %v, want %v", tmpl.Grapi.ExtraSecrets, tt.wantSecrets)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateTemplateForRestcruds(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		extra    []extraDatasource
+		want     []NamedSpec
+	}{
+		{name: "db file", template: utils.DB_FILE,
+			want: []NamedSpec{{Name: "restcrud", Spec: map[string]interface{}{"datasource": "db"}}}},
+		{name: "mysql", template: utils.DB_MYSQL_MODEL_BASED,
+			want: []NamedSpec{{Name: "shopdb", Spec: map[string]interface{}{"datasource": "shopdb"}}}},
+		{name: "mysql with extra datasources", template: utils.DB_MYSQL_DISCOVERY_BASED, extra: []extraDatasource{{Name: "legacy"}},
+			want: []NamedSpec{
+				{Name: "shopdb", Spec: map[string]interface{}{"datasource": "shopdb"}},
+				{Name: "legacy", Spec: map[string]interface{}{"datasource": "legacy"}},
+			}},
+		{name: "other templates are left alone", template: "custom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDeployFlags(t, tt.template, "shopdb")
+			extraDatasources = tt.extra
+			tmpl := &GrasTemplate{}
+			if err := updateTemplateForRestcruds(tmpl); err != nil {
+				t.Fatalf("updateTemplateForRestcruds() error = %v", err)
+			}
+			if !reflect.DeepEqual(tmpl.Grapi.Restcruds, tt.want) {
+				t.Errorf("restcruds = %#v, want %#v", tmpl.Grapi.Restcruds, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateTemplateForInitContainers(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		sourceData  string
+		wantName    string
+		wantImage   string
+		wantCommand string
+	}{
+		{name: "mysql creates the database", template: utils.DB_MYSQL_MODEL_BASED, wantName: "init-db", wantImage: "mysql",
+			wantCommand: "CREATE DATABASE IF NOT EXISTS shopdb;"},
+		{name: "mysql loads the source data", template: utils.DB_MYSQL_DISCOVERY_BASED, sourceData: "https://example.com/shop.sql",
+			wantName: "init-db", wantImage: "mysql", wantCommand: "curl -o /tmp/shopdb.sql https://example.com/shop.sql"},
+		{name: "db file downloads the file", template: utils.DB_FILE, sourceData: "https://example.com/db.json",
+			wantName: "test", wantImage: "busybox:1.28", wantCommand: "wget -O /data/db.json https://example.com/db.json"},
+		{name: "other templates have none", template: "custom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDeployFlags(t, tt.template, "shopdb")
+			withNamespaceServer(t)
+			SourceData, DBFilePath = tt.sourceData, "/data/db.json"
+			tmpl := &GrasTemplate{}
+			if err := updateTemplateForInitContainers(tmpl, true); err != nil {
+				t.Fatalf("updateTemplateForInitContainers() error = %v", err)
+			}
+			if tt.wantName == "" {
+				if len(tmpl.Grapi.InitContainers) != 0 {
+					t.Errorf("initContainers = %#v, want none", tmpl.Grapi.InitContainers)
+				}
+				return
+			}
+			if len(tmpl.Grapi.InitContainers) != 1 {
+				t.Fatalf("initContainers = %#v, want one", tmpl.Grapi.InitContainers)
+			}
+			container := tmpl.Grapi.InitContainers[0]
+			if container.Name != tt.wantName {
+				t.Errorf("init container name = %s, want %s", container.Name, tt.wantName)
+			}
+			if image, _ := container.Spec["image"].(string); !strings.HasSuffix(image, tt.wantImage) {
+				t.Errorf("init container image = %s, want %s", image, tt.wantImage)
+			}
+			command, _ := container.Spec["command"].([]string)
+			if len(command) != 3 || !strings.Contains(command[2], tt.wantCommand) {
+				t.Errorf("init container command = %q, want it to contain %q", command, tt.wantCommand)
+			}
+			// The namespace enforces no Pod Security Standard, the containers may run as root
+			securityContext, _ := container.Spec["securityContext"].(map[string]interface{})
+			if securityContext == nil || securityContext["runAsNonRoot"] != nil || container.Spec["resources"] == nil {
+				t.Errorf("init container securityContext = %#v, resources = %#v, want hardened without runAsNonRoot", securityContext, container.Spec["resources"])
+			}
+		})
+	}
+}
+
+func TestGrasTemplateRoundTrip(t *testing.T) {
+	input := `gras:
+  name: shop
+grapi:
+  models:
+  - name: customer
+    spec:
+      base: Entity
+  ingress:
+    enabled: true
+  volumes:
+  - name: data
+gruim:
+  enabled: true
+controller:
+  replicas: 2
+`
+	tmpl := &GrasTemplate{}
+	if err := yaml.Unmarshal([]byte(input), tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Grapi.Extra["ingress"] == nil || tmpl.Grapi.Extra["volumes"] == nil {
+		t.Errorf("grapi.Extra = %#v, want ingress and volumes", tmpl.Grapi.Extra)
+	}
+	if tmpl.Extra["controller"] == nil {
+		t.Errorf("Extra = %#v, want controller", tmpl.Extra)
+	}
+
+	if err := transformModelInputToYAML(`order:{}`, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	data, err := tmpl.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got, want map[string]interface{}
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal([]byte(input), &want); err != nil {
+		t.Fatal(err)
+	}
+	wantModels := want["grapi"].(map[interface{}]interface{})["models"].([]interface{})
+	want["grapi"].(map[interface{}]interface{})["models"] = append(wantModels, map[interface{}]interface{}{"name": "order", "spec": map[interface{}]interface{}{}})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() =\n%s\nwant the input with the order model added", data)
+	}
+}
+
+func TestValuesHash(t *testing.T) {
+	hash := func(vals map[string]interface{}) string {
+		t.Helper()
+		sum, err := valuesHash(vals)
+		if err != nil {
+			t.Fatalf("valuesHash() error = %v", err)
+		}
+		return sum
+	}
+	tests := []struct {
+		name  string
+		a, b  map[string]interface{}
+		equal bool
+	}{
+		{name: "nil and empty", a: nil, b: map[string]interface{}{}, equal: true},
+		{name: "same values", a: map[string]interface{}{"a": "1", "b": map[string]interface{}{"c": 2}}, b: map[string]interface{}{"b": map[string]interface{}{"c": 2}, "a": "1"}, equal: true},
+		{name: "rendered and read back numbers", a: map[string]interface{}{"replicas": 2}, b: map[string]interface{}{"replicas": float64(2)}, equal: true},
+		{name: "changed value", a: map[string]interface{}{"a": "1"}, b: map[string]interface{}{"a": "2"}},
+		{name: "nested change", a: map[string]interface{}{"b": map[string]interface{}{"c": 2}}, b: map[string]interface{}{"b": map[string]interface{}{"c": 3}}},
+		{name: "added key", a: map[string]interface{}{"a": "1"}, b: map[string]interface{}{"a": "1", "b": "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if equal := hash(tt.a) == hash(tt.b); equal != tt.equal {
+				t.Errorf("valuesHash(%v) == valuesHash(%v) is %v, want %v", tt.a, tt.b, equal, tt.equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package resource
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var templatePullVersion string
+
+// templateInfo is a template type listed by 'grapple resource templates list'
+type templateInfo struct {
+	Name        string `json:"name" yaml:"name"`
+	Source      string `json:"source" yaml:"source"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Dir         string `json:"dir,omitempty" yaml:"dir,omitempty"`
+}
+
+// templateFileSource is where 'grapple resource templates which' found a file of template-files or files
+type templateFileSource struct {
+	File       string                    `json:"file" yaml:"file"`
+	Used       string                    `json:"used,omitempty" yaml:"used,omitempty"`
+	Source     string                    `json:"source,omitempty" yaml:"source,omitempty"`
+	Candidates []utils.ResourceCandidate `json:"candidates" yaml:"candidates"`
+}
+
+// resourceSubdirs are the directories of the installation the template and files lookups read from
+var resourceSubdirs = []string{"template-files", "files"}
+
+// TemplatesCmd represents the resource templates command
+var TemplatesCmd = &cobra.Command{
+	Use:     "templates",
+	Aliases: []string{"template"},
+	Short:   "Manage GRAS template types and export or import GrappleApplicationSet manifests",
+	Long: `Export writes the spec of a deployed GRAS to a manifest for Git, import deploys such a manifest again.
+
+Besides the built-in template types (db-file, db-mysql-model-based, ...), resource deploy and render accept
+template plugins: directories of the templates directory (~/.config/grpl/templates, or the templates-dir
+setting) with a template.yaml manifest:
+
+  name: db-postgres
+  description: grapi on an external PostgreSQL database
+  base: gras.yaml          # GRAS template the plugin starts from, like template-files/db.yaml
+  dbType: external         # used when --db-type is not set
+  prompts:
+    - name: schema
+      label: Enter the database schema
+      default: public
+      pattern: ^[a-z_]+$
+    - name: tier
+      options: [small, large]
+  values:                  # dotted template paths set from the answers
+    grapi.env.DB_SCHEMA: "{{ .Values.schema }}"
+    gras.tier: "{{ .Values.tier }}"
+
+The value templates see the answers as .Values, the GRAS name as .Name and the namespace as .Namespace.
+Prompts are answered with --template-value <name>=<value> or interactively.
+
+Plugins are shared through OCI registries packaged as helm charts: a Chart.yaml next to template.yaml and the
+base template, packaged with 'helm package' and pushed with 'helm push'. 'grapple resource templates pull'
+installs them, so does --gras-template oci://<ref>.
+
+The files of the built-in templates (template-files/db.yaml, files/clusterissuer.yaml, ...) are looked up in
+this order, the first one that has the file is used:
+
+  1. the directory of the --workdir flag
+  2. the directory of the workdir setting (GRPL_WORKDIR)
+  3. ./grpl-templates of the current directory, for project-local overrides
+  4. the share directory of the installation
+
+Overrides only need the files they change, e.g. ./grpl-templates/template-files/db.yaml.
+'grapple resource templates which' shows which one is used.`,
+}
+
+// TemplatesListCmd represents the resource templates list command
+var TemplatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the built-in template types and the installed template plugins",
+	Long: `List the template types resource deploy and render accept: the built-in ones and the template plugins of the
+templates directory.
+
+Example:
+  grapple resource templates list
+  grapple resource templates list -o json`,
+	Args: cobra.NoArgs,
+	RunE: runTemplatesList,
+}
+
+// TemplatesPullCmd represents the resource templates pull command
+var TemplatesPullCmd = &cobra.Command{
+	Use:   "pull <oci-ref>",
+	Short: "Install a template plugin from an OCI registry",
+	Long: `Pull a template plugin packaged as a helm chart from an OCI registry into the templates directory, replacing
+an installed version of it. The credentials of the chart registry settings are used.
+
+Example:
+  grapple resource templates pull oci://registry.corp.com/grapple-templates/db-postgres --version 1.2.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, err := pullTemplatePlugin(args[0], templatePullVersion)
+		return err
+	},
+}
+
+// TemplateWhichCmd represents the resource templates which command
+var TemplateWhichCmd = &cobra.Command{
+	Use:   "which <file>",
+	Short: "Show which source a file of template-files or files is read from",
+	Long: `Show the places a file of template-files or files is looked up in, in order of precedence (--workdir,
+GRPL_WORKDIR, ./grpl-templates, the installation), and which one is used. A file without a directory is looked
+up in template-files, then files.
+
+Example:
+  grapple resource templates which template-files/db.yaml
+  grapple resource templates which clusterissuer.yaml
+  grapple resource templates which db.yaml --workdir ./my-templates -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplateWhich,
+}
+
+func init() {
+	TemplatesCmd.AddCommand(TemplatesListCmd)
+	TemplatesCmd.AddCommand(TemplatesPullCmd)
+	TemplatesCmd.AddCommand(TemplateWhichCmd)
+	TemplatesCmd.AddCommand(TemplateExportCmd)
+	TemplatesCmd.AddCommand(TemplateImportCmd)
+	TemplatesCmd.AddCommand(TemplateImportSchemaCmd)
+	TemplatesPullCmd.Flags().StringVar(&templatePullVersion, "version", "", "Version of the plugin (default: the latest)")
+}
+
+func runTemplatesList(cmd *cobra.Command, args []string) error {
+	var templates []templateInfo
+	for _, name := range utils.GrasTemplates {
+		templates = append(templates, templateInfo{Name: name, Source: "built-in"})
+	}
+	plugins, err := listTemplatePlugins()
+	if err != nil {
+		return err
+	}
+	for _, plugin := range plugins {
+		templates = append(templates, templateInfo{Name: plugin.Name, Source: "plugin", Description: plugin.Description, Dir: plugin.Dir})
+	}
+
+	return utils.PrintResult(templates, func() {
+		fmt.Printf("%-30s %-10s %s\n", "NAME", "SOURCE", "DESCRIPTION")
+		for _, t := range templates {
+			fmt.Printf("%-30s %-10s %s\n", t.Name, t.Source, t.Description)
+		}
+	})
+}
+
+func runTemplateWhich(cmd *cobra.Command, args []string) error {
+	file := filepath.ToSlash(filepath.Clean(args[0]))
+	subdirs := resourceSubdirs
+	name := file
+	if dir, base, found := strings.Cut(file, "/"); found {
+		if !utils.Contains(resourceSubdirs, dir) || strings.Contains(base, "/") {
+			return fmt.Errorf("%w: %s is not a file of %s", utils.ErrValidation, args[0], strings.Join(resourceSubdirs, " or "))
+		}
+		subdirs, name = []string{dir}, base
+	}
+
+	var result templateFileSource
+	for _, subdir := range subdirs {
+		source := templateFileSource{File: subdir + "/" + name, Candidates: utils.ResourceCandidates(subdir, name)}
+		for _, candidate := range source.Candidates {
+			if candidate.Exists {
+				source.Used, source.Source = candidate.Path, candidate.Source
+				break
+			}
+		}
+		result = source
+		if source.Used != "" {
+			break
+		}
+	}
+	if result.Used == "" && len(subdirs) > 1 {
+		return fmt.Errorf("%s not found in %s of the workdir, ./%s or the installation", name, strings.Join(subdirs, " or "), utils.ProjectResourcesDir)
+	}
+
+	return utils.PrintResult(result, func() {
+		fmt.Printf("%s\n", result.File)
+		for _, candidate := range result.Candidates {
+			marker := " "
+			switch {
+			case candidate.Path == result.Used:
+				marker = "*"
+			case !candidate.Exists:
+				marker = "-"
+			}
+			fmt.Printf("  %s %-14s %s\n", marker, candidate.Source, candidate.Path)
+		}
+		if result.Used == "" {
+			fmt.Println("  not found")
+		}
+	})
+}
@@ -0,0 +1,328 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	testAPIURL    string
+	testAPIModels []string
+)
+
+// apiTestStep is the outcome of one request of a model test
+type apiTestStep struct {
+	Passed    bool   `json:"passed" yaml:"passed"`
+	Status    int    `json:"status,omitempty" yaml:"status,omitempty"`
+	LatencyMs int64  `json:"latencyMs" yaml:"latencyMs"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// apiModelTest is the outcome of the CRUD test of a model
+type apiModelTest struct {
+	Model  string      `json:"model" yaml:"model"`
+	Path   string      `json:"path" yaml:"path"`
+	Create apiTestStep `json:"create" yaml:"create"`
+	Read   apiTestStep `json:"read" yaml:"read"`
+	Update apiTestStep `json:"update" yaml:"update"`
+	Delete apiTestStep `json:"delete" yaml:"delete"`
+	Passed bool        `json:"passed" yaml:"passed"`
+
+	id     interface{}
+	record map[string]interface{}
+}
+
+// apiTestReport is the result of 'grapple resource test-api'
+type apiTestReport struct {
+	GRAS      string         `json:"gras" yaml:"gras"`
+	Namespace string         `json:"namespace" yaml:"namespace"`
+	URL       string         `json:"url" yaml:"url"`
+	Models    []apiModelTest `json:"models" yaml:"models"`
+	Failed    int            `json:"failed" yaml:"failed"`
+	Passed    bool           `json:"passed" yaml:"passed"`
+}
+
+// TestAPICmd represents the resource test-api command
+var TestAPICmd = &cobra.Command{
+	Use:   "test-api [gras-name]",
+	Short: "Run generated CRUD requests against the grapi endpoints of a deployed GRAS",
+	Long: `Test-api is a quick functional check of the API generated for a GrappleApplicationSet. It reads the models
+grapi serves from its OpenAPI spec and, per model, creates a temporary record from sample values, reads it,
+updates it and deletes it again, reporting pass/fail and the latency of each request.
+
+Models are tested in the order of their relations, so the foreign keys of a record reference the temporary
+record of the related model. The temporary records are deleted in reverse order at the end.
+
+grapi is reached through its ingress, set --url to test another address, e.g. the one of
+'grapple resource port-forward'. Without a GRAS name, the GRAS is selected among the GRAS resources of
+--namespace (or of the cluster). The command fails if any request fails.
+
+Example:
+  grapple resource test-api shop --namespace shop
+  grapple resource test-api shop --model customer --model order -o json
+  grapple resource test-api shop --url http://localhost:3000`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTestAPI,
+}
+
+func init() {
+	TestAPICmd.Flags().StringVar(&KubeNS, "namespace", "", "Namespace of the GRAS resource")
+	TestAPICmd.Flags().StringVar(&testAPIURL, "url", "", "URL of grapi (default: the ingress of the GRAS)")
+	TestAPICmd.Flags().StringSliceVar(&testAPIModels, "model", nil, "Only test these models (repeatable)")
+}
+
+func runTestAPI(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		GRASName = args[0]
+	}
+
+	var err error
+	restConfig, clientset, err = utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+	if err := resolveGrasName(); err != nil {
+		return err
+	}
+
+	grapiURL := strings.TrimSuffix(testAPIURL, "/")
+	if grapiURL == "" {
+		if grapiURL, err = grapiIngressURL(); err != nil {
+			return err
+		}
+	}
+	spec, err := fetchGrapiOpenAPI(grapiURL)
+	if err != nil {
+		return fmt.Errorf("grapi of GRAS %s doesn't serve its API at %s: %w", GRASName, grapiURL, err)
+	}
+
+	models, err := selectTestModels(seedModelsFromOpenAPI(spec))
+	if err != nil {
+		return err
+	}
+	relations := map[string][]seedRelation{}
+	if gras, err := utils.GetGras(restConfig, KubeNS, GRASName); err == nil {
+		relations = seedRelations(grasRelationTemplate(gras))
+	} else {
+		utils.InfoMessage(fmt.Sprintf("Failed to read the relations of GRAS %s, foreign keys are left unset: %v", GRASName, err))
+	}
+
+	report := apiTestReport{GRAS: GRASName, Namespace: KubeNS, URL: grapiURL}
+	utils.InfoMessage(fmt.Sprintf("Testing %d models of %s...", len(models), grapiURL))
+	tests := runModelTests(grapiURL, seedOrder(models, relations), relations)
+	for _, test := range tests {
+		if !test.Passed {
+			report.Failed++
+		}
+	}
+	report.Models = tests
+	report.Passed = report.Failed == 0
+
+	if err := utils.PrintResult(report, func() { printAPITestReport(&report) }); err != nil {
+		return err
+	}
+	if !report.Passed {
+		return fmt.Errorf("API test failed: %d of %d models failed", report.Failed, len(report.Models))
+	}
+	return nil
+}
+
+// selectTestModels returns the models of --model, all models without it
+func selectTestModels(models []seedModel) ([]seedModel, error) {
+	if len(models) == 0 {
+		return nil, fmt.Errorf("grapi of GRAS %s serves no models with REST endpoints", GRASName)
+	}
+	if len(testAPIModels) == 0 {
+		return models, nil
+	}
+	var selected []seedModel
+	var names []string
+	for _, model := range models {
+		names = append(names, model.Name)
+	}
+	for _, name := range testAPIModels {
+		found := false
+		for _, model := range models {
+			if strings.EqualFold(model.Name, name) {
+				selected = append(selected, model)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: grapi serves no model %s, it serves %s", utils.ErrValidation, name, strings.Join(names, ", "))
+		}
+	}
+	return selected, nil
+}
+
+// runModelTests creates, reads and updates a temporary record per model, then deletes the records in reverse
+// order, so the records referenced by foreign keys are deleted last
+func runModelTests(grapiURL string, models []seedModel, relations map[string][]seedRelation) []apiModelTest {
+	tests := make([]apiModelTest, len(models))
+	// ids holds the id of the temporary record by lower case model name
+	ids := map[string]interface{}{}
+	for i, model := range models {
+		test := &tests[i]
+		test.Model, test.Path = model.Name, model.Path
+		key := strings.ToLower(model.Name)
+
+		test.record = sampleRecord(model, 1)
+		for _, rel := range relations[key] {
+			if id, ok := ids[rel.Target]; ok {
+				test.record[rel.ForeignKey] = id
+			}
+		}
+		var created []byte
+		test.Create, created = apiRequest(http.MethodPost, grapiURL+model.Path, test.record)
+		if !test.Create.Passed {
+			continue
+		}
+		test.id = createdRecordID(created, model.IDProperties)
+		if test.id == nil {
+			test.Create.Passed = false
+			test.Create.Error = "the created record has no id"
+			continue
+		}
+		ids[key] = test.id
+		recordURL := fmt.Sprintf("%s%s/%v", grapiURL, model.Path, test.id)
+
+		test.Read, _ = apiRequest(http.MethodGet, recordURL, nil)
+		update := sampleRecord(model, 2)
+		for _, rel := range relations[key] {
+			if id, ok := test.record[rel.ForeignKey]; ok {
+				update[rel.ForeignKey] = id
+			}
+		}
+		test.Update, _ = apiRequest(http.MethodPatch, recordURL, update)
+	}
+
+	for i := len(tests) - 1; i >= 0; i-- {
+		test := &tests[i]
+		if test.id != nil {
+			test.Delete, _ = apiRequest(http.MethodDelete, fmt.Sprintf("%s%s/%v", grapiURL, test.Path, test.id), nil)
+		}
+		test.Passed = test.Create.Passed && test.Read.Passed && test.Update.Passed && test.Delete.Passed
+	}
+	return tests
+}
+
+// apiRequest sends a request with an optional JSON body and returns its outcome and response body
+func apiRequest(method, url string, body map[string]interface{}) (apiTestStep, []byte) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return apiTestStep{Error: fmt.Sprintf("failed to marshal record: %v", err)}, nil
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return apiTestStep{Error: err.Error()}, nil
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	resp, err := seedHTTPClient.Do(req)
+	step := apiTestStep{LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		step.Error = err.Error()
+		return step, nil
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	step.Status = resp.StatusCode
+	if resp.StatusCode >= 300 {
+		step.Error = fmt.Sprintf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+		return step, data
+	}
+	step.Passed = true
+	return step, data
+}
+
+// createdRecordID returns the id of a record created by grapi, nil if it has none
+func createdRecordID(data []byte, idProperties []string) interface{} {
+	created := map[string]interface{}{}
+	if err := json.Unmarshal(data, &created); err != nil {
+		return nil
+	}
+	for _, property := range idProperties {
+		if id, ok := created[property]; ok {
+			return id
+		}
+	}
+	return created["id"]
+}
+
+// grasRelationTemplate returns a template holding the relations of all grapis of a deployed GRAS
+func grasRelationTemplate(gras *unstructured.Unstructured) *GrasTemplate {
+	tmpl := &GrasTemplate{}
+	grapis, _, _ := unstructured.NestedSlice(gras.Object, "spec", "grapis")
+	for _, grapi := range grapis {
+		grapiMap, ok := grapi.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		relations, _, _ := unstructured.NestedSlice(grapiMap, "spec", "relations")
+		for _, relation := range relations {
+			entry, ok := relation.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			spec, _ := entry["spec"].(map[string]interface{})
+			tmpl.Grapi.Relations = append(tmpl.Grapi.Relations, NamedSpec{Name: fmt.Sprint(entry["name"]), Spec: spec})
+		}
+	}
+	return tmpl
+}
+
+func printAPITestReport(report *apiTestReport) {
+	fmt.Printf("GRAS %s/%s, grapi at %s\n\n", report.Namespace, report.GRAS, report.URL)
+	fmt.Printf("%-30s %-12s %-12s %-12s %-12s\n", "MODEL", "CREATE", "READ", "UPDATE", "DELETE")
+	for _, test := range report.Models {
+		fmt.Printf("%-30s %-12s %-12s %-12s %-12s\n", test.Model,
+			formatAPITestStep(test.Create), formatAPITestStep(test.Read), formatAPITestStep(test.Update), formatAPITestStep(test.Delete))
+	}
+	for _, test := range report.Models {
+		for _, step := range []struct {
+			name string
+			step apiTestStep
+		}{{"create", test.Create}, {"read", test.Read}, {"update", test.Update}, {"delete", test.Delete}} {
+			if step.step.Error != "" {
+				utils.ErrorMessage(fmt.Sprintf("%s %s: %s", test.Model, step.name, step.step.Error))
+			}
+		}
+	}
+
+	fmt.Println()
+	if report.Passed {
+		utils.SuccessMessage(fmt.Sprintf("All %d models passed", len(report.Models)))
+	} else {
+		utils.ErrorMessage(fmt.Sprintf("%d of %d models failed", report.Failed, len(report.Models)))
+	}
+}
+
+// formatAPITestStep formats a step as its result and latency, e.g. "ok 12ms", "-" for a skipped step
+func formatAPITestStep(step apiTestStep) string {
+	switch {
+	case step.Passed:
+		return fmt.Sprintf("ok %dms", step.LatencyMs)
+	case step.Error == "":
+		return "-"
+	default:
+		return "FAIL"
+	}
+}
@@ -0,0 +1,156 @@
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// relationTypes are the relation types of grapi, as offered by the relation prompt
+var relationTypes = []string{"belongsTo", "hasMany", "hasOne", "referencesMany"}
+
+// modelSpecSchema is the JSON schema of a --models entry
+var modelSpecSchema = fmt.Sprintf(`{
+	"type": "object",
+	"required": ["properties"],
+	"properties": {
+		"base": {"enum": ["Entity", "Model"]},
+		"properties": {
+			"type": "object",
+			"minProperties": 1,
+			"additionalProperties": {
+				"type": "object",
+				"required": ["type"],
+				"properties": {
+					"type": {"enum": %s},
+					"id": {"type": "boolean"},
+					"generated": {"type": "boolean"},
+					"required": {"type": "boolean"},
+					"defaultFn": {"type": "string"}
+				}
+			}
+		}
+	}
+}`, jsonList(propertyTypes))
+
+// relationSpecSchema is the JSON schema of a --relations entry
+var relationSpecSchema = fmt.Sprintf(`{
+	"type": "object",
+	"required": ["relationType", "sourceModel", "destinationModel"],
+	"properties": {
+		"relationType": {"enum": %s},
+		"relationName": {"type": "string"},
+		"sourceModel": {"type": "string", "minLength": 1},
+		"destinationModel": {"type": "string", "minLength": 1},
+		"foreignKeyName": {"type": "string"},
+		"registerInclusionResolver": {"type": "boolean"}
+	}
+}`, jsonList(relationTypes))
+
+// discoverySpecSchema is the JSON schema of a --discoveries entry
+const discoverySpecSchema = `{
+	"type": "object",
+	"required": ["dataSource"],
+	"properties": {
+		"dataSource": {"type": "string", "minLength": 1},
+		"schema": {"type": "string"},
+		"all": {"type": "boolean"},
+		"views": {"type": "boolean"},
+		"relations": {"type": "boolean"},
+		"optionalId": {"type": "boolean"},
+		"disableCamelCase": {"type": "boolean"},
+		"models": {"type": "string"},
+		"outDir": {"type": "string"}
+	}
+}`
+
+func jsonList(values []string) string {
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// validateSpecInputs checks the --models, --relations and --discoveries flags before anything is deployed,
+// so a typo fails the deploy right away instead of producing a broken manifest
+func validateSpecInputs() error {
+	var problems []string
+
+	models, err := parseNamedSpecs(ModelsInput, "models")
+	if err != nil {
+		return err
+	}
+	problems = append(problems, validateNamedSpecs(models, "models", modelSpecSchema)...)
+
+	relations, err := parseNamedSpecs(RelationsInput, "relations")
+	if err != nil {
+		return err
+	}
+	problems = append(problems, validateNamedSpecs(relations, "relations", relationSpecSchema)...)
+	// Models are only known up front for model based templates, discovered models can't be checked
+	if len(models) > 0 {
+		modelNames := map[string]bool{}
+		for _, model := range models {
+			modelNames[model.Name] = true
+		}
+		for i, rel := range relations {
+			for _, key := range []string{"sourceModel", "destinationModel"} {
+				if name, ok := rel.Spec[key].(string); ok && name != "" && !modelNames[name] {
+					problems = append(problems, fmt.Sprintf("--relations entry %d (%s): %s %q is not one of the models passed with --models", i+1, rel.Name, key, name))
+				}
+			}
+		}
+	}
+
+	discoveries, err := parseNamedSpecs(DiscoveriesInput, "discoveries")
+	if err != nil {
+		return err
+	}
+	problems = append(problems, validateNamedSpecs(discoveries, "discoveries", discoverySpecSchema)...)
+	for i, discovery := range discoveries {
+		if all, _ := discovery.Spec["all"].(bool); !all {
+			if models, _ := discovery.Spec["models"].(string); models == "" {
+				problems = append(problems, fmt.Sprintf("--discoveries entry %d (%s): models is required when all is not true", i+1, discovery.Name))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid input:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// validateNamedSpecs validates every spec against the schema and returns a message per violation
+func validateNamedSpecs(specs []NamedSpec, flag, schema string) []string {
+	var problems []string
+	schemaLoader := gojsonschema.NewStringLoader(schema)
+	for i, spec := range specs {
+		if spec.Name == "" {
+			problems = append(problems, fmt.Sprintf("--%s entry %d: name is empty", flag, i+1))
+		}
+		result, err := gojsonschema.Validate(schemaLoader, gojsonschema.NewGoLoader(spec.Spec))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("--%s entry %d (%s): %v", flag, i+1, spec.Name, err))
+			continue
+		}
+		for _, desc := range result.Errors() {
+			problems = append(problems, fmt.Sprintf("--%s entry %d (%s): %s: %s", flag, i+1, spec.Name, desc.Field(), desc.Description()))
+		}
+	}
+	return problems
+}
+
+// jsonErrorPosition describes where a JSON document failed to parse
+func jsonErrorPosition(err error) string {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Sprintf(" at character %d", syntaxErr.Offset)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf(" at character %d", typeErr.Offset)
+	}
+	return ""
+}
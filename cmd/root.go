@@ -3,15 +3,38 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/grapple-solution/grapple_cli/cmd/ai"
 	"github.com/grapple-solution/grapple_cli/cmd/application"
+	"github.com/grapple-solution/grapple_cli/cmd/cache"
 	"github.com/grapple-solution/grapple_cli/cmd/civo" // Import the civo package
+	"github.com/grapple-solution/grapple_cli/cmd/cluster"
+	"github.com/grapple-solution/grapple_cli/cmd/completion"
+	"github.com/grapple-solution/grapple_cli/cmd/config"
 	"github.com/grapple-solution/grapple_cli/cmd/dev"
+	"github.com/grapple-solution/grapple_cli/cmd/docs"
+	"github.com/grapple-solution/grapple_cli/cmd/doks"
 	"github.com/grapple-solution/grapple_cli/cmd/example" // Import the example package
+	"github.com/grapple-solution/grapple_cli/cmd/execenv"
+	"github.com/grapple-solution/grapple_cli/cmd/gke"
+	"github.com/grapple-solution/grapple_cli/cmd/housekeeping"
+	"github.com/grapple-solution/grapple_cli/cmd/install"
 	"github.com/grapple-solution/grapple_cli/cmd/k3d"
+	"github.com/grapple-solution/grapple_cli/cmd/license"
+	"github.com/grapple-solution/grapple_cli/cmd/preflight"
+	"github.com/grapple-solution/grapple_cli/cmd/proxy"
 	"github.com/grapple-solution/grapple_cli/cmd/resource"
+	"github.com/grapple-solution/grapple_cli/cmd/sbom"
+	"github.com/grapple-solution/grapple_cli/cmd/selftest"
+	"github.com/grapple-solution/grapple_cli/cmd/selfupdate"
+	"github.com/grapple-solution/grapple_cli/cmd/ssl"
+	"github.com/grapple-solution/grapple_cli/cmd/status"
+	"github.com/grapple-solution/grapple_cli/cmd/uninstall"
+	"github.com/grapple-solution/grapple_cli/cmd/upgrade"
+	"github.com/grapple-solution/grapple_cli/cmd/verify"
 	"github.com/grapple-solution/grapple_cli/cmd/version"
+	"github.com/grapple-solution/grapple_cli/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -20,25 +43,106 @@ var rootCmd = &cobra.Command{
 	Use:   "grapple",
 	Short: "A CLI tool for managing Civo and Kubernetes clusters",
 	Long:  "Grapple CLI is a tool for managing cloud and Kubernetes operations.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := utils.ValidateOutputFormat(); err != nil {
+			return err
+		}
+		if err := utils.ValidateProgressFormat(); err != nil {
+			return err
+		}
+		if err := utils.ValidatePollIntervals(); err != nil {
+			return err
+		}
+		if utils.Verbose && utils.Quiet {
+			return fmt.Errorf("%w: --verbose and --quiet are mutually exclusive", utils.ErrValidation)
+		}
+		if err := utils.ApplyConfig(cmd); err != nil {
+			return err
+		}
+		if utils.KubeContext != "" {
+			utils.PinKubeContext(utils.KubeContext)
+		}
+		if utils.Kubeconfig != "" {
+			// helm, kubectl, k3d and devspace read the kubeconfig from KUBECONFIG
+			os.Setenv("KUBECONFIG", utils.Kubeconfig)
+		}
+		utils.RememberKubeContext()
+		if utils.OutputFormat == utils.OutputText {
+			utils.CheckForUpdates()
+		}
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main().
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	start := time.Now()
+	// Ctrl+C and SIGTERM cancel the context of the command, so waits and helm actions stop cleanly
+	ctx, stop := utils.HandleInterrupts()
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	stop()
+	utils.CloseSSHTunnels()
+	utils.RecordTelemetry(cmd, time.Since(start), err)
+	utils.WaitForVersionCheck()
+	// Provider commands connect to their cluster, switch the current context back unless that is their purpose
+	utils.RestoreKubeContext(cmd.Annotations[utils.AnnotationSwitchesKubeContext] == "true")
+	if err != nil {
+		os.Exit(utils.ReportError(err))
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVarP(&utils.OutputFormat, "output", "o", utils.OutputText, "Output format of command results: text, json or yaml")
+	rootCmd.PersistentFlags().DurationVar(&utils.WaitTimeout, "timeout", utils.WaitTimeout, "Timeout of each readiness wait (e.g. grsf-init, grsf, grsf-config)")
+	rootCmd.PersistentFlags().DurationVar(&utils.PollInterval, "poll-interval", utils.PollInterval, "First interval of polling waits, it doubles after every attempt up to --poll-max-interval")
+	rootCmd.PersistentFlags().DurationVar(&utils.PollMaxInterval, "poll-max-interval", utils.PollMaxInterval, "Longest interval of polling waits")
+	rootCmd.PersistentFlags().StringVar(&utils.ChartRegistry, "chart-registry", "", "OCI registry (mirror) the Grapple charts are pulled from (default: oci://public.ecr.aws/p7h7z5g3)")
+	rootCmd.PersistentFlags().StringVar(&utils.ImageRegistry, "image-registry", "", "Registry mirror prefixed to the images of Grapple and its charts")
+	rootCmd.PersistentFlags().StringVar(&utils.HelmDriver, "helm-driver", "", "Storage backend of the helm releases: secret, configmap, memory or sql (default: $HELM_DRIVER or secret)")
+	rootCmd.PersistentFlags().DurationVar(&utils.WaitProgressInterval, "progress-interval", utils.WaitProgressInterval, "How often long running waits report elapsed time, 0 disables the reports")
+	rootCmd.PersistentFlags().StringVar(&utils.ProgressFormat, "progress-format", utils.ProgressText, "Format of install progress: text, or json to emit a JSON line per install step (started, succeeded, failed)")
+	rootCmd.PersistentFlags().StringVar(&utils.KubeContext, "kube-context", "", "Kubeconfig context to use instead of the current context")
+	rootCmd.PersistentFlags().StringVar(&utils.Kubeconfig, "kubeconfig", "", "Kubeconfig file to use (default: the files of $KUBECONFIG, or ~/.kube/config)")
+	rootCmd.PersistentFlags().BoolVarP(&utils.Verbose, "verbose", "v", false, "Also print debug messages, the log file of the command always has them")
+	rootCmd.PersistentFlags().BoolVarP(&utils.Quiet, "quiet", "q", false, "Only print errors and command results")
+	rootCmd.PersistentFlags().StringVar(&utils.SSHBastion, "ssh-bastion", "", "Reach the API server of the cluster through this SSH jump host, user@host[:port]")
+	rootCmd.PersistentFlags().StringVar(&utils.SSHKey, "ssh-key", "", "Private key of the SSH bastion (default: the ssh agent and ~/.ssh/id_*)")
+	rootCmd.PersistentFlags().StringVar(&utils.Workdir, "workdir", "", "Directory of template-files and files overriding the ones of $GRPL_WORKDIR, ./grpl-templates and the installation")
+	rootCmd.PersistentFlags().StringVar(&utils.ProgressFile, "progress-file", "", "Write the --progress-format json events to this file instead of stdout")
+
 	// Add the civo command
 	rootCmd.AddCommand(civo.CivoCmd)
 	rootCmd.AddCommand(k3d.K3dCmd)
+	rootCmd.AddCommand(gke.GkeCmd)
+	rootCmd.AddCommand(doks.DoksCmd)
+	rootCmd.AddCommand(cluster.ClusterCmd)
 	rootCmd.AddCommand(example.ExampleCmd)
 	rootCmd.AddCommand(resource.ResourceCmd)
 	rootCmd.AddCommand(application.ApplicationCmd)
 	rootCmd.AddCommand(dev.DevCmd)
 	rootCmd.AddCommand(version.VersionCmd)
+	rootCmd.AddCommand(selftest.SelftestCmd)
 	rootCmd.AddCommand(ai.AiCmd)
+	rootCmd.AddCommand(config.ConfigCmd)
+	rootCmd.AddCommand(upgrade.UpgradeCmd)
+	rootCmd.AddCommand(install.InstallCmd)
+	rootCmd.AddCommand(status.StatusCmd)
+	rootCmd.AddCommand(housekeeping.HousekeepingCmd)
+	rootCmd.AddCommand(ssl.SslCmd)
+	rootCmd.AddCommand(license.LicenseCmd)
+	rootCmd.AddCommand(cache.CacheCmd)
+	rootCmd.AddCommand(sbom.SbomCmd)
+	rootCmd.AddCommand(uninstall.UninstallCmd)
+	rootCmd.AddCommand(verify.VerifyCmd)
+	rootCmd.AddCommand(preflight.PreflightCmd)
+	rootCmd.AddCommand(proxy.ProxyCmd)
+	rootCmd.AddCommand(completion.CompletionCmd)
+	rootCmd.AddCommand(docs.DocsCmd)
+	rootCmd.AddCommand(selfupdate.SelfUpdateCmd)
+	rootCmd.AddCommand(execenv.ExecEnvCmd)
+
+	// cobra's completion command is replaced by the one above, which adds the cluster lookups
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	utils.RegisterDynamicCompletions(rootCmd)
 }
@@ -0,0 +1,100 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	format          string
+	outputFile      string
+	queryRegistries bool
+)
+
+// SbomCmd represents the sbom command
+var SbomCmd = &cobra.Command{
+	Use:   "sbom",
+	Short: "Generate a software bill of materials of the Grapple installation",
+	Long: `Sbom collects the helm charts and the images (with their digests) running in the Grapple platform
+namespaces (grpl-system, kb-system) and in the namespaces of the GrappleApplicationSets of the cluster of
+the current kubectl context.
+
+The default table format is a third-party license report, --format spdx or --format cyclonedx writes an
+SPDX 2.3 or CycloneDX 1.5 JSON document. With --query-registries the registries of the images are asked
+for the license label of the image and for SBOM attestations (OCI referrers, buildx attestations and
+cosign .att/.sbom tags).
+
+Example:
+  grapple sbom
+  grapple sbom --format cyclonedx --file grapple.cdx.json --query-registries`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if format != utils.SBOMFormatTable && format != utils.SBOMFormatSPDX && format != utils.SBOMFormatCycloneDX {
+			return fmt.Errorf("unsupported format %q, use one of: %s, %s, %s", format, utils.SBOMFormatTable, utils.SBOMFormatSPDX, utils.SBOMFormatCycloneDX)
+		}
+
+		restConfig, kubeClient, err := utils.GetKubernetesConfig()
+		if err != nil {
+			utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+			return err
+		}
+
+		sbom, err := utils.CollectSBOM(kubeClient, restConfig, queryRegistries)
+		if err != nil {
+			return fmt.Errorf("failed to collect the SBOM: %w", err)
+		}
+
+		if format == utils.SBOMFormatTable {
+			return utils.PrintResult(sbom, func() { printLicenseReport(sbom) })
+		}
+
+		document, err := utils.SBOMDocument(sbom, format)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal the SBOM: %w", err)
+		}
+		if outputFile == "" {
+			fmt.Println(string(data))
+			return nil
+		}
+		if err := os.WriteFile(outputFile, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", outputFile, err)
+		}
+		utils.SuccessMessage(fmt.Sprintf("SBOM with %d components written to %s", len(sbom.Components), outputFile))
+		return nil
+	},
+}
+
+func printLicenseReport(sbom *utils.SBOM) {
+	if sbom.GrappleVersion != "" {
+		fmt.Printf("Grapple version: %s\n\n", sbom.GrappleVersion)
+	}
+	fmt.Printf("%-10s %-50s %-20s %-20s %s\n", "TYPE", "NAME", "VERSION", "LICENSE", "NAMESPACES")
+	for _, component := range sbom.Components {
+		license := component.License
+		if license == "" {
+			license = "unknown"
+		}
+		fmt.Printf("%-10s %-50s %-20s %-20s %s\n", component.Type, component.Name, component.Version, license, strings.Join(component.Namespaces, ","))
+	}
+	if !queryRegistries {
+		fmt.Println()
+		utils.InfoMessage("Run with --query-registries to look up the licenses and SBOM attestations of the images")
+	}
+}
+
+func init() {
+	SbomCmd.Flags().StringVar(&format, "format", utils.SBOMFormatTable, "Output format: table (license report), spdx or cyclonedx")
+	SbomCmd.Flags().StringVar(&outputFile, "file", "", "Write the SPDX/CycloneDX document to this file instead of stdout")
+	SbomCmd.Flags().BoolVar(&queryRegistries, "query-registries", false, "Query the image registries for license labels and SBOM attestations")
+}
@@ -0,0 +1,275 @@
+package selftest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	clusterName    string
+	grappleVersion string
+	keepCluster    bool
+	endpointWait   time.Duration
+)
+
+// selftestStep is a single timed stage of the self test
+type selftestStep struct {
+	name     string
+	duration time.Duration
+	err      error
+}
+
+// SelftestCmd represents the selftest command
+var SelftestCmd = &cobra.Command{
+	Use:     "selftest",
+	Aliases: []string{"st"},
+	Short:   "Run an end-to-end test of the CLI on a disposable k3d cluster",
+	Long: `Selftest creates a disposable k3d cluster, installs Grapple, deploys the db-file example,
+verifies that its endpoints respond and tears everything down again.
+
+It reports a single PASS/FAIL result together with the time spent in every step,
+which makes it suitable for CI of the CLI itself as well as for validating a local setup.`,
+	RunE: runSelftest,
+}
+
+func init() {
+	SelftestCmd.Flags().StringVar(&clusterName, "cluster-name", "grpl-selftest", "Name of the disposable k3d cluster")
+	SelftestCmd.Flags().StringVar(&grappleVersion, "grapple-version", "latest", "Version of Grapple to install (default: latest)")
+	SelftestCmd.Flags().BoolVar(&keepCluster, "keep-cluster", false, "Keep the k3d cluster after the test, e.g. to investigate a failure")
+	SelftestCmd.Flags().DurationVar(&endpointWait, "endpoint-timeout", 5*time.Minute, "How long to wait for the example endpoints to respond")
+}
+
+func runSelftest(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_selftest.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Selftest failed, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	binary, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate grapple binary: %w", err)
+	}
+
+	if _, lookErr := exec.LookPath("k3d"); lookErr != nil {
+		err = fmt.Errorf("k3d is required for the selftest, please install it first: %w", lookErr)
+		return err
+	}
+
+	// A leftover cluster from a previous run would make the create step fail
+	if exec.Command("k3d", "cluster", "get", clusterName).Run() == nil {
+		err = fmt.Errorf("k3d cluster '%s' already exists, remove it or choose another --cluster-name", clusterName)
+		return err
+	}
+
+	var steps []selftestStep
+	run := func(name string, fn func() error) error {
+		utils.InfoMessage(fmt.Sprintf("==> %s", name))
+		start := time.Now()
+		stepErr := fn()
+		steps = append(steps, selftestStep{name: name, duration: time.Since(start), err: stepErr})
+		if stepErr != nil {
+			utils.ErrorMessage(fmt.Sprintf("%s failed: %v", name, stepErr))
+		}
+		return stepErr
+	}
+
+	start := time.Now()
+	clusterCreated := false
+
+	err = run("Create cluster and install Grapple", func() error {
+		clusterCreated = true
+		return runGrapple(binary, "k3d", "create-install",
+			"--cluster-name="+clusterName,
+			"--grapple-version="+grappleVersion,
+			"--auto-confirm",
+			// The db-file example needs no KubeBlocks, set explicitly so that unattended runs are not prompted
+			"--install-kubeblocks=false",
+			"--wait")
+	})
+
+	var restConfig *rest.Config
+	if err == nil {
+		err = run("Wait for Grapple to be ready", func() error {
+			config, _, configErr := utils.GetKubernetesConfig()
+			if configErr != nil {
+				return fmt.Errorf("failed to get kubernetes config: %w", configErr)
+			}
+			restConfig = config
+			return utils.WaitForGrappleReady(restConfig)
+		})
+	}
+
+	if err == nil {
+		err = run("Deploy db-file example", func() error {
+			return runGrapple(binary, "example", "deploy",
+				"--gras-template="+utils.DB_FILE,
+				"--wait")
+		})
+	}
+
+	if err == nil {
+		err = run("Verify example endpoints", func() error {
+			return verifyEndpoints(restConfig)
+		})
+	}
+
+	// Teardown always runs, a failed test must not leave a cluster behind
+	if clusterCreated && !keepCluster {
+		if teardownErr := run("Remove cluster", func() error {
+			return runGrapple(binary, "k3d", "remove", "--cluster-name="+clusterName, "-y")
+		}); teardownErr != nil && err == nil {
+			err = teardownErr
+		}
+	} else if keepCluster {
+		utils.InfoMessage(fmt.Sprintf("Keeping cluster '%s', remove it with: grapple k3d remove --cluster-name=%s -y", clusterName, clusterName))
+	}
+
+	printSummary(steps, time.Since(start), err)
+	return err
+}
+
+// runGrapple runs a subcommand of the current grapple binary, streaming its output
+func runGrapple(binary string, args ...string) error {
+	c := exec.Command(binary, args...)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Stdin = os.Stdin
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("grapple %s: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// verifyEndpoints checks that the remote entry of every deployed gruim and the matching grapi are reachable
+func verifyEndpoints(restConfig *rest.Config) error {
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	clusterDomain, err := utils.ExtractDomainFromGrplConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to extract cluster domain: %w", err)
+	}
+	sslEnabled, err := utils.IsSSLEnabled(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to check SSL status: %w", err)
+	}
+	httpPrefix := "http"
+	if sslEnabled {
+		httpPrefix = "https"
+	}
+
+	muimGVR := schema.GroupVersionResource{
+		Group:    "grsf.grpl.io",
+		Version:  "v1alpha1",
+		Resource: "manageduimodules",
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	progress := utils.StartWaitProgress("example endpoints", endpointWait)
+	defer progress.Stop()
+
+	deadline := time.Now().Add(endpointWait)
+	var lastErr error
+	for time.Now().Before(deadline) {
+		lastErr = func() error {
+			muims, err := dynamicClient.Resource(muimGVR).List(context.TODO(), v1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list MUIM resources: %w", err)
+			}
+			if len(muims.Items) == 0 {
+				return fmt.Errorf("no MUIM resource found")
+			}
+
+			for _, muim := range muims.Items {
+				remoteEntry, _, _ := unstructured.NestedString(muim.Object, "spec", "remoteentry")
+				if remoteEntry == "" {
+					return fmt.Errorf("MUIM %s/%s has no remote entry yet", muim.GetNamespace(), muim.GetName())
+				}
+				slash := strings.LastIndex(remoteEntry, "/")
+				if slash < 0 {
+					return fmt.Errorf("MUIM %s/%s has an invalid remote entry %q", muim.GetNamespace(), muim.GetName(), remoteEntry)
+				}
+				uiURL := remoteEntry[:slash]
+				if err := expectStatus(client, uiURL, func(code int) bool { return code == http.StatusOK }); err != nil {
+					return err
+				}
+				utils.SuccessMessage(fmt.Sprintf("gruim is reachable at %s", uiURL))
+
+				// any non server error means the request went through the ingress to grapi
+				grasName := strings.TrimSuffix(muim.GetName(), "-gruim")
+				apiURL := fmt.Sprintf("%s://%s-grapi.%s", httpPrefix, grasName, clusterDomain)
+				if err := expectStatus(client, apiURL, func(code int) bool { return code < http.StatusInternalServerError }); err != nil {
+					return err
+				}
+				utils.SuccessMessage(fmt.Sprintf("grapi is reachable at %s", apiURL))
+			}
+			return nil
+		}()
+		if lastErr == nil {
+			progress.Done("All example endpoints are reachable")
+			return nil
+		}
+		utils.InfoMessage(fmt.Sprintf("Endpoints not ready yet (%v), retrying in 10s...", lastErr))
+		time.Sleep(10 * time.Second)
+	}
+	return fmt.Errorf("endpoints did not become ready within %s: %w", utils.FormatDuration(endpointWait), lastErr)
+}
+
+func expectStatus(client *http.Client, url string, ok func(int) bool) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if !ok(resp.StatusCode) {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return nil
+}
+
+func printSummary(steps []selftestStep, total time.Duration, err error) {
+	fmt.Println()
+	utils.InfoMessage("Selftest summary")
+	for _, step := range steps {
+		status := "ok"
+		if step.err != nil {
+			status = "FAILED"
+		}
+		fmt.Printf("  %-40s %-8s %s\n", step.name, status, utils.FormatDuration(step.duration))
+	}
+	fmt.Printf("  %-40s %-8s %s\n", "Total", "", utils.FormatDuration(total))
+	fmt.Println()
+
+	if err != nil {
+		utils.ErrorMessage("SELFTEST FAIL")
+		return
+	}
+	utils.SuccessMessage("SELFTEST PASS")
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package selfupdate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	channel      string
+	version      string
+	checkOnly    bool
+	skipChecksum bool
+	autoConfirm  bool
+)
+
+// updateResult is the output of 'grapple self-update' with -o json or yaml
+type updateResult struct {
+	Current string `json:"current" yaml:"current"`
+	Latest  string `json:"latest" yaml:"latest"`
+	Channel string `json:"channel" yaml:"channel"`
+	Updated bool   `json:"updated" yaml:"updated"`
+}
+
+// SelfUpdateCmd represents the self-update command
+var SelfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update the Grapple CLI to the latest release",
+	Long: `Update the Grapple CLI to the latest GitHub release of the channel, or to the release given with --version.
+
+The archive for the current OS and architecture is downloaded and its sha256 is verified against the
+checksums.txt of the release, which is verified against its signature (checksums.txt.sig) and the release
+signing key the CLI was built with, before the running executable is replaced. The new binary is staged next to the
+current one and renamed over it, an interrupted update leaves the old CLI in place. The template files of the
+installation are updated as well. The stable channel follows the releases of main, the beta channel includes
+the test releases of develop. Development builds are only updated to the release given with --version.
+
+Installations managed by Homebrew are updated with 'brew upgrade grapple-go-cli' instead.
+
+Example:
+  grapple self-update
+  grapple self-update --check
+  grapple self-update --channel beta
+  grapple self-update --version 0.0.42 --auto-confirm`,
+	RunE: runSelfUpdate,
+}
+
+func init() {
+	SelfUpdateCmd.Flags().StringVar(&channel, "channel", "stable", "Release channel to update from (stable, beta)")
+	SelfUpdateCmd.Flags().StringVar(&version, "version", "", "Install this release instead of the latest one of the channel")
+	SelfUpdateCmd.Flags().BoolVar(&checkOnly, "check", false, "Only check for a newer release, don't install it")
+	SelfUpdateCmd.Flags().BoolVar(&skipChecksum, "skip-checksum", false, "Install releases without verifying their signed checksums.txt (not recommended)")
+	SelfUpdateCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts (default: false)")
+	_ = SelfUpdateCmd.RegisterFlagCompletionFunc("channel", cobra.FixedCompletions([]string{"stable", "beta"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	if channel != "stable" && channel != "beta" {
+		return fmt.Errorf("%w: --channel must be stable or beta, got %q", utils.ErrValidation, channel)
+	}
+
+	current := utils.GetGrappleCliVersion()
+	release, err := utils.FindCLIRelease(channel, version)
+	if err != nil {
+		return err
+	}
+	result := updateResult{Current: current, Latest: release.Version, Channel: channel}
+
+	if version == "" && !isNewer(release.Version, current) {
+		return utils.PrintResult(result, func() {
+			if _, err := semver.NewVersion(current); err != nil {
+				utils.InfoMessage(fmt.Sprintf("Grapple CLI %s is not a release build, install the latest %s release with 'grapple self-update --version %s'", current, channel, release.Version))
+				return
+			}
+			utils.SuccessMessage(fmt.Sprintf("Grapple CLI %s is up to date (latest %s release: %s)", current, channel, release.Version))
+		})
+	}
+	if checkOnly {
+		return utils.PrintResult(result, func() {
+			utils.InfoMessage(fmt.Sprintf("Grapple CLI %s is available (installed: %s), install it with 'grapple self-update --channel %s'", release.Version, current, channel))
+		})
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("failed to resolve executable path: %w", err)
+	}
+	if strings.Contains(executable, "/Cellar/") {
+		return fmt.Errorf("%w: the Grapple CLI was installed with Homebrew, update it with 'brew upgrade grapple-go-cli'", utils.ErrValidation)
+	}
+
+	if !autoConfirm {
+		confirmed, promptErr := utils.PromptConfirm(fmt.Sprintf("Update the Grapple CLI at %s from %s to %s?", executable, current, release.Version))
+		if promptErr != nil || !confirmed {
+			return fmt.Errorf("self-update: %w", utils.ErrUserAborted)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "grapple-self-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	utils.InfoMessage(fmt.Sprintf("Downloading Grapple CLI %s...", release.Version))
+	archive, err := utils.DownloadCLIRelease(release, tmpDir, skipChecksum)
+	if err != nil {
+		return err
+	}
+	releaseDir, err := utils.ExtractCLIArchive(archive, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	binary := "grapple"
+	if runtime.GOOS == "windows" {
+		binary = "grapple.exe"
+	}
+	if err := utils.ReplaceExecutable(executable, filepath.Join(releaseDir, binary)); err != nil {
+		return err
+	}
+
+	// The share directory is next to bin/, as created by install.sh
+	shareDir := filepath.Join(filepath.Dir(filepath.Dir(executable)), "share", "grapple-go-cli")
+	if _, statErr := os.Stat(shareDir); statErr == nil {
+		if err := utils.ReplaceSharedFiles(shareDir, releaseDir, release.Version); err != nil {
+			utils.ErrorMessage(fmt.Sprintf("The CLI was updated, but not its template files in %s: %v", shareDir, err))
+		}
+	}
+
+	result.Updated = true
+	return utils.PrintResult(result, func() {
+		utils.SuccessMessage(fmt.Sprintf("Grapple CLI updated from %s to %s", current, release.Version))
+	})
+}
+
+// isNewer reports whether latest is a newer version than current, development builds without a release version
+// are never replaced by the latest release
+func isNewer(latest, current string) bool {
+	latestVersion, err := semver.NewVersion(latest)
+	if err != nil {
+		return false
+	}
+	currentVersion, err := semver.NewVersion(current)
+	if err != nil {
+		return false
+	}
+	return latestVersion.GreaterThan(currentVersion)
+}
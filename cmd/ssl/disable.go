@@ -0,0 +1,104 @@
+package ssl
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// DisableCmd represents the ssl disable command
+var DisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable SSL of the Grapple installation of the current cluster",
+	Long: `Disable SSL of the Grapple installation of the current cluster.
+
+ssl is unset in the config values of the grsf-config release, the TLS added by 'grapple ssl enable' is
+removed from the ingresses of the cluster domain and the configured ClusterIssuer is deleted (use
+--keep-issuer to keep it). Afterwards the command verifies that the ingress hosts are reachable over http.
+
+Example:
+  grapple ssl disable --keep-issuer`,
+	RunE: runDisable,
+}
+
+func init() {
+	DisableCmd.Flags().BoolVar(&keepIssuer, "keep-issuer", false, "Keep the ClusterIssuer")
+	DisableCmd.Flags().BoolVar(&skipVerify, "skip-verify", false, "Don't verify http reachability")
+	DisableCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts (default: false)")
+}
+
+func runDisable(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_ssl_disable.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to disable SSL, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	restConfig, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+
+	clusterDomain, err := utils.ExtractDomainFromGrplConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	issuer, err := utils.GetSSLIssuer(kubeClient)
+	if err != nil {
+		return err
+	}
+
+	if !autoConfirm {
+		confirmed, promptErr := utils.PromptConfirm(fmt.Sprintf("Disable SSL on %s?", clusterDomain))
+		if promptErr != nil || !confirmed {
+			err = fmt.Errorf("disabling SSL: %w", utils.ErrUserAborted)
+			return err
+		}
+	}
+
+	if err = utils.SetSSLConfig(kubeClient, restConfig, false, ""); err != nil {
+		return err
+	}
+	utils.SuccessMessage("Rendered grsf-config with ssl=false")
+
+	ingresses, err := utils.PatchIngressesTLS(kubeClient, clusterDomain, "")
+	if err != nil {
+		return err
+	}
+	for _, ingress := range ingresses {
+		utils.InfoMessage(fmt.Sprintf("Removed TLS of ingress %s/%s", ingress.Namespace, ingress.Name))
+	}
+
+	if !keepIssuer && issuer != "" {
+		if err = utils.DeleteClusterIssuer(restConfig, issuer); err != nil {
+			return err
+		}
+		utils.InfoMessage(fmt.Sprintf("Deleted ClusterIssuer %s", issuer))
+	}
+
+	if !skipVerify {
+		for _, ingress := range ingresses {
+			if err = utils.CheckReachable(ingress.Hosts[0], false); err != nil {
+				return err
+			}
+			utils.SuccessMessage(fmt.Sprintf("http://%s is reachable", ingress.Hosts[0]))
+		}
+	}
+
+	return utils.PrintResult(map[string]interface{}{"ssl": false, "ingresses": ingresses}, func() {
+		utils.SuccessMessage(fmt.Sprintf("SSL disabled on %s", clusterDomain))
+	})
+}
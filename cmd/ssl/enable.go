@@ -0,0 +1,122 @@
+package ssl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/cmd/k3d"
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// EnableCmd represents the ssl enable command
+var EnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable SSL of the Grapple installation of the current cluster",
+	Long: `Enable SSL of the Grapple installation of the current cluster.
+
+The ClusterIssuer is created (mkcert-ca-issuer on k3d, letsencrypt-grapple-demo otherwise, unless
+--ssl-issuer names an existing one), ssl is set in the config values of the grsf-config release, and the
+ingresses of the cluster domain without TLS of their own get TLS, so cert-manager issues their certificates. Afterwards the command waits for the
+certificates and verifies that the ingress hosts are reachable over https.
+
+Example:
+  grapple ssl enable
+  grapple ssl enable --ssl-issuer=my-issuer`,
+	RunE: runEnable,
+}
+
+func init() {
+	EnableCmd.Flags().StringVar(&sslIssuer, "ssl-issuer", "", "ClusterIssuer to use (default: mkcert-ca-issuer on k3d, letsencrypt-grapple-demo otherwise)")
+	EnableCmd.Flags().BoolVar(&skipVerify, "skip-verify", false, "Don't wait for the certificates and don't verify https reachability")
+	EnableCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts, e.g. to create the local CA on k3d (default: false)")
+}
+
+func runEnable(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_ssl_enable.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, _, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to enable SSL, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	restConfig, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+
+	clusterDomain, err := utils.ExtractDomainFromGrplConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	provider, err := utils.GetClusterProviderType(kubeClient)
+	if err != nil {
+		return err
+	}
+
+	if sslIssuer == "" {
+		sslIssuer = utils.DefaultSSLIssuer
+		if provider == utils.ProviderClusterTypeK3d {
+			sslIssuer = utils.MkcertSSLIssuer
+		}
+	}
+
+	switch sslIssuer {
+	case utils.MkcertSSLIssuer:
+		if provider != utils.ProviderClusterTypeK3d {
+			err = fmt.Errorf("%s is only available on k3d clusters", utils.MkcertSSLIssuer)
+			return err
+		}
+		k3d.SetAutoConfirm(autoConfirm)
+		if err = k3d.SetupClusterIssuer(context.TODO(), restConfig); err != nil {
+			return err
+		}
+	case utils.DefaultSSLIssuer:
+		if err = utils.CreateClusterIssuer(restConfig, true, utils.DefaultIngressClass(kubeClient)); err != nil {
+			return err
+		}
+	default:
+		utils.InfoMessage(fmt.Sprintf("Using existing ClusterIssuer %s", sslIssuer))
+	}
+
+	if err = utils.SetSSLConfig(kubeClient, restConfig, true, sslIssuer); err != nil {
+		return err
+	}
+	utils.SuccessMessage(fmt.Sprintf("Rendered grsf-config with ssl=true and sslissuer=%s", sslIssuer))
+
+	ingresses, err := utils.PatchIngressesTLS(kubeClient, clusterDomain, sslIssuer)
+	if err != nil {
+		return err
+	}
+	for _, ingress := range ingresses {
+		utils.InfoMessage(fmt.Sprintf("Enabled TLS of ingress %s/%s", ingress.Namespace, ingress.Name))
+	}
+
+	if !skipVerify && len(ingresses) > 0 {
+		utils.InfoMessage("Waiting for the certificates to be issued...")
+		if err = utils.WaitForCertificates(restConfig, ingresses); err != nil {
+			return err
+		}
+		for _, ingress := range ingresses {
+			if err = utils.CheckReachable(ingress.Hosts[0], true); err != nil {
+				return err
+			}
+			utils.SuccessMessage(fmt.Sprintf("https://%s is reachable", ingress.Hosts[0]))
+		}
+	}
+
+	return utils.PrintResult(map[string]interface{}{"ssl": true, "sslIssuer": sslIssuer, "ingresses": ingresses}, func() {
+		utils.SuccessMessage(fmt.Sprintf("SSL enabled on %s", clusterDomain))
+	})
+}
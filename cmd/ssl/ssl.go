@@ -0,0 +1,30 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package ssl
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	sslIssuer   string
+	skipVerify  bool
+	keepIssuer  bool
+	autoConfirm bool
+)
+
+// SslCmd represents the ssl command
+var SslCmd = &cobra.Command{
+	Use:   "ssl",
+	Short: "Enable or disable SSL of an existing Grapple installation",
+	Long: `Enable or disable SSL of the Grapple installation of the current cluster after it was installed.
+
+The commands update the grsf-config secret, create or remove the ClusterIssuer, add or remove TLS of
+the ingresses of the cluster domain, and verify that the cluster domain is reachable afterwards.`,
+}
+
+func init() {
+	SslCmd.AddCommand(EnableCmd)
+	SslCmd.AddCommand(DisableCmd)
+}
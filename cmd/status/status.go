@@ -0,0 +1,195 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package status
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	repair           bool
+	skipConfirmation bool
+)
+
+// StatusCmd represents the status command
+var StatusCmd = &cobra.Command{
+	Use:     "status",
+	Aliases: []string{"st"},
+	Short:   "Show the health of the Grapple installation of the current cluster",
+	Long: `Status inspects the cluster of the current kubectl context once, without changing anything, and reports:
+  - the installed versions of grsf-init, grsf, grsf-config and grsf-integration
+  - the health of cert-manager, external-secrets and crossplane
+  - the health of the crossplane providers and configurations
+  - the ingress controller, and whether KubeBlocks is installed
+  - the cluster domain, and whether it is online (with a valid certificate if SSL is enabled)
+  - the number of GrappleApplicationSets
+  - evicted or preempted pods of grpl-system and kb-system, see --priority-class of the install commands
+  - values of the grsf-config secret that no longer match the cluster: the grapple version, the provider, the
+    ClusterIssuer of SSL and its ingress class (e.g. after the ingress controller was replaced), the ingress IP
+
+With --repair, the drifted values are fixed after a confirmation: grsf-config is updated to the deployed
+versions and addresses, a missing letsencrypt ClusterIssuer is recreated and the solvers of the ClusterIssuer
+are switched to the default IngressClass. DNS records are reported only.
+
+Use -o json or -o yaml for machine readable output.
+
+Example:
+  grapple status -o json
+  grapple status --repair`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restConfig, kubeClient, err := utils.GetKubernetesConfig()
+		if err != nil {
+			utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+			return err
+		}
+
+		status, err := utils.CollectGrappleStatus(kubeClient, restConfig)
+		if err != nil {
+			return fmt.Errorf("failed to get the status of grapple: %w", err)
+		}
+
+		if repair {
+			if status, err = repairDrift(kubeClient, restConfig, status); err != nil {
+				return err
+			}
+		}
+
+		return utils.PrintResult(status, func() { printStatus(status) })
+	},
+}
+
+func init() {
+	StatusCmd.Flags().BoolVar(&repair, "repair", false, "Fix the values of grsf-config that no longer match the cluster")
+	StatusCmd.Flags().BoolVarP(&skipConfirmation, "yes", "y", false, "Skip the confirmation prompt of --repair")
+}
+
+// repairDrift fixes the repairable drifts of the status and returns the status collected again afterwards
+func repairDrift(kubeClient kubernetes.Interface, restConfig *rest.Config, status *utils.GrappleStatus) (*utils.GrappleStatus, error) {
+	var repairable []utils.ConfigDrift
+	for _, drift := range status.Drift {
+		if drift.Repairable() {
+			repairable = append(repairable, drift)
+		}
+	}
+	if len(repairable) == 0 {
+		utils.InfoMessage("Nothing to repair")
+		return status, nil
+	}
+
+	for _, drift := range repairable {
+		utils.InfoMessage(fmt.Sprintf("%s: %s", drift.Key, drift.Repair))
+	}
+	if !skipConfirmation {
+		confirmed, err := utils.PromptConfirm(fmt.Sprintf("Apply %d repairs?", len(repairable)))
+		if err != nil || !confirmed {
+			return nil, fmt.Errorf("repair canceled: %w", utils.ErrUserAborted)
+		}
+	}
+	if err := utils.RepairGrsfConfig(kubeClient, repairable); err != nil {
+		return nil, err
+	}
+
+	status, err := utils.CollectGrappleStatus(kubeClient, restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the status of grapple: %w", err)
+	}
+	return status, nil
+}
+
+func printStatus(status *utils.GrappleStatus) {
+	fmt.Printf("Context:            %s\n", status.Context)
+	fmt.Printf("Provider:           %s\n", valueOrNone(status.Provider))
+	fmt.Printf("Cluster domain:     %s\n", valueOrNone(status.ClusterDomain))
+	fmt.Printf("SSL:                %s\n", enabled(status.SSLEnabled))
+	online := "no"
+	if status.Online {
+		online = "yes"
+	}
+	if status.OnlineMessage != "" {
+		online = fmt.Sprintf("%s, %s", online, status.OnlineMessage)
+	} else if status.ClusterDomain != "" && !status.DomainResolvable {
+		online = "no, domain does not resolve"
+	}
+	fmt.Printf("Online:             %s\n", online)
+	fmt.Printf("Ingress controller: %s\n", valueOrNone(status.IngressController))
+	fmt.Printf("KubeBlocks:         %s\n", installed(status.KubeBlocks))
+	fmt.Printf("GRAS resources:     %d\n", status.GrasCount)
+
+	fmt.Println("\nReleases:")
+	for _, release := range utils.GrplReleases {
+		fmt.Printf("  %-26s %s\n", release, status.Releases[release])
+	}
+
+	fmt.Println("\nComponents:")
+	printComponents(status.Components)
+
+	if len(status.CrossplanePackages) > 0 {
+		fmt.Println("\nCrossplane packages:")
+		printComponents(status.CrossplanePackages)
+	}
+
+	if len(status.Drift) > 0 {
+		fmt.Println("\nConfiguration drift (grsf-config):")
+		repairable := false
+		for _, drift := range status.Drift {
+			fmt.Printf("  %-26s recorded %s, actual %s: %s\n", drift.Key, valueOrNone(drift.Recorded), valueOrNone(drift.Actual), drift.Message)
+			repairable = repairable || drift.Repairable()
+		}
+		if repairable {
+			utils.InfoMessage("Run 'grapple status --repair' to fix them")
+		}
+	}
+
+	if len(status.EvictedPods) > 0 {
+		fmt.Println()
+		utils.InfoMessage(fmt.Sprintf("Warning: %d platform pods were evicted or preempted, the cluster is short on resources:", len(status.EvictedPods)))
+		for _, pod := range status.EvictedPods {
+			fmt.Printf("  %s\n", pod)
+		}
+		utils.InfoMessage("Reinstall with --priority-class to rank the platform components above workloads")
+	}
+
+	fmt.Println()
+	if status.Healthy {
+		utils.SuccessMessage("Grapple is healthy")
+	} else {
+		utils.ErrorMessage("Grapple is not healthy")
+	}
+}
+
+func printComponents(components []utils.ComponentStatus) {
+	for _, component := range components {
+		state := "ready"
+		if !component.Ready {
+			state = "not ready"
+		}
+		fmt.Printf("  %-26s %-10s %s\n", component.Name, state, component.Message)
+	}
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func enabled(value bool) string {
+	if value {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+func installed(value bool) string {
+	if value {
+		return "installed"
+	}
+	return "not installed"
+}
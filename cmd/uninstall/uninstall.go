@@ -0,0 +1,106 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package uninstall
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	opts        utils.UninstallOptions
+	autoConfirm bool
+)
+
+// UninstallCmd represents the uninstall command
+var UninstallCmd = &cobra.Command{
+	Use:     "uninstall",
+	Aliases: []string{"u"},
+	Short:   "Uninstall Grapple, or parts of it, from the current cluster",
+	Long: `Uninstall removes Grapple from the cluster of the current kubectl context, independent of the cluster
+provider. By default everything is removed: the grpl CRDs, KubeBlocks with the kb-system namespace, and the
+grsf releases with the grpl-system namespace.
+
+Use the --keep-* flags for a partial teardown, e.g. to reinstall Grapple without losing the KubeBlocks
+databases, and --dry-run to list what would be deleted without deleting anything.
+
+Example:
+  grapple uninstall --dry-run
+  grapple uninstall --keep-kubeblocks --keep-crds
+  grapple uninstall --releases-only --auto-confirm`,
+	RunE: runUninstall,
+}
+
+func init() {
+	UninstallCmd.Flags().BoolVar(&opts.KeepKubeBlocks, "keep-kubeblocks", false, "Keep KubeBlocks and the kb-system namespace")
+	UninstallCmd.Flags().BoolVar(&opts.KeepCRDs, "keep-crds", false, "Keep the grpl CRDs (and so the custom resources of the applications)")
+	UninstallCmd.Flags().BoolVar(&opts.KeepNamespaces, "keep-namespaces", false, "Keep the grpl-system and kb-system namespaces")
+	UninstallCmd.Flags().BoolVar(&opts.ReleasesOnly, "releases-only", false, "Only uninstall the helm releases, keep CRDs and namespaces")
+	UninstallCmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Only list what would be deleted")
+	UninstallCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip the confirmation prompt (default: false)")
+}
+
+func runUninstall(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_uninstall.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, logOnFileStart, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to uninstall grpl, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	notConnected := func() error {
+		return errors.New("not connected to a cluster, connect first using 'grapple <provider> connect'")
+	}
+
+	// The plan is always listed first, so the confirmation shows what will be deleted
+	planOpts := opts
+	planOpts.DryRun = true
+	plan, err := utils.UninstallGrappleWithOptions(notConnected, logOnFileStart, logOnCliAndFileStart, planOpts)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		return utils.PrintResult(plan, func() { printPlan(plan, "Would delete") })
+	}
+	if len(plan) == 0 {
+		utils.InfoMessage("Nothing to uninstall")
+		return nil
+	}
+
+	printPlan(plan, "Will delete")
+	if !autoConfirm {
+		confirmed, promptErr := utils.PromptConfirm("Delete these Grapple components? This cannot be undone")
+		if promptErr != nil || !confirmed {
+			utils.InfoMessage("Uninstallation cancelled")
+			return nil
+		}
+	}
+
+	_, err = utils.UninstallGrappleWithOptions(notConnected, logOnFileStart, logOnCliAndFileStart, opts)
+	return err
+}
+
+func printPlan(plan []utils.UninstallStep, action string) {
+	if len(plan) == 0 {
+		utils.InfoMessage("Nothing to uninstall")
+		return
+	}
+	for _, step := range plan {
+		utils.InfoMessage(fmt.Sprintf("%s %s", action, step))
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package upgrade
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	grappleVersion        string
+	dryRun                bool
+	autoConfirm           bool
+	waitForReady          bool
+	additionalValuesFiles []string
+)
+
+// releaseUpgrade is the planned version change of a single release
+type releaseUpgrade struct {
+	Release string `json:"release" yaml:"release"`
+	Current string `json:"current" yaml:"current"`
+	Target  string `json:"target" yaml:"target"`
+}
+
+// unchanged reports whether the release is already at the target version
+func (u releaseUpgrade) unchanged() bool {
+	return strings.TrimPrefix(u.Current, "v") == strings.TrimPrefix(u.Target, "v")
+}
+
+// UpgradeCmd represents the upgrade command
+var UpgradeCmd = &cobra.Command{
+	Use:     "upgrade",
+	Aliases: []string{"up"},
+	Short:   "Upgrade the Grapple installation of the current cluster in place",
+	Long: `Upgrade upgrades the Grapple installation of the cluster of the current kubectl context.
+
+It detects the installed versions of grsf-init, grsf, grsf-config and grsf-integration from their
+Helm release history and upgrades them in sequence to --grapple-version, keeping the values they
+were installed with. Readiness is validated after every step, the same way as during install.
+
+Use --dry-run to only show the version changes.
+
+Example:
+  grapple upgrade --grapple-version=0.3.5 --dry-run`,
+	RunE: runUpgrade,
+}
+
+func init() {
+	UpgradeCmd.Flags().StringVar(&grappleVersion, "grapple-version", "latest", "Version of Grapple to upgrade to (default: latest)")
+	UpgradeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show the installed and target versions, don't upgrade")
+	UpgradeCmd.Flags().BoolVar(&autoConfirm, "auto-confirm", false, "Skip confirmation prompts (default: false)")
+	UpgradeCmd.Flags().BoolVar(&waitForReady, "wait", false, "Wait for Grapple to be fully ready at the end (default: false)")
+	UpgradeCmd.Flags().BoolVar(&utils.OfflineMode, "offline", false, "Resolve charts only from the chart cache, see 'grapple cache pull'")
+	UpgradeCmd.Flags().StringSliceVar(&additionalValuesFiles, "values", []string{}, "Additional values files applied on top of the installed values (e.g: --values=values1.yaml,values2.yaml)")
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+
+	logFileName := "grpl_upgrade.log"
+	logFilePath := utils.GetLogFilePath(logFileName)
+	logFile, logOnFileStart, logOnCliAndFileStart := utils.GetLogWriters(logFilePath)
+
+	var err error
+
+	defer func() {
+		logFile.Sync()
+		logFile.Close()
+		if err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to upgrade grapple, please run cat %s for more details", logFilePath))
+		}
+	}()
+
+	logOnCliAndFileStart()
+
+	if grappleVersion == "" || grappleVersion == "latest" {
+		grappleVersion = utils.DefaultGrappleVersion
+	}
+
+	restConfig, kubeClient, err := utils.GetKubernetesConfig()
+	if err != nil {
+		utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+		return err
+	}
+
+	plan, err := planUpgrade(restConfig)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		err = fmt.Errorf("grapple is not installed on this cluster, use 'grapple <provider> install' instead")
+		return err
+	}
+
+	if upToDate(plan) {
+		return utils.PrintResult(plan, func() {
+			utils.SuccessMessage(fmt.Sprintf("Grapple is already at %s, nothing to upgrade", grappleVersion))
+		})
+	}
+
+	if dryRun {
+		return utils.PrintResult(plan, func() { printPlan(plan) })
+	}
+	printPlan(plan)
+
+	if !autoConfirm {
+		confirmed, promptErr := utils.PromptConfirm(fmt.Sprintf("Upgrade grapple to %s?", grappleVersion))
+		if promptErr != nil || !confirmed {
+			err = fmt.Errorf("upgrade: %w", utils.ErrUserAborted)
+			return err
+		}
+	}
+
+	for _, step := range plan {
+		if step.unchanged() {
+			continue
+		}
+		if err = upgradeRelease(kubeClient, restConfig, step, logOnFileStart, logOnCliAndFileStart); err != nil {
+			return err
+		}
+	}
+
+	if waitForReady {
+		if err = utils.WaitForGrappleReady(restConfig); err != nil {
+			utils.ErrorMessage(fmt.Sprintf("Failed to wait for grapple to be ready: %v", err))
+			return err
+		}
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("Grapple upgraded to %s", grappleVersion))
+	return nil
+}
+
+// planUpgrade returns the installed releases with their current and target version
+func planUpgrade(restConfig *rest.Config) ([]releaseUpgrade, error) {
+	var plan []releaseUpgrade
+	for _, release := range utils.GrplReleases {
+		current, err := utils.GetGrplReleaseVersion(restConfig, release, "grpl-system")
+		if err != nil {
+			return nil, err
+		}
+		if current == "" {
+			utils.InfoMessage(fmt.Sprintf("Release %s is not installed, skipping it", release))
+			continue
+		}
+		plan = append(plan, releaseUpgrade{Release: release, Current: current, Target: grappleVersion})
+	}
+	return plan, nil
+}
+
+// upToDate reports whether all installed releases are already at the target version
+func upToDate(plan []releaseUpgrade) bool {
+	for _, step := range plan {
+		if !step.unchanged() {
+			return false
+		}
+	}
+	return true
+}
+
+func printPlan(plan []releaseUpgrade) {
+	utils.InfoMessage("Planned upgrade:")
+	for _, step := range plan {
+		change := fmt.Sprintf("%s -> %s", step.Current, step.Target)
+		if step.unchanged() {
+			change = fmt.Sprintf("%s (unchanged)", step.Current)
+		}
+		utils.InfoMessage(fmt.Sprintf("  %-18s %s", step.Release, change))
+	}
+}
+
+// upgradeRelease upgrades a single release with its installed values and waits for it to be ready
+func upgradeRelease(kubeClient *kubernetes.Clientset, restConfig *rest.Config, step releaseUpgrade, logOnFileStart, logOnCliAndFileStart func()) error {
+	valuesFile, err := utils.WriteGrplReleaseValues(restConfig, step.Release, "grpl-system", grappleVersion)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(valuesFile)
+
+	utils.InfoMessage(fmt.Sprintf("Upgrading %s from %s to %s...", step.Release, step.Current, step.Target))
+	logOnFileStart()
+	err = utils.HelmDeployGrplReleasesWithRetry(kubeClient, step.Release, "grpl-system", grappleVersion, append([]string{valuesFile}, additionalValuesFiles...))
+	logOnCliAndFileStart()
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("Failed to upgrade %s: %v", step.Release, err))
+		return err
+	}
+
+	switch step.Release {
+	case "grsf-init":
+		err = utils.WaitForGrsfInit(kubeClient, restConfig)
+	case "grsf":
+		err = utils.WaitForGrsf(kubeClient, restConfig, "grpl-system")
+	case "grsf-config":
+		err = utils.WaitForGrsfConfig(kubeClient, restConfig)
+	case "grsf-integration":
+		err = utils.WaitForGrsfIntegration(restConfig)
+	}
+	if err != nil {
+		utils.ErrorMessage(fmt.Sprintf("%s is not ready after the upgrade: %v", step.Release, err))
+		return err
+	}
+
+	utils.SuccessMessage(fmt.Sprintf("%s upgraded to %s", step.Release, step.Target))
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+package verify
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	skipCRUD      bool
+	crudNamespace string
+)
+
+// VerifyCmd represents the verify command
+var VerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the Grapple installation of the current cluster",
+	Long: `Verify runs the checks an install waits for once, without waiting, and can be run at any time,
+e.g. after a node failure or from a monitoring system:
+  - the grsf-init, grsf, grsf-config and grsf-integration releases are installed
+  - cert-manager is available
+  - the ClusterIssuer and grsf CRDs are established
+  - all XRDs are offered
+  - all crossplane providers and configurations are healthy
+  - the cluster domain resolves, is served by the ingress and, with SSL enabled, has a valid certificate
+  - a sample GrappleApplicationSet can be created, read, updated and deleted (--skip-crud to skip)
+
+The command exits with an error if any check fails. Use -o json for a report monitoring systems can consume.
+
+Example:
+  grapple verify
+  grapple verify -o json --skip-crud`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restConfig, kubeClient, err := utils.GetKubernetesConfig()
+		if err != nil {
+			utils.ErrorMessage("Failed to connect to the cluster, connect first using 'grapple <provider> connect': " + err.Error())
+			return err
+		}
+
+		report, err := utils.VerifyGrapple(kubeClient, restConfig, utils.VerifyOptions{SkipCRUD: skipCRUD, CRUDNamespace: crudNamespace})
+		if err != nil {
+			return fmt.Errorf("failed to verify grapple: %w", err)
+		}
+
+		if err := utils.PrintResult(report, func() { printReport(report) }); err != nil {
+			return err
+		}
+		if !report.Passed {
+			return fmt.Errorf("verification failed: %d of %d checks failed", report.Failed, len(report.Checks))
+		}
+		return nil
+	},
+}
+
+func init() {
+	VerifyCmd.Flags().BoolVar(&skipCRUD, "skip-crud", false, "Skip creating, updating and deleting a sample GrappleApplicationSet")
+	VerifyCmd.Flags().StringVar(&crudNamespace, "crud-namespace", "default", "Namespace of the sample GrappleApplicationSet")
+}
+
+func printReport(report *utils.VerifyReport) {
+	fmt.Printf("Context: %s\n", report.Context)
+	category := ""
+	for _, check := range report.Checks {
+		if check.Category != category {
+			category = check.Category
+			fmt.Printf("\n%s:\n", category)
+		}
+		state := "PASS"
+		if !check.Passed {
+			state = "FAIL"
+		}
+		fmt.Printf("  %-4s %-40s %s\n", state, check.Name, check.Message)
+	}
+
+	fmt.Println()
+	if report.Passed {
+		utils.SuccessMessage(fmt.Sprintf("All %d checks passed in %s", len(report.Checks), report.Duration))
+	} else {
+		utils.ErrorMessage(fmt.Sprintf("%d of %d checks failed", report.Failed, len(report.Checks)))
+	}
+}
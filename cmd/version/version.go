@@ -5,23 +5,116 @@ package version
 
 import (
 	"fmt"
+	"runtime"
 
 	"github.com/grapple-solution/grapple_cli/utils"
 	"github.com/spf13/cobra"
 )
 
+var (
+	clientOnly  bool
+	checkUpdate bool
+)
+
+// versionInfo is the result of the version command
+type versionInfo struct {
+	Version   string                `json:"version" yaml:"version"`
+	GitCommit string                `json:"gitCommit" yaml:"gitCommit"`
+	BuildDate string                `json:"buildDate" yaml:"buildDate"`
+	Platform  string                `json:"platform" yaml:"platform"`
+	Server    *utils.ServerVersions `json:"server,omitempty" yaml:"server,omitempty"`
+	LatestCLI string                `json:"latestCli,omitempty" yaml:"latestCli,omitempty"`
+}
+
 // VersionCmd represents the version command
 var VersionCmd = &cobra.Command{
 	Use:     "version",
 	Aliases: []string{"v"},
-	Short:   "Display the version of Grapple CLI",
-	Long:    `Display the current version of the Grapple CLI tool.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		version := utils.GetGrappleCliVersion()
-		fmt.Printf("Grapple CLI version: %s\n", version)
-	},
+	Short:   "Display the version of Grapple CLI and of the Grapple installation of the cluster",
+	Long: `Display the version, git commit and build date of the Grapple CLI tool.
+
+When connected to a cluster, the installed versions of the grsf charts, the image versions of the deployed
+grapi and gruim containers and the KubeBlocks version are shown as well (skip them with --client).
+With --check-update, the latest CLI release is looked up on GitHub.
+
+Example:
+  grapple version
+  grapple version --client --check-update -o json`,
+	RunE: runVersion,
 }
 
 func init() {
-	// No flags needed for version command
+	VersionCmd.Flags().BoolVar(&clientOnly, "client", false, "Only show the CLI version, don't connect to the cluster")
+	VersionCmd.Flags().BoolVar(&checkUpdate, "check-update", false, "Check GitHub for a newer CLI release")
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	commit, date := utils.BuildInfo()
+	info := versionInfo{
+		Version:   utils.GetGrappleCliVersion(),
+		GitCommit: commit,
+		BuildDate: date,
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	var serverErr error
+	if !clientOnly {
+		restConfig, kubeClient, err := utils.GetKubernetesConfig()
+		if err != nil {
+			serverErr = err
+		} else {
+			info.Server, serverErr = utils.CollectServerVersions(kubeClient, restConfig)
+		}
+	}
+
+	var updateHint string
+	var updateErr error
+	if checkUpdate {
+		info.LatestCLI, updateHint, updateErr = utils.CheckCLIUpdate()
+	}
+
+	return utils.PrintResult(info, func() {
+		fmt.Printf("Grapple CLI version: %s\n", info.Version)
+		fmt.Printf("  Git commit: %s\n", info.GitCommit)
+		fmt.Printf("  Build date: %s\n", info.BuildDate)
+		fmt.Printf("  Platform:   %s\n", info.Platform)
+
+		if !clientOnly {
+			if serverErr != nil {
+				fmt.Printf("Server: not available (%v)\n", serverErr)
+			} else {
+				printServer(info.Server)
+			}
+		}
+
+		switch {
+		case updateErr != nil:
+			utils.ErrorMessage(updateErr.Error())
+		case updateHint != "":
+			utils.InfoMessage(updateHint)
+		case checkUpdate:
+			utils.SuccessMessage("Grapple CLI is up to date")
+		}
+	})
+}
+
+func printServer(server *utils.ServerVersions) {
+	fmt.Println("Server:")
+	for _, release := range utils.GrplReleases {
+		fmt.Printf("  %-18s %s\n", release+":", server.Releases[release])
+	}
+	fmt.Printf("  %-18s %s\n", "grapi images:", listOrNone(server.Grapi))
+	fmt.Printf("  %-18s %s\n", "gruim images:", listOrNone(server.Gruim))
+	kubeBlocks := server.KubeBlocks
+	if kubeBlocks == "" {
+		kubeBlocks = "not installed"
+	}
+	fmt.Printf("  %-18s %s\n", "kubeblocks:", kubeBlocks)
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none deployed"
+	}
+	return fmt.Sprint(values)
 }
@@ -0,0 +1,91 @@
+package installer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+)
+
+const (
+	// defaultHostedZoneID is the zone of grapple-demo.com in Grapple's Route53 account
+	defaultHostedZoneID = "Z03015782ZG7K1CRJLN42"
+	dnsManagerURL       = "https://4t2skptq3g.execute-api.eu-central-1.amazonaws.com/dev/grpl-route53-dns-manager-v2"
+)
+
+// DomainRecords configures SetupDomainRecords
+type DomainRecords struct {
+	// Cloud is the provider name of the code verification server, e.g. "civo"
+	Cloud string
+	// DNS is the --grapple-dns value, the records of a name that doesn't resolve are created in Grapple's zone
+	DNS          string
+	HostedZoneID string
+	// VerifyCustomDomain waits up to VerifyTimeout for the records of a domain of the user
+	VerifyCustomDomain bool
+	VerifyTimeout      time.Duration
+	// WaitForIP returns the IP of the records when the cluster IP is only known once an ingress exists
+	WaitForIP func() (string, error)
+}
+
+// SetupDomainRecords points the Grapple domain to the cluster: a name in Grapple's zone gets an A record through
+// the DNS manager, which verifies the request with a code served from the cluster
+func SetupDomainRecords(inst *Installation, records DomainRecords) error {
+	if utils.IsResolvable(utils.ExtractDomain(records.DNS)) && records.HostedZoneID == "" {
+		if !records.VerifyCustomDomain {
+			return nil
+		}
+		// The domain is the user's own, nothing creates its records for them
+		if err := utils.VerifyCustomDomain(inst.RestConfig, inst.Domain, inst.ClusterIP, records.VerifyTimeout); err != nil {
+			utils.ErrorMessage("Failed to verify the DNS setup of your domain: " + err.Error())
+			return err
+		}
+		return nil
+	}
+
+	utils.InfoMessage("Domain not resolvable. Creating DNS upsert job...")
+	code := utils.GenerateRandomString()
+	if err := utils.SetupCodeVerificationServer(inst.RestConfig, code, inst.Domain, records.Cloud); err != nil {
+		utils.ErrorMessage("Failed to setup code verification server: " + err.Error())
+		return err
+	}
+	defer func() {
+		if err := utils.RemoveCodeVerificationServer(inst.RestConfig); err != nil {
+			// Not critical, the verification server is only used by the DNS manager
+			utils.ErrorMessage("Failed to remove code verification server: " + err.Error())
+		}
+	}()
+
+	if inst.ClusterIP == "" && records.WaitForIP != nil {
+		clusterIP, err := records.WaitForIP()
+		if err != nil {
+			utils.ErrorMessage("Failed to get ingress IP: " + err.Error())
+			return err
+		}
+		inst.ClusterIP = clusterIP
+	}
+	hostedZoneID := records.HostedZoneID
+	if hostedZoneID == "" {
+		hostedZoneID = defaultHostedZoneID
+	}
+	if err := utils.UpsertDNSRecord(inst.RestConfig, dnsManagerURL, inst.Domain, code, inst.ClusterIP, hostedZoneID, "A"); err != nil {
+		utils.ErrorMessage("Failed to upsert DNS record: " + err.Error())
+		return fmt.Errorf("failed to upsert DNS record of %s: %w", inst.Domain, err)
+	}
+	return nil
+}
+
+// EnableSSL creates the cluster issuer of the ingress controller when SSL is enabled
+func EnableSSL(inst *Installation, ingressController string) error {
+	if !inst.SSL {
+		return nil
+	}
+	utils.InfoMessage("Enabling SSL (applying clusterissuer, etc.)")
+	inst.LogOnFileStart()
+	err := utils.CreateClusterIssuer(inst.RestConfig, inst.SSL, ingressController)
+	inst.LogOnCliAndFileStart()
+	if err != nil {
+		return fmt.Errorf("failed to create clusterissuer: %w", err)
+	}
+	utils.InfoMessage("Successfully created clusterissuer.")
+	return nil
+}
@@ -0,0 +1,298 @@
+/*
+Copyright © 2025 Grapple Solutions
+*/
+
+// Package installer drives the Grapple installation of every provider through one pipeline. A provider only
+// implements what differs between clusters: preparing the cluster, finding its IP, its config values and the
+// steps after the charts are deployed.
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// valuesFileName is the values override file written to the temp directory
+	valuesFileName = "values-override.yaml"
+	// preloadVersion is the version of the grapi and gruim images preloaded on the nodes
+	preloadVersion = "0.2.8"
+)
+
+// Provider is the provider specific part of an installation
+type Provider interface {
+	// PrepareCluster readies the cluster before Grapple is deployed, e.g. installs the ingress controller
+	PrepareCluster(inst *Installation) error
+	// ClusterIP returns the address the Grapple domain resolves to, "" when it is only known later
+	ClusterIP(inst *Installation) (string, error)
+	// ExtraValues returns the provider's values, merged into the values override file (e.g. a "config" map with
+	// the provider's cluster ID), and the values files applied after it
+	ExtraValues(inst *Installation) (map[string]interface{}, []string, error)
+	// PostInstall runs once the charts are deployed, e.g. creates the cluster issuer and the DNS records
+	PostInstall(inst *Installation) error
+}
+
+// Installation is the state of an installation shared by the pipeline and the provider
+type Installation struct {
+	// ClusterType is the provider type recorded in grsf-config, e.g. utils.ProviderClusterTypeCivo
+	ClusterType       string
+	ClusterName       string
+	GrappleVersion    string
+	Domain            string
+	Organization      string
+	Email             string
+	License           string
+	ImagePullSecret   string
+	SSL               bool
+	SSLIssuer         string
+	InstallKubeblocks bool
+	WaitForReady      bool
+	AutoConfirm       bool
+	// ValuesFiles are the --values files, they are applied last
+	ValuesFiles []string
+	// ProviderValues is --provider-values: the values profile to use instead of the provider's, see providerValuesFile
+	ProviderValues string
+	// Summary are provider specific lines of the confirmation, e.g. "civo-region: fra1"
+	Summary []string
+
+	KubeClient           apiv1.Interface
+	RestConfig           *rest.Config
+	ClusterIP            string
+	LogOnFileStart       func()
+	LogOnCliAndFileStart func()
+}
+
+// Run installs Grapple on the cluster of the installation:
+//  0. run the preflight checks, unless --skip-preflight is set
+//  1. confirm the settings, unless AutoConfirm is set
+//  2. create the PriorityClass of --priority-class, check that the images are published for the architectures of the nodes and prepare the cluster, then install KubeBlocks and preload the images in the background
+//  3. look up the cluster IP and write the values override file
+//  4. deploy grsf-init, grsf, grsf-config and grsf-integration, each waiting for the previous one
+//  5. run the provider's post install steps and wait for Grapple to be ready when requested
+//  6. wait for the background tasks and remove the helper workloads
+func Run(provider Provider, inst *Installation) error {
+	if err := utils.LoadSecretValues(inst.KubeClient); err != nil {
+		return err
+	}
+	if !utils.SkipPreflight {
+		err := utils.RunInstallPhase("preflight", func() error {
+			return utils.Preflight(inst.KubeClient, inst.RestConfig, utils.PreflightOptions{Install: true})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := confirm(inst); err != nil {
+		return err
+	}
+
+	if utils.PriorityClass != "" {
+		if err := utils.RunInstallPhase("priority-class", func() error { return utils.EnsurePriorityClass(inst.KubeClient) }); err != nil {
+			return err
+		}
+	}
+	err := utils.RunInstallPhase("architectures", func() error {
+		return utils.VerifyImageArchitectures(context.TODO(), inst.KubeClient, utils.GrappleImages(preloadVersion))
+	})
+	if err != nil {
+		return err
+	}
+	if err := utils.RunInstallPhase("prepare-cluster", func() error { return provider.PrepareCluster(inst) }); err != nil {
+		return err
+	}
+
+	var background sync.WaitGroup
+	var kubeblocksErr, preloadErr error
+	if inst.InstallKubeblocks {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			if kubeblocksErr = utils.InstallKubeBlocksOnCluster(inst.RestConfig); kubeblocksErr != nil {
+				utils.ErrorMessage("kubeblocks installation error: " + kubeblocksErr.Error())
+			} else {
+				utils.InfoMessage("kubeblocks installed.")
+			}
+		}()
+	}
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		if preloadErr = utils.PreloadGrappleImages(inst.RestConfig, preloadVersion); preloadErr != nil {
+			utils.ErrorMessage("image preload error: " + preloadErr.Error())
+		} else {
+			utils.InfoMessage("grapple images preloaded.")
+		}
+	}()
+	// A failed installation is rolled back by the caller once Run returns, the background tasks must not keep
+	// writing to the cluster then
+	defer background.Wait()
+
+	var valuesFiles []string
+	err = utils.RunInstallPhase("values", func() error {
+		clusterIP, err := provider.ClusterIP(inst)
+		if err != nil {
+			return err
+		}
+		inst.ClusterIP = clusterIP
+		valuesFiles, err = writeValuesFile(provider, inst)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := utils.RunInstallPhases(utils.GrplChartPhases(inst.KubeClient, inst.RestConfig, inst.GrappleVersion, valuesFiles, inst.LogOnFileStart, inst.LogOnCliAndFileStart)); err != nil {
+		return err
+	}
+
+	if err := utils.RunInstallPhase("post-install", func() error { return provider.PostInstall(inst) }); err != nil {
+		return err
+	}
+
+	if inst.WaitForReady {
+		err := utils.RunInstallPhase("wait-ready", func() error {
+			utils.InfoMessage("Waiting for Grapple to be ready...")
+			inst.LogOnFileStart()
+			err := utils.WaitForGrappleReady(inst.RestConfig)
+			inst.LogOnCliAndFileStart()
+			if err != nil {
+				return fmt.Errorf("failed to wait for grapple to be ready: %w", err)
+			}
+			utils.SuccessMessage("Grapple is ready!")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if inst.InstallKubeblocks {
+		utils.InfoMessage("Waiting for kubeblocks and the grapple images, it might take a while...")
+	} else {
+		utils.InfoMessage("Waiting for grapple images to be preloaded...")
+	}
+	inst.LogOnFileStart()
+	background.Wait()
+	inst.LogOnCliAndFileStart()
+	if inst.InstallKubeblocks {
+		if kubeblocksErr != nil {
+			utils.ErrorMessage("Kubeblocks installation failed! with error: " + kubeblocksErr.Error())
+		} else {
+			utils.SuccessMessage("Kubeblocks installation completed!")
+		}
+	}
+	if preloadErr != nil {
+		utils.ErrorMessage("image preload error: " + preloadErr.Error())
+	} else {
+		utils.SuccessMessage("Grapple images preloaded.")
+	}
+
+	utils.PruneHelperWorkloadsAfterInstall(inst.KubeClient)
+
+	utils.SuccessMessage("Grapple installation completed!")
+	return nil
+}
+
+// PromptKubeblocks asks whether KubeBlocks should be installed, unless --install-kubeblocks was given
+func PromptKubeblocks(cmd *cobra.Command, installKubeblocks *bool) error {
+	if cmd.Flags().Changed("install-kubeblocks") || *installKubeblocks {
+		return nil
+	}
+	confirmed, err := utils.PromptInput("Do you want to install KubeBlocks? (y/N): ", "n", "^[yYnN]$")
+	if err != nil {
+		return err
+	}
+	*installKubeblocks = strings.ToLower(confirmed) == "y"
+	return nil
+}
+
+// confirm prints the settings of the installation and asks to proceed
+func confirm(inst *Installation) error {
+	if inst.AutoConfirm {
+		return nil
+	}
+	utils.InfoMessage(fmt.Sprintf("Going to deploy grpl on %s with following configurations", strings.ToUpper(inst.ClusterType)))
+	for _, line := range inst.Summary {
+		utils.InfoMessage(line)
+	}
+	utils.InfoMessage(fmt.Sprintf("cluster-name: %s", inst.ClusterName))
+	utils.InfoMessage(fmt.Sprintf("grapple-version: %s", inst.GrappleVersion))
+	utils.InfoMessage(fmt.Sprintf("grapple-dns: %s", inst.Domain))
+	if utils.IsSecretValue("config", utils.SecKeyGrapleLicense) {
+		utils.InfoMessage("grapple-license: (from values secret)")
+	} else {
+		utils.InfoMessage(fmt.Sprintf("grapple-license: %s", inst.License))
+	}
+	utils.InfoMessage(fmt.Sprintf("organization: %s", inst.Organization))
+	utils.InfoMessage(fmt.Sprintf("email: %s", inst.Email))
+	utils.InfoMessage(fmt.Sprintf("image-pull-secret: %s", inst.ImagePullSecret))
+
+	if confirmed, err := utils.PromptConfirm("Proceed with deployment using the values above?"); err != nil || !confirmed {
+		return fmt.Errorf("failed to install grpl: %w", utils.ErrUserAborted)
+	}
+	return nil
+}
+
+// writeValuesFile writes the values override file and returns the values files of the chart deploys
+func writeValuesFile(provider Provider, inst *Installation) ([]string, error) {
+	config := map[string]interface{}{
+		utils.SecKeyEmail:               inst.Email,
+		utils.SecKeyOrganization:        inst.Organization,
+		utils.SecKeyClusterdomain:       inst.Domain,
+		utils.SecKeyGrapiversion:        "0.0.1",
+		utils.SecKeyGruimversion:        "0.0.1",
+		utils.SecKeyDev:                 "false",
+		utils.SecKeySsl:                 fmt.Sprintf("%v", inst.SSL),
+		utils.SecKeySslissuer:           inst.SSLIssuer,
+		utils.SecKeyClusterName:         inst.ClusterName,
+		utils.SecKeyGrapleDNS:           inst.Domain,
+		utils.SecKeyGrapleVersion:       inst.GrappleVersion,
+		utils.SecKeyGrapleCliVersion:    utils.GetGrappleCliVersion(),
+		utils.SecKeyGrapleLicense:       inst.License,
+		utils.SecKeyProviderClusterType: inst.ClusterType,
+		utils.SecKeyImagePullSecret:     inst.ImagePullSecret,
+	}
+	values := map[string]interface{}{
+		"clusterdomain": inst.Domain,
+		"config":        config,
+	}
+	extraValues, extraFiles, err := provider.ExtraValues(inst)
+	if err != nil {
+		return nil, err
+	}
+	utils.MergeValues(values, extraValues)
+
+	// Values of --values-secret and --values-sops are only passed to helm in memory
+	utils.StripSecretValues(values)
+
+	yamlData, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal values to YAML: %w", err)
+	}
+	valuesFilePath := filepath.Join(os.TempDir(), valuesFileName)
+	if err := os.WriteFile(valuesFilePath, yamlData, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write values file: %w", err)
+	}
+
+	valuesFiles := []string{valuesFilePath}
+	profile, err := providerValuesFile(inst)
+	if err != nil {
+		return nil, err
+	}
+	if profile != "" {
+		utils.InfoMessage(fmt.Sprintf("Using values profile %s", profile))
+		valuesFiles = append(valuesFiles, profile)
+	}
+	valuesFiles = append(valuesFiles, extraFiles...)
+	return append(valuesFiles, inst.ValuesFiles...), nil
+}
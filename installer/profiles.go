@@ -0,0 +1,108 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ProfileGeneric is the values profile of providers without a profile of their own
+	ProfileGeneric = "generic"
+	// ProfileNone disables the values profile
+	ProfileNone = "none"
+)
+
+// AddProviderValuesFlag adds --provider-values to an install command
+func AddProviderValuesFlag(cmd *cobra.Command, providerValues *string) {
+	cmd.Flags().StringVar(providerValues, "provider-values", "", "Values profile of the provider: a profile name (k3d, civo, generic, ...), a values file or none (default: the profile of the provider)")
+}
+
+// ProviderValuesDir returns the directory of the user's values profiles: the provider-values-dir setting
+// (GRPL_PROVIDER_VALUES_DIR), or ~/.config/grpl/provider-values
+func ProviderValuesDir() (string, error) {
+	if dir := utils.ConfigValue("provider-values-dir"); dir != "" {
+		return dir, nil
+	}
+	configPath, err := utils.ConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "provider-values"), nil
+}
+
+// providerValuesFile returns the values profile of the installation, applied after the values override file and
+// before --values. ProviderValues is a values file, the name of a profile or none; by default the profile is
+// named after the provider (values-civo.yaml for civo), providers without one use values-generic.yaml.
+// Profiles of the user's directory take precedence over the ones shipped in template-files.
+func providerValuesFile(inst *Installation) (string, error) {
+	switch {
+	case inst.ProviderValues == ProfileNone:
+		return "", nil
+	case strings.HasSuffix(inst.ProviderValues, ".yaml") || strings.HasSuffix(inst.ProviderValues, ".yml") || strings.ContainsRune(inst.ProviderValues, os.PathSeparator):
+		if _, err := os.Stat(inst.ProviderValues); err != nil {
+			return "", fmt.Errorf("%w: provider values file %s not found", utils.ErrValidation, inst.ProviderValues)
+		}
+		return inst.ProviderValues, nil
+	}
+
+	dirs := profileDirs()
+	if inst.ProviderValues != "" {
+		if path := findProfile(dirs, inst.ProviderValues); path != "" {
+			return path, nil
+		}
+		return "", fmt.Errorf("%w: no values profile %s, use one of %s, a values file or %s", utils.ErrValidation,
+			inst.ProviderValues, strings.Join(listProfiles(dirs), ", "), ProfileNone)
+	}
+
+	for _, name := range []string{strings.ToLower(inst.ClusterType), ProfileGeneric} {
+		if path := findProfile(dirs, name); path != "" {
+			return path, nil
+		}
+	}
+	utils.DebugMessage(fmt.Sprintf("No values profile found for %s in %s", inst.ClusterType, strings.Join(dirs, ", ")))
+	return "", nil
+}
+
+// profileDirs returns the directories profiles are looked up in, in order of precedence
+func profileDirs() []string {
+	var dirs []string
+	if dir, err := ProviderValuesDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	return append(dirs, utils.ResourceDirs("template-files")...)
+}
+
+// findProfile returns the path of values-<name>.yaml in the first directory that has it
+func findProfile(dirs []string, name string) string {
+	for _, dir := range dirs {
+		path := filepath.Join(dir, fmt.Sprintf("values-%s.yaml", name))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// listProfiles returns the names of the profiles of dirs
+func listProfiles(dirs []string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "values-*.yaml"))
+		for _, match := range matches {
+			name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), "values-"), ".yaml")
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
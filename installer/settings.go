@@ -0,0 +1,57 @@
+package installer
+
+import (
+	"fmt"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	apiv1 "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultOrganization is the organization of installations without --organization or an email to derive it from
+	DefaultOrganization = "grapple solutions AG"
+	// demoDomain is the domain of the names that aren't FQDNs, their records are created in Grapple's zone
+	demoDomain = ".grapple-demo.com"
+)
+
+// GrappleVersion returns the version to install for --grapple-version, "" and "latest" are the default version
+func GrappleVersion(version string) string {
+	if version == "" || version == "latest" {
+		return utils.DefaultGrappleVersion
+	}
+	return version
+}
+
+// ResolveDomain returns the Grapple DNS name of --grapple-dns, the cluster name when it is empty, and the complete
+// domain: the name itself when it is a resolvable FQDN, a subdomain of grapple-demo.com otherwise.
+// verifyCustomDomain is whether the records of the user's own domain are verified, see DomainRecords.
+func ResolveDomain(dns, clusterName, hostedZoneID string, verifyCustomDomain bool) (string, string) {
+	if dns == "" {
+		dns = clusterName
+		utils.InfoMessage(fmt.Sprintf("Using cluster name as Grapple DNS: %s%s", dns, demoDomain))
+		return dns, dns + demoDomain
+	}
+
+	if !utils.IsResolvable(utils.ExtractDomain(dns)) {
+		utils.InfoMessage(fmt.Sprintf("DNS name %s is not a FQDN", dns))
+		return dns, dns + demoDomain
+	}
+	if hostedZoneID == "" {
+		if verifyCustomDomain {
+			utils.InfoMessage("Using your own domain, the DNS records to create are shown once the ingress has an external IP")
+		} else {
+			utils.InfoMessage("Make sure you have a wildcard entry for your domain e.g *.<your-domain> in your hosted zone and it points to the current cluster. If it doesn't then the dns won't work")
+		}
+	}
+	return dns, dns
+}
+
+// ExistingLicense returns the license key of a previous installation on the cluster, the free license if there
+// is none
+func ExistingLicense(kubeClient apiv1.Interface) string {
+	license, err := utils.GetClusterLicense(kubeClient)
+	if err != nil {
+		return utils.FreeLicense
+	}
+	return license.Key
+}
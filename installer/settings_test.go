@@ -0,0 +1,56 @@
+package installer
+
+import (
+	"testing"
+
+	"github.com/grapple-solution/grapple_cli/utils"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGrappleVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "", want: utils.DefaultGrappleVersion},
+		{version: "latest", want: utils.DefaultGrappleVersion},
+		{version: "0.2.8", want: "0.2.8"},
+	}
+	for _, tt := range tests {
+		if got := GrappleVersion(tt.version); got != tt.want {
+			t.Errorf("GrappleVersion(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestResolveDomainDefaultsToClusterName(t *testing.T) {
+	dns, domain := ResolveDomain("", "my-cluster", "", false)
+	if dns != "my-cluster" || domain != "my-cluster.grapple-demo.com" {
+		t.Errorf("ResolveDomain() = %q, %q, want %q, %q", dns, domain, "my-cluster", "my-cluster.grapple-demo.com")
+	}
+}
+
+func TestExistingLicense(t *testing.T) {
+	grsfConfig := func(data map[string][]byte) runtime.Object {
+		return &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: "grsf-config", Namespace: "grpl-system"}, Data: data}
+	}
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		want    string
+	}{
+		{name: "first install", want: utils.FreeLicense},
+		{name: "without a license", objects: []runtime.Object{grsfConfig(nil)}, want: utils.FreeLicense},
+		{name: "licensed", objects: []runtime.Object{grsfConfig(map[string][]byte{"LIC": []byte("key-123")})}, want: "key-123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExistingLicense(fake.NewSimpleClientset(tt.objects...)); got != tt.want {
+				t.Errorf("ExistingLicense() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
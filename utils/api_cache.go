@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// apiCacheEntry is a cached API response with the time it was fetched
+type apiCacheEntry struct {
+	FetchedAt time.Time       `json:"fetchedAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// apiCachePath returns the file of a cached API response, $XDG_CACHE_HOME/grpl/api/<name>.json
+func apiCachePath(name string) (string, error) {
+	dir, err := cacheHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "grpl", "api", name+".json"), nil
+}
+
+// APICacheKey returns a cache name of parts that may be secret (e.g. an API key), so responses of different
+// accounts are cached apart without writing the secret to disk
+func APICacheKey(prefix string, parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return prefix + "-" + hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// ReadAPICache decodes the cached response of name into v, it reports false when there is none younger than ttl
+func ReadAPICache(name string, ttl time.Duration, v interface{}) bool {
+	path, err := apiCachePath(name)
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var entry apiCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Since(entry.FetchedAt) > ttl {
+		return false
+	}
+	return json.Unmarshal(entry.Data, v) == nil
+}
+
+// WriteAPICache caches the response v under name, failures are ignored as the cache is only a shortcut
+func WriteAPICache(name string, v interface{}) {
+	path, err := apiCachePath(name)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	entry, err := json.Marshal(apiCacheEntry{FetchedAt: time.Now(), Data: data})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, entry, 0600)
+}
+
+// InvalidateAPICache removes the cached response of name
+func InvalidateAPICache(name string) {
+	if path, err := apiCachePath(name); err == nil {
+		_ = os.Remove(path)
+	}
+}
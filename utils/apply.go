@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/kubectl/pkg/util/openapi"
+	"k8s.io/kubectl/pkg/validation"
+)
+
+// FieldManager is the field manager of the objects the CLI applies server side
+const FieldManager = "grpl"
+
+// Applier applies objects server side, resolving their resource with the discovery of the cluster
+type Applier struct {
+	dynamic dynamic.Interface
+	mapper  meta.ResettableRESTMapper
+	schema  validation.Schema
+	// Force takes over the fields other field managers own, without it such a conflict fails the apply (see
+	// k8serrors.IsConflict)
+	Force bool
+}
+
+// openAPIResources returns the OpenAPI schema of the cluster, fetched once, to the client side validation
+type openAPIResources struct {
+	parser *openapi.CachedOpenAPIParser
+}
+
+func (r openAPIResources) OpenAPISchema() (openapi.Resources, error) {
+	return r.parser.Parse()
+}
+
+// AppliedObject is an object applied by an Applier
+type AppliedObject struct {
+	GVR       schema.GroupVersionResource
+	Kind      string
+	Namespace string
+	Name      string
+	// Created is true when the object didn't exist before
+	Created bool
+}
+
+// NewApplier returns an Applier for the cluster of restConfig
+func NewApplier(restConfig *rest.Config) (*Applier, error) {
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	schema := validation.NewSchemaValidation(openAPIResources{parser: openapi.NewOpenAPIParser(discoveryClient)})
+	return &Applier{dynamic: dynamicClient, mapper: mapper, schema: schema}, nil
+}
+
+// DecodeManifest splits a YAML or JSON manifest into its objects, empty documents are skipped
+func DecodeManifest(data []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				return objects, nil
+			}
+			return nil, fmt.Errorf("failed to decode manifest: %w", err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// ApplyManifest applies all objects of a multi-document manifest, see Apply
+func (a *Applier) ApplyManifest(ctx context.Context, data []byte, namespace string) ([]AppliedObject, error) {
+	objects, err := DecodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	applied := make([]AppliedObject, 0, len(objects))
+	for _, obj := range objects {
+		result, err := a.Apply(ctx, obj, namespace)
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, result)
+	}
+	return applied, nil
+}
+
+// Apply applies obj server side, conflicting fields are only taken over with Force. Namespaced objects without a
+// namespace are applied in namespace, the namespace of cluster scoped objects is dropped.
+func (a *Applier) Apply(ctx context.Context, obj *unstructured.Unstructured, namespace string) (AppliedObject, error) {
+	resource, result, _, err := a.resolve(ctx, obj, namespace)
+	if err != nil {
+		return result, err
+	}
+	if _, err := resource.Apply(ctx, obj.GetName(), obj, v1.ApplyOptions{FieldManager: FieldManager, Force: a.Force}); err != nil {
+		return result, fmt.Errorf("failed to apply %s %s: %w", result.Kind, obj.GetName(), err)
+	}
+	DebugMessage(fmt.Sprintf("Applied %s %s (%s)", result.Kind, obj.GetName(), result.GVR.String()))
+	return result, nil
+}
+
+// DryRun applies obj like Apply with a server side dry run, which validates it against the schema of its kind. It
+// returns the object as it would be stored and the existing object, nil when obj would be created.
+func (a *Applier) DryRun(ctx context.Context, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, *unstructured.Unstructured, error) {
+	resource, result, existing, err := a.resolve(ctx, obj, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	applied, err := resource.Apply(ctx, obj.GetName(), obj, v1.ApplyOptions{FieldManager: FieldManager, Force: a.Force, DryRun: []string{v1.DryRunAll}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s %s is invalid: %w", result.Kind, obj.GetName(), err)
+	}
+	return applied, existing, nil
+}
+
+// Validate checks obj against the OpenAPI schema the cluster publishes for its kind, like kubectl does before
+// sending it. Kinds without a published schema are left to the server.
+func (a *Applier) Validate(obj *unstructured.Unstructured) error {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+	if err := a.schema.ValidateBytes(data); err != nil {
+		return fmt.Errorf("%w: %s %s does not match the schema of the cluster: %w", ErrValidation, obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// NeedsNamespace reports whether obj is of a namespaced kind and has no namespace of its own
+func (a *Applier) NeedsNamespace(obj *unstructured.Unstructured) (bool, error) {
+	if obj.GetNamespace() != "" {
+		return false, nil
+	}
+	mapping, err := a.restMapping(obj)
+	if err != nil {
+		return false, err
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// restMapping returns the resource of the kind of obj
+func (a *Applier) restMapping(obj *unstructured.Unstructured) (*meta.RESTMapping, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// The CRD may have been installed after the discovery was cached
+		a.mapper.Reset()
+		mapping, err = a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unknown kind %s of %s: %w", gvk.String(), obj.GetName(), err)
+	}
+	return mapping, nil
+}
+
+// resolve returns the client of the resource of obj and the existing object, nil if there is none. It sets the
+// namespace of obj.
+func (a *Applier) resolve(ctx context.Context, obj *unstructured.Unstructured, namespace string) (dynamic.ResourceInterface, AppliedObject, *unstructured.Unstructured, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := a.restMapping(obj)
+	if err != nil {
+		return nil, AppliedObject{}, nil, err
+	}
+
+	var resource dynamic.ResourceInterface
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+		if obj.GetNamespace() == "" {
+			return nil, AppliedObject{}, nil, fmt.Errorf("%w: %s %s needs a namespace", ErrValidation, gvk.Kind, obj.GetName())
+		}
+		resource = a.dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+	} else {
+		obj.SetNamespace("")
+		resource = a.dynamic.Resource(mapping.Resource)
+	}
+
+	result := AppliedObject{GVR: mapping.Resource, Kind: gvk.Kind, Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	existing, err := resource.Get(ctx, obj.GetName(), v1.GetOptions{})
+	switch {
+	case k8serrors.IsNotFound(err):
+		result.Created = true
+		existing = nil
+	case err != nil:
+		return nil, result, nil, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	// Server side apply rejects a resourceVersion that isn't the current one
+	obj.SetResourceVersion("")
+	return resource, result, existing, nil
+}
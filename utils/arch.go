@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+)
+
+// ToolArch returns the architecture of the CLI tool downloads (devspace, task, yq, stern), they are only
+// published for amd64 and arm64
+func ToolArch() (string, error) {
+	switch runtime.GOARCH {
+	case "amd64", "arm64":
+		return runtime.GOARCH, nil
+	}
+	return "", fmt.Errorf("%w: no download for %s/%s, install the tool with your package manager (PACKAGE_MANAGER=brew, apt, dnf or choco)", ErrValidation, runtime.GOOS, runtime.GOARCH)
+}
+
+// NodeArchitectures returns the architectures of the cluster nodes with the nodes of each
+func NodeArchitectures(ctx context.Context, kubeClient apiv1.Interface) (map[string][]string, error) {
+	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	architectures := map[string][]string{}
+	for _, node := range nodes.Items {
+		arch := node.Status.NodeInfo.Architecture
+		if arch == "" {
+			arch = node.Labels[corev1.LabelArchStable]
+		}
+		if arch != "" {
+			architectures[arch] = append(architectures[arch], node.Name)
+		}
+	}
+	return architectures, nil
+}
+
+// ImageArchitectures returns the linux architectures an image is published for, from the manifest list of
+// multi-arch images or the config of single-arch images
+func ImageArchitectures(ctx context.Context, image string) ([]string, error) {
+	name, tag := splitImage(image)
+	reference := tag
+	if _, digest, found := strings.Cut(image, "@"); found {
+		reference = digest
+	}
+	host, repository := splitRegistryHost(name)
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	repo := &registryRepository{host: host, repository: repository}
+
+	manifest, err := repo.manifest(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	var architectures []string
+	if len(manifest.Manifests) > 0 {
+		for _, m := range manifest.Manifests {
+			if m.Platform != nil && m.Platform.OS == "linux" && m.Platform.Architecture != "unknown" {
+				architectures = append(architectures, m.Platform.Architecture)
+			}
+		}
+	} else if manifest.Config.Digest != "" {
+		var config struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+		}
+		if err := repo.getJSON(ctx, "/blobs/"+manifest.Config.Digest, "", &config); err != nil {
+			return nil, err
+		}
+		architectures = append(architectures, config.Architecture)
+	}
+	sort.Strings(architectures)
+	return architectures, nil
+}
+
+// VerifyImageArchitectures checks that the images are published for the architectures of the cluster nodes.
+// It fails when an image runs on none of the nodes and warns when it only runs on some of them. Images the
+// registry doesn't answer for (e.g. private mirrors) are skipped.
+func VerifyImageArchitectures(ctx context.Context, kubeClient apiv1.Interface, images []string) error {
+	nodeArchitectures, err := NodeArchitectures(ctx, kubeClient)
+	if err != nil {
+		return err
+	}
+	if len(nodeArchitectures) == 0 {
+		return nil
+	}
+
+	for _, image := range images {
+		imageArchitectures, err := ImageArchitectures(ctx, image)
+		if err != nil {
+			InfoMessage(fmt.Sprintf("Skipping the architecture check of %s: %v", image, err))
+			continue
+		}
+		if len(imageArchitectures) == 0 {
+			continue
+		}
+
+		var supported, missing []string
+		for arch, nodes := range nodeArchitectures {
+			if containsArch(imageArchitectures, arch) {
+				supported = append(supported, arch)
+			} else {
+				missing = append(missing, fmt.Sprintf("%s (%s)", arch, strings.Join(nodes, ", ")))
+			}
+		}
+		sort.Strings(missing)
+		if len(missing) == 0 {
+			continue
+		}
+		if len(supported) == 0 {
+			return fmt.Errorf("%w: image %s is only published for linux/%s, but the cluster nodes are %s; use nodes of a supported architecture (e.g. an amd64 node pool instead of arm), or mirror a multi-arch build with --image-registry",
+				ErrValidation, image, strings.Join(imageArchitectures, ", linux/"), strings.Join(missing, ", "))
+		}
+		InfoMessage(fmt.Sprintf("Warning: image %s is not published for the nodes %s, its pods only run on %s nodes", image, strings.Join(missing, ", "), strings.Join(supported, ", ")))
+	}
+	return nil
+}
+
+// archNodeAffinity pins pods to nodes of the given architectures
+func archNodeAffinity(architectures []string) *corev1.Affinity {
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key:      corev1.LabelArchStable,
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   architectures,
+					}},
+				}},
+			},
+		},
+	}
+}
+
+func containsArch(architectures []string, arch string) bool {
+	for _, a := range architectures {
+		if a == arch {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// GitCommit and BuildDate are set at release builds with
+// -ldflags "-X github.com/grapple-solution/grapple_cli/utils.GitCommit=... -X github.com/grapple-solution/grapple_cli/utils.BuildDate=..."
+var (
+	GitCommit string
+	BuildDate string
+)
+
+// BuildInfo returns the commit and date the CLI was built from, local builds fall back to the VCS information Go
+// embeds in the binary
+func BuildInfo() (commit, date string) {
+	commit, date = GitCommit, BuildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && commit == "":
+				commit = setting.Value
+			case setting.Key == "vcs.time" && date == "":
+				date = setting.Value
+			}
+		}
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return commit, date
+}
+
+// ServerVersions are the versions of the Grapple components installed in a cluster
+type ServerVersions struct {
+	Releases   map[string]string `json:"releases" yaml:"releases"`
+	Grapi      []string          `json:"grapi,omitempty" yaml:"grapi,omitempty"`
+	Gruim      []string          `json:"gruim,omitempty" yaml:"gruim,omitempty"`
+	KubeBlocks string            `json:"kubeblocks,omitempty" yaml:"kubeblocks,omitempty"`
+}
+
+// CollectServerVersions returns the chart versions of the grsf releases, the image tags of the deployed grapi
+// and gruim containers, and the KubeBlocks version
+func CollectServerVersions(kubeClient apiv1.Interface, restConfig *rest.Config) (*ServerVersions, error) {
+	versions := &ServerVersions{Releases: map[string]string{}}
+	for _, release := range GrplReleases {
+		version, err := GetGrplReleaseVersion(restConfig, release, "grpl-system")
+		if err != nil {
+			return nil, err
+		}
+		if version == "" {
+			version = "not installed"
+		}
+		versions.Releases[release] = version
+	}
+
+	deployments, err := kubeClient.AppsV1().Deployments("").List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	grapi, gruim := map[string]bool{}, map[string]bool{}
+	for _, deployment := range deployments.Items {
+		var seen map[string]bool
+		switch {
+		case strings.HasSuffix(deployment.Name, "-grapi"):
+			seen = grapi
+		case strings.HasSuffix(deployment.Name, "-gruim"):
+			seen = gruim
+		case deployment.Namespace == "kb-system" && deployment.Name == "kubeblocks" && len(deployment.Spec.Template.Spec.Containers) > 0:
+			versions.KubeBlocks = imageTag(deployment.Spec.Template.Spec.Containers[0].Image)
+			continue
+		default:
+			continue
+		}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			seen[imageTag(container.Image)] = true
+		}
+	}
+	versions.Grapi = sortedKeys(grapi)
+	versions.Gruim = sortedKeys(gruim)
+
+	if version, err := GetGrplReleaseVersion(restConfig, "kubeblocks", "kb-system"); err == nil && version != "" {
+		versions.KubeBlocks = version
+	}
+	return versions, nil
+}
+
+// imageTag returns the tag of an image reference, latest when it has none
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return "latest"
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
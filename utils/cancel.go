@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// commandCtx is the context of the running command, it is canceled on SIGINT/SIGTERM, see HandleInterrupts
+var commandCtx = context.Background()
+
+// CommandContext returns the context of the running command: waits, helm actions and polling loops stop when it
+// is canceled. Cleanup (rollbacks, records of what was created) must not use it, it runs after the cancellation.
+func CommandContext() context.Context {
+	return commandCtx
+}
+
+// HandleInterrupts cancels the context of the command on the first SIGINT or SIGTERM, a second one kills the
+// CLI right away. stop releases the signals at the end of the command.
+func HandleInterrupts() (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	commandCtx = ctx
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			// Restore the default handling, so the next Ctrl+C ends the CLI
+			signal.Stop(signals)
+			StopSpinner()
+			ErrorMessage(fmt.Sprintf("Received %s, stopping... (press Ctrl+C again to quit right away)", sig))
+			cancel(ErrCanceled)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// Canceled returns ErrCanceled if the command was interrupted, nil otherwise
+func Canceled() error {
+	if commandCtx.Err() != nil {
+		return fmt.Errorf("%w: interrupted", ErrCanceled)
+	}
+	return nil
+}
+
+// IsCanceled reports whether err comes from an interrupted command
+func IsCanceled(err error) bool {
+	return errors.Is(err, ErrCanceled)
+}
+
+// Sleep waits for d, or returns ErrCanceled as soon as the command is interrupted. Polling loops use it
+// instead of time.Sleep.
+func Sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-commandCtx.Done():
+		return Canceled()
+	}
+}
+
+var (
+	// phaseMu guards the phases of the running installation, recorded for the summary of an interrupted install
+	phaseMu         sync.Mutex
+	completedPhases []string
+	runningPhase    string
+)
+
+func phaseStarted(name string) {
+	phaseMu.Lock()
+	defer phaseMu.Unlock()
+	runningPhase = name
+}
+
+func phaseFinished(name string, err error) {
+	phaseMu.Lock()
+	defer phaseMu.Unlock()
+	runningPhase = ""
+	if err == nil {
+		completedPhases = append(completedPhases, name)
+	}
+}
+
+// printInterruptedInstall prints where an interrupted installation stopped and how to go on from there
+func printInterruptedInstall(tx *InstallTransaction) {
+	phaseMu.Lock()
+	completed, running := append([]string(nil), completedPhases...), runningPhase
+	phaseMu.Unlock()
+
+	ErrorMessage("The installation was interrupted")
+	if len(completed) > 0 {
+		InfoMessage(fmt.Sprintf("  completed phases: %s", strings.Join(completed, ", ")))
+	}
+	if running != "" {
+		InfoMessage(fmt.Sprintf("  interrupted phase: %s", running))
+	}
+	for _, step := range tx.Steps {
+		ref := step.Name
+		if step.Namespace != "" {
+			ref = step.Namespace + "/" + step.Name
+		}
+		InfoMessage(fmt.Sprintf("  created %s %s", step.Kind, ref))
+	}
+	InfoMessage("Run the same install command again with --resume to continue from the interrupted phase, or run 'grapple install rollback' to remove what was created")
+}
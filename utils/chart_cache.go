@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+const (
+	kubeBlocksRepoURL = "https://apecloud.github.io/helm-charts"
+	// KubeBlocksVersion is the version of the KubeBlocks chart and CRDs that is installed
+	KubeBlocksVersion = "0.9.1"
+)
+
+// OfflineMode resolves charts only from the chart cache, it is set by the --offline flag of the installers
+var OfflineMode bool
+
+var imageLineRegex = regexp.MustCompile(`(?m)^\s*-?\s*image:\s*["']?([^"'\s]+)["']?\s*$`)
+
+// ChartCache is the result of 'grapple cache pull'
+type ChartCache struct {
+	Dir            string   `json:"dir" yaml:"dir"`
+	GrappleVersion string   `json:"grappleVersion" yaml:"grappleVersion"`
+	Charts         []string `json:"charts" yaml:"charts"`
+	Images         []string `json:"images" yaml:"images"`
+}
+
+// ChartCacheDir returns the directory of the chart cache, $XDG_CACHE_HOME/grpl/charts or ~/.cache/grpl/charts
+func ChartCacheDir() (string, error) {
+	cacheHome, err := cacheHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheHome, "grpl", "charts"), nil
+}
+
+// ExamplesCacheDir returns the directory of the cached examples repository, $XDG_CACHE_HOME/grpl/examples or
+// ~/.cache/grpl/examples
+func ExamplesCacheDir() (string, error) {
+	cacheHome, err := cacheHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheHome, "grpl", "examples"), nil
+}
+
+// cacheHomeDir returns $XDG_CACHE_HOME, or ~/.cache if it is not set
+func cacheHomeDir() (string, error) {
+	if cacheHome := os.Getenv("XDG_CACHE_HOME"); cacheHome != "" {
+		return cacheHome, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".cache"), nil
+}
+
+// cachedChartPath returns the path of a chart archive in the cache, or "" if it is not cached
+func cachedChartPath(chart, version string) string {
+	dir, err := ChartCacheDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.tgz", chart, version))
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// locateChart returns the cached chart archive, or locates (downloads) the chart when it is not cached.
+// In offline mode a chart that is not cached is an error.
+func locateChart(opts *action.ChartPathOptions, chart, chartRef, version string, settings *cli.EnvSettings) (string, error) {
+	if path := cachedChartPath(chart, version); path != "" {
+		InfoMessage(fmt.Sprintf("Using cached chart %s", path))
+		return path, nil
+	}
+	if OfflineMode {
+		return "", fmt.Errorf("%w: chart %s %s is not cached, run 'grapple cache pull --grapple-version %s' while online", ErrChartNotFound, chart, version, version)
+	}
+	path, err := opts.LocateChart(chartRef, settings)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrChartNotFound, err)
+	}
+	return path, nil
+}
+
+// kubeBlocksCRDs returns the KubeBlocks CRDs manifest from the cache, it is downloaded into the cache first
+// when it is missing
+func kubeBlocksCRDs() (io.ReadCloser, error) {
+	dir, err := ChartCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, kubeBlocksCRDsFile())
+	if _, err := os.Stat(path); err != nil {
+		if OfflineMode {
+			return nil, fmt.Errorf("KubeBlocks CRDs are not cached, run 'grapple cache pull' while online")
+		}
+		if _, err := NewDownloader().Fetch(Download{URL: kubeBlocksCRDsURL(), Path: path}); err != nil {
+			return nil, fmt.Errorf("failed to download CRDs yaml: %w", err)
+		}
+	}
+	return os.Open(path)
+}
+
+func kubeBlocksCRDsURL() string {
+	return fmt.Sprintf("https://github.com/apecloud/kubeblocks/releases/download/v%s/kubeblocks_crds.yaml", KubeBlocksVersion)
+}
+
+func kubeBlocksCRDsFile() string {
+	return fmt.Sprintf("kubeblocks_crds-%s.yaml", KubeBlocksVersion)
+}
+
+// PullCharts downloads the grsf charts of the given version, the KubeBlocks chart and CRDs into the chart
+// cache, and writes the list of images the charts use to images-<version>.txt
+func PullCharts(version string) (*ChartCache, error) {
+	dir, err := ChartCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create chart cache %s: %w", dir, err)
+	}
+
+	regClient, err := NewChartRegistryClient()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := cli.New()
+	cache := &ChartCache{Dir: dir, GrappleVersion: version}
+	images := map[string]bool{
+		MirrorImage(fmt.Sprintf("grpl/grapi:%s", version)): true,
+		MirrorImage(fmt.Sprintf("grpl/gruim:%s", version)): true,
+	}
+
+	// The charts are pulled in parallel, chartPaths keeps the order of the list
+	var mu sync.Mutex
+	pull := func(chart, chartRef, chartVersion, repoURL string, chartPath *string) error {
+		path := cachedChartPath(chart, chartVersion)
+		if path == "" {
+			InfoMessage(fmt.Sprintf("Pulling %s %s...", chart, chartVersion))
+			client := action.NewPullWithOpts(action.WithConfig(&action.Configuration{RegistryClient: regClient}))
+			client.Settings = settings
+			client.DestDir = dir
+			client.Version = chartVersion
+			client.RepoURL = repoURL
+			if _, err := client.Run(chartRef); err != nil {
+				return fmt.Errorf("failed to pull chart %s %s: %w", chart, chartVersion, err)
+			}
+			if path = cachedChartPath(chart, chartVersion); path == "" {
+				return fmt.Errorf("chart %s %s was pulled but not found in %s", chart, chartVersion, dir)
+			}
+		} else {
+			InfoMessage(fmt.Sprintf("%s %s is already cached", chart, chartVersion))
+		}
+		*chartPath = path
+
+		chartImages, err := renderedImages(path)
+		if err != nil {
+			InfoMessage(fmt.Sprintf("Could not list the images of %s: %v", chart, err))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, image := range chartImages {
+			images[image] = true
+		}
+		return nil
+	}
+
+	chartPaths := make([]string, len(GrplReleases)+1)
+	var tasks []func() error
+	for i, release := range GrplReleases {
+		i, release := i, release
+		tasks = append(tasks, func() error { return pull(release, GrplChartRef(release), version, "", &chartPaths[i]) })
+	}
+	tasks = append(tasks, func() error {
+		return pull("kubeblocks", "kubeblocks", KubeBlocksVersion, kubeBlocksRepoURL, &chartPaths[len(GrplReleases)])
+	})
+
+	crdsPath := filepath.Join(dir, kubeBlocksCRDsFile())
+	if _, err := os.Stat(crdsPath); err != nil {
+		tasks = append(tasks, func() error {
+			InfoMessage("Downloading KubeBlocks CRDs...")
+			if _, err := NewDownloader().Fetch(Download{URL: kubeBlocksCRDsURL(), Path: crdsPath}); err != nil {
+				return fmt.Errorf("failed to download CRDs yaml: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := NewDownloader().Run(tasks); err != nil {
+		return nil, err
+	}
+	cache.Charts = append(chartPaths, crdsPath)
+
+	for image := range images {
+		cache.Images = append(cache.Images, image)
+	}
+	sort.Strings(cache.Images)
+	imagesPath := filepath.Join(dir, fmt.Sprintf("images-%s.txt", version))
+	if err := os.WriteFile(imagesPath, []byte(strings.Join(cache.Images, "\n")+"\n"), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", imagesPath, err)
+	}
+
+	return cache, nil
+}
+
+// renderedImages renders the chart with its default values, without a cluster, and returns the images it uses
+func renderedImages(chartPath string) ([]string, error) {
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+
+	client := action.NewInstall(&action.Configuration{})
+	client.DryRun = true
+	client.ClientOnly = true
+	client.Replace = true
+	client.IncludeCRDs = false
+	client.ReleaseName = chart.Name()
+	client.Namespace = "grpl-system"
+
+	rel, err := client.Run(chart, map[string]interface{}{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render chart: %w", err)
+	}
+
+	manifests := rel.Manifest
+	for _, hook := range rel.Hooks {
+		manifests += "\n" + hook.Manifest
+	}
+
+	var images []string
+	for _, match := range imageLineRegex.FindAllStringSubmatch(manifests, -1) {
+		if !Contains(images, match[1]) {
+			images = append(images, match[1])
+		}
+	}
+	return images, nil
+}
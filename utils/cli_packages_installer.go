@@ -2,10 +2,16 @@ package utils
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
+	"time"
 )
 
 const (
@@ -45,6 +51,15 @@ func init() {
 	}
 }
 
+// ValidatePackageManager checks that name is one of the supported package managers
+func ValidatePackageManager(name string) error {
+	switch name {
+	case brewPackageManager, aptPackageManager, dnfPackageManager, chocoPackageManager:
+		return nil
+	}
+	return fmt.Errorf("unsupported package manager %q, use one of: brew, apt, dnf, choco", name)
+}
+
 func displayPackageInstallerMessage() {
 	if !messagedPrinted {
 		if os.Getenv("PACKAGE_MANAGER") != "" {
@@ -72,34 +87,30 @@ func InstallDevspace() error {
 		cmd = exec.Command(brewPackageManager, "install", "devspace")
 	case aptPackageManager, dnfPackageManager:
 
+		arch, err := ToolArch()
+		if err != nil {
+			return err
+		}
 		// Download devspace binary
-		downloadCmd := exec.Command("curl", "-L", "-o", "devspace",
-			"https://github.com/loft-sh/devspace/releases/latest/download/devspace-linux-amd64")
-		downloadCmd.Stdout = os.Stdout
-		StartSpinner("Downloading Devspace CLI, It will take a few minutes...")
-		if err := downloadCmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error downloading devspace: %v", err))
-			return fmt.Errorf("error downloading devspace: %w", err)
+		path, err := downloadTool("https://github.com/loft-sh/devspace/releases/latest/download/devspace-linux-"+arch, "devspace-linux-"+arch,
+			"https://github.com/loft-sh/devspace/releases/latest/download/devspace-linux-"+arch+".sha256")
+		if err != nil {
+			return err
 		}
-		StopSpinner()
 
 		// Install binary to /usr/local/bin with correct permissions
-		cmd = exec.Command("sudo", "install", "-c", "-m", "0755", "devspace", "/usr/local/bin")
+		cmd = exec.Command("sudo", "install", "-c", "-m", "0755", path, "/usr/local/bin/devspace")
 	case chocoPackageManager:
 		// Download devspace binary for Windows
-		downloadCmd := exec.Command("powershell", "-Command",
-			"Invoke-WebRequest -Uri https://github.com/loft-sh/devspace/releases/latest/download/devspace-windows-amd64.exe -OutFile devspace.exe")
-		downloadCmd.Stdout = os.Stdout
-		StartSpinner("Downloading Devspace CLI, It will take a few minutes...")
-		if err := downloadCmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error downloading devspace: %v", err))
-			return fmt.Errorf("error downloading devspace: %w", err)
+		path, err := downloadTool("https://github.com/loft-sh/devspace/releases/latest/download/devspace-windows-amd64.exe", "devspace.exe",
+			"https://github.com/loft-sh/devspace/releases/latest/download/devspace-windows-amd64.exe.sha256")
+		if err != nil {
+			return err
 		}
-		StopSpinner()
 
 		// Move to Windows PATH location
 		cmd = exec.Command("powershell", "-Command",
-			"Move-Item -Force devspace.exe $env:USERPROFILE\\AppData\\Local\\Microsoft\\WindowsApps\\")
+			fmt.Sprintf("Move-Item -Force '%s' $env:USERPROFILE\\AppData\\Local\\Microsoft\\WindowsApps\\", path))
 	default:
 		return fmt.Errorf("unsupported package manager: %s", PackageManager)
 	}
@@ -127,27 +138,27 @@ func InstallTaskCLI() error {
 	case brewPackageManager:
 		cmd = exec.Command(brewPackageManager, "install", "go-task/tap/go-task")
 	case aptPackageManager, dnfPackageManager:
-
-		cmd = exec.Command("sh", "-c", `
-		curl -sL https://github.com/go-task/task/releases/latest/download/task_linux_amd64.tar.gz | \
-		tar xz -C /tmp && \
-		sudo mv /tmp/task /usr/local/bin/
-	  `)
+		arch, err := ToolArch()
+		if err != nil {
+			return err
+		}
+		path, err := downloadTool(fmt.Sprintf("https://github.com/go-task/task/releases/latest/download/task_linux_%s.tar.gz", arch), fmt.Sprintf("task_linux_%s.tar.gz", arch),
+			"https://github.com/go-task/task/releases/latest/download/task_checksums.txt")
+		if err != nil {
+			return err
+		}
+		cmd = exec.Command("sh", "-c", fmt.Sprintf(`tar xzf '%s' -C /tmp && sudo mv /tmp/task /usr/local/bin/`, path))
 	case chocoPackageManager:
 		// Download Task binary for Windows
-		downloadCmd := exec.Command("powershell", "-Command",
-			"Invoke-WebRequest -Uri https://github.com/go-task/task/releases/latest/download/task_windows_amd64.zip -OutFile task.zip")
-		downloadCmd.Stdout = os.Stdout
-		StartSpinner("Downloading Task CLI, It will take a few minutes...")
-		if err := downloadCmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error downloading task: %v", err))
-			return fmt.Errorf("error downloading task: %w", err)
+		path, err := downloadTool("https://github.com/go-task/task/releases/latest/download/task_windows_amd64.zip", "task_windows_amd64.zip",
+			"https://github.com/go-task/task/releases/latest/download/task_checksums.txt")
+		if err != nil {
+			return err
 		}
-		StopSpinner()
 
 		// Extract and install
 		cmd = exec.Command("powershell", "-Command",
-			"Expand-Archive -Path task.zip -DestinationPath $env:USERPROFILE\\AppData\\Local\\Microsoft\\WindowsApps\\ -Force")
+			fmt.Sprintf("Expand-Archive -Path '%s' -DestinationPath $env:USERPROFILE\\AppData\\Local\\Microsoft\\WindowsApps\\ -Force", path))
 	default:
 		return fmt.Errorf("unsupported package manager: %s", PackageManager)
 	}
@@ -175,35 +186,30 @@ func InstallYq() error {
 		cmd = exec.Command(brewPackageManager, "install", "yq")
 	case aptPackageManager, dnfPackageManager:
 
-		// Download yq binary using curl
-		downloadCmd := exec.Command("sh", "-c", `
-			sudo curl -sL https://github.com/mikefarah/yq/releases/latest/download/yq_linux_amd64 -o /usr/bin/yq
-		`)
-		downloadCmd.Stdout = os.Stdout
-		StartSpinner("Downloading Yq CLI, It will take a few minutes...")
-		if err := downloadCmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error downloading yq: %v", err))
-			return fmt.Errorf("error downloading yq: %w", err)
+		arch, err := ToolArch()
+		if err != nil {
+			return err
+		}
+		// Download yq binary
+		path, err := downloadTool(fmt.Sprintf("https://github.com/mikefarah/yq/releases/latest/download/yq_linux_%s", arch), "yq_linux_"+arch,
+			"https://github.com/mikefarah/yq/releases/latest/download/checksums")
+		if err != nil {
+			return err
 		}
-		StopSpinner()
 
-		// Set executable permissions
-		cmd = exec.Command("sudo", "chmod", "+x", "/usr/bin/yq")
+		// Install binary to /usr/bin with executable permissions
+		cmd = exec.Command("sudo", "install", "-c", "-m", "0755", path, "/usr/bin/yq")
 	case chocoPackageManager:
 		// Download yq binary for Windows
-		downloadCmd := exec.Command("powershell", "-Command",
-			"Invoke-WebRequest -Uri https://github.com/mikefarah/yq/releases/latest/download/yq_windows_amd64.exe -OutFile yq.exe")
-		downloadCmd.Stdout = os.Stdout
-		StartSpinner("Downloading Yq CLI, It will take a few minutes...")
-		if err := downloadCmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error downloading yq: %v", err))
-			return fmt.Errorf("error downloading yq: %w", err)
+		path, err := downloadTool("https://github.com/mikefarah/yq/releases/latest/download/yq_windows_amd64.exe", "yq.exe",
+			"https://github.com/mikefarah/yq/releases/latest/download/checksums")
+		if err != nil {
+			return err
 		}
-		StopSpinner()
 
 		// Move to Windows PATH location
 		cmd = exec.Command("powershell", "-Command",
-			"Move-Item -Force yq.exe $env:USERPROFILE\\AppData\\Local\\Microsoft\\WindowsApps\\")
+			fmt.Sprintf("Move-Item -Force '%s' $env:USERPROFILE\\AppData\\Local\\Microsoft\\WindowsApps\\", path))
 	default:
 		return fmt.Errorf("unsupported package manager: %s", PackageManager)
 	}
@@ -280,70 +286,6 @@ func InstallDnsmasq() error {
 	SuccessMessage("Dnsmasq installed successfully")
 	return nil
 }
-func InstallMkcert() error {
-	if _, err := exec.LookPath("mkcert"); err == nil {
-		return nil // Already installed
-	}
-
-	displayPackageInstallerMessage()
-	InfoMessage("Installing Mkcert...")
-
-	var err error
-	switch PackageManager {
-	case brewPackageManager:
-		// Install mkcert
-		cmd := exec.Command(brewPackageManager, "install", "mkcert")
-		if err = cmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error installing mkcert: %v", err))
-			return fmt.Errorf("error installing mkcert: %w", err)
-		}
-
-		// Install nss
-		cmd = exec.Command(brewPackageManager, "install", "nss")
-		if err = cmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error installing nss: %v", err))
-			return fmt.Errorf("error installing nss: %w", err)
-		}
-
-	case aptPackageManager, dnfPackageManager:
-		// Check if brew is available
-		if _, err := exec.LookPath("brew"); err != nil {
-			ErrorMessage("Mkcert can only be installed using Homebrew. Please install Homebrew first.")
-			return fmt.Errorf("brew is required to install mkcert")
-		}
-		InfoMessage("Mkcert can only be installed using Homebrew, proceeding with brew installation...")
-
-		// Install mkcert
-		cmd := exec.Command("brew", "install", "mkcert")
-		if err = cmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error installing mkcert: %v", err))
-			return fmt.Errorf("error installing mkcert: %w", err)
-		}
-
-		// Install nss
-		cmd = exec.Command("brew", "install", "nss")
-		if err = cmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error installing nss: %v", err))
-			return fmt.Errorf("error installing nss: %w", err)
-		}
-
-	case chocoPackageManager:
-		cmd := exec.Command(chocoPackageManager, "install", "-y", "mkcert")
-		StartSpinner("Installing Mkcert, It will take a few minutes...")
-		if err = cmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error installing mkcert: %v", err))
-			return fmt.Errorf("error installing mkcert: %w", err)
-		}
-		StopSpinner()
-
-	default:
-		return fmt.Errorf("unsupported package manager: %s", PackageManager)
-	}
-
-	SuccessMessage("Mkcert installed successfully")
-	return nil
-}
-
 func InstallStern() error {
 	if _, err := exec.LookPath("stern"); err == nil {
 		return nil // Already installed
@@ -365,12 +307,9 @@ func InstallStern() error {
 		}
 		StopSpinner()
 	case aptPackageManager, dnfPackageManager:
-		// Determine architecture
-		var arch string
-		if runtime.GOARCH == "arm64" {
-			arch = "arm64"
-		} else {
-			arch = "amd64"
+		arch, err := ToolArch()
+		if err != nil {
+			return err
 		}
 
 		// Get the latest release download URL from GitHub API
@@ -401,18 +340,10 @@ func InstallStern() error {
 
 		// Extract filename from URL
 		parts := strings.Split(downloadURL, "/")
-		tarballName := parts[len(parts)-1]
-
-		// Download stern tarball
-		downloadCmd := exec.Command("curl", "-L", "-o", tarballName, downloadURL)
-		downloadCmd.Stdout = os.Stdout
-		downloadCmd.Stderr = os.Stderr
-		StartSpinner("Downloading Stern CLI, It will take a few minutes...")
-		if err := downloadCmd.Run(); err != nil {
-			ErrorMessage(fmt.Sprintf("Error downloading stern: %v", err))
-			return fmt.Errorf("error downloading stern: %w", err)
+		tarballName, err := downloadTool(downloadURL, parts[len(parts)-1], strings.Join(parts[:len(parts)-1], "/")+"/checksums.txt")
+		if err != nil {
+			return err
 		}
-		StopSpinner()
 
 		// Extract tarball
 		extractCmd := exec.Command("tar", "-xzf", tarballName, "stern")
@@ -533,3 +464,87 @@ func InstallStern() error {
 	SuccessMessage("Stern CLI installed successfully")
 	return nil
 }
+
+// downloadTool downloads a release asset of a tool into the downloads cache and verifies it against the sha256
+// of the release's checksums file, an interrupted download is resumed by the next install
+func downloadTool(url, name, checksumsURL string) (string, error) {
+	cacheHome, err := cacheHomeDir()
+	if err != nil {
+		return "", err
+	}
+	expected, err := toolChecksum(checksumsURL, path.Base(url))
+	if err != nil {
+		ErrorMessage(fmt.Sprintf("Error verifying %s: %v", name, err))
+		return "", fmt.Errorf("error verifying %s: %w", name, err)
+	}
+	path := filepath.Join(cacheHome, "grpl", "downloads", name)
+	if _, err := NewDownloader().Fetch(Download{URL: url, Path: path, Mode: 0755, SHA256: expected}); err != nil {
+		ErrorMessage(fmt.Sprintf("Error downloading %s: %v", name, err))
+		return "", fmt.Errorf("error downloading %s: %w", name, err)
+	}
+	return path, nil
+}
+
+// toolChecksum returns the sha256 of a release asset from the checksums file of the release: a sha256sum file
+// ("<sha256>  <asset>" lines), a .sha256 file of the asset alone, or yq's checksums file with the asset followed
+// by several hashes, SHA-256 being the first 256-bit one of them
+func toolChecksum(checksumsURL, asset string) (string, error) {
+	req, err := http.NewRequestWithContext(CommandContext(), http.MethodGet, checksumsURL, nil)
+	if err != nil {
+		return "", err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the checksums of %s: %w", asset, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get the checksums of %s from %s: unexpected status %s", asset, checksumsURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the checksums of %s: %w", asset, err)
+	}
+	if sum, ok := parseChecksums(string(data), asset); ok {
+		return sum, nil
+	}
+	return "", fmt.Errorf("%s has no sha256 of %s", checksumsURL, asset)
+}
+
+// sha256Pattern matches a hex encoded sha256
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// parseChecksums looks up the sha256 of asset in the content of a checksums file, see toolChecksum
+func parseChecksums(content, asset string) (string, bool) {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if fields := strings.Fields(content); len(lines) == 1 && len(fields) == 1 && sha256Pattern.MatchString(fields[0]) {
+		return fields[0], true
+	}
+	if sum, ok := sha256sumLookup(content, asset); ok {
+		return sum, true
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) > 2 && fields[0] == asset {
+			for _, field := range fields[1:] {
+				if sha256Pattern.MatchString(field) {
+					return field, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// sha256sumLookup looks up the sha256 of asset in the output of sha256sum, "<sha256>  <asset>" lines. Assets
+// hashed in binary mode are prefixed with *, and assets hashed in a subdirectory are matched by their base name.
+func sha256sumLookup(content, asset string) (string, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && sha256Pattern.MatchString(fields[0]) && path.Base(strings.TrimPrefix(fields[1], "*")) == asset {
+			return fields[0], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseChecksums(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	other := strings.Repeat("cd", 32)
+	tests := []struct {
+		name    string
+		content string
+		asset   string
+		want    string
+		wantOK  bool
+	}{
+		{name: "sha256sum", content: other + "  k3d-darwin-amd64\n" + hash + "  k3d-linux-amd64\n", asset: "k3d-linux-amd64", want: hash, wantOK: true},
+		{name: "sha256sum binary mode", content: hash + " *kubectl\n", asset: "kubectl", want: hash, wantOK: true},
+		{name: "sha256sum with directory", content: hash + "  dist/helm-linux-amd64.tar.gz\n", asset: "helm-linux-amd64.tar.gz", want: hash, wantOK: true},
+		{name: "single hash file", content: hash + "\n", asset: "kubectl", want: hash, wantOK: true},
+		{name: "yq format", content: "yq_linux_amd64  " + other[:32] + "  " + hash + "\n", asset: "yq_linux_amd64", want: hash, wantOK: true},
+		{name: "asset missing", content: other + "  k3d-darwin-amd64\n", asset: "k3d-linux-amd64"},
+		{name: "single hash of several lines", content: hash + "\n" + other + "\n", asset: "kubectl"},
+		{name: "not a hash", content: "not-a-hash  kubectl\n", asset: "kubectl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseChecksums(tt.content, tt.asset)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseChecksums() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSha256sumLookup(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	tests := []struct {
+		name    string
+		content string
+		asset   string
+		wantOK  bool
+	}{
+		{name: "text mode", content: hash + "  grapple-linux-amd64.tar.gz\n", asset: "grapple-linux-amd64.tar.gz", wantOK: true},
+		{name: "binary mode", content: hash + " *grapple-linux-amd64.tar.gz\n", asset: "grapple-linux-amd64.tar.gz", wantOK: true},
+		{name: "binary mode with directory", content: hash + " *dist/grapple-linux-amd64.tar.gz\n", asset: "grapple-linux-amd64.tar.gz", wantOK: true},
+		{name: "other asset", content: hash + "  grapple-darwin-arm64.tar.gz\n", asset: "grapple-linux-amd64.tar.gz"},
+		{name: "prefix of another asset", content: hash + "  grapple-linux-amd64.tar.gz.sig\n", asset: "grapple-linux-amd64.tar.gz"},
+		{name: "no hash", content: "grapple-linux-amd64.tar.gz\n", asset: "grapple-linux-amd64.tar.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sha256sumLookup(tt.content, tt.asset)
+			if ok != tt.wantOK || (ok && got != hash) {
+				t.Errorf("sha256sumLookup() = %q, %v, want %q, %v", got, ok, hash, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ClusterLogConfigMap is the ConfigMap the install log is mirrored to with --log-to-cluster
+	ClusterLogConfigMap = "grpl-install-log"
+	clusterLogKey       = "install.log"
+	// ConfigMaps are limited to 1MiB, the start of a long log is dropped
+	clusterLogMaxSize = 512 * 1024
+)
+
+// ClusterLogEnabled mirrors the install log into the cluster, set by --log-to-cluster or 'grapple config set log-to-cluster true'
+var ClusterLogEnabled bool
+
+var (
+	ansiColorPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	// secretPatterns match credentials that may end up in the log, e.g. tokens in helm values or command lines
+	secretPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)((?:^|[^a-z])(?:token|password|passwd|secret|api[-_]?key|license|lic)[a-z_-]*["']?\s*[:=]\s*["']?)[^\s"',}]+`),
+		regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/-]+=*`),
+		regexp.MustCompile(`(?i)(--(?:[a-z-]*token|[a-z-]*password|[a-z-]*license|[a-z-]*api-key)[= ])\S+`),
+		regexp.MustCompile(`()\b(?:ghp|gho|ghs|github_pat|sk-ant|sk)[-_][A-Za-z0-9_-]{16,}`),
+	}
+)
+
+// SanitizeLog removes terminal colors and masks credentials in a log
+func SanitizeLog(log string) string {
+	log = ansiColorPattern.ReplaceAllString(log, "")
+	for _, pattern := range secretPatterns {
+		log = pattern.ReplaceAllString(log, "${1}********")
+	}
+	return log
+}
+
+// MirrorLogToCluster stores the sanitized log file in the grpl-install-log ConfigMap, so support can read it with
+// 'kubectl get cm grpl-install-log -n grpl-system -o yaml'. It is a no-op unless ClusterLogEnabled is set, failures
+// are only reported since the log is still available locally.
+func MirrorLogToCluster(logFilePath, command string) {
+	if !ClusterLogEnabled {
+		return
+	}
+	_, kubeClient, err := GetKubernetesConfig()
+	if err != nil {
+		InfoMessage(fmt.Sprintf("Install log not mirrored to the cluster: %v", err))
+		return
+	}
+	namespace, err := storeClusterLog(kubeClient, logFilePath, command)
+	if err != nil {
+		InfoMessage(fmt.Sprintf("Install log not mirrored to the cluster: %v", err))
+		return
+	}
+	InfoMessage(fmt.Sprintf("Install log stored in the cluster, read it with 'kubectl get cm %s -n %s -o jsonpath={.data.install\\.log}'", ClusterLogConfigMap, namespace))
+}
+
+// storeClusterLog writes the log to grpl-system, or to kube-system when grpl-system doesn't exist (e.g. after a
+// rolled back installation), and returns the namespace
+func storeClusterLog(kubeClient apiv1.Interface, logFilePath, command string) (string, error) {
+	data, err := os.ReadFile(logFilePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", logFilePath, err)
+	}
+	log := SanitizeLog(string(data))
+	if len(log) > clusterLogMaxSize {
+		log = fmt.Sprintf("... (%d bytes truncated)\n", len(log)-clusterLogMaxSize) + log[len(log)-clusterLogMaxSize:]
+	}
+
+	namespace := "grpl-system"
+	if _, err := kubeClient.CoreV1().Namespaces().Get(context.TODO(), namespace, v1.GetOptions{}); errors.IsNotFound(err) {
+		namespace = "kube-system"
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      ClusterLogConfigMap,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				"grpl.io/command":     command,
+				"grpl.io/cli-version": GetGrappleCliVersion(),
+				"grpl.io/written-at":  time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+		Data: map[string]string{clusterLogKey: log},
+	}
+	ApplyCommonMetadata(&cm.ObjectMeta)
+
+	configMaps := kubeClient.CoreV1().ConfigMaps(namespace)
+	_, err = configMaps.Update(context.TODO(), cm, v1.UpdateOptions{})
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(context.TODO(), cm, v1.CreateOptions{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", ClusterLogConfigMap, err)
+	}
+	return namespace, nil
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// completionTimeout bounds the cluster lookups of shell completions, a slow cluster must not block the shell
+const completionTimeout = 3 * time.Second
+
+// RegisterDynamicCompletions adds completions looked up in the cluster to cmd and its subcommands: namespaces
+// for --namespace, kubeconfig clusters for --cluster-name and GrappleApplicationSets for a [gras-name] argument
+func RegisterDynamicCompletions(cmd *cobra.Command) {
+	if cmd.Flags().Lookup("namespace") != nil {
+		_ = cmd.RegisterFlagCompletionFunc("namespace", CompleteNamespaces)
+	}
+	if cmd.Flags().Lookup("cluster-name") != nil {
+		_ = cmd.RegisterFlagCompletionFunc("cluster-name", CompleteClusterNames)
+	}
+	if cmd.ValidArgsFunction == nil && strings.Contains(cmd.Use, "[gras-name]") {
+		cmd.ValidArgsFunction = CompleteGrasNames
+	}
+	for _, sub := range cmd.Commands() {
+		RegisterDynamicCompletions(sub)
+	}
+}
+
+// CompleteNamespaces completes the namespaces of the current cluster
+func CompleteNamespaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	restConfig, _, err := GetKubernetesConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	restConfig.Timeout = completionTimeout
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	namespaces, err := kubeClient.CoreV1().Namespaces().List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, ns := range namespaces.Items {
+		if strings.HasPrefix(ns.Name, toComplete) {
+			names = append(names, ns.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// CompleteGrasNames completes the GrappleApplicationSets of --namespace, or of all namespaces
+func CompleteGrasNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	restConfig, _, err := GetKubernetesConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	restConfig.Timeout = completionTimeout
+	namespace, _ := cmd.Flags().GetString("namespace")
+	grasNames, err := ListGrasNames(restConfig, namespace)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for ns, nsNames := range grasNames {
+		for _, name := range nsNames {
+			if !strings.HasPrefix(name, toComplete) {
+				continue
+			}
+			if namespace == "" {
+				name += "\t" + ns
+			}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// CompleteClusterNames completes the clusters of the kubeconfig, for k3d commands the k3d cluster names
+func CompleteClusterNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	kubeConfig, err := KubeconfigLoadingRules().Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	k3d := cmd.Parent() != nil && cmd.Parent().Name() == "k3d"
+	var names []string
+	for name := range kubeConfig.Clusters {
+		if k3d {
+			var ok bool
+			if name, ok = strings.CutPrefix(name, "k3d-"); !ok {
+				continue
+			}
+		}
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
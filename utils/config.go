@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// ConfigSetting is a value that can be stored in the CLI config file
+type ConfigSetting struct {
+	Key         string
+	Env         string
+	Description string
+	// Flag is the name of the command flags the setting provides the default for
+	Flag string
+	// Secret values are masked by 'grapple config view'
+	Secret bool
+}
+
+// ConfigSettings are all supported settings. Precedence is: command-line flag > environment variable > config file.
+var ConfigSettings = []ConfigSetting{
+	{Key: "civo-api-token", Env: "CIVO_API_TOKEN", Description: "Civo API token", Secret: true},
+	{Key: "digitalocean-access-token", Env: "DIGITALOCEAN_ACCESS_TOKEN", Description: "DigitalOcean access token", Secret: true},
+	{Key: "github-token", Env: "GITHUB_TOKEN", Description: "GitHub token for private repositories", Secret: true},
+	{Key: "anthropic-api-key", Env: "ANTHROPIC_API_KEY", Description: "Anthropic API key used by 'grapple ai'", Secret: true},
+	{Key: "openai-api-key", Env: "OPENAI_API_KEY", Description: "OpenAI API key used by 'grapple ai'", Secret: true},
+	{Key: "gemini-api-key", Env: "GEMINI_API_KEY", Description: "Google AI API key used by 'grapple ai'", Secret: true},
+	{Key: "ai-providers", Env: "GRPL_AI_PROVIDERS", Description: "AI providers in priority order, e.g. anthropic,openai (the next one is used when a request fails)"},
+	{Key: "namespace", Env: "GRPL_NAMESPACE", Description: "Default namespace of resource and example commands", Flag: "namespace"},
+	{Key: "cluster-provider", Env: "GRPL_CLUSTER_PROVIDER", Description: "Cluster provider of clusters whose grsf-config doesn't record one (k3d, civo, gke, doks)"},
+	{Key: "grapple-version", Env: "GRPL_GRAPPLE_VERSION", Description: "Default version of Grapple to install", Flag: "grapple-version"},
+	{Key: "chart-registry", Env: "GRPL_CHART_REGISTRY", Description: "OCI registry (mirror) the Grapple charts are pulled from, e.g. oci://registry.corp.com/grapple", Flag: "chart-registry"},
+	{Key: "chart-registry-username", Env: "GRPL_CHART_REGISTRY_USERNAME", Description: "Username of the chart registry"},
+	{Key: "chart-registry-password", Env: "GRPL_CHART_REGISTRY_PASSWORD", Description: "Password of the chart registry", Secret: true},
+	{Key: "registry-config", Env: "GRPL_REGISTRY_CONFIG", Description: "Docker config file with the credentials of the chart registry, e.g. ~/.docker/config.json"},
+	{Key: "image-registry", Env: "GRPL_IMAGE_REGISTRY", Description: "Registry mirror prefixed to the images of Grapple and its charts, e.g. registry.corp.com/dockerhub", Flag: "image-registry"},
+	{Key: "helm-driver", Env: "HELM_DRIVER", Description: "Storage backend of the helm releases: secret (default), configmap, memory or sql", Flag: "helm-driver"},
+	{Key: "helm-driver-sql-connection-string", Env: "HELM_DRIVER_SQL_CONNECTION_STRING", Description: "PostgreSQL connection string of the sql helm driver", Secret: true},
+	{Key: "update-check", Env: "GRPL_UPDATE_CHECK", Description: "Weekly check for new CLI and Grapple versions, false disables it"},
+	{Key: "license-api", Env: "GRPL_LICENSE_API", Description: "Licensing API license keys are validated against by 'grapple license'"},
+	{Key: "log-to-cluster", Env: "GRPL_LOG_TO_CLUSTER", Description: "Mirror the sanitized install log to the grpl-install-log ConfigMap, true enables it", Flag: "log-to-cluster"},
+	{Key: "download-parallelism", Env: "GRPL_DOWNLOAD_PARALLELISM", Description: "Maximum number of concurrent downloads of charts, CRDs and tools (default: 4)"},
+	{Key: "provider-values-dir", Env: "GRPL_PROVIDER_VALUES_DIR", Description: "Directory of the values profiles of the providers, values-<profile>.yaml (default: ~/.config/grpl/provider-values)"},
+	{Key: "workdir", Env: "GRPL_WORKDIR", Description: "Directory of template-files and files overriding the ones of the installation (see 'grapple resource templates which')"},
+	{Key: "templates-dir", Env: "GRPL_TEMPLATES_DIR", Description: "Directory of the GRAS template plugins (default: ~/.config/grpl/templates)"},
+	{Key: "ssh-bastion", Env: "GRPL_SSH_BASTION", Description: "SSH jump host the API server of the cluster is reached through, user@host[:port]", Flag: "ssh-bastion"},
+	{Key: "ssh-key", Env: "GRPL_SSH_KEY", Description: "Private key of the SSH bastion (default: the ssh agent and ~/.ssh/id_*)", Flag: "ssh-key"},
+	{Key: "telemetry.enabled", Env: "GRPL_TELEMETRY", Description: "Send anonymized command metrics (command, flags used, duration, result, versions), true enables it"},
+	{Key: "telemetry.endpoint", Env: "GRPL_TELEMETRY_ENDPOINT", Description: "Endpoint the command metrics are posted to (default: " + DefaultTelemetryEndpoint + ")"},
+	{Key: "package-manager", Env: "PACKAGE_MANAGER", Description: "Package manager used to install missing tools (brew, apt, dnf, choco)"},
+}
+
+var cliConfig = viper.New()
+
+// exportedFromConfig tracks the environment variables ApplyConfig set from the config file
+var exportedFromConfig = map[string]bool{}
+
+// ConfigFilePath returns the path of the CLI config file, ~/.config/grpl/config.yaml (or under $XDG_CONFIG_HOME)
+func ConfigFilePath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "grpl", "config.yaml"), nil
+}
+
+// LookupConfigSetting returns the setting with the given key
+func LookupConfigSetting(key string) (ConfigSetting, error) {
+	for _, setting := range ConfigSettings {
+		if setting.Key == key {
+			return setting, nil
+		}
+	}
+	keys := make([]string, 0, len(ConfigSettings))
+	for _, setting := range ConfigSettings {
+		keys = append(keys, setting.Key)
+	}
+	sort.Strings(keys)
+	return ConfigSetting{}, fmt.Errorf("unknown config key %q, supported keys are: %s", key, strings.Join(keys, ", "))
+}
+
+// LoadConfig reads the config file, a missing file is not an error
+func LoadConfig() error {
+	path, err := ConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	cliConfig.SetConfigFile(path)
+	cliConfig.SetConfigType("yaml")
+	for _, setting := range ConfigSettings {
+		if err := cliConfig.BindEnv(setting.Key, setting.Env); err != nil {
+			return fmt.Errorf("failed to bind %s: %w", setting.Env, err)
+		}
+	}
+
+	if err := cliConfig.ReadInConfig(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// ApplyConfig makes the config file values visible to the command: unset environment variables are set
+// from the file, so code reading them (e.g. CIVO_API_TOKEN) picks them up, and flags not passed on the
+// command line get their value from the environment or the file.
+func ApplyConfig(cmd *cobra.Command) error {
+	if err := LoadConfig(); err != nil {
+		return err
+	}
+
+	for _, setting := range ConfigSettings {
+		if _, ok := os.LookupEnv(setting.Env); ok {
+			continue
+		}
+		if value := cliConfig.GetString(setting.Key); value != "" {
+			if err := os.Setenv(setting.Env, value); err != nil {
+				return fmt.Errorf("failed to set %s: %w", setting.Env, err)
+			}
+			exportedFromConfig[setting.Env] = true
+		}
+	}
+	// PackageManager is detected before the config is loaded
+	if packageManager := os.Getenv("PACKAGE_MANAGER"); packageManager != "" {
+		PackageManager = packageManager
+	}
+
+	var flagErr error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed || flagErr != nil {
+			return
+		}
+		for _, setting := range ConfigSettings {
+			if setting.Flag != flag.Name {
+				continue
+			}
+			if value := cliConfig.GetString(setting.Key); value != "" {
+				if err := flag.Value.Set(value); err != nil {
+					flagErr = fmt.Errorf("invalid %s %q from config: %w", setting.Key, value, err)
+				}
+			}
+		}
+	})
+	return flagErr
+}
+
+// ConfigValue returns the effective value of a setting, taking the environment into account
+func ConfigValue(key string) string {
+	return cliConfig.GetString(key)
+}
+
+// ConfigSource returns where the effective value of a setting comes from: "env", "file" or "" if it is unset
+func ConfigSource(setting ConfigSetting) string {
+	if value, ok := os.LookupEnv(setting.Env); ok && value != "" && !exportedFromConfig[setting.Env] {
+		return "env"
+	}
+	if cliConfig.InConfig(setting.Key) && cliConfig.GetString(setting.Key) != "" {
+		return "file"
+	}
+	return ""
+}
+
+// ConfigFileValues returns the values stored in the config file only
+func ConfigFileValues() (map[string]string, error) {
+	path, err := ConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	fileConfig := viper.New()
+	fileConfig.SetConfigFile(path)
+	fileConfig.SetConfigType("yaml")
+	if err := fileConfig.ReadInConfig(); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	for _, key := range fileConfig.AllKeys() {
+		values[key] = fileConfig.GetString(key)
+	}
+	return values, nil
+}
+
+// SetConfigValue stores a setting in the config file, an empty value removes it
+func SetConfigValue(key, value string) error {
+	if _, err := LookupConfigSetting(key); err != nil {
+		return err
+	}
+
+	values, err := ConfigFileValues()
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		delete(values, key)
+	} else {
+		values[key] = value
+	}
+
+	path, err := ConfigFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	fileConfig := viper.New()
+	fileConfig.SetConfigType("yaml")
+	for k, v := range values {
+		fileConfig.Set(k, v)
+	}
+	if err := fileConfig.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+	// The file holds API keys
+	return os.Chmod(path, 0600)
+}
+
+// ValidateClusterProvider checks that name is one of the providers the CLI can manage
+func ValidateClusterProvider(name string) error {
+	switch strings.ToUpper(name) {
+	case ProviderClusterTypeK3d, ProviderClusterTypeCivo, ProviderClusterTypeGke, ProviderClusterTypeDoks:
+		return nil
+	}
+	return fmt.Errorf("unsupported cluster provider %q, use one of: k3d, civo, gke, doks", name)
+}
+
+// MaskSecret hides all but the last characters of a secret value
+func MaskSecret(value string) string {
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", 8) + value[len(value)-4:]
+}
@@ -0,0 +1,329 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ConfigDrift is a value of the grsf-config secret that no longer matches the cluster
+type ConfigDrift struct {
+	Key      string `json:"key" yaml:"key"`
+	Recorded string `json:"recorded" yaml:"recorded"`
+	Actual   string `json:"actual" yaml:"actual"`
+	Message  string `json:"message" yaml:"message"`
+	// Repair describes what 'grapple status --repair' changes, empty if the drift has to be fixed by hand
+	Repair string `json:"repair,omitempty" yaml:"repair,omitempty"`
+
+	// secretValue is written to the key of grsf-config by the repair, repairCluster changes the cluster instead
+	secretValue   *string
+	repairCluster func(ctx context.Context) error
+}
+
+// Repairable reports whether 'grapple status --repair' can fix the drift
+func (d ConfigDrift) Repairable() bool {
+	return d.secretValue != nil || d.repairCluster != nil
+}
+
+// CheckGrsfConfig compares the values recorded in the grsf-config secret with the deployed components: the
+// grapple version, the provider, the cluster issuer and its ingress class, and the ingress IP
+func CheckGrsfConfig(kubeClient apiv1.Interface, restConfig *rest.Config, releases map[string]string) ([]ConfigDrift, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	secret, err := kubeClient.CoreV1().Secrets("grpl-system").Get(ctx, "grsf-config", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grsf-config: %w", err)
+	}
+	recorded := func(key string) string { return string(secret.Data[key]) }
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	var drifts []ConfigDrift
+	secretDrift := func(key, actual, message string) {
+		drifts = append(drifts, ConfigDrift{
+			Key: key, Recorded: recorded(key), Actual: actual, Message: message,
+			Repair:      fmt.Sprintf("set %s of grsf-config to %s", key, actual),
+			secretValue: &actual,
+		})
+	}
+
+	// grsf is upgraded by 'grapple upgrade' or helm directly, the version of grsf-config can lag behind
+	if version, deployed := recorded(SecKeyGrapleVersion), releases["grsf"]; version != "" && version != "latest" &&
+		deployed != "" && deployed != "not installed" && strings.TrimPrefix(version, "v") != strings.TrimPrefix(deployed, "v") {
+		secretDrift(SecKeyGrapleVersion, deployed, "the deployed grsf release has another version")
+	}
+
+	if dns, domain := recorded(SecKeyGrapleDNS), recorded(SecKeyClusterdomain); dns != "" && domain != "" && dns != domain {
+		secretDrift(SecKeyGrapleDNS, domain, "differs from clusterdomain")
+	}
+
+	if provider := nodeProvider(ctx, kubeClient); provider != "" && recorded(SecKeyProviderClusterType) != "" &&
+		!strings.EqualFold(recorded(SecKeyProviderClusterType), provider) {
+		secretDrift(SecKeyProviderClusterType, provider, "the nodes of the cluster belong to another provider")
+	}
+
+	classes := ingressClassNames(ctx, kubeClient)
+	defaultClass := ""
+	if len(classes) > 0 {
+		defaultClass = DefaultIngressClass(kubeClient)
+	}
+
+	if recorded(SecKeySsl) == "true" {
+		drifts = append(drifts, issuerDrifts(ctx, dynamicClient, restConfig, recorded(SecKeySslissuer), classes, defaultClass)...)
+	}
+
+	ips := ingressLoadBalancerIPs(ctx, kubeClient, classes)
+	if len(ips) > 0 {
+		if masterIP := recorded(SecKeyCivoMasterIP); masterIP != "" && !Contains(ips, masterIP) {
+			secretDrift(SecKeyCivoMasterIP, ips[0], "the load balancer of the ingress controller has another IP")
+		}
+		if domain := recorded(SecKeyClusterdomain); domain != "" {
+			if resolved, err := net.LookupHost(domain); err == nil && !anyAddress(resolved, ips) && !anyLoopback(resolved) {
+				drifts = append(drifts, ConfigDrift{
+					Key: SecKeyClusterdomain, Recorded: strings.Join(resolved, ", "), Actual: strings.Join(ips, ", "),
+					Message: fmt.Sprintf("%s does not resolve to the ingress load balancer, update its DNS record", domain),
+				})
+			}
+		}
+	}
+
+	return drifts, nil
+}
+
+// issuerDrifts checks that the cluster issuer of SSL exists and solves its challenges with an existing ingress class
+func issuerDrifts(ctx context.Context, dynamicClient dynamic.Interface, restConfig *rest.Config, issuerName string, classes []string, defaultClass string) []ConfigDrift {
+	if issuerName == "" {
+		return nil
+	}
+	issuer, err := dynamicClient.Resource(clusterIssuerGVR).Get(ctx, issuerName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		drift := ConfigDrift{Key: SecKeySslissuer, Recorded: issuerName, Actual: "not found", Message: "the ClusterIssuer of SSL does not exist"}
+		// Only the letsencrypt issuer can be recreated, the mkcert issuer of k3d needs the local CA
+		if issuerName == DefaultSSLIssuer && defaultClass != "" {
+			drift.Repair = fmt.Sprintf("create ClusterIssuer %s for ingress class %s", issuerName, defaultClass)
+			drift.repairCluster = func(ctx context.Context) error {
+				return CreateClusterIssuer(restConfig, true, defaultClass)
+			}
+		}
+		return []ConfigDrift{drift}
+	}
+	if err != nil {
+		return nil
+	}
+
+	solvers, _, _ := unstructured.NestedSlice(issuer.Object, "spec", "acme", "solvers")
+	for _, solver := range solvers {
+		class := solverIngressClass(solver)
+		if class == "" || len(classes) == 0 || Contains(classes, class) {
+			continue
+		}
+		drift := ConfigDrift{
+			Key: SecKeySslissuer, Recorded: class, Actual: strings.Join(classes, ", "),
+			Message: fmt.Sprintf("ClusterIssuer %s solves challenges with ingress class %s, which does not exist", issuerName, class),
+		}
+		if defaultClass != "" {
+			drift.Actual = defaultClass
+			drift.Repair = fmt.Sprintf("switch the solvers of ClusterIssuer %s to ingress class %s", issuerName, defaultClass)
+			drift.repairCluster = func(ctx context.Context) error {
+				return setIssuerIngressClass(ctx, dynamicClient, issuerName, defaultClass)
+			}
+		}
+		return []ConfigDrift{drift}
+	}
+	return nil
+}
+
+// solverIngressClass returns the ingress class of an http01 solver, set by class or ingressClassName
+func solverIngressClass(solver interface{}) string {
+	solverMap, ok := solver.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for _, field := range []string{"class", "ingressClassName"} {
+		if class, found, _ := unstructured.NestedString(solverMap, "http01", "ingress", field); found {
+			return class
+		}
+	}
+	return ""
+}
+
+// setIssuerIngressClass points the http01 solvers of a ClusterIssuer to another ingress class
+func setIssuerIngressClass(ctx context.Context, dynamicClient dynamic.Interface, issuerName, class string) error {
+	issuer, err := dynamicClient.Resource(clusterIssuerGVR).Get(ctx, issuerName, v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get ClusterIssuer %s: %w", issuerName, err)
+	}
+	solvers, _, _ := unstructured.NestedSlice(issuer.Object, "spec", "acme", "solvers")
+	for i, solver := range solvers {
+		solverMap, ok := solver.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"class", "ingressClassName"} {
+			if _, found, _ := unstructured.NestedString(solverMap, "http01", "ingress", field); found {
+				if err := unstructured.SetNestedField(solverMap, class, "http01", "ingress", field); err != nil {
+					return err
+				}
+			}
+		}
+		solvers[i] = solverMap
+	}
+	if err := unstructured.SetNestedSlice(issuer.Object, solvers, "spec", "acme", "solvers"); err != nil {
+		return err
+	}
+	if _, err := dynamicClient.Resource(clusterIssuerGVR).Update(ctx, issuer, v1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update ClusterIssuer %s: %w", issuerName, err)
+	}
+	return nil
+}
+
+// RepairGrsfConfig fixes the repairable drifts: the secret values are written to grsf-config in a single
+// update, the others change the cluster
+func RepairGrsfConfig(kubeClient apiv1.Interface, drifts []ConfigDrift) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	values := map[string]string{}
+	for _, drift := range drifts {
+		switch {
+		case drift.secretValue != nil:
+			values[drift.Key] = *drift.secretValue
+		case drift.repairCluster != nil:
+			if err := drift.repairCluster(ctx); err != nil {
+				return err
+			}
+			SuccessMessage(fmt.Sprintf("Repaired %s: %s", drift.Key, drift.Repair))
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	secret, err := kubeClient.CoreV1().Secrets("grpl-system").Get(ctx, "grsf-config", v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get grsf-config: %w", err)
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for key, value := range values {
+		secret.Data[key] = []byte(value)
+	}
+	if _, err := kubeClient.CoreV1().Secrets("grpl-system").Update(ctx, secret, v1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update grsf-config: %w", err)
+	}
+	for _, drift := range drifts {
+		if drift.secretValue != nil {
+			SuccessMessage(fmt.Sprintf("Repaired %s: %s", drift.Key, drift.Repair))
+		}
+	}
+	return nil
+}
+
+// nodeProvider returns the provider of the cluster from the provider IDs of its nodes, empty if unknown
+func nodeProvider(ctx context.Context, kubeClient apiv1.Interface) string {
+	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, v1.ListOptions{Limit: 1})
+	if err != nil || len(nodes.Items) == 0 {
+		return ""
+	}
+	providerID := nodes.Items[0].Spec.ProviderID
+	switch {
+	case strings.HasPrefix(providerID, "civo://"):
+		return ProviderClusterTypeCivo
+	case strings.HasPrefix(providerID, "k3s://") && strings.HasPrefix(nodes.Items[0].Name, "k3d-"):
+		return ProviderClusterTypeK3d
+	case strings.HasPrefix(providerID, "gce://"):
+		return ProviderClusterTypeGke
+	case strings.HasPrefix(providerID, "digitalocean://"):
+		return ProviderClusterTypeDoks
+	}
+	return ""
+}
+
+// ingressClassNames returns the IngressClasses of the cluster
+func ingressClassNames(ctx context.Context, kubeClient apiv1.Interface) []string {
+	list, err := kubeClient.NetworkingV1().IngressClasses().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, class := range list.Items {
+		names = append(names, class.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ingressLoadBalancerIPs returns the external addresses of the LoadBalancer services of the ingress controllers,
+// matched by name or namespace the way GetClusterExternalIP matches them
+func ingressLoadBalancerIPs(ctx context.Context, kubeClient apiv1.Interface, classes []string) []string {
+	services, err := kubeClient.CoreV1().Services("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	var ips []string
+	for _, svc := range services.Items {
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+		for _, class := range classes {
+			if !strings.Contains(svc.Name, class) && !strings.Contains(svc.Namespace, class) {
+				continue
+			}
+			for _, ingress := range svc.Status.LoadBalancer.Ingress {
+				if ingress.IP != "" {
+					ips = append(ips, ingress.IP)
+				} else if ingress.Hostname != "" {
+					ips = append(ips, ingress.Hostname)
+				}
+			}
+			break
+		}
+	}
+	return ips
+}
+
+// anyAddress reports whether a resolved address is one of the load balancer addresses, hostnames of load
+// balancers are resolved first
+func anyAddress(resolved, ips []string) bool {
+	for _, ip := range ips {
+		addresses := []string{ip}
+		if net.ParseIP(ip) == nil {
+			addresses, _ = net.LookupHost(ip)
+		}
+		for _, address := range addresses {
+			if Contains(resolved, address) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// anyLoopback reports local domains, e.g. the *.grpl-k3d.dev domains of k3d resolve to 127.0.0.1
+func anyLoopback(resolved []string) bool {
+	for _, address := range resolved {
+		if ip := net.ParseIP(address); ip != nil && ip.IsLoopback() {
+			return true
+		}
+	}
+	return false
+}
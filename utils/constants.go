@@ -40,8 +40,14 @@ const (
 // Default values
 const (
 	DefaultValue = ""
+
+	// DefaultGrappleVersion is installed when --grapple-version is "latest"
+	DefaultGrappleVersion = "0.3.5"
 )
 
+// GrplReleases are the helm releases of a Grapple installation, in install order
+var GrplReleases = []string{"grsf-init", "grsf", "grsf-config", "grsf-integration"}
+
 const (
 	SecKeyEmail               = "email"
 	SecKeyOrganization        = "organization"
@@ -61,9 +67,17 @@ const (
 	SecKeyCivoRegion          = "CIVO_REGION"
 	SecKeyCivoMasterIP        = "CIVO_MASTER_IP"
 	SecKeyImagePullSecret     = "IMAGE_PULL_SECRET"
+	SecKeyGkeProject          = "GKE_PROJECT"
+	SecKeyGkeLocation         = "GKE_LOCATION"
+	SecKeyDoksClusterID       = "DOKS_CLUSTER_ID"
+	SecKeyDoksRegion          = "DOKS_REGION"
+	SecKeyLicenseTier         = "LICENSE_TIER"
+	SecKeyLicenseExpires      = "LICENSE_EXPIRES"
 )
 
 const (
 	ProviderClusterTypeCivo = "CIVO"
 	ProviderClusterTypeK3d  = "K3D"
+	ProviderClusterTypeGke  = "GKE"
+	ProviderClusterTypeDoks = "DOKS"
 )
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// customDomainChallengePrefix is the TXT record proving the ownership of a custom domain
+const customDomainChallengePrefix = "_grpl-challenge"
+
+// VerifyCustomDomain guides through the DNS setup of a domain the user brings, instead of assuming a wildcard
+// record exists: it prints the records to create, waits for a TXT challenge proving ownership of the domain,
+// then for *.<domain> to route to the ingress of this cluster, checked through the code verification server.
+func VerifyCustomDomain(restConfig *rest.Config, domain, clusterIP string, timeout time.Duration) error {
+	code, err := customDomainCode(domain)
+	if err != nil {
+		return err
+	}
+	challenge := fmt.Sprintf("%s.%s", customDomainChallengePrefix, domain)
+
+	InfoMessage(fmt.Sprintf("%s is your own domain, create these DNS records at its DNS provider:", domain))
+	InfoMessage(fmt.Sprintf("  %-40s TXT  %s", challenge, code))
+	InfoMessage(fmt.Sprintf("  %-40s A    %s", "*."+domain, clusterIP))
+	if nameservers := lookupNameservers(domain); len(nameservers) > 0 {
+		InfoMessage(fmt.Sprintf("The zone is served by %s, if %s is delegated to another zone, create the records there", strings.Join(nameservers, ", "), domain))
+	}
+	InfoMessage(fmt.Sprintf("Waiting up to %s for the records (rerunning the install waits for the same record)", FormatDuration(timeout)))
+
+	deadline := time.Now().Add(timeout)
+	poller := NewPoller()
+	progress := StartWaitProgress(fmt.Sprintf("TXT record %s", challenge), timeout)
+	for !hasTXTRecord(challenge, code) {
+		if time.Now().After(deadline) {
+			progress.Stop()
+			return fmt.Errorf("%w: TXT record %s with value %s not found within %s", ErrTimeout, challenge, code, FormatDuration(timeout))
+		}
+		if err := poller.Wait(); err != nil {
+			progress.Stop()
+			return err
+		}
+	}
+	progress.Done(fmt.Sprintf("Ownership of %s verified", domain))
+
+	// The verification server answers on verification-server.<domain>, reaching it proves the wildcard record
+	// points to the ingress of this cluster and not somewhere else
+	if err := SetupCodeVerificationServer(restConfig, code, domain, "civo"); err != nil {
+		return fmt.Errorf("failed to setup code verification server: %w", err)
+	}
+	host := "verification-server." + domain
+	progress = StartWaitProgress(fmt.Sprintf("*.%s to route to this cluster", domain), time.Until(deadline))
+	defer progress.Stop()
+	var lastErr error
+	poller = NewPoller()
+	for time.Now().Before(deadline) {
+		if lastErr = checkRoutesToCluster(host, clusterIP); lastErr == nil {
+			progress.Done(fmt.Sprintf("*.%s routes to this cluster", domain))
+			return nil
+		}
+		if err := poller.Wait(); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: *.%s does not route to this cluster (%w), check that the wildcard record points to %s", ErrTimeout, domain, lastErr, clusterIP)
+}
+
+// customDomainCode returns the ownership code of a domain, it is generated once and kept in
+// ~/.config/grpl/domain-challenges so that a rerun of the install waits for the record created before
+func customDomainCode(domain string) (string, error) {
+	configPath, err := ConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(filepath.Dir(configPath), "domain-challenges", domain)
+	if data, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(data)) != "" {
+		return strings.TrimSpace(string(data)), nil
+	}
+	code := GenerateRandomString()[:16]
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(code+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to save the ownership code of %s: %w", domain, err)
+	}
+	return code, nil
+}
+
+// lookupNameservers returns the name servers of the domain, or of its closest parent zone
+func lookupNameservers(domain string) []string {
+	for name := domain; strings.Contains(name, "."); name = name[strings.Index(name, ".")+1:] {
+		records, err := net.LookupNS(name)
+		if err != nil || len(records) == 0 {
+			continue
+		}
+		var nameservers []string
+		for _, ns := range records {
+			nameservers = append(nameservers, strings.TrimSuffix(ns.Host, "."))
+		}
+		return nameservers
+	}
+	return nil
+}
+
+func hasTXTRecord(name, value string) bool {
+	records, err := net.LookupTXT(name)
+	if err != nil {
+		return false
+	}
+	for _, record := range records {
+		if strings.TrimSpace(record) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRoutesToCluster checks that host resolves to the cluster IP and is served by the verification server
+func checkRoutesToCluster(host, clusterIP string) error {
+	addresses, err := net.LookupHost(host)
+	if err != nil {
+		return fmt.Errorf("%s does not resolve", host)
+	}
+	if clusterIP != "" && !Contains(addresses, clusterIP) {
+		return fmt.Errorf("%s resolves to %s instead of %s", host, strings.Join(addresses, ", "), clusterIP)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get("http://" + host)
+	if err != nil {
+		return fmt.Errorf("%s is not reachable", host)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s answered with %s", host, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const dbCheckTimeout = 3 * time.Minute
+
+// MySQLConnection are the connection settings of an external MySQL datasource
+type MySQLConnection struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Database string
+	// RequireDatabase fails the check if the database does not exist yet, templates with an init container create it
+	RequireDatabase bool
+}
+
+// VerifyMySQLConnection checks that the datasource accepts the credentials before anything is deployed with them.
+// The CLI has no MySQL client, so the login is tried from a short lived pod in the target namespace, which also
+// covers databases that are only reachable from inside the cluster.
+func VerifyMySQLConnection(clientset kubernetes.Interface, namespace string, conn MySQLConnection) error {
+	address := net.JoinHostPort(conn.Host, conn.Port)
+	if c, err := net.DialTimeout("tcp", address, 5*time.Second); err == nil {
+		c.Close()
+		InfoMessage(fmt.Sprintf("%s is reachable, checking the credentials from inside the cluster...", address))
+	} else {
+		InfoMessage(fmt.Sprintf("%s is not reachable from this machine, checking from inside the cluster...", address))
+	}
+
+	script := `mysql --connect-timeout=10 -h "$DB_HOST" -P "$DB_PORT" -u "$DB_USER" -e "SELECT 1" >/dev/null || exit 1`
+	if conn.RequireDatabase {
+		script += "; mysql --connect-timeout=10 -h \"$DB_HOST\" -P \"$DB_PORT\" -u \"$DB_USER\" -e \"USE \\`$DB_NAME\\`\" >/dev/null || exit 1"
+	}
+
+	logs, succeeded, err := runMySQLPod(clientset, namespace, conn, script)
+	if err != nil {
+		return err
+	}
+	if succeeded {
+		return nil
+	}
+	if logs == "" {
+		return fmt.Errorf("failed to connect to %s as %s", address, conn.User)
+	}
+	return fmt.Errorf("failed to connect to %s as %s: %s", address, conn.User, logs)
+}
+
+// runMySQLPod runs script in a short lived mysql pod of namespace with the connection settings of conn in its
+// environment (DB_HOST, DB_PORT, DB_USER, MYSQL_PWD and DB_NAME) and returns its trimmed logs and whether the
+// script succeeded
+func runMySQLPod(clientset kubernetes.Interface, namespace string, conn MySQLConnection, script string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbCheckTimeout)
+	defer cancel()
+
+	name, cleanup, err := createMySQLPod(ctx, clientset, namespace, conn, script)
+	if err != nil {
+		return "", false, err
+	}
+	defer cleanup()
+
+	var phase corev1.PodPhase
+	err = wait.PollUntilContextCancel(ctx, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		phase = pod.Status.Phase
+		return phase == corev1.PodSucceeded || phase == corev1.PodFailed, nil
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("%w: db check pod did not finish within %s", ErrTimeout, FormatDuration(dbCheckTimeout))
+	}
+	logs, err := clientset.CoreV1().Pods(namespace).GetLogs(name, &corev1.PodLogOptions{}).Do(context.TODO()).Raw()
+	if err != nil {
+		DebugMessage(fmt.Sprintf("Failed to read the logs of db check pod %s: %v", name, err))
+	}
+	return strings.TrimSpace(string(logs)), phase == corev1.PodSucceeded, nil
+}
+
+// createMySQLPod creates the mysql pod running script and the secret with the connection settings of its
+// environment, cleanup deletes both
+func createMySQLPod(ctx context.Context, clientset kubernetes.Interface, namespace string, conn MySQLConnection, script string) (string, func(), error) {
+	name := "grpl-db-check-" + GenerateRandomString()[:8]
+
+	// The credentials are passed through a secret, so they don't show up in the pod spec
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace, Labels: HelperLabels(helperDBCheck)},
+		StringData: map[string]string{
+			"DB_HOST":   conn.Host,
+			"DB_PORT":   conn.Port,
+			"DB_USER":   conn.User,
+			"MYSQL_PWD": conn.Password,
+			"DB_NAME":   conn.Database,
+		},
+	}
+	ApplyCommonMetadata(&secret.ObjectMeta)
+	if _, err := clientset.CoreV1().Secrets(namespace).Create(ctx, secret, v1.CreateOptions{}); err != nil {
+		return "", nil, fmt.Errorf("failed to create db check secret: %w", err)
+	}
+	deleteSecret := func() { clientset.CoreV1().Secrets(namespace).Delete(context.TODO(), name, v1.DeleteOptions{}) }
+
+	podSecurityContext, containerSecurityContext := HelperSecurityContexts(clientset, namespace)
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace, Labels: HelperLabels(helperDBCheck)},
+		Spec: corev1.PodSpec{
+			RestartPolicy:   corev1.RestartPolicyNever,
+			SecurityContext: podSecurityContext,
+			Containers: []corev1.Container{
+				{
+					Name:            "db-check",
+					Image:           MirrorImage("mysql"),
+					Command:         []string{"bash", "-c", script},
+					SecurityContext: containerSecurityContext,
+					Resources:       HelperPodResources(),
+					EnvFrom: []corev1.EnvFromSource{
+						{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}}},
+					},
+				},
+			},
+		},
+	}
+	ApplyCommonMetadata(&pod.ObjectMeta)
+	if _, err := clientset.CoreV1().Pods(namespace).Create(ctx, pod, v1.CreateOptions{}); err != nil {
+		deleteSecret()
+		return "", nil, fmt.Errorf("failed to create db check pod: %w", ExplainPodAdmissionError(clientset, namespace, name, err))
+	}
+	return name, func() {
+		clientset.CoreV1().Pods(namespace).Delete(context.TODO(), name, v1.DeleteOptions{})
+		deleteSecret()
+	}, nil
+}
+
+// MySQLTable is a table of a MySQL database with its columns in their order
+type MySQLTable struct {
+	Name    string
+	Columns []MySQLColumn
+}
+
+// MySQLColumn is a column of a MySQL table as described by information_schema
+type MySQLColumn struct {
+	Name string
+	// Type is the full column type, e.g. int unsigned, tinyint(1) or varchar(255)
+	Type          string
+	Nullable      bool
+	PrimaryKey    bool
+	AutoIncrement bool
+	HasDefault    bool
+	// References is the table a foreign key of the column points to
+	References string
+}
+
+// mysqlSchemaQuery lists the columns and the foreign keys of the current database, each row tagged with its kind
+const mysqlSchemaQuery = "SELECT 'column', table_name, column_name, column_type, is_nullable, column_key, extra, column_default IS NOT NULL " +
+	"FROM information_schema.columns WHERE table_schema = DATABASE() ORDER BY table_name, ordinal_position; " +
+	"SELECT 'fk', table_name, column_name, referenced_table_name FROM information_schema.key_column_usage " +
+	"WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL"
+
+// DescribeMySQLSchema returns the tables of the database of conn, read from information_schema by a short lived
+// pod in namespace like VerifyMySQLConnection
+func DescribeMySQLSchema(clientset kubernetes.Interface, namespace string, conn MySQLConnection) ([]MySQLTable, error) {
+	script := fmt.Sprintf(`mysql --connect-timeout=10 -h "$DB_HOST" -P "$DB_PORT" -u "$DB_USER" -B -N -e "%s" "$DB_NAME"`, mysqlSchemaQuery)
+	logs, succeeded, err := runMySQLPod(clientset, namespace, conn, script)
+	if err != nil {
+		return nil, err
+	}
+	address := net.JoinHostPort(conn.Host, conn.Port)
+	if !succeeded {
+		return nil, fmt.Errorf("failed to read the schema of %s from %s: %s", conn.Database, address, logs)
+	}
+	return parseMySQLSchema(logs), nil
+}
+
+// parseMySQLSchema reads the tab separated rows of mysqlSchemaQuery, other lines (warnings) are skipped
+func parseMySQLSchema(output string) []MySQLTable {
+	var tables []MySQLTable
+	index := map[string]int{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		switch {
+		case fields[0] == "column" && len(fields) == 8:
+			i, ok := index[fields[1]]
+			if !ok {
+				i = len(tables)
+				index[fields[1]] = i
+				tables = append(tables, MySQLTable{Name: fields[1]})
+			}
+			tables[i].Columns = append(tables[i].Columns, MySQLColumn{
+				Name:          fields[2],
+				Type:          fields[3],
+				Nullable:      fields[4] == "YES",
+				PrimaryKey:    fields[5] == "PRI",
+				AutoIncrement: strings.Contains(fields[6], "auto_increment"),
+				HasDefault:    fields[7] == "1",
+			})
+		case fields[0] == "fk" && len(fields) == 4:
+			i, ok := index[fields[1]]
+			if !ok {
+				continue
+			}
+			for c := range tables[i].Columns {
+				if tables[i].Columns[c].Name == fields[2] {
+					tables[i].Columns[c].References = fields[3]
+				}
+			}
+		}
+	}
+	return tables
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// DumpMySQL writes a SQL dump of the database of conn to w. Like VerifyMySQLConnection it runs from a short
+// lived mysql pod in namespace, the dump is streamed from it.
+func DumpMySQL(restConfig *rest.Config, clientset kubernetes.Interface, namespace string, conn MySQLConnection, w io.Writer) error {
+	script := `mysqldump --single-transaction --routines --triggers --no-tablespaces -h "$DB_HOST" -P "$DB_PORT" -u "$DB_USER" "$DB_NAME"`
+	return execInMySQLPod(restConfig, clientset, namespace, conn, script, nil, w)
+}
+
+// RestoreMySQL loads a SQL dump read from r into the database of conn, the database is created if it doesn't exist
+func RestoreMySQL(restConfig *rest.Config, clientset kubernetes.Interface, namespace string, conn MySQLConnection, r io.Reader) error {
+	script := "mysql -h \"$DB_HOST\" -P \"$DB_PORT\" -u \"$DB_USER\" -e \"CREATE DATABASE IF NOT EXISTS \\`$DB_NAME\\`\" && " +
+		"mysql -h \"$DB_HOST\" -P \"$DB_PORT\" -u \"$DB_USER\" \"$DB_NAME\""
+	return execInMySQLPod(restConfig, clientset, namespace, conn, script, r, io.Discard)
+}
+
+// execInMySQLPod starts an idle mysql pod and runs script in it with stdin and stdout attached. Unlike the db
+// check, there is no time limit once the pod runs, dumps of large databases take a while.
+func execInMySQLPod(restConfig *rest.Config, clientset kubernetes.Interface, namespace string, conn MySQLConnection, script string, stdin io.Reader, stdout io.Writer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbCheckTimeout)
+	defer cancel()
+
+	name, cleanup, err := createMySQLPod(ctx, clientset, namespace, conn, "sleep 86400")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	err = wait.PollUntilContextCancel(ctx, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		if pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded {
+			return false, fmt.Errorf("db pod %s stopped", name)
+		}
+		return pod.Status.Phase == corev1.PodRunning, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: db pod did not start within %s: %w", ErrTimeout, FormatDuration(dbCheckTimeout), err)
+	}
+
+	req := clientset.CoreV1().RESTClient().Post().Resource("pods").Namespace(namespace).Name(name).SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: "db-check",
+			Command:   []string{"bash", "-c", script},
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to exec in db pod %s: %w", name, err)
+	}
+
+	var stderr bytes.Buffer
+	err = executor.StreamWithContext(context.Background(), remotecommand.StreamOptions{Stdin: stdin, Stdout: stdout, Stderr: &stderr})
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
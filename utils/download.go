@@ -0,0 +1,396 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultDownloadParallelism caps the concurrent downloads unless download-parallelism is set
+	DefaultDownloadParallelism = 4
+	downloadRetries            = 3
+	// staleDownloadLock is the age after which the lock of a download is assumed to be left by a killed process
+	staleDownloadLock = 10 * time.Minute
+)
+
+// downloadProgressInterval is how often running downloads report their progress
+var downloadProgressInterval = 5 * time.Second
+
+// downloadLocks serializes the downloads of the same file within the CLI, the lock file across processes
+var downloadLocks sync.Map
+
+// Download is a file fetched by the Downloader
+type Download struct {
+	URL  string
+	Path string
+	// Name is shown in the progress lines, the file name of Path by default
+	Name string
+	// SHA256 is the expected checksum of the content, verified before the file is moved into place
+	SHA256 string
+	// Verify checks the downloaded file before it is moved into place, e.g. that an archive can be opened
+	Verify func(path string) error
+	// Mode of the file, 0644 by default
+	Mode os.FileMode
+}
+
+// Downloader fetches artifacts (chart archives, CRD bundles, binaries) over HTTP. Interrupted downloads are kept
+// as <path>.part and resumed with a range request conditional on the validator (ETag or Last-Modified) of the
+// first response, so that a file changed on the server, e.g. a "latest" URL moved to another release, is
+// downloaded again instead of being spliced. Failed requests are retried, at most Parallelism downloads run at
+// once and the file only appears at its path once it is complete and verified.
+type Downloader struct {
+	Client      *http.Client
+	Parallelism int
+	Retries     int
+}
+
+// NewDownloader returns a downloader with the parallelism of the download-parallelism setting
+func NewDownloader() *Downloader {
+	parallelism := DefaultDownloadParallelism
+	if value, err := strconv.Atoi(ConfigValue("download-parallelism")); err == nil && value > 0 {
+		parallelism = value
+	}
+	return &Downloader{
+		// No overall timeout, large artifacts take long on slow links; stalled connections are retried
+		Client:      &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, ResponseHeaderTimeout: 30 * time.Second}},
+		Parallelism: parallelism,
+		Retries:     downloadRetries,
+	}
+}
+
+// Run runs the tasks with at most Parallelism of them at once and returns the first error, the remaining tasks
+// are skipped after a failure
+func (d *Downloader) Run(tasks []func() error) error {
+	limit := d.Parallelism
+	if limit < 1 {
+		limit = 1
+	}
+	slots := make(chan struct{}, limit)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		failed   atomic.Bool
+	)
+	for _, task := range tasks {
+		if failed.Load() {
+			break
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(task func() error) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if failed.Load() {
+				return
+			}
+			if err := task(); err != nil {
+				once.Do(func() { firstErr = err })
+				failed.Store(true)
+			}
+		}(task)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// FetchAll downloads the files in parallel
+func (d *Downloader) FetchAll(downloads []Download) error {
+	tasks := make([]func() error, len(downloads))
+	for i := range downloads {
+		download := downloads[i]
+		tasks[i] = func() error {
+			_, err := d.Fetch(download)
+			return err
+		}
+	}
+	return d.Run(tasks)
+}
+
+// Fetch downloads a file and returns the sha256 of its content
+func (d *Downloader) Fetch(download Download) (string, error) {
+	if download.Name == "" {
+		download.Name = filepath.Base(download.Path)
+	}
+	if download.Mode == 0 {
+		download.Mode = 0644
+	}
+	if err := os.MkdirAll(filepath.Dir(download.Path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(download.Path), err)
+	}
+
+	mu, _ := downloadLocks.LoadOrStore(download.Path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+	unlock, err := lockDownload(download.Path)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	part := download.Path + ".part"
+	var lastErr error
+	for attempt := 0; attempt <= d.Retries; attempt++ {
+		if attempt > 0 {
+			DebugMessage(fmt.Sprintf("Retrying the download of %s (%d/%d): %v", download.Name, attempt, d.Retries, lastErr))
+			if err := Sleep(time.Duration(1<<(attempt-1)) * 2 * time.Second); err != nil {
+				return "", err
+			}
+		}
+		sum, err := d.fetchPart(download, part)
+		if err == nil {
+			return d.finish(download, part, sum)
+		}
+		lastErr = err
+		var permanent *permanentDownloadError
+		if errors.As(err, &permanent) || CommandContext().Err() != nil {
+			break
+		}
+	}
+	if err := Canceled(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("failed to download %s: %w", download.URL, lastErr)
+}
+
+// permanentDownloadError is a failure retrying won't fix, e.g. a 404
+type permanentDownloadError struct {
+	status string
+}
+
+func (e *permanentDownloadError) Error() string {
+	return "unexpected status " + e.status
+}
+
+// fetchPart downloads the missing content of the .part file, resuming it when the server supports ranges,
+// and returns the sha256 of the complete content
+func (d *Downloader) fetchPart(download Download, part string) (string, error) {
+	offset := int64(0)
+	validatorFile := part + ".validator"
+	validator := ""
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+	if data, err := os.ReadFile(validatorFile); err == nil {
+		validator = strings.TrimSpace(string(data))
+	}
+	if offset > 0 && validator == "" {
+		// Without a validator a resumed range can't be told apart from a range of another file
+		offset = 0
+	}
+
+	req, err := http.NewRequestWithContext(CommandContext(), http.MethodGet, download.URL, nil)
+	if err != nil {
+		return "", &permanentDownloadError{status: err.Error()}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	hash := sha256.New()
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	total := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		// The hash covers the whole file, the part already on disk is hashed first
+		if err := hashFile(part, hash); err != nil {
+			return "", err
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+		if total >= 0 {
+			total += offset
+		}
+		DebugMessage(fmt.Sprintf("Resuming the download of %s at %s", download.Name, FormatBytes(offset)))
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The part is complete or doesn't match the file anymore, start over
+		os.Remove(part)
+		os.Remove(validatorFile)
+		return "", fmt.Errorf("range not satisfiable, restarting")
+	case resp.StatusCode == http.StatusOK:
+		// The server sends the whole file when it changed since the part was downloaded
+		if offset > 0 {
+			DebugMessage(fmt.Sprintf("%s changed on the server, restarting its download", download.Name))
+		}
+		offset = 0
+		os.Remove(validatorFile)
+		if validator := responseValidator(resp); validator != "" {
+			if err := os.WriteFile(validatorFile, []byte(validator), 0644); err != nil {
+				return "", fmt.Errorf("failed to write %s: %w", validatorFile, err)
+			}
+		}
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	default:
+		return "", &permanentDownloadError{status: resp.Status}
+	}
+
+	file, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", part, err)
+	}
+	defer file.Close()
+
+	progress := startDownloadProgress(download.Name, offset, total)
+	defer progress.stop()
+	if _, err := io.Copy(io.MultiWriter(file, hash, progress), resp.Body); err != nil {
+		return "", err
+	}
+	if total >= 0 && progress.received() != total {
+		return "", fmt.Errorf("connection closed after %s of %s", FormatBytes(progress.received()), FormatBytes(total))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// responseValidator returns what identifies the version of the file of a response for If-Range: its ETag if it is
+// a strong one, else its Last-Modified date
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// finish verifies the complete .part file and moves it into place
+func (d *Downloader) finish(download Download, part, sum string) (string, error) {
+	os.Remove(part + ".validator")
+	if download.SHA256 != "" && !strings.EqualFold(download.SHA256, sum) {
+		os.Remove(part)
+		return "", fmt.Errorf("checksum mismatch of %s: expected %s, got %s", download.Name, download.SHA256, sum)
+	}
+	if download.Verify != nil {
+		if err := download.Verify(part); err != nil {
+			os.Remove(part)
+			return "", fmt.Errorf("verification of %s failed: %w", download.Name, err)
+		}
+	}
+	if err := os.Chmod(part, download.Mode); err != nil {
+		return "", err
+	}
+	if err := os.Rename(part, download.Path); err != nil {
+		return "", fmt.Errorf("failed to move %s into place: %w", download.Name, err)
+	}
+	return sum, nil
+}
+
+// lockDownload takes the lock file of a download, another CLI process downloading the same file is waited for
+func lockDownload(path string) (func(), error) {
+	lock := path + ".lock"
+	for {
+		file, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > staleDownloadLock {
+			os.Remove(lock)
+			continue
+		}
+		DebugMessage(fmt.Sprintf("Waiting for another download of %s", filepath.Base(path)))
+		if err := Sleep(time.Second); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func hashFile(path string, hash hash.Hash) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(hash, file)
+	return err
+}
+
+// downloadProgress counts the received bytes of a download and periodically reports them as a progress bar
+type downloadProgress struct {
+	name  string
+	total int64
+	bytes atomic.Int64
+	start time.Time
+	done  chan struct{}
+	once  sync.Once
+}
+
+func startDownloadProgress(name string, offset, total int64) *downloadProgress {
+	p := &downloadProgress{name: name, total: total, start: time.Now(), done: make(chan struct{})}
+	p.bytes.Store(offset)
+	go func() {
+		ticker := time.NewTicker(downloadProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				logger.write(slog.LevelInfo, ColorYellow, p.String(), true)
+			case <-p.done:
+				return
+			}
+		}
+	}()
+	return p
+}
+
+func (p *downloadProgress) Write(data []byte) (int, error) {
+	p.bytes.Add(int64(len(data)))
+	return len(data), nil
+}
+
+func (p *downloadProgress) received() int64 {
+	return p.bytes.Load()
+}
+
+// String returns the progress line, e.g. "kubeblocks_crds.yaml [#######-------------] 35% 4.2 MB / 12.0 MB"
+func (p *downloadProgress) String() string {
+	received := p.received()
+	if p.total <= 0 {
+		return fmt.Sprintf("%s %s (elapsed %s)", p.name, FormatBytes(received), FormatDuration(time.Since(p.start)))
+	}
+	const width = 20
+	filled := int(received * width / p.total)
+	if filled > width {
+		filled = width
+	}
+	return fmt.Sprintf("%s [%s%s] %d%% %s / %s", p.name, strings.Repeat("#", filled), strings.Repeat("-", width-filled),
+		received*100/p.total, FormatBytes(received), FormatBytes(p.total))
+}
+
+func (p *downloadProgress) stop() {
+	p.once.Do(func() { close(p.done) })
+}
+
+// FormatBytes formats a size for humans, e.g. "4.2 MB"
+func FormatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
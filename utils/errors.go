@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
+	"gopkg.in/yaml.v2"
+)
+
+// Sentinel errors classify failures so callers can handle them with errors.Is instead of matching messages,
+// functions wrap them with fmt.Errorf("%w: ...: %w", ErrX, err) to keep the details and the cause
+var (
+	// ErrClusterUnreachable means the kubeconfig is missing or the cluster doesn't answer
+	ErrClusterUnreachable = errors.New("cluster unreachable")
+	// ErrTimeout means something was not ready in time
+	ErrTimeout = errors.New("timeout")
+	// ErrChartNotFound means a chart could not be located, neither in the cache nor in its registry
+	ErrChartNotFound = errors.New("chart not found")
+	// ErrUserAborted means the user declined a confirmation or interrupted a prompt
+	ErrUserAborted = errors.New("aborted by user")
+	// ErrValidation means an input (flag, file, prompt answer) is invalid
+	ErrValidation = errors.New("validation failed")
+	// ErrCanceled means the command was interrupted with Ctrl+C or SIGTERM
+	ErrCanceled = errors.New("canceled")
+)
+
+// Exit codes of the CLI, anything not classified exits with 1
+const (
+	ExitCodeError              = 1
+	ExitCodeValidation         = 2
+	ExitCodeUserAborted        = 3
+	ExitCodeClusterUnreachable = 4
+	ExitCodeTimeout            = 5
+	ExitCodeChartNotFound      = 6
+	// ExitCodeCanceled follows the shell convention for SIGINT (128+2)
+	ExitCodeCanceled = 130
+)
+
+// errorKinds maps the sentinel errors to their exit code and the kind reported in structured output
+var errorKinds = []struct {
+	err  error
+	kind string
+	code int
+}{
+	{ErrValidation, "validation", ExitCodeValidation},
+	{prompt.ErrNotInteractive, "validation", ExitCodeValidation},
+	{ErrUserAborted, "user-aborted", ExitCodeUserAborted},
+	{prompt.ErrAborted, "user-aborted", ExitCodeUserAborted},
+	{ErrClusterUnreachable, "cluster-unreachable", ExitCodeClusterUnreachable},
+	{ErrTimeout, "timeout", ExitCodeTimeout},
+	{ErrChartNotFound, "chart-not-found", ExitCodeChartNotFound},
+	{ErrCanceled, "canceled", ExitCodeCanceled},
+}
+
+// CommandError is the error printed with -o json or -o yaml
+type CommandError struct {
+	Error    string `json:"error" yaml:"error"`
+	Kind     string `json:"kind" yaml:"kind"`
+	ExitCode int    `json:"exitCode" yaml:"exitCode"`
+}
+
+// ClassifyError returns the kind and exit code of an error, "error" and 1 when it wraps no sentinel error
+func ClassifyError(err error) (string, int) {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.err) {
+			return k.kind, k.code
+		}
+	}
+	return "error", ExitCodeError
+}
+
+// ReportError prints the error of a command, as a CommandError with -o json or -o yaml, and returns the exit code
+func ReportError(err error) int {
+	kind, code := ClassifyError(err)
+	result := CommandError{Error: err.Error(), Kind: kind, ExitCode: code}
+	switch OutputFormat {
+	case OutputJSON:
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Fprintln(os.Stdout, string(data))
+	case OutputYAML:
+		data, _ := yaml.Marshal(result)
+		fmt.Fprint(os.Stdout, string(data))
+	default:
+		fmt.Println(err)
+	}
+	return code
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
+)
+
+func TestClassifyError(t *testing.T) {
+	cause := errors.New("connection refused")
+	tests := []struct {
+		name     string
+		err      error
+		wantKind string
+		wantCode int
+	}{
+		{name: "unclassified", err: errors.New("boom"), wantKind: "error", wantCode: ExitCodeError},
+		{name: "validation", err: fmt.Errorf("%w: --nodes must be positive", ErrValidation), wantKind: "validation", wantCode: ExitCodeValidation},
+		{name: "not interactive", err: fmt.Errorf("cluster name: %w", prompt.ErrNotInteractive), wantKind: "validation", wantCode: ExitCodeValidation},
+		{name: "user aborted", err: fmt.Errorf("upgrade: %w", ErrUserAborted), wantKind: "user-aborted", wantCode: ExitCodeUserAborted},
+		{name: "prompt aborted", err: prompt.ErrAborted, wantKind: "user-aborted", wantCode: ExitCodeUserAborted},
+		{name: "cluster unreachable", err: fmt.Errorf("%w: failed to connect: %w", ErrClusterUnreachable, cause), wantKind: "cluster-unreachable", wantCode: ExitCodeClusterUnreachable},
+		{name: "timeout", err: fmt.Errorf("waiting for grsf: %w", fmt.Errorf("%w: not ready within 5m", ErrTimeout)), wantKind: "timeout", wantCode: ExitCodeTimeout},
+		{name: "chart not found", err: fmt.Errorf("%w: grsf 0.3.5", ErrChartNotFound), wantKind: "chart-not-found", wantCode: ExitCodeChartNotFound},
+		{name: "canceled", err: fmt.Errorf("install: %w", ErrCanceled), wantKind: "canceled", wantCode: ExitCodeCanceled},
+		{name: "first sentinel wins", err: fmt.Errorf("%w: %w", ErrValidation, ErrTimeout), wantKind: "validation", wantCode: ExitCodeValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, code := ClassifyError(tt.err)
+			if kind != tt.wantKind || code != tt.wantCode {
+				t.Errorf("ClassifyError() = %s, %d, want %s, %d", kind, code, tt.wantKind, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestClassifyErrorKeepsCause(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("%w: failed to connect: %w", ErrClusterUnreachable, cause)
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(%v, cause) = false, want the cause to stay wrapped", err)
+	}
+	if _, code := ClassifyError(err); code != ExitCodeClusterUnreachable {
+		t.Errorf("ClassifyError() code = %d, want %d", code, ExitCodeClusterUnreachable)
+	}
+}
+
+func TestReportErrorJSON(t *testing.T) {
+	oldFormat, oldStdout := OutputFormat, os.Stdout
+	t.Cleanup(func() { OutputFormat, os.Stdout = oldFormat, oldStdout })
+	OutputFormat = OutputJSON
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = writer
+
+	code := ReportError(fmt.Errorf("%w: not ready within 5m", ErrTimeout))
+	writer.Close()
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != ExitCodeTimeout {
+		t.Errorf("ReportError() = %d, want %d", code, ExitCodeTimeout)
+	}
+	var result CommandError
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("ReportError() printed %q, not JSON: %v", output, err)
+	}
+	want := CommandError{Error: "timeout: not ready within 5m", Kind: "timeout", ExitCode: ExitCodeTimeout}
+	if result != want {
+		t.Errorf("ReportError() printed %+v, want %+v", result, want)
+	}
+}
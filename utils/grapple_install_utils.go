@@ -2,13 +2,11 @@ package utils
 
 import (
 	"context"
-	"encoding/json"
+	"encoding/base64"
 	"fmt"
 	"io"
-	"log"
-	"net/http"
 	"os"
-	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,7 +16,6 @@ import (
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/getter"
-	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/repo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -63,12 +60,9 @@ func helmInstallOrUpgradeGrpl(kubeClient apiv1.Interface, releaseName, namespace
 		return fmt.Errorf("failed to check or create namespace: %v", err)
 	}
 
-	// Mirrors the Bash variables
-	awsRegistry := "p7h7z5g3"
-
 	// Construct the OCI chart reference without version in URL
-	// Example: "oci://public.ecr.aws/p7h7z5g3/grsf-init"
-	chartRef := fmt.Sprintf("oci://public.ecr.aws/%s/%s", awsRegistry, releaseName)
+	// Example: "oci://public.ecr.aws/p7h7z5g3/grsf-init", or the same chart on --chart-registry
+	chartRef := GrplChartRef(releaseName)
 
 	InfoMessage(fmt.Sprintf("chartRef: %s", chartRef))
 
@@ -77,23 +71,16 @@ func helmInstallOrUpgradeGrpl(kubeClient apiv1.Interface, releaseName, namespace
 	settings.SetNamespace(namespace)
 	// Prepare an action.Configuration, which wires up Helm internals
 	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(
-		settings.RESTClientGetter(),
-		namespace,
-		os.Getenv("HELM_DRIVER"), // defaults to "secret" if empty
-		log.Printf,
-	); err != nil {
+	if err := InitHelmActionConfig(actionConfig, settings.RESTClientGetter(), namespace); err != nil {
 		return fmt.Errorf("failed to initialize Helm action configuration: %v", err)
 	}
 
 	// Create a registry client (for pulling OCI charts)
-	regClient, err := registry.NewClient()
+	regClient, err := NewChartRegistryClient()
 	if err != nil {
-		return fmt.Errorf("failed to create registry client: %v", err)
+		return err
 	}
 
-	_ = LogoutHelmRegistry(regClient)
-
 	actionConfig.RegistryClient = regClient
 
 	// Check if release exists
@@ -109,7 +96,7 @@ func helmInstallOrUpgradeGrpl(kubeClient apiv1.Interface, releaseName, namespace
 		installClient.ChartPathOptions.Version = chartVersion
 
 		// Locate the chart (pull it if needed) and get a local path
-		chartPath, err := installClient.ChartPathOptions.LocateChart(chartRef, settings)
+		chartPath, err := locateChart(&installClient.ChartPathOptions, releaseName, chartRef, chartVersion, settings)
 		if err != nil {
 			return fmt.Errorf("failed to locate chart from %q: %v", chartRef, err)
 		}
@@ -130,6 +117,10 @@ func helmInstallOrUpgradeGrpl(kubeClient apiv1.Interface, releaseName, namespace
 		if err != nil {
 			return fmt.Errorf("failed to merge values from %q: %v", valuesFiles, err)
 		}
+		AddCommonMetadataToValues(vals)
+		AddRegistryOverrideToValues(vals)
+		AddPriorityClassToValues(vals)
+		AddSecretValuesToValues(vals)
 
 		InfoMessage("Values from file:")
 		for key, value := range vals {
@@ -137,15 +128,19 @@ func helmInstallOrUpgradeGrpl(kubeClient apiv1.Interface, releaseName, namespace
 			case map[string]interface{}:
 				InfoMessage(fmt.Sprintf("%s:", key))
 				for subKey, subValue := range v {
-					InfoMessage(fmt.Sprintf("  %s: %v", subKey, subValue))
+					InfoMessage(fmt.Sprintf("  %s: %v", subKey, maskSecretValue(subValue, key, subKey)))
 				}
 			default:
-				InfoMessage(fmt.Sprintf("%s: %v", key, value))
+				InfoMessage(fmt.Sprintf("%s: %v", key, maskSecretValue(value, key)))
 			}
 		}
 
-		// Run the install
-		rel, err := installClient.Run(chartLoaded, vals)
+		// Run the install, a failed install leaves a release behind so it is recorded up front
+		recordInstallStep(installStepRelease, releaseName, namespace)
+		rel, err := installClient.RunWithContext(CommandContext(), chartLoaded, vals)
+		if cErr := Canceled(); cErr != nil {
+			return fmt.Errorf("install of chart %q aborted: %w", chartRef, cErr)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to install chart %q: %v", chartRef, err)
 		}
@@ -160,7 +155,7 @@ func helmInstallOrUpgradeGrpl(kubeClient apiv1.Interface, releaseName, namespace
 		upgradeClient.ChartPathOptions.Version = chartVersion
 
 		// Locate the chart (pull it if needed) and get a local path
-		chartPath, err := upgradeClient.ChartPathOptions.LocateChart(chartRef, settings)
+		chartPath, err := locateChart(&upgradeClient.ChartPathOptions, releaseName, chartRef, chartVersion, settings)
 		if err != nil {
 			return fmt.Errorf("failed to locate chart from %q: %v", chartRef, err)
 		}
@@ -179,6 +174,10 @@ func helmInstallOrUpgradeGrpl(kubeClient apiv1.Interface, releaseName, namespace
 		if err != nil {
 			return fmt.Errorf("failed to merge values from %q: %v", valuesFiles, err)
 		}
+		AddCommonMetadataToValues(vals)
+		AddRegistryOverrideToValues(vals)
+		AddPriorityClassToValues(vals)
+		AddSecretValuesToValues(vals)
 
 		InfoMessage("Values from file:")
 		for key, value := range vals {
@@ -186,14 +185,17 @@ func helmInstallOrUpgradeGrpl(kubeClient apiv1.Interface, releaseName, namespace
 			case map[string]interface{}:
 				InfoMessage(fmt.Sprintf("%s:", key))
 				for subKey, subValue := range v {
-					InfoMessage(fmt.Sprintf("  %s: %v", subKey, subValue))
+					InfoMessage(fmt.Sprintf("  %s: %v", subKey, maskSecretValue(subValue, key, subKey)))
 				}
 			default:
-				InfoMessage(fmt.Sprintf("%s: %v", key, value))
+				InfoMessage(fmt.Sprintf("%s: %v", key, maskSecretValue(value, key)))
 			}
 		}
 		// Run the upgrade
-		rel, err := upgradeClient.Run(releaseName, chartLoaded, vals)
+		rel, err := upgradeClient.RunWithContext(CommandContext(), releaseName, chartLoaded, vals)
+		if cErr := Canceled(); cErr != nil {
+			return fmt.Errorf("upgrade of chart %q aborted: %w", chartRef, cErr)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to upgrade chart %q: %v", chartRef, err)
 		}
@@ -215,18 +217,21 @@ func CheckAndCreateNamespace(kubeClient apiv1.Interface, namespace string) error
 
 	// If the error says "NotFound," then we need to create the namespace
 	if errors.IsNotFound(err) {
+		ns := &corev1.Namespace{
+			ObjectMeta: v1.ObjectMeta{
+				Name: namespace,
+			},
+		}
+		ApplyCommonMetadata(&ns.ObjectMeta)
 		_, createErr := kubeClient.CoreV1().Namespaces().Create(
 			context.Background(),
-			&corev1.Namespace{
-				ObjectMeta: v1.ObjectMeta{
-					Name: namespace,
-				},
-			},
+			ns,
 			v1.CreateOptions{},
 		)
 		if createErr != nil {
 			return fmt.Errorf("failed to create namespace %q: %w", namespace, createErr)
 		}
+		recordInstallStep(installStepNamespace, namespace, "")
 		return nil
 	}
 
@@ -234,346 +239,100 @@ func CheckAndCreateNamespace(kubeClient apiv1.Interface, namespace string) error
 	return fmt.Errorf("failed to get namespace %q: %w", namespace, err)
 }
 
-// waitForGrsfInit checks for cert-manager, crossplane, external secrets, etc.
-func WaitForGrsfInit(kubeClient apiv1.Interface) error {
+// WaitForGrsfInit waits for cert-manager, crossplane, external secrets, etc. All prerequisites are watched
+// concurrently and the wait returns as soon as every one of them is ready.
+func WaitForGrsfInit(kubeClient apiv1.Interface, restConfig *rest.Config) error {
+	progress := StartWaitProgress("grsf-init", WaitTimeout)
+	defer progress.Stop()
 
-	// STEP 1: Check if traefik is installed in kube-system namespace
-	_, err := kubeClient.AppsV1().Deployments("kube-system").Get(context.TODO(), "traefik", v1.GetOptions{})
-	if err == nil {
-		// Wait for Middleware CRD if traefik exists
-		InfoMessage("Waiting for Middleware CRD...")
-		discoveryClient := kubeClient.Discovery()
-		for attempts := 0; attempts < 30; attempts++ {
-			_, resources, err := discoveryClient.ServerGroupsAndResources()
-			if err != nil {
-				time.Sleep(time.Second)
-				continue
-			}
-
-			crdFound := false
-			for _, list := range resources {
-				for _, r := range list.APIResources {
-					if r.Kind == "Middleware" {
-						crdFound = true
-						SuccessMessage("Middleware CRD is available")
-						break
-					}
-				}
-				if crdFound {
-					break
-				}
-			}
+	ctx, cancel := readinessContext()
+	defer cancel()
 
-			if crdFound {
-				break
-			}
-
-			InfoMessage("Waiting for Middleware CRD...")
-			time.Sleep(time.Second)
-		}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
-	// STEP 2: Check if cert-manager is installed in grpl-system namespace
-	for attempts := 0; attempts < 30; attempts++ {
-		deployment, err := kubeClient.AppsV1().Deployments("grpl-system").Get(context.TODO(), "grsf-init-cert-manager", v1.GetOptions{})
-		if err != nil {
-			InfoMessage("Waiting for cert-manager deployment...")
-			time.Sleep(10 * time.Second)
-			continue
-		}
-
-		if deployment.Status.AvailableReplicas == *deployment.Spec.Replicas {
-			SuccessMessage("Cert-manager deployment is available")
-			break
-		}
-
-		InfoMessage("Waiting for cert-manager replicas to be ready...")
-		time.Sleep(10 * time.Second)
+	checks := []readinessCheck{
+		deploymentCheck(kubeClient, "grpl-system", "grsf-init-cert-manager"),
+		crdKindCheck(dynamicClient, "ClusterIssuer"),
 	}
-
-	// Wait for ClusterIssuer CRD
-	discoveryClient := kubeClient.Discovery()
-	for attempts := 0; attempts < 30; attempts++ {
-		_, resources, err := discoveryClient.ServerGroupsAndResources()
-		if err != nil {
-			InfoMessage("Waiting for ClusterIssuer CRD...")
-			time.Sleep(10 * time.Second)
-			continue
-		}
-
-		crdFound := false
-		for _, list := range resources {
-			for _, r := range list.APIResources {
-				if r.Kind == "ClusterIssuer" {
-					crdFound = true
-					SuccessMessage("ClusterIssuer CRD is available")
-					break
-				}
-			}
-			if crdFound {
-				break
-			}
-		}
-
-		if crdFound {
-			break
-		}
-
-		InfoMessage("Waiting for ClusterIssuer CRD...")
-		time.Sleep(10 * time.Second)
+	// The remaining prerequisites only apply when the component is part of the cluster
+	if deploymentExists(ctx, kubeClient, "kube-system", "traefik") {
+		checks = append(checks, crdKindCheck(dynamicClient, "Middleware"))
 	}
-
-	// STEP 3: Check if crossplane is installed in grpl-system namespace
-	_, err = kubeClient.AppsV1().Deployments("grpl-system").Get(context.TODO(), "crossplane", v1.GetOptions{})
-	if err == nil {
-		// Wait for Provider CRD
-		discoveryClient := kubeClient.Discovery()
-		for attempts := 0; attempts < 30; attempts++ {
-			_, resources, err := discoveryClient.ServerGroupsAndResources()
-			if err != nil {
-				InfoMessage("Waiting for Provider CRD...")
-				time.Sleep(10 * time.Second)
-				continue
-			}
-
-			crdFound := false
-			for _, list := range resources {
-				for _, r := range list.APIResources {
-					if r.Kind == "Provider" {
-						crdFound = true
-						SuccessMessage("Provider CRD is available")
-						break
-					}
-				}
-				if crdFound {
-					break
-				}
-			}
-
-			if crdFound {
-				break
-			}
-
-			InfoMessage("Waiting for Provider CRD...")
-			time.Sleep(10 * time.Second)
-		}
+	if deploymentExists(ctx, kubeClient, "grpl-system", "crossplane") {
+		checks = append(checks, crdKindCheck(dynamicClient, "Provider"))
+	}
+	if deploymentExists(ctx, kubeClient, "grpl-system", "grsf-init-external-secrets-webhook") {
+		checks = append(checks, deploymentCheck(kubeClient, "grpl-system", "grsf-init-external-secrets-webhook"))
 	}
 
-	// STEP 4: Check if external-secrets webhook is installed and ready
-	_, err = kubeClient.AppsV1().Deployments("grpl-system").Get(context.TODO(), "grsf-init-external-secrets-webhook", v1.GetOptions{})
-	if err == nil {
-
-		// Wait for webhook deployment to be ready
-		for attempts := 0; attempts < 30; attempts++ {
-			deployment, err := kubeClient.AppsV1().Deployments("grpl-system").Get(context.TODO(), "grsf-init-external-secrets-webhook", v1.GetOptions{})
-			if err != nil {
-				InfoMessage("Waiting for external-secrets webhook deployment...")
-				time.Sleep(10 * time.Second)
-				continue
-			}
-
-			if deployment.Status.AvailableReplicas == *deployment.Spec.Replicas {
-				SuccessMessage("External-secrets webhook deployment is available")
-				break
-			}
-
-			InfoMessage("Waiting for external-secrets webhook replicas to be ready...")
-			time.Sleep(10 * time.Second)
-		}
+	if err := waitForAll(ctx, checks); err != nil {
+		return err
 	}
 
+	progress.Done("grsf-init components are ready")
 	return nil
 }
 
-func WaitForGrsf(kubeClient apiv1.Interface, ns string) error {
-	// Cast the interface back to a *apiv1.Clientset so we can use RESTClient().
-	cs, ok := kubeClient.(*apiv1.Clientset)
-	if !ok {
-		return fmt.Errorf("kubeClient is not a *apiv1.Clientset; got %T", kubeClient)
-	}
-
-	// Sleep 10 seconds before checking providers
-	time.Sleep(10 * time.Second)
-
-	// STEP 1: Check if provider-civo deployment exists
-	_, err := cs.AppsV1().Deployments(ns).Get(context.Background(), "provider-civo", v1.GetOptions{})
-	if err == nil {
-		// Wait for provider-civo to be healthy
-		for attempts := 0; attempts < 30; attempts++ {
-			provider, err := cs.RESTClient().Get().
-				AbsPath("apis/pkg.crossplane.io/v1/providers/provider-civo").
-				Do(context.Background()).
-				Raw()
-			if err != nil {
-				time.Sleep(10 * time.Second)
-				continue
-			}
-
-			var unstr unstructured.Unstructured
-			if err := json.Unmarshal(provider, &unstr); err != nil {
-				return fmt.Errorf("failed to unmarshal provider: %w", err)
-			}
-
-			conditions, found, err := unstructured.NestedSlice(unstr.Object, "status", "conditions")
-			if err != nil || !found {
-				time.Sleep(10 * time.Second)
-				continue
-			}
+// WaitForGrsf waits for the crossplane providers installed by grsf to be healthy and their CRDs to be served
+func WaitForGrsf(kubeClient apiv1.Interface, restConfig *rest.Config, ns string) error {
+	progress := StartWaitProgress("grsf providers", WaitTimeout)
+	defer progress.Stop()
 
-			healthy := false
-			for _, c := range conditions {
-				condition := c.(map[string]interface{})
-				if condition["type"] == "Healthy" && condition["status"] == "True" {
-					healthy = true
-					break
-				}
-			}
-
-			if healthy {
-				InfoMessage("Provider-civo is healthy")
-				break
-			}
-
-			time.Sleep(10 * time.Second)
-		}
-
-		// Wait for provider-civo CRD
-		InfoMessage("Waiting for provider-civo CRD...")
-		for attempts := 0; attempts < 30; attempts++ {
-			_, resources, err := cs.Discovery().ServerGroupsAndResources()
-			if err != nil {
-				time.Sleep(1 * time.Second)
-				continue
-			}
+	ctx, cancel := readinessContext()
+	defer cancel()
 
-			found := false
-			for _, list := range resources {
-				for _, r := range list.APIResources {
-					if r.Name == "providerconfigs.civo.crossplane.io" {
-						found = true
-						InfoMessage("Provider-civo CRD is available")
-						break
-					}
-				}
-				if found {
-					break
-				}
-			}
-
-			if found {
-				break
-			}
-			time.Sleep(1 * time.Second)
-		}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
-	// STEP 2: Wait for all packages to be healthy
-	pkgs, err := cs.RESTClient().Get().
-		AbsPath(fmt.Sprintf("apis/pkg.crossplane.io/v1/namespaces/%s/providers", ns)).
-		Do(context.Background()).
-		Raw()
-	if err == nil {
-		var pkgList unstructured.UnstructuredList
-		if err := json.Unmarshal(pkgs, &pkgList); err != nil {
-			return fmt.Errorf("failed to unmarshal packages: %w", err)
-		}
-
-		for _, pkg := range pkgList.Items {
-			for attempts := 0; attempts < 30; attempts++ {
-				conditions, found, err := unstructured.NestedSlice(pkg.Object, "status", "conditions")
-				if err != nil || !found {
-					time.Sleep(10 * time.Second)
-					continue
-				}
-
-				healthy := false
-				for _, c := range conditions {
-					condition := c.(map[string]interface{})
-					if condition["type"] == "Healthy" && condition["status"] == "True" {
-						healthy = true
-						break
-					}
-				}
-
-				if healthy {
-					break
-				}
-				time.Sleep(10 * time.Second)
-			}
-		}
+	var checks []readinessCheck
+	if deploymentExists(ctx, kubeClient, ns, "provider-civo") {
+		checks = append(checks,
+			conditionCheck(dynamicClient, crossplaneProviderGVR, "provider-civo", "Healthy"),
+			crdNameCheck(dynamicClient, "providerconfigs.civo.crossplane.io"))
+	}
+	if deploymentExists(ctx, kubeClient, ns, "provider-helm") {
+		checks = append(checks, crdNameCheck(dynamicClient, "providerconfigs.helm.crossplane.io"))
+	}
+	if deploymentExists(ctx, kubeClient, ns, "provider-kubernetes") {
+		checks = append(checks, crdNameCheck(dynamicClient, "providerconfigs.kubernetes.crossplane.io"))
 	}
 
-	// STEP 3: Check for provider-helm CRD if deployment exists
-	_, err = cs.AppsV1().Deployments(ns).Get(context.Background(), "provider-helm", v1.GetOptions{})
+	// Every provider package installed so far has to become healthy
+	providers, err := dynamicClient.Resource(crossplaneProviderGVR).List(ctx, v1.ListOptions{})
 	if err == nil {
-		InfoMessage("Waiting for provider-helm CRD...")
-		for attempts := 0; attempts < 30; attempts++ {
-			_, resources, err := cs.Discovery().ServerGroupsAndResources()
-			if err != nil {
-				time.Sleep(1 * time.Second)
+		for _, provider := range providers.Items {
+			if provider.GetName() == "provider-civo" {
 				continue
 			}
-
-			found := false
-			for _, list := range resources {
-				for _, r := range list.APIResources {
-					if r.Name == "providerconfigs.helm.crossplane.io" {
-						found = true
-						InfoMessage("Provider-helm CRD is available")
-						break
-					}
-				}
-				if found {
-					break
-				}
-			}
-
-			if found {
-				break
-			}
-			time.Sleep(1 * time.Second)
+			checks = append(checks, conditionCheck(dynamicClient, crossplaneProviderGVR, provider.GetName(), "Healthy"))
 		}
 	}
 
-	// STEP 4: Check for provider-kubernetes CRD if deployment exists
-	_, err = cs.AppsV1().Deployments(ns).Get(context.Background(), "provider-kubernetes", v1.GetOptions{})
-	if err == nil {
-		InfoMessage("Waiting for provider-kubernetes CRD...")
-		for attempts := 0; attempts < 30; attempts++ {
-			_, resources, err := cs.Discovery().ServerGroupsAndResources()
-			if err != nil {
-				time.Sleep(1 * time.Second)
-				continue
-			}
-
-			found := false
-			for _, list := range resources {
-				for _, r := range list.APIResources {
-					if r.Name == "providerconfigs.apiv1.crossplane.io" {
-						found = true
-						InfoMessage("Provider-kubernetes CRD is available")
-						break
-					}
-				}
-				if found {
-					break
-				}
-			}
-
-			if found {
-				break
-			}
-			time.Sleep(1 * time.Second)
-		}
+	if err := waitForAll(ctx, checks); err != nil {
+		return err
 	}
 
+	progress.Done("grsf providers are ready")
 	return nil
 }
 
-// waitForGrsfConfig checks for CRDs, XRDs, etc.
-// waitForGrsfConfig waits for specific CRDs to be available and waits for all XRDs to reach the "Offered" condition
+// WaitForGrsfConfig waits for the grsf CRDs to be served and for all XRDs to reach the "Offered" condition
 func WaitForGrsfConfig(kubeClient apiv1.Interface, restConfig *rest.Config) error {
-	discoveryClient := kubeClient.Discovery()
+	progress := StartWaitProgress("grsf-config CRDs and XRDs", WaitTimeout)
+	defer progress.Stop()
+
+	ctx, cancel := readinessContext()
+	defer cancel()
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
 
 	var requiredKinds = []string{
 		"CompositeManagedApi",
@@ -581,117 +340,31 @@ func WaitForGrsfConfig(kubeClient apiv1.Interface, restConfig *rest.Config) erro
 		"CompositeManagedDataSource",
 	}
 
-	// 1) Wait for the CRDs to show up via discovery
-	found := make(map[string]bool)
-	for attempts := 0; attempts < 50; attempts++ {
-		// Grab the full list of server groups/resources
-		_, resourceLists, err := discoveryClient.ServerGroupsAndResources()
-		if err != nil {
-			// On an error, just wait and retry
-			time.Sleep(time.Second)
-			continue
-		}
-
-		// Look for each required "kind" in the returned resources
-		for _, list := range resourceLists {
-			for _, r := range list.APIResources {
-				// If the resource's Kind is one of our required ones, mark it found
-				if Contains(requiredKinds, r.Kind) {
-					found[r.Kind] = true
-				}
-			}
-		}
-
-		// Check if we've found all required kinds
-		// Checks if for every requiredKind we have found[kind] == true
-		allFound := true
-		for _, r := range requiredKinds {
-			if !found[r] {
-				allFound = false
-				break
-			}
-		}
-		if allFound {
-			log.Println("All required CRDs are available!")
-			break
-		}
-
-		log.Println("Waiting for required CRDs to appear...")
-		time.Sleep(time.Second)
-
-		// If we've hit the last attempt and not all are found, error
-		if attempts == 49 {
-			// Checks if for every requiredKind we have found[kind] == true
-			allFound := true
-			for _, r := range requiredKinds {
-				if !found[r] {
-					allFound = false
-					break
-				}
-			}
-			if !allFound {
-				return fmt.Errorf("timeout waiting for all required CRDs to appear")
-			}
-		}
+	// 1) Wait for the CRDs to be established
+	var checks []readinessCheck
+	for _, kind := range requiredKinds {
+		checks = append(checks, crdKindCheck(dynamicClient, kind))
 	}
-
-	// Wait for all XRDs to reach "Offered" condition
-	dynamicClient, err := dynamic.NewForConfig(restConfig)
-	if err != nil {
-		return fmt.Errorf("failed to create dynamic client: %w", err)
+	if err := waitForAll(ctx, checks); err != nil {
+		return err
 	}
 
-	// Get list of XRDs
-	xrds, err := dynamicClient.Resource(schema.GroupVersionResource{
-		Group:    "apiextensions.crossplane.io",
-		Version:  "v1",
-		Resource: "compositeresourcedefinitions",
-	}).List(context.Background(), v1.ListOptions{})
+	// 2) Wait for all XRDs to reach "Offered" condition
+	xrds, err := dynamicClient.Resource(compositeDefinitionGVR).List(ctx, v1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list XRDs: %w", err)
 	}
 
-	// Wait for each XRD to reach "Offered" condition
+	checks = nil
 	for _, xrd := range xrds.Items {
-		err = waitForCondition(dynamicClient, xrd.GetName(), "Offered")
-		if err != nil {
-			return fmt.Errorf("failed waiting for XRD %s: %w", xrd.GetName(), err)
-		}
+		checks = append(checks, conditionCheck(dynamicClient, compositeDefinitionGVR, xrd.GetName(), "Offered"))
 	}
-
-	log.Println("All required CRDs and XRDs are available!")
-	return nil
-}
-
-func waitForCondition(client dynamic.Interface, xrdName string, condition string) error {
-	for attempts := 0; attempts < 30; attempts++ {
-		xrd, err := client.Resource(schema.GroupVersionResource{
-			Group:    "apiextensions.crossplane.io",
-			Version:  "v1",
-			Resource: "compositeresourcedefinitions",
-		}).Get(context.Background(), xrdName, v1.GetOptions{})
-
-		if err != nil {
-			return err
-		}
-
-		conditions, found, err := unstructured.NestedSlice(xrd.Object, "status", "conditions")
-		if err != nil || !found {
-			time.Sleep(time.Second)
-			continue
-		}
-
-		for _, c := range conditions {
-			cond := c.(map[string]interface{})
-			if cond["type"] == condition && cond["status"] == "True" {
-				return nil
-			}
-		}
-
-		time.Sleep(time.Second)
+	if err := waitForAll(ctx, checks); err != nil {
+		return err
 	}
 
-	return fmt.Errorf("timeout waiting for condition %s on XRD %s", condition, xrdName)
+	progress.Done("All required CRDs and XRDs are available")
+	return nil
 }
 
 func CreateClusterIssuer(restConfig *rest.Config, sslEnable bool, ingressController string) error {
@@ -700,12 +373,10 @@ func CreateClusterIssuer(restConfig *rest.Config, sslEnable bool, ingressControl
 		InfoMessage("Applying SSL cluster issuer configuration...")
 
 		// Get clusterIssuer yaml path
-		clusterIssuerPath, err := GetResourcePath("files")
+		src, err := ResolveResourceFile("files", "clusterissuer.yaml")
 		if err != nil {
 			return fmt.Errorf("failed to get cluster issuer path: %w", err)
 		}
-		// clusterIssuerPath := "files"
-		src := filepath.Join(clusterIssuerPath, "clusterissuer.yaml")
 
 		// Read the cluster issuer manifest
 		yamlFile, err := os.ReadFile(src)
@@ -717,32 +388,34 @@ func CreateClusterIssuer(restConfig *rest.Config, sslEnable bool, ingressControl
 		yamlStr := string(yamlFile)
 		yamlStr = strings.ReplaceAll(yamlStr, "$INGRESS_CLASS", ingressController)
 
-		// Create dynamic client with the provided restConfig
+		applier, err := NewApplier(restConfig)
+		if err != nil {
+			return err
+		}
 		dynamicClient, err := dynamic.NewForConfig(restConfig)
 		if err != nil {
 			return fmt.Errorf("failed to create dynamic client: %w", err)
 		}
-
-		decoder := k8syaml.NewYAMLOrJSONDecoder(strings.NewReader(yamlStr), 4096)
-		var obj unstructured.Unstructured
-		if err := decoder.Decode(&obj); err != nil {
+		objects, err := DecodeManifest([]byte(yamlStr))
+		if err != nil {
 			return fmt.Errorf("failed to decode cluster issuer manifest: %w", err)
 		}
-
-		// Attempt to create the ClusterIssuer resource
-		_, err = dynamicClient.Resource(schema.GroupVersionResource{
-			Group:    "cert-manager.io",
-			Version:  "v1",
-			Resource: "clusterissuers",
-		}).Create(context.TODO(), &obj, v1.CreateOptions{})
-
-		if err != nil {
-			// Check if it's an "already exists" error
-			if errors.IsAlreadyExists(err) {
-				InfoMessage("ClusterIssuer 'letsencrypt-grapple-demo' already exists, skipping creation")
-				return nil
+		for _, obj := range objects {
+			// An existing issuer may have been changed by the user (e.g. another ACME email), it is kept as is
+			if obj.GetKind() == "ClusterIssuer" {
+				_, err := dynamicClient.Resource(clusterIssuerGVR).Get(context.TODO(), obj.GetName(), v1.GetOptions{})
+				if err == nil {
+					InfoMessage(fmt.Sprintf("ClusterIssuer %s already exists, keeping it", obj.GetName()))
+					continue
+				}
+				if !errors.IsNotFound(err) {
+					return fmt.Errorf("failed to get ClusterIssuer %s: %w", obj.GetName(), err)
+				}
+			}
+			ApplyCommonMetadataToUnstructured(obj)
+			if _, err := applier.Apply(context.TODO(), obj, ""); err != nil {
+				return fmt.Errorf("failed to apply cluster issuer: %w", err)
 			}
-			return fmt.Errorf("failed to apply cluster issuer: %w", err)
 		}
 
 		SuccessMessage("Applied cluster issuer configuration")
@@ -756,7 +429,12 @@ func WaitForGrsfIntegration(restConfig *rest.Config) error {
 	// Wait for all Crossplane packages to be healthy
 	InfoMessage("Checking Crossplane package health...")
 
-	deadline := time.Now().Add(5 * time.Minute)
+	timeout := WaitTimeout
+	progress := StartWaitProgress("Crossplane packages to be healthy", timeout)
+	defer progress.Stop()
+
+	poller := NewPoller()
+	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
 
 		dynamicClient, err := dynamic.NewForConfig(restConfig)
@@ -788,7 +466,9 @@ func WaitForGrsfIntegration(restConfig *rest.Config) error {
 
 		if len(packages.Items) == 0 {
 			InfoMessage("No Crossplane packages found yet...")
-			time.Sleep(10 * time.Second)
+			if err := poller.Wait(); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -818,14 +498,16 @@ func WaitForGrsfIntegration(restConfig *rest.Config) error {
 		}
 
 		if allHealthy {
-			SuccessMessage("All Crossplane packages are healthy")
+			progress.Done("All Crossplane packages are healthy")
 			return nil
 		}
 
-		time.Sleep(10 * time.Second)
+		if err := poller.Wait(); err != nil {
+			return err
+		}
 	}
 
-	return fmt.Errorf("timeout waiting for Crossplane packages to be healthy")
+	return progress.TimeoutError()
 }
 
 // installKubeBlocksOnCluster installs the KubeBlocks chart using Helm.
@@ -878,6 +560,7 @@ func InstallKubeBlocksOnCluster(
 					Name: "kb-system",
 				},
 			}
+			ApplyCommonMetadata(&ns.ObjectMeta)
 			InfoMessage("Creating kb-system namespace...")
 			_, err = clientset.CoreV1().Namespaces().Create(context.Background(), ns, v1.CreateOptions{})
 			if err != nil {
@@ -890,7 +573,6 @@ func InstallKubeBlocksOnCluster(
 
 	InfoMessage("Installing KubeBlocks CRDs...")
 	// 1. Create CRDs first
-	crdsURL := "https://github.com/apecloud/kubeblocks/releases/download/v0.9.1/kubeblocks_crds.yaml"
 
 	// Use dynamic client to create CRDs
 	dynamicClient, err := dynamic.NewForConfig(restConfig)
@@ -898,19 +580,21 @@ func InstallKubeBlocksOnCluster(
 		return fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
-	// Fetch and apply CRDs
-	resp, err := http.Get(crdsURL)
+	// Fetch (or read from the chart cache) and apply CRDs
+	crds, err := kubeBlocksCRDs()
 	if err != nil {
-		return fmt.Errorf("failed to download CRDs yaml: %w", err)
+		return err
 	}
 	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			InfoMessage(fmt.Sprintf("Warning: failed to close response body: %v", closeErr))
+		if closeErr := crds.Close(); closeErr != nil {
+			InfoMessage(fmt.Sprintf("Warning: failed to close CRDs yaml: %v", closeErr))
 		}
 	}()
 
 	// Use k8syaml decoder to properly handle Kubernetes YAML
-	decoder := k8syaml.NewYAMLOrJSONDecoder(resp.Body, 4096)
+	decoder := k8syaml.NewYAMLOrJSONDecoder(crds, 4096)
+	crdGVR := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	var crdNames []string
 	for {
 		var obj unstructured.Unstructured
 		if err := decoder.Decode(&obj); err != nil {
@@ -925,59 +609,36 @@ func InstallKubeBlocksOnCluster(
 			continue
 		}
 
-		gvr := schema.GroupVersionResource{
-			Group:    "apiextensions.k8s.io",
-			Version:  "v1",
-			Resource: "customresourcedefinitions",
-		}
-
-		_, err = dynamicClient.Resource(gvr).Create(context.Background(), &obj, v1.CreateOptions{})
+		_, err = dynamicClient.Resource(crdGVR).Create(context.Background(), &obj, v1.CreateOptions{})
 		if err != nil && !errors.IsAlreadyExists(err) {
 			return fmt.Errorf("failed to create CRD %s: %w", obj.GetName(), err)
 		}
+		crdNames = append(crdNames, obj.GetName())
 	}
 
 	InfoMessage("Waiting for CRDs to be established...")
-	// Wait a bit for CRDs to be established
-	time.Sleep(10 * time.Second)
+	err = Poll(WaitTimeout, func() (bool, error) {
+		for _, name := range crdNames {
+			crd, err := dynamicClient.Resource(crdGVR).Get(context.Background(), name, v1.GetOptions{})
+			if err != nil || !hasTrueCondition(crd, "Established") {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for the KubeBlocks CRDs to be established: %w", err)
+	}
 
 	// 2. Create Helm environment settings
 	settings := cli.New()
 	settings.SetNamespace("kb-system")
 
-	// 3. Add the KubeBlocks Helm repository
-	repoEntry := repo.Entry{
-		Name: "kubeblocks",
-		URL:  "https://apecloud.github.io/helm-charts",
-	}
-
-	chartRepo, err := repo.NewChartRepository(&repoEntry, getter.All(settings))
-	if err != nil {
-		return fmt.Errorf("failed to create chart repository object: %w", err)
-	}
-
-	// Add repo to repositories.yaml
-	repoFile := settings.RepositoryConfig
-	b, err := os.ReadFile(repoFile)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to read repository file: %w", err)
-	}
-
-	var f repo.File
-	if err := yaml.Unmarshal(b, &f); err != nil {
-		return fmt.Errorf("failed to unmarshal repository file: %w", err)
-	}
-
-	// Add new repo or update existing
-	f.Add(&repoEntry)
-
-	if err := f.WriteFile(repoFile, 0644); err != nil {
-		return fmt.Errorf("failed to write repository file: %w", err)
-	}
-
-	_, err = chartRepo.DownloadIndexFile()
-	if err != nil {
-		return fmt.Errorf("failed to download repository index: %w", err)
+	// 3. Add the KubeBlocks Helm repository, not needed when the chart is cached
+	if cachedChartPath("kubeblocks", KubeBlocksVersion) == "" && !OfflineMode {
+		if err := addKubeBlocksRepo(settings); err != nil {
+			return err
+		}
 	}
 
 	// Suppress wait-related logs
@@ -990,13 +651,13 @@ func InstallKubeBlocksOnCluster(
 	installClient.Namespace = "kb-system"
 	installClient.CreateNamespace = true
 	installClient.Timeout = 1200 * time.Second // 20 minute timeout
-	installClient.Version = "0.9.1"
+	installClient.Version = KubeBlocksVersion
 	// installClient.Wait = true
 	installClient.Description = "Installing KubeBlocks"
 
 	// 5. Locate and load the chart
 	InfoMessage("Locating KubeBlocks chart...")
-	chartPath, err := installClient.ChartPathOptions.LocateChart("kubeblocks/kubeblocks", settings)
+	chartPath, err := locateChart(&installClient.ChartPathOptions, "kubeblocks", "kubeblocks/kubeblocks", KubeBlocksVersion, settings)
 	if err != nil {
 		return fmt.Errorf("failed to locate KubeBlocks chart: %w", err)
 	}
@@ -1008,273 +669,207 @@ func InstallKubeBlocksOnCluster(
 	}
 
 	// Set values to ensure installation in kb-system namespace
+	imageRegistry := imageRegistry()
+	if imageRegistry == "" {
+		imageRegistry = "docker.io"
+	}
 	values := map[string]interface{}{
 		"image": map[string]interface{}{
-			"registry":   "docker.io",
+			"registry":   imageRegistry,
 			"repository": "apecloud/kubeblocks",
 		},
 		"dataScriptImage": map[string]interface{}{
-			"registry":   "docker.io",
+			"registry":   imageRegistry,
 			"repository": "apecloud/kubeblocks-datascript",
 		},
 		"toolImage": map[string]interface{}{
-			"registry":   "docker.io",
+			"registry":   imageRegistry,
 			"repository": "apecloud/kubeblocks-tools",
 		},
 	}
+	if PriorityClass != "" {
+		values["priorityClassName"] = PriorityClass
+	}
 	InfoMessage("Installing KubeBlocks chart...")
-	if _, err := installClient.Run(chartRequested, values); err != nil {
+	recordNamespaceIfMissing(installClient.Namespace)
+	recordInstallStep(installStepRelease, installClient.ReleaseName, installClient.Namespace)
+	if _, err := installClient.RunWithContext(CommandContext(), chartRequested, values); err != nil {
 		return fmt.Errorf("failed to install the KubeBlocks chart: %w", err)
 	}
 
 	return nil
 }
 
-func WaitForGrappleReady(restConfig *rest.Config) error {
-	// Wait for all Crossplane packages to be healthy
-	InfoMessage("Waiting for grpl to be ready")
-
-	deadline := time.Now().Add(5 * time.Minute)
-	for time.Now().Before(deadline) {
-
-		dynamicClient, err := dynamic.NewForConfig(restConfig)
-		if err != nil {
-			return fmt.Errorf("failed to create dynamic client: %w", err)
-		}
-
-		// Try to list all types of packages (providers, configurations, functions)
-		gvr := schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "configurations"}
+// addKubeBlocksRepo adds the KubeBlocks Helm repository and downloads its index
+func addKubeBlocksRepo(settings *cli.EnvSettings) error {
+	repoEntry := repo.Entry{
+		Name: "kubeblocks",
+		URL:  kubeBlocksRepoURL,
+	}
 
-		var grplPackage unstructured.Unstructured
-		pkgList, err := dynamicClient.Resource(gvr).List(context.TODO(), v1.ListOptions{})
-		if err != nil {
-			if !strings.Contains(err.Error(), "the server could not find the requested resource") {
-				ErrorMessage(fmt.Sprintf("Failed to list Crossplane %s for grpl: %v", gvr.Resource, err))
-				return err
-			}
-			continue
-		}
+	chartRepo, err := repo.NewChartRepository(&repoEntry, getter.All(settings))
+	if err != nil {
+		return fmt.Errorf("failed to create chart repository object: %w", err)
+	}
 
-		for _, pkg := range pkgList.Items {
-			if pkg.GetName() == "grpl" {
-				grplPackage = pkg
-				break
-			}
-		}
+	// Add repo to repositories.yaml
+	repoFile := settings.RepositoryConfig
+	b, err := os.ReadFile(repoFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read repository file: %w", err)
+	}
 
-		InfoMessage(fmt.Sprintf("Checking package %s", grplPackage.GetName()))
-		conditions, found, err := unstructured.NestedSlice(grplPackage.Object, "status", "conditions")
-		if err != nil || !found {
-			InfoMessage(fmt.Sprintf("Package %s not yet healthy", grplPackage.GetName()))
-			continue
-		}
+	var f repo.File
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("failed to unmarshal repository file: %w", err)
+	}
 
-		isHealthy := false
-		for _, condition := range conditions {
-			conditionMap := condition.(map[string]interface{})
-			if conditionMap["type"] == "Healthy" && conditionMap["status"] == "True" {
-				SuccessMessage("grpl is ready")
-				return nil
-			}
-		}
+	// Add new repo or update existing
+	f.Add(&repoEntry)
 
-		if !isHealthy {
-			InfoMessage(fmt.Sprintf("Package %s not yet healthy", grplPackage.GetName()))
-			continue
-		}
+	if err := f.WriteFile(repoFile, 0644); err != nil {
+		return fmt.Errorf("failed to write repository file: %w", err)
+	}
 
-		time.Sleep(10 * time.Second)
+	_, err = chartRepo.DownloadIndexFile()
+	if err != nil {
+		return fmt.Errorf("failed to download repository index: %w", err)
 	}
 
-	return fmt.Errorf("timeout waiting for Crossplane packages to be healthy")
+	return nil
 }
 
-// waitForDeployment waits for a deployment to be ready
-func WaitForDeployment(kubeClient *apiv1.Clientset, namespace, name string) error {
-	for {
-		deployment, err := kubeClient.AppsV1().Deployments(namespace).Get(context.TODO(), name, v1.GetOptions{})
-		if err != nil {
-			return err
-		}
-
-		if deployment.Status.ReadyReplicas == *deployment.Spec.Replicas {
-			return nil
-		}
-
-		InfoMessage(fmt.Sprintf("Waiting for deployment %s in namespace %s to be ready...", name, namespace))
-		time.Sleep(5 * time.Second)
-	}
-}
+// WaitForGrappleReady waits for the grpl crossplane configuration to be healthy
+func WaitForGrappleReady(restConfig *rest.Config) error {
+	InfoMessage("Waiting for grpl to be ready")
 
-func UninstallGrapple(connectToCluster func() error, logOnFileStart, logOnCliAndFileStart func()) error {
+	progress := StartWaitProgress("grpl to be ready", WaitTimeout)
+	defer progress.Stop()
 
-	// Initialize Kubernetes clients
-	settings := cli.New()
+	ctx, cancel := readinessContext()
+	defer cancel()
 
-	config, clientset, err := GetKubernetesConfig()
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
 	if err != nil {
-		InfoMessage("No existing connection found")
-		err = connectToCluster()
-		if err != nil {
-			ErrorMessage(fmt.Sprintf("Failed to connect to cluster: %v", err))
-			return err
-		}
-
-		config, err = settings.RESTClientGetter().ToRESTConfig()
-		if err != nil {
-			ErrorMessage(fmt.Sprintf("Failed to get REST config: %v", err))
-			return err
-		}
-
-		clientset, err = apiv1.NewForConfig(config)
-		if err != nil {
-			ErrorMessage(fmt.Sprintf("Failed to create Kubernetes client: %v", err))
-			return err
-		}
+		return fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
-	dynamicClient, err := dynamic.NewForConfig(config)
-	if err != nil {
-		ErrorMessage(fmt.Sprintf("Failed to create dynamic client: %v", err))
-		return err
+	if err := watchUnstructured(ctx, dynamicClient, crossplaneConfigGVR, "", "grpl", func(pkg *unstructured.Unstructured) bool {
+		return hasTrueCondition(pkg, "Healthy")
+	}); err != nil {
+		return fmt.Errorf("grpl configuration is not healthy: %w", err)
 	}
 
-	InfoMessage("Checking and deleting all Grapple resources across all namespaces...")
-	logOnFileStart()
-
-	// Get all CRDs with grpl in the name
-	InfoMessage("Getting all Grapple CRDs...")
-	crdGVR := schema.GroupVersionResource{
-		Group:    "apiextensions.k8s.io",
-		Version:  "v1",
-		Resource: "customresourcedefinitions",
-	}
+	progress.Done("grpl is ready")
+	return nil
+}
 
-	crdList, err := dynamicClient.Resource(crdGVR).List(context.TODO(), v1.ListOptions{})
-	if err != nil {
-		ErrorMessage(fmt.Sprintf("Failed to list CRDs: %v", err))
-		return err
-	}
+// WaitForDeployment waits for a deployment to be ready
+func WaitForDeployment(kubeClient *apiv1.Clientset, namespace, name string) error {
+	progress := StartWaitProgress(fmt.Sprintf("deployment %s/%s", namespace, name), WaitTimeout)
+	defer progress.Stop()
 
-	// Track unique namespaces that have Grapple resources
-	namespacesToDelete := make(map[string]bool)
+	ctx, cancel := readinessContext()
+	defer cancel()
 
-	// Delete all CRDs with grpl in the name
-	for _, crd := range crdList.Items {
-		name := crd.GetName()
-		if strings.Contains(strings.ToLower(name), "grpl") {
-			InfoMessage(fmt.Sprintf("Deleting CRD '%s'...", name))
-			err := dynamicClient.Resource(crdGVR).Delete(context.TODO(), name, v1.DeleteOptions{})
-			if err != nil {
-				ErrorMessage(fmt.Sprintf("Failed to delete CRD '%s': %v", name, err))
-			} else {
-				SuccessMessage(fmt.Sprintf("CRD '%s' deleted", name))
-			}
-		}
+	if err := watchDeploymentAvailable(ctx, kubeClient, namespace, name); err != nil {
+		return err
 	}
 
-	// Delete collected namespaces
-	for namespace := range namespacesToDelete {
-		InfoMessage(fmt.Sprintf("Deleting namespace '%s'...", namespace))
-		err := clientset.CoreV1().Namespaces().Delete(context.TODO(), namespace, v1.DeleteOptions{})
-		if err != nil {
-			ErrorMessage(fmt.Sprintf("Failed to delete namespace '%s': %v", namespace, err))
-		} else {
-			SuccessMessage(fmt.Sprintf("Namespace '%s' deleted", namespace))
-		}
-	}
+	progress.Done(fmt.Sprintf("Deployment %s/%s is ready", namespace, name))
+	return nil
+}
 
-	logOnCliAndFileStart()
-	SuccessMessage("All Grapple resources deleted across all namespaces")
+// DBConnection is where a database is reached, from the conn-credential secret KubeBlocks creates for a cluster
+type DBConnection struct {
+	Host     string `json:"host" yaml:"host"`
+	Port     string `json:"port" yaml:"port"`
+	Username string `json:"username" yaml:"username"`
+	// Secret holds the password next to the other values
+	Secret string `json:"secret" yaml:"secret"`
+}
 
-	InfoMessage("Checking and deleting kb-system namespace if it exists...")
-	logOnFileStart()
+// connCredentialKeys are the keys of the conn-credential secret the datasource of a GRAS references
+var connCredentialKeys = []string{"host", "port", "username", "password"}
 
-	// Check and delete kb-system namespace if it exists
-	_, err = clientset.CoreV1().Namespaces().Get(context.TODO(), "kb-system", v1.GetOptions{})
-	if err == nil {
-		InfoMessage("Found kb-system namespace, uninstalling kubeblocks...")
+// WaitForKubeBlocksCluster waits for a KubeBlocks cluster, e.g. the internal DB of a GRAS, to be running and for
+// its conn-credential secret to be populated, and returns the connection of the cluster. The phase of the cluster
+// and of its components is reported while waiting.
+func WaitForKubeBlocksCluster(restConfig *rest.Config, namespace, name string) (*DBConnection, error) {
+	progress := StartWaitProgress(fmt.Sprintf("database %s/%s", namespace, name), WaitTimeout)
+	defer progress.Stop()
 
-		// Initialize Helm for kb-system
-		settings.SetNamespace("kb-system")
-		actionConfig := new(action.Configuration)
-		if err := actionConfig.Init(settings.RESTClientGetter(), "kb-system", os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
-			ErrorMessage(fmt.Sprintf("Failed to initialize helm config: %v", err))
-		} else {
-			// Uninstall kubeblocks helm release
-			uninstall := action.NewUninstall(actionConfig)
-			_, err := uninstall.Run("kubeblocks")
-			if err != nil {
-				ErrorMessage(fmt.Sprintf("Failed to uninstall kubeblocks: %v", err))
-			} else {
-				SuccessMessage("Kubeblocks uninstalled successfully")
-			}
-		}
+	ctx, cancel := readinessContext()
+	defer cancel()
 
-		// Delete kb-system namespace
-		InfoMessage("Deleting kb-system namespace...")
-		err = clientset.CoreV1().Namespaces().Delete(context.TODO(), "kb-system", v1.DeleteOptions{})
-		if err != nil {
-			ErrorMessage(fmt.Sprintf("Failed to delete kb-system namespace: %v", err))
-		} else {
-			SuccessMessage("kb-system namespace deleted")
-		}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
-	logOnCliAndFileStart()
-	SuccessMessage("kubeblocks uninstalled and kb-system namespace deleted successfully")
-
-	// Check if grpl-system namespace exists
-	_, err = clientset.CoreV1().Namespaces().Get(context.TODO(), "grpl-system", v1.GetOptions{})
-	if err == nil {
-		// Initialize Helm for grpl-system
-		settings.SetNamespace("grpl-system")
-		actionConfig := new(action.Configuration)
-		if err := actionConfig.Init(settings.RESTClientGetter(), "grpl-system", os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
-			return fmt.Errorf("failed to initialize helm config: %w", err)
-		}
+	if err := watchUnstructured(ctx, dynamicClient, kubeBlocksClusterGVR, namespace, name, func(cluster *unstructured.Unstructured) bool {
+		phase, _, _ := unstructured.NestedString(cluster.Object, "status", "phase")
+		progress.SetStatus(kubeBlocksClusterStatus(cluster))
+		return phase == "Running"
+	}); err != nil {
+		return nil, err
+	}
 
-		// Uninstall Helm releases in reverse order
-		releases := []string{"grsf-integration", "grsf-config", "grsf", "grsf-init"}
-		for _, release := range releases {
-			InfoMessage(fmt.Sprintf("Uninstalling %s...", release))
-			logOnFileStart()
-			uninstall := action.NewUninstall(actionConfig)
-			_, err := uninstall.Run(release)
-			logOnCliAndFileStart()
-			if err != nil {
-				ErrorMessage(fmt.Sprintf("Failed to uninstall %s: %v", release, err))
-				// Continue with other releases even if one fails
-			} else {
-				SuccessMessage(fmt.Sprintf("%s uninstalled successfully", release))
+	secretName := name + "-conn-credential"
+	progress.SetStatus("waiting for secret " + secretName)
+	var connection *DBConnection
+	if err := watchUnstructured(ctx, dynamicClient, secretGVR, namespace, secretName, func(secret *unstructured.Unstructured) bool {
+		data, _, _ := unstructured.NestedStringMap(secret.Object, "data")
+		for _, key := range connCredentialKeys {
+			if data[key] == "" {
+				return false
 			}
 		}
-
-		// Delete grpl-system namespace
-		InfoMessage("Deleting grpl-system namespace...")
-		logOnFileStart()
-		err = clientset.CoreV1().Namespaces().Delete(context.TODO(), "grpl-system", v1.DeleteOptions{})
-		logOnCliAndFileStart()
-		if err != nil {
-			ErrorMessage(fmt.Sprintf("Failed to delete namespace: %v", err))
-		} else {
-			SuccessMessage("Namespace deleted successfully")
+		connection = &DBConnection{
+			Host:     decodeSecretValue(data["host"]),
+			Port:     decodeSecretValue(data["port"]),
+			Username: decodeSecretValue(data["username"]),
+			Secret:   secretName,
 		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
 
-		// Wait for namespace deletion
-		InfoMessage("Waiting for namespace deletion to complete...")
-		deadline := time.Now().Add(2 * time.Minute)
-		for time.Now().Before(deadline) {
-			_, err := clientset.CoreV1().Namespaces().Get(context.TODO(), "grpl-system", v1.GetOptions{})
-			if err != nil {
-				break
-			}
-			time.Sleep(5 * time.Second)
+	progress.Done(fmt.Sprintf("Database %s/%s is running", namespace, name))
+	return connection, nil
+}
+
+// kubeBlocksClusterStatus summarizes the phase of a KubeBlocks cluster and of its components, e.g.
+// "phase Creating (mysql Creating)"
+func kubeBlocksClusterStatus(cluster *unstructured.Unstructured) string {
+	phase, _, _ := unstructured.NestedString(cluster.Object, "status", "phase")
+	if phase == "" {
+		return "cluster created"
+	}
+	components, _, _ := unstructured.NestedMap(cluster.Object, "status", "components")
+	names := make([]string, 0, len(components))
+	for component := range components {
+		names = append(names, component)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, component := range names {
+		if componentPhase, _, _ := unstructured.NestedString(components, component, "phase"); componentPhase != "" {
+			parts = append(parts, component+" "+componentPhase)
 		}
-	} else {
-		InfoMessage("grpl-system namespace not found, skipping uninstallation steps")
 	}
+	if len(parts) == 0 {
+		return "phase " + phase
+	}
+	return fmt.Sprintf("phase %s (%s)", phase, strings.Join(parts, ", "))
+}
 
-	SuccessMessage("Grapple uninstallation completed!")
-	return nil
+// decodeSecretValue decodes a value of the data of a secret read as unstructured object
+func decodeSecretValue(value string) string {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return value
+	}
+	return string(decoded)
 }
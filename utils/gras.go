@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+)
+
+// ListGrasNames returns the names of the GrappleApplicationSets by namespace, namespace "" lists all namespaces
+func ListGrasNames(restConfig *rest.Config, namespace string) (map[string][]string, error) {
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	list, err := dynamicClient.Resource(grasGVR).Namespace(namespace).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GrappleApplicationSets: %w", err)
+	}
+
+	names := map[string][]string{}
+	for _, item := range list.Items {
+		names[item.GetNamespace()] = append(names[item.GetNamespace()], item.GetName())
+	}
+	for ns := range names {
+		sort.Strings(names[ns])
+	}
+	return names, nil
+}
+
+// GetGras returns a GrappleApplicationSet
+func GetGras(restConfig *rest.Config, namespace, name string) (*unstructured.Unstructured, error) {
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	gras, err := dynamicClient.Resource(grasGVR).Namespace(namespace).Get(context.TODO(), name, v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GrappleApplicationSet %s: %w", name, err)
+	}
+	return gras, nil
+}
+
+// GrasGruimNames returns the names of the GRUIMs of a GrappleApplicationSet, the deployment of each one is
+// named <gruim-name>-gruim
+func GrasGruimNames(restConfig *rest.Config, namespace, name string) ([]string, error) {
+	gras, err := GetGras(restConfig, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	gruims, _, _ := unstructured.NestedSlice(gras.Object, "spec", "gruims")
+	var names []string
+	for _, gruim := range gruims {
+		if entry, ok := gruim.(map[string]interface{}); ok {
+			if gruimName, ok := entry["name"].(string); ok && gruimName != "" {
+				names = append(names, gruimName)
+			}
+		}
+	}
+	return names, nil
+}
+
+// EnsureGrasGruims adds the GRUIMs missing from the spec of a GrappleApplicationSet, GRUIMs are matched by name
+// and the ones already present are left as they are
+func EnsureGrasGruims(restConfig *rest.Config, namespace, name string, gruims []map[string]interface{}) error {
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	resource := dynamicClient.Resource(grasGVR).Namespace(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		gras, err := resource.Get(context.TODO(), name, v1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get GrappleApplicationSet %s: %w", name, err)
+		}
+		existing, _, _ := unstructured.NestedSlice(gras.Object, "spec", "gruims")
+		present := map[string]bool{}
+		for _, gruim := range existing {
+			if entry, ok := gruim.(map[string]interface{}); ok {
+				present[fmt.Sprint(entry["name"])] = true
+			}
+		}
+		changed := false
+		for _, gruim := range gruims {
+			if !present[fmt.Sprint(gruim["name"])] {
+				existing = append(existing, runtime.DeepCopyJSONValue(gruim))
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		if err := unstructured.SetNestedSlice(gras.Object, existing, "spec", "gruims"); err != nil {
+			return err
+		}
+		_, err = resource.Update(context.TODO(), gras, v1.UpdateOptions{})
+		return err
+	})
+}
+
+// AnnotateGras sets annotations of a GrappleApplicationSet
+func AnnotateGras(restConfig *rest.Config, namespace, name string, annotations map[string]string) error {
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": annotations}})
+	if err != nil {
+		return err
+	}
+	_, err = dynamicClient.Resource(grasGVR).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType, patch, v1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to annotate GrappleApplicationSet %s: %w", name, err)
+	}
+	return nil
+}
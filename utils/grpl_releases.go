@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/client-go/rest"
+)
+
+// GetGrplReleaseVersion returns the chart version of the deployed release, or "" if it is not installed
+func GetGrplReleaseVersion(restConfig *rest.Config, releaseName, namespace string) (string, error) {
+	helmConfig, err := GetHelmConfig(restConfig, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	histClient := action.NewHistory(helmConfig)
+	histClient.Max = 1
+	releases, err := histClient.Run(releaseName)
+	if err == driver.ErrReleaseNotFound || (err == nil && len(releases) == 0) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get history of release %s: %w", releaseName, err)
+	}
+
+	latest := releases[len(releases)-1]
+	if latest.Chart == nil || latest.Chart.Metadata == nil {
+		return "", nil
+	}
+	return latest.Chart.Metadata.Version, nil
+}
+
+// WriteGrplReleaseValues writes the values the release was installed with to a temp file, with the
+// Grapple version in the config section set to grappleVersion, so an upgrade keeps the cluster settings
+func WriteGrplReleaseValues(restConfig *rest.Config, releaseName, namespace, grappleVersion string) (string, error) {
+	helmConfig, err := GetHelmConfig(restConfig, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	vals, err := action.NewGetValues(helmConfig).Run(releaseName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get values of release %s: %w", releaseName, err)
+	}
+	if config, ok := vals["config"].(map[string]interface{}); ok {
+		config[SecKeyGrapleVersion] = grappleVersion
+		if _, ok := config[SecKeyGrapleCliVersion]; ok {
+			config[SecKeyGrapleCliVersion] = GetGrappleCliVersion()
+		}
+	}
+
+	data, err := yaml.Marshal(vals)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal values of release %s: %w", releaseName, err)
+	}
+
+	file, err := os.CreateTemp("", fmt.Sprintf("grpl-%s-values-*.yaml", releaseName))
+	if err != nil {
+		return "", fmt.Errorf("failed to create values file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		return "", fmt.Errorf("failed to write values file: %w", err)
+	}
+	return file.Name(), nil
+}
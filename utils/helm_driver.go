@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// helmSQLConnectionEnv is read by helm itself when the sql driver is used
+const helmSQLConnectionEnv = "HELM_DRIVER_SQL_CONNECTION_STRING"
+
+// HelmDriver is the storage backend of the helm release information, set by the global --helm-driver flag
+var HelmDriver string
+
+// helmDrivers are the storage backends helm supports
+var helmDrivers = []string{"secret", "secrets", "configmap", "configmaps", "memory", "sql"}
+
+// HelmDriverName returns the storage backend of the helm releases: --helm-driver, the helm-driver setting
+// (HELM_DRIVER) or "secret", the default of helm
+func HelmDriverName() string {
+	driver := HelmDriver
+	if driver == "" {
+		driver = ConfigValue("helm-driver")
+	}
+	if driver == "" {
+		return "secret"
+	}
+	return strings.ToLower(driver)
+}
+
+// InitHelmActionConfig initializes a helm action configuration with the configured storage backend, every helm
+// action of the CLI has to use it, otherwise releases stored with another driver are not found
+func InitHelmActionConfig(actionConfig *action.Configuration, getter genericclioptions.RESTClientGetter, namespace string) error {
+	driver := HelmDriverName()
+	if !Contains(helmDrivers, driver) {
+		return fmt.Errorf("%w: unsupported helm driver %q, use one of %s", ErrValidation, driver, strings.Join(helmDrivers, ", "))
+	}
+	if driver == "sql" && os.Getenv(helmSQLConnectionEnv) == "" {
+		return fmt.Errorf("%w: the sql helm driver needs a connection string, set it with 'grapple config set helm-driver-sql-connection-string <dsn>' or %s", ErrValidation, helmSQLConnectionEnv)
+	}
+	return actionConfig.Init(getter, namespace, driver, log.Printf)
+}
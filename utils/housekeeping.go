@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// HelperLabelKey marks the short lived workloads the CLI creates during installs, e.g. the DNS upsert pod
+	HelperLabelKey = "grpl.io/helper"
+
+	helperDNSUpsert    = "dns-upsert"
+	helperImagePreload = "image-preload"
+	helperDBCheck      = "db-check"
+
+	dnsUpsertPodName       = "grpl-dns-route53-upsert"
+	imagePreloadNamePrefix = "image-preload-"
+)
+
+// HelperRetention is how long failed helper workloads are kept for debugging before they are pruned
+var HelperRetention = 24 * time.Hour
+
+// PrunedObject is a helper workload removed (or, in a dry run, to be removed) by the housekeeping
+type PrunedObject struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Name      string `json:"name" yaml:"name"`
+	Reason    string `json:"reason" yaml:"reason"`
+}
+
+// HelperLabels returns the labels of a helper workload of the given type
+func HelperLabels(helper string) map[string]string {
+	return map[string]string{HelperLabelKey: helper}
+}
+
+// PruneHelperWorkloads deletes succeeded helper pods and jobs, and failed ones older than the retention.
+// Workloads created by older CLI versions, before they were labelled, are matched by name.
+func PruneHelperWorkloads(kubeClient apiv1.Interface, retention time.Duration, dryRun bool) ([]PrunedObject, error) {
+	ctx := context.TODO()
+	pruned := []PrunedObject{}
+
+	prune := func(kind, namespace, name, reason string, del func() error) error {
+		if !dryRun {
+			if err := del(); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete %s %s/%s: %w", kind, namespace, name, err)
+			}
+		}
+		pruned = append(pruned, PrunedObject{Kind: kind, Namespace: namespace, Name: name, Reason: reason})
+		return nil
+	}
+
+	pods, err := kubeClient.CoreV1().Pods("").List(ctx, v1.ListOptions{LabelSelector: HelperLabelKey})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list helper pods: %w", err)
+	}
+	if legacy, err := kubeClient.CoreV1().Pods("default").Get(ctx, dnsUpsertPodName, v1.GetOptions{}); err == nil && legacy.Labels[HelperLabelKey] == "" {
+		pods.Items = append(pods.Items, *legacy)
+	}
+	for _, pod := range pods.Items {
+		if len(pod.OwnerReferences) > 0 {
+			// Pods of jobs and daemonsets are removed together with their owner
+			continue
+		}
+		reason := pruneReason(pod.Status.Phase == corev1.PodSucceeded, pod.Status.Phase == corev1.PodFailed, podFinishedAt(&pod), retention)
+		if reason == "" {
+			continue
+		}
+		namespace, name := pod.Namespace, pod.Name
+		if err := prune("Pod", namespace, name, reason, func() error {
+			return kubeClient.CoreV1().Pods(namespace).Delete(ctx, name, v1.DeleteOptions{})
+		}); err != nil {
+			return pruned, err
+		}
+	}
+
+	jobs, err := kubeClient.BatchV1().Jobs("").List(ctx, v1.ListOptions{LabelSelector: HelperLabelKey})
+	if err != nil {
+		return pruned, fmt.Errorf("failed to list helper jobs: %w", err)
+	}
+	for _, job := range jobs.Items {
+		reason := pruneReason(jobHasCondition(&job, batchv1.JobComplete), jobHasCondition(&job, batchv1.JobFailed), jobFailedAt(&job), retention)
+		if reason == "" {
+			continue
+		}
+		namespace, name := job.Namespace, job.Name
+		propagation := v1.DeletePropagationBackground
+		if err := prune("Job", namespace, name, reason, func() error {
+			return kubeClient.BatchV1().Jobs(namespace).Delete(ctx, name, v1.DeleteOptions{PropagationPolicy: &propagation})
+		}); err != nil {
+			return pruned, err
+		}
+	}
+
+	// Preload daemonsets are left behind when waiting for them failed or the CLI was interrupted
+	daemonSets, err := kubeClient.AppsV1().DaemonSets("default").List(ctx, v1.ListOptions{})
+	if err != nil {
+		return pruned, fmt.Errorf("failed to list image preload daemonsets: %w", err)
+	}
+	for _, ds := range daemonSets.Items {
+		if ds.Labels[HelperLabelKey] != helperImagePreload && !strings.HasPrefix(ds.Name, imagePreloadNamePrefix) {
+			continue
+		}
+		reason := pruneReason(daemonSetReady(&ds), false, ds.CreationTimestamp.Time, retention)
+		if reason == "" && time.Since(ds.CreationTimestamp.Time) > retention {
+			reason = fmt.Sprintf("not ready for more than %s", retention)
+		}
+		if reason == "" {
+			continue
+		}
+		name := ds.Name
+		if err := prune("DaemonSet", ds.Namespace, name, reason, func() error {
+			return kubeClient.AppsV1().DaemonSets("default").Delete(ctx, name, v1.DeleteOptions{})
+		}); err != nil {
+			return pruned, err
+		}
+	}
+
+	return pruned, nil
+}
+
+// PruneHelperWorkloadsAfterInstall runs the housekeeping at the end of a successful install, failures
+// are only reported since the installation itself succeeded
+func PruneHelperWorkloadsAfterInstall(kubeClient apiv1.Interface) {
+	pruned, err := PruneHelperWorkloads(kubeClient, HelperRetention, false)
+	if err != nil {
+		ErrorMessage(fmt.Sprintf("Failed to prune helper workloads: %v", err))
+	}
+	for _, obj := range pruned {
+		InfoMessage(fmt.Sprintf("Pruned %s %s/%s (%s)", obj.Kind, obj.Namespace, obj.Name, obj.Reason))
+	}
+}
+
+// pruneReason returns why a helper workload should be pruned, or "" if it should be kept. Failed workloads are
+// kept for the retention from the time they failed.
+func pruneReason(succeeded, failed bool, failedAt time.Time, retention time.Duration) string {
+	switch {
+	case succeeded:
+		return "succeeded"
+	case failed && time.Since(failedAt) > retention:
+		return fmt.Sprintf("failed more than %s ago", retention)
+	default:
+		return ""
+	}
+}
+
+// podFinishedAt returns when the last container of the pod terminated, its creation if none did
+func podFinishedAt(pod *corev1.Pod) time.Time {
+	finished := pod.CreationTimestamp.Time
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.FinishedAt.After(finished) {
+			finished = terminated.FinishedAt.Time
+		}
+	}
+	return finished
+}
+
+// jobFailedAt returns when the job failed, its creation if it has no Failed condition
+func jobFailedAt(job *batchv1.Job) time.Time {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue && !condition.LastTransitionTime.IsZero() {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return job.CreationTimestamp.Time
+}
+
+func jobHasCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func daemonSetReady(ds *appsv1.DaemonSet) bool {
+	return ds.Status.DesiredNumberScheduled > 0 && ds.Status.DesiredNumberScheduled == ds.Status.NumberReady
+}
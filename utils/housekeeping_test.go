@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPruneReason(t *testing.T) {
+	retention := time.Hour
+	now := time.Now()
+	tests := []struct {
+		name      string
+		succeeded bool
+		failed    bool
+		failedAt  time.Time
+		want      string
+	}{
+		{name: "succeeded", succeeded: true, failedAt: now, want: "succeeded"},
+		{name: "running", failedAt: now.Add(-2 * retention)},
+		{name: "failed just now", failed: true, failedAt: now},
+		{name: "failed within the retention", failed: true, failedAt: now.Add(-retention / 2)},
+		{name: "failed before the retention", failed: true, failedAt: now.Add(-2 * retention), want: "failed more than 1h0m0s ago"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pruneReason(tt.succeeded, tt.failed, tt.failedAt, retention); got != tt.want {
+				t.Errorf("pruneReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailureTimes(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	failed := created.Add(5 * time.Hour)
+	terminated := func(finished time.Time) corev1.ContainerStatus {
+		return corev1.ContainerStatus{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: v1.NewTime(finished)}}}
+	}
+
+	pods := []struct {
+		name string
+		pod  corev1.Pod
+		want time.Time
+	}{
+		{name: "pod without terminated containers", pod: corev1.Pod{}, want: created},
+		{name: "pod with a failed container", pod: corev1.Pod{Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{terminated(failed)},
+		}}, want: failed},
+		{name: "pod with the last container failing", pod: corev1.Pod{Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{terminated(created.Add(time.Minute))},
+			ContainerStatuses:     []corev1.ContainerStatus{terminated(failed), {}},
+		}}, want: failed},
+	}
+	for _, tt := range pods {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.pod.CreationTimestamp = v1.NewTime(created)
+			if got := podFinishedAt(&tt.pod); !got.Equal(tt.want) {
+				t.Errorf("podFinishedAt() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	jobs := []struct {
+		name       string
+		conditions []batchv1.JobCondition
+		want       time.Time
+	}{
+		{name: "job without conditions", want: created},
+		{name: "failed job", conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, LastTransitionTime: v1.NewTime(failed)},
+		}, want: failed},
+		{name: "job with a false Failed condition", conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobFailed, Status: corev1.ConditionFalse, LastTransitionTime: v1.NewTime(failed)},
+		}, want: created},
+	}
+	for _, tt := range jobs {
+		t.Run(tt.name, func(t *testing.T) {
+			job := batchv1.Job{ObjectMeta: v1.ObjectMeta{CreationTimestamp: v1.NewTime(created)}, Status: batchv1.JobStatus{Conditions: tt.conditions}}
+			if got := jobFailedAt(&job); !got.Equal(tt.want) {
+				t.Errorf("jobFailedAt() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	ProgressText = "text"
+	ProgressJSON = "json"
+
+	StepStarted   = "started"
+	StepSucceeded = "succeeded"
+	StepFailed    = "failed"
+	StepSkipped   = "skipped"
+)
+
+var (
+	// ProgressFormat is the format of install progress events, set by the global --progress-format flag. With json,
+	// every install step emits a JSON line when it starts and when it finishes.
+	ProgressFormat = ProgressText
+	// ProgressFile receives the progress events instead of stdout, set by the global --progress-file flag
+	ProgressFile string
+
+	progressWriter io.Writer
+	progressMu     sync.Mutex
+
+	// ResumeInstall continues an interrupted installation, skipping its completed phases (--resume)
+	ResumeInstall bool
+	// alwaysRunPhases are run again on resume, they set state the later phases use (the ingress class, the
+	// cluster IP, the values files) and are idempotent
+	alwaysRunPhases = []string{"prepare-cluster", "values"}
+)
+
+// ProgressEvent is one line of the --progress-format json event stream
+type ProgressEvent struct {
+	Time            time.Time `json:"time"`
+	Step            string    `json:"step"`
+	State           string    `json:"state"`
+	StartedAt       time.Time `json:"startedAt"`
+	DurationSeconds float64   `json:"durationSeconds,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// InstallPhase is a named step of an installation, the unit progress events are reported for
+type InstallPhase struct {
+	Name string
+	Run  func() error
+}
+
+// ValidateProgressFormat checks the value of the --progress-format flag and opens the --progress-file
+func ValidateProgressFormat() error {
+	switch strings.ToLower(ProgressFormat) {
+	case ProgressText:
+		ProgressFormat = ProgressText
+		return nil
+	case ProgressJSON:
+		ProgressFormat = ProgressJSON
+	default:
+		return fmt.Errorf("%w: invalid progress format %q, must be one of: %s, %s", ErrValidation, ProgressFormat, ProgressText, ProgressJSON)
+	}
+
+	progressWriter = os.Stdout
+	if ProgressFile != "" {
+		file, err := os.OpenFile(ProgressFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open progress file: %w", err)
+		}
+		progressWriter = file
+	}
+	return nil
+}
+
+// progressOnStdout reports whether stdout carries the progress events, log messages then go to stderr
+func progressOnStdout() bool {
+	return ProgressFormat == ProgressJSON && ProgressFile == ""
+}
+
+// EmitProgress writes a progress event, it is a no-op unless --progress-format json is set
+func EmitProgress(event ProgressEvent) {
+	if ProgressFormat != ProgressJSON || progressWriter == nil {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	fmt.Fprintln(progressWriter, string(data))
+}
+
+// RunInstallPhase runs a single phase and emits its started and succeeded or failed events, it doesn't start
+// once the command is interrupted. A phase completed by a resumed installation is skipped.
+func RunInstallPhase(name string, run func() error) error {
+	if err := Canceled(); err != nil {
+		return err
+	}
+	start := time.Now()
+	if resumedPhase(name) && !Contains(alwaysRunPhases, name) {
+		InfoMessage(fmt.Sprintf("Skipping %s, completed by the resumed installation", name))
+		EmitProgress(ProgressEvent{Time: start, Step: name, State: StepSkipped, StartedAt: start})
+		phaseFinished(name, nil)
+		return nil
+	}
+	EmitProgress(ProgressEvent{Time: start, Step: name, State: StepStarted, StartedAt: start})
+
+	phaseStarted(name)
+	err := run()
+	phaseFinished(name, err)
+	if err == nil {
+		recordCompletedPhase(name)
+	}
+
+	end := time.Now()
+	event := ProgressEvent{Time: end, Step: name, State: StepSucceeded, StartedAt: start, DurationSeconds: end.Sub(start).Seconds()}
+	if err != nil {
+		event.State = StepFailed
+		event.Error = SanitizeLog(err.Error())
+	}
+	EmitProgress(event)
+	return err
+}
+
+// RunInstallPhases runs the phases in order and stops at the first one that fails
+func RunInstallPhases(steps []InstallPhase) error {
+	for _, step := range steps {
+		if err := RunInstallPhase(step.Name, step.Run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GrplChartPhases returns the phases every provider installs Grapple with: grsf-init, grsf, grsf-config and
+// grsf-integration are deployed one after the other, each waiting for the previous one to be ready. The helm
+// and wait output only goes to the log file.
+func GrplChartPhases(kubeClient apiv1.Interface, restConfig *rest.Config, version string, valuesFiles []string, logOnFileStart, logOnCliAndFileStart func()) []InstallPhase {
+	deploy := func(release string) InstallPhase {
+		return InstallPhase{Name: "deploy-" + release, Run: func() error {
+			InfoMessage(fmt.Sprintf("Deploying '%s' chart...", release))
+			logOnFileStart()
+			err := HelmDeployGrplReleasesWithRetry(kubeClient, release, "grpl-system", version, valuesFiles)
+			logOnCliAndFileStart()
+			if err != nil {
+				return fmt.Errorf("failed to deploy %s: %w", release, err)
+			}
+			return nil
+		}}
+	}
+	wait := func(release, waiting, ready string, waitFn func() error) InstallPhase {
+		return InstallPhase{Name: "wait-" + release, Run: func() error {
+			InfoMessage(waiting)
+			logOnFileStart()
+			err := waitFn()
+			logOnCliAndFileStart()
+			if err != nil {
+				return fmt.Errorf("%s not ready: %w", release, err)
+			}
+			SuccessMessage(ready)
+			return nil
+		}}
+	}
+
+	return []InstallPhase{
+		deploy("grsf-init"),
+		wait("grsf-init", "Waiting for grsf-init to be ready...", "grsf-init is installed and ready.", func() error {
+			return WaitForGrsfInit(kubeClient, restConfig)
+		}),
+		deploy("grsf"),
+		wait("grsf", "Waiting for grsf to be ready (checking crossplane providers, etc.)...", "grsf is installed and ready.", func() error {
+			return WaitForGrsf(kubeClient, restConfig, "grpl-system")
+		}),
+		deploy("grsf-config"),
+		wait("grsf-config", "Waiting for grsf-config to be applied (CRDs, XRDs, etc.)...", "grsf-config is installed.", func() error {
+			return WaitForGrsfConfig(kubeClient, restConfig)
+		}),
+		deploy("grsf-integration"),
+		wait("grsf-integration", "Waiting for grsf-integration to be ready...", "grsf-integration is installed.", func() error {
+			return WaitForGrsfIntegration(restConfig)
+		}),
+	}
+}
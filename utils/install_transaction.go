@@ -0,0 +1,313 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// The transaction is stored outside of grpl-system, which is one of the namespaces it may remove
+	installTransactionNamespace = "kube-system"
+	installTransactionConfigMap = "grpl-install-transaction"
+	installTransactionKey       = "transaction"
+
+	InstallStatusInProgress = "in-progress"
+	InstallStatusCompleted  = "completed"
+	InstallStatusFailed     = "failed"
+	InstallStatusRolledBack = "rolled-back"
+
+	installStepNamespace     = "namespace"
+	installStepRelease       = "release"
+	installStepPriorityClass = "priorityclass"
+)
+
+// InstallStep is an object created by an installation
+type InstallStep struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// InstallTransaction records the namespaces and releases an installation created, so a failed
+// installation can be rolled back to the state before it started
+type InstallTransaction struct {
+	Provider  string        `json:"provider"`
+	Cluster   string        `json:"cluster"`
+	Version   string        `json:"version"`
+	StartedAt time.Time     `json:"startedAt"`
+	Status    string        `json:"status"`
+	Steps     []InstallStep `json:"steps"`
+	// CompletedPhases are the install phases that succeeded, a run with --resume skips them
+	CompletedPhases []string `json:"completedPhases,omitempty"`
+
+	kubeClient apiv1.Interface
+	restConfig *rest.Config
+}
+
+var (
+	// activeInstall is the transaction of the running installation, nil outside of installations
+	activeInstall *InstallTransaction
+	// installMu guards activeInstall, steps are recorded from background installs as well (e.g. KubeBlocks)
+	installMu sync.Mutex
+)
+
+// BeginInstallTransaction starts recording the objects created by the installation. With --resume, an
+// interrupted or failed installation of the same cluster and version is continued: its objects stay recorded
+// and its completed phases are skipped.
+func BeginInstallTransaction(kubeClient apiv1.Interface, restConfig *rest.Config, provider, cluster, version string) error {
+	tx := &InstallTransaction{
+		Provider:   provider,
+		Cluster:    cluster,
+		Version:    version,
+		StartedAt:  time.Now().UTC(),
+		Status:     InstallStatusInProgress,
+		Steps:      []InstallStep{},
+		kubeClient: kubeClient,
+		restConfig: restConfig,
+	}
+	if ResumeInstall {
+		if err := resumeInstallTransaction(tx); err != nil {
+			return err
+		}
+	}
+	if err := tx.save(); err != nil {
+		return err
+	}
+	installMu.Lock()
+	activeInstall = tx
+	installMu.Unlock()
+	EmitProgress(ProgressEvent{Time: tx.StartedAt, Step: "install", State: StepStarted, StartedAt: tx.StartedAt})
+	return nil
+}
+
+// FinishInstallTransaction marks the running installation as completed or failed, a failed
+// installation is rolled back right away when rollback is set
+func FinishInstallTransaction(installErr error, rollback bool) {
+	installMu.Lock()
+	tx := activeInstall
+	activeInstall = nil
+	installMu.Unlock()
+	if tx == nil {
+		return
+	}
+	emitInstallFinished(tx, installErr)
+
+	if installErr == nil {
+		tx.Status = InstallStatusCompleted
+		if err := tx.save(); err != nil {
+			ErrorMessage(fmt.Sprintf("Failed to record installation: %v", err))
+		}
+		return
+	}
+
+	tx.Status = InstallStatusFailed
+	if err := tx.save(); err != nil {
+		ErrorMessage(fmt.Sprintf("Failed to record installation: %v", err))
+	}
+	if IsCanceled(installErr) {
+		// An interrupted install is never rolled back without asking, it may just be continued
+		printInterruptedInstall(tx)
+		return
+	}
+	if len(tx.Steps) == 0 {
+		return
+	}
+	if !rollback {
+		InfoMessage("The cluster was left as is, re-run the install with --resume to continue from the failed phase, or run 'grapple install rollback' to remove what this installation created (--rollback-on-failure does so right away)")
+		return
+	}
+
+	InfoMessage("Rolling back the failed installation...")
+	if err := RollbackInstallTransaction(tx, tx.kubeClient, tx.restConfig); err != nil {
+		ErrorMessage(fmt.Sprintf("Rollback failed: %v, run 'grapple install rollback' to retry", err))
+	}
+}
+
+// recordInstallStep adds a created object to the running installation, if any
+func recordInstallStep(kind, name, namespace string) {
+	installMu.Lock()
+	defer installMu.Unlock()
+	tx := activeInstall
+	if tx == nil {
+		return
+	}
+	for _, step := range tx.Steps {
+		if step.Kind == kind && step.Name == name && step.Namespace == namespace {
+			return
+		}
+	}
+	tx.Steps = append(tx.Steps, InstallStep{Kind: kind, Name: name, Namespace: namespace})
+	if err := tx.save(); err != nil {
+		ErrorMessage(fmt.Sprintf("Failed to record %s %s: %v", kind, name, err))
+	}
+}
+
+// resumeInstallTransaction continues the last installation in tx, if it was interrupted or failed without
+// being rolled back
+func resumeInstallTransaction(tx *InstallTransaction) error {
+	last, err := LoadInstallTransaction(tx.kubeClient)
+	if err != nil {
+		return err
+	}
+	if last == nil || (last.Status != InstallStatusInProgress && last.Status != InstallStatusFailed) {
+		InfoMessage("No interrupted installation to resume, installing from the start")
+		return nil
+	}
+	if last.Provider != tx.Provider || last.Cluster != tx.Cluster || last.Version != tx.Version {
+		return fmt.Errorf("%w: the interrupted installation is version %s on %s cluster %s, re-run with the same settings or without --resume",
+			ErrValidation, last.Version, last.Provider, last.Cluster)
+	}
+
+	tx.Steps = last.Steps
+	tx.CompletedPhases = last.CompletedPhases
+	InfoMessage(fmt.Sprintf("Resuming the installation started at %s", last.StartedAt.Local().Format("2006-01-02 15:04:05")))
+	if len(tx.CompletedPhases) > 0 {
+		InfoMessage(fmt.Sprintf("  completed phases: %s", strings.Join(tx.CompletedPhases, ", ")))
+	}
+	return nil
+}
+
+// resumedPhase reports whether a phase was completed by the installation that is resumed
+func resumedPhase(name string) bool {
+	installMu.Lock()
+	defer installMu.Unlock()
+	return activeInstall != nil && Contains(activeInstall.CompletedPhases, name)
+}
+
+// recordCompletedPhase adds a phase that succeeded to the running installation, if any
+func recordCompletedPhase(name string) {
+	installMu.Lock()
+	defer installMu.Unlock()
+	tx := activeInstall
+	if tx == nil || Contains(tx.CompletedPhases, name) {
+		return
+	}
+	tx.CompletedPhases = append(tx.CompletedPhases, name)
+	if err := tx.save(); err != nil {
+		ErrorMessage(fmt.Sprintf("Failed to record phase %s: %v", name, err))
+	}
+}
+
+// recordNamespaceIfMissing records a namespace that is about to be created by helm (CreateNamespace)
+func recordNamespaceIfMissing(namespace string) {
+	installMu.Lock()
+	tx := activeInstall
+	installMu.Unlock()
+	if tx == nil {
+		return
+	}
+	if _, err := tx.kubeClient.CoreV1().Namespaces().Get(context.TODO(), namespace, v1.GetOptions{}); errors.IsNotFound(err) {
+		recordInstallStep(installStepNamespace, namespace, "")
+	}
+}
+
+// LoadInstallTransaction returns the transaction of the last installation, or nil if there is none
+func LoadInstallTransaction(kubeClient apiv1.Interface) (*InstallTransaction, error) {
+	cm, err := kubeClient.CoreV1().ConfigMaps(installTransactionNamespace).Get(context.TODO(), installTransactionConfigMap, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get installation record: %w", err)
+	}
+
+	var tx InstallTransaction
+	if err := json.Unmarshal([]byte(cm.Data[installTransactionKey]), &tx); err != nil {
+		return nil, fmt.Errorf("failed to parse installation record: %w", err)
+	}
+	return &tx, nil
+}
+
+// RollbackInstallTransaction removes the objects of the transaction in reverse order of creation
+func RollbackInstallTransaction(tx *InstallTransaction, kubeClient apiv1.Interface, restConfig *rest.Config) error {
+	tx.kubeClient = kubeClient
+	tx.restConfig = restConfig
+
+	for i := len(tx.Steps) - 1; i >= 0; i-- {
+		step := tx.Steps[i]
+		switch step.Kind {
+		case installStepRelease:
+			InfoMessage(fmt.Sprintf("Uninstalling release %s/%s...", step.Namespace, step.Name))
+			helmConfig, err := GetHelmConfig(restConfig, step.Namespace)
+			if err != nil {
+				return err
+			}
+			if _, err := action.NewUninstall(helmConfig).Run(step.Name); err != nil && err != driver.ErrReleaseNotFound {
+				return fmt.Errorf("failed to uninstall release %s: %w", step.Name, err)
+			}
+		case installStepNamespace:
+			InfoMessage(fmt.Sprintf("Deleting namespace %s...", step.Name))
+			err := kubeClient.CoreV1().Namespaces().Delete(context.TODO(), step.Name, v1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete namespace %s: %w", step.Name, err)
+			}
+		case installStepPriorityClass:
+			InfoMessage(fmt.Sprintf("Deleting PriorityClass %s...", step.Name))
+			err := kubeClient.SchedulingV1().PriorityClasses().Delete(context.TODO(), step.Name, v1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete PriorityClass %s: %w", step.Name, err)
+			}
+		}
+		tx.Steps = tx.Steps[:i]
+		if err := tx.save(); err != nil {
+			return err
+		}
+	}
+
+	tx.Status = InstallStatusRolledBack
+	if err := tx.save(); err != nil {
+		return err
+	}
+	SuccessMessage("Installation rolled back")
+	return nil
+}
+
+// save stores the transaction in its ConfigMap
+func (tx *InstallTransaction) save() error {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal installation record: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      installTransactionConfigMap,
+			Namespace: installTransactionNamespace,
+		},
+		Data: map[string]string{installTransactionKey: string(data)},
+	}
+	ApplyCommonMetadata(&cm.ObjectMeta)
+
+	configMaps := tx.kubeClient.CoreV1().ConfigMaps(installTransactionNamespace)
+	_, err = configMaps.Update(context.TODO(), cm, v1.UpdateOptions{})
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(context.TODO(), cm, v1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store installation record: %w", err)
+	}
+	return nil
+}
+
+// emitInstallFinished emits the progress event that ends the event stream of an installation
+func emitInstallFinished(tx *InstallTransaction, installErr error) {
+	now := time.Now().UTC()
+	event := ProgressEvent{Time: now, Step: "install", State: StepSucceeded, StartedAt: tx.StartedAt, DurationSeconds: now.Sub(tx.StartedAt).Seconds()}
+	if installErr != nil {
+		event.State = StepFailed
+		event.Error = SanitizeLog(installErr.Error())
+	}
+	EmitProgress(event)
+}
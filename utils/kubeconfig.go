@@ -0,0 +1,369 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// kubeconfigBackups is the number of kubeconfig backups kept next to the kubeconfig
+const kubeconfigBackups = 5
+
+// AnnotationSwitchesKubeContext marks the commands meant to switch the current context of the kubeconfig
+// (connect, create, cluster use): the context they switch to is kept. Other commands switch it back at the end.
+const AnnotationSwitchesKubeContext = "grpl/switches-kube-context"
+
+// startKubeContext is the current context of the kubeconfig when the command started, see RememberKubeContext
+var (
+	startKubeContext      string
+	startKubeContextKnown bool
+)
+
+// KubeContext is the kube-context of --kube-context, "" is the current context of the kubeconfig
+var KubeContext string
+
+// Kubeconfig is the kubeconfig file of --kubeconfig, "" is $KUBECONFIG (a list of files) or ~/.kube/config
+var Kubeconfig string
+
+// KubeContextInfo is a context of the kubeconfig
+type KubeContextInfo struct {
+	Name      string `json:"name" yaml:"name"`
+	Cluster   string `json:"cluster" yaml:"cluster"`
+	Server    string `json:"server" yaml:"server"`
+	User      string `json:"user" yaml:"user"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Current   bool   `json:"current" yaml:"current"`
+}
+
+// KubeconfigLoadingRules returns the loading rules of the kubeconfig: the file of --kubeconfig, the files of
+// $KUBECONFIG merged in order, or ~/.kube/config
+func KubeconfigLoadingRules() *clientcmd.ClientConfigLoadingRules {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = Kubeconfig
+	return rules
+}
+
+// kubeconfigAccess returns the access of clientcmd.ModifyConfig, which writes changed entries back to the file
+// of the kubeconfig list that defines them and new entries to KubeconfigPath
+func kubeconfigAccess() clientcmd.ConfigAccess {
+	access := clientcmd.NewDefaultPathOptions()
+	access.LoadingRules = KubeconfigLoadingRules()
+	return access
+}
+
+// KubeconfigPath returns the kubeconfig file the CLI writes new clusters to: --kubeconfig, the first file of
+// $KUBECONFIG or ~/.kube/config
+func KubeconfigPath() (string, error) {
+	access := kubeconfigAccess()
+	if access.IsExplicitFile() {
+		return access.GetExplicitFile(), nil
+	}
+	path := access.GetDefaultFilename()
+	if path == "" {
+		return "", fmt.Errorf("failed to determine the kubeconfig file")
+	}
+	return path, nil
+}
+
+// kubeconfigFiles returns the existing files of the kubeconfig
+func kubeconfigFiles() []string {
+	var files []string
+	for _, file := range kubeconfigAccess().GetLoadingPrecedence() {
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// loadKubeconfig reads the kubeconfig merged from all its files, no file is an empty config
+func loadKubeconfig() (*clientcmdapi.Config, error) {
+	config, err := kubeconfigAccess().GetStartingConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	return config, nil
+}
+
+// currentKubeContext returns the context the CLI works on, --kube-context or the current context
+func currentKubeContext(config *clientcmdapi.Config) string {
+	if KubeContext != "" {
+		return KubeContext
+	}
+	return config.CurrentContext
+}
+
+// ListKubeContexts returns the contexts of the kubeconfig sorted by name
+func ListKubeContexts() ([]KubeContextInfo, error) {
+	config, err := loadKubeconfig()
+	if err != nil {
+		return nil, err
+	}
+	current := currentKubeContext(config)
+	var contexts []KubeContextInfo
+	for name, kubeContext := range config.Contexts {
+		info := KubeContextInfo{
+			Name:      name,
+			Cluster:   kubeContext.Cluster,
+			User:      kubeContext.AuthInfo,
+			Namespace: kubeContext.Namespace,
+			Current:   name == current,
+		}
+		if cluster, ok := config.Clusters[kubeContext.Cluster]; ok {
+			info.Server = cluster.Server
+		}
+		contexts = append(contexts, info)
+	}
+	sort.Slice(contexts, func(i, j int) bool { return contexts[i].Name < contexts[j].Name })
+	return contexts, nil
+}
+
+// CurrentKubeContext returns the context the CLI works on, --kube-context or the current context of the kubeconfig
+func CurrentKubeContext() (string, error) {
+	config, err := loadKubeconfig()
+	if err != nil {
+		return "", err
+	}
+	return currentKubeContext(config), nil
+}
+
+// UseKubeContext makes name the current context of the kubeconfig
+func UseKubeContext(name string) error {
+	config, err := loadKubeconfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := config.Contexts[name]; !ok {
+		return fmt.Errorf("%w: context %s not found in the kubeconfig", ErrValidation, name)
+	}
+	if config.CurrentContext == name {
+		return nil
+	}
+	config.CurrentContext = name
+	return writeKubeconfig(config)
+}
+
+// MergeKubeconfig merges the clusters, users and contexts of a provider kubeconfig into the kubeconfig, entries
+// of the same name are replaced. The current context is switched to switchTo, if it is set. The kubeconfig is
+// backed up before it is written.
+func MergeKubeconfig(newConfig *clientcmdapi.Config, switchTo string) error {
+	config, err := loadKubeconfig()
+	if err != nil {
+		return err
+	}
+	for name, cluster := range newConfig.Clusters {
+		config.Clusters[name] = cluster
+	}
+	for name, authInfo := range newConfig.AuthInfos {
+		config.AuthInfos[name] = authInfo
+	}
+	for name, kubeContext := range newConfig.Contexts {
+		config.Contexts[name] = kubeContext
+	}
+	if switchTo != "" {
+		if _, ok := config.Contexts[switchTo]; !ok {
+			return fmt.Errorf("context %s is not part of the merged kubeconfig", switchTo)
+		}
+		config.CurrentContext = switchTo
+	}
+	if err := writeKubeconfig(config); err != nil {
+		return err
+	}
+	if switchTo != "" {
+		PinKubeContext(switchTo)
+	}
+	return nil
+}
+
+// PinKubeContext makes the rest of the command work on a context, whatever the current context of the
+// kubeconfig is, e.g. on the cluster a provider command just connected to
+func PinKubeContext(name string) {
+	KubeContext = name
+	// helm reads the kube-context of its actions from HELM_KUBECONTEXT
+	os.Setenv("HELM_KUBECONTEXT", name)
+}
+
+// RememberKubeContext records the current context of the kubeconfig at the start of a command, for
+// RestoreKubeContext
+func RememberKubeContext() {
+	config, err := loadKubeconfig()
+	if err != nil {
+		return
+	}
+	startKubeContext, startKubeContextKnown = config.CurrentContext, true
+}
+
+// RestoreKubeContext runs at the end of a command: if the command changed the current context of the kubeconfig,
+// it is switched back to the context of the start, unless keep is set (or there was none). The final context
+// is reported either way.
+func RestoreKubeContext(keep bool) {
+	if !startKubeContextKnown {
+		return
+	}
+	config, err := loadKubeconfig()
+	if err != nil || config.CurrentContext == startKubeContext {
+		return
+	}
+	final := config.CurrentContext
+	if startKubeContext == "" {
+		InfoMessage(fmt.Sprintf("The current kubeconfig context is now %s", final))
+		return
+	}
+	if keep {
+		InfoMessage(fmt.Sprintf("The current kubeconfig context is now %s (was %s)", final, startKubeContext))
+		return
+	}
+	if _, ok := config.Contexts[startKubeContext]; !ok {
+		InfoMessage(fmt.Sprintf("The current kubeconfig context is now %s, the previous context %s no longer exists", final, startKubeContext))
+		return
+	}
+	config.CurrentContext = startKubeContext
+	if err := writeKubeconfig(config); err != nil {
+		ErrorMessage(fmt.Sprintf("Failed to switch the kubeconfig context back to %s, it is still %s: %v", startKubeContext, final, err))
+		return
+	}
+	InfoMessage(fmt.Sprintf("Switched the kubeconfig context back to %s, the command worked on %s ('grapple cluster use %s' switches to it)", startKubeContext, final, final))
+}
+
+// BackupKubeconfig copies each file of the kubeconfig to <file>.grpl-backup-<time> and removes all but the
+// newest backups, it returns the paths of the backups (none if there is no kubeconfig yet)
+func BackupKubeconfig() ([]string, error) {
+	stamp := time.Now().UTC().Format("20060102T150405.000")
+	var backups []string
+	for _, file := range kubeconfigFiles() {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubeconfig %s: %w", file, err)
+		}
+		backup := fmt.Sprintf("%s.grpl-backup-%s", file, stamp)
+		if err := os.WriteFile(backup, data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to back up kubeconfig: %w", err)
+		}
+		backups = append(backups, backup)
+
+		previous, _ := filepath.Glob(file + ".grpl-backup-*")
+		sort.Strings(previous)
+		for len(previous) > kubeconfigBackups {
+			_ = os.Remove(previous[0])
+			previous = previous[1:]
+		}
+	}
+	return backups, nil
+}
+
+// writeKubeconfig backs up the kubeconfig and writes config to its files
+func writeKubeconfig(config *clientcmdapi.Config) error {
+	path, err := KubeconfigPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	backups, err := BackupKubeconfig()
+	if err != nil {
+		return err
+	}
+	if err := clientcmd.ModifyConfig(kubeconfigAccess(), *config, false); err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to restrict the permissions of %s: %w", path, err)
+	}
+	if len(backups) > 0 {
+		InfoMessage(fmt.Sprintf("Updated the kubeconfig, the previous version is in %s", strings.Join(backups, ", ")))
+	}
+	return nil
+}
+
+// KubeContextRESTConfig returns the rest config of a context of the kubeconfig, "" is the current context
+func KubeContextRESTConfig(name string) (*rest.Config, error) {
+	if len(kubeconfigFiles()) == 0 {
+		return nil, fmt.Errorf("%w: kubeconfig not found at %s", ErrClusterUnreachable, strings.Join(kubeconfigAccess().GetLoadingPrecedence(), ", "))
+	}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		KubeconfigLoadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: name}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to build REST config: %w", ErrClusterUnreachable, err)
+	}
+	// Behind a bastion, the API server is reached through the SSH tunnel
+	if err := TunnelRESTConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ValidateKubeContext checks that the cluster of a context answers and the user may list namespaces, it
+// returns the Kubernetes version of the cluster
+func ValidateKubeContext(name string) (string, error) {
+	config, err := KubeContextRESTConfig(name)
+	if err != nil {
+		return "", err
+	}
+	config.Timeout = 15 * time.Second
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Kubernetes clientset: %w", err)
+	}
+	version, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrClusterUnreachable, err)
+	}
+	if _, err := client.CoreV1().Namespaces().List(context.TODO(), v1.ListOptions{Limit: 1}); err != nil {
+		if strings.Contains(err.Error(), "forbidden") {
+			return version.GitVersion, fmt.Errorf("the cluster answers, but the user of context %s may not list namespaces: %w", name, err)
+		}
+		return "", fmt.Errorf("%w: %w", ErrClusterUnreachable, err)
+	}
+	return version.GitVersion, nil
+}
+
+// KubeContextNamespace returns the namespace of the context the CLI works on, "default" if it sets none
+func KubeContextNamespace() string {
+	namespace, _, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		KubeconfigLoadingRules(), &clientcmd.ConfigOverrides{CurrentContext: KubeContext}).Namespace()
+	if err != nil || namespace == "" {
+		return "default"
+	}
+	return namespace
+}
+
+// WaitForClusterAPI waits until the API server of a new cluster answers to the kubeconfig of its provider, which
+// lags behind the cluster being reported ready. kubeconfig is called on every attempt.
+func WaitForClusterAPI(kubeconfig func() ([]byte, error), timeout time.Duration) error {
+	return Poll(timeout, func() (bool, error) {
+		data, err := kubeconfig()
+		if err != nil || len(data) == 0 {
+			DebugMessage(fmt.Sprintf("The kubeconfig of the cluster is not available yet: %v", err))
+			return false, nil
+		}
+		config, err := clientcmd.RESTConfigFromKubeConfig(data)
+		if err != nil {
+			return false, fmt.Errorf("invalid kubeconfig of the cluster: %w", err)
+		}
+		if err := TunnelRESTConfig(config); err != nil {
+			return false, err
+		}
+		config.Timeout = 10 * time.Second
+		client, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return false, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
+		}
+		if _, err := client.Discovery().ServerVersion(); err != nil {
+			DebugMessage(fmt.Sprintf("The API server of the cluster does not answer yet: %v", err))
+			return false, nil
+		}
+		return true, nil
+	})
+}
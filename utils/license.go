@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// FreeLicense is the license of installations without a license key
+	FreeLicense = "free"
+	// DefaultLicenseAPIURL is the licensing API keys are validated against, overridden by 'grapple config set license-api'
+	DefaultLicenseAPIURL = "https://license.grapple-solutions.com/api/v1"
+
+	// licenseSecretKey is the key of the license in the grsf-config secret, the installers read it on reinstalls
+	licenseSecretKey = "LIC"
+	licenseTimeout   = 15 * time.Second
+)
+
+// License is the license of a cluster
+type License struct {
+	Key      string     `json:"key" yaml:"key"`
+	Tier     string     `json:"tier" yaml:"tier"`
+	Expires  *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+	Features []string   `json:"features,omitempty" yaml:"features,omitempty"`
+	// Verified is false when the licensing API could not be reached and the stored tier and expiry are shown
+	Verified bool `json:"verified" yaml:"verified"`
+}
+
+// Expired reports whether the license has an expiry date in the past
+func (l *License) Expired() bool {
+	return l.Expires != nil && l.Expires.Before(time.Now())
+}
+
+// licenseAPIURL returns the licensing API of 'grapple config set license-api' or GRPL_LICENSE_API
+func licenseAPIURL() string {
+	if url := ConfigValue("license-api"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return DefaultLicenseAPIURL
+}
+
+// ValidateLicense checks a license key against the licensing API and returns its tier, expiry and features. A
+// key the API rejects is an ErrValidation.
+func ValidateLicense(key string) (*License, error) {
+	if key == "" || key == FreeLicense {
+		return &License{Key: FreeLicense, Tier: FreeLicense, Verified: true}, nil
+	}
+
+	var response struct {
+		Valid     bool     `json:"valid"`
+		Reason    string   `json:"reason"`
+		Tier      string   `json:"tier"`
+		ExpiresAt string   `json:"expiresAt"`
+		Features  []string `json:"features"`
+	}
+	status, err := postLicenseAPI("/licenses/validate", key, &response)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound || !response.Valid {
+		reason := response.Reason
+		if reason == "" {
+			reason = "unknown license key"
+		}
+		return nil, fmt.Errorf("%w: license %s is not valid: %s", ErrValidation, MaskSecret(key), reason)
+	}
+
+	license := &License{Key: key, Tier: response.Tier, Features: response.Features, Verified: true}
+	if response.ExpiresAt != "" {
+		expires, err := time.Parse(time.RFC3339, response.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry %q of the licensing API: %w", response.ExpiresAt, err)
+		}
+		license.Expires = &expires
+	}
+	if license.Expired() {
+		return nil, fmt.Errorf("%w: license %s expired on %s", ErrValidation, MaskSecret(key), license.Expires.Format("2006-01-02"))
+	}
+	return license, nil
+}
+
+// ReleaseLicense tells the licensing API the key is no longer used by the cluster
+func ReleaseLicense(key string) error {
+	if key == "" || key == FreeLicense {
+		return nil
+	}
+	if _, err := postLicenseAPI("/licenses/deactivate", key, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// postLicenseAPI posts the key to the licensing API, the response is decoded into result unless it is nil
+func postLicenseAPI(path, key string, result interface{}) (int, error) {
+	body, err := json.Marshal(map[string]string{"key": key})
+	if err != nil {
+		return 0, err
+	}
+	client := &http.Client{Timeout: licenseTimeout}
+	resp, err := client.Post(licenseAPIURL()+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach the licensing API %s: %w", licenseAPIURL(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return resp.StatusCode, fmt.Errorf("licensing API %s returned %s", licenseAPIURL(), resp.Status)
+	}
+	if result != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to parse the response of the licensing API: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// GetClusterLicense returns the license stored in the grsf-config secret, with the tier and expiry recorded at
+// activation. Installations without a license key have the free license.
+func GetClusterLicense(kubeClient apiv1.Interface) (*License, error) {
+	secret, err := kubeClient.CoreV1().Secrets("grpl-system").Get(context.TODO(), "grsf-config", v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grsf-config: %w", err)
+	}
+	key := string(secret.Data[licenseSecretKey])
+	if key == "" {
+		key = string(secret.Data[SecKeyGrapleLicense])
+	}
+	if key == "" || key == FreeLicense {
+		return &License{Key: FreeLicense, Tier: FreeLicense}, nil
+	}
+
+	license := &License{Key: key, Tier: string(secret.Data[SecKeyLicenseTier])}
+	if expires, err := time.Parse(time.RFC3339, string(secret.Data[SecKeyLicenseExpires])); err == nil {
+		license.Expires = &expires
+	}
+	return license, nil
+}
+
+// SetClusterLicense stores the license in the grsf-config secret
+func SetClusterLicense(kubeClient apiv1.Interface, license *License) error {
+	secrets := kubeClient.CoreV1().Secrets("grpl-system")
+	secret, err := secrets.Get(context.TODO(), "grsf-config", v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get grsf-config: %w", err)
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[licenseSecretKey] = []byte(license.Key)
+	secret.Data[SecKeyGrapleLicense] = []byte(license.Key)
+	secret.Data[SecKeyLicenseTier] = []byte(license.Tier)
+	delete(secret.Data, SecKeyLicenseExpires)
+	if license.Expires != nil {
+		secret.Data[SecKeyLicenseExpires] = []byte(license.Expires.Format(time.RFC3339))
+	}
+	if _, err := secrets.Update(context.TODO(), secret, v1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update grsf-config: %w", err)
+	}
+	return nil
+}
+
+// RenderLicenseConfig upgrades the grsf-config release with the license at its installed version, so the
+// configuration derived from the license (e.g. the GRUIM feature flags) is rendered again
+func RenderLicenseConfig(kubeClient apiv1.Interface, restConfig *rest.Config, key string) error {
+	if err := RenderGrsfConfig(kubeClient, restConfig, map[string]interface{}{SecKeyGrapleLicense: key}); err != nil {
+		return fmt.Errorf("failed to render grsf-config with the license: %w", err)
+	}
+	return nil
+}
+
+// RenderGrsfConfig upgrades the grsf-config release at its installed version with config set in the config
+// section of its values, so the secret and everything derived from it are rendered by the chart
+func RenderGrsfConfig(kubeClient apiv1.Interface, restConfig *rest.Config, config map[string]interface{}) error {
+	version, err := GetGrplReleaseVersion(restConfig, "grsf-config", "grpl-system")
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		return fmt.Errorf("grsf-config is not installed on this cluster")
+	}
+
+	valuesFile, err := WriteGrplReleaseValues(restConfig, "grsf-config", "grpl-system", version)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(valuesFile)
+
+	data, err := yaml.Marshal(map[string]interface{}{"config": config})
+	if err != nil {
+		return err
+	}
+	configFile, err := os.CreateTemp("", "grpl-config-values-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create values file: %w", err)
+	}
+	defer os.Remove(configFile.Name())
+	defer configFile.Close()
+	if _, err := configFile.Write(data); err != nil {
+		return fmt.Errorf("failed to write values file: %w", err)
+	}
+
+	if err := HelmDeployGrplReleasesWithRetry(kubeClient, "grsf-config", "grpl-system", version, []string{valuesFile, configFile.Name()}); err != nil {
+		return err
+	}
+	return WaitForGrsfConfig(kubeClient, restConfig)
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	// LocalCACertFile and LocalCAKeyFile are the file names mkcert uses, so a CA created by mkcert is reused
+	LocalCACertFile = "rootCA.pem"
+	LocalCAKeyFile  = "rootCA-key.pem"
+
+	localCAName = "grapple development CA"
+)
+
+// linuxTrustStores are the anchor directories of the Linux distributions and the command that rebuilds the
+// system bundle from them
+var linuxTrustStores = []struct {
+	dir     string
+	command []string
+}{
+	{"/usr/local/share/ca-certificates", []string{"update-ca-certificates"}},           // Debian, Ubuntu, Alpine
+	{"/etc/pki/ca-trust/source/anchors", []string{"update-ca-trust", "extract"}},       // Fedora, RHEL
+	{"/etc/ca-certificates/trust-source/anchors", []string{"trust", "extract-compat"}}, // Arch
+	{"/usr/share/pki/trust/anchors", []string{"update-ca-certificates"}},               // openSUSE
+}
+
+// LocalCADir returns the directory of the local CA of k3d clusters, the CAROOT of mkcert: $CAROOT, or
+// mkcert's default of the OS
+func LocalCADir() (string, error) {
+	if dir := os.Getenv("CAROOT"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "mkcert"), nil
+	case "windows":
+		return filepath.Join(os.Getenv("LocalAppData"), "mkcert"), nil
+	}
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "mkcert"), nil
+	}
+	return filepath.Join(home, ".local", "share", "mkcert"), nil
+}
+
+// CreateLocalCA generates a root CA (RSA 3072, valid for 10 years) in dir, in the format of mkcert
+func CreateLocalCA(dir string) error {
+	key, err := rsa.GenerateKey(rand.Reader, 3072)
+	if err != nil {
+		return fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode CA key: %w", err)
+	}
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to encode CA public key: %w", err)
+	}
+	keyID := sha1.Sum(publicKeyDER)
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	owner := localCAOwner()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization:       []string{localCAName},
+			OrganizationalUnit: []string{owner},
+			CommonName:         fmt.Sprintf("grapple %s", owner),
+		},
+		SubjectKeyId:          keyID[:],
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(filepath.Join(dir, LocalCAKeyFile), keyPEM, 0400); err != nil {
+		return fmt.Errorf("failed to write CA key: %w", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	if err := os.WriteFile(filepath.Join(dir, LocalCACertFile), certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write CA certificate: %w", err)
+	}
+	return nil
+}
+
+// localCAOwner returns user@host, which identifies the CA in the trust stores
+func localCAOwner() string {
+	owner := "grapple"
+	if u, err := user.Current(); err == nil {
+		owner = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		owner += "@" + host
+	}
+	return owner
+}
+
+// InstallLocalCATrust adds the CA certificate to the trust store of the OS, and on Linux to the NSS databases of
+// Chrome and Firefox when certutil is installed. Administrator rights are requested with sudo where needed.
+func InstallLocalCATrust(certPath string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		cmd := exec.Command("sudo", "security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", "/Library/Keychains/System.keychain", certPath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to add the CA to the system keychain: %w: %s", err, output)
+		}
+		return nil
+	case "windows":
+		// The user store needs no administrator rights
+		cmd := exec.Command("certutil", "-user", "-addstore", "-f", "Root", certPath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to add the CA to the certificate store: %w: %s", err, output)
+		}
+		return nil
+	}
+
+	if err := installLinuxTrust(certPath); err != nil {
+		return err
+	}
+	installNSSTrust(certPath)
+	return nil
+}
+
+// installLinuxTrust copies the CA into the anchors of the first trust store found and rebuilds the bundle
+func installLinuxTrust(certPath string) error {
+	for _, store := range linuxTrustStores {
+		if info, err := os.Stat(store.dir); err != nil || !info.IsDir() {
+			continue
+		}
+		if _, err := exec.LookPath(store.command[0]); err != nil {
+			continue
+		}
+		anchor := filepath.Join(store.dir, "grapple-local-ca.crt")
+		if err := exec.Command("sudo", "cp", certPath, anchor).Run(); err != nil {
+			return fmt.Errorf("failed to copy the CA to %s: %w", store.dir, err)
+		}
+		if output, err := exec.Command("sudo", store.command...).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to run %s: %w: %s", store.command[0], err, output)
+		}
+		return nil
+	}
+	return fmt.Errorf("no supported system trust store found, add %s to the trust store of your system manually", certPath)
+}
+
+// installNSSTrust adds the CA to the NSS databases of Chrome (~/.pki/nssdb) and the Firefox profiles, which
+// don't use the system trust store. Without certutil (libnss3-tools, nss-tools) they are skipped.
+func installNSSTrust(certPath string) {
+	if _, err := exec.LookPath("certutil"); err != nil {
+		InfoMessage("certutil not found, browsers using their own trust store (Firefox, Chrome) won't trust the local CA; install libnss3-tools or nss-tools and add " + certPath + " with certutil")
+		return
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	databases := []string{filepath.Join(home, ".pki", "nssdb")}
+	profiles, _ := filepath.Glob(filepath.Join(home, ".mozilla", "firefox", "*"))
+	databases = append(databases, profiles...)
+	for _, db := range databases {
+		if _, err := os.Stat(filepath.Join(db, "cert9.db")); err != nil {
+			continue
+		}
+		cmd := exec.Command("certutil", "-A", "-d", "sql:"+db, "-t", "C,,", "-n", fmt.Sprintf("%s %s", localCAName, localCAOwner()), "-i", certPath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			InfoMessage(fmt.Sprintf("Failed to add the local CA to %s: %v: %s", db, err, output))
+		}
+	}
+}
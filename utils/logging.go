@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logRetention is the number of log files kept per command, older runs are removed
+const logRetention = 20
+
+// Verbose shows debug messages on the terminal (--verbose), Quiet only errors (--quiet). The log file of a
+// command always receives every level.
+var (
+	Verbose bool
+	Quiet   bool
+)
+
+// cliLog writes the messages of the CLI to the terminal and to the log file of the command. Both sinks are
+// guarded by one mutex, so background tasks (e.g. the KubeBlocks install) can log while the main flow does
+// without interleaving lines or racing on the standard logger.
+type cliLog struct {
+	mu sync.Mutex
+	// file is the structured log of the command, nil until GetLogWriters opened it
+	file *slog.Logger
+	// toCLI is false while an install step logs to the file only, e.g. during noisy helm installs
+	toCLI bool
+}
+
+var logger = &cliLog{toCLI: true}
+
+// ansiColors matches the color codes of messages, they are kept out of the log file
+var ansiColors = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func init() {
+	// Code and libraries logging through the standard logger (e.g. the helm actions) go through cliLog too
+	log.SetFlags(0)
+	log.SetOutput(stdLogWriter{})
+}
+
+// stdLogWriter routes the standard logger to cliLog at info level
+type stdLogWriter struct{}
+
+func (stdLogWriter) Write(p []byte) (int, error) {
+	logger.write(slog.LevelInfo, "", string(p), false)
+	return len(p), nil
+}
+
+// SuccessMessage prints a message in green
+func SuccessMessage(message string) {
+	logger.write(slog.LevelInfo, ColorGreen, message, false)
+}
+
+// InfoMessage prints a message in yellow
+func InfoMessage(message string) {
+	logger.write(slog.LevelInfo, ColorYellow, message, false)
+}
+
+// ErrorMessage prints a message in red
+func ErrorMessage(message string) {
+	logger.write(slog.LevelError, ColorRed, message, false)
+}
+
+// DebugMessage writes a message to the log file, it is printed on the terminal with --verbose only
+func DebugMessage(message string) {
+	logger.write(slog.LevelDebug, "", message, false)
+}
+
+// write logs a message to the file and, unless the level is filtered or the CLI sink is paused, to the
+// terminal. alwaysOnCLI prints it on the terminal even while the CLI sink is paused, e.g. progress reports.
+func (l *cliLog) write(level slog.Level, color, message string, alwaysOnCLI bool) {
+	message = strings.TrimRight(message, "\n")
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		l.file.Log(context.Background(), level, ansiColors.ReplaceAllString(message, ""))
+	}
+	if !(l.toCLI || alwaysOnCLI) || !showOnCLI(level) {
+		return
+	}
+	stamp := time.Now().Format("2006/01/02 15:04:05")
+	if color == "" {
+		fmt.Fprintf(cliWriter(), "%s %s\n", stamp, message)
+	} else {
+		fmt.Fprintf(cliWriter(), "%s %s%s%s\n", stamp, color, message, ColorReset)
+	}
+}
+
+// showOnCLI reports whether messages of a level are printed on the terminal
+func showOnCLI(level slog.Level) bool {
+	switch {
+	case Quiet:
+		return level >= slog.LevelError
+	case Verbose:
+		return true
+	}
+	return level >= slog.LevelInfo
+}
+
+func (l *cliLog) setFile(file io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.file = slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func (l *cliLog) setCLI(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.toCLI = enabled
+}
+
+// LogDir returns the directory of the command logs, ~/.local/state/grpl/logs (or under $XDG_STATE_HOME)
+func LogDir() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "grpl", "logs"), nil
+}
+
+// GetLogFilePath returns the log file of this run of a command, named after logFileName and the start time, e.g.
+// ~/.local/state/grpl/logs/grpl_civo_install-20250102T150405.log. Only the newest logRetention runs of a command
+// are kept. Without a usable log directory, the log goes to the temp directory.
+func GetLogFilePath(logFileName string) string {
+	dir, err := LogDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0700)
+	}
+	if err != nil {
+		return filepath.Join(os.TempDir(), logFileName)
+	}
+	name := strings.TrimSuffix(logFileName, ".log")
+	rotateLogs(dir, name)
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.log", name, time.Now().Format("20060102T150405")))
+}
+
+// rotateLogs removes the oldest log files of a command, leaving room for the log of the current run
+func rotateLogs(dir, name string) {
+	files, err := filepath.Glob(filepath.Join(dir, name+"-*.log"))
+	if err != nil {
+		return
+	}
+	// The timestamps in the names sort chronologically
+	sort.Strings(files)
+	for len(files) >= logRetention {
+		_ = os.Remove(files[0])
+		files = files[1:]
+	}
+}
+
+// GetLogWriters opens the log file of a command and makes it the file sink of the CLI messages. It returns the
+// file, to be closed by the command, and functions sending the messages to the file only (e.g. during helm
+// installs) and to the terminal and the file again.
+func GetLogWriters(logFilePath string) (*os.File, func(), func()) {
+	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		fallback, tempErr := os.CreateTemp("", "grpl-*.log")
+		if tempErr != nil {
+			log.Fatalf("failed to open log file: %v", err)
+		}
+		InfoMessage(fmt.Sprintf("Failed to open log file %s, logging to %s: %v", logFilePath, fallback.Name(), err))
+		logFile = fallback
+	}
+	logger.setFile(logFile)
+	DebugMessage(fmt.Sprintf("Logging to %s", logFile.Name()))
+
+	logOnFileStart := func() {
+		logger.setCLI(false)
+	}
+	logOnCliAndFileStart := func() {
+		logger.setCLI(true)
+	}
+	return logFile, logOnFileStart, logOnCliAndFileStart
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CommonLabels and CommonAnnotations are attached to every object the CLI creates,
+// they are filled from the --labels/--annotations flags of the installers and resource commands
+var (
+	CommonLabels      = map[string]string{}
+	CommonAnnotations = map[string]string{}
+)
+
+// SetCommonMetadata stores the labels and annotations that should be propagated to generated resources
+func SetCommonMetadata(labels, annotations map[string]string) {
+	for k, v := range labels {
+		CommonLabels[k] = v
+	}
+	for k, v := range annotations {
+		CommonAnnotations[k] = v
+	}
+}
+
+// ApplyCommonMetadata merges the common labels/annotations into the given object meta
+func ApplyCommonMetadata(meta *v1.ObjectMeta) {
+	if len(CommonLabels) > 0 {
+		if meta.Labels == nil {
+			meta.Labels = map[string]string{}
+		}
+		for k, v := range CommonLabels {
+			meta.Labels[k] = v
+		}
+	}
+	if len(CommonAnnotations) > 0 {
+		if meta.Annotations == nil {
+			meta.Annotations = map[string]string{}
+		}
+		for k, v := range CommonAnnotations {
+			meta.Annotations[k] = v
+		}
+	}
+}
+
+// ApplyCommonMetadataToUnstructured merges the common labels/annotations into an unstructured object
+func ApplyCommonMetadataToUnstructured(obj *unstructured.Unstructured) {
+	if len(CommonLabels) > 0 {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for k, v := range CommonLabels {
+			labels[k] = v
+		}
+		obj.SetLabels(labels)
+	}
+	if len(CommonAnnotations) > 0 {
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		for k, v := range CommonAnnotations {
+			annotations[k] = v
+		}
+		obj.SetAnnotations(annotations)
+	}
+}
+
+// AddCommonMetadataToValues sets commonLabels/commonAnnotations in helm values so charts can render them
+func AddCommonMetadataToValues(vals map[string]interface{}) {
+	mergeInto := func(key string, src map[string]string) {
+		if len(src) == 0 {
+			return
+		}
+		dst, ok := vals[key].(map[string]interface{})
+		if !ok {
+			dst = map[string]interface{}{}
+		}
+		for k, v := range src {
+			dst[k] = v
+		}
+		vals[key] = dst
+	}
+	mergeInto("commonLabels", CommonLabels)
+	mergeInto("commonAnnotations", CommonAnnotations)
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// OutputFormat is the format command results are printed in, it is set by the global --output flag
+var OutputFormat = OutputText
+
+// ValidateOutputFormat checks the value of the --output flag
+func ValidateOutputFormat() error {
+	switch strings.ToLower(OutputFormat) {
+	case OutputText, OutputJSON, OutputYAML:
+		OutputFormat = strings.ToLower(OutputFormat)
+		return nil
+	}
+	return fmt.Errorf("%w: invalid output format %q, must be one of: %s, %s, %s", ErrValidation, OutputFormat, OutputText, OutputJSON, OutputYAML)
+}
+
+// IsStructuredOutput reports whether results are printed as JSON or YAML. In that case stdout only
+// carries the result, progress and log messages go to stderr so that the output can be piped.
+func IsStructuredOutput() bool {
+	return OutputFormat == OutputJSON || OutputFormat == OutputYAML
+}
+
+// cliWriter is where human readable messages are written to, stderr while stdout carries a result or progress events
+func cliWriter() io.Writer {
+	if IsStructuredOutput() || progressOnStdout() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// PrintResult prints the result of a command in the selected output format, printText is used for the text format
+func PrintResult(result interface{}, printText func()) error {
+	switch OutputFormat {
+	case OutputJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal output: %w", err)
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+	case OutputYAML:
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal output: %w", err)
+		}
+		fmt.Fprint(os.Stdout, string(data))
+	default:
+		if printText != nil {
+			printText()
+		}
+	}
+	return nil
+}
+
+// ClusterConnection is the result of the connect commands
+type ClusterConnection struct {
+	Provider string `json:"provider" yaml:"provider"`
+	Cluster  string `json:"cluster" yaml:"cluster"`
+	Region   string `json:"region,omitempty" yaml:"region,omitempty"`
+	Context  string `json:"context" yaml:"context"`
+}
+
+// PrintClusterConnection prints the result of a connect command, the context is read from the kubeconfig
+func PrintClusterConnection(provider, cluster, region string) error {
+	connection := ClusterConnection{Provider: provider, Cluster: cluster, Region: region}
+	if kubeContext, err := CurrentKubeContext(); err == nil {
+		connection.Context = kubeContext
+	}
+	return PrintResult(connection, nil)
+}
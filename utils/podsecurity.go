@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// PodSecurityEnforceLabel is the namespace label used by Pod Security Admission
+	PodSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+	PodSecurityLevelPrivileged = "privileged"
+	PodSecurityLevelBaseline   = "baseline"
+	PodSecurityLevelRestricted = "restricted"
+
+	// uid of the "nobody" user, available in the helper images
+	helperPodUser int64 = 65534
+)
+
+// HardenedPodSecurityContext returns a pod security context that satisfies the "restricted" Pod Security Standard
+func HardenedPodSecurityContext() *corev1.PodSecurityContext {
+	runAsNonRoot := true
+	runAsUser := helperPodUser
+	return &corev1.PodSecurityContext{
+		RunAsNonRoot: &runAsNonRoot,
+		RunAsUser:    &runAsUser,
+		RunAsGroup:   &runAsUser,
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
+
+// HardenedContainerSecurityContext returns a container security context that satisfies the "restricted" Pod Security Standard
+func HardenedContainerSecurityContext() *corev1.SecurityContext {
+	runAsNonRoot := true
+	allowPrivilegeEscalation := false
+	return &corev1.SecurityContext{
+		RunAsNonRoot:             &runAsNonRoot,
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
+
+// HelperSecurityContexts returns the pod and container security contexts of a helper pod in namespace. The
+// helper images are not built to run as a fixed non-root user, so the hardened contexts are only used when the
+// namespace enforces the "restricted" Pod Security Standard, otherwise the pods run as the image's user.
+func HelperSecurityContexts(clientset kubernetes.Interface, namespace string) (*corev1.PodSecurityContext, *corev1.SecurityContext) {
+	level, err := GetNamespacePodSecurityLevel(clientset, namespace)
+	if err != nil {
+		DebugMessage(fmt.Sprintf("Failed to get the Pod Security level of namespace %s: %v", namespace, err))
+		return nil, nil
+	}
+	if level != PodSecurityLevelRestricted {
+		return nil, nil
+	}
+	return HardenedPodSecurityContext(), HardenedContainerSecurityContext()
+}
+
+// HelperPodResources returns the requests/limits used for short lived helper pods
+func HelperPodResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("200m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+	}
+}
+
+// HardenedContainerSpecValues returns the securityContext and resources of a hardened container as plain
+// values, for containers that are rendered through helm templates (e.g. grapi init containers)
+func HardenedContainerSpecValues() map[string]interface{} {
+	return map[string]interface{}{
+		"securityContext": map[string]interface{}{
+			"runAsNonRoot":             true,
+			"runAsUser":                helperPodUser,
+			"runAsGroup":               helperPodUser,
+			"allowPrivilegeEscalation": false,
+			"capabilities": map[string]interface{}{
+				"drop": []string{"ALL"},
+			},
+			"seccompProfile": map[string]interface{}{
+				"type": "RuntimeDefault",
+			},
+		},
+		"resources": map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": "50m", "memory": "64Mi"},
+			"limits":   map[string]interface{}{"cpu": "500m", "memory": "512Mi"},
+		},
+	}
+}
+
+// GetNamespacePodSecurityLevel returns the enforced Pod Security Standard of a namespace,
+// namespaces without the label are treated as "privileged"
+func GetNamespacePodSecurityLevel(clientset kubernetes.Interface, namespace string) (string, error) {
+	ns, err := clientset.CoreV1().Namespaces().Get(context.TODO(), namespace, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return PodSecurityLevelPrivileged, nil
+		}
+		return "", fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	if level, ok := ns.Labels[PodSecurityEnforceLabel]; ok && level != "" {
+		return level, nil
+	}
+	return PodSecurityLevelPrivileged, nil
+}
+
+// ExplainPodAdmissionError turns a rejected helper pod into an actionable error, it detects
+// Pod Security Admission as well as policy engines like OPA Gatekeeper or Kyverno
+func ExplainPodAdmissionError(clientset kubernetes.Interface, namespace, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if !errors.IsForbidden(err) && !errors.IsInvalid(err) && !strings.Contains(err.Error(), "denied the request") {
+		return err
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "violates PodSecurity"):
+		level, _ := GetNamespacePodSecurityLevel(clientset, namespace)
+		return fmt.Errorf("%s was rejected by Pod Security Admission (namespace %s enforces %q): %w\n"+
+			"the helper pods run as non-root without privilege escalation in restricted namespaces; check that the cluster does not enforce a custom level, "+
+			"or relax the namespace with: kubectl label namespace %s %s=baseline --overwrite",
+			name, namespace, level, err, namespace, PodSecurityEnforceLabel)
+	case strings.Contains(msg, "admission webhook") || strings.Contains(msg, "denied the request"):
+		return fmt.Errorf("%s was rejected by an admission policy in namespace %s (e.g. OPA Gatekeeper or Kyverno): %w\n"+
+			"ask your cluster administrator to allow the image or add an exemption for namespace %s",
+			name, namespace, err, namespace)
+	}
+	return err
+}
+
+// PodCreationFailure returns an error when a controller (e.g. a DaemonSet) reports that it could not
+// create its pods, which is how admission rejections surface for pods that are not created directly
+func PodCreationFailure(clientset kubernetes.Interface, namespace, ownerName string) error {
+	events, err := clientset.CoreV1().Events(namespace).List(context.TODO(), v1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.name=%s,reason=FailedCreate", ownerName),
+	})
+	if err != nil || len(events.Items) == 0 {
+		return nil
+	}
+	last := events.Items[len(events.Items)-1]
+	return ExplainPodAdmissionError(clientset, namespace, ownerName, errors.NewForbidden(corev1.Resource("pods"), ownerName, fmt.Errorf("%s", last.Message)))
+}
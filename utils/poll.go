@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// Polling waits start with PollInterval and double it after every attempt up to PollMaxInterval, so that fast
+// clusters are not kept waiting a full interval while slow ones are not polled more than needed. Both are set by
+// the global --poll-interval and --poll-max-interval flags.
+var (
+	PollInterval    = 2 * time.Second
+	PollMaxInterval = 15 * time.Second
+)
+
+// ValidatePollIntervals checks the --poll-interval and --poll-max-interval flags
+func ValidatePollIntervals() error {
+	if PollInterval <= 0 {
+		return fmt.Errorf("%w: --poll-interval must be positive, got %s", ErrValidation, PollInterval)
+	}
+	if PollMaxInterval < PollInterval {
+		return fmt.Errorf("%w: --poll-max-interval (%s) must not be below --poll-interval (%s)", ErrValidation, PollMaxInterval, PollInterval)
+	}
+	return nil
+}
+
+// Poller spaces the attempts of a polling loop
+type Poller struct {
+	next time.Duration
+}
+
+// NewPoller returns a poller whose first wait is PollInterval
+func NewPoller() *Poller {
+	return &Poller{next: PollInterval}
+}
+
+// Wait sleeps until the next attempt and grows the interval, it returns ErrCanceled when the command is interrupted
+func (p *Poller) Wait() error {
+	interval := p.next
+	p.next *= 2
+	if p.next > PollMaxInterval {
+		p.next = PollMaxInterval
+	}
+	return Sleep(interval)
+}
+
+// Poll calls condition until it returns true or an error, or timeout elapses. It replaces wait.PollImmediate
+// with the adaptive intervals of Poller.
+func Poll(timeout time.Duration, condition func() (bool, error)) error {
+	poller := NewPoller()
+	deadline := time.Now().Add(timeout)
+	for {
+		done, err := condition()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: not ready within %s", ErrTimeout, FormatDuration(timeout))
+		}
+		if err := poller.Wait(); err != nil {
+			return err
+		}
+	}
+}
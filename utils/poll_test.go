@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// withPollIntervals sets short poll intervals for the duration of a test
+func withPollIntervals(t *testing.T, interval, maxInterval time.Duration) {
+	t.Helper()
+	oldInterval, oldMax := PollInterval, PollMaxInterval
+	PollInterval, PollMaxInterval = interval, maxInterval
+	t.Cleanup(func() { PollInterval, PollMaxInterval = oldInterval, oldMax })
+}
+
+func TestPollerIntervals(t *testing.T) {
+	tests := []struct {
+		name        string
+		interval    time.Duration
+		maxInterval time.Duration
+		want        []time.Duration
+	}{
+		{name: "doubles up to the max", interval: time.Millisecond, maxInterval: 5 * time.Millisecond,
+			want: []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 5 * time.Millisecond, 5 * time.Millisecond}},
+		{name: "fixed when max equals interval", interval: time.Millisecond, maxInterval: time.Millisecond,
+			want: []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPollIntervals(t, tt.interval, tt.maxInterval)
+			poller := NewPoller()
+			for i, want := range tt.want {
+				if poller.next != want {
+					t.Fatalf("wait %d = %s, want %s", i, poller.next, want)
+				}
+				if err := poller.Wait(); err != nil {
+					t.Fatalf("Wait() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPoll(t *testing.T) {
+	errCondition := errors.New("condition failed")
+	tests := []struct {
+		name      string
+		results   []bool
+		err       error
+		timeout   time.Duration
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "ready at once", results: []bool{true}, timeout: time.Second, wantCalls: 1},
+		{name: "ready after retries", results: []bool{false, false, true}, timeout: time.Second, wantCalls: 3},
+		{name: "condition error", err: errCondition, timeout: time.Second, wantErr: errCondition, wantCalls: 1},
+		{name: "timeout", results: []bool{false}, timeout: 10 * time.Millisecond, wantErr: ErrTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPollIntervals(t, time.Millisecond, 2*time.Millisecond)
+			calls := 0
+			err := Poll(tt.timeout, func() (bool, error) {
+				calls++
+				if tt.err != nil {
+					return false, tt.err
+				}
+				if calls > len(tt.results) {
+					return tt.results[len(tt.results)-1], nil
+				}
+				return tt.results[calls-1], nil
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Poll() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantCalls > 0 && calls != tt.wantCalls {
+				t.Errorf("condition called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestValidatePollIntervals(t *testing.T) {
+	tests := []struct {
+		name        string
+		interval    time.Duration
+		maxInterval time.Duration
+		wantErr     bool
+	}{
+		{name: "defaults", interval: 2 * time.Second, maxInterval: 15 * time.Second},
+		{name: "equal", interval: time.Second, maxInterval: time.Second},
+		{name: "zero interval", interval: 0, maxInterval: time.Second, wantErr: true},
+		{name: "max below interval", interval: 5 * time.Second, maxInterval: time.Second, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPollIntervals(t, tt.interval, tt.maxInterval)
+			err := ValidatePollIntervals()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePollIntervals() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrValidation) {
+				t.Errorf("ValidatePollIntervals() error = %v, want ErrValidation", err)
+			}
+		})
+	}
+}
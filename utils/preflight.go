@@ -0,0 +1,359 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/registry"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Kubernetes versions Grapple is installed on: older clusters fail the preflight, newer ones only warn as they
+// are not tested yet
+const (
+	minKubernetesVersion    = "1.25.0"
+	testedKubernetesVersion = "1.32"
+)
+
+// Node capacity of the cluster: below the minimum Grapple doesn't fit, below the recommendation a few GRAS do
+var (
+	minClusterCPU            = resource.MustParse("2")
+	minClusterMemory         = resource.MustParse("4Gi")
+	recommendedClusterCPU    = resource.MustParse("4")
+	recommendedClusterMemory = resource.MustParse("8Gi")
+)
+
+// SkipPreflight skips the preflight checks of installs and deploys, it is set by --skip-preflight
+var SkipPreflight bool
+
+// Results of a preflight check
+const (
+	PreflightPass = "pass"
+	PreflightWarn = "warn"
+	PreflightFail = "fail"
+)
+
+// PreflightOptions select the preflight checks
+type PreflightOptions struct {
+	// Install checks a cluster Grapple is about to be installed on, otherwise one GRAS are deployed on
+	Install bool
+	// Domain is checked to resolve as a wildcard, "" reads it from grsf-config when Grapple is installed
+	Domain string
+}
+
+// PreflightCheck is the result of a single preflight check
+type PreflightCheck struct {
+	Name    string `json:"name" yaml:"name"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// PreflightReport is the result of the preflight checks of a cluster
+type PreflightReport struct {
+	Context  string           `json:"context" yaml:"context"`
+	Checks   []PreflightCheck `json:"checks" yaml:"checks"`
+	Warnings int              `json:"warnings" yaml:"warnings"`
+	Failures int              `json:"failures" yaml:"failures"`
+	Passed   bool             `json:"passed" yaml:"passed"`
+}
+
+func (r *PreflightReport) add(name, status, message string) {
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, Status: status, Message: message})
+	switch status {
+	case PreflightWarn:
+		r.Warnings++
+	case PreflightFail:
+		r.Failures++
+	}
+}
+
+// Err returns a validation error naming the failed checks, nil if none failed
+func (r *PreflightReport) Err() error {
+	if r.Failures == 0 {
+		return nil
+	}
+	var failed []string
+	for _, check := range r.Checks {
+		if check.Status == PreflightFail {
+			failed = append(failed, fmt.Sprintf("%s (%s)", check.Name, check.Message))
+		}
+	}
+	return fmt.Errorf("%w: preflight checks failed: %s; fix them or rerun with --skip-preflight", ErrValidation, strings.Join(failed, ", "))
+}
+
+// RunPreflight checks that the cluster can take Grapple (opts.Install) or a GRAS: the Kubernetes version, the
+// node capacity, a default StorageClass, an ingress controller, the registries and GitHub being reachable, the
+// cluster domain resolving as wildcard and conflicting installations. Failed checks are part of the report,
+// only an unreachable cluster is returned as error. What the user isn't allowed to read, e.g. the nodes with
+// namespace-scoped rights, is a warning.
+func RunPreflight(kubeClient apiv1.Interface, restConfig *rest.Config, opts PreflightOptions) (*PreflightReport, error) {
+	ctx, cancel := context.WithTimeout(CommandContext(), time.Minute)
+	defer cancel()
+
+	report := &PreflightReport{}
+	if kubeContext, err := CurrentKubeContext(); err == nil {
+		report.Context = kubeContext
+	}
+
+	if err := preflightKubernetesVersion(kubeClient, report); err != nil {
+		return nil, err
+	}
+	preflightNodeCapacity(ctx, kubeClient, report)
+	preflightStorageClass(ctx, kubeClient, report)
+	preflightIngress(ctx, kubeClient, opts, report)
+	preflightConnectivity(ctx, report)
+	if CommandContext().Err() != nil {
+		return nil, Canceled()
+	}
+
+	grsfVersion, err := GetGrplReleaseVersion(restConfig, "grsf", "grpl-system")
+	switch {
+	case err != nil:
+		report.add("grapple", PreflightWarn, readWarning("the grsf release", err))
+	case opts.Install:
+		preflightConflicts(ctx, kubeClient, grsfVersion, report)
+	case grsfVersion == "":
+		report.add("grapple", PreflightFail, "Grapple is not installed, install it with 'grapple <provider> install'")
+	default:
+		report.add("grapple", PreflightPass, "grsf "+grsfVersion)
+	}
+
+	domain := opts.Domain
+	if domain == "" && grsfVersion != "" {
+		if secret, err := kubeClient.CoreV1().Secrets("grpl-system").Get(ctx, "grsf-config", v1.GetOptions{}); err == nil {
+			domain = string(secret.Data[SecKeyClusterdomain])
+		}
+	}
+	if domain != "" {
+		preflightWildcardDNS(domain, report)
+	}
+
+	report.Passed = report.Failures == 0
+	return report, nil
+}
+
+// preflightKubernetesVersion checks the version of the API server
+func preflightKubernetesVersion(kubeClient apiv1.Interface, report *PreflightReport) error {
+	info, err := kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get the Kubernetes version: %w", ErrClusterUnreachable, err)
+	}
+	version, err := semver.NewVersion(info.GitVersion)
+	if err != nil {
+		report.add("kubernetes-version", PreflightWarn, fmt.Sprintf("unknown version %s", info.GitVersion))
+		return nil
+	}
+	minVersion := semver.MustParse(minKubernetesVersion)
+	tested := semver.MustParse(testedKubernetesVersion)
+	switch {
+	case version.LessThan(minVersion):
+		report.add("kubernetes-version", PreflightFail, fmt.Sprintf("%s is older than the minimum %s", info.GitVersion, minKubernetesVersion))
+	case version.Major() > tested.Major() || version.Minor() > tested.Minor():
+		report.add("kubernetes-version", PreflightWarn, fmt.Sprintf("%s is newer than the tested %s", info.GitVersion, testedKubernetesVersion))
+	default:
+		report.add("kubernetes-version", PreflightPass, info.GitVersion)
+	}
+	return nil
+}
+
+// readWarning is the message of a check whose objects couldn't be read
+func readWarning(what string, err error) string {
+	if k8serrors.IsForbidden(err) {
+		return fmt.Sprintf("not allowed to read %s, skipped", what)
+	}
+	return fmt.Sprintf("failed to read %s, skipped: %v", what, err)
+}
+
+// preflightNodeCapacity checks the allocatable CPU and memory of the schedulable nodes
+func preflightNodeCapacity(ctx context.Context, kubeClient apiv1.Interface, report *PreflightReport) {
+	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, v1.ListOptions{})
+	if err != nil {
+		report.add("node-capacity", PreflightWarn, readWarning("the nodes", err))
+		return
+	}
+	cpu, memory := resource.Quantity{}, resource.Quantity{}
+	schedulable := 0
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		schedulable++
+		cpu.Add(*node.Status.Allocatable.Cpu())
+		memory.Add(*node.Status.Allocatable.Memory())
+	}
+	message := fmt.Sprintf("%d schedulable nodes with %s CPU and %s memory", schedulable, cpu.String(), formatMemory(memory))
+	switch {
+	case cpu.Cmp(minClusterCPU) < 0 || memory.Cmp(minClusterMemory) < 0:
+		report.add("node-capacity", PreflightFail, fmt.Sprintf("%s, at least %s CPU and %s are needed", message, minClusterCPU.String(), minClusterMemory.String()))
+	case cpu.Cmp(recommendedClusterCPU) < 0 || memory.Cmp(recommendedClusterMemory) < 0:
+		report.add("node-capacity", PreflightWarn, fmt.Sprintf("%s, %s CPU and %s are recommended", message, recommendedClusterCPU.String(), recommendedClusterMemory.String()))
+	default:
+		report.add("node-capacity", PreflightPass, message)
+	}
+}
+
+// formatMemory formats a memory quantity in Gi, e.g. "7.6Gi"
+func formatMemory(memory resource.Quantity) string {
+	return fmt.Sprintf("%.1fGi", float64(memory.Value())/(1<<30))
+}
+
+// preflightStorageClass checks for a default StorageClass, the databases of KubeBlocks claim their volumes with it
+func preflightStorageClass(ctx context.Context, kubeClient apiv1.Interface, report *PreflightReport) {
+	classes, err := kubeClient.StorageV1().StorageClasses().List(ctx, v1.ListOptions{})
+	if err != nil {
+		report.add("storage-class", PreflightWarn, readWarning("the storage classes", err))
+		return
+	}
+	for _, class := range classes.Items {
+		if class.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" {
+			report.add("storage-class", PreflightPass, "default "+class.Name)
+			return
+		}
+	}
+	if len(classes.Items) == 0 {
+		report.add("storage-class", PreflightFail, "no StorageClass, the databases can't claim volumes")
+		return
+	}
+	report.add("storage-class", PreflightWarn, fmt.Sprintf("no default StorageClass among %d, volumes without a class stay pending", len(classes.Items)))
+}
+
+// preflightIngress checks for an IngressClass. Installs only warn, as the providers set up traefik or nginx.
+func preflightIngress(ctx context.Context, kubeClient apiv1.Interface, opts PreflightOptions, report *PreflightReport) {
+	classes, err := kubeClient.NetworkingV1().IngressClasses().List(ctx, v1.ListOptions{})
+	if err != nil {
+		report.add("ingress", PreflightWarn, readWarning("the ingress classes", err))
+		return
+	}
+	var names []string
+	for _, class := range classes.Items {
+		if class.Annotations["ingressclass.kubernetes.io/is-default-class"] == "true" {
+			report.add("ingress", PreflightPass, "default IngressClass "+class.Name)
+			return
+		}
+		names = append(names, class.Name)
+	}
+	switch {
+	case len(names) > 0:
+		report.add("ingress", PreflightPass, "IngressClass "+strings.Join(names, ", "))
+	case opts.Install:
+		report.add("ingress", PreflightWarn, "no IngressClass yet, the install sets up the ingress controller of the provider")
+	default:
+		report.add("ingress", PreflightFail, "no IngressClass, the GRAS endpoints are not reachable")
+	}
+}
+
+// preflightConnectivity checks that the chart registry and GitHub answer over HTTPS, offline installs skip it
+func preflightConnectivity(ctx context.Context, report *PreflightReport) {
+	if OfflineMode {
+		report.add("connectivity", PreflightPass, "skipped with --offline")
+		return
+	}
+	chartHost := strings.TrimPrefix(grplChartRegistry(), registry.OCIScheme+"://")
+	chartHost, _, _ = strings.Cut(chartHost, "/")
+	targets := []string{"https://" + chartHost + "/v2/", "https://github.com"}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	for _, target := range targets {
+		name := "connectivity " + hostOf(target)
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+		if err != nil {
+			report.add(name, PreflightWarn, err.Error())
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			report.add(name, PreflightWarn, fmt.Sprintf("not reachable (%v), use --chart-registry or --offline behind a firewall", err))
+			continue
+		}
+		resp.Body.Close()
+		report.add(name, PreflightPass, resp.Status)
+	}
+}
+
+func hostOf(target string) string {
+	if u, err := url.Parse(target); err == nil {
+		return u.Host
+	}
+	return target
+}
+
+// preflightWildcardDNS checks that a random subdomain of the domain resolves, the GRAS endpoints are served on them
+func preflightWildcardDNS(domain string, report *PreflightReport) {
+	host := fmt.Sprintf("grpl-preflight-%d.%s", time.Now().UnixNano()%100000, domain)
+	if _, err := net.LookupHost(host); err != nil {
+		report.add("wildcard-dns", PreflightWarn, fmt.Sprintf("*.%s does not resolve, GRAS endpoints won't be reachable by name", domain))
+		return
+	}
+	report.add("wildcard-dns", PreflightPass, fmt.Sprintf("*.%s resolves", domain))
+}
+
+// preflightConflicts checks for an existing Grapple and for cert-manager or crossplane installed outside of Grapple
+func preflightConflicts(ctx context.Context, kubeClient apiv1.Interface, grsfVersion string, report *PreflightReport) {
+	if grsfVersion != "" {
+		report.add("existing-install", PreflightWarn, fmt.Sprintf("Grapple %s is already installed, 'grapple upgrade' upgrades it in place", grsfVersion))
+	} else {
+		report.add("existing-install", PreflightPass, "Grapple is not installed yet")
+	}
+
+	deployments, err := kubeClient.AppsV1().Deployments("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		report.add("conflicts", PreflightWarn, readWarning("the deployments of the cluster", err))
+		return
+	}
+	var conflicts []string
+	for _, deployment := range deployments.Items {
+		if deployment.Namespace == "grpl-system" {
+			continue
+		}
+		for _, component := range []string{"cert-manager", "crossplane"} {
+			if deployment.Name == component {
+				conflicts = append(conflicts, fmt.Sprintf("%s in %s", component, deployment.Namespace))
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		report.add("conflicts", PreflightWarn, strings.Join(conflicts, ", ")+" already installed, Grapple installs its own in grpl-system")
+	} else {
+		report.add("conflicts", PreflightPass, "no cert-manager or crossplane outside of grpl-system")
+	}
+}
+
+// PrintPreflightReport prints the checks of a report, one line each
+func PrintPreflightReport(report *PreflightReport) {
+	for _, check := range report.Checks {
+		line := fmt.Sprintf("  %-4s %-32s %s", strings.ToUpper(check.Status), check.Name, check.Message)
+		switch check.Status {
+		case PreflightPass:
+			SuccessMessage(line)
+		case PreflightWarn:
+			InfoMessage(line)
+		default:
+			ErrorMessage(line)
+		}
+	}
+}
+
+// Preflight runs the preflight checks at the start of an install or deploy and prints them, unless
+// --skip-preflight is set. It fails if a check failed.
+func Preflight(kubeClient apiv1.Interface, restConfig *rest.Config, opts PreflightOptions) error {
+	if SkipPreflight {
+		return nil
+	}
+	InfoMessage("Running preflight checks (--skip-preflight to skip)...")
+	report, err := RunPreflight(kubeClient, restConfig, opts)
+	if err != nil {
+		return err
+	}
+	PrintPreflightReport(report)
+	return report.Err()
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+)
+
+// platformPriority is the value of the PriorityClass created by --priority-class, above any workload class
+// (the default is 0) and below the system-* classes of Kubernetes
+const platformPriority = 1000000
+
+// PriorityClass is the PriorityClass of the platform components, set by --priority-class
+var PriorityClass string
+
+// EnsurePriorityClass creates the PriorityClass of --priority-class if it doesn't exist yet, an existing class
+// is used as is
+func EnsurePriorityClass(kubeClient apiv1.Interface) error {
+	if PriorityClass == "" {
+		return nil
+	}
+	if _, err := kubeClient.Discovery().ServerResourcesForGroupVersion(schedulingv1.SchemeGroupVersion.String()); err != nil {
+		return fmt.Errorf("the cluster doesn't serve %s, --priority-class can't be used: %w", schedulingv1.SchemeGroupVersion, err)
+	}
+
+	existing, err := kubeClient.SchedulingV1().PriorityClasses().Get(context.TODO(), PriorityClass, v1.GetOptions{})
+	if err == nil {
+		InfoMessage(fmt.Sprintf("Using existing PriorityClass %s (value %d)", PriorityClass, existing.Value))
+		if existing.Value <= 0 {
+			InfoMessage(fmt.Sprintf("Warning: PriorityClass %s doesn't rank above other workloads, platform pods may still be evicted", PriorityClass))
+		}
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get PriorityClass %s: %w", PriorityClass, err)
+	}
+
+	preemption := corev1.PreemptLowerPriority
+	priorityClass := &schedulingv1.PriorityClass{
+		ObjectMeta:       v1.ObjectMeta{Name: PriorityClass},
+		Value:            platformPriority,
+		PreemptionPolicy: &preemption,
+		Description:      "Priority of the Grapple platform components, so they are not evicted before workloads",
+	}
+	ApplyCommonMetadata(&priorityClass.ObjectMeta)
+	if _, err := kubeClient.SchedulingV1().PriorityClasses().Create(context.TODO(), priorityClass, v1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create PriorityClass %s: %w", PriorityClass, err)
+	}
+	recordInstallStep(installStepPriorityClass, PriorityClass, "")
+	SuccessMessage(fmt.Sprintf("Created PriorityClass %s (value %d)", PriorityClass, platformPriority))
+	return nil
+}
+
+// AddPriorityClassToValues sets priorityClassName and global.priorityClassName in helm values, values files
+// setting them take precedence
+func AddPriorityClassToValues(vals map[string]interface{}) {
+	if PriorityClass == "" {
+		return
+	}
+	if _, set := vals["priorityClassName"]; !set {
+		vals["priorityClassName"] = PriorityClass
+	}
+	global, ok := vals["global"].(map[string]interface{})
+	if !ok {
+		global = map[string]interface{}{}
+		vals["global"] = global
+	}
+	if _, set := global["priorityClassName"]; !set {
+		global["priorityClassName"] = PriorityClass
+	}
+}
+
+// EvictedPlatformPods returns the evicted or preempted pods of the platform namespaces as namespace/name
+func EvictedPlatformPods(ctx context.Context, kubeClient apiv1.Interface) []string {
+	var evicted []string
+	for _, namespace := range platformNamespaces {
+		pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, v1.ListOptions{})
+		if err != nil {
+			continue
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Reason == "Evicted" || pod.Status.Reason == "Preempting" || hasDisruptionCondition(pod) {
+				evicted = append(evicted, pod.Namespace+"/"+pod.Name)
+			}
+		}
+	}
+	sort.Strings(evicted)
+	return evicted
+}
+
+// hasDisruptionCondition reports whether the pod is being removed by an eviction or a preemption
+func hasDisruptionCondition(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.DisruptionTarget && condition.Status == corev1.ConditionTrue &&
+			(condition.Reason == "EvictionByEvictionAPI" || condition.Reason == "PreemptionByScheduler" || condition.Reason == "TerminationByKubelet") {
+			return true
+		}
+	}
+	return false
+}
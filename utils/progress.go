@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WaitProgressInterval is how often long running waits report their elapsed time, 0 disables the reports
+var WaitProgressInterval = 30 * time.Second
+
+// WaitProgress periodically reports how long a wait has been running and how much of its timeout is left
+type WaitProgress struct {
+	what    string
+	timeout time.Duration
+	start   time.Time
+	done    chan struct{}
+	once    sync.Once
+
+	mu     sync.Mutex
+	status string
+}
+
+// StartWaitProgress starts reporting progress for a wait, the returned reporter must be stopped with Stop or Done
+func StartWaitProgress(what string, timeout time.Duration) *WaitProgress {
+	p := &WaitProgress{
+		what:    what,
+		timeout: timeout,
+		start:   time.Now(),
+		done:    make(chan struct{}),
+	}
+
+	if WaitProgressInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(WaitProgressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					p.report(ColorYellow, p.String())
+				case <-p.done:
+					return
+				}
+			}
+		}()
+	}
+
+	return p
+}
+
+// String returns the current progress line, e.g. "waiting for grsf (elapsed 2m15s / timeout 10m)"
+func (p *WaitProgress) String() string {
+	what := p.what
+	if status := p.Status(); status != "" {
+		what += ": " + status
+	}
+	if p.timeout > 0 {
+		return fmt.Sprintf("waiting for %s (elapsed %s / timeout %s)", what, FormatDuration(p.Elapsed()), FormatDuration(p.timeout))
+	}
+	return fmt.Sprintf("waiting for %s (elapsed %s)", what, FormatDuration(p.Elapsed()))
+}
+
+// SetStatus sets what the wait is currently at, e.g. "phase Creating", it is part of the progress lines and
+// reported right away when it changes
+func (p *WaitProgress) SetStatus(status string) {
+	p.mu.Lock()
+	changed := p.status != status
+	p.status = status
+	p.mu.Unlock()
+	if changed && status != "" {
+		p.report(ColorYellow, p.String())
+	}
+}
+
+// Status returns the status set with SetStatus
+func (p *WaitProgress) Status() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// Elapsed returns the time since the wait started
+func (p *WaitProgress) Elapsed() time.Duration {
+	return time.Since(p.start)
+}
+
+// Stop stops the periodic reports and returns the total duration of the wait
+func (p *WaitProgress) Stop() time.Duration {
+	p.once.Do(func() {
+		close(p.done)
+	})
+	return p.Elapsed()
+}
+
+// Done stops the reports and prints a success message that includes the total duration of the wait
+func (p *WaitProgress) Done(message string) {
+	elapsed := p.Stop()
+	p.report(ColorGreen, fmt.Sprintf("%s (took %s)", message, FormatDuration(elapsed)))
+}
+
+// TimeoutError returns the error used when the wait ran out of time
+func (p *WaitProgress) TimeoutError() error {
+	p.Stop()
+	return fmt.Errorf("%w waiting for %s after %s", ErrTimeout, p.what, FormatDuration(p.timeout))
+}
+
+// report writes a line to the log and makes sure it is also visible on the terminal while the
+// log is temporarily redirected to the log file only
+func (p *WaitProgress) report(color, line string) {
+	logger.write(slog.LevelInfo, color, line, true)
+}
+
+// FormatDuration formats a duration for humans, rounded to seconds and without zero units (e.g. "2m15s", "10m", "1h5m")
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return "0s"
+	}
+	s := d.Round(time.Second).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// historySize is the number of answers remembered per question
+const historySize = 10
+
+// historyFilePath returns the file the answers are remembered in, ~/.config/grpl/prompt-history.json (or
+// under $XDG_CONFIG_HOME), next to the CLI config file
+func historyFilePath() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "grpl", "prompt-history.json")
+}
+
+// loadHistory reads the remembered answers by question, newest first; a missing or broken file is no history
+func loadHistory() map[string][]string {
+	history := map[string][]string{}
+	path := historyFilePath()
+	if path == "" {
+		return history
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return history
+	}
+	_ = json.Unmarshal(data, &history)
+	return history
+}
+
+// History returns the remembered answers of a question, newest first
+func History(key string) []string {
+	if key == "" {
+		return nil
+	}
+	return loadHistory()[key]
+}
+
+// lastAnswer returns the newest remembered answer of a question
+func lastAnswer(key string) string {
+	if answers := History(key); len(answers) > 0 {
+		return answers[0]
+	}
+	return ""
+}
+
+// remember records an answer of a question, failures to write the history are ignored as it is a convenience
+func remember(key, answer string) {
+	if key == "" || answer == "" {
+		return
+	}
+	path := historyFilePath()
+	if path == "" {
+		return
+	}
+	history := loadHistory()
+	answers := []string{answer}
+	for _, previous := range history[key] {
+		if previous != answer && len(answers) < historySize {
+			answers = append(answers, previous)
+		}
+	}
+	history[key] = answers
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0600)
+}
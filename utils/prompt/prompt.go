@@ -0,0 +1,249 @@
+// Package prompt asks the interactive questions of the CLI. It fails fast instead of hanging when stdin is
+// not a terminal (CI), offers defaults and the answers of earlier runs, and can be replaced by a stub in tests.
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
+)
+
+var (
+	// ErrNotInteractive means a question was asked but stdin is not a terminal
+	ErrNotInteractive = errors.New("stdin is not a terminal")
+	// ErrAborted means the user interrupted a prompt (Ctrl+C, Ctrl+D)
+	ErrAborted = errors.New("prompt aborted")
+)
+
+// Question is a question to the user
+type Question struct {
+	// Label is the question shown to the user
+	Label string
+	// Default is the answer offered when the user just presses enter (the selected item of a select)
+	Default string
+	// Flag is the flag that answers the question without prompting, it is named when stdin is not a terminal
+	Flag string
+	// History is the key the answer is remembered under and offered as default next time, "" remembers nothing
+	History string
+	// Validate checks the answer of an input
+	Validate func(string) error
+	// Mask hides the typed characters, e.g. of passwords; masked answers are never remembered
+	Mask rune
+	// Search lets the user filter the items of a select by typing ("/"), long lists start in search mode
+	Search bool
+}
+
+// Prompter asks the questions, SetPrompter replaces it
+type Prompter interface {
+	Input(q Question) (string, error)
+	Select(q Question, items []string) (string, error)
+	Confirm(q Question) (bool, error)
+}
+
+var current Prompter = terminal{}
+
+// SetPrompter replaces the prompter, e.g. with a Stub in tests, and returns a function restoring the previous one
+func SetPrompter(p Prompter) (restore func()) {
+	previous := current
+	current = p
+	return func() { current = previous }
+}
+
+// Interactive reports whether questions can be asked, it is a variable so tests can override it
+var Interactive = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Input asks for a free text answer
+func Input(q Question) (string, error) {
+	if q.Default == "" && q.Mask == 0 {
+		q.Default = lastAnswer(q.History)
+	}
+	answer, err := current.Input(q)
+	if err != nil {
+		return "", err
+	}
+	if q.Mask == 0 {
+		remember(q.History, answer)
+	}
+	return answer, nil
+}
+
+// Select asks to choose one of items
+func Select(q Question, items []string) (string, error) {
+	if q.Default == "" {
+		q.Default = lastAnswer(q.History)
+	}
+	answer, err := current.Select(q, items)
+	if err != nil {
+		return "", err
+	}
+	remember(q.History, answer)
+	return answer, nil
+}
+
+// Confirm asks a yes/no question, Default "y" makes yes the answer of enter
+func Confirm(q Question) (bool, error) {
+	return current.Confirm(q)
+}
+
+// notInteractive returns the error of a question that can't be asked
+func notInteractive(q Question) error {
+	if q.Flag != "" {
+		return fmt.Errorf("%w, cannot ask %q: set %s", ErrNotInteractive, q.Label, q.Flag)
+	}
+	return fmt.Errorf("%w, cannot ask %q: pass the answer as a flag (see --help)", ErrNotInteractive, q.Label)
+}
+
+// terminal asks the questions with promptui
+type terminal struct{}
+
+func (terminal) Input(q Question) (string, error) {
+	if !Interactive() {
+		return "", notInteractive(q)
+	}
+	p := promptui.Prompt{
+		Label:     q.Label,
+		Default:   q.Default,
+		AllowEdit: q.Default != "",
+		Validate:  q.Validate,
+		Mask:      q.Mask,
+	}
+	answer, err := p.Run()
+	return answer, promptError(err)
+}
+
+func (terminal) Select(q Question, items []string) (string, error) {
+	if !Interactive() {
+		return "", notInteractive(q)
+	}
+	p := promptui.Select{
+		Label: q.Label,
+		Items: items,
+	}
+	if q.Search {
+		p.Size = searchPageSize
+		p.Searcher = func(input string, index int) bool { return FuzzyMatch(input, items[index]) }
+		p.StartInSearchMode = len(items) > searchPageSize && q.Default == ""
+	}
+	for i, item := range items {
+		if item == q.Default {
+			p.CursorPos = i
+			break
+		}
+	}
+	_, answer, err := p.Run()
+	return answer, promptError(err)
+}
+
+func (terminal) Confirm(q Question) (bool, error) {
+	if !Interactive() {
+		return false, notInteractive(q)
+	}
+	p := promptui.Prompt{
+		Label:     q.Label,
+		IsConfirm: true,
+		Default:   q.Default,
+	}
+	answer, err := p.Run()
+	if errors.Is(err, promptui.ErrAbort) {
+		return false, nil
+	}
+	if err != nil {
+		return false, promptError(err)
+	}
+	return (answer == "" && q.Default == "y") || answer == "y" || answer == "Y", nil
+}
+
+// searchPageSize is the number of items a searchable select shows at once
+const searchPageSize = 10
+
+// FuzzyMatch reports whether the characters of input appear in item in order, ignoring case and spaces, so
+// "prdfr" matches "prod-fra1"
+func FuzzyMatch(input, item string) bool {
+	item = strings.ToLower(item)
+	for _, r := range strings.ToLower(strings.ReplaceAll(input, " ", "")) {
+		i := strings.IndexRune(item, r)
+		if i < 0 {
+			return false
+		}
+		item = item[i+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+// promptError classifies the errors of promptui, Ctrl+C and Ctrl+D abort the command
+func promptError(err error) error {
+	if errors.Is(err, promptui.ErrInterrupt) || errors.Is(err, promptui.ErrEOF) || errors.Is(err, promptui.ErrAbort) {
+		return fmt.Errorf("%w: %w", ErrAborted, err)
+	}
+	return err
+}
+
+// Stub answers the questions from a map of labels to answers, questions without an answer take their default
+// or fail like a prompt without a terminal. Confirmations are answered with "y" or "n".
+type Stub map[string]string
+
+func (s Stub) answer(q Question) (string, error) {
+	if answer, ok := s[q.Label]; ok {
+		return answer, nil
+	}
+	if q.Default != "" {
+		return q.Default, nil
+	}
+	return "", notInteractive(q)
+}
+
+func (s Stub) Input(q Question) (string, error) {
+	answer, err := s.answer(q)
+	if err != nil {
+		return "", err
+	}
+	if q.Validate != nil {
+		if err := q.Validate(answer); err != nil {
+			return "", err
+		}
+	}
+	return answer, nil
+}
+
+func (s Stub) Select(q Question, items []string) (string, error) {
+	answer, err := s.answer(q)
+	if err != nil {
+		return "", err
+	}
+	for _, item := range items {
+		if item == answer {
+			return answer, nil
+		}
+	}
+	return "", fmt.Errorf("%q is not an item of %q", answer, q.Label)
+}
+
+func (s Stub) Confirm(q Question) (bool, error) {
+	answer, err := s.answer(q)
+	if err != nil {
+		return false, err
+	}
+	return answer == "y" || answer == "Y", nil
+}
+
+// MatchRegex returns a Validate function accepting answers matching pattern
+func MatchRegex(pattern string) func(string) error {
+	re, err := regexp.Compile(pattern)
+	return func(input string) error {
+		if err != nil {
+			return fmt.Errorf("invalid regex pattern: %v", err)
+		}
+		if !re.MatchString(input) {
+			return fmt.Errorf("input does not match required pattern")
+		}
+		return nil
+	}
+}
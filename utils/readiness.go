@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// WaitTimeout bounds each readiness wait of an install, it is set by the global --timeout flag
+var WaitTimeout = 10 * time.Minute
+
+var (
+	crdGVR                 = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	crossplaneProviderGVR  = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "providers"}
+	crossplaneConfigGVR    = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "configurations"}
+	compositeDefinitionGVR = schema.GroupVersionResource{Group: "apiextensions.crossplane.io", Version: "v1", Resource: "compositeresourcedefinitions"}
+	kubeBlocksClusterGVR   = schema.GroupVersionResource{Group: "apps.kubeblocks.io", Version: "v1alpha1", Resource: "clusters"}
+	secretGVR              = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+)
+
+// readinessCheck is a single prerequisite, all checks of a wait are watched concurrently
+type readinessCheck struct {
+	name string
+	wait func(ctx context.Context) error
+}
+
+// waitForAll runs the checks concurrently and returns as soon as all of them are met, or with the first failure
+func waitForAll(ctx context.Context, checks []readinessCheck) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan error, len(checks))
+	for _, check := range checks {
+		go func(check readinessCheck) {
+			if err := check.wait(ctx); err != nil {
+				results <- fmt.Errorf("%s: %w", check.name, err)
+				return
+			}
+			SuccessMessage(fmt.Sprintf("%s is ready", check.name))
+			results <- nil
+		}(check)
+	}
+
+	for range checks {
+		if err := <-results; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readinessContext returns the context every readiness wait runs with, it ends with --timeout or when the
+// command is interrupted
+func readinessContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(CommandContext(), WaitTimeout)
+}
+
+// timeoutAware turns the errors returned when the context expires into a readable timeout or cancellation error
+func timeoutAware(ctx context.Context, err error) error {
+	if err != nil && Canceled() != nil {
+		return Canceled()
+	}
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("%w: not ready within %s (use --timeout to wait longer)", ErrTimeout, FormatDuration(WaitTimeout))
+	}
+	return err
+}
+
+// watchUnstructured watches the objects of gvr (optionally a single one by name) until ready returns true for one of them
+func watchUnstructured(ctx context.Context, client dynamic.Interface, gvr schema.GroupVersionResource, namespace, name string, ready func(*unstructured.Unstructured) bool) error {
+	resource := client.Resource(gvr).Namespace(namespace)
+	selector := fields.Everything().String()
+	if name != "" {
+		selector = fields.OneTermEqualSelector("metadata.name", name).String()
+	}
+
+	lw := &cache.ListWatch{
+		ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return resource.List(ctx, options)
+		},
+		WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return resource.Watch(ctx, options)
+		},
+	}
+
+	_, err := watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{}, nil, func(event watch.Event) (bool, error) {
+		if event.Type == watch.Deleted {
+			return false, nil
+		}
+		obj, ok := event.Object.(*unstructured.Unstructured)
+		return ok && ready(obj), nil
+	})
+	return timeoutAware(ctx, err)
+}
+
+// hasTrueCondition reports whether the object has a status condition of the given type set to "True"
+func hasTrueCondition(obj *unstructured.Unstructured, conditionType string) bool {
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return false
+	}
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == conditionType && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// crdKindCheck waits for an established CRD serving the given kind
+func crdKindCheck(client dynamic.Interface, kind string) readinessCheck {
+	return readinessCheck{
+		name: fmt.Sprintf("%s CRD", kind),
+		wait: func(ctx context.Context) error {
+			return watchUnstructured(ctx, client, crdGVR, "", "", func(crd *unstructured.Unstructured) bool {
+				crdKind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+				return crdKind == kind && hasTrueCondition(crd, "Established")
+			})
+		},
+	}
+}
+
+// crdNameCheck waits for the CRD with the given name to be established
+func crdNameCheck(client dynamic.Interface, name string) readinessCheck {
+	return readinessCheck{
+		name: fmt.Sprintf("CRD %s", name),
+		wait: func(ctx context.Context) error {
+			return watchUnstructured(ctx, client, crdGVR, "", name, func(crd *unstructured.Unstructured) bool {
+				return hasTrueCondition(crd, "Established")
+			})
+		},
+	}
+}
+
+// conditionCheck waits for a cluster scoped object to report the given condition
+func conditionCheck(client dynamic.Interface, gvr schema.GroupVersionResource, name, conditionType string) readinessCheck {
+	return readinessCheck{
+		name: name,
+		wait: func(ctx context.Context) error {
+			return watchUnstructured(ctx, client, gvr, "", name, func(obj *unstructured.Unstructured) bool {
+				return hasTrueCondition(obj, conditionType)
+			})
+		},
+	}
+}
+
+// deploymentCheck waits for all replicas of a deployment to be available, the deployment may not exist yet
+func deploymentCheck(kubeClient apiv1.Interface, namespace, name string) readinessCheck {
+	return readinessCheck{
+		name: fmt.Sprintf("deployment %s/%s", namespace, name),
+		wait: func(ctx context.Context) error {
+			return watchDeploymentAvailable(ctx, kubeClient, namespace, name)
+		},
+	}
+}
+
+func watchDeploymentAvailable(ctx context.Context, kubeClient apiv1.Interface, namespace, name string) error {
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return kubeClient.AppsV1().Deployments(namespace).List(ctx, options)
+		},
+		WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return kubeClient.AppsV1().Deployments(namespace).Watch(ctx, options)
+		},
+	}
+
+	_, err := watchtools.UntilWithSync(ctx, lw, &appsv1.Deployment{}, nil, func(event watch.Event) (bool, error) {
+		deployment, ok := event.Object.(*appsv1.Deployment)
+		if !ok || event.Type == watch.Deleted {
+			return false, nil
+		}
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		return deployment.Status.ObservedGeneration >= deployment.Generation &&
+			deployment.Status.ReadyReplicas == replicas &&
+			deployment.Status.AvailableReplicas == replicas, nil
+	})
+	return timeoutAware(ctx, err)
+}
+
+// deploymentExists reports whether a deployment exists, used for prerequisites that are optional
+func deploymentExists(ctx context.Context, kubeClient apiv1.Interface, namespace, name string) bool {
+	_, err := kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, v1.GetOptions{})
+	return err == nil
+}
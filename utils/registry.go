@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// defaultChartRegistry is the public OCI registry of the Grapple charts
+const defaultChartRegistry = "oci://public.ecr.aws/p7h7z5g3"
+
+var (
+	// ChartRegistry overrides the OCI registry the Grapple charts are pulled from, e.g. a private mirror
+	ChartRegistry string
+	// ImageRegistry is prefixed to the images of Grapple, the helpers and the charts, e.g. registry.corp.com/dockerhub
+	ImageRegistry string
+)
+
+var manifestImageRegex = regexp.MustCompile(`(?m)^(\s*-?\s*image:\s*["']?)([^"'\s]+)`)
+
+// GrplChartRef returns the OCI reference of a Grapple chart, from --chart-registry or the chart-registry setting
+func GrplChartRef(chart string) string {
+	return fmt.Sprintf("%s/%s", grplChartRegistry(), chart)
+}
+
+func grplChartRegistry() string {
+	chartRegistry := ChartRegistry
+	if chartRegistry == "" {
+		chartRegistry = ConfigValue("chart-registry")
+	}
+	if chartRegistry == "" {
+		return defaultChartRegistry
+	}
+	if !strings.Contains(chartRegistry, "://") {
+		chartRegistry = "oci://" + chartRegistry
+	}
+	return strings.TrimSuffix(chartRegistry, "/")
+}
+
+// imageRegistry returns the registry mirror of the images, "" to use the registries of the images as is
+func imageRegistry() string {
+	mirror := ImageRegistry
+	if mirror == "" {
+		mirror = ConfigValue("image-registry")
+	}
+	return strings.TrimSuffix(mirror, "/")
+}
+
+// NewChartRegistryClient returns a registry client for the chart registry. Credentials are taken from the
+// chart-registry-username/password settings, or from a docker config file set with registry-config.
+func NewChartRegistryClient() (*registry.Client, error) {
+	var opts []registry.ClientOption
+	if credentialsFile := ConfigValue("registry-config"); credentialsFile != "" {
+		opts = append(opts, registry.ClientOptCredentialsFile(credentialsFile))
+	}
+	if username := ConfigValue("chart-registry-username"); username != "" {
+		opts = append(opts, registry.ClientOptBasicAuth(username, ConfigValue("chart-registry-password")))
+	}
+
+	client, err := registry.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client: %w", err)
+	}
+
+	// Stale credentials of the public registry make anonymous pulls fail
+	if grplChartRegistry() == defaultChartRegistry {
+		_ = LogoutHelmRegistry(client)
+	}
+	return client, nil
+}
+
+// MirrorImage returns the image pulled through the image registry mirror, if one is configured
+func MirrorImage(image string) string {
+	mirror := imageRegistry()
+	if mirror == "" {
+		return image
+	}
+
+	// Images of Docker Hub have no registry host, e.g. grpl/grapi:0.3.5 or nginx:latest
+	if first, rest, found := strings.Cut(image, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		image = rest
+	}
+	return mirror + "/" + image
+}
+
+// MirrorImagesInManifest rewrites the image fields of a YAML manifest to the image registry mirror
+func MirrorImagesInManifest(manifest string) string {
+	if imageRegistry() == "" {
+		return manifest
+	}
+	return manifestImageRegex.ReplaceAllStringFunc(manifest, func(match string) string {
+		parts := manifestImageRegex.FindStringSubmatch(match)
+		return parts[1] + MirrorImage(parts[2])
+	})
+}
+
+// AddRegistryOverrideToValues sets global.imageRegistry in helm values so charts pull their images through the mirror
+func AddRegistryOverrideToValues(vals map[string]interface{}) {
+	mirror := imageRegistry()
+	if mirror == "" {
+		return
+	}
+	global, ok := vals["global"].(map[string]interface{})
+	if !ok {
+		global = map[string]interface{}{}
+		vals["global"] = global
+	}
+	if _, set := global["imageRegistry"]; !set {
+		global["imageRegistry"] = mirror
+	}
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Workdir is a directory with template-files and files overriding the ones of the installation, it is set by the
+// global --workdir flag
+var Workdir string
+
+// ProjectResourcesDir is the directory of the project local overrides, relative to the current directory
+const ProjectResourcesDir = "grpl-templates"
+
+// Sources of the template-files and files, in order of precedence
+const (
+	ResourceSourceFlag         = "--workdir"
+	ResourceSourceEnv          = "GRPL_WORKDIR"
+	ResourceSourceProject      = "project"
+	ResourceSourceInstallation = "installation"
+)
+
+// ResourceCandidate is a place a file of template-files or files is looked up in
+type ResourceCandidate struct {
+	Source string `json:"source" yaml:"source"`
+	Path   string `json:"path" yaml:"path"`
+	Exists bool   `json:"exists" yaml:"exists"`
+}
+
+// resourceRoot is a directory with template-files and files subdirectories
+type resourceRoot struct {
+	source string
+	dir    string
+}
+
+// resourceRoots returns the directories resources are looked up in, in order of precedence: --workdir, the
+// workdir setting (GRPL_WORKDIR), ./grpl-templates and the share directory of the installation
+func resourceRoots() []resourceRoot {
+	var roots []resourceRoot
+	if Workdir != "" {
+		roots = append(roots, resourceRoot{source: ResourceSourceFlag, dir: Workdir})
+	}
+	if dir := ConfigValue("workdir"); dir != "" && dir != Workdir {
+		roots = append(roots, resourceRoot{source: ResourceSourceEnv, dir: dir})
+	}
+	roots = append(roots, resourceRoot{source: ResourceSourceProject, dir: ProjectResourcesDir})
+	if execPath, err := os.Executable(); err == nil {
+		// The layout of GetResourcePath, bin/grapple next to share/grapple-go-cli
+		roots = append(roots, resourceRoot{source: ResourceSourceInstallation, dir: filepath.Join(filepath.Dir(filepath.Dir(execPath)), "share", "grapple-go-cli")})
+	}
+	return roots
+}
+
+// ResourceCandidates returns where subdir/name is looked up, in order of precedence
+func ResourceCandidates(subdir, name string) []ResourceCandidate {
+	var candidates []ResourceCandidate
+	for _, root := range resourceRoots() {
+		path := filepath.Join(root.dir, subdir, name)
+		info, err := os.Stat(path)
+		candidates = append(candidates, ResourceCandidate{Source: root.source, Path: path, Exists: err == nil && !info.IsDir()})
+	}
+	return candidates
+}
+
+// ResolveResourceFile returns the path of subdir/name, e.g. template-files/db.yaml, in the first source that has
+// it. Overrides only need the files they change, the others come from the installation.
+func ResolveResourceFile(subdir, name string) (string, error) {
+	for _, candidate := range ResourceCandidates(subdir, name) {
+		if candidate.Exists {
+			if candidate.Source != ResourceSourceInstallation {
+				DebugMessage(fmt.Sprintf("Using %s/%s of %s: %s", subdir, name, candidate.Source, candidate.Path))
+			}
+			return candidate.Path, nil
+		}
+	}
+	return "", fmt.Errorf("%s/%s not found in the workdir, ./%s or the installation (see 'grapple resource templates which %s/%s')",
+		subdir, name, ProjectResourcesDir, subdir, name)
+}
+
+// ResourceDirs returns the existing subdir directories of the sources, in order of precedence
+func ResourceDirs(subdir string) []string {
+	var dirs []string
+	for _, root := range resourceRoots() {
+		dir := filepath.Join(root.dir, subdir)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeResource creates root/subdir/name
+func writeResource(t *testing.T, root, subdir, name string) {
+	t.Helper()
+	dir := filepath.Join(root, subdir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("kind: Test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveResourceFileOrdering(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []string // sources that have the file
+		want    string
+		wantErr bool
+	}{
+		{name: "workdir flag first", in: []string{ResourceSourceFlag, ResourceSourceEnv, ResourceSourceProject}, want: ResourceSourceFlag},
+		{name: "workdir setting before project", in: []string{ResourceSourceEnv, ResourceSourceProject}, want: ResourceSourceEnv},
+		{name: "project", in: []string{ResourceSourceProject}, want: ResourceSourceProject},
+		{name: "overrides without the file fall through", in: []string{ResourceSourceEnv}, want: ResourceSourceEnv},
+		{name: "not found", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			dirs := map[string]string{
+				ResourceSourceFlag:    filepath.Join(tmp, "flag"),
+				ResourceSourceEnv:     filepath.Join(tmp, "env"),
+				ResourceSourceProject: filepath.Join(tmp, "project", ProjectResourcesDir),
+			}
+			for _, source := range tt.in {
+				writeResource(t, dirs[source], "template-files", "db.yaml")
+			}
+			// The other overrides exist, without the file
+			for _, dir := range dirs {
+				if err := os.MkdirAll(filepath.Join(dir, "template-files"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			oldWorkdir := Workdir
+			Workdir = dirs[ResourceSourceFlag]
+			t.Cleanup(func() { Workdir = oldWorkdir })
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "config"))
+			t.Setenv("GRPL_WORKDIR", dirs[ResourceSourceEnv])
+			if err := LoadConfig(); err != nil {
+				t.Fatal(err)
+			}
+			wd, err := os.Getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chdir(filepath.Join(tmp, "project")); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = os.Chdir(wd) })
+
+			got, err := ResolveResourceFile("template-files", "db.yaml")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ResolveResourceFile() = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveResourceFile() error = %v", err)
+			}
+			want := filepath.Join(dirs[tt.want], "template-files", "db.yaml")
+			if tt.want == ResourceSourceProject {
+				want = filepath.Join(ProjectResourcesDir, "template-files", "db.yaml")
+			}
+			if got != want {
+				t.Errorf("ResolveResourceFile() = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"helm.sh/helm/v3/pkg/action"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	SBOMFormatSPDX      = "spdx"
+	SBOMFormatCycloneDX = "cyclonedx"
+	SBOMFormatTable     = "table"
+)
+
+// platformNamespaces hold the workloads of the Grapple platform itself
+var platformNamespaces = []string{"grpl-system", "kb-system"}
+
+// SBOMComponent is an image or helm chart running on the cluster
+type SBOMComponent struct {
+	// Type is "container" for images and "chart" for helm releases
+	Type         string   `json:"type" yaml:"type"`
+	Name         string   `json:"name" yaml:"name"`
+	Version      string   `json:"version,omitempty" yaml:"version,omitempty"`
+	Digest       string   `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Namespaces   []string `json:"namespaces" yaml:"namespaces"`
+	Release      string   `json:"release,omitempty" yaml:"release,omitempty"`
+	License      string   `json:"license,omitempty" yaml:"license,omitempty"`
+	Attestations []string `json:"attestations,omitempty" yaml:"attestations,omitempty"`
+}
+
+// SBOM is the inventory of the Grapple platform and the GRAS workloads of a cluster
+type SBOM struct {
+	Created        time.Time       `json:"created" yaml:"created"`
+	GrappleVersion string          `json:"grappleVersion,omitempty" yaml:"grappleVersion,omitempty"`
+	Components     []SBOMComponent `json:"components" yaml:"components"`
+}
+
+// CollectSBOM lists the helm releases and the images (with their digests) of the platform namespaces and of
+// the namespaces holding GrappleApplicationSets. With queryRegistries the registries are asked for the
+// license label and the SBOM attestations of every image.
+func CollectSBOM(kubeClient apiv1.Interface, restConfig *rest.Config, queryRegistries bool) (*SBOM, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	sbom := &SBOM{Created: time.Now().UTC()}
+	if version, err := GetGrplReleaseVersion(restConfig, "grsf", "grpl-system"); err == nil {
+		sbom.GrappleVersion = version
+	}
+
+	namespaces := append([]string{}, platformNamespaces...)
+	if gras, err := dynamicClient.Resource(grasGVR).List(ctx, v1.ListOptions{}); err == nil {
+		for _, item := range gras.Items {
+			if !Contains(namespaces, item.GetNamespace()) {
+				namespaces = append(namespaces, item.GetNamespace())
+			}
+		}
+	}
+
+	charts, err := releaseComponents(restConfig, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	images, err := imageComponents(ctx, kubeClient, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	if queryRegistries {
+		for i := range images {
+			if images[i].Digest == "" {
+				continue
+			}
+			info, err := fetchImageRegistryInfo(ctx, images[i].Name, images[i].Digest)
+			if err != nil {
+				InfoMessage(fmt.Sprintf("Could not query the registry of %s: %v", images[i].Name, err))
+				continue
+			}
+			images[i].License = info.License
+			images[i].Attestations = info.Attestations
+		}
+	}
+
+	sbom.Components = append(charts, images...)
+	return sbom, nil
+}
+
+// releaseComponents returns the deployed helm releases of the namespaces
+func releaseComponents(restConfig *rest.Config, namespaces []string) ([]SBOMComponent, error) {
+	var components []SBOMComponent
+	for _, namespace := range namespaces {
+		helmConfig, err := GetHelmConfig(restConfig, namespace)
+		if err != nil {
+			return nil, err
+		}
+		list := action.NewList(helmConfig)
+		list.Deployed = true
+		releases, err := list.Run()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases of namespace %s: %w", namespace, err)
+		}
+		for _, rel := range releases {
+			if rel.Chart == nil || rel.Chart.Metadata == nil {
+				continue
+			}
+			component := SBOMComponent{
+				Type:       "chart",
+				Name:       rel.Chart.Metadata.Name,
+				Version:    rel.Chart.Metadata.Version,
+				Namespaces: []string{rel.Namespace},
+				Release:    rel.Name,
+			}
+			// Charts published on artifacthub declare their license in an annotation
+			if license, ok := rel.Chart.Metadata.Annotations["artifacthub.io/license"]; ok {
+				component.License = license
+			}
+			components = append(components, component)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i].Release < components[j].Release })
+	return components, nil
+}
+
+// imageComponents returns the images the pods of the namespaces run, one per image digest
+func imageComponents(ctx context.Context, kubeClient apiv1.Interface, namespaces []string) ([]SBOMComponent, error) {
+	byImage := map[string]*SBOMComponent{}
+	for _, namespace := range namespaces {
+		pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, v1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods of namespace %s: %w", namespace, err)
+		}
+		for _, pod := range pods.Items {
+			statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+			for _, status := range statuses {
+				name, version := splitImage(status.Image)
+				digest := ""
+				if _, d, found := strings.Cut(status.ImageID, "@"); found {
+					digest = d
+				}
+				key := name + "@" + digest
+				if digest == "" {
+					key = name + ":" + version
+				}
+				component, ok := byImage[key]
+				if !ok {
+					component = &SBOMComponent{Type: "container", Name: name, Version: version, Digest: digest}
+					byImage[key] = component
+				}
+				if !Contains(component.Namespaces, namespace) {
+					component.Namespaces = append(component.Namespaces, namespace)
+				}
+			}
+		}
+	}
+
+	components := make([]SBOMComponent, 0, len(byImage))
+	for _, component := range byImage {
+		components = append(components, *component)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Name != components[j].Name {
+			return components[i].Name < components[j].Name
+		}
+		return components[i].Version < components[j].Version
+	})
+	return components, nil
+}
+
+// splitImage splits an image reference into the repository and the tag, e.g. grpl/grapi:0.3.5
+func splitImage(image string) (string, string) {
+	image, _, _ = strings.Cut(image, "@")
+	slash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > slash {
+		return image[:colon], image[colon+1:]
+	}
+	return image, "latest"
+}
+
+// SBOMDocument renders the SBOM in the SPDX 2.3 or CycloneDX 1.5 JSON format
+func SBOMDocument(sbom *SBOM, format string) (map[string]interface{}, error) {
+	switch format {
+	case SBOMFormatSPDX:
+		return spdxDocument(sbom), nil
+	case SBOMFormatCycloneDX:
+		return cycloneDXDocument(sbom), nil
+	}
+	return nil, fmt.Errorf("unsupported SBOM format %q, use one of: %s, %s", format, SBOMFormatSPDX, SBOMFormatCycloneDX)
+}
+
+func spdxDocument(sbom *SBOM) map[string]interface{} {
+	packages := []map[string]interface{}{}
+	relationships := []map[string]interface{}{}
+	for i, component := range sbom.Components {
+		id := fmt.Sprintf("SPDXRef-%s-%d", component.Type, i+1)
+		license := component.License
+		if license == "" {
+			license = "NOASSERTION"
+		}
+		pkg := map[string]interface{}{
+			"SPDXID":           id,
+			"name":             component.Name,
+			"versionInfo":      component.Version,
+			"downloadLocation": "NOASSERTION",
+			"filesAnalyzed":    false,
+			"licenseConcluded": "NOASSERTION",
+			"licenseDeclared":  license,
+			"primaryPackagePurpose": map[string]string{
+				"container": "CONTAINER",
+				"chart":     "APPLICATION",
+			}[component.Type],
+			"externalRefs": []map[string]string{{
+				"referenceCategory": "PACKAGE-MANAGER",
+				"referenceType":     "purl",
+				"referenceLocator":  componentPurl(component),
+			}},
+		}
+		if component.Digest != "" {
+			if algorithm, value, found := strings.Cut(component.Digest, ":"); found {
+				pkg["checksums"] = []map[string]string{{"algorithm": strings.ToUpper(algorithm), "checksumValue": value}}
+			}
+		}
+		if len(component.Attestations) > 0 {
+			pkg["comment"] = "SBOM attestations: " + strings.Join(component.Attestations, ", ")
+		}
+		packages = append(packages, pkg)
+		relationships = append(relationships, map[string]interface{}{
+			"spdxElementId":      "SPDXRef-DOCUMENT",
+			"relationshipType":   "DESCRIBES",
+			"relatedSpdxElement": id,
+		})
+	}
+
+	return map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              "grapple-" + sbomVersion(sbom),
+		"documentNamespace": "https://grapple-solutions.com/spdx/" + uuid.NewString(),
+		"creationInfo": map[string]interface{}{
+			"created":  sbom.Created.Format(time.RFC3339),
+			"creators": []string{"Tool: grapple-cli-" + GetGrappleCliVersion()},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
+
+func cycloneDXDocument(sbom *SBOM) map[string]interface{} {
+	components := []map[string]interface{}{}
+	for _, component := range sbom.Components {
+		purl := componentPurl(component)
+		c := map[string]interface{}{
+			"bom-ref": purl,
+			"type":    map[string]string{"container": "container", "chart": "application"}[component.Type],
+			"name":    component.Name,
+			"version": component.Version,
+			"purl":    purl,
+		}
+		if component.Digest != "" {
+			if algorithm, value, found := strings.Cut(component.Digest, ":"); found {
+				c["hashes"] = []map[string]string{{"alg": cycloneDXHashAlg(algorithm), "content": value}}
+			}
+		}
+		if component.License != "" {
+			c["licenses"] = []map[string]string{{"expression": component.License}}
+		}
+		properties := []map[string]string{{"name": "grpl:namespaces", "value": strings.Join(component.Namespaces, ",")}}
+		if component.Release != "" {
+			properties = append(properties, map[string]string{"name": "grpl:helm-release", "value": component.Release})
+		}
+		for _, attestation := range component.Attestations {
+			properties = append(properties, map[string]string{"name": "grpl:sbom-attestation", "value": attestation})
+		}
+		c["properties"] = properties
+		components = append(components, c)
+	}
+
+	return map[string]interface{}{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + uuid.NewString(),
+		"version":      1,
+		"metadata": map[string]interface{}{
+			"timestamp": sbom.Created.Format(time.RFC3339),
+			"tools": map[string]interface{}{
+				"components": []map[string]string{{"type": "application", "name": "grapple-cli", "version": GetGrappleCliVersion()}},
+			},
+			"component": map[string]string{"type": "platform", "name": "grapple", "version": sbomVersion(sbom)},
+		},
+		"components": components,
+	}
+}
+
+func sbomVersion(sbom *SBOM) string {
+	if sbom.GrappleVersion == "" {
+		return "unknown"
+	}
+	return sbom.GrappleVersion
+}
+
+// componentPurl returns the package URL of an image (pkg:oci) or a helm chart (pkg:helm)
+func componentPurl(component SBOMComponent) string {
+	if component.Type == "chart" {
+		return fmt.Sprintf("pkg:helm/%s@%s", component.Name, component.Version)
+	}
+
+	host, repository := splitRegistryHost(component.Name)
+	name := repository[strings.LastIndex(repository, "/")+1:]
+	version := component.Digest
+	if version == "" {
+		version = component.Version
+	}
+	return fmt.Sprintf("pkg:oci/%s@%s?repository_url=%s/%s&tag=%s", name, strings.ReplaceAll(version, ":", "%3A"), host, repository, component.Version)
+}
+
+func cycloneDXHashAlg(algorithm string) string {
+	switch algorithm {
+	case "sha256":
+		return "SHA-256"
+	case "sha512":
+		return "SHA-512"
+	}
+	return strings.ToUpper(algorithm)
+}
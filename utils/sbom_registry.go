@@ -0,0 +1,247 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+var (
+	registryHTTPClient = &http.Client{Timeout: 15 * time.Second}
+	authParamRegex     = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// imageRegistryInfo is what the registry knows about an image beyond the cluster
+type imageRegistryInfo struct {
+	License      string
+	Attestations []string
+}
+
+type ociDescriptor struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType"`
+	Digest       string            `json:"digest"`
+	Annotations  map[string]string `json:"annotations"`
+	Platform     *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform"`
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Config    ociDescriptor   `json:"config"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// registryRepository talks to the distribution API of one repository, anonymously with a bearer token if
+// the registry requires one
+type registryRepository struct {
+	host       string
+	repository string
+	token      string
+}
+
+// splitRegistryHost returns the registry host and the repository of an image name, Docker Hub images
+// have no host, e.g. grpl/grapi or nginx
+func splitRegistryHost(name string) (string, string) {
+	if first, rest, found := strings.Cut(name, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first, rest
+	}
+	if !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	return "docker.io", name
+}
+
+// fetchImageRegistryInfo reads the license label of the image config and lists the SBOM attestations of
+// the image: OCI referrers, buildx attestation manifests and cosign .att/.sbom tags
+func fetchImageRegistryInfo(ctx context.Context, name, digest string) (*imageRegistryInfo, error) {
+	host, repository := splitRegistryHost(name)
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	repo := &registryRepository{host: host, repository: repository}
+	info := &imageRegistryInfo{}
+
+	manifest, err := repo.manifest(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Manifests) > 0 {
+		platformDigest := ""
+		for _, m := range manifest.Manifests {
+			if m.Annotations["vnd.docker.reference.type"] == "attestation-manifest" {
+				info.Attestations = append(info.Attestations, "attestation-manifest@"+m.Digest)
+				continue
+			}
+			if platformDigest == "" || (m.Platform != nil && m.Platform.OS == "linux" && m.Platform.Architecture == "amd64") {
+				platformDigest = m.Digest
+			}
+		}
+		if platformDigest != "" {
+			if manifest, err = repo.manifest(ctx, platformDigest); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if manifest.Config.Digest != "" {
+		var config struct {
+			Config struct {
+				Labels map[string]string `json:"Labels"`
+			} `json:"config"`
+		}
+		if err := repo.getJSON(ctx, "/blobs/"+manifest.Config.Digest, "", &config); err == nil {
+			info.License = config.Config.Labels["org.opencontainers.image.licenses"]
+		}
+	}
+
+	var referrers ociManifest
+	if err := repo.getJSON(ctx, "/referrers/"+digest, mediaTypeOCIIndex, &referrers); err == nil {
+		for _, referrer := range referrers.Manifests {
+			if isSBOMArtifact(referrer.ArtifactType) {
+				info.Attestations = append(info.Attestations, referrer.ArtifactType+"@"+referrer.Digest)
+			}
+		}
+	}
+
+	// cosign stores attestations and SBOMs under tags derived from the digest
+	cosignTag := strings.Replace(digest, ":", "-", 1)
+	for _, suffix := range []string{".att", ".sbom"} {
+		resp, err := repo.do(ctx, http.MethodHead, "/manifests/"+cosignTag+suffix, strings.Join([]string{mediaTypeOCIManifest, mediaTypeDockerManifest}, ","))
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			info.Attestations = append(info.Attestations, fmt.Sprintf("%s/%s:%s%s", host, repository, cosignTag, suffix))
+		}
+	}
+
+	return info, nil
+}
+
+func isSBOMArtifact(artifactType string) bool {
+	for _, kind := range []string{"spdx", "cyclonedx", "in-toto", "sbom"} {
+		if strings.Contains(artifactType, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *registryRepository) manifest(ctx context.Context, reference string) (*ociManifest, error) {
+	accept := strings.Join([]string{mediaTypeOCIIndex, mediaTypeOCIManifest, mediaTypeDockerManifestList, mediaTypeDockerManifest}, ",")
+	manifest := &ociManifest{}
+	if err := r.getJSON(ctx, "/manifests/"+reference, accept, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func (r *registryRepository) getJSON(ctx context.Context, path, accept string, target interface{}) error {
+	resp, err := r.do(ctx, http.MethodGet, path, accept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// do sends a request to the repository, answering a bearer challenge with an anonymous token
+func (r *registryRepository) do(ctx context.Context, method, path, accept string) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("https://%s/v2/%s%s", r.host, r.repository, path), nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if r.token != "" {
+			req.Header.Set("Authorization", "Bearer "+r.token)
+		}
+		return registryHTTPClient.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || r.token != "" {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if err := r.fetchToken(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+func (r *registryRepository) fetchToken(ctx context.Context, challenge string) error {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return fmt.Errorf("registry %s requires authentication", r.host)
+	}
+	params := map[string]string{}
+	for _, match := range authParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	if params["realm"] == "" {
+		return fmt.Errorf("registry %s sent an invalid authentication challenge", r.host)
+	}
+
+	query := url.Values{}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", r.repository)
+	}
+	query.Set("scope", scope)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := registryHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get a token of registry %s: %w", r.host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to get a token of registry %s: %s %s", r.host, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to parse the token of registry %s: %w", r.host, err)
+	}
+	r.token = token.Token
+	if r.token == "" {
+		r.token = token.AccessToken
+	}
+	return nil
+}
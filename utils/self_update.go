@@ -0,0 +1,368 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	cliReleasesListURL = "https://api.github.com/repos/grapple-solution/grapple-go-cli/releases?per_page=30"
+	// ReleaseChecksumsAsset lists the sha256 of the release archives, written by the release pipelines
+	ReleaseChecksumsAsset = "checksums.txt"
+	// ReleaseSignatureAsset is the ed25519 signature of checksums.txt, written by the release pipelines
+	ReleaseSignatureAsset = "checksums.txt.sig"
+)
+
+// ReleaseSigningKey is the base64 encoded ed25519 public key the checksums of the releases are signed with, it is
+// set at release builds with -ldflags "-X github.com/grapple-solution/grapple_cli/utils.ReleaseSigningKey=..."
+var ReleaseSigningKey string
+
+// CLIRelease is a GitHub release of the CLI with its download URLs by asset name
+type CLIRelease struct {
+	Version    string            `json:"version" yaml:"version"`
+	Prerelease bool              `json:"prerelease" yaml:"prerelease"`
+	Assets     map[string]string `json:"-" yaml:"-"`
+}
+
+// ReleaseArchiveName returns the archive of the release pipelines for the running OS and architecture
+func ReleaseArchiveName() string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf("grapple-%s-%s.zip", runtime.GOOS, runtime.GOARCH)
+	}
+	return fmt.Sprintf("grapple-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+}
+
+// FindCLIRelease returns the release to update to: the given version, or the newest release of the channel.
+// The stable channel only has full releases, beta includes the prereleases of the test pipeline. Releases
+// without an archive for the running platform are skipped.
+func FindCLIRelease(channel, version string) (*CLIRelease, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(cliReleasesListURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CLI releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list CLI releases: unexpected status %s", resp.Status)
+	}
+
+	var releases []struct {
+		TagName    string `json:"tag_name"`
+		Prerelease bool   `json:"prerelease"`
+		Draft      bool   `json:"draft"`
+		Assets     []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to parse CLI releases: %w", err)
+	}
+
+	var best *CLIRelease
+	var bestVersion *semver.Version
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel != "beta" && version == "") {
+			continue
+		}
+		release := &CLIRelease{Version: strings.TrimPrefix(r.TagName, "v"), Prerelease: r.Prerelease, Assets: map[string]string{}}
+		for _, asset := range r.Assets {
+			release.Assets[asset.Name] = asset.URL
+		}
+		if _, ok := release.Assets[ReleaseArchiveName()]; !ok {
+			continue
+		}
+		if version != "" {
+			if release.Version == strings.TrimPrefix(version, "v") {
+				return release, nil
+			}
+			continue
+		}
+		v, err := semver.NewVersion(release.Version)
+		if err != nil {
+			continue
+		}
+		if bestVersion == nil || v.GreaterThan(bestVersion) {
+			best, bestVersion = release, v
+		}
+	}
+	if version != "" {
+		return nil, fmt.Errorf("%w: release %s has no %s", ErrValidation, version, ReleaseArchiveName())
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no %s release has a %s", channel, ReleaseArchiveName())
+	}
+	return best, nil
+}
+
+// DownloadCLIRelease downloads the archive of the release to dir and verifies it against the checksums of the
+// release and the checksums against their signature, unless skipChecksum is set
+func DownloadCLIRelease(release *CLIRelease, dir string, skipChecksum bool) (string, error) {
+	name := ReleaseArchiveName()
+	archive := filepath.Join(dir, name)
+	downloader := NewDownloader()
+
+	if skipChecksum {
+		InfoMessage("Skipping the checksum verification of " + name)
+		if _, err := downloader.Fetch(Download{URL: release.Assets[name], Path: archive}); err != nil {
+			return "", err
+		}
+		return archive, nil
+	}
+	checksumsURL, ok := release.Assets[ReleaseChecksumsAsset]
+	if !ok {
+		return "", fmt.Errorf("release %s has no %s to verify %s against, use --skip-checksum to install it anyway", release.Version, ReleaseChecksumsAsset, name)
+	}
+	checksums := filepath.Join(dir, ReleaseChecksumsAsset)
+	if _, err := downloader.Fetch(Download{URL: checksumsURL, Path: checksums}); err != nil {
+		return "", err
+	}
+	if err := verifyChecksumsSignature(release, downloader, checksums); err != nil {
+		return "", err
+	}
+	expected, err := lookupChecksum(checksums, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := downloader.Fetch(Download{URL: release.Assets[name], Path: archive, SHA256: expected}); err != nil {
+		return "", err
+	}
+	SuccessMessage(fmt.Sprintf("Verified sha256 of %s", name))
+	return archive, nil
+}
+
+// verifyChecksumsSignature verifies the checksums file of the release against its signature and the signing key
+// the CLI was built with, so a release asset replaced together with its checksum is rejected
+func verifyChecksumsSignature(release *CLIRelease, downloader *Downloader, checksums string) error {
+	if ReleaseSigningKey == "" {
+		return fmt.Errorf("this build of the CLI has no release signing key to verify %s against, use --skip-checksum to install release %s anyway", ReleaseChecksumsAsset, release.Version)
+	}
+	key, err := base64.StdEncoding.DecodeString(ReleaseSigningKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release signing key of the CLI build")
+	}
+	signatureURL, ok := release.Assets[ReleaseSignatureAsset]
+	if !ok {
+		return fmt.Errorf("release %s has no %s to verify %s against, use --skip-checksum to install it anyway", release.Version, ReleaseSignatureAsset, ReleaseChecksumsAsset)
+	}
+	signaturePath := checksums + ".sig"
+	if _, err := downloader.Fetch(Download{URL: signatureURL, Path: signaturePath}); err != nil {
+		return err
+	}
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(checksums)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), content, signature) {
+		return fmt.Errorf("the signature of %s of release %s is invalid", ReleaseChecksumsAsset, release.Version)
+	}
+	SuccessMessage(fmt.Sprintf("Verified signature of %s", ReleaseChecksumsAsset))
+	return nil
+}
+
+// lookupChecksum reads the checksum of name from a sha256sum file
+func lookupChecksum(path, name string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if sum, ok := sha256sumLookup(string(content), name); ok {
+		return sum, nil
+	}
+	return "", fmt.Errorf("%s has no checksum of %s", ReleaseChecksumsAsset, name)
+}
+
+// ExtractCLIArchive unpacks a release archive into dir and returns the directory of its content
+// (grapple-<os>-<arch> with the binary, template-files and files)
+func ExtractCLIArchive(archive, dir string) (string, error) {
+	var err error
+	if strings.HasSuffix(archive, ".zip") {
+		err = extractZip(archive, dir)
+	} else {
+		err = extractTarGz(archive, dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", filepath.Base(archive), err)
+	}
+	return filepath.Join(dir, strings.TrimSuffix(strings.TrimSuffix(filepath.Base(archive), ".zip"), ".tar.gz")), nil
+}
+
+// safeJoin joins an archive entry to dir, entries escaping dir are rejected
+func safeJoin(dir, name string) (string, error) {
+	target := filepath.Join(dir, name)
+	if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid archive entry %s", name)
+	}
+	return target, nil
+}
+
+func extractTarGz(archive, dir string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := safeJoin(dir, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeExtracted(target, reader, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractZip(archive, dir string) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for _, entry := range reader.File {
+		target, err := safeJoin(dir, entry.Name)
+		if err != nil {
+			return err
+		}
+		if entry.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		content, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		err = writeExtracted(target, content, entry.Mode())
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeExtracted(target string, content io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, content)
+	return err
+}
+
+// ReplaceExecutable swaps the running executable for newBinary. The new binary is copied next to the current
+// one and renamed over it, so the executable is never missing or half written. Windows can't replace a running
+// executable, it is moved aside to <name>.old first.
+func ReplaceExecutable(current, newBinary string) error {
+	dir := filepath.Dir(current)
+	staged, err := os.CreateTemp(dir, ".grapple-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to write to %s, run the update with permissions to replace %s: %w", dir, current, err)
+	}
+	stagedPath := staged.Name()
+	defer os.Remove(stagedPath)
+
+	source, err := os.Open(newBinary)
+	if err != nil {
+		staged.Close()
+		return err
+	}
+	_, err = io.Copy(staged, source)
+	source.Close()
+	if closeErr := staged.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stage the new executable: %w", err)
+	}
+	if err := os.Chmod(stagedPath, 0755); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := current + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(current, old); err != nil {
+			return fmt.Errorf("failed to move %s aside: %w", current, err)
+		}
+	}
+	if err := os.Rename(stagedPath, current); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", current, err)
+	}
+	return nil
+}
+
+// ReplaceSharedFiles replaces the template-files and files directories in the share directory of the install and
+// records the version of the release in its VERSION file
+func ReplaceSharedFiles(shareDir, releaseDir, version string) error {
+	for _, name := range []string{"template-files", "files"} {
+		source := filepath.Join(releaseDir, name)
+		if _, err := os.Stat(source); err != nil {
+			continue
+		}
+		target := filepath.Join(shareDir, name)
+		staged := target + ".new"
+		_ = os.RemoveAll(staged)
+		if err := os.Rename(source, staged); err != nil {
+			// the release was extracted to another filesystem, copy it instead
+			if err := os.CopyFS(staged, os.DirFS(source)); err != nil {
+				return fmt.Errorf("failed to stage %s: %w", name, err)
+			}
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return fmt.Errorf("failed to remove the old %s: %w", name, err)
+		}
+		if err := os.Rename(staged, target); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(shareDir, "VERSION"), []byte(version+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write the VERSION file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"k8s.io/client-go/rest"
+)
+
+// SSHBastion is the jump host the API server is reached through (--ssh-bastion user@host[:port]), SSHKey the
+// private key authenticating on it (--ssh-key), the keys of the ssh agent and ~/.ssh/id_* are tried otherwise
+var (
+	SSHBastion string
+	SSHKey     string
+)
+
+// sshTunnel forwards a local port to the API server through the bastion
+type sshTunnel struct {
+	client   *ssh.Client
+	listener net.Listener
+	// target is the host:port of the API server, as the bastion reaches it
+	target string
+}
+
+var (
+	// tunnelMu guards tunnels, which are keyed by the host:port of the API server
+	tunnelMu sync.Mutex
+	tunnels  = map[string]*sshTunnel{}
+)
+
+// TunnelRESTConfig routes a rest config through the SSH bastion if one is set: the host becomes a local port
+// forwarded by the bastion and the TLS server name keeps the certificate of the API server valid. helm is pointed
+// at the tunnel too, through HELM_KUBEAPISERVER and HELM_KUBETLS_SERVER_NAME.
+func TunnelRESTConfig(config *rest.Config) error {
+	if SSHBastion == "" {
+		return nil
+	}
+	server, err := url.Parse(config.Host)
+	if err != nil || server.Host == "" {
+		return fmt.Errorf("%w: failed to parse the API server address %q: %w", ErrValidation, config.Host, err)
+	}
+	port := server.Port()
+	if port == "" {
+		port = "443"
+		if server.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	tunnel, err := openSSHTunnel(net.JoinHostPort(server.Hostname(), port))
+	if err != nil {
+		return err
+	}
+	if config.TLSClientConfig.ServerName == "" {
+		config.TLSClientConfig.ServerName = server.Hostname()
+	}
+	server.Host = tunnel.listener.Addr().String()
+	config.Host = server.String()
+
+	os.Setenv("HELM_KUBEAPISERVER", config.Host)
+	os.Setenv("HELM_KUBETLS_SERVER_NAME", config.TLSClientConfig.ServerName)
+	return nil
+}
+
+// openSSHTunnel returns the tunnel to target, connecting to the bastion on first use
+func openSSHTunnel(target string) (*sshTunnel, error) {
+	tunnelMu.Lock()
+	defer tunnelMu.Unlock()
+	if tunnel, ok := tunnels[target]; ok {
+		return tunnel, nil
+	}
+
+	user, address := parseSSHBastion(SSHBastion)
+	clientConfig, err := sshClientConfig(user)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ssh.Dial("tcp", address, clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to connect to the SSH bastion %s: %w", ErrClusterUnreachable, address, err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to open a local port for the SSH tunnel: %w", err)
+	}
+
+	tunnel := &sshTunnel{client: client, listener: listener, target: target}
+	go tunnel.serve()
+	tunnels[target] = tunnel
+	DebugMessage(fmt.Sprintf("Forwarding %s to %s through the SSH bastion %s", listener.Addr(), target, address))
+	return tunnel, nil
+}
+
+func (t *sshTunnel) serve() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.forward(conn)
+	}
+}
+
+// forward copies a local connection to the API server and back until either side closes
+func (t *sshTunnel) forward(local net.Conn) {
+	defer local.Close()
+	remote, err := t.client.Dial("tcp", t.target)
+	if err != nil {
+		DebugMessage(fmt.Sprintf("The SSH bastion failed to reach %s: %v", t.target, err))
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// CloseSSHTunnels closes the tunnels and the connections to the bastion at the end of a command
+func CloseSSHTunnels() {
+	tunnelMu.Lock()
+	defer tunnelMu.Unlock()
+	for target, tunnel := range tunnels {
+		tunnel.listener.Close()
+		tunnel.client.Close()
+		delete(tunnels, target)
+	}
+}
+
+// parseSSHBastion splits user@host[:port] into the user, the current user by default, and host:port
+func parseSSHBastion(bastion string) (string, string) {
+	user := os.Getenv("USER")
+	host := bastion
+	if at := strings.LastIndex(bastion, "@"); at >= 0 {
+		user, host = bastion[:at], bastion[at+1:]
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "22")
+	}
+	return user, host
+}
+
+// sshClientConfig authenticates with the ssh agent and the private keys, the host key of the bastion has to be
+// in ~/.ssh/known_hosts
+func sshClientConfig(user string) (*ssh.ClientConfig, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	knownHostsFile := filepath.Join(home, ".ssh", "known_hosts")
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read %s, connect to the bastion once with ssh to trust its host key: %w", ErrValidation, knownHostsFile, err)
+	}
+
+	var methods []ssh.AuthMethod
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	signers, err := sshKeySigners(home)
+	if err != nil {
+		return nil, err
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("%w: no SSH key found for the bastion, start an ssh agent or set --ssh-key", ErrValidation)
+	}
+
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            methods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         15 * time.Second,
+	}, nil
+}
+
+// sshKeySigners loads --ssh-key, or the unencrypted default keys of ~/.ssh
+func sshKeySigners(home string) ([]ssh.Signer, error) {
+	if SSHKey != "" {
+		signer, err := loadSSHKey(SSHKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to load SSH key %s: %w", ErrValidation, SSHKey, err)
+		}
+		return []ssh.Signer{signer}, nil
+	}
+
+	var signers []ssh.Signer
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		// Missing and passphrase protected keys are skipped, the agent provides the latter
+		if signer, err := loadSSHKey(filepath.Join(home, ".ssh", name)); err == nil {
+			signers = append(signers, signer)
+		}
+	}
+	return signers, nil
+}
+
+func loadSSHKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	var passphraseErr *ssh.PassphraseMissingError
+	if errors.As(err, &passphraseErr) {
+		return nil, fmt.Errorf("the key is protected by a passphrase, add it to the ssh agent instead")
+	}
+	return signer, err
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// DefaultSSLIssuer is the ClusterIssuer of files/clusterissuer.yaml
+	DefaultSSLIssuer = "letsencrypt-grapple-demo"
+	// MkcertSSLIssuer is the ClusterIssuer k3d installs use for local certificates
+	MkcertSSLIssuer = "mkcert-ca-issuer"
+
+	clusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+	// managedTLSAnnotation marks the ingresses whose TLS was added by 'grapple ssl enable', only their TLS is
+	// removed by 'grapple ssl disable'
+	managedTLSAnnotation = "grpl.io/managed-tls"
+)
+
+var (
+	clusterIssuerGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}
+	certificateGVR   = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+)
+
+// SetSSLConfig stores the SSL settings in the config values of the grsf-config release, so they are kept by
+// later upgrades. Installations without the release get them in the grsf-config secret.
+func SetSSLConfig(kubeClient apiv1.Interface, restConfig *rest.Config, enabled bool, issuer string) error {
+	version, err := GetGrplReleaseVersion(restConfig, "grsf-config", "grpl-system")
+	if err != nil {
+		return err
+	}
+	if version != "" {
+		config := map[string]interface{}{SecKeySsl: fmt.Sprintf("%v", enabled)}
+		if issuer != "" {
+			config[SecKeySslissuer] = issuer
+		}
+		if err := RenderGrsfConfig(kubeClient, restConfig, config); err != nil {
+			return fmt.Errorf("failed to render grsf-config with the SSL settings: %w", err)
+		}
+		return nil
+	}
+
+	secrets := kubeClient.CoreV1().Secrets("grpl-system")
+	secret, err := secrets.Get(context.TODO(), "grsf-config", v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get grsf-config: %w", err)
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[SecKeySsl] = []byte(fmt.Sprintf("%v", enabled))
+	if issuer != "" {
+		secret.Data[SecKeySslissuer] = []byte(issuer)
+	}
+	if _, err := secrets.Update(context.TODO(), secret, v1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update grsf-config: %w", err)
+	}
+	return nil
+}
+
+// GetSSLIssuer returns the ClusterIssuer configured in the grsf-config secret
+func GetSSLIssuer(kubeClient apiv1.Interface) (string, error) {
+	secret, err := kubeClient.CoreV1().Secrets("grpl-system").Get(context.TODO(), "grsf-config", v1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get grsf-config: %w", err)
+	}
+	return string(secret.Data[SecKeySslissuer]), nil
+}
+
+// DefaultIngressClass returns the default IngressClass of the cluster, or the first one if none is marked as default
+func DefaultIngressClass(kubeClient apiv1.Interface) string {
+	classes, err := kubeClient.NetworkingV1().IngressClasses().List(context.TODO(), v1.ListOptions{})
+	if err != nil || len(classes.Items) == 0 {
+		return "traefik"
+	}
+	for _, class := range classes.Items {
+		if class.Annotations["ingressclass.kubernetes.io/is-default-class"] == "true" {
+			return class.Name
+		}
+	}
+	return classes.Items[0].Name
+}
+
+// DeleteClusterIssuer removes a ClusterIssuer, a missing issuer is not an error
+func DeleteClusterIssuer(restConfig *rest.Config, name string) error {
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	err = dynamicClient.Resource(clusterIssuerGVR).Delete(context.TODO(), name, v1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete ClusterIssuer %s: %w", name, err)
+	}
+	return nil
+}
+
+// TLSIngress is an ingress whose TLS was changed by PatchIngressesTLS
+type TLSIngress struct {
+	Namespace string   `json:"namespace" yaml:"namespace"`
+	Name      string   `json:"name" yaml:"name"`
+	Hosts     []string `json:"hosts" yaml:"hosts"`
+}
+
+// PatchIngressesTLS adds (issuer set) or removes (issuer empty) TLS of the ingresses serving hosts of the
+// cluster domain. With an issuer, cert-manager issues a certificate for every patched ingress. Ingresses that
+// already have TLS of their own are left alone, and only the TLS added here is removed again.
+func PatchIngressesTLS(kubeClient apiv1.Interface, clusterDomain, issuer string) ([]TLSIngress, error) {
+	ingresses, err := kubeClient.NetworkingV1().Ingresses("").List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	patched := []TLSIngress{}
+	for _, ingress := range ingresses.Items {
+		hosts := ingressDomainHosts(&ingress, clusterDomain)
+		if len(hosts) == 0 {
+			continue
+		}
+
+		managed := ingress.Annotations[managedTLSAnnotation] == "true"
+		if issuer != "" {
+			if len(ingress.Spec.TLS) > 0 && !managed {
+				InfoMessage(fmt.Sprintf("Keeping the TLS of ingress %s/%s", ingress.Namespace, ingress.Name))
+				continue
+			}
+			if ingress.Annotations == nil {
+				ingress.Annotations = map[string]string{}
+			}
+			ingress.Annotations[clusterIssuerAnnotation] = issuer
+			ingress.Annotations[managedTLSAnnotation] = "true"
+			ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: hosts, SecretName: ingress.Name + "-tls"}}
+		} else {
+			if !managed {
+				continue
+			}
+			delete(ingress.Annotations, clusterIssuerAnnotation)
+			delete(ingress.Annotations, managedTLSAnnotation)
+			ingress.Spec.TLS = nil
+		}
+
+		if _, err := kubeClient.NetworkingV1().Ingresses(ingress.Namespace).Update(context.TODO(), &ingress, v1.UpdateOptions{}); err != nil {
+			return patched, fmt.Errorf("failed to update ingress %s/%s: %w", ingress.Namespace, ingress.Name, err)
+		}
+		patched = append(patched, TLSIngress{Namespace: ingress.Namespace, Name: ingress.Name, Hosts: hosts})
+	}
+	return patched, nil
+}
+
+// WaitForCertificates waits until cert-manager issued the certificates of the given ingresses
+func WaitForCertificates(restConfig *rest.Config, ingresses []TLSIngress) error {
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	progress := StartWaitProgress("certificates to be issued", WaitTimeout)
+	defer progress.Stop()
+
+	for _, ingress := range ingresses {
+		namespace, certificate := ingress.Namespace, ingress.Name+"-tls"
+		err := Poll(WaitTimeout, func() (bool, error) {
+			obj, err := dynamicClient.Resource(certificateGVR).Namespace(namespace).Get(context.TODO(), certificate, v1.GetOptions{})
+			if err != nil {
+				return false, nil
+			}
+			return hasTrueCondition(obj, "Ready"), nil
+		})
+		if err != nil {
+			return fmt.Errorf("certificate %s/%s was not issued: %w", namespace, certificate, err)
+		}
+	}
+	return nil
+}
+
+// CheckReachable sends a single request to the host, over https the certificate has to be valid
+func CheckReachable(host string, ssl bool) error {
+	if online, message := checkOnline(host, ssl); !online {
+		return fmt.Errorf("%s: %s", host, message)
+	}
+	return nil
+}
+
+// ingressDomainHosts returns the hosts of the ingress rules that belong to the cluster domain
+func ingressDomainHosts(ingress *networkingv1.Ingress, clusterDomain string) []string {
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host == clusterDomain || strings.HasSuffix(rule.Host, "."+clusterDomain) {
+			if !Contains(hosts, rule.Host) {
+				hosts = append(hosts, rule.Host)
+			}
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+var grasGVR = schema.GroupVersionResource{Group: "grsf.grpl.io", Version: "v1alpha1", Resource: "grappleapplicationsets"}
+
+// ComponentStatus is the one-shot health of a single component
+type ComponentStatus struct {
+	Name    string `json:"name" yaml:"name"`
+	Ready   bool   `json:"ready" yaml:"ready"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// GrappleStatus is the health report of the Grapple installation of a cluster
+type GrappleStatus struct {
+	Context            string            `json:"context" yaml:"context"`
+	Provider           string            `json:"provider,omitempty" yaml:"provider,omitempty"`
+	ClusterDomain      string            `json:"clusterDomain,omitempty" yaml:"clusterDomain,omitempty"`
+	SSLEnabled         bool              `json:"sslEnabled" yaml:"sslEnabled"`
+	DomainResolvable   bool              `json:"domainResolvable" yaml:"domainResolvable"`
+	Online             bool              `json:"online" yaml:"online"`
+	OnlineMessage      string            `json:"onlineMessage,omitempty" yaml:"onlineMessage,omitempty"`
+	IngressController  string            `json:"ingressController,omitempty" yaml:"ingressController,omitempty"`
+	KubeBlocks         bool              `json:"kubeblocks" yaml:"kubeblocks"`
+	Releases           map[string]string `json:"releases" yaml:"releases"`
+	Components         []ComponentStatus `json:"components" yaml:"components"`
+	CrossplanePackages []ComponentStatus `json:"crossplanePackages" yaml:"crossplanePackages"`
+	GrasCount          int               `json:"grasCount" yaml:"grasCount"`
+	EvictedPods        []string          `json:"evictedPods,omitempty" yaml:"evictedPods,omitempty"`
+	Drift              []ConfigDrift     `json:"drift,omitempty" yaml:"drift,omitempty"`
+	Healthy            bool              `json:"healthy" yaml:"healthy"`
+}
+
+// CollectGrappleStatus inspects the cluster once, read-only, with the same checks the install waits use
+func CollectGrappleStatus(kubeClient apiv1.Interface, restConfig *rest.Config) (*GrappleStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	status := &GrappleStatus{Releases: map[string]string{}, Healthy: true}
+	if kubeContext, err := CurrentKubeContext(); err == nil {
+		status.Context = kubeContext
+	}
+
+	for _, release := range GrplReleases {
+		version, err := GetGrplReleaseVersion(restConfig, release, "grpl-system")
+		if err != nil {
+			return nil, err
+		}
+		if version == "" {
+			version = "not installed"
+			status.Healthy = false
+		}
+		status.Releases[release] = version
+	}
+
+	if secret, err := kubeClient.CoreV1().Secrets("grpl-system").Get(ctx, "grsf-config", v1.GetOptions{}); err == nil {
+		status.Provider = string(secret.Data[SecKeyProviderClusterType])
+		status.ClusterDomain = string(secret.Data[SecKeyClusterdomain])
+		status.SSLEnabled = string(secret.Data[SecKeySsl]) == "true"
+		if status.ClusterDomain != "" {
+			status.DomainResolvable = IsResolvable(status.ClusterDomain)
+		}
+		if status.DomainResolvable {
+			status.Online, status.OnlineMessage = checkOnline(status.ClusterDomain, status.SSLEnabled)
+		}
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get grsf-config: %w", err)
+	}
+
+	// Deployments the install waits for, optional ones are only reported when present
+	components := []struct {
+		label, namespace, name string
+		optional               bool
+	}{
+		{"cert-manager", "grpl-system", "grsf-init-cert-manager", false},
+		{"external-secrets", "grpl-system", "grsf-init-external-secrets-webhook", true},
+		{"crossplane", "grpl-system", "crossplane", true},
+	}
+	for _, c := range components {
+		component, found := deploymentStatus(ctx, kubeClient, c.namespace, c.name)
+		component.Name = c.label
+		if !found && c.optional {
+			continue
+		}
+		if !component.Ready {
+			status.Healthy = false
+		}
+		status.Components = append(status.Components, component)
+	}
+
+	for _, gvr := range []schema.GroupVersionResource{crossplaneProviderGVR, crossplaneConfigGVR} {
+		packages, err := dynamicClient.Resource(gvr).List(ctx, v1.ListOptions{})
+		if err != nil {
+			continue
+		}
+		for _, pkg := range packages.Items {
+			component := packageStatus(&pkg)
+			if !component.Ready {
+				status.Healthy = false
+			}
+			status.CrossplanePackages = append(status.CrossplanePackages, component)
+		}
+	}
+	sort.Slice(status.CrossplanePackages, func(i, j int) bool {
+		return status.CrossplanePackages[i].Name < status.CrossplanePackages[j].Name
+	})
+
+	status.IngressController = detectIngressController(ctx, kubeClient)
+	status.KubeBlocks = deploymentExists(ctx, kubeClient, "kb-system", "kubeblocks")
+	status.EvictedPods = EvictedPlatformPods(ctx, kubeClient)
+
+	if gras, err := dynamicClient.Resource(grasGVR).List(ctx, v1.ListOptions{}); err == nil {
+		status.GrasCount = len(gras.Items)
+	}
+
+	status.Drift, err = CheckGrsfConfig(kubeClient, restConfig, status.Releases)
+	if err != nil {
+		return nil, err
+	}
+	if len(status.Drift) > 0 {
+		status.Healthy = false
+	}
+
+	return status, nil
+}
+
+// deploymentStatus reports whether a deployment has all of its replicas available
+func deploymentStatus(ctx context.Context, kubeClient apiv1.Interface, namespace, name string) (ComponentStatus, bool) {
+	deployment, err := kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return ComponentStatus{Message: "not found"}, false
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return ComponentStatus{
+		Ready:   deployment.Status.AvailableReplicas == replicas,
+		Message: fmt.Sprintf("%d/%d available", deployment.Status.AvailableReplicas, replicas),
+	}, true
+}
+
+// packageStatus reports the Installed and Healthy conditions of a crossplane package
+func packageStatus(pkg *unstructured.Unstructured) ComponentStatus {
+	component := ComponentStatus{Name: fmt.Sprintf("%s/%s", pkg.GetKind(), pkg.GetName())}
+	installed := hasTrueCondition(pkg, "Installed")
+	healthy := hasTrueCondition(pkg, "Healthy")
+	component.Ready = installed && healthy
+	switch {
+	case !installed:
+		component.Message = "not installed"
+	case !healthy:
+		component.Message = "unhealthy"
+	default:
+		component.Message = "healthy"
+	}
+	return component
+}
+
+// detectIngressController returns the controllers of the cluster's IngressClasses, e.g. traefik or nginx
+func detectIngressController(ctx context.Context, kubeClient apiv1.Interface) string {
+	classes, err := kubeClient.NetworkingV1().IngressClasses().List(ctx, v1.ListOptions{})
+	if err != nil || len(classes.Items) == 0 {
+		if deploymentExists(ctx, kubeClient, "kube-system", "traefik") {
+			return "traefik"
+		}
+		return ""
+	}
+
+	var names []string
+	for _, class := range classes.Items {
+		names = append(names, class.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// checkOnline sends a single request to the cluster domain, with SSL enabled the certificate has to be valid
+func checkOnline(domain string, ssl bool) (bool, string) {
+	scheme := "http"
+	if ssl {
+		scheme = "https"
+	}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		// Any response of the ingress controller, including redirects, means the domain is served
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(fmt.Sprintf("%s://%s", scheme, domain))
+	if err != nil {
+		if ssl && strings.Contains(err.Error(), "certificate") {
+			return false, "SSL certificate is not valid yet"
+		}
+		return false, fmt.Sprintf("not reachable over %s", scheme)
+	}
+	resp.Body.Close()
+	return true, fmt.Sprintf("reachable over %s (%s)", scheme, resp.Status)
+}
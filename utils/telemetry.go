@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// DefaultTelemetryEndpoint receives the command metrics unless telemetry.endpoint is set
+	DefaultTelemetryEndpoint = "https://telemetry.grapple-solutions.com/api/v1/events"
+	// telemetryQueueSize is the number of unsent events kept, older ones are dropped
+	telemetryQueueSize = 500
+	telemetryTimeout   = 3 * time.Second
+)
+
+// telemetryProviders are the first command segments reported as provider
+var telemetryProviders = map[string]bool{"k3d": true, "civo": true, "gke": true, "doks": true}
+
+// TelemetryEvent is the anonymized record of a command run. It is built from an allow list only: the command
+// path and the names of the flags passed, never arguments, flag values (except a release --grapple-version),
+// error messages, cluster names, domains or keys.
+type TelemetryEvent struct {
+	InstallID      string    `json:"installId"`
+	Time           time.Time `json:"time"`
+	Command        string    `json:"command"`
+	Flags          []string  `json:"flags,omitempty"`
+	DurationMs     int64     `json:"durationMs"`
+	Success        bool      `json:"success"`
+	ErrorKind      string    `json:"errorKind,omitempty"`
+	CLIVersion     string    `json:"cliVersion"`
+	GrappleVersion string    `json:"grappleVersion,omitempty"`
+	Provider       string    `json:"provider,omitempty"`
+	OS             string    `json:"os"`
+	Arch           string    `json:"arch"`
+}
+
+// TelemetryEnabled reports whether command metrics are sent: only when enabled with
+// 'grapple config set telemetry.enabled true' (or GRPL_TELEMETRY=true), and never with DO_NOT_TRACK set
+func TelemetryEnabled() bool {
+	if doNotTrack := os.Getenv("DO_NOT_TRACK"); doNotTrack != "" && doNotTrack != "0" {
+		return false
+	}
+	enabled, _ := strconv.ParseBool(ConfigValue("telemetry.enabled"))
+	return enabled
+}
+
+// RecordTelemetry queues the event of a finished command and sends the queue to the telemetry endpoint, it does
+// nothing unless telemetry is enabled. Failures are silent, the events stay queued for the next command.
+func RecordTelemetry(cmd *cobra.Command, duration time.Duration, err error) {
+	if !TelemetryEnabled() {
+		return
+	}
+	event := newTelemetryEvent(cmd, duration, err)
+	if event.InstallID == "" {
+		return
+	}
+	path, qErr := telemetryQueuePath()
+	if qErr != nil {
+		return
+	}
+	events := append(readTelemetryQueue(path), event)
+	if len(events) > telemetryQueueSize {
+		events = events[len(events)-telemetryQueueSize:]
+	}
+	if OfflineMode || sendTelemetry(events) != nil {
+		writeTelemetryQueue(path, events)
+		return
+	}
+	_ = os.Remove(path)
+}
+
+// newTelemetryEvent builds the redacted event of a command
+func newTelemetryEvent(cmd *cobra.Command, duration time.Duration, err error) TelemetryEvent {
+	event := TelemetryEvent{
+		InstallID:  telemetryInstallID(),
+		Time:       time.Now().UTC().Truncate(time.Second),
+		Command:    cmd.CommandPath(),
+		DurationMs: duration.Milliseconds(),
+		Success:    err == nil,
+		CLIVersion: GetGrappleCliVersion(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+	}
+	if err != nil {
+		event.ErrorKind, _ = ClassifyError(err)
+	}
+	if path := strings.Fields(event.Command); len(path) > 1 && telemetryProviders[path[1]] {
+		event.Provider = path[1]
+	}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		event.Flags = append(event.Flags, flag.Name)
+		if flag.Name == "grapple-version" {
+			event.GrappleVersion = redactVersion(flag.Value.String())
+		}
+	})
+	return event
+}
+
+// redactVersion keeps release versions and "latest", anything else (e.g. a branch or a path) is reported as "custom"
+func redactVersion(version string) string {
+	if version == "latest" {
+		return version
+	}
+	if v, err := semver.StrictNewVersion(strings.TrimPrefix(version, "v")); err == nil {
+		return v.String()
+	}
+	return "custom"
+}
+
+// telemetryInstallID returns the random ID of this installation of the CLI, created on first use. It only tells
+// events of the same machine apart and is not derived from any user or machine data.
+func telemetryInstallID() string {
+	configPath, err := ConfigFilePath()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(filepath.Dir(configPath), "telemetry-id")
+	if data, err := os.ReadFile(path); err == nil && len(bytes.TrimSpace(data)) > 0 {
+		return string(bytes.TrimSpace(data))
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return ""
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(id)), 0600); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
+
+// telemetryQueuePath returns the file of the unsent events, ~/.cache/grpl/telemetry-queue.jsonl
+func telemetryQueuePath() (string, error) {
+	cacheHome, err := cacheHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheHome, "grpl", "telemetry-queue.jsonl"), nil
+}
+
+func readTelemetryQueue(path string) []TelemetryEvent {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	var events []TelemetryEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event TelemetryEvent
+		if json.Unmarshal(scanner.Bytes(), &event) == nil {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func writeTelemetryQueue(path string, events []TelemetryEvent) {
+	var buf bytes.Buffer
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// sendTelemetry posts the events as a JSON array to telemetry.endpoint
+func sendTelemetry(events []TelemetryEvent) error {
+	endpoint := ConfigValue("telemetry.endpoint")
+	if endpoint == "" {
+		endpoint = DefaultTelemetryEndpoint
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "grapple-cli/"+GetGrappleCliVersion())
+	resp, err := (&http.Client{Timeout: telemetryTimeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("telemetry endpoint answered %s", resp.Status)
+	}
+	return nil
+}
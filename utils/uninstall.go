@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	apiv1 "k8s.io/client-go/kubernetes"
+)
+
+const (
+	UninstallKindCRD       = "CustomResourceDefinition"
+	UninstallKindRelease   = "HelmRelease"
+	UninstallKindNamespace = "Namespace"
+)
+
+// UninstallOptions select the components UninstallGrappleWithOptions removes, the zero value removes everything
+type UninstallOptions struct {
+	KeepKubeBlocks bool
+	KeepCRDs       bool
+	KeepNamespaces bool
+	// ReleasesOnly uninstalls the helm releases and keeps CRDs and namespaces
+	ReleasesOnly bool
+	// DryRun only returns the plan
+	DryRun bool
+}
+
+// UninstallStep is a single deletion of an uninstall, in the order they are executed
+type UninstallStep struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+}
+
+func (s UninstallStep) String() string {
+	if s.Namespace == "" {
+		return fmt.Sprintf("%s %s", s.Kind, s.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", s.Kind, s.Namespace, s.Name)
+}
+
+// UninstallGrapple removes everything Grapple installed: the grpl CRDs, KubeBlocks, the grsf releases and
+// the kb-system and grpl-system namespaces
+func UninstallGrapple(connectToCluster func() error, logOnFileStart, logOnCliAndFileStart func()) error {
+	_, err := UninstallGrappleWithOptions(connectToCluster, logOnFileStart, logOnCliAndFileStart, UninstallOptions{})
+	return err
+}
+
+// UninstallGrappleWithOptions removes the components selected by opts and returns the steps it executed,
+// or with opts.DryRun the steps it would execute
+func UninstallGrappleWithOptions(connectToCluster func() error, logOnFileStart, logOnCliAndFileStart func(), opts UninstallOptions) ([]UninstallStep, error) {
+
+	// Initialize Kubernetes clients
+	config, clientset, err := GetKubernetesConfig()
+	if err != nil {
+		InfoMessage("No existing connection found")
+		err = connectToCluster()
+		if err != nil {
+			ErrorMessage(fmt.Sprintf("Failed to connect to cluster: %v", err))
+			return nil, err
+		}
+
+		config, clientset, err = GetKubernetesConfig()
+		if err != nil {
+			ErrorMessage(fmt.Sprintf("Failed to get REST config: %v", err))
+			return nil, err
+		}
+	}
+
+	// The helm settings are read after the kube config is resolved, they pick up the SSH tunnel of the API server
+	settings := cli.New()
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		ErrorMessage(fmt.Sprintf("Failed to create dynamic client: %v", err))
+		return nil, err
+	}
+
+	releaseVersion := func(release, namespace string) (string, error) {
+		return GetGrplReleaseVersion(config, release, namespace)
+	}
+	plan, err := PlanUninstall(clientset, dynamicClient, releaseVersion, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return plan, nil
+	}
+	if len(plan) == 0 {
+		InfoMessage("Nothing to uninstall")
+		return plan, nil
+	}
+
+	logOnFileStart()
+	for _, step := range plan {
+		InfoMessage(fmt.Sprintf("Deleting %s...", step))
+		switch step.Kind {
+		case UninstallKindCRD:
+			err = dynamicClient.Resource(crdGVR).Delete(context.TODO(), step.Name, v1.DeleteOptions{})
+		case UninstallKindRelease:
+			err = uninstallRelease(settings, step.Namespace, step.Name)
+		case UninstallKindNamespace:
+			err = clientset.CoreV1().Namespaces().Delete(context.TODO(), step.Name, v1.DeleteOptions{})
+		}
+		logOnCliAndFileStart()
+		if err != nil {
+			// Continue with the other steps even if one fails
+			ErrorMessage(fmt.Sprintf("Failed to delete %s: %v", step, err))
+		} else {
+			SuccessMessage(fmt.Sprintf("%s deleted", step))
+		}
+		logOnFileStart()
+
+		if step.Kind == UninstallKindNamespace && step.Name == "grpl-system" && err == nil {
+			InfoMessage("Waiting for namespace deletion to complete...")
+			deadline := time.Now().Add(2 * time.Minute)
+			for time.Now().Before(deadline) {
+				if _, err := clientset.CoreV1().Namespaces().Get(context.TODO(), "grpl-system", v1.GetOptions{}); err != nil {
+					break
+				}
+				time.Sleep(5 * time.Second)
+			}
+		}
+	}
+	logOnCliAndFileStart()
+
+	SuccessMessage("Grapple uninstallation completed!")
+	return plan, nil
+}
+
+// PlanUninstall lists, in order, what an uninstall with opts deletes from the cluster: the grpl CRDs, the
+// kubeblocks release and kb-system, then the grsf releases in reverse install order and grpl-system.
+// releaseVersion returns the installed version of a helm release, "" if it isn't installed.
+func PlanUninstall(clientset apiv1.Interface, dynamicClient dynamic.Interface, releaseVersion func(release, namespace string) (string, error), opts UninstallOptions) ([]UninstallStep, error) {
+	keepCRDs := opts.KeepCRDs || opts.ReleasesOnly
+	keepNamespaces := opts.KeepNamespaces || opts.ReleasesOnly
+
+	var plan []UninstallStep
+
+	if !keepCRDs {
+		crdList, err := dynamicClient.Resource(crdGVR).List(context.TODO(), v1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list CRDs: %w", err)
+		}
+		for _, crd := range crdList.Items {
+			if strings.Contains(strings.ToLower(crd.GetName()), "grpl") {
+				plan = append(plan, UninstallStep{Kind: UninstallKindCRD, Name: crd.GetName()})
+			}
+		}
+	}
+
+	for _, ns := range []struct {
+		namespace string
+		releases  []string
+		keep      bool
+	}{
+		{"kb-system", []string{"kubeblocks"}, opts.KeepKubeBlocks},
+		{"grpl-system", []string{"grsf-integration", "grsf-config", "grsf", "grsf-init"}, false},
+	} {
+		if ns.keep {
+			continue
+		}
+		if _, err := clientset.CoreV1().Namespaces().Get(context.TODO(), ns.namespace, v1.GetOptions{}); err != nil {
+			InfoMessage(fmt.Sprintf("%s namespace not found, skipping", ns.namespace))
+			continue
+		}
+		for _, release := range ns.releases {
+			version, err := releaseVersion(release, ns.namespace)
+			if err != nil {
+				return nil, err
+			}
+			if version != "" {
+				plan = append(plan, UninstallStep{Kind: UninstallKindRelease, Namespace: ns.namespace, Name: release})
+			}
+		}
+		if !keepNamespaces {
+			plan = append(plan, UninstallStep{Kind: UninstallKindNamespace, Name: ns.namespace})
+		}
+	}
+
+	return plan, nil
+}
+
+func uninstallRelease(settings *cli.EnvSettings, namespace, release string) error {
+	settings.SetNamespace(namespace)
+	actionConfig := new(action.Configuration)
+	if err := InitHelmActionConfig(actionConfig, settings.RESTClientGetter(), namespace); err != nil {
+		return fmt.Errorf("failed to initialize helm config: %w", err)
+	}
+	_, err := action.NewUninstall(actionConfig).Run(release)
+	return err
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPlanUninstall(t *testing.T) {
+	crd := func(name string) runtime.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("apiextensions.k8s.io/v1")
+		obj.SetKind("CustomResourceDefinition")
+		obj.SetName(name)
+		return obj
+	}
+	namespace := func(name string) runtime.Object {
+		return &corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: name}}
+	}
+	installed := map[string]bool{"kubeblocks": true, "grsf-init": true, "grsf": true, "grsf-config": true, "grsf-integration": true}
+	releaseVersion := func(release, namespace string) (string, error) {
+		if installed[release] {
+			return "0.3.5", nil
+		}
+		return "", nil
+	}
+
+	grplCRDs := []UninstallStep{
+		{Kind: UninstallKindCRD, Name: "grapi.grsf.grpl.io"},
+		{Kind: UninstallKindCRD, Name: "gras.grsf.grpl.io"},
+	}
+	kubeBlocks := []UninstallStep{
+		{Kind: UninstallKindRelease, Namespace: "kb-system", Name: "kubeblocks"},
+		{Kind: UninstallKindNamespace, Name: "kb-system"},
+	}
+	grsfReleases := []UninstallStep{
+		{Kind: UninstallKindRelease, Namespace: "grpl-system", Name: "grsf-integration"},
+		{Kind: UninstallKindRelease, Namespace: "grpl-system", Name: "grsf-config"},
+		{Kind: UninstallKindRelease, Namespace: "grpl-system", Name: "grsf"},
+		{Kind: UninstallKindRelease, Namespace: "grpl-system", Name: "grsf-init"},
+	}
+	grplSystem := []UninstallStep{{Kind: UninstallKindNamespace, Name: "grpl-system"}}
+	concat := func(parts ...[]UninstallStep) []UninstallStep {
+		var steps []UninstallStep
+		for _, part := range parts {
+			steps = append(steps, part...)
+		}
+		return steps
+	}
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		opts       UninstallOptions
+		want       []UninstallStep
+	}{
+		{name: "everything", namespaces: []string{"kb-system", "grpl-system"},
+			want: concat(grplCRDs, kubeBlocks, grsfReleases, grplSystem)},
+		{name: "keep kubeblocks", namespaces: []string{"kb-system", "grpl-system"}, opts: UninstallOptions{KeepKubeBlocks: true},
+			want: concat(grplCRDs, grsfReleases, grplSystem)},
+		{name: "keep CRDs", namespaces: []string{"kb-system", "grpl-system"}, opts: UninstallOptions{KeepCRDs: true},
+			want: concat(kubeBlocks, grsfReleases, grplSystem)},
+		{name: "keep namespaces", namespaces: []string{"kb-system", "grpl-system"}, opts: UninstallOptions{KeepNamespaces: true},
+			want: concat(grplCRDs, kubeBlocks[:1], grsfReleases)},
+		{name: "releases only", namespaces: []string{"kb-system", "grpl-system"}, opts: UninstallOptions{ReleasesOnly: true},
+			want: concat(kubeBlocks[:1], grsfReleases)},
+		{name: "without kubeblocks", namespaces: []string{"grpl-system"},
+			want: concat(grplCRDs, grsfReleases, grplSystem)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var namespaces []runtime.Object
+			for _, name := range tt.namespaces {
+				namespaces = append(namespaces, namespace(name))
+			}
+			clientset := fake.NewSimpleClientset(namespaces...)
+			dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{crdGVR: "CustomResourceDefinitionList"},
+				crd("grapi.grsf.grpl.io"), crd("certificates.cert-manager.io"), crd("gras.grsf.grpl.io"))
+
+			got, err := PlanUninstall(clientset, dynamicClient, releaseVersion, tt.opts)
+			if err != nil {
+				t.Fatalf("PlanUninstall() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlanUninstall() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,17 +4,14 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
-	"io"
-	"log"
 	"net"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
-	"github.com/manifoldco/promptui"
+	"github.com/grapple-solution/grapple_cli/utils/prompt"
 	"golang.org/x/exp/rand"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli"
@@ -24,91 +21,34 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
-	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
-	"k8s.io/client-go/discovery"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
-// Print success message in green
-func SuccessMessage(message string) {
-	log.Printf("%s%s%s\n", ColorGreen, message, ColorReset)
-}
-
-// Print info message in yellow
-func InfoMessage(message string) {
-	log.Printf("%s%s%s\n", ColorYellow, message, ColorReset)
-}
-
-// Print error message in red
-func ErrorMessage(message string) {
-	log.Printf("%s%s%s\n", ColorRed, message, ColorReset)
-}
-
 // Prompt user for input if not provided via flags
-func PromptInput(prompt string, defaultValue string, validationRegex string) (string, error) {
+func PromptInput(label string, defaultValue string, validationRegex string) (string, error) {
 	if validationRegex == "" {
 		return "", fmt.Errorf("validation regex is required")
 	}
-	promptUI := promptui.Prompt{
-		Label:   prompt,
-		Default: defaultValue,
-		Validate: func(input string) error {
-			matched, err := regexp.MatchString(validationRegex, input)
-			if err != nil {
-				return fmt.Errorf("invalid regex pattern: %v", err)
-			}
-			if !matched {
-				return fmt.Errorf("input does not match required pattern")
-			}
-			return nil
-		},
-	}
-	result, err := promptUI.Run()
-	if err != nil {
-		return "", err
-	}
-	return result, nil
+	return prompt.Input(prompt.Question{
+		Label:    label,
+		Default:  defaultValue,
+		Validate: prompt.MatchRegex(validationRegex),
+	})
 }
 
 func PromptSelect(label string, items []string) (string, error) {
-	prompt := promptui.Select{
-		Label: label,
-		Items: items,
-	}
-
-	_, result, err := prompt.Run()
-	if err != nil {
-		return "", err
-	}
-	return result, nil
+	return prompt.Select(prompt.Question{Label: label}, items)
 }
 
 func PromptConfirm(message string) (bool, error) {
-	prompt := promptui.Prompt{
-		Label:     message,
-		IsConfirm: true,
-	}
-
-	result, err := prompt.Run()
-	if err != nil {
-		if err == promptui.ErrAbort {
-			return false, nil
-		}
-		return false, err
-	}
-
-	return strings.ToLower(result) == "y", nil
+	return prompt.Confirm(prompt.Question{Label: message})
 }
 
-func PromptPassword(prompt string) (string, error) {
-	promptUI := promptui.Prompt{
-		Label: prompt,
+func PromptPassword(label string) (string, error) {
+	return prompt.Input(prompt.Question{
+		Label: label,
 		Mask:  '*',
 		Validate: func(input string) error {
 			if input == "" {
@@ -116,12 +56,7 @@ func PromptPassword(prompt string) (string, error) {
 			}
 			return nil
 		},
-	}
-	result, err := promptUI.Run()
-	if err != nil {
-		return "", err
-	}
-	return result, nil
+	})
 }
 
 // Define grappleDomain variable
@@ -147,6 +82,7 @@ var s *spinner.Spinner
 func StartSpinner(message string) {
 	s = spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 	s.Suffix = " " + message
+	s.Writer = cliWriter()
 	s.Start()
 }
 
@@ -157,37 +93,6 @@ func StopSpinner() {
 	}
 }
 
-func GetLogFilePath(logFileName string) string {
-	// Get the system's temp directory
-	tempDir := os.TempDir()
-
-	// Create the full path using filepath.Join which handles OS-specific separators
-	logFilePath := filepath.Join(tempDir, logFileName)
-	return logFilePath
-}
-
-func GetLogWriters(logFilePath string) (*os.File, func(), func()) {
-
-	// Open the log file (create if not exists, append mode)
-	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		log.Fatalf("failed to open log file: %v", err)
-	}
-
-	// Create a multi-writer to log both to console (stdout) and file
-	multiWriter := io.MultiWriter(os.Stdout, logFile)
-
-	logOnFileStart := func() {
-		log.SetOutput(logFile)
-	}
-
-	logOnCliAndFileStart := func() {
-		log.SetOutput(multiWriter)
-	}
-
-	return logFile, logOnFileStart, logOnCliAndFileStart
-}
-
 func Contains(slice []string, val string) bool {
 	for _, s := range slice {
 		if s == val {
@@ -215,15 +120,7 @@ func GetHelmConfig(restConfig *rest.Config, helmNamespace string) (*action.Confi
 	helmSettings.SetNamespace(helmNamespace)
 
 	var helmCfg action.Configuration
-	err = helmCfg.Init(
-		helmSettings.RESTClientGetter(),
-		helmNamespace, // default namespace
-		"secret",      // can be configmap
-		func(format string, v ...interface{}) {
-			log.Printf(format, v...)
-		},
-	)
-	if err != nil {
+	if err := InitHelmActionConfig(&helmCfg, helmSettings.RESTClientGetter(), helmNamespace); err != nil {
 		return nil, fmt.Errorf("failed to init helm config: %w", err)
 	}
 
@@ -238,30 +135,18 @@ func GetKubernetesConfig() (*rest.Config, *kubernetes.Clientset, error) {
 	var restConfig *rest.Config
 	var err error
 
-	// Check if running inside a cluster
-	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+	// Check if running inside a cluster, unless a kubeconfig or context is requested explicitly
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" && Kubeconfig == "" && KubeContext == "" {
 		// Get in-cluster config
 		restConfig, err = rest.InClusterConfig()
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to get in-cluster config: %w", err)
+			return nil, nil, fmt.Errorf("%w: failed to get in-cluster config: %w", ErrClusterUnreachable, err)
 		}
 	} else {
-		// Get home directory
-		home := os.Getenv("HOME")
-		if home == "" {
-			return nil, nil, fmt.Errorf("HOME environment variable not set")
-		}
-
-		// Load kubeconfig
-		kubeConfigPath := filepath.Join(home, ".kube", "config")
-		if _, err := os.Stat(kubeConfigPath); err != nil {
-			return nil, nil, fmt.Errorf("kubeconfig not found at %s", kubeConfigPath)
-		}
-
-		// Get REST config from kubeconfig
-		restConfig, err = clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+		// Get REST config from kubeconfig, on the context of --kube-context if it is set
+		restConfig, err = KubeContextRESTConfig(KubeContext)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to build REST config: %w", err)
+			return nil, nil, err
 		}
 	}
 
@@ -274,7 +159,7 @@ func GetKubernetesConfig() (*rest.Config, *kubernetes.Clientset, error) {
 	// Verify connection by listing namespaces
 	_, err = clientset.CoreV1().Namespaces().List(context.TODO(), v1.ListOptions{})
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to connect to cluster: %w", err)
+		return nil, nil, fmt.Errorf("%w: failed to connect to cluster: %w", ErrClusterUnreachable, err)
 	}
 
 	SuccessMessage("Already Connected to a cluster")
@@ -283,8 +168,11 @@ func GetKubernetesConfig() (*rest.Config, *kubernetes.Clientset, error) {
 }
 
 func WaitForExampleDeployment(client *kubernetes.Clientset, namespace, deploymentName string) error {
+	progress := StartWaitProgress(fmt.Sprintf("deployment %s/%s", namespace, deploymentName), 0)
+	defer progress.Stop()
+
 	// Watch deployment status
-	watcher, err := client.AppsV1().Deployments(namespace).Watch(context.TODO(), v1.ListOptions{
+	watcher, err := client.AppsV1().Deployments(namespace).Watch(CommandContext(), v1.ListOptions{
 		FieldSelector: fmt.Sprintf("metadata.name=%s", deploymentName),
 	})
 	if err != nil {
@@ -341,12 +229,13 @@ func WaitForExampleDeployment(client *kubernetes.Clientset, namespace, deploymen
 			}
 
 			if allPodsReady {
-				SuccessMessage("Deployment is ready")
+				progress.Done("Deployment is ready")
 				break
 			}
 		}
 	}
-	return nil
+	// The watch ends when the command is interrupted
+	return Canceled()
 }
 
 func CreateExternalDBSecret(client *kubernetes.Clientset, deploymentNamespace string, grasName string) error {
@@ -368,6 +257,7 @@ func CreateExternalDBSecret(client *kubernetes.Clientset, deploymentNamespace st
 			"password": existingSecret.Data["password"],
 		},
 	}
+	ApplyCommonMetadata(&newSecret.ObjectMeta)
 
 	_, err = client.CoreV1().Secrets(deploymentNamespace).Create(context.TODO(), newSecret, v1.CreateOptions{})
 	if errors.IsAlreadyExists(err) {
@@ -411,18 +301,17 @@ func SetupCodeVerificationServer(restConfig *rest.Config, code, completeDomain,
 			Name: "verification-server",
 		},
 	}
+	ApplyCommonMetadata(&namespace.ObjectMeta)
 	_, err = client.CoreV1().Namespaces().Create(context.TODO(), namespace, v1.CreateOptions{})
 	if err != nil && !errors.IsAlreadyExists(err) {
 		return fmt.Errorf("failed to create namespace: %w", err)
 	}
 
 	// Get deployment yaml path
-	deploymentPath, err := GetResourcePath("files")
+	src, err := ResolveResourceFile("files", "code-verification-server-deployment.yaml")
 	if err != nil {
 		return fmt.Errorf("failed to get deployment path: %w", err)
 	}
-	// deploymentPath := "files"
-	src := filepath.Join(deploymentPath, "code-verification-server-deployment.yaml")
 	// Read deployment yaml
 	yamlFile, err := os.ReadFile(src)
 	if err != nil {
@@ -432,28 +321,18 @@ func SetupCodeVerificationServer(restConfig *rest.Config, code, completeDomain,
 	// Replace variables in yaml
 	yamlStr := string(yamlFile)
 	yamlStr = strings.ReplaceAll(yamlStr, "$CLUSTER_ADDRESS", "verification-server."+completeDomain)
+	yamlStr = MirrorImagesInManifest(yamlStr)
 
-	// Parse yaml into k8s objects
-	decoder := k8syaml.NewYAMLOrJSONDecoder(strings.NewReader(yamlStr), 100)
-	var objects []runtime.Object
-
-	for {
-		obj := &unstructured.Unstructured{}
-		if err := decoder.Decode(obj); err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("failed to decode yaml: %w", err)
-		}
-		objects = append(objects, obj)
+	objects, err := DecodeManifest([]byte(yamlStr))
+	if err != nil {
+		return fmt.Errorf("failed to decode yaml: %w", err)
 	}
 
 	// Modify ingress for AWS if needed
 	if cloud == "aws" {
 		for _, obj := range objects {
-			if obj.GetObjectKind().GroupVersionKind().Kind == "Ingress" {
-				unstructuredObj := obj.(*unstructured.Unstructured)
-				if err := unstructured.SetNestedField(unstructuredObj.Object, "traefik", "spec", "ingressClassName"); err != nil {
+			if obj.GetKind() == "Ingress" {
+				if err := unstructured.SetNestedField(obj.Object, "traefik", "spec", "ingressClassName"); err != nil {
 					return fmt.Errorf("failed to set ingressClassName: %w", err)
 				}
 			}
@@ -461,36 +340,21 @@ func SetupCodeVerificationServer(restConfig *rest.Config, code, completeDomain,
 	}
 
 	// Apply objects
-	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	applier, err := NewApplier(restConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create dynamic client: %w", err)
+		return err
 	}
-
+	applier.Force = true
 	for _, obj := range objects {
-		gvk := obj.GetObjectKind().GroupVersionKind()
-		apiResource, err := getAPIResource(client.Discovery(), gvk)
-		if err != nil {
-			return fmt.Errorf("failed to get API resource: %w", err)
-		}
-
-		unstructuredObj := obj.(*unstructured.Unstructured)
-		_, err = dynamicClient.Resource(*apiResource).Namespace("verification-server").Create(context.TODO(), unstructuredObj, v1.CreateOptions{})
-		if err != nil {
-			if errors.IsAlreadyExists(err) {
-				// If resource exists, try to update it instead
-				_, err = dynamicClient.Resource(*apiResource).Namespace("verification-server").Update(context.TODO(), unstructuredObj, v1.UpdateOptions{})
-				if err != nil {
-					return fmt.Errorf("failed to update resource: %w", err)
-				}
-			} else {
-				return fmt.Errorf("failed to create resource: %w", err)
-			}
+		ApplyCommonMetadataToUnstructured(obj)
+		if _, err := applier.Apply(context.TODO(), obj, "verification-server"); err != nil {
+			return err
 		}
 	}
 
 	// Wait for deployment to be ready
 	InfoMessage("Waiting for code verification server deployment to be ready")
-	err = wait.PollImmediate(5*time.Second, 300*time.Second, func() (bool, error) {
+	err = Poll(300*time.Second, func() (bool, error) {
 		deployment, err := client.AppsV1().Deployments("verification-server").Get(context.TODO(), "code-verification-server", v1.GetOptions{})
 		if err != nil {
 			return false, nil
@@ -498,7 +362,7 @@ func SetupCodeVerificationServer(restConfig *rest.Config, code, completeDomain,
 		return deployment.Status.AvailableReplicas == deployment.Status.Replicas, nil
 	})
 	if err != nil {
-		return fmt.Errorf("timeout waiting for deployment: %w", err)
+		return fmt.Errorf("waiting for deployment: %w", err)
 	}
 
 	// Set CODE env var
@@ -541,7 +405,7 @@ func RemoveCodeVerificationServer(restConfig *rest.Config) error {
 
 	// Wait for namespace deletion
 	InfoMessage("Waiting for verification server namespace to be deleted")
-	err = wait.PollImmediate(5*time.Second, 300*time.Second, func() (bool, error) {
+	err = Poll(300*time.Second, func() (bool, error) {
 		_, err := client.CoreV1().Namespaces().Get(context.TODO(), "verification-server", v1.GetOptions{})
 		if errors.IsNotFound(err) {
 			return true, nil
@@ -549,7 +413,7 @@ func RemoveCodeVerificationServer(restConfig *rest.Config) error {
 		return false, nil
 	})
 	if err != nil {
-		return fmt.Errorf("timeout waiting for namespace deletion: %w", err)
+		return fmt.Errorf("waiting for namespace deletion: %w", err)
 	}
 
 	InfoMessage("Code verification server has been removed")
@@ -566,23 +430,28 @@ func UpsertDNSRecord(restConfig *rest.Config, apiURL, completeDomain, code, exte
 	maxRetries := 3
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		// Delete existing pod if exists
-		err = client.CoreV1().Pods("default").Delete(context.TODO(), "grpl-dns-route53-upsert", v1.DeleteOptions{})
+		err = client.CoreV1().Pods("default").Delete(context.TODO(), dnsUpsertPodName, v1.DeleteOptions{})
 		if err != nil && !errors.IsNotFound(err) {
 			return fmt.Errorf("failed to delete existing pod: %w", err)
 		}
 
 		// Create DNS update pod
+		podSecurityContext, containerSecurityContext := HelperSecurityContexts(client, "default")
 		pod := &corev1.Pod{
 			ObjectMeta: v1.ObjectMeta{
-				Name:      "grpl-dns-route53-upsert",
+				Name:      dnsUpsertPodName,
 				Namespace: "default",
+				Labels:    HelperLabels(helperDNSUpsert),
 			},
 			Spec: corev1.PodSpec{
-				RestartPolicy: corev1.RestartPolicyNever,
+				RestartPolicy:   corev1.RestartPolicyNever,
+				SecurityContext: podSecurityContext,
 				Containers: []corev1.Container{
 					{
-						Name:  "dns-upsert",
-						Image: "grpl/grpl-route53-upsert:latest",
+						Name:            "dns-upsert",
+						Image:           MirrorImage("grpl/grpl-route53-upsert:latest"),
+						SecurityContext: containerSecurityContext,
+						Resources:       HelperPodResources(),
 						Env: []corev1.EnvVar{
 							{Name: "HOSTED_ZONE_ID", Value: hostedZoneID},
 							{Name: "GRAPPLE_DNS", Value: "*." + completeDomain},
@@ -596,16 +465,18 @@ func UpsertDNSRecord(restConfig *rest.Config, apiURL, completeDomain, code, exte
 			},
 		}
 
+		ApplyCommonMetadata(&pod.ObjectMeta)
+
 		InfoMessage(fmt.Sprintf("Deploying grpl-dns-route53-upsert (Attempt %d/%d)", attempt, maxRetries))
 		_, err = client.CoreV1().Pods("default").Create(context.TODO(), pod, v1.CreateOptions{})
 		if err != nil {
-			return fmt.Errorf("failed to create DNS update pod: %w", err)
+			return fmt.Errorf("failed to create DNS update pod: %w", ExplainPodAdmissionError(client, "default", dnsUpsertPodName, err))
 		}
 
 		// Wait for pod completion
 		InfoMessage("Waiting for DNS update pod to complete")
 		err = wait.PollImmediate(2*time.Second, 90*time.Second, func() (bool, error) {
-			pod, err := client.CoreV1().Pods("default").Get(context.TODO(), "grpl-dns-route53-upsert", v1.GetOptions{})
+			pod, err := client.CoreV1().Pods("default").Get(context.TODO(), dnsUpsertPodName, v1.GetOptions{})
 			if err != nil {
 				return false, nil
 			}
@@ -636,26 +507,6 @@ func UpsertDNSRecord(restConfig *rest.Config, apiURL, completeDomain, code, exte
 	return nil // Should never reach here due to error return in last iteration
 }
 
-// Helper function to get APIResource for dynamic client
-func getAPIResource(discovery discovery.DiscoveryInterface, gvk schema.GroupVersionKind) (*schema.GroupVersionResource, error) {
-	resources, err := discovery.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
-	if err != nil {
-		return nil, err
-	}
-
-	for _, r := range resources.APIResources {
-		if r.Kind == gvk.Kind {
-			return &schema.GroupVersionResource{
-				Group:    gvk.Group,
-				Version:  gvk.Version,
-				Resource: r.Name,
-			}, nil
-		}
-	}
-
-	return nil, fmt.Errorf("resource not found for GroupVersionKind %v", gvk)
-}
-
 // GenerateRandomString generates a random 32 character hex string
 func GenerateRandomString() string {
 	bytes := make([]byte, 16) // 16 bytes = 32 hex characters
@@ -668,6 +519,14 @@ func GenerateRandomString() string {
 	return hex.EncodeToString(bytes)
 }
 
+// GrappleImages returns the grapi and gruim images of a version, from the mirror of --image-registry if set
+func GrappleImages(version string) []string {
+	return []string{
+		MirrorImage(fmt.Sprintf("grpl/grapi:%s", version)),
+		MirrorImage(fmt.Sprintf("grpl/gruim:%s", version)),
+	}
+}
+
 // PreloadGrappleImages downloads and caches Grapple images across all nodes in the cluster
 func PreloadGrappleImages(restConfig *rest.Config, version string) error {
 	// Create the clientset
@@ -676,16 +535,12 @@ func PreloadGrappleImages(restConfig *rest.Config, version string) error {
 		return fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	// Define the images to preload
-	images := []string{
-		fmt.Sprintf("grpl/grapi:%s", version),
-		fmt.Sprintf("grpl/gruim:%s", version),
-	}
+	podSecurityContext, containerSecurityContext := HelperSecurityContexts(clientset, "default")
 
 	// Create DaemonSets to pull images on all nodes
-	for _, image := range images {
+	for _, image := range GrappleImages(version) {
 		// Create a unique name for the DaemonSet by replacing invalid characters
-		dsName := fmt.Sprintf("image-preload-%s-%s",
+		dsName := fmt.Sprintf("%s%s-%s", imagePreloadNamePrefix,
 			strings.ReplaceAll(strings.Split(image, ":")[0], "/", "-"),
 			strings.ReplaceAll(strings.Split(image, ":")[1], ".", "-"))
 
@@ -704,7 +559,8 @@ func PreloadGrappleImages(restConfig *rest.Config, version string) error {
 		// Create the DaemonSet
 		ds := &appsv1.DaemonSet{
 			ObjectMeta: v1.ObjectMeta{
-				Name: dsName,
+				Name:   dsName,
+				Labels: HelperLabels(helperImagePreload),
 			},
 			Spec: appsv1.DaemonSetSpec{
 				Selector: &v1.LabelSelector{
@@ -719,10 +575,13 @@ func PreloadGrappleImages(restConfig *rest.Config, version string) error {
 						},
 					},
 					Spec: corev1.PodSpec{
+						SecurityContext: podSecurityContext,
 						Containers: []corev1.Container{
 							{
-								Name:  "preload",
-								Image: image,
+								Name:            "preload",
+								Image:           image,
+								SecurityContext: containerSecurityContext,
+								Resources:       HelperPodResources(),
 								Command: []string{
 									"sh",
 									"-c",
@@ -736,18 +595,30 @@ func PreloadGrappleImages(restConfig *rest.Config, version string) error {
 			},
 		}
 
+		// Only pull on the nodes the image is published for, pods on other nodes would never become ready
+		if architectures, err := ImageArchitectures(context.Background(), image); err == nil && len(architectures) > 0 {
+			ds.Spec.Template.Spec.Affinity = archNodeAffinity(architectures)
+		}
+
+		ApplyCommonMetadata(&ds.ObjectMeta)
+
 		// Create the DaemonSet
 		_, err = clientset.AppsV1().DaemonSets("default").Create(context.Background(), ds, v1.CreateOptions{})
 		if err != nil {
-			return fmt.Errorf("failed to create image preload DaemonSet for %s: %w", image, err)
+			return fmt.Errorf("failed to create image preload DaemonSet for %s: %w", image, ExplainPodAdmissionError(clientset, "default", dsName, err))
 		}
 
 		// Wait for DaemonSet to complete (all pods running or succeeded)
-		err = wait.PollImmediate(5*time.Second, time.Minute*10, func() (bool, error) {
+		err = Poll(time.Minute*10, func() (bool, error) {
 			ds, err := clientset.AppsV1().DaemonSets("default").Get(context.Background(), dsName, v1.GetOptions{})
 			if err != nil {
 				return false, err
 			}
+			if ds.Status.NumberReady == 0 {
+				if err := PodCreationFailure(clientset, "default", dsName); err != nil {
+					return false, err
+				}
+			}
 
 			// Check if all desired pods are ready
 			return ds.Status.DesiredNumberScheduled == ds.Status.NumberReady, nil
@@ -757,8 +628,7 @@ func PreloadGrappleImages(restConfig *rest.Config, version string) error {
 			return fmt.Errorf("error waiting for image preload DaemonSet %s: %w", dsName, err)
 		}
 
-		// Give pods a moment to finish their work
-		time.Sleep(10 * time.Second)
+		// The preload pods are ready once their image is pulled, the DaemonSet isn't needed anymore
 
 		// Clean up the DaemonSet
 		err = clientset.AppsV1().DaemonSets("default").Delete(context.Background(), dsName, v1.DeleteOptions{})
@@ -820,6 +690,8 @@ func IsSSLEnabled(restClient *rest.Config) (bool, error) {
 	return string(sslEnabled) == "true", nil
 }
 
+// GetClusterProviderType returns the provider recorded in the grsf-config of the cluster. Clusters that don't
+// record one (e.g. installed without the CLI) fall back to the cluster-provider setting.
 func GetClusterProviderType(clientset *kubernetes.Clientset) (string, error) {
 
 	// Try to get grsf-config secret
@@ -828,17 +700,30 @@ func GetClusterProviderType(clientset *kubernetes.Clientset) (string, error) {
 		return "", fmt.Errorf("failed to get secret: %w", err)
 	}
 
-	return string(secret.Data[SecKeyProviderClusterType]), nil
+	if provider := string(secret.Data[SecKeyProviderClusterType]); provider != "" {
+		return provider, nil
+	}
+	provider := ConfigValue("cluster-provider")
+	if provider == "" {
+		return "", nil
+	}
+	if err := ValidateClusterProvider(provider); err != nil {
+		return "", fmt.Errorf("%w: cluster-provider setting: %w", ErrValidation, err)
+	}
+	return strings.ToUpper(provider), nil
 }
 
 // GetClusterExternalIP finds the external IP of a LoadBalancer service whose name or namespace contains the ingressController string.
 func GetClusterExternalIP(restConfig *rest.Config, ingressController string) (string, error) {
 	maxWait := 300 * time.Second
-	interval := 5 * time.Second
+	poller := NewPoller()
 	deadline := time.Now().Add(maxWait)
 
 	InfoMessage(fmt.Sprintf("Waiting for the external IP of LoadBalancer service matching '%s'", ingressController))
 
+	progress := StartWaitProgress(fmt.Sprintf("external IP of LoadBalancer matching '%s'", ingressController), maxWait)
+	defer progress.Stop()
+
 	clientset, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return "", fmt.Errorf("failed to create kubernetes client: %w", err)
@@ -867,7 +752,7 @@ func GetClusterExternalIP(restConfig *rest.Config, ingressController string) (st
 							externalIP = svc.Status.LoadBalancer.Ingress[0].Hostname
 						}
 						if externalIP != "" {
-							InfoMessage(fmt.Sprintf("External IP for LoadBalancer '%s/%s': %s", svc.Namespace, svc.Name, externalIP))
+							progress.Done(fmt.Sprintf("External IP for LoadBalancer '%s/%s': %s", svc.Namespace, svc.Name, externalIP))
 							return externalIP, nil
 						}
 					}
@@ -880,7 +765,9 @@ func GetClusterExternalIP(restConfig *rest.Config, ingressController string) (st
 		}
 
 		fmt.Print(".")
-		time.Sleep(interval)
+		if err := poller.Wait(); err != nil {
+			return "", err
+		}
 	}
 
 	return "", fmt.Errorf("timeout: external IP not assigned for any LoadBalancer service matching '%s' within %v", ingressController, maxWait)
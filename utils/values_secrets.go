@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/kubernetes"
+)
+
+// valuesSecretKey is the key of a values secret holding a complete helm values document
+const valuesSecretKey = "values.yaml"
+
+var (
+	// ValuesSecret is the <namespace>/<name> of a Secret with sensitive helm values, set by --values-secret
+	ValuesSecret string
+	// ValuesSopsFile is a SOPS-encrypted values file, set by --values-sops
+	ValuesSopsFile string
+
+	// secretValues are the values loaded from ValuesSecret and ValuesSopsFile, they are only kept in memory
+	secretValues map[string]interface{}
+)
+
+// LoadSecretValues reads the values of --values-secret and --values-sops into memory. A values secret either has
+// a values.yaml key with a helm values document, or one key per config value (e.g. GRAPPLE_LICENSE), which is set
+// in the config section. The SOPS file is decrypted with the sops binary, its output is never written to disk.
+func LoadSecretValues(kubeClient apiv1.Interface) error {
+	secretValues = nil
+	if ValuesSecret != "" {
+		vals, err := readValuesSecret(kubeClient, ValuesSecret)
+		if err != nil {
+			return err
+		}
+		mergeValueMaps(vals)
+		InfoMessage(fmt.Sprintf("Loaded values from secret %s", ValuesSecret))
+	}
+	if ValuesSopsFile != "" {
+		vals, err := decryptSopsValues(ValuesSopsFile)
+		if err != nil {
+			return err
+		}
+		mergeValueMaps(vals)
+		InfoMessage(fmt.Sprintf("Loaded values from SOPS file %s", ValuesSopsFile))
+	}
+	return nil
+}
+
+func readValuesSecret(kubeClient apiv1.Interface, ref string) (map[string]interface{}, error) {
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok {
+		namespace, name = "grpl-system", ref
+	}
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), name, v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values secret %s/%s: %w", namespace, name, err)
+	}
+
+	vals := map[string]interface{}{}
+	if data, ok := secret.Data[valuesSecretKey]; ok {
+		if err := yaml.Unmarshal(data, &vals); err != nil {
+			return nil, fmt.Errorf("failed to parse %s of values secret %s/%s: %w", valuesSecretKey, namespace, name, err)
+		}
+		return vals, nil
+	}
+	config := map[string]interface{}{}
+	for key, value := range secret.Data {
+		config[key] = string(value)
+	}
+	vals["config"] = config
+	return vals, nil
+}
+
+func decryptSopsValues(path string) (map[string]interface{}, error) {
+	if _, err := exec.LookPath("sops"); err != nil {
+		return nil, fmt.Errorf("sops is required to decrypt %s, install it from https://github.com/getsops/sops", path)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sops", "--decrypt", "--output-type", "yaml", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %v: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	vals := map[string]interface{}{}
+	if err := yaml.Unmarshal(stdout.Bytes(), &vals); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted %s: %w", path, err)
+	}
+	return vals, nil
+}
+
+// mergeValueMaps merges src into the loaded secret values, later sources win
+func mergeValueMaps(src map[string]interface{}) {
+	if secretValues == nil {
+		secretValues = map[string]interface{}{}
+	}
+	MergeValues(secretValues, src)
+}
+
+// MergeValues merges the helm values src into dst, maps are merged key by key and other values replaced
+func MergeValues(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if srcMap, ok := value.(map[string]interface{}); ok {
+			if dstMap, ok := dst[key].(map[string]interface{}); ok {
+				MergeValues(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// AddSecretValuesToValues merges the values of --values-secret and --values-sops into helm values, they take
+// precedence over the values files
+func AddSecretValuesToValues(vals map[string]interface{}) {
+	if len(secretValues) == 0 {
+		return
+	}
+	MergeValues(vals, deepCopyValues(secretValues))
+}
+
+func deepCopyValues(src map[string]interface{}) map[string]interface{} {
+	dst := make(map[string]interface{}, len(src))
+	for key, value := range src {
+		if m, ok := value.(map[string]interface{}); ok {
+			value = deepCopyValues(m)
+		}
+		dst[key] = value
+	}
+	return dst
+}
+
+// StripSecretValues removes the values that are provided by a values secret from values that are written to a
+// values file, so a sensitive value never ends up on disk
+func StripSecretValues(vals map[string]interface{}) {
+	stripValues(vals, secretValues)
+}
+
+func stripValues(vals, secret map[string]interface{}) {
+	for key, value := range secret {
+		if secretMap, ok := value.(map[string]interface{}); ok {
+			if valsMap, ok := vals[key].(map[string]interface{}); ok {
+				stripValues(valsMap, secretMap)
+				continue
+			}
+		}
+		delete(vals, key)
+	}
+}
+
+// IsSecretValue reports whether the value at path (e.g. "config", "GRAPPLE_LICENSE") comes from a values secret
+func IsSecretValue(path ...string) bool {
+	current := secretValues
+	for i, key := range path {
+		value, ok := current[key]
+		if !ok {
+			return false
+		}
+		if i == len(path)-1 {
+			return true
+		}
+		if current, ok = value.(map[string]interface{}); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// maskSecretValue hides a value from a values secret in the log
+func maskSecretValue(value interface{}, path ...string) interface{} {
+	if IsSecretValue(path...) {
+		return "********"
+	}
+	return value
+}
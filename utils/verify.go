@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	apiv1 "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// VerifyOptions select the optional checks of VerifyGrapple
+type VerifyOptions struct {
+	// SkipCRUD skips creating, updating and deleting a sample GRAS
+	SkipCRUD bool
+	// CRUDNamespace is the namespace of the sample GRAS
+	CRUDNamespace string
+}
+
+// VerifyCheck is the result of a single verification check
+type VerifyCheck struct {
+	Category string `json:"category" yaml:"category"`
+	Name     string `json:"name" yaml:"name"`
+	Passed   bool   `json:"passed" yaml:"passed"`
+	Message  string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// VerifyReport is the result of verifying the Grapple installation of a cluster
+type VerifyReport struct {
+	Context   string        `json:"context" yaml:"context"`
+	CheckedAt time.Time     `json:"checkedAt" yaml:"checkedAt"`
+	Duration  string        `json:"duration" yaml:"duration"`
+	Checks    []VerifyCheck `json:"checks" yaml:"checks"`
+	Failed    int           `json:"failed" yaml:"failed"`
+	Passed    bool          `json:"passed" yaml:"passed"`
+}
+
+func (r *VerifyReport) add(category, name string, passed bool, message string) {
+	r.Checks = append(r.Checks, VerifyCheck{Category: category, Name: name, Passed: passed, Message: message})
+	if !passed {
+		r.Failed++
+	}
+}
+
+// VerifyGrapple runs the checks of the install waits once, without waiting, plus DNS, reachability and SSL of the
+// cluster domain and a sample GRAS round trip. Failed checks are part of the report, only errors talking to the
+// cluster are returned.
+func VerifyGrapple(kubeClient apiv1.Interface, restConfig *rest.Config, opts VerifyOptions) (*VerifyReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	report := &VerifyReport{CheckedAt: time.Now()}
+	if kubeContext, err := CurrentKubeContext(); err == nil {
+		report.Context = kubeContext
+	}
+
+	for _, release := range GrplReleases {
+		version, err := GetGrplReleaseVersion(restConfig, release, "grpl-system")
+		if err != nil {
+			return nil, err
+		}
+		if version == "" {
+			report.add("releases", release, false, "not installed")
+		} else {
+			report.add("releases", release, true, version)
+		}
+	}
+
+	component, _ := deploymentStatus(ctx, kubeClient, "grpl-system", "grsf-init-cert-manager")
+	report.add("components", "cert-manager", component.Ready, component.Message)
+
+	if err := verifyCRDs(ctx, dynamicClient, report); err != nil {
+		return nil, err
+	}
+	if err := verifyConditions(ctx, dynamicClient, compositeDefinitionGVR, "xrds", "Offered", report); err != nil {
+		return nil, err
+	}
+	for _, gvr := range []schema.GroupVersionResource{crossplaneProviderGVR, crossplaneConfigGVR} {
+		if err := verifyConditions(ctx, dynamicClient, gvr, "packages", "Healthy", report); err != nil {
+			return nil, err
+		}
+	}
+
+	verifyDomain(ctx, kubeClient, report)
+
+	if !opts.SkipCRUD {
+		namespace := opts.CRUDNamespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		if err := verifyGrasCRUD(ctx, dynamicClient, namespace); err != nil {
+			report.add("gras", "sample GRAS CRUD", false, err.Error())
+		} else {
+			report.add("gras", "sample GRAS CRUD", true, fmt.Sprintf("created, read, updated and deleted in %s", namespace))
+		}
+	}
+
+	report.Duration = FormatDuration(time.Since(report.CheckedAt))
+	report.Passed = report.Failed == 0
+	return report, nil
+}
+
+// verifyCRDs checks that the CRDs the install waits for are established
+func verifyCRDs(ctx context.Context, dynamicClient dynamic.Interface, report *VerifyReport) error {
+	crds, err := dynamicClient.Resource(crdGVR).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list CRDs: %w", err)
+	}
+	established := map[string]bool{}
+	for i := range crds.Items {
+		kind, _, _ := unstructured.NestedString(crds.Items[i].Object, "spec", "names", "kind")
+		if hasTrueCondition(&crds.Items[i], "Established") {
+			established[kind] = true
+		} else if _, ok := established[kind]; !ok {
+			established[kind] = false
+		}
+	}
+
+	for _, kind := range []string{"ClusterIssuer", "CompositeManagedApi", "CompositeManagedUIModule", "CompositeManagedDataSource"} {
+		ready, found := established[kind]
+		switch {
+		case !found:
+			report.add("crds", kind, false, "not found")
+		case !ready:
+			report.add("crds", kind, false, "not established")
+		default:
+			report.add("crds", kind, true, "established")
+		}
+	}
+	return nil
+}
+
+// verifyConditions checks that every object of gvr reports the given condition, an empty list is a failure
+func verifyConditions(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, category, conditionType string, report *VerifyReport) error {
+	list, err := dynamicClient.Resource(gvr).List(ctx, v1.ListOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			report.add(category, gvr.Resource, false, "resource type not served")
+			return nil
+		}
+		return fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+	if len(list.Items) == 0 {
+		report.add(category, gvr.Resource, false, "none found")
+		return nil
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].GetName() < list.Items[j].GetName() })
+	for i := range list.Items {
+		name := fmt.Sprintf("%s/%s", list.Items[i].GetKind(), list.Items[i].GetName())
+		if hasTrueCondition(&list.Items[i], conditionType) {
+			report.add(category, name, true, conditionType)
+		} else {
+			report.add(category, name, false, fmt.Sprintf("not %s", conditionType))
+		}
+	}
+	return nil
+}
+
+// verifyDomain checks that the cluster domain resolves and is served by the ingress, with a valid certificate if SSL is enabled
+func verifyDomain(ctx context.Context, kubeClient apiv1.Interface, report *VerifyReport) {
+	secret, err := kubeClient.CoreV1().Secrets("grpl-system").Get(ctx, "grsf-config", v1.GetOptions{})
+	if err != nil {
+		report.add("domain", "grsf-config", false, fmt.Sprintf("failed to read grsf-config: %v", err))
+		return
+	}
+	domain := string(secret.Data[SecKeyClusterdomain])
+	ssl := string(secret.Data[SecKeySsl]) == "true"
+	if domain == "" {
+		report.add("domain", "grsf-config", false, "no cluster domain configured")
+		return
+	}
+
+	if !IsResolvable(domain) {
+		report.add("domain", "dns", false, fmt.Sprintf("%s does not resolve", domain))
+		return
+	}
+	report.add("domain", "dns", true, fmt.Sprintf("%s resolves", domain))
+
+	online, message := checkOnline(domain, false)
+	report.add("domain", "ingress", online, message)
+	if ssl {
+		valid, message := checkOnline(domain, true)
+		report.add("domain", "ssl", valid, message)
+	}
+}
+
+// verifyGrasCRUD creates a GRAS without grapis or gruims, so nothing gets deployed, then reads, updates and deletes it
+func verifyGrasCRUD(ctx context.Context, dynamicClient dynamic.Interface, namespace string) error {
+	name := fmt.Sprintf("grpl-verify-%d", time.Now().Unix())
+	resource := dynamicClient.Resource(grasGVR).Namespace(namespace)
+	gras := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "grsf.grpl.io/v1alpha1",
+		"kind":       "GrappleApplicationSet",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    map[string]interface{}{"grpl.io/verify": "true"},
+		},
+		"spec": map[string]interface{}{
+			"name": name,
+		},
+	}}
+
+	if _, err := resource.Create(ctx, gras, v1.CreateOptions{}); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	// Always clean up, even if a later step fails
+	defer resource.Delete(context.Background(), name, v1.DeleteOptions{})
+
+	created, err := resource.Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	labels := created.GetLabels()
+	labels["grpl.io/verify-updated"] = "true"
+	created.SetLabels(labels)
+	if _, err := resource.Update(ctx, created, v1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+	if err := resource.Delete(ctx, name, v1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	deadline := time.Now().Add(time.Minute)
+	for time.Now().Before(deadline) {
+		if _, err := resource.Get(ctx, name, v1.GetOptions{}); errors.IsNotFound(err) {
+			return nil
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return fmt.Errorf("delete: %s still exists after a minute", name)
+}
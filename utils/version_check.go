@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	versionCheckInterval = 7 * 24 * time.Hour
+	versionCheckTimeout  = 5 * time.Second
+	// versionCheckExitWait is how long a finished command waits for a running version check before it exits
+	versionCheckExitWait = 2 * time.Second
+	cliReleasesAPIURL    = "https://api.github.com/repos/grapple-solution/grapple-go-cli/releases/latest"
+	cliReleasesURL       = "https://github.com/grapple-solution/grapple-go-cli/releases"
+)
+
+// versionCheckResult is cached in ~/.cache/grpl/version-check.json between checks
+type versionCheckResult struct {
+	CheckedAt     time.Time `json:"checkedAt"`
+	LatestCLI     string    `json:"latestCli,omitempty"`
+	LatestGrapple string    `json:"latestGrapple,omitempty"`
+}
+
+// versionCheckDone is closed when the background version check of the command has written its result
+var versionCheckDone chan struct{}
+
+// VersionCheckEnabled reports whether the weekly version check runs, it is disabled with
+// 'grapple config set update-check false' or GRPL_UPDATE_CHECK=false
+func VersionCheckEnabled() bool {
+	switch strings.ToLower(ConfigValue("update-check")) {
+	case "false", "0", "no", "off":
+		return false
+	}
+	return !OfflineMode
+}
+
+// CheckForUpdates prints a hint when the last check found a newer CLI or Grapple version, and refreshes the
+// cached result in the background when it is older than a week. It never waits for the network, see
+// WaitForVersionCheck.
+func CheckForUpdates() {
+	if !VersionCheckEnabled() {
+		return
+	}
+
+	path, err := versionCheckPath()
+	if err != nil {
+		return
+	}
+	cached := &versionCheckResult{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, cached)
+	}
+
+	printUpdateHints(cached)
+
+	if time.Since(cached.CheckedAt) < versionCheckInterval {
+		return
+	}
+	// Only the finished check is recorded, a failing one (e.g. offline) is recorded as well so it is not retried
+	// on every command, a check cut short by the exit of the command is retried by the next one
+	versionCheckDone = make(chan struct{})
+	go func() {
+		defer close(versionCheckDone)
+		result := &versionCheckResult{CheckedAt: time.Now(), LatestCLI: cached.LatestCLI, LatestGrapple: cached.LatestGrapple}
+		if version, err := latestCLIVersion(); err == nil {
+			result.LatestCLI = version
+		}
+		if version, err := latestGrappleVersion(); err == nil {
+			result.LatestGrapple = version
+		}
+		writeVersionCheck(path, result)
+	}()
+}
+
+// WaitForVersionCheck gives a version check started by CheckForUpdates a moment to finish before the CLI exits
+func WaitForVersionCheck() {
+	if versionCheckDone == nil {
+		return
+	}
+	select {
+	case <-versionCheckDone:
+	case <-time.After(versionCheckExitWait):
+		DebugMessage("The version check did not finish, it is retried by the next command")
+	}
+}
+
+func printUpdateHints(cached *versionCheckResult) {
+	if isNewerVersion(cached.LatestCLI, GetGrappleCliVersion()) {
+		InfoMessage(fmt.Sprintf("Grapple CLI %s is available (installed: %s), changelog: %s/tag/v%s, upgrade with 'brew upgrade grapple-go-cli' or the install script",
+			cached.LatestCLI, GetGrappleCliVersion(), cliReleasesURL, strings.TrimPrefix(cached.LatestCLI, "v")))
+	}
+	if isNewerVersion(cached.LatestGrapple, DefaultGrappleVersion) {
+		InfoMessage(fmt.Sprintf("Grapple %s is available (this CLI installs %s by default), upgrade clusters with 'grapple upgrade --grapple-version=%s', changelog: %s",
+			cached.LatestGrapple, DefaultGrappleVersion, cached.LatestGrapple, cliReleasesURL))
+	}
+}
+
+// isNewerVersion reports whether latest is a higher semantic version than current, unparsable versions are never newer
+func isNewerVersion(latest, current string) bool {
+	if latest == "" {
+		return false
+	}
+	latestVersion, err := semver.NewVersion(latest)
+	if err != nil {
+		return false
+	}
+	currentVersion, err := semver.NewVersion(current)
+	if err != nil {
+		return false
+	}
+	return latestVersion.GreaterThan(currentVersion)
+}
+
+func latestCLIVersion() (string, error) {
+	client := &http.Client{Timeout: versionCheckTimeout}
+	resp, err := client.Get(cliReleasesAPIURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(release.TagName, "v"), nil
+}
+
+// latestGrappleVersion returns the highest stable version of the grsf chart in the chart registry
+func latestGrappleVersion() (string, error) {
+	client, err := NewChartRegistryClient()
+	if err != nil {
+		return "", err
+	}
+	tags, err := client.Tags(strings.TrimPrefix(GrplChartRef("grsf"), "oci://"))
+	if err != nil {
+		return "", err
+	}
+
+	var latest *semver.Version
+	for _, tag := range tags {
+		version, err := semver.NewVersion(tag)
+		if err != nil || version.Prerelease() != "" {
+			continue
+		}
+		if latest == nil || version.GreaterThan(latest) {
+			latest = version
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("no grsf versions found")
+	}
+	return latest.Original(), nil
+}
+
+func versionCheckPath() (string, error) {
+	dir, err := cacheHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "grpl", "version-check.json"), nil
+}
+
+func writeVersionCheck(path string, result *versionCheckResult) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0644)
+}
+
+// CheckCLIUpdate asks GitHub for the latest CLI release and returns it with a hint how to upgrade, the hint is
+// empty when the installed CLI is up to date
+func CheckCLIUpdate() (latest, hint string, err error) {
+	latest, err = latestCLIVersion()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check for a newer CLI: %w", err)
+	}
+	if isNewerVersion(latest, GetGrappleCliVersion()) {
+		hint = fmt.Sprintf("Grapple CLI %s is available (installed: %s), upgrade with 'brew upgrade grapple-go-cli' or download it from %s/tag/v%s",
+			latest, GetGrappleCliVersion(), cliReleasesURL, strings.TrimPrefix(latest, "v"))
+	}
+	return latest, hint, nil
+}